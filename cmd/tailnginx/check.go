@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/tailer"
+)
+
+// Exit codes of the check subcommand, as expected by Nagios plugins
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	checkUnknown  = 3
+)
+
+// checkStates are the names of the exit codes of the check subcommand.
+var checkStates = [...]string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// runCheck runs the check subcommand: it reads the requests of the last
+// window of an access log, prints a one-line summary and exits with a
+// status a monitoring system or a cron job understands, e.g.
+//
+//	tailnginx check -log /var/log/nginx/access.log -window 5m -warn-5xx-rate 0.5% -max-5xx-rate 1%
+func runCheck(args []string) {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	path := fs.String("log", "", "nginx access log to check")
+	logFormat := fs.String("log-format", "", "format of the log: a preset (combined, common, vcombined, json, caddy) or an nginx log_format definition (default: combined)")
+	window := fs.Duration("window", 5*time.Minute, "check the requests of the last duration")
+	maxRate := fs.String("max-5xx-rate", "", "critical above this share of 5xx responses, e.g. 1%")
+	warnRate := fs.String("warn-5xx-rate", "", "warning above this share of 5xx responses, e.g. 0.5%")
+	minRequests := fs.Int("min-requests", 0, "only check the 5xx rate with at least this many requests")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: tailnginx check -log FILE [-window DURATION] [-warn-5xx-rate RATE] [-max-5xx-rate RATE]\n\n")
+		fmt.Fprintf(out, "Reads the requests of the last window of the log, newest first, and prints\n")
+		fmt.Fprintf(out, "a one-line summary with performance data. Exits with 0 (OK), 1 (WARNING),\n")
+		fmt.Fprintf(out, "2 (CRITICAL) or 3 (UNKNOWN, e.g. an unreadable log), like a Nagios plugin.\n")
+		fmt.Fprintf(out, "Rates are percentages, with or without the %% sign.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(checkUnknown)
+	}
+	if *path == "" || fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(checkUnknown)
+	}
+	unknown := func(format string, args ...any) {
+		fmt.Printf("UNKNOWN - "+format+"\n", args...)
+		os.Exit(checkUnknown)
+	}
+	if *window <= 0 {
+		unknown("-window must be positive")
+	}
+	critical, err := parseRate(*maxRate)
+	if err != nil {
+		unknown("-max-5xx-rate: %v", err)
+	}
+	warning, err := parseRate(*warnRate)
+	if err != nil {
+		unknown("-warn-5xx-rate: %v", err)
+	}
+	parse := parser.Parse
+	format, err := parser.NewFormats([]string{*logFormat})
+	if err != nil {
+		unknown("-log-format: %v", err)
+	}
+	if format != nil {
+		parse = format.Parse
+	}
+
+	since := time.Now().Add(-*window)
+	var requests, errors5xx int
+	err = tailer.ReadBackwards(*path, func(line string) bool {
+		v := parse(line)
+		if v == nil || v.Time.IsZero() {
+			return true
+		}
+		if v.Time.Before(since) {
+			return false
+		}
+		requests++
+		if v.Status >= 500 && v.Status < 600 {
+			errors5xx++
+		}
+		return true
+	})
+	if err != nil {
+		unknown("%v", err)
+	}
+
+	var rate float64
+	if requests > 0 {
+		rate = 100 * float64(errors5xx) / float64(requests)
+	}
+	state := checkOK
+	if requests >= *minRequests {
+		switch {
+		case critical >= 0 && rate > critical:
+			state = checkCritical
+		case warning >= 0 && rate > warning:
+			state = checkWarning
+		}
+	}
+	fmt.Printf("%s - 5xx rate %.2f%% (%d of %d requests in the last %s) | requests=%d 5xx=%d 5xx_rate=%.2f%%;%s;%s\n",
+		checkStates[state], rate, errors5xx, requests, *window,
+		requests, errors5xx, rate, formatRate(warning), formatRate(critical))
+	os.Exit(state)
+}
+
+// parseRate parses a percentage such as "1%" or "0.5", or returns -1 for
+// an empty string.
+func parseRate(s string) (float64, error) {
+	if s == "" {
+		return -1, nil
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil || rate < 0 || rate > 100 {
+		return 0, errors.New("expected a percentage between 0 and 100, e.g. 1%")
+	}
+	return rate, nil
+}
+
+// formatRate formats a threshold for performance data, empty when unset.
+func formatRate(rate float64) string {
+	if rate < 0 {
+		return ""
+	}
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
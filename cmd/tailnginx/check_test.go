@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logLine returns a line of the combined format logged at t.
+func logLine(t time.Time, status int) string {
+	return fmt.Sprintf("203.0.113.7 - - [%s] \"GET / HTTP/1.1\" %d 512 \"-\" \"curl/8.0\"\n", t.Format("02/Jan/2006:15:04:05 -0700"), status)
+}
+
+// exitCode returns the exit status of a command run with err.
+func exitCode(t *testing.T, err error) int {
+	t.Helper()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatal(err)
+	}
+	if exit != nil {
+		return exit.ExitCode()
+	}
+	return 0
+}
+
+// TestRunCheck tests the exit status and summary of the check subcommand,
+// over a log with 2 5xx responses out of 20 requests in the last minutes.
+func TestRunCheck(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "access.log")
+	now := time.Now()
+	var lines strings.Builder
+	lines.WriteString(logLine(now.Add(-time.Hour), 500))
+	for i := 0; i < 20; i++ {
+		status := 200
+		if i < 2 {
+			status = 502
+		}
+		lines.WriteString(logLine(now.Add(-time.Minute), status))
+	}
+	writeFile(t, logFile, lines.String())
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+		out  string
+	}{
+		{"ok", []string{"-warn-5xx-rate", "20%"}, checkOK, "OK - 5xx rate 10.00% (2 of 20 requests in the last 5m0s)"},
+		{"warning", []string{"-warn-5xx-rate", "5%", "-max-5xx-rate", "20%"}, checkWarning, "WARNING - 5xx rate 10.00%"},
+		{"critical", []string{"-warn-5xx-rate", "1%", "-max-5xx-rate", "5"}, checkCritical, "| requests=20 5xx=2 5xx_rate=10.00%;1;5"},
+		{"too few requests", []string{"-max-5xx-rate", "5%", "-min-requests", "50"}, checkOK, "OK - "},
+		{"older window", []string{"-window", "2h", "-max-5xx-rate", "5%"}, checkCritical, "3 of 21 requests"},
+		{"invalid rate", []string{"-max-5xx-rate", "150%"}, checkUnknown, "UNKNOWN - -max-5xx-rate"},
+		{"invalid window", []string{"-window", "0s"}, checkUnknown, "UNKNOWN - -window must be positive"},
+	}
+	for _, tt := range tests {
+		out, err := command(append([]string{"check", "-log", logFile}, tt.args...)...).Output()
+		if got := exitCode(t, err); got != tt.want || !strings.Contains(string(out), tt.out) {
+			t.Errorf("%s: check exited with %d and printed %q, want %d and %q", tt.name, got, out, tt.want, tt.out)
+		}
+	}
+
+	// A missing log or option is unknown
+	if err := command("check", "-log", filepath.Join(t.TempDir(), "none.log")).Run(); exitCode(t, err) != checkUnknown {
+		t.Errorf("check of a missing log exited with %v, want %d", err, checkUnknown)
+	}
+	if err := command("check").Run(); exitCode(t, err) != checkUnknown {
+		t.Errorf("check without -log exited with %v, want %d", err, checkUnknown)
+	}
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/internal/config"
+	"github.com/papaganelli/tailnginx/pkg/abuse"
+	"github.com/papaganelli/tailnginx/pkg/alert"
+	"github.com/papaganelli/tailnginx/pkg/detector"
+	"github.com/papaganelli/tailnginx/pkg/exclude"
+	"github.com/papaganelli/tailnginx/pkg/feed"
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+	"github.com/papaganelli/tailnginx/pkg/hotlink"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/ratelimit"
+	"github.com/papaganelli/tailnginx/pkg/reputation"
+	"github.com/papaganelli/tailnginx/pkg/sensitive"
+	"github.com/papaganelli/tailnginx/pkg/stats"
+	"github.com/papaganelli/tailnginx/pkg/watchlist"
+	"github.com/papaganelli/tailnginx/ui"
+)
+
+// formatSampleLines is the number of lines of each log the -log-format is
+// tried on.
+const formatSampleLines = 20
+
+// configCheck collects the problems found by the check-config subcommand.
+type configCheck struct {
+	flags    *flag.FlagSet
+	errors   []string
+	warnings []string
+}
+
+// runCheckConfig runs the check-config subcommand on the options parsed from
+// the command line: it loads the configuration file like the dashboard would
+// and validates every option without opening the logs for tailing, listening
+// or connecting to anything. The problems are printed to w, each with the
+// option it is about. It returns the exit status: 0 when the configuration
+// is valid, warnings included, 1 otherwise.
+func runCheckConfig(w io.Writer, flags *flag.FlagSet, path, profile string) int {
+	c := &configCheck{flags: flags}
+	c.checkFile(w, path, profile)
+	if len(c.errors) == 0 {
+		c.checkLogs(w)
+		c.checkRules()
+		c.checkDisplay()
+		c.checkOutputs()
+	}
+
+	for _, msg := range c.warnings {
+		fmt.Fprintf(w, "Warning: %s\n", msg)
+	}
+	for _, msg := range c.errors {
+		fmt.Fprintf(w, "Error: %s\n", msg)
+	}
+	if len(c.errors) > 0 {
+		fmt.Fprintf(w, "Configuration is invalid: %d error(s)\n", len(c.errors))
+		return 1
+	}
+	fmt.Fprintln(w, "Configuration is valid")
+	return 0
+}
+
+// fail records an error of an option, if any.
+func (c *configCheck) fail(option string, err error) {
+	if err != nil {
+		c.errors = append(c.errors, fmt.Sprintf("-%s: %v", option, err))
+	}
+}
+
+// warn records a warning.
+func (c *configCheck) warn(format string, args ...any) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+// value returns the value of an option.
+func value[T any](c *configCheck, name string) T {
+	return optionValue[T](c.flags, name)
+}
+
+// checkFile loads the configuration file and reports which one is used.
+func (c *configCheck) checkFile(w io.Writer, path, profile string) {
+	shown := path
+	if shown == "" {
+		if def, err := config.DefaultFile(); err == nil {
+			if _, err := os.Stat(def); err == nil {
+				shown = def
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				c.fail("config", err)
+			}
+		}
+	}
+	switch {
+	case shown == "":
+		fmt.Fprintln(w, "Configuration file: none, command line options only")
+	case profile != "":
+		fmt.Fprintf(w, "Configuration file: %s, profile %s\n", shown, profile)
+	default:
+		fmt.Fprintf(w, "Configuration file: %s\n", shown)
+	}
+	c.fail("config", loadConfigFile(c.flags, path, profile))
+}
+
+// checkLogs checks that the access logs can be read and that the log format
+// parses them.
+func (c *configCheck) checkLogs(w io.Writer) {
+	paths := value[[]string](c, "log")
+	if len(paths) == 0 {
+		logs, err := detector.DetectLogFiles()
+		if err != nil {
+			c.fail("log", errors.New("no nginx access log found in the usual locations, give one with -log"))
+		} else {
+			best := detector.GetBestLogFile(logs)
+			fmt.Fprintf(w, "Access log: %s (auto-detected)\n", best.Path)
+			paths = []string{best.Path}
+		}
+	}
+
+	format, err := parser.NewFormats(value[[]string](c, "log-format"))
+	c.fail("log-format", err)
+
+	for _, path := range paths {
+		if err := validateLogPath(path); err != nil {
+			c.fail("log", err)
+			continue
+		}
+		sample, readErr := readSample(path, formatSampleLines)
+		if readErr != nil {
+			c.fail("log", readErr)
+			continue
+		}
+		if len(sample) == 0 || err != nil {
+			continue // Nothing to try the format on
+		}
+		parse := parser.Parse
+		if format != nil {
+			parse = format.Parse
+		}
+		parsed := 0
+		for _, line := range sample {
+			if parse(line) != nil {
+				parsed++
+			}
+		}
+		switch {
+		case parsed == 0 && format == nil:
+			c.fail("log-format", fmt.Errorf("none of the first %d lines of %s are in the combined format, give the format of the log, a preset such as json or caddy or its log_format, e.g. %q", len(sample), path, sample[0]))
+		case parsed == 0:
+			c.fail("log-format", fmt.Errorf("none of the first %d lines of %s match, e.g. %q", len(sample), path, sample[0]))
+		case parsed < len(sample):
+			c.warn("-log-format: %d of the first %d lines of %s do not match and will be skipped", len(sample)-parsed, len(sample), path)
+		}
+	}
+
+	if errorLog := value[string](c, "error-log"); errorLog != "" {
+		if _, err := readSample(errorLog, 1); err != nil {
+			c.fail("error-log", err)
+		}
+	}
+}
+
+// readSample returns the first non-empty lines of a file.
+func readSample(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory, not a file", path)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for len(lines) < n && scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// checkRules checks the expressions of the highlight, filter, exclude, watch
+// and alert rules.
+func (c *configCheck) checkRules() {
+	_, err := highlight.ParseAll(value[[]string](c, "highlight"))
+	c.fail("highlight", err)
+	for _, text := range value[[]string](c, "filter") {
+		_, err := highlight.ParseCondition(text)
+		c.fail("filter", err)
+	}
+	_, err = exclude.New(value[[]string](c, "exclude-path"), value[[]string](c, "exclude-ip"), value[[]string](c, "exclude-agent"))
+	c.fail("exclude-path, -exclude-ip, -exclude-agent", err)
+	_, err = exclude.ParseNetworks(value[[]string](c, "ignore-cidr"))
+	c.fail("ignore-cidr", err)
+	_, err = watchlist.New(value[[]string](c, "watch"))
+	c.fail("watch", err)
+	_, err = alert.ParseRules(value[[]string](c, "alert"))
+	c.fail("alert", err)
+	for _, path := range value[[]string](c, "login-path") {
+		c.fail("login-path", checkLoginPath(path))
+	}
+	_, err = sensitive.New(value[[]string](c, "sensitive-path"))
+	c.fail("sensitive-path", err)
+	_, err = hotlink.NewDetector(value[[]string](c, "site-domain"))
+	c.fail("site-domain", err)
+	if value[int](c, "login-failures") <= 0 {
+		c.fail("login-failures", errors.New("must be positive"))
+	}
+	if value[time.Duration](c, "login-window") <= 0 {
+		c.fail("login-window", errors.New("must be positive"))
+	}
+	_, err = abuse.NewDetector(abuse.Thresholds{
+		Requests: value[int](c, "ban-requests"),
+		Errors:   value[int](c, "ban-errors"),
+		Scan:     value[int](c, "ban-scan"),
+		Window:   value[time.Duration](c, "ban-window"),
+	})
+	c.fail("ban-requests, -ban-errors, -ban-scan, -ban-window", err)
+	_, err = ratelimit.NewTracker(value[float64](c, "ratelimit-rps"), value[time.Duration](c, "ratelimit-window"))
+	c.fail("ratelimit-rps, -ratelimit-window", err)
+}
+
+// checkDisplay checks the options of the dashboard and of the time range.
+func (c *configCheck) checkDisplay() {
+	refresh := time.Duration(value[int](c, "refresh")) * time.Millisecond
+	if refresh < config.MinRefreshRate || refresh > config.MaxRefreshRate {
+		c.warn("-refresh: %dms is out of range and will be clamped to %s-%s", value[int](c, "refresh"), config.MinRefreshRate, config.MaxRefreshRate)
+	}
+	if value[int](c, "top") < 0 {
+		c.fail("top", errors.New("must be 0 or more"))
+	}
+	if value[time.Duration](c, "interval") <= 0 {
+		c.fail("interval", errors.New("must be positive"))
+	}
+	if value[int](c, "max-entries") <= 0 {
+		c.fail("max-entries", errors.New("must be positive"))
+	}
+	if value[int](c, "max-memory-mb") < 0 {
+		c.fail("max-memory-mb", errors.New("must be 0 or more"))
+	}
+
+	// The dashboard checks its own options
+	app := ui.NewTviewApp(make(chan string), "", time.Second, nil)
+	var columns []string
+	for _, column := range strings.Split(value[string](c, "stream-columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	c.fail("stream-columns", app.SetStreamColumns(columns))
+	c.fail("key", app.SetKeys(value[[]string](c, "key")))
+	c.fail("filter-status, -filter-path, -filter-ip", app.SetFilters(ui.Filters{
+		Status: value[string](c, "filter-status"),
+		Path:   value[string](c, "filter-path"),
+		IP:     value[string](c, "filter-ip"),
+	}))
+	if mini := value[string](c, "mini"); mini != "" {
+		c.fail("mini", app.SetMini(mini))
+	}
+
+	if zone := value[string](c, "timezone"); zone != "" {
+		_, err := time.LoadLocation(zone)
+		c.fail("timezone", err)
+	}
+	if strings.TrimSpace(value[string](c, "time-format")) == "" {
+		c.fail("time-format", errors.New("must not be empty"))
+	}
+	now := time.Now()
+	since, err := parseTimeBound(value[string](c, "since"), now)
+	c.fail("since", err)
+	until, err := parseTimeBound(value[string](c, "until"), now)
+	c.fail("until", err)
+	if !since.IsZero() && !until.IsZero() && !until.After(since) {
+		c.fail("until", errors.New("must be after -since"))
+	}
+}
+
+// checkOutputs checks the addresses, URLs and settings of the servers,
+// exporters and notifiers, without connecting to them.
+func (c *configCheck) checkOutputs() {
+	output := value[string](c, "output")
+	if output != "" && output != "jsonl" {
+		c.fail("output", fmt.Errorf("must be 'jsonl', got %q", output))
+	}
+	headless := value[bool](c, "headless")
+	if output != "" && headless {
+		c.fail("output", errors.New("cannot be combined with -headless"))
+	}
+
+	for _, name := range []string{"metrics-listen", "listen", "grpc-listen", "debug-listen", "statsd", "graphite"} {
+		if addr := value[string](c, name); addr != "" {
+			c.fail(name, checkAddr(addr))
+		}
+	}
+	if value[time.Duration](c, "flush-interval") <= 0 {
+		c.fail("flush-interval", errors.New("must be positive"))
+	}
+	if influx := value[string](c, "influx"); strings.HasPrefix(influx, "http://") || strings.HasPrefix(influx, "https://") {
+		if u, err := url.Parse(influx); err != nil || u.Host == "" {
+			c.fail("influx", fmt.Errorf("invalid URL %q", influx))
+		} else if os.Getenv("INFLUX_TOKEN") == "" {
+			c.warn("-influx: $INFLUX_TOKEN is not set, writes are sent without a token")
+		}
+	} else if influx != "" {
+		c.fail("influx", checkDir(filepath.Dir(influx)))
+	}
+	if brokers := value[string](c, "kafka"); brokers != "" {
+		_, err := feed.NewKafka(brokers, value[string](c, "kafka-topic"))
+		c.fail("kafka", err)
+		for _, addr := range strings.Split(brokers, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				c.fail("kafka", checkAddr(addr))
+			}
+		}
+	}
+	if loki := value[string](c, "loki"); loki != "" {
+		_, err := feed.NewLoki(loki, value[[]string](c, "loki-label"))
+		c.fail("loki", err)
+	}
+	if mqtt := value[string](c, "mqtt"); mqtt != "" {
+		_, err := stats.NewMQTT(mqtt, value[string](c, "mqtt-topic"), value[string](c, "mqtt-user"), "")
+		c.fail("mqtt", err)
+		if value[time.Duration](c, "mqtt-interval") <= 0 {
+			c.fail("mqtt-interval", errors.New("must be positive"))
+		}
+	}
+	if pushTo := value[string](c, "push-to"); pushTo != "" {
+		_, err := stats.NewPush(pushTo, os.Getenv("TAILNGINX_PUSH_TOKEN"), "check")
+		if err != nil && os.Getenv("TAILNGINX_PUSH_TOKEN") == "" {
+			err = errors.New("$TAILNGINX_PUSH_TOKEN must hold the token of the central instance")
+		}
+		c.fail("push-to", err)
+		if value[time.Duration](c, "push-interval") <= 0 {
+			c.fail("push-interval", errors.New("must be positive"))
+		}
+	}
+	if reportDir := value[string](c, "report-dir"); reportDir != "" {
+		if value[time.Duration](c, "report-interval") <= 0 {
+			c.fail("report-interval", errors.New("must be positive"))
+		}
+		if _, err := os.Stat(reportDir); err == nil {
+			c.fail("report-dir", checkDir(reportDir))
+		} else {
+			c.fail("report-dir", checkDir(filepath.Dir(reportDir)))
+		}
+	}
+
+	c.checkNotifiers(headless)
+
+	c.fail("deny-format", abuse.CheckFormat(value[string](c, "deny-format")))
+	if value[string](c, "nginx-pid") != "" && value[string](c, "deny-file") == "" {
+		c.fail("nginx-pid", errors.New("needs -deny-file"))
+	}
+	for _, path := range value[[]string](c, "blocklist") {
+		_, err := reputation.LoadBlocklist(path)
+		c.fail("blocklist", err)
+	}
+	for _, name := range []string{"deny-file", "ban-file", "ratelimit-file", "alert-log", "store", "dump", "checkpoint"} {
+		if path := value[string](c, name); path != "" {
+			c.fail(name, checkDir(filepath.Dir(path)))
+		}
+	}
+}
+
+// checkNotifiers checks the alert and summary destinations.
+func (c *configCheck) checkNotifiers(headless bool) {
+	if webhooks := value[[]string](c, "webhook"); len(webhooks) > 0 {
+		tmpl := ""
+		if path := value[string](c, "webhook-template"); path != "" {
+			b, err := os.ReadFile(path)
+			c.fail("webhook-template", err)
+			tmpl = string(b)
+		}
+		_, err := alert.NewWebhook(webhooks, tmpl)
+		c.fail("webhook", err)
+	}
+	slack, routes := value[string](c, "slack"), value[[]string](c, "slack-route")
+	if slack != "" || len(routes) > 0 {
+		_, err := alert.NewSlack(slack, routes)
+		c.fail("slack", err)
+	}
+	if value[time.Duration](c, "slack-summary") > 0 && slack == "" {
+		c.fail("slack-summary", errors.New("needs -slack"))
+	}
+	if discord := value[string](c, "discord"); discord != "" {
+		_, err := alert.NewDiscord(discord)
+		c.fail("discord", err)
+	} else if value[time.Duration](c, "discord-summary") > 0 {
+		c.fail("discord-summary", errors.New("needs -discord"))
+	}
+	if smtp := value[string](c, "smtp"); smtp != "" {
+		var to []string
+		for _, addr := range strings.Split(value[string](c, "smtp-to"), ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				to = append(to, addr)
+			}
+		}
+		_, err := alert.NewEmail(alert.SMTP{
+			Addr: smtp,
+			From: value[string](c, "smtp-from"),
+			To:   to,
+		})
+		c.fail("smtp", err)
+	} else if value[bool](c, "smtp-digest") {
+		c.fail("smtp-digest", errors.New("needs -smtp"))
+	}
+	if desktop := value[string](c, "desktop-notify"); desktop != "" {
+		_, err := alert.NewDesktop(desktop, io.Discard)
+		c.fail("desktop-notify", err)
+	}
+
+	// The Hotlinks and Security panels are only shown by the dashboard, and
+	// OSC notifications and the alarm go through its terminal
+	if headless {
+		for _, name := range []string{"site-domain", "deny-file", "abuseipdb-key", "blocklist"} {
+			if c.flags.Lookup(name).Value.String() != "" {
+				c.warn("-%s has no effect with -headless", name)
+			}
+		}
+		if value[string](c, "desktop-notify") == alert.DesktopOSC {
+			c.warn("-desktop-notify osc has no effect with -headless")
+		}
+		if value[bool](c, "alarm") {
+			c.warn("-alarm has no effect with -headless")
+		}
+	}
+}
+
+// checkAddr checks a host:port address.
+func checkAddr(addr string) error {
+	if _, port, err := net.SplitHostPort(addr); err != nil {
+		return err
+	} else if port == "" {
+		return fmt.Errorf("address %s: missing port", addr)
+	}
+	return nil
+}
+
+// checkDir checks that a directory exists, for the files written to it.
+func checkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRunCheckConfig tests the exit status and report of the check-config
+// subcommand.
+func TestRunCheckConfig(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "access.log")
+	configFile := filepath.Join(dir, "config.yaml")
+	writeFile(t, logFile, "")
+
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		want   int
+		out    string
+	}{
+		{"valid", "alert: rps > 100\ntop: 5\n", nil, 0, "Configuration is valid"},
+		{"invalid alert", "alert: rps >\n", nil, 1, "Error: -alert: "},
+		{"invalid option", "top: many\n", nil, 1, "Configuration is invalid"},
+		{"unknown profile", "top: 5\n", []string{"-profile", "none"}, 1, "Configuration is invalid"},
+	}
+	for _, tt := range tests {
+		writeFile(t, configFile, tt.config)
+		args := append([]string{"check-config", "-config", configFile, "-log", logFile}, tt.args...)
+		out, err := command(args...).Output()
+		if got := exitCode(t, err); got != tt.want || !strings.Contains(string(out), tt.out) {
+			t.Errorf("%s: check-config exited with %d and printed %q, want %d and %q", tt.name, got, out, tt.want, tt.out)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+
+	"github.com/papaganelli/tailnginx/internal/config"
+)
+
+// loadConfigFile sets the options of a configuration file, and of one of its
+// profiles when profile is not empty, that were not given on the command
+// line. Without a path, the default file is loaded if it exists.
+func loadConfigFile(flags *flag.FlagSet, path, profile string) error {
+	explicit := path != ""
+	if !explicit {
+		var err error
+		if path, err = config.DefaultFile(); err != nil {
+			if profile != "" {
+				return fmt.Errorf("-profile %s: %w", profile, err)
+			}
+			return nil
+		}
+	}
+	file, err := config.LoadFile(path)
+	if err != nil {
+		if !explicit && profile == "" && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	options := file.Options
+	if profile != "" {
+		if options, err = file.WithProfile(profile); err != nil {
+			return err
+		}
+	}
+
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	for _, option := range options {
+		if option.Name == "config" || option.Name == "profile" || flags.Lookup(option.Name) == nil {
+			return fmt.Errorf("%s:%d: unknown option %s", file.Path, option.Line, option.Name)
+		}
+		if given[option.Name] {
+			continue // The command line wins, lists included
+		}
+		for _, value := range option.Values {
+			if err := flags.Set(option.Name, value); err != nil {
+				return fmt.Errorf("%s:%d: %s: %w", file.Path, option.Line, option.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/papaganelli/tailnginx/internal/health"
+	"github.com/papaganelli/tailnginx/internal/systemd"
+	"github.com/papaganelli/tailnginx/pkg/abuse"
+	"github.com/papaganelli/tailnginx/pkg/alert"
+	"github.com/papaganelli/tailnginx/pkg/exclude"
+	"github.com/papaganelli/tailnginx/pkg/feed"
+	"github.com/papaganelli/tailnginx/pkg/geoip"
+	"github.com/papaganelli/tailnginx/pkg/metrics"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/ratelimit"
+	"github.com/papaganelli/tailnginx/pkg/stats"
+	"github.com/papaganelli/tailnginx/pkg/store"
+	"github.com/papaganelli/tailnginx/pkg/tailer"
+)
+
+const (
+	// headlessTop is the number of entries per section in printed summaries.
+	headlessTop = 5
+	// headlessCheck is the interval between two checks of the alert rules.
+	headlessCheck = time.Second
+)
+
+// headless configures the pipeline run without the dashboard.
+type headless struct {
+	logPath    string
+	interval   time.Duration
+	checkpoint string // File the positions of the logs are saved to, empty for none
+	exportDir  string // Directory for JSON and CSV summaries, empty for none
+	format     *parser.Format
+	exclude    *exclude.Rules
+	reloads    <-chan rules // Rules of the reloaded configuration
+	geoLocator *geoip.Locator
+	exporter   *metrics.Exporter
+	statsd     *metrics.StatsD
+	pusher     *metrics.Pusher
+	store      *store.Store
+	feed       *feed.Hub
+	summarizer *stats.Summarizer
+	offenders  *abuse.Detector
+	rateLimits *ratelimit.Tracker
+	checker    *alert.Checker
+	health     *health.Monitor
+	latest     *atomic.Pointer[stats.Snapshot] // Summary of the last interval
+}
+
+// snapshot returns the summary of the last complete interval, e.g. for the
+// HTTP API, or an empty one during the first interval.
+func (h headless) snapshot() *stats.Snapshot {
+	if s := h.latest.Load(); s != nil {
+		return s
+	}
+	return stats.NewAggregator().Flush(time.Now(), h.logPath, h.interval.String())
+}
+
+// run aggregates the log lines without the dashboard and prints a summary
+// of each interval to stdout. With an export directory, each summary is
+// also written there as JSON and CSV. Entries are also counted in the
+// exporter, StatsD emitter, pusher, summarizer and abuse detector, saved to
+// the store and published to the feed, if any, and the alert rules are
+// checked over them every second. Under systemd, readiness, watchdog pings
+// and the last summary are notified. Exclude and alert rules received from
+// reloads apply to the next lines. With a checkpoint file, the positions of
+// the lines handled are saved with each summary. It returns when lines
+// is closed or on SIGINT/SIGTERM, after handling the lines already read,
+// printing the last partial interval and saving the positions.
+func (h headless) run(lines <-chan tailer.Line) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	agg := stats.NewAggregator()
+	positions := make(map[string]tailer.Position)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	checks := time.NewTicker(headlessCheck)
+	defer checks.Stop()
+
+	flush := func() {
+		s := agg.Flush(time.Now(), h.logPath, h.interval.String())
+		h.latest.Store(s)
+		notify(fmt.Sprintf("STATUS=%d requests in the last %s", s.Requests, h.interval))
+		if err := s.WriteText(os.Stdout, headlessTop); err != nil {
+			log.Printf("Error: writing summary: %v", err)
+		}
+		h.savePositions(positions)
+		if h.exportDir == "" {
+			return
+		}
+		if _, err := s.Export(h.exportDir); err != nil {
+			log.Printf("Error: %v", err)
+		}
+	}
+
+	handle := func(l tailer.Line) {
+		if l.Path != "" {
+			positions[l.Path] = l.Position
+		}
+		line := l.Text
+		var v *parser.Visitor
+		if h.format != nil {
+			v = h.format.Parse(line)
+		} else {
+			v = parser.Parse(line)
+		}
+		if v == nil {
+			if strings.TrimSpace(line) != "" {
+				h.health.Line(false)
+				h.exporter.ParseFailure()
+				h.statsd.ParseFailure()
+				h.pusher.ParseFailure()
+			}
+			return
+		}
+		if h.exclude.Match(v) {
+			h.health.Line(true)
+			return
+		}
+		if h.geoLocator != nil {
+			if loc, err := h.geoLocator.Lookup(v.IP); err == nil && loc != nil {
+				v.Country = loc.Country
+			}
+		}
+		h.health.Line(true)
+		h.exporter.Observe(v)
+		h.statsd.Observe(v)
+		h.pusher.Observe(v)
+		h.store.Add(v)
+		h.feed.Publish(v)
+		h.summarizer.Observe(v)
+		h.offenders.Observe(v)
+		h.rateLimits.Observe(v)
+		h.checker.Observe(v)
+		agg.Add(v)
+	}
+
+	// The watchdog is only fed by this loop, so systemd restarts a stuck
+	// pipeline
+	var watchdog <-chan time.Time
+	if every := systemd.WatchdogInterval(); every > 0 {
+		t := time.NewTicker(every)
+		defer t.Stop()
+		watchdog = t.C
+	}
+	notify("READY=1\nSTATUS=Tailing " + h.logPath)
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				h.health.Stopped()
+				flush()
+				return
+			}
+			handle(line)
+
+		case <-ticker.C:
+			flush()
+
+		case now := <-checks.C:
+			h.checker.Check(now)
+
+		case <-watchdog:
+			notify("WATCHDOG=1")
+
+		case r := <-h.reloads:
+			h.exclude = r.exclude
+			h.checker.SetRules(r.alerts)
+			log.Printf("Configuration reloaded")
+			notify("READY=1")
+
+		case <-ctx.Done():
+			notify("STOPPING=1")
+			for drained := false; !drained; {
+				select {
+				case line, ok := <-lines:
+					if !ok {
+						drained = true
+						break
+					}
+					handle(line)
+				default:
+					drained = true
+				}
+			}
+			flush()
+			return
+		}
+	}
+}
+
+// savePositions saves the positions of the logs to the checkpoint file, if
+// any.
+func (h headless) savePositions(positions map[string]tailer.Position) {
+	if h.checkpoint == "" || len(positions) == 0 {
+		return
+	}
+	saved := make([]tailer.Position, 0, len(positions))
+	for _, p := range positions {
+		saved = append(saved, p)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Path < saved[j].Path })
+	if err := tailer.SavePositions(h.checkpoint, saved); err != nil {
+		log.Printf("Error: -checkpoint: %v", err)
+	}
+}
+
+// withoutPositions wraps lines read without their positions, e.g. by
+// -since, which are not checkpointed.
+func withoutPositions(text <-chan string) <-chan tailer.Line {
+	lines := make(chan tailer.Line, cap(text))
+	go func() {
+		defer close(lines)
+		for line := range text {
+			lines <- tailer.Line{Text: line}
+		}
+	}()
+	return lines
+}
+
+// alertLogger logs the alerts as they fire and clear, e.g. to the journal.
+type alertLogger struct{}
+
+// Notify logs a fired alert.
+func (alertLogger) Notify(a alert.Alert) {
+	log.Printf("Alert: %s %s", a.Severity, a.Message)
+}
+
+// Resolve logs a cleared alert.
+func (alertLogger) Resolve(a alert.Alert) {
+	log.Printf("Alert cleared: %s", a.ID)
+}
+
+// notify sends a state to systemd, if started by it.
+func notify(state string) {
+	if err := systemd.Notify(state); err != nil {
+		log.Printf("Error: notifying systemd: %v", err)
+	}
+}
@@ -4,29 +4,246 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/papaganelli/tailnginx/internal/config"
+	"github.com/papaganelli/tailnginx/internal/debug"
+	"github.com/papaganelli/tailnginx/internal/health"
+	"github.com/papaganelli/tailnginx/internal/state"
 	"github.com/papaganelli/tailnginx/internal/version"
+	"github.com/papaganelli/tailnginx/pkg/abuse"
+	"github.com/papaganelli/tailnginx/pkg/alert"
 	"github.com/papaganelli/tailnginx/pkg/detector"
+	"github.com/papaganelli/tailnginx/pkg/feed"
 	"github.com/papaganelli/tailnginx/pkg/geoip"
+	"github.com/papaganelli/tailnginx/pkg/grpcapi"
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+	"github.com/papaganelli/tailnginx/pkg/hotlink"
+	"github.com/papaganelli/tailnginx/pkg/metrics"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/ratelimit"
+	"github.com/papaganelli/tailnginx/pkg/reputation"
+	"github.com/papaganelli/tailnginx/pkg/sensitive"
+	"github.com/papaganelli/tailnginx/pkg/stats"
+	"github.com/papaganelli/tailnginx/pkg/store"
 	"github.com/papaganelli/tailnginx/pkg/tailer"
+	"github.com/papaganelli/tailnginx/pkg/watchlist"
 	"github.com/papaganelli/tailnginx/ui"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "query" {
+		runQuery(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		runCheck(os.Args[2:])
+		return
+	}
+	// check-config takes the options of the dashboard
+	args := os.Args[1:]
+	checkConfig := len(args) > 0 && args[0] == "check-config"
+	if checkConfig {
+		args = args[1:]
+	}
+
 	var cfg config.Config
 	var refreshMs int
-	var logPath string
+	var logPaths []string
 	var showVersion bool
+	var streamColumns string
+	var statsdPrefix string
+	var statsdTags bool
+	var graphitePrefix string
+	var webhookTemplate string
+	var slackSummary time.Duration
+	var discordSummary time.Duration
+	var smtpFrom, smtpTo, smtpUser string
+	var smtpDigest bool
+	var mqttTopic, mqttUser string
+	var mqttInterval time.Duration
+	var pushInterval, rollupWindow time.Duration
+	var pushName string
+	var healthMaxAge time.Duration
+	var reportInterval time.Duration
+	var reportKeep int
+	var banLimits abuse.Thresholds
+	var denyFormat, nginxPID string
+	var rateLimitRPS float64
+	var rateLimitWindow time.Duration
+	var rateLimitFile string
+	var checkpointFile string
+	var abuseIPDBKey string
+	var alertLog string
+	var alarm bool
+	var blocklists []string
+	var configFile, profile string
+	var excludePaths, excludeIPs, excludeAgents []string
+	var ignoreSelf bool
+	var noGeoIP bool
+	var maxEntries, maxMemoryMB int
+	var ignoreCIDRs []string
+	var alertRules []string
+	var loginPaths []string
+	var loginFailures int
+	var loginWindow time.Duration
+	var sensitivePaths []string
+	var siteDomains []string
+	var startFilters ui.Filters
+	var timezone, timeFormat string
+	var keyBindings []string
+	var sinceText, untilText string
 
-	flag.StringVar(&logPath, "log", "", "path to nginx access log (auto-detect if not specified)")
+	flag.StringVar(&configFile, "config", "", "configuration file setting options by name, e.g. 'refresh: 500' (default: tailnginx/config.yaml in the user config directory, if any)")
+	flag.StringVar(&profile, "profile", "", "profile of the configuration file to use, e.g. 'shop' for the options under 'profiles: shop:'")
+	flag.Var((*stringList)(&logPaths), "log", "path to nginx access log, repeatable to tail several logs together (auto-detect if not specified)")
 	flag.IntVar(&refreshMs, "refresh", 1000, "refresh rate in milliseconds (100-10000)")
 	flag.BoolVar(&showVersion, "version", false, "show version information and exit")
-	flag.Parse()
+	flag.StringVar(&cfg.ErrorLog, "error-log", "", "path to nginx error log (auto-detect next to the access log if not specified)")
+	flag.Var((*stringList)(&cfg.LogFormats), "log-format", "format of the log: a preset (combined, common, vcombined, json, caddy) or an nginx log_format definition, optionally prefixed with 'custom:', repeatable for -log files in different formats (default: combined)")
+	flag.Var((*stringList)(&cfg.LogFormats), "format", "alias of -log-format")
+	flag.Var((*stringList)(&cfg.Highlights), "highlight", "highlight rule, e.g. 'status>=500 -> red background' (repeatable)")
+	flag.Var((*stringList)(&excludePaths), "exclude-path", "regular expression of request paths left out of the stats, e.g. '^/healthz$' or '\\.(css|js|png)$' (repeatable)")
+	flag.Var((*stringList)(&excludeIPs), "exclude-ip", "regular expression of client IPs left out of the stats, e.g. '^10\\.' (repeatable)")
+	flag.Var((*stringList)(&excludeAgents), "exclude-agent", "regular expression of user agents left out of the stats, e.g. '(?i)uptimerobot|pingdom' (repeatable)")
+	flag.BoolVar(&ignoreSelf, "ignore-self", false, "leave the requests of this host's own IPs out of the stats")
+	flag.BoolVar(&noGeoIP, "no-geoip", false, "disable geo lookups and hide the Countries panel, e.g. for internal-only services")
+	flag.Var((*stringList)(&ignoreCIDRs), "ignore-cidr", "network whose requests are left out of the stats, e.g. an office range '203.0.113.0/24' (repeatable)")
+	flag.IntVar(&maxEntries, "max-entries", 10000, "entries kept in memory for the dashboard panels and the alert rules, the oldest are evicted first")
+	flag.IntVar(&maxMemoryMB, "max-memory-mb", 0, "approximate memory in MB of the entries kept for the dashboard panels (0 = no limit)")
+	flag.Var((*stringList)(&cfg.Watch), "watch", "IP or path to watch, e.g. '203.0.113.7' or '/wp-login.php' (repeatable)")
+	flag.Var((*stringList)(&alertRules), "alert", "alert rule checked over the last minute, e.g. '5xx_rate > 5% for 2m', 'p95_latency > 800ms -> critical' or 'rps > 500' (repeatable)")
+	flag.IntVar(&cfg.TopN, "top", 0, "rows in top N tables (0 = fit the panel height)")
+	flag.StringVar(&cfg.ExportDir, "export-dir", ".", "directory for snapshots exported with the e key")
+	flag.BoolVar(&cfg.Plain, "plain", false, "plain text mode: ASCII borders and symbols, no emoji")
+	flag.BoolVar(&cfg.Accessible, "accessible", false, "screen reader mode: panels as plain lines of text, without borders or charts")
+	flag.StringVar(&cfg.Mini, "mini", "", "show only one panel, e.g. 'rate' (rate sparkline and status classes) or 'paths', for small tmux panes")
+	flag.StringVar(&cfg.MetricsAddr, "metrics-listen", "", "address to serve Prometheus metrics on at /metrics, e.g. ':9145'")
+	flag.StringVar(&cfg.APIAddr, "listen", "", "address to serve the HTTP API on, e.g. ':8080' (stats under /api, live stream at /api/stream)")
+	flag.StringVar(&cfg.GRPCAddr, "grpc-listen", "", "address to serve the gRPC API (stats snapshots and live entry stream, see pkg/grpcapi/tailnginx.proto) on, e.g. ':9147'")
+	flag.StringVar(&cfg.DebugAddr, "debug-listen", "", "address to serve pprof profiles (/debug/pprof/) and internal stats (/debug/stats) on, e.g. 'localhost:6060'")
+	flag.DurationVar(&healthMaxAge, "health-max-age", 0, "report the instance as unhealthy at /healthz when no line was read for this long, e.g. '10m' (0 = no limit)")
+	flag.StringVar(&cfg.StatsDAddr, "statsd", "", "StatsD server to push request counters and timers to over UDP, e.g. '127.0.0.1:8125'")
+	flag.StringVar(&statsdPrefix, "statsd-prefix", "tailnginx", "prefix of the StatsD metric names")
+	flag.BoolVar(&statsdTags, "statsd-tags", false, "send the status class as a DogStatsD tag instead of in the StatsD metric name")
+	flag.StringVar(&cfg.Influx, "influx", "", "InfluxDB write URL (e.g. 'http://localhost:8086/api/v2/write?org=ops&bucket=nginx', token in $INFLUX_TOKEN) or file to push per-interval stats to in line protocol")
+	flag.StringVar(&cfg.Graphite, "graphite", "", "Graphite server to push per-interval stats to over TCP in the plaintext protocol, e.g. 'localhost:2003'")
+	flag.StringVar(&graphitePrefix, "graphite-prefix", "tailnginx", "prefix of the Graphite metric paths")
+	flag.StringVar(&cfg.Kafka, "kafka", "", "comma-separated Kafka brokers to publish every parsed entry to as JSON, e.g. 'kafka1:9092,kafka2:9092'")
+	flag.StringVar(&cfg.KafkaTopic, "kafka-topic", "nginx-access", "Kafka topic of the -kafka entries")
+	flag.StringVar(&cfg.Loki, "loki", "", "Grafana Loki push URL to ship every parsed entry to, e.g. 'http://localhost:3100/loki/api/v1/push'")
+	flag.Var((*stringList)(&cfg.LokiLabels), "loki-label", "static label of the -loki streams, e.g. 'env=prod' (repeatable)")
+	flag.StringVar(&cfg.MQTT, "mqtt", "", "MQTT broker to publish a retained JSON stats snapshot to every -mqtt-interval, e.g. 'localhost:1883' (port 8883 uses TLS, password in $MQTT_PASSWORD)")
+	flag.StringVar(&mqttTopic, "mqtt-topic", "tailnginx/stats", "MQTT topic of the -mqtt snapshots")
+	flag.DurationVar(&mqttInterval, "mqtt-interval", time.Minute, "interval between -mqtt snapshots")
+	flag.StringVar(&mqttUser, "mqtt-user", "", "MQTT user name, empty to connect without authentication")
+	flag.StringVar(&cfg.PushTo, "push-to", "", "base URL of the HTTP API of a central tailnginx to push aggregates to every -push-interval, e.g. 'https://central:9146' (token in $TAILNGINX_PUSH_TOKEN)")
+	flag.DurationVar(&pushInterval, "push-interval", time.Minute, "interval between -push-to pushes")
+	flag.StringVar(&pushName, "push-name", "", "name of this instance in -push-to aggregates (default: the host name)")
+	flag.DurationVar(&rollupWindow, "rollup-window", time.Hour, "window of the aggregates pushed by other instances, served at /api/rollup by -listen when $TAILNGINX_PUSH_TOKEN is set")
+	flag.StringVar(&cfg.StoreFile, "store", "", "SQLite database to save every parsed entry to, for 'tailnginx query'")
+	flag.DurationVar(&cfg.FlushPeriod, "flush-interval", 10*time.Second, "interval between pushes to -statsd, -influx and -graphite")
+	flag.StringVar(&cfg.DumpFile, "dump", "", "JSON file the aggregates are written to on exit and on SIGUSR1")
+	flag.StringVar(&cfg.ReportFile, "report-md", "", "Markdown file a summary report is written to on exit, '-' for stdout")
+	flag.StringVar(&cfg.ReportDir, "report-dir", "", "directory a JSON and HTML report of the traffic is written to every -report-interval")
+	flag.DurationVar(&reportInterval, "report-interval", time.Hour, "interval covered by each -report-dir report, e.g. '24h' for daily reports")
+	flag.IntVar(&reportKeep, "report-keep", 168, "number of -report-dir reports kept, older ones are removed (0 = keep all)")
+	flag.Var((*stringList)(&cfg.Webhooks), "webhook", "URL to POST warning and critical alerts to as JSON (repeatable)")
+	flag.StringVar(&webhookTemplate, "webhook-template", "", "file with a Go text/template of the -webhook request body, e.g. '{\"text\": {{json .Message}}}'")
+	flag.StringVar(&cfg.Slack, "slack", "", "Slack incoming webhook URL to post warning and critical alerts to")
+	flag.Var((*stringList)(&cfg.SlackRoutes), "slack-route", "post the alerts of a rule to another Slack channel or webhook, e.g. '5xx_spike=#oncall' (repeatable)")
+	flag.DurationVar(&slackSummary, "slack-summary", 0, "interval between traffic summaries posted to -slack, e.g. '1h' (0 = none)")
+	flag.StringVar(&cfg.Discord, "discord", "", "Discord webhook URL to post warning and critical alerts to")
+	flag.DurationVar(&discordSummary, "discord-summary", 0, "interval between traffic summaries posted to -discord, e.g. '1h' (0 = none)")
+	flag.StringVar(&cfg.SMTPAddr, "smtp", "", "SMTP server to email warning and critical alerts through, e.g. 'mail.example.com:587' (password in $SMTP_PASSWORD)")
+	flag.StringVar(&smtpFrom, "smtp-from", "", "sender address of -smtp emails")
+	flag.StringVar(&smtpTo, "smtp-to", "", "comma-separated recipients of -smtp emails")
+	flag.StringVar(&smtpUser, "smtp-user", "", "SMTP user name, empty to send without authentication")
+	flag.BoolVar(&smtpDigest, "smtp-digest", false, "also email a daily report, with the HTML report attached")
+	flag.StringVar(&cfg.PagerDuty, "pagerduty", "", "PagerDuty Events API v2 routing key to trigger incidents for critical alerts with, resolved when they clear")
+	flag.StringVar(&cfg.Opsgenie, "opsgenie", "", "Opsgenie API integration key to create alerts for critical alerts with, closed when they clear")
+	flag.StringVar(&cfg.Desktop, "desktop-notify", "", "show alerts and watchlist hits as desktop notifications: 'osc' (terminal escape sequence and bell) or 'notify-send'")
+	flag.BoolVar(&alarm, "alarm", false, "ring the terminal bell and flash the header when an alert becomes critical")
+	flag.StringVar(&alertLog, "alert-log", "", "file every alert that fires, escalates or clears is appended to as a JSON line, watchlist hits included")
+	flag.StringVar(&cfg.BanFile, "ban-file", "", "file IPs exceeding -ban-requests, -ban-errors or -ban-scan are appended to, for a fail2ban jail")
+	flag.IntVar(&banLimits.Requests, "ban-requests", 600, "requests per -ban-window that flag an IP as abusive (0 = no limit)")
+	flag.IntVar(&banLimits.Errors, "ban-errors", 100, "4xx responses per -ban-window that flag an IP as abusive (0 = no limit)")
+	flag.IntVar(&banLimits.Scan, "ban-scan", 20, "distinct paths answered 404 per -ban-window that flag an IP as scanning, e.g. directory brute forcing (0 = no limit)")
+	flag.DurationVar(&banLimits.Window, "ban-window", time.Minute, "window of -ban-requests, -ban-errors and -ban-scan")
+	flag.Var((*stringList)(&loginPaths), "login-path", "authentication endpoint watched for brute force, e.g. '/wp-login.php' or '/api/login' (repeatable)")
+	flag.IntVar(&loginFailures, "login-failures", 10, "401, 403 and 429 responses to -login-path per -login-window from one IP or subnet that raise a brute force alert")
+	flag.DurationVar(&loginWindow, "login-window", 5*time.Minute, "window of -login-failures")
+	flag.Var((*stringList)(&sensitivePaths), "sensitive-path", "path segment pattern whose requests raise an alert, added to the built-in ones such as '.env', '.git' and '*.sql', e.g. '*.pem', or '!wp-admin' to remove a built-in one (repeatable)")
+	flag.Var((*stringList)(&siteDomains), "site-domain", "domain of the site, with its subdomains, whose referers are not hotlinks, e.g. 'example.com'; needed when the log format has no $host (repeatable)")
+	flag.StringVar(&cfg.DenyFile, "deny-file", "", "nginx include file the b key writes the deny list of the IPs flagged in the last hour to (default: a file in -export-dir)")
+	flag.StringVar(&denyFormat, "deny-format", abuse.FormatDeny, "format of the deny list: 'deny' (deny directives) or 'geo' (lines of a geo block)")
+	flag.StringVar(&nginxPID, "nginx-pid", "", "nginx PID file, to reload nginx after writing -deny-file, e.g. '/run/nginx.pid'")
+	flag.StringVar(&abuseIPDBKey, "abuseipdb-key", "", "AbuseIPDB API key to show the abuse confidence score of the IPs of the Security panel with")
+	flag.Var((*stringList)(&blocklists), "blocklist", "file of IPs and CIDR networks, e.g. FireHOL's firehol_level1.netset, whose listed IPs are marked in the Security panel (repeatable)")
+	flag.Float64Var(&rateLimitRPS, "ratelimit-rps", 10, "requests per second on average over -ratelimit-window above which an IP is a rate limit candidate")
+	flag.DurationVar(&rateLimitWindow, "ratelimit-window", time.Minute, "window of -ratelimit-rps")
+	flag.StringVar(&rateLimitFile, "ratelimit-file", "", "nginx include file the report of the rate limit candidates and the suggested limit_req zone is written to every minute and by the L key (default: the L key writes a file in -export-dir)")
+	flag.StringVar(&cfg.Output, "output", "", "'jsonl' to write every parsed entry to stdout as a JSON line instead of showing the dashboard")
+	flag.Var((*stringList)(&cfg.Filters), "filter", "condition -output entries must match, e.g. 'status>=500' or 'path prefix /api' (repeatable)")
+	flag.StringVar(&startFilters.Status, "filter-status", "", "status class or code the dashboard opens filtered by, e.g. '5xx' or '404'")
+	flag.StringVar(&startFilters.Path, "filter-path", "", "path prefix the dashboard opens filtered by, e.g. '/api'")
+	flag.StringVar(&startFilters.IP, "filter-ip", "", "client IP or network the dashboard opens filtered by, e.g. '192.0.2.0/24'")
+	flag.BoolVar(&cfg.Headless, "headless", false, "run without the dashboard and print a stats summary every -interval (e.g. under systemd)")
+	flag.DurationVar(&cfg.Interval, "interval", time.Minute, "interval between summaries in -headless mode")
+	flag.StringVar(&checkpointFile, "checkpoint", "", "file the read offsets of the logs are saved to every -interval and on exit in -headless mode, to resume where the last run stopped")
+	flag.StringVar(&timezone, "timezone", "", "time zone times are shown in, e.g. 'UTC', 'Local' or 'Europe/Paris' (default: the zone of the log for entries, the system zone otherwise)")
+	flag.StringVar(&timeFormat, "time-format", "15:04:05", "Go layout of the times in the live stream, error log and alert banner, e.g. 'Jan 02 15:04:05' to show dates")
+	flag.StringVar(&sinceText, "since", "", "only analyze the requests logged since this time, e.g. '2h' or '2025-10-08 14:30', reading the whole log and its rotated files")
+	flag.StringVar(&untilText, "until", "", "only analyze the requests logged until this time, e.g. '30m' or '2025-10-08 15:00'; new lines are not followed once it is past")
+	flag.Var((*stringList)(&keyBindings), "key", "bind a dashboard action to another key, e.g. 'pause=z' or 'window=F2' (repeatable)")
+	flag.StringVar(&streamColumns, "stream-columns", "time,ip,method,path,status,bytes,latency,referer", "comma-separated live stream columns")
+	flag.CommandLine.Parse(args)
+	if checkConfig {
+		os.Exit(runCheckConfig(os.Stdout, flag.CommandLine, configFile, profile))
+	}
+	if err := loadConfigFile(flag.CommandLine, configFile, profile); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	for _, column := range strings.Split(streamColumns, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			cfg.Stream = append(cfg.Stream, column)
+		}
+	}
+
+	// Reports, summaries and alerts use the local time zone
+	var displayZone *time.Location
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			log.Fatalf("Error: -timezone: %v", err)
+		}
+		time.Local = loc
+		displayZone = loc
+	}
+	if strings.TrimSpace(timeFormat) == "" {
+		log.Fatalf("Error: -time-format must not be empty")
+	}
+	now := time.Now()
+	since, err := parseTimeBound(sinceText, now)
+	if err != nil {
+		log.Fatalf("Error: -since: %v", err)
+	}
+	until, err := parseTimeBound(untilText, now)
+	if err != nil {
+		log.Fatalf("Error: -until: %v", err)
+	}
+	if !since.IsZero() && !until.IsZero() && !until.After(since) {
+		log.Fatalf("Error: -until must be after -since")
+	}
 
 	// Handle version flag
 	if showVersion {
@@ -35,7 +252,7 @@ func main() {
 	}
 
 	// Autodetect log file if not specified
-	if logPath == "" {
+	if len(logPaths) == 0 {
 		logs, err := detector.DetectLogFiles()
 		if err != nil {
 			log.Fatalf("Error: No nginx log files found. Please specify one with -log flag.\nTried common locations: /var/log/nginx/, /usr/local/nginx/logs/, /opt/nginx/logs/")
@@ -44,6 +261,7 @@ func main() {
 		// Use the best detected log file
 		bestLog := detector.GetBestLogFile(logs)
 		cfg.LogPath = bestLog.Path
+		cfg.LogPaths = []string{cfg.LogPath}
 		log.Printf("Auto-detected nginx log: %s", cfg.LogPath)
 
 		// If multiple logs found, show them
@@ -64,20 +282,25 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Use -log flag to specify a different file\n\n")
 		}
 	} else {
-		// Validate user-provided log path
-		if err := validateLogPath(logPath); err != nil {
-			log.Fatalf("Error: Invalid log path: %v", err)
+		// Validate user-provided log paths
+		for _, logPath := range logPaths {
+			if err := validateLogPath(logPath); err != nil {
+				log.Fatalf("Error: Invalid log path: %v", err)
+			}
 		}
-		cfg.LogPath = logPath
+		cfg.LogPath = logPaths[0]
+		cfg.LogPaths = logPaths
 	}
 
-	// Verify log file exists and is readable
-	if info, err := os.Stat(cfg.LogPath); os.IsNotExist(err) {
-		log.Fatalf("Error: Log file does not exist: %s", cfg.LogPath)
-	} else if err != nil {
-		log.Fatalf("Error: Cannot access log file: %v", err)
-	} else if info.IsDir() {
-		log.Fatalf("Error: Path is a directory, not a file: %s", cfg.LogPath)
+	// Verify log files exist and are readable
+	for _, logPath := range cfg.LogPaths {
+		if info, err := os.Stat(logPath); os.IsNotExist(err) {
+			log.Fatalf("Error: Log file does not exist: %s", logPath)
+		} else if err != nil {
+			log.Fatalf("Error: Cannot access log file: %v", err)
+		} else if info.IsDir() {
+			log.Fatalf("Error: Path is a directory, not a file: %s", logPath)
+		}
 	}
 
 	// Convert milliseconds to duration and validate
@@ -89,27 +312,815 @@ func main() {
 		cfg.RefreshRate = config.MaxRefreshRate
 	}
 
-	// Initialize GeoIP locator with automatic database management
-	geoLocator, err := geoip.NewLocator()
+	if cfg.Interval <= 0 {
+		log.Fatalf("Error: -interval must be positive, got %s", cfg.Interval)
+	}
+	if cfg.FlushPeriod <= 0 {
+		log.Fatalf("Error: -flush-interval must be positive, got %s", cfg.FlushPeriod)
+	}
+
+	if cfg.Output != "" && cfg.Output != "jsonl" {
+		log.Fatalf("Error: -output must be 'jsonl', got %q", cfg.Output)
+	}
+	if cfg.Output != "" && cfg.Headless {
+		log.Fatalf("Error: -output and -headless cannot be combined")
+	}
+	var filters highlight.Rules
+	for _, text := range cfg.Filters {
+		f, err := highlight.ParseCondition(text)
+		if err != nil {
+			log.Fatalf("Error: -filter: %v", err)
+		}
+		filters = append(filters, f)
+	}
+
+	if cfg.TopN < 0 {
+		log.Fatalf("Error: -top must be 0 or more, got %d", cfg.TopN)
+	}
+	if maxEntries <= 0 {
+		log.Fatalf("Error: -max-entries must be positive, got %d", maxEntries)
+	}
+	if maxMemoryMB < 0 {
+		log.Fatalf("Error: -max-memory-mb must be 0 or more, got %d", maxMemoryMB)
+	}
+	for _, path := range loginPaths {
+		if err := checkLoginPath(path); err != nil {
+			log.Fatalf("Error: -login-path: %v", err)
+		}
+	}
+	if loginFailures <= 0 || loginWindow <= 0 {
+		log.Fatalf("Error: -login-failures and -login-window must be positive, got %d and %s", loginFailures, loginWindow)
+	}
+	sensitivePatterns, err := sensitive.New(sensitivePaths)
+	if err != nil {
+		log.Fatalf("Error: -sensitive-path: %v", err)
+	}
+	hotlinks, err := hotlink.NewDetector(siteDomains)
+	if err != nil {
+		log.Fatalf("Error: -site-domain: %v", err)
+	}
+	if cfg.Headless && len(siteDomains) > 0 {
+		log.Printf("Warning: -site-domain has no effect with -headless")
+	}
+
+	// Highlight, exclude and alert rules are built again when the
+	// configuration is reloaded
+	loaded, err := loadRules(flag.CommandLine, since, until)
 	if err != nil {
-		geoLocator = nil
-	} else {
-		defer geoLocator.Close()
+		log.Fatalf("Error: %v", err)
+	}
+	highlights, excluded := loaded.highlights, loaded.exclude
+
+	watched, err := watchlist.New(cfg.Watch)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	format, err := parser.NewFormats(cfg.LogFormats)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	// Initialize GeoIP locator with automatic database management, unless geo
+	// lookups are disabled: a nil locator leaves the country of every entry
+	// empty
+	var geoLocator *geoip.Locator
+	if !noGeoIP {
+		if geoLocator, err = geoip.NewLocator(); err != nil {
+			geoLocator = nil
+		} else {
+			defer geoLocator.Close()
+		}
+	}
+
+	// Pipe mode writes the new entries to stdout for other tools and nothing
+	// else
+	if cfg.Output == "jsonl" {
+		done := make(chan struct{})
+		defer close(done)
+		lines, err := openLogs(cfg.LogPaths, true, since, until, done)
+		if err != nil {
+			log.Fatalf("failed to tail file: %v", err)
+		}
+		if err := pipe(os.Stdout, lines, format, excluded, geoLocator, filters); err != nil {
+			log.Printf("Error: -output: %v", err)
+		}
+		return
+	}
+
+	// Every embedded server reports the health of the pipeline at /healthz
+	var monitor *health.Monitor
+	if cfg.MetricsAddr != "" || cfg.APIAddr != "" || cfg.DebugAddr != "" {
+		monitor = health.NewMonitor(cfg.LogPath, healthMaxAge)
+	}
+
+	// Prometheus metrics, StatsD, InfluxDB, Graphite, Kafka, Loki and the
+	// HTTP API are fed by the same pipeline as the dashboard
+	var exporter *metrics.Exporter
+	if cfg.MetricsAddr != "" {
+		exporter = metrics.NewExporter()
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", exporter)
+		mux.Handle("/healthz", monitor)
+		serveHTTP("-metrics-listen", cfg.MetricsAddr, mux)
+	}
+	var statsd *metrics.StatsD
+	if cfg.StatsDAddr != "" {
+		statsd, err = metrics.DialStatsD(cfg.StatsDAddr, statsdPrefix, statsdTags)
+		if err != nil {
+			log.Fatalf("Error: -statsd: %v", err)
+		}
+		defer statsd.Close()
+		go statsd.Run(cfg.FlushPeriod)
+	}
+	// The pusher, Kafka producer and Loki pusher start once they know where
+	// to report errors
+	var pusher *metrics.Pusher
+	if cfg.Influx != "" || cfg.Graphite != "" {
+		pusher = metrics.NewPusher()
+	}
+	if cfg.Influx != "" {
+		tags := map[string]string{"log": cfg.LogPath}
+		if strings.HasPrefix(cfg.Influx, "http://") || strings.HasPrefix(cfg.Influx, "https://") {
+			pusher.AddOutput(metrics.InfluxHTTP(cfg.Influx, os.Getenv("INFLUX_TOKEN"), tags))
+		} else {
+			pusher.AddOutput(metrics.InfluxFile(cfg.Influx, tags))
+		}
+	}
+	if cfg.Graphite != "" {
+		pusher.AddOutput(metrics.GraphiteTCP(cfg.Graphite, graphitePrefix))
+	}
+	var kafka *feed.Kafka
+	if cfg.Kafka != "" {
+		kafka, err = feed.NewKafka(cfg.Kafka, cfg.KafkaTopic)
+		if err != nil {
+			log.Fatalf("Error: -kafka: %v", err)
+		}
+	}
+	var loki *feed.Loki
+	if cfg.Loki != "" {
+		loki, err = feed.NewLoki(cfg.Loki, cfg.LokiLabels)
+		if err != nil {
+			log.Fatalf("Error: -loki: %v", err)
+		}
+	}
+	// Alert rules and the built-in checks run over the pipeline, with or
+	// without the dashboard, and fire on one board
+	checker := alert.NewChecker(alert.NewBoard(), maxEntries)
+	checker.SetRules(loaded.alerts)
+	checker.SetLoginPaths(loginPaths, loginFailures, loginWindow)
+	checker.SetSensitivePaths(sensitivePatterns)
+	// Notifiers are told about the alerts of both modes, and deliver them
+	// once they know where to report errors
+	var notifiers []notifier
+	addNotifier := func(n notifier, least alert.Severity) {
+		checker.Board().AddNotifier(n, least)
+		notifiers = append(notifiers, n)
+	}
+	var webhook *alert.Webhook
+	if len(cfg.Webhooks) > 0 {
+		tmpl := ""
+		if webhookTemplate != "" {
+			b, err := os.ReadFile(webhookTemplate)
+			if err != nil {
+				log.Fatalf("Error: -webhook-template: %v", err)
+			}
+			tmpl = string(b)
+		}
+		webhook, err = alert.NewWebhook(cfg.Webhooks, tmpl)
+		if err != nil {
+			log.Fatalf("Error: -webhook: %v", err)
+		}
+		addNotifier(webhook, alert.SeverityWarning)
+	}
+	// Incidents are deduplicated per host
+	host, _ := os.Hostname()
+	if cfg.PagerDuty != "" {
+		addNotifier(alert.NewPagerDuty(cfg.PagerDuty, host), alert.SeverityWarning)
+	}
+	if cfg.Opsgenie != "" {
+		addNotifier(alert.NewOpsgenie(cfg.Opsgenie, host), alert.SeverityWarning)
+	}
+	// OSC notifications and the alarm go through the terminal of the
+	// dashboard
+	if cfg.Desktop == alert.DesktopOSC && cfg.Headless {
+		log.Printf("Warning: -desktop-notify osc has no effect with -headless")
+	} else if cfg.Desktop != "" && cfg.Desktop != alert.DesktopOSC {
+		desktop, err := alert.NewDesktop(cfg.Desktop, nil)
+		if err != nil {
+			log.Fatalf("Error: -desktop-notify: %v", err)
+		}
+		addNotifier(desktop, alert.SeverityInfo)
+	}
+	if alarm && cfg.Headless {
+		log.Printf("Warning: -alarm has no effect with -headless")
+	}
+	if alertLog != "" {
+		auditLog, err := alert.NewAuditLog(alertLog)
+		if err != nil {
+			log.Fatalf("Error: -alert-log: %v", err)
+		}
+		addNotifier(auditLog, alert.SeverityInfo)
+	}
+	var slack *alert.Slack
+	var summarizer *stats.Summarizer
+	if cfg.Slack != "" || len(cfg.SlackRoutes) > 0 {
+		slack, err = alert.NewSlack(cfg.Slack, cfg.SlackRoutes)
+		if err != nil {
+			log.Fatalf("Error: -slack: %v", err)
+		}
+		addNotifier(slack, alert.SeverityWarning)
+	}
+	var discord *alert.Discord
+	if cfg.Discord != "" {
+		discord, err = alert.NewDiscord(cfg.Discord)
+		if err != nil {
+			log.Fatalf("Error: -discord: %v", err)
+		}
+		addNotifier(discord, alert.SeverityWarning)
+	}
+	var email *alert.Email
+	if cfg.SMTPAddr != "" {
+		var to []string
+		for _, addr := range strings.Split(smtpTo, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				to = append(to, addr)
+			}
+		}
+		email, err = alert.NewEmail(alert.SMTP{
+			Addr:     cfg.SMTPAddr,
+			Username: smtpUser,
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     smtpFrom,
+			To:       to,
+		})
+		if err != nil {
+			log.Fatalf("Error: -smtp: %v", err)
+		}
+		addNotifier(email, alert.SeverityWarning)
+	}
+	if slackSummary > 0 || discordSummary > 0 || smtpDigest || cfg.MQTT != "" || cfg.ReportDir != "" || cfg.PushTo != "" {
+		summarizer = stats.NewSummarizer(cfg.LogPath)
+	}
+	if cfg.ReportDir != "" {
+		if reportInterval <= 0 {
+			log.Fatalf("Error: -report-interval must be positive")
+		}
+		reports, err := stats.NewReportDir(cfg.ReportDir, reportKeep)
+		if err != nil {
+			log.Fatalf("Error: -report-dir: %v", err)
+		}
+		summarizer.AddOutput(reportInterval, reports.Write)
+	}
+	if cfg.MQTT != "" {
+		if mqttInterval <= 0 {
+			log.Fatalf("Error: -mqtt-interval must be positive")
+		}
+		mqtt, err := stats.NewMQTT(cfg.MQTT, mqttTopic, mqttUser, os.Getenv("MQTT_PASSWORD"))
+		if err != nil {
+			log.Fatalf("Error: -mqtt: %v", err)
+		}
+		summarizer.AddOutput(mqttInterval, mqtt.Publish)
+	}
+	if cfg.PushTo != "" {
+		if pushInterval <= 0 {
+			log.Fatalf("Error: -push-interval must be positive")
+		}
+		if pushName == "" {
+			pushName, _ = os.Hostname()
+		}
+		push, err := stats.NewPush(cfg.PushTo, os.Getenv("TAILNGINX_PUSH_TOKEN"), pushName)
+		if err != nil {
+			log.Fatalf("Error: -push-to: %v", err)
+		}
+		summarizer.AddOutput(pushInterval, push.Send)
+	}
+	if smtpDigest {
+		if email == nil {
+			log.Fatalf("Error: -smtp-digest needs -smtp")
+		}
+		summarizer.AddOutput(digestInterval, email.Digest)
+	}
+	if slackSummary > 0 {
+		if cfg.Slack == "" {
+			log.Fatalf("Error: -slack-summary needs -slack")
+		}
+		summarizer.AddOutput(slackSummary, slack.Summary)
+	}
+	if discordSummary > 0 {
+		if discord == nil {
+			log.Fatalf("Error: -discord-summary needs -discord")
+		}
+		summarizer.AddOutput(discordSummary, discord.Summary)
+	}
+	// Abusive IPs are flagged for -ban-file, or for the deny list key of the
+	// dashboard
+	var offenders *abuse.Detector
+	if cfg.BanFile != "" || !cfg.Headless {
+		offenders, err = abuse.NewDetector(banLimits)
+		if err != nil {
+			log.Fatalf("Error: -ban-requests, -ban-errors, -ban-scan, -ban-window: %v", err)
+		}
+	}
+	if cfg.BanFile != "" {
+		f2b, err := abuse.NewFail2ban(cfg.BanFile)
+		if err != nil {
+			log.Fatalf("Error: -ban-file: %v", err)
+		}
+		offenders.AddOutput(f2b.Write)
+	}
+	if err := abuse.CheckFormat(denyFormat); err != nil {
+		log.Fatalf("Error: -deny-format: %v", err)
+	}
+	if nginxPID != "" && cfg.DenyFile == "" {
+		log.Fatalf("Error: -nginx-pid needs -deny-file")
+	}
+	if cfg.Headless && cfg.DenyFile != "" {
+		log.Printf("Warning: -deny-file has no effect with -headless")
+	}
+	// Threat intelligence about the IPs of the Security panel
+	var threatIntel *reputation.Checker
+	if abuseIPDBKey != "" || len(blocklists) > 0 {
+		var lists []*reputation.Blocklist
+		for _, path := range blocklists {
+			list, err := reputation.LoadBlocklist(path)
+			if err != nil {
+				log.Fatalf("Error: -blocklist: %v", err)
+			}
+			lists = append(lists, list)
+		}
+		threatIntel = reputation.NewChecker(abuseIPDBKey, lists)
+	}
+	if cfg.Headless && threatIntel != nil {
+		log.Printf("Warning: -abuseipdb-key and -blocklist have no effect with -headless")
+	}
+	// IP request rates are tracked for -ratelimit-file, or for the rate
+	// limit key of the dashboard
+	var rateLimits *ratelimit.Tracker
+	if rateLimitFile != "" || !cfg.Headless {
+		rateLimits, err = ratelimit.NewTracker(rateLimitRPS, rateLimitWindow)
+		if err != nil {
+			log.Fatalf("Error: -ratelimit-rps, -ratelimit-window: %v", err)
+		}
+	}
+	var db *store.Store
+	if cfg.StoreFile != "" {
+		db, err = store.Open(cfg.StoreFile)
+		if err != nil {
+			log.Fatalf("Error: -store: %v", err)
+		}
+		defer db.Close()
+		go db.Run(storeInterval, logError)
+	}
+	// The stats endpoints are added to the API once their source is known
+	var hub *feed.Hub
+	var api *http.ServeMux
+	if cfg.APIAddr != "" || cfg.GRPCAddr != "" || cfg.Kafka != "" || cfg.Loki != "" {
+		hub = feed.NewHub()
+		monitor.SetFeedDropped(hub.Dropped)
+	}
+	// The gRPC API is served once the source of its snapshots is known
+	var grpcListener net.Listener
+	if cfg.GRPCAddr != "" {
+		grpcListener, err = net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			log.Fatalf("Error: -grpc-listen: %v", err)
+		}
+	}
+	if cfg.APIAddr != "" {
+		api = http.NewServeMux()
+		api.Handle("/api/stream", hub)
+		api.Handle("/healthz", monitor)
+		// Other instances push their aggregates with -push-to
+		if token := os.Getenv("TAILNGINX_PUSH_TOKEN"); token != "" {
+			if rollupWindow <= 0 {
+				log.Fatalf("Error: -rollup-window must be positive")
+			}
+			rollup := stats.NewRollup(rollupWindow).Handler(token)
+			api.Handle("/api/push", rollup)
+			api.Handle("/api/rollup", rollup)
+			api.Handle("/api/rollup/", rollup)
+		}
+		serveHTTP("-listen", cfg.APIAddr, api)
+	}
+	// Queues are added to the stats page once they exist
+	var debugServer *debug.Server
+	if cfg.DebugAddr != "" {
+		debugServer = debug.NewServer()
+		debugServer.AddQueue("feed", hub.Backlog)
+		debugServer.AddCounter("feed_dropped", hub.Dropped)
+		debugServer.Handle("/healthz", monitor)
+		serveHTTP("-debug-listen", cfg.DebugAddr, debugServer)
 	}
 
 	done := make(chan struct{})
 	defer close(done)
 
+	if checkpointFile != "" && !cfg.Headless {
+		log.Printf("Warning: -checkpoint only applies with -headless")
+	}
+	// Headless summaries cover the requests of each interval from now on,
+	// or since the offsets of the last run were saved
+	if cfg.Headless {
+		var lines <-chan tailer.Line
+		if since.IsZero() && until.IsZero() {
+			var from []tailer.Position
+			if checkpointFile != "" {
+				if from, err = tailer.LoadPositions(checkpointFile); err != nil {
+					log.Fatalf("Error: -checkpoint: %v", err)
+				}
+			}
+			lines, err = tailer.FollowFiles(cfg.LogPaths, from, done)
+			if err != nil {
+				log.Fatalf("failed to tail file: %v", err)
+			}
+		} else {
+			if checkpointFile != "" {
+				log.Printf("Warning: -checkpoint has no effect with -since or -until")
+				checkpointFile = ""
+			}
+			text, err := openLogs(cfg.LogPaths, true, since, until, done)
+			if err != nil {
+				log.Fatalf("failed to tail file: %v", err)
+			}
+			lines = withoutPositions(text)
+		}
+		debugServer.AddQueue("lines", func() (int, int) { return len(lines), cap(lines) })
+		// Snapshots are only written when an export directory is given
+		exportDir := ""
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "export-dir" {
+				exportDir = cfg.ExportDir
+			}
+		})
+		// Reloaded rules are handed to the pipeline between two lines
+		reloads := make(chan rules, 1)
+		onReloadSignal(func() {
+			notify("RELOADING=1")
+			r, restart, err := reloadConfig(flag.CommandLine, args, configFile, profile, since, until)
+			if err != nil {
+				log.Printf("Error: reload: %v, keeping the current configuration", err)
+				notify("READY=1")
+				return
+			}
+			if len(restart) > 0 {
+				log.Printf("Warning: reload: restart to apply %s", strings.Join(restart, ", "))
+			}
+			reloads <- r
+		})
+		h := headless{
+			logPath:    cfg.LogPath,
+			interval:   cfg.Interval,
+			checkpoint: checkpointFile,
+			exportDir:  exportDir,
+			format:     format,
+			exclude:    excluded,
+			reloads:    reloads,
+			geoLocator: geoLocator,
+			exporter:   exporter,
+			statsd:     statsd,
+			pusher:     pusher,
+			store:      db,
+			feed:       hub,
+			summarizer: summarizer,
+			offenders:  offenders,
+			rateLimits: rateLimits,
+			checker:    checker,
+			health:     monitor,
+			latest:     new(atomic.Pointer[stats.Snapshot]),
+		}
+		if api != nil {
+			api.Handle("/api/", stats.NewHandler(h.snapshot))
+		}
+		serveGRPC(grpcListener, h.snapshot, hub, logError)
+		onDumpSignal(cfg.DumpFile, func() {
+			if err := h.snapshot().Save(cfg.DumpFile); err != nil {
+				log.Printf("Error: %v", err)
+			}
+		})
+		if pusher != nil {
+			go pusher.Run(cfg.FlushPeriod, logError)
+		}
+		if summarizer != nil {
+			go summarizer.Run(logError)
+		}
+		if offenders != nil {
+			go offenders.Run(logError)
+		}
+		if rateLimitFile != "" {
+			go rateLimits.Run(rateLimitFile, time.Minute, logError)
+		}
+		checker.Board().AddNotifier(alertLogger{}, alert.SeverityInfo)
+		for _, n := range notifiers {
+			go n.Run(logError)
+		}
+		// Kafka and Loki send the entries left once the hub is closed
+		var shippers sync.WaitGroup
+		if kafka != nil {
+			shippers.Add(1)
+			go func() {
+				defer shippers.Done()
+				kafka.Run(hub, logError)
+			}()
+		}
+		if loki != nil {
+			shippers.Add(1)
+			go func() {
+				defer shippers.Done()
+				loki.Run(hub, logError)
+			}()
+		}
+		h.run(lines)
+		hub.Close()
+		shippers.Wait()
+		if err := pusher.Flush(time.Now()); err != nil {
+			logError(err)
+		}
+		if err := summarizer.Flush(time.Now()); err != nil {
+			logError(err)
+		}
+		if err := offenders.Flush(); err != nil {
+			logError(err)
+		}
+		if err := saveRateLimits(rateLimits, rateLimitFile); err != nil {
+			logError(err)
+		}
+		if cfg.DumpFile != "" {
+			if err := h.snapshot().Save(cfg.DumpFile); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+		if err := writeReport(cfg.ReportFile, h.snapshot()); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
 	// Read last 500 lines for quick startup, then tail for new entries
-	lines, err := tailer.TailLines(cfg.LogPath, false, done)
+	lines, err := openLogs(cfg.LogPaths, false, since, until, done)
 	if err != nil {
 		log.Fatalf("failed to tail file: %v", err)
 	}
+	debugServer.AddQueue("lines", func() (int, int) { return len(lines), cap(lines) })
 
 	app := ui.NewTviewApp(lines, cfg.LogPath, cfg.RefreshRate, geoLocator)
+	app.SetHighlightRules(highlights)
+	app.SetLogFormat(format)
+	app.SetExclude(excluded)
+	app.SetAlertChecker(checker)
+	onReloadSignal(func() {
+		r, restart, err := reloadConfig(flag.CommandLine, args, configFile, profile, since, until)
+		if err != nil {
+			app.ShowError(fmt.Errorf("reload: %w, keeping the current configuration", err))
+			return
+		}
+		app.ReloadRules(r.highlights, r.exclude, r.alerts, restart)
+	})
+	app.SetGeoIP(!noGeoIP)
+	app.SetTimeDisplay(displayZone, timeFormat)
+	if err := app.SetKeys(keyBindings); err != nil {
+		log.Fatalf("Error: -key: %v", err)
+	}
+	app.SetMetrics(exporter)
+	app.SetStatsD(statsd)
+	app.SetPusher(pusher)
+	app.SetStore(db)
+	if pusher != nil {
+		go pusher.Run(cfg.FlushPeriod, app.ShowError)
+	}
+	if kafka != nil {
+		go kafka.Run(hub, app.ShowError)
+	}
+	if loki != nil {
+		go loki.Run(hub, app.ShowError)
+	}
+	app.SetFeed(hub)
+	if cfg.Desktop == alert.DesktopOSC {
+		desktop, err := alert.NewDesktop(cfg.Desktop, app.Terminal())
+		if err != nil {
+			log.Fatalf("Error: -desktop-notify: %v", err)
+		}
+		addNotifier(desktop, alert.SeverityInfo)
+	}
+	for _, n := range notifiers {
+		go n.Run(app.ShowError)
+	}
+	app.SetAlarm(alarm)
+	app.SetSummarizer(summarizer)
+	if summarizer != nil {
+		go summarizer.Run(app.ShowError)
+	}
+	app.SetOffenders(offenders)
+	app.SetHealth(monitor)
+	app.SetDenyList(cfg.DenyFile, denyFormat, nginxPID)
+	if offenders != nil {
+		go offenders.Run(app.ShowError)
+	}
+	app.SetRateLimits(rateLimits, rateLimitFile)
+	app.SetReputation(threatIntel)
+	if threatIntel != nil {
+		go threatIntel.Run(app.ShowError)
+	}
+	if rateLimitFile != "" {
+		go rateLimits.Run(rateLimitFile, time.Minute, app.ShowError)
+	}
+	if api != nil {
+		api.Handle("/api/", stats.NewHandler(app.Snapshot))
+	}
+	serveGRPC(grpcListener, app.Snapshot, hub, app.ShowError)
+	app.SetWatchlist(watched)
+	app.SetSensitivePaths(sensitivePatterns)
+	app.SetSiteDomains(hotlinks)
+	app.SetTopN(cfg.TopN)
+	app.SetRetention(maxEntries, int64(maxMemoryMB)<<20)
+	app.SetExportDir(cfg.ExportDir)
+	app.SetPlain(cfg.Plain)
+	app.SetDumpFile(cfg.DumpFile)
+	onDumpSignal(cfg.DumpFile, app.DumpSnapshot)
+	app.SetAccessible(cfg.Accessible)
+	if err := app.SetMini(cfg.Mini); err != nil {
+		log.Fatalf("Error: -mini: %v", err)
+	}
+	if err := app.SetStreamColumns(cfg.Stream); err != nil {
+		log.Fatalf("Error: -stream-columns: %v", err)
+	}
+
+	// UI preferences (hidden panels, layouts) and what was shown last, e.g.
+	// the time window and filters, are kept across sessions
+	statePath, err := state.DefaultPath()
+	if err != nil {
+		log.Printf("Warning: UI preferences will not be saved: %v", err)
+	}
+	st := &state.State{}
+	if statePath != "" {
+		if st, err = state.Load(statePath); err != nil {
+			log.Printf("Warning: ignoring saved UI preferences: %v", err)
+			st = &state.State{}
+		}
+	}
+	app.SetState(st, statePath)
+	if startFilters != (ui.Filters{}) {
+		// Filters given on the command line replace the ones of the last
+		// session
+		if err := app.SetFilters(startFilters); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	// Error log is optional: use the one given or the one next to the access log
+	if cfg.ErrorLog == "" {
+		cfg.ErrorLog = detector.DetectErrorLog(cfg.LogPath)
+	} else if err := validateLogPath(cfg.ErrorLog); err != nil {
+		log.Fatalf("Error: Invalid error log path: %v", err)
+	}
+	if cfg.ErrorLog != "" {
+		errorLines, err := tailer.TailLines(cfg.ErrorLog, false, done)
+		if err != nil {
+			log.Fatalf("failed to tail error log: %v", err)
+		}
+		app.SetErrorLog(cfg.ErrorLog, errorLines)
+		debugServer.AddQueue("error_lines", func() (int, int) { return len(errorLines), cap(errorLines) })
+	}
+
 	if err := app.Run(); err != nil {
 		log.Fatalf("app error: %v", err)
 	}
+	if cfg.DumpFile != "" {
+		log.Printf("Snapshot saved to %s", cfg.DumpFile)
+	}
+	if err := pusher.Flush(time.Now()); err != nil {
+		logError(err)
+	}
+	if err := offenders.Flush(); err != nil {
+		logError(err)
+	}
+	if err := saveRateLimits(rateLimits, rateLimitFile); err != nil {
+		logError(err)
+	}
+	if err := writeReport(cfg.ReportFile, app.Snapshot()); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// storeInterval is the time between two writes of the parsed entries to
+// the -store database.
+const storeInterval = time.Second
+
+// digestInterval is the period covered by -smtp-digest reports.
+const digestInterval = 24 * time.Hour
+
+// logError logs an error that does not stop the program.
+func logError(err error) {
+	log.Printf("Error: %v", err)
+}
+
+// reportTop is the number of entries per table in Markdown reports.
+const reportTop = 10
+
+// writeReport writes a Markdown report of s to path, or to stdout if path
+// is "-". It does nothing if path is empty.
+func writeReport(path string, s *stats.Snapshot) error {
+	switch path {
+	case "":
+		return nil
+	case "-":
+		return s.WriteMarkdown(os.Stdout, reportTop)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	if err := s.WriteMarkdown(f, reportTop); err != nil {
+		f.Close()
+		return fmt.Errorf("write report %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write report %s: %w", path, err)
+	}
+	log.Printf("Report written to %s", path)
+	return nil
+}
+
+// saveRateLimits writes the last rate limit report on exit, if a report
+// file is set, so that it covers the lines read since the last minute.
+func saveRateLimits(t *ratelimit.Tracker, path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := t.SaveReport(path, time.Now()); err != nil {
+		return fmt.Errorf("ratelimit: %w", err)
+	}
+	return nil
+}
+
+// onDumpSignal calls dump whenever one of the dump signals is received, if
+// a dump file is set.
+func onDumpSignal(path string, dump func()) {
+	if path == "" || len(dumpSignals) == 0 {
+		return
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, dumpSignals...)
+	go func() {
+		for range signals {
+			dump()
+		}
+	}()
+}
+
+// serveHTTP serves handler on addr in the background. Failing to listen,
+// e.g. on an address in use, is fatal.
+func serveHTTP(flagName, addr string, handler http.Handler) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Error: %s: %v", flagName, err)
+	}
+	go http.Serve(listener, handler)
+}
+
+// notifier is an alert destination that delivers the alerts it is told
+// about in the background.
+type notifier interface {
+	alert.Notifier
+	Run(report func(error))
+}
+
+// serveGRPC serves the gRPC API on listener in the background, if any, and
+// passes the error ending it to report.
+func serveGRPC(listener net.Listener, source func() *stats.Snapshot, hub *feed.Hub, report func(error)) {
+	if listener == nil {
+		return
+	}
+	go func() {
+		report(fmt.Errorf("-grpc-listen: %w", grpcapi.NewServer(source, hub).Serve(listener)))
+	}()
+}
+
+// stringList is a flag.Value collecting repeated string flags.
+type stringList []string
+
+// String returns the flag values joined by commas.
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set appends a flag value.
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// Get returns the flag values.
+func (s *stringList) Get() any {
+	return []string(*s)
+}
+
+// checkLoginPath checks an authentication endpoint of -login-path.
+func checkLoginPath(path string) error {
+	if !strings.HasPrefix(path, "/") || strings.Contains(path, "?") {
+		return fmt.Errorf("%q is not a path, e.g. '/wp-login.php'", path)
+	}
+	return nil
 }
 
 // validateLogPath validates that the provided log path is safe to read.
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestMain runs main instead of the tests in the child processes started by
+// command, with a command line of its own flags.
+func TestMain(m *testing.M) {
+	if os.Getenv("TAILNGINX_TEST_MAIN") != "" {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// command returns a command running main with args in a child process.
+func command(args ...string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "TAILNGINX_TEST_MAIN=1")
+	return cmd
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/papaganelli/tailnginx/pkg/exclude"
+	"github.com/papaganelli/tailnginx/pkg/feed"
+	"github.com/papaganelli/tailnginx/pkg/geoip"
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// pipe writes the entries parsed from lines that are not excluded and match
+// all filters to w, one JSON object per line in the format of the HTTP
+// stream. It returns when lines is closed, on SIGINT/SIGTERM or when w
+// fails, e.g. because the reading end of a pipe was closed.
+func pipe(w io.Writer, lines <-chan string, format *parser.Format, excluded *exclude.Rules, geoLocator *geoip.Locator, filters highlight.Rules) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			var v *parser.Visitor
+			if format != nil {
+				v = format.Parse(line)
+			} else {
+				v = parser.Parse(line)
+			}
+			if v == nil || excluded.Match(v) {
+				continue
+			}
+			if geoLocator != nil {
+				if loc, err := geoLocator.Lookup(v.IP); err == nil && loc != nil {
+					v.Country = loc.Country
+				}
+			}
+			if !feed.MatchAll(filters, v) {
+				continue
+			}
+			if err := enc.Encode(feed.NewEntry(v)); err != nil {
+				return err
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/papaganelli/tailnginx/pkg/exclude"
+	"github.com/papaganelli/tailnginx/pkg/feed"
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+)
+
+// TestPipe tests that pipe writes the parsed entries that are not excluded
+// and match the filters as JSON lines, skipping the unparsable lines.
+func TestPipe(t *testing.T) {
+	excluded, err := exclude.New(nil, []string{`^10\.`}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := highlight.ParseCondition("status>=500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := make(chan string, 5)
+	lines <- `203.0.113.7 - - [08/Oct/2025:14:30:00 +0000] "GET /api HTTP/1.1" 502 120 "-" "curl/8.0"`
+	lines <- `203.0.113.8 - - [08/Oct/2025:14:30:01 +0000] "GET / HTTP/1.1" 200 512 "-" "curl/8.0"`
+	lines <- `10.0.0.1 - - [08/Oct/2025:14:30:02 +0000] "GET /api HTTP/1.1" 500 120 "-" "curl/8.0"`
+	lines <- `not a log line`
+	lines <- `198.51.100.9 - - [08/Oct/2025:14:30:03 +0000] "POST /login HTTP/1.1" 503 0 "-" "Mozilla/5.0"`
+	close(lines)
+
+	var out bytes.Buffer
+	if err := pipe(&out, lines, nil, excluded, nil, highlight.Rules{filter}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []feed.Entry
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var e feed.Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 2 {
+		t.Fatalf("pipe wrote %d entries, want 2:\n%s", len(got), out.String())
+	}
+	if got[0].IP != "203.0.113.7" || got[0].Path != "/api" || got[0].Status != 502 || got[0].Bytes != 120 {
+		t.Errorf("first entry = %+v, want the 502 of 203.0.113.7 on /api", got[0])
+	}
+	if got[1].IP != "198.51.100.9" || got[1].Method != "POST" || got[1].Status != 503 {
+		t.Errorf("second entry = %+v, want the 503 of 198.51.100.9", got[1])
+	}
+}
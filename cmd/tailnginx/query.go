@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/store"
+)
+
+// runQuery runs the query subcommand on a database written with -store. It
+// prints the result of an SQL query, e.g.
+//
+//	tailnginx query -store requests.db "SELECT path, COUNT(*) FROM requests GROUP BY path"
+//
+// or, without SQL, the requests matching an expression, optionally grouped:
+//
+//	tailnginx query -store requests.db -where 'status>=500 && path=~"/api"' -since 24h -group-by path
+func runQuery(args []string) {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	path := fs.String("store", "", "SQLite database written with -store")
+	var search store.Search
+	where := fs.String("where", "", "condition on the requests, e.g. 'status>=500 && path=~\"/api\"'")
+	fs.DurationVar(&search.Since, "since", 0, "only the requests of the last duration, e.g. 24h (default: all)")
+	fs.StringVar(&search.GroupBy, "group-by", "", "field to group the requests by, e.g. path, ip, status, hour or day")
+	fs.IntVar(&search.Limit, "limit", 20, "rows printed, 0 for all")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: tailnginx query -store FILE \"SELECT ...\"\n")
+		fmt.Fprintf(out, "       tailnginx query -store FILE [-where EXPR] [-since DURATION] [-group-by FIELD] [-limit N]\n\n")
+		fmt.Fprintf(out, "Runs an SQL query on the requests table of the database, or lists the\n")
+		fmt.Fprintf(out, "requests matching an expression, latest first, or their groups, largest first.\n")
+		fmt.Fprintf(out, "Expressions compare fields (ip, method, path, protocol, status, bytes,\n")
+		fmt.Fprintf(out, "referer, agent, country, request_time, tls_protocol, tls_cipher) with\n")
+		fmt.Fprintf(out, "==, !=, <, <=, >, >=, =~ and !~ (regular expressions), combined with &&, ||,\n")
+		fmt.Fprintf(out, "! and parentheses.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *path == "" || fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	sql := fs.Arg(0)
+	if sql != "" && (*where != "" || search.Since != 0 || search.GroupBy != "") {
+		log.Fatalf("Error: -where, -since and -group-by cannot be combined with SQL")
+	}
+	if *where != "" {
+		expr, err := store.ParseExpr(*where)
+		if err != nil {
+			log.Fatalf("Error: -where: %v", err)
+		}
+		search.Where = expr
+	}
+	if _, err := os.Stat(*path); err != nil {
+		log.Fatalf("Error: -store: %v", err)
+	}
+
+	db, err := store.Open(*path)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	defer db.Close()
+	if sql != "" {
+		err = db.Query(os.Stdout, sql)
+	} else {
+		err = db.Summarize(os.Stdout, search, time.Now())
+	}
+	if err != nil {
+		log.Fatalf("Error: query: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/alert"
+	"github.com/papaganelli/tailnginx/pkg/exclude"
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+)
+
+// reloadOptions are the options applied again when the configuration is
+// reloaded. The others, e.g. the logs, servers and exporters, only apply
+// after a restart.
+var reloadOptions = []string{"highlight", "exclude-path", "exclude-ip", "exclude-agent", "ignore-cidr", "ignore-self", "alert"}
+
+// rules are the highlight, exclude and alert rules built from the
+// reloadable options.
+type rules struct {
+	highlights highlight.Rules
+	exclude    *exclude.Rules
+	alerts     []alert.Rule
+}
+
+// loadRules builds the rules of the reloadable options of flags. Requests
+// logged outside since and until, if set, are excluded too.
+func loadRules(flags *flag.FlagSet, since, until time.Time) (rules, error) {
+	highlights, err := highlight.ParseAll(optionValue[[]string](flags, "highlight"))
+	if err != nil {
+		return rules{}, err
+	}
+
+	excluded, err := exclude.New(optionValue[[]string](flags, "exclude-path"), optionValue[[]string](flags, "exclude-ip"), optionValue[[]string](flags, "exclude-agent"))
+	if err != nil {
+		return rules{}, err
+	}
+	if !since.IsZero() || !until.IsZero() {
+		excluded = excluded.Between(since, until)
+	}
+	ignored, err := exclude.ParseNetworks(optionValue[[]string](flags, "ignore-cidr"))
+	if err != nil {
+		return rules{}, fmt.Errorf("-ignore-cidr: %w", err)
+	}
+	if optionValue[bool](flags, "ignore-self") {
+		local, err := exclude.LocalNetworks()
+		if err != nil {
+			return rules{}, fmt.Errorf("-ignore-self: %w", err)
+		}
+		ignored = append(ignored, local...)
+	}
+	if len(ignored) > 0 {
+		excluded = excluded.Networks(ignored)
+	}
+	alerts, err := alert.ParseRules(optionValue[[]string](flags, "alert"))
+	if err != nil {
+		return rules{}, err
+	}
+	return rules{highlights: highlights, exclude: excluded, alerts: alerts}, nil
+}
+
+// reloadConfig parses the command line args and the configuration file
+// again, and returns the rules of the reloadable options and the changed
+// options that only apply after a restart. flags holds the options in use,
+// which are left as they are.
+func reloadConfig(flags *flag.FlagSet, args []string, path, profile string, since, until time.Time) (rules, []string, error) {
+	fresh, err := emptyFlags(flags)
+	if err != nil {
+		return rules{}, nil, err
+	}
+	if err := fresh.Parse(args); err != nil {
+		return rules{}, nil, err
+	}
+	if err := loadConfigFile(fresh, path, profile); err != nil {
+		return rules{}, nil, err
+	}
+	r, err := loadRules(fresh, since, until)
+	if err != nil {
+		return rules{}, nil, err
+	}
+
+	var restart []string
+	flags.VisitAll(func(f *flag.Flag) {
+		if !slices.Contains(reloadOptions, f.Name) && fresh.Lookup(f.Name).Value.String() != f.Value.String() {
+			restart = append(restart, "-"+f.Name)
+		}
+	})
+	return r, restart, nil
+}
+
+// emptyFlags returns a flag set with the options of flags at their default
+// values, to parse the options again.
+func emptyFlags(flags *flag.FlagSet) (*flag.FlagSet, error) {
+	fresh := flag.NewFlagSet(flags.Name(), flag.ContinueOnError)
+	fresh.SetOutput(io.Discard)
+	var err error
+	lists := make(map[flag.Value]*stringList) // Aliases share their list
+	// Defaults were formatted by the flag package, so they parse back
+	flags.VisitAll(func(f *flag.Flag) {
+		switch f.Value.(flag.Getter).Get().(type) {
+		case []string:
+			if lists[f.Value] == nil {
+				lists[f.Value] = new(stringList)
+			}
+			fresh.Var(lists[f.Value], f.Name, f.Usage)
+		case string:
+			fresh.String(f.Name, f.DefValue, f.Usage)
+		case bool:
+			b, _ := strconv.ParseBool(f.DefValue)
+			fresh.Bool(f.Name, b, f.Usage)
+		case int:
+			n, _ := strconv.Atoi(f.DefValue)
+			fresh.Int(f.Name, n, f.Usage)
+		case float64:
+			x, _ := strconv.ParseFloat(f.DefValue, 64)
+			fresh.Float64(f.Name, x, f.Usage)
+		case time.Duration:
+			d, _ := time.ParseDuration(f.DefValue)
+			fresh.Duration(f.Name, d, f.Usage)
+		default:
+			err = fmt.Errorf("option -%s cannot be reloaded", f.Name)
+		}
+	})
+	return fresh, err
+}
+
+// optionValue returns the value of an option of flags, e.g. a string, a
+// duration or, for repeatable options, a []string.
+func optionValue[T any](flags *flag.FlagSet, name string) T {
+	return flags.Lookup(name).Value.(flag.Getter).Get().(T)
+}
+
+// onReloadSignal calls reload whenever one of the reload signals is
+// received.
+func onReloadSignal(reload func()) {
+	if len(reloadSignals) == 0 {
+		return
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, reloadSignals...)
+	go func() {
+		for range signals {
+			reload()
+		}
+	}()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReloadConfig tests that the configuration of a headless run is
+// reloaded on the reload signal, over the options of its command line, and
+// that the changed options needing a restart are reported.
+func TestReloadConfig(t *testing.T) {
+	if len(reloadSignals) == 0 {
+		t.Skip("no reload signal on this platform")
+	}
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "access.log")
+	configFile := filepath.Join(dir, "config.yaml")
+	writeFile(t, logFile, "")
+	writeFile(t, configFile, "ratelimit-rps: 5\n")
+	ready := listenNotify(t)
+
+	cmd := command("-headless", "-no-geoip", "-log", logFile, "-config", configFile, "-interval", "1h")
+	cmd.Env = append(cmd.Env, "NOTIFY_SOCKET="+ready.LocalAddr().String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	waitReady(t, ready)
+
+	writeFile(t, configFile, "ratelimit-rps: 7.5\ntop: 3\nalert: rps > 100\n")
+	if err := cmd.Process.Signal(reloadSignals[0]); err != nil {
+		t.Fatal(err)
+	}
+	waitReady(t, ready)
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("headless run: %v\n%s", err, stderr.String())
+	}
+
+	logged := stderr.String()
+	if strings.Contains(logged, "Error") || !strings.Contains(logged, "Configuration reloaded") {
+		t.Errorf("reload logged %q, want the configuration reloaded", logged)
+	}
+	if !strings.Contains(logged, "restart to apply -ratelimit-rps, -top") {
+		t.Errorf("reload logged %q, want a restart for -ratelimit-rps and -top", logged)
+	}
+}
+
+// TestReloadInvalidConfig tests that a headless run keeps its configuration
+// when the reloaded one is invalid, and reloads it once it is fixed.
+func TestReloadInvalidConfig(t *testing.T) {
+	if len(reloadSignals) == 0 {
+		t.Skip("no reload signal on this platform")
+	}
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "access.log")
+	configFile := filepath.Join(dir, "config.yaml")
+	writeFile(t, logFile, "")
+	writeFile(t, configFile, "alert: rps > 100\n")
+	ready := listenNotify(t)
+
+	cmd := command("-headless", "-no-geoip", "-log", logFile, "-config", configFile, "-interval", "1h")
+	cmd.Env = append(cmd.Env, "NOTIFY_SOCKET="+ready.LocalAddr().String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	waitReady(t, ready)
+
+	for _, config := range []string{"alert: rps >\n", "alert: 5xx_rate > 5%\n"} {
+		writeFile(t, configFile, config)
+		if err := cmd.Process.Signal(reloadSignals[0]); err != nil {
+			t.Fatal(err)
+		}
+		waitReady(t, ready)
+	}
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("headless run: %v\n%s", err, stderr.String())
+	}
+
+	logged := stderr.String()
+	kept := strings.Index(logged, "keeping the current configuration")
+	reloaded := strings.Index(logged, "Configuration reloaded")
+	if kept < 0 || reloaded < kept {
+		t.Errorf("reloads logged %q, want the invalid configuration kept out, then the fixed one reloaded", logged)
+	}
+}
+
+// listenNotify returns a socket receiving the notifications meant for
+// systemd, passed to a child process in $NOTIFY_SOCKET.
+func listenNotify(t *testing.T) *net.UnixConn {
+	t.Helper()
+	// Socket paths are short, which temporary test directories may not be
+	dir, err := os.MkdirTemp("", "tailnginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: filepath.Join(dir, "notify"), Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitReady waits for a child process to notify that it is ready, failing
+// the test after 10 seconds.
+func waitReady(t *testing.T, conn *net.UnixConn) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	buf := make([]byte, 1024)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("waiting for READY=1: %v", err)
+		}
+		if strings.HasPrefix(string(buf[:n]), "READY=1") {
+			return
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// dumpSignals are the signals that write the aggregates to the -dump file.
+// Windows has no SIGUSR1, so the file is only written on exit.
+var dumpSignals []os.Signal
+
+// reloadSignals are the signals that reload the configuration. Windows has
+// no SIGHUP, so a restart is needed.
+var reloadSignals []os.Signal
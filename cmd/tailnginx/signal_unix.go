@@ -0,0 +1,14 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// dumpSignals are the signals that write the aggregates to the -dump file.
+var dumpSignals = []os.Signal{syscall.SIGUSR1}
+
+// reloadSignals are the signals that reload the configuration.
+var reloadSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/tailer"
+)
+
+// timeBoundLayouts are the accepted layouts of -since and -until times, in
+// the local time zone unless they have an offset.
+var timeBoundLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTimeBound parses a -since or -until time: a duration before now such
+// as "2h", or a time such as "2025-10-08 14:30". An empty text is no bound.
+func parseTimeBound(text string, now time.Time) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(text); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("duration %s must be positive", text)
+		}
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeBoundLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected a duration such as 2h or a time such as 2025-10-08 14:30", text)
+}
+
+// openLogs tails the access logs. With a time range, the whole logs and their
+// rotated files are read first, and new lines are only followed when the
+// range is open-ended or ends in the future.
+func openLogs(paths []string, fromEnd bool, since, until time.Time, done <-chan struct{}) (<-chan string, error) {
+	if since.IsZero() && until.IsZero() {
+		return tailer.TailFiles(paths, fromEnd, done)
+	}
+	follow := until.IsZero() || until.After(time.Now())
+	return tailer.TailFilesHistory(paths, since, follow, done)
+}
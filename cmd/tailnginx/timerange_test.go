@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2025, 10, 8, 16, 0, 0, 0, time.Local)
+	tests := []struct {
+		text    string
+		want    time.Time
+		wantErr bool
+	}{
+		{text: "", want: time.Time{}},
+		{text: "  ", want: time.Time{}},
+		{text: "2h", want: now.Add(-2 * time.Hour)},
+		{text: "90m", want: now.Add(-90 * time.Minute)},
+		{text: "0s", want: now},
+		{text: "-1h", wantErr: true},
+		{text: "2025-10-08", want: time.Date(2025, 10, 8, 0, 0, 0, 0, time.Local)},
+		{text: "2025-10-08 14:30", want: time.Date(2025, 10, 8, 14, 30, 0, 0, time.Local)},
+		{text: " 2025-10-08 14:30:15 ", want: time.Date(2025, 10, 8, 14, 30, 15, 0, time.Local)},
+		{text: "2025-10-08T14:30:15", want: time.Date(2025, 10, 8, 14, 30, 15, 0, time.Local)},
+		{text: "2025-10-08T14:30:15+02:00", want: time.Date(2025, 10, 8, 12, 30, 15, 0, time.UTC)},
+		{text: "2025-10-08T14:30:15Z", want: time.Date(2025, 10, 8, 14, 30, 15, 0, time.UTC)},
+		{text: "yesterday", wantErr: true},
+		{text: "2025-13-01", wantErr: true},
+		{text: "14:30", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeBound(tt.text, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTimeBound(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeBound(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
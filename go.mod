@@ -4,6 +4,7 @@ go 1.24.5
 
 require (
 	github.com/gdamore/tcell/v2 v2.8.1
+	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/nxadm/tail v1.4.11
 	github.com/phuslu/iploc v1.0.20251001
 	github.com/rivo/tview v0.42.0
@@ -11,6 +11,49 @@ const MaxRefreshRate = 10 * time.Second
 // Config holds runtime configuration for the monitoring app.
 type Config struct {
 	LogPath     string
+	LogPaths    []string // Access logs tailed together, LogPath first
 	FromEnd     bool
 	RefreshRate time.Duration
+	Interval    time.Duration
+	FlushPeriod time.Duration
+	Highlights  []string // Highlight rules, e.g. "status>=500 -> red background"
+	LogFormats  []string // nginx log_format definitions of the logs, empty for combined
+	ErrorLog    string   // nginx error log path, auto-detected when empty
+	Watch       []string // Watched IPs and paths, e.g. "203.0.113.7" or "/wp-login.php"
+	TopN        int      // Rows in top N tables, 0 to fit the panel height
+	ExportDir   string   // Directory for exported snapshots
+	Stream      []string // Live stream columns, e.g. "time", "ip", "path"
+	Plain       bool     // ASCII-only rendering, without emoji
+	Accessible  bool     // Linear text rendering for screen readers
+	Mini        string   // Single panel shown instead of the dashboard
+	MetricsAddr string   // Listen address of the Prometheus endpoint, empty to disable
+	APIAddr     string   // Listen address of the HTTP API, empty to disable
+	DebugAddr   string   // Listen address of pprof and the internal stats page, empty to disable
+	GRPCAddr    string   // Listen address of the gRPC API, empty to disable
+	StatsDAddr  string   // StatsD server address, empty to disable
+	Influx      string   // InfluxDB write URL or line protocol file, empty to disable
+	Graphite    string   // Graphite server address, empty to disable
+	Kafka       string   // Comma-separated Kafka brokers, empty to disable
+	KafkaTopic  string   // Kafka topic parsed entries are published to
+	Loki        string   // Loki push API URL, empty to disable
+	LokiLabels  []string // Static labels of the Loki streams, e.g. "env=prod"
+	MQTT        string   // MQTT broker stats snapshots are published to, empty to disable
+	PushTo      string   // Base URL of a central instance aggregates are pushed to, empty to disable
+	StoreFile   string   // SQLite database parsed entries are stored in, empty to disable
+	DumpFile    string   // JSON file the aggregates are written to on exit
+	ReportFile  string   // Markdown report written on exit, "-" for stdout
+	ReportDir   string   // Directory periodic JSON and HTML reports are written to, empty to disable
+	Webhooks    []string // URLs warning and critical alerts are posted to
+	Slack       string   // Slack incoming webhook URL, empty to disable
+	SlackRoutes []string // Slack destinations of the alerts of a rule, e.g. "5xx_spike=#oncall"
+	Discord     string   // Discord webhook URL, empty to disable
+	SMTPAddr    string   // SMTP server alert emails are sent through, empty to disable
+	PagerDuty   string   // PagerDuty Events API v2 routing key, empty to disable
+	Opsgenie    string   // Opsgenie API integration key, empty to disable
+	Desktop     string   // Desktop notification method: "osc" or "notify-send", empty to disable
+	BanFile     string   // File offending IPs are appended to for fail2ban, empty to disable
+	DenyFile    string   // nginx include the b key writes the deny list of offending IPs to
+	Output      string   // Entries written to stdout instead of the dashboard: "jsonl", empty for none
+	Filters     []string // Conditions entries written to stdout must all match, e.g. "status>=500"
+	Headless    bool     // Periodic summaries every Interval instead of the dashboard
 }
@@ -0,0 +1,419 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// File is a configuration file: command-line options with their values, in
+// a subset of YAML:
+//
+//	# Comments start with #
+//	log: /var/log/nginx/access.log
+//	refresh: 500
+//	headless: true
+//	highlight:
+//	  - "status>=500 -> red background"
+//	  - "path prefix /admin -> bold"
+//	loki-label: [env=prod, "team=web"]
+//
+// Keys are option names without the dash. Values are plain, single-quoted
+// or double-quoted scalars, or lists of them for repeatable options.
+//
+// Profiles set options of their own, e.g. for one of several sites:
+//
+//	profiles:
+//	  shop:
+//	    log: /var/log/nginx/shop.access.log
+//	    exclude-path: ['^/healthz$']
+type File struct {
+	Path     string
+	Options  []Option  // In file order
+	Profiles []Profile // In file order
+}
+
+// Profile is a named set of options of a configuration file.
+type Profile struct {
+	Name    string
+	Options []Option
+	Line    int
+}
+
+// Option is an option set in a configuration file.
+type Option struct {
+	Name   string
+	Values []string // One value, or the items of a list
+	Line   int
+}
+
+// DefaultFile returns the location of the configuration file in the user's
+// config directory, e.g. ~/.config/tailnginx/config.yaml.
+func DefaultFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tailnginx", "config.yaml"), nil
+}
+
+// LoadFile reads and parses a configuration file.
+func LoadFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	file, err := ParseFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	file.Path = path
+	return file, nil
+}
+
+// ParseFile parses a configuration file.
+func ParseFile(r io.Reader) (*File, error) {
+	root, err := parseYAML(r)
+	if err != nil {
+		return nil, err
+	}
+	file := &File{}
+	for _, e := range root.entries {
+		if e.key != "profiles" {
+			o, err := newOption(e)
+			if err != nil {
+				return nil, err
+			}
+			file.Options = append(file.Options, o)
+			continue
+		}
+		if e.value.mapping == nil {
+			return nil, fmt.Errorf("line %d: profiles must be a mapping of profile names to options", e.line)
+		}
+		for _, p := range e.value.mapping.entries {
+			if p.value.mapping == nil {
+				return nil, fmt.Errorf("line %d: profile %s must be a mapping of options", p.line, p.key)
+			}
+			profile := Profile{Name: p.key, Line: p.line}
+			for _, pe := range p.value.mapping.entries {
+				o, err := newOption(pe)
+				if err != nil {
+					return nil, err
+				}
+				profile.Options = append(profile.Options, o)
+			}
+			file.Profiles = append(file.Profiles, profile)
+		}
+	}
+	return file, nil
+}
+
+// newOption returns the option of a mapping entry.
+func newOption(e yamlEntry) (Option, error) {
+	if e.value.mapping != nil {
+		return Option{}, fmt.Errorf("line %d: %s: nested settings are not supported", e.line, e.key)
+	}
+	return Option{Name: e.key, Values: e.value.values(), Line: e.line}, nil
+}
+
+// WithProfile returns the options of the file with those of a profile: the
+// profile's replace the file's options of the same name, lists included.
+func (f *File) WithProfile(name string) ([]Option, error) {
+	i := slices.IndexFunc(f.Profiles, func(p Profile) bool { return p.Name == name })
+	if i < 0 {
+		var names []string
+		for _, p := range f.Profiles {
+			names = append(names, p.Name)
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%s: unknown profile %q, no profiles defined", f.Path, name)
+		}
+		return nil, fmt.Errorf("%s: unknown profile %q, expected one of %s", f.Path, name, strings.Join(names, ", "))
+	}
+
+	profile := f.Profiles[i]
+	set := make(map[string]bool)
+	for _, o := range profile.Options {
+		set[o.Name] = true
+	}
+	var options []Option
+	for _, o := range f.Options {
+		if !set[o.Name] {
+			options = append(options, o)
+		}
+	}
+	return append(options, profile.Options...), nil
+}
+
+// yamlValue is a scalar, a list of scalars or a mapping.
+type yamlValue struct {
+	scalar  string
+	list    []string
+	isList  bool
+	mapping *yamlMapping
+}
+
+// values returns the scalar or the items of a list.
+func (v yamlValue) values() []string {
+	if v.isList {
+		return v.list
+	}
+	return []string{v.scalar}
+}
+
+// yamlMapping is a mapping in file order.
+type yamlMapping struct {
+	entries []yamlEntry
+}
+
+type yamlEntry struct {
+	key   string
+	value yamlValue
+	line  int
+}
+
+// yamlLine is a line with content, without its comment.
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+// parseYAML parses the YAML subset of configuration files: nested mappings
+// of scalars and lists of scalars, without anchors, multi-line strings or
+// multiple documents.
+func parseYAML(r io.Reader) (*yamlMapping, error) {
+	var lines []yamlLine
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		raw := strings.TrimRight(scanner.Text(), " \r")
+		content := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: tabs cannot indent YAML", n)
+		}
+		text, err := stripComment(content)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if text == "" || (n == 1 && text == "---") {
+			continue
+		}
+		lines = append(lines, yamlLine{number: n, indent: len(raw) - len(content), text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 && lines[0].indent > 0 {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[0].number)
+	}
+	m, rest, err := parseMapping(lines, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("line %d: unexpected indentation", rest[0].number)
+	}
+	return m, nil
+}
+
+// parseMapping parses the entries of a mapping indented by indent, and
+// returns the lines after it.
+func parseMapping(lines []yamlLine, indent int) (*yamlMapping, []yamlLine, error) {
+	m := &yamlMapping{}
+	seen := make(map[string]bool)
+	for len(lines) > 0 && lines[0].indent == indent {
+		l := lines[0]
+		lines = lines[1:]
+		if strings.HasPrefix(l.text, "- ") || l.text == "-" {
+			return nil, nil, fmt.Errorf("line %d: list item without a key", l.number)
+		}
+		key, rest, ok := cutKey(l.text)
+		if !ok {
+			return nil, nil, fmt.Errorf("line %d: expected \"key: value\"", l.number)
+		}
+		if seen[key] {
+			return nil, nil, fmt.Errorf("line %d: %s set twice", l.number, key)
+		}
+		seen[key] = true
+		entry := yamlEntry{key: key, line: l.number}
+
+		switch {
+		case rest != "":
+			v, err := parseInline(rest)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %s: %w", l.number, key, err)
+			}
+			entry.value = v
+		case len(lines) > 0 && lines[0].indent >= indent && isListItem(lines[0].text):
+			// Block list, indented or not
+			itemIndent := lines[0].indent
+			entry.value.isList = true
+			for len(lines) > 0 && lines[0].indent == itemIndent && isListItem(lines[0].text) {
+				item, err := parseScalar(strings.TrimSpace(strings.TrimPrefix(lines[0].text, "-")))
+				if err != nil {
+					return nil, nil, fmt.Errorf("line %d: %s: %w", lines[0].number, key, err)
+				}
+				entry.value.list = append(entry.value.list, item)
+				lines = lines[1:]
+			}
+		case len(lines) > 0 && lines[0].indent > indent:
+			sub, rest, err := parseMapping(lines, lines[0].indent)
+			if err != nil {
+				return nil, nil, err
+			}
+			entry.value.mapping = sub
+			lines = rest
+		default:
+			// An empty value, e.g. to clear an option
+		}
+		m.entries = append(m.entries, entry)
+	}
+	if len(lines) > 0 && lines[0].indent > indent {
+		return nil, nil, fmt.Errorf("line %d: unexpected indentation", lines[0].number)
+	}
+	return m, lines, nil
+}
+
+func isListItem(text string) bool {
+	return strings.HasPrefix(text, "- ") || text == "-"
+}
+
+// cutKey splits "key: value" into a plain key and the rest of the line.
+func cutKey(text string) (key, rest string, ok bool) {
+	i := strings.Index(text, ":")
+	for i >= 0 && i+1 < len(text) && text[i+1] != ' ' {
+		j := strings.Index(text[i+1:], ":")
+		if j < 0 {
+			return "", "", false
+		}
+		i += 1 + j
+	}
+	if i <= 0 {
+		return "", "", false
+	}
+	key = strings.TrimSpace(text[:i])
+	if strings.ContainsAny(key, `"'[]{}#`) {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(text[i+1:]), true
+}
+
+// parseInline parses a scalar or a flow list such as [a, "b, c"].
+func parseInline(text string) (yamlValue, error) {
+	if !strings.HasPrefix(text, "[") {
+		s, err := parseScalar(text)
+		return yamlValue{scalar: s}, err
+	}
+	if !strings.HasSuffix(text, "]") {
+		return yamlValue{}, errors.New("unterminated list")
+	}
+	v := yamlValue{isList: true}
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	for inner != "" {
+		item, rest := inner, ""
+		if inner[0] == '"' || inner[0] == '\'' {
+			end := closingQuote(inner)
+			if end < 0 {
+				return yamlValue{}, errors.New("unterminated string")
+			}
+			item, rest = inner[:end+1], strings.TrimSpace(inner[end+1:])
+			if rest != "" && rest[0] != ',' {
+				return yamlValue{}, errors.New("expected , between list items")
+			}
+		} else if i := strings.Index(inner, ","); i >= 0 {
+			item, rest = inner[:i], inner[i:]
+		}
+		s, err := parseScalar(strings.TrimSpace(item))
+		if err != nil {
+			return yamlValue{}, err
+		}
+		v.list = append(v.list, s)
+		inner = strings.TrimSpace(strings.TrimPrefix(rest, ","))
+	}
+	return v, nil
+}
+
+// parseScalar parses a plain or quoted scalar.
+func parseScalar(text string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	switch text[0] {
+	case '"':
+		if closingQuote(text) != len(text)-1 {
+			return "", errors.New("unterminated string")
+		}
+		var b strings.Builder
+		for i := 1; i < len(text)-1; i++ {
+			c := text[i]
+			if c != '\\' {
+				b.WriteByte(c)
+				continue
+			}
+			i++
+			switch text[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '/':
+				b.WriteByte(text[i])
+			default:
+				return "", fmt.Errorf("unsupported escape \\%c", text[i])
+			}
+		}
+		return b.String(), nil
+	case '\'':
+		if closingQuote(text) != len(text)-1 {
+			return "", errors.New("unterminated string")
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case '|', '>', '&', '*', '{', '!':
+		return "", fmt.Errorf("unsupported YAML value %q, quote it", text)
+	}
+	return text, nil
+}
+
+// closingQuote returns the index of the quote closing the string text
+// starts with, or -1.
+func closingQuote(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment removes a comment from a line, outside quoted strings. A #
+// starts a comment at the start of the line or after a space.
+func stripComment(text string) (string, error) {
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '"' || c == '\'':
+			if i > 0 && !strings.ContainsRune(" [,:-", rune(text[i-1])) {
+				continue // Quote inside a plain scalar, e.g. it's
+			}
+			end := closingQuote(text[i:])
+			if end < 0 {
+				return "", errors.New("unterminated string")
+			}
+			i += end
+		case c == '#' && (i == 0 || text[i-1] == ' '):
+			return strings.TrimRight(text[:i], " "), nil
+		}
+	}
+	return text, nil
+}
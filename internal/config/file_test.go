@@ -0,0 +1,131 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	input := `---
+# Dashboard
+log: /var/log/nginx/access.log   # the main site
+refresh: 500
+headless: true
+log-format: '$remote_addr - [$time_local] "$request" $status'
+mini: "rate"
+highlight:
+  - "status>=500 -> red background"
+  - path prefix /admin -> bold
+watch:
+- 203.0.113.7
+loki-label: [env=prod, "team=web, ops", 'it''s']
+export-dir:
+slack: https://hooks.slack.com/services/T0#x
+`
+	file, err := ParseFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	want := []Option{
+		{Name: "log", Values: []string{"/var/log/nginx/access.log"}, Line: 3},
+		{Name: "refresh", Values: []string{"500"}, Line: 4},
+		{Name: "headless", Values: []string{"true"}, Line: 5},
+		{Name: "log-format", Values: []string{`$remote_addr - [$time_local] "$request" $status`}, Line: 6},
+		{Name: "mini", Values: []string{"rate"}, Line: 7},
+		{Name: "highlight", Values: []string{"status>=500 -> red background", "path prefix /admin -> bold"}, Line: 8},
+		{Name: "watch", Values: []string{"203.0.113.7"}, Line: 11},
+		{Name: "loki-label", Values: []string{"env=prod", "team=web, ops", "it's"}, Line: 13},
+		{Name: "export-dir", Values: []string{""}, Line: 14},
+		{Name: "slack", Values: []string{"https://hooks.slack.com/services/T0#x"}, Line: 15},
+	}
+	if !reflect.DeepEqual(file.Options, want) {
+		t.Errorf("Options = %+v\nwant %+v", file.Options, want)
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"no key", "just text\n", "line 1"},
+		{"duplicate", "log: a\nlog: b\n", "log set twice"},
+		{"nested", "alerts:\n  rate: 10\n", "nested settings are not supported"},
+		{"profiles list", "profiles: [shop]\n", "profiles must be a mapping"},
+		{"profile scalar", "profiles:\n  shop: 1\n", "profile shop must be a mapping"},
+		{"profile nested", "profiles:\n  shop:\n    alerts:\n      rate: 1\n", "nested settings are not supported"},
+		{"indent", "log: a\n  refresh: 5\n", "unexpected indentation"},
+		{"tab", "log: a\n\trefresh: 5\n", "tabs"},
+		{"unterminated", "log: \"a\n", "unterminated string"},
+		{"block string", "log-format: |\n", "unsupported YAML value"},
+		{"list item", "- a\n", "list item without a key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFile(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseFile() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("refresh: 250\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if file.Path != path || len(file.Options) != 1 || file.Options[0].Values[0] != "250" {
+		t.Errorf("LoadFile() = %+v", file)
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); !os.IsNotExist(err) {
+		t.Errorf("LoadFile(missing) error = %v, want not exist", err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	input := `refresh: 500
+log: /var/log/nginx/access.log
+exclude-path: ['^/healthz$']
+profiles:
+  shop:
+    log:
+      - /var/log/nginx/shop.access.log
+      - /var/log/nginx/shop-api.access.log
+    exclude-agent: UptimeRobot
+  blog:
+    log: /var/log/nginx/blog.access.log
+`
+	file, err := ParseFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if len(file.Options) != 3 || len(file.Profiles) != 2 || file.Profiles[1].Name != "blog" {
+		t.Fatalf("ParseFile() = %+v", file)
+	}
+
+	options, err := file.WithProfile("shop")
+	if err != nil {
+		t.Fatalf("WithProfile() error = %v", err)
+	}
+	want := []Option{
+		{Name: "refresh", Values: []string{"500"}, Line: 1},
+		{Name: "exclude-path", Values: []string{"^/healthz$"}, Line: 3},
+		{Name: "log", Values: []string{"/var/log/nginx/shop.access.log", "/var/log/nginx/shop-api.access.log"}, Line: 6},
+		{Name: "exclude-agent", Values: []string{"UptimeRobot"}, Line: 9},
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("WithProfile() = %+v\nwant %+v", options, want)
+	}
+
+	if _, err := file.WithProfile("wiki"); err == nil || !strings.Contains(err.Error(), "shop, blog") {
+		t.Errorf("WithProfile(unknown) error = %v, want the profile names", err)
+	}
+}
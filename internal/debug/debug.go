@@ -0,0 +1,128 @@
+// Package debug serves the profiles and internal statistics of the running
+// process, to diagnose tailnginx itself on busy servers.
+package debug
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// queue is a channel or buffer whose depth is shown on the stats page.
+type queue struct {
+	name  string
+	depth func() (length, capacity int)
+}
+
+// counter is a running total shown on the stats page.
+type counter struct {
+	name  string
+	count func() uint64
+}
+
+// Server serves net/http/pprof under /debug/pprof/ and a plain text page of
+// the goroutines, memory, queue depths and counters at /debug/stats. It is
+// safe for concurrent use.
+type Server struct {
+	mu       sync.Mutex
+	start    time.Time
+	queues   []queue
+	counters []counter
+	mux      *http.ServeMux
+}
+
+// NewServer creates a server without queues.
+func NewServer() *Server {
+	s := &Server{start: time.Now(), mux: http.NewServeMux()}
+	s.mux.HandleFunc("/debug/pprof/", pprof.Index)
+	s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	s.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	s.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	s.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	s.mux.HandleFunc("/debug/stats", s.serveStats)
+	return s
+}
+
+// AddQueue shows the depth of a queue on the stats page, e.g. of the
+// channel of log lines waiting to be parsed. A nil Server ignores it.
+func (s *Server) AddQueue(name string, depth func() (length, capacity int)) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.queues = append(s.queues, queue{name: name, depth: depth})
+	s.mu.Unlock()
+}
+
+// AddCounter shows a running total on the stats page, e.g. of the entries
+// dropped for slow feed subscribers. A nil Server ignores it.
+func (s *Server) AddCounter(name string, count func() uint64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.counters = append(s.counters, counter{name: name, count: count})
+	s.mu.Unlock()
+}
+
+// Handle serves another handler on the debug address, e.g. a health check.
+func (s *Server) Handle(pattern string, h http.Handler) {
+	if s == nil {
+		return
+	}
+	s.mux.Handle(pattern, h)
+}
+
+// ServeHTTP serves the profiles and the stats page.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) serveStats(w http.ResponseWriter, r *http.Request) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	s.mu.Lock()
+	queues, counters := s.queues, s.counters
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "uptime\t%s\n", time.Since(s.start).Round(time.Second))
+	fmt.Fprintf(tw, "goroutines\t%d\n", runtime.NumGoroutine())
+	fmt.Fprintf(tw, "gomaxprocs\t%d\n", runtime.GOMAXPROCS(0))
+	fmt.Fprintf(tw, "heap_alloc\t%s\n", formatBytes(m.HeapAlloc))
+	fmt.Fprintf(tw, "heap_inuse\t%s\n", formatBytes(m.HeapInuse))
+	fmt.Fprintf(tw, "heap_objects\t%d\n", m.HeapObjects)
+	fmt.Fprintf(tw, "sys\t%s\n", formatBytes(m.Sys))
+	fmt.Fprintf(tw, "gc_cycles\t%d\n", m.NumGC)
+	fmt.Fprintf(tw, "gc_pause_total\t%s\n", time.Duration(m.PauseTotalNs))
+	if m.LastGC > 0 {
+		fmt.Fprintf(tw, "gc_last\t%s ago\n", time.Since(time.Unix(0, int64(m.LastGC))).Round(time.Millisecond))
+	}
+	for _, q := range queues {
+		length, capacity := q.depth()
+		fmt.Fprintf(tw, "queue %s\t%d/%d\n", q.name, length, capacity)
+	}
+	for _, c := range counters {
+		fmt.Fprintf(tw, "%s\t%d\n", c.name, c.count())
+	}
+	tw.Flush()
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "12.3 MiB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,65 @@
+package debug
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	lines := make(chan string, 10)
+	lines <- "a"
+	lines <- "b"
+	s.AddQueue("lines", func() (int, int) { return len(lines), cap(lines) })
+	s.AddCounter("feed_dropped", func() uint64 { return 42 })
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s = %s", path, resp.Status)
+		}
+		return string(body)
+	}
+
+	stats := get("/debug/stats")
+	for _, want := range []string{"goroutines", "heap_alloc", "gc_cycles", "queue lines", "2/10", "feed_dropped", " 42\n"} {
+		if !strings.Contains(stats, want) {
+			t.Errorf("stats page lacks %q:\n%s", want, stats)
+		}
+	}
+	if index := get("/debug/pprof/"); !strings.Contains(index, "goroutine") {
+		t.Errorf("pprof index = %q", index)
+	}
+	if dump := get("/debug/pprof/goroutine?debug=1"); !strings.Contains(dump, "goroutine profile") {
+		t.Errorf("goroutine profile = %.100q", dump)
+	}
+
+	var nilServer *Server
+	nilServer.AddQueue("lines", nil)
+	nilServer.AddCounter("feed_dropped", nil)
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		512:             "512 B",
+		2048:            "2.0 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 30:         "3.0 GiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
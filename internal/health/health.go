@@ -0,0 +1,186 @@
+// Package health reports whether the log is still being tailed and parsed,
+// for the /healthz endpoint of the embedded servers, so that an orchestrator
+// can restart a wedged instance.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// stallAfter is how long the log may be written to without a line being
+	// read before the instance is reported as wedged.
+	stallAfter = time.Minute
+	// rateMinutes is the number of minutes the parse failure rate covers.
+	rateMinutes = 5
+	// maxFailureRate is the parse failure rate above which the instance is
+	// unhealthy, e.g. after a change of log_format.
+	maxFailureRate = 0.5
+	// minRateLines is the number of recent lines below which the parse
+	// failure rate is not checked.
+	minRateLines = 20
+)
+
+// minute counts the lines read in a minute.
+type minute struct {
+	start    int64 // Unix minute
+	lines    int
+	failures int
+}
+
+// Monitor follows the lines read from a log. A nil Monitor ignores them, so
+// it can be passed around when no server is enabled. It is safe for
+// concurrent use.
+type Monitor struct {
+	mu       sync.Mutex
+	logPath  string
+	maxAge   time.Duration // Longest time without a line, 0 for no limit
+	start    time.Time
+	lastLine time.Time
+	lines    uint64
+	failures uint64
+	minutes  [rateMinutes]minute
+	stopped  bool
+	dropped  func() uint64 // Entries missed by slow feed subscribers, nil without a feed
+}
+
+// NewMonitor creates a monitor of the tailing of the log at logPath. With a
+// positive maxAge, the instance is unhealthy when no line was read for that
+// long, e.g. for sites that are never idle.
+func NewMonitor(logPath string, maxAge time.Duration) *Monitor {
+	return &Monitor{logPath: logPath, maxAge: maxAge, start: time.Now()}
+}
+
+// Line records a non-empty line read from the log, parsed or not.
+func (m *Monitor) Line(parsed bool) {
+	if m == nil {
+		return
+	}
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastLine = now
+	m.lines++
+	b := m.minute(now)
+	b.lines++
+	if !parsed {
+		m.failures++
+		b.failures++
+	}
+}
+
+// minute returns the counts of the minute of now, reset if it held an older
+// minute. Caller must hold the lock.
+func (m *Monitor) minute(now time.Time) *minute {
+	start := now.Unix() / 60
+	b := &m.minutes[start%rateMinutes]
+	if b.start != start {
+		*b = minute{start: start}
+	}
+	return b
+}
+
+// Stopped records that the log is no longer tailed, e.g. after an error.
+func (m *Monitor) Stopped() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.stopped = true
+	m.mu.Unlock()
+}
+
+// SetFeedDropped reports the number of entries missed by slow subscribers
+// of the feed, e.g. Kafka or Loki, in the health report.
+func (m *Monitor) SetFeedDropped(dropped func() uint64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.dropped = dropped
+	m.mu.Unlock()
+}
+
+// Report is the health of an instance.
+type Report struct {
+	Status           string     `json:"status"`             // "ok" or "unhealthy"
+	Problems         []string   `json:"problems,omitempty"` // Why the instance is unhealthy
+	Tail             string     `json:"tail"`               // "running" or "stopped"
+	Log              string     `json:"log"`
+	LastLine         *time.Time `json:"last_line,omitempty"`
+	LastLineAge      float64    `json:"last_line_age_seconds"` // Since startup when no line was read
+	Lines            uint64     `json:"lines"`
+	ParseFailures    uint64     `json:"parse_failures"`
+	ParseFailureRate float64    `json:"parse_failure_rate"` // Over the last 5 minutes, from 0 to 1
+	FeedDropped      uint64     `json:"feed_dropped"`       // Entries missed by slow feed subscribers
+}
+
+// Check returns the health of the instance at now.
+func (m *Monitor) Check(now time.Time) Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := Report{Tail: "running", Log: m.logPath, Lines: m.lines, ParseFailures: m.failures}
+	if m.dropped != nil {
+		r.FeedDropped = m.dropped()
+	}
+	last := m.start
+	if !m.lastLine.IsZero() {
+		last = m.lastLine
+		r.LastLine = &m.lastLine
+	}
+	age := now.Sub(last)
+	r.LastLineAge = age.Round(time.Millisecond).Seconds()
+
+	var lines, failures int
+	for _, b := range m.minutes {
+		if b.start > now.Unix()/60-rateMinutes {
+			lines += b.lines
+			failures += b.failures
+		}
+	}
+	if lines > 0 {
+		r.ParseFailureRate = float64(failures) / float64(lines)
+	}
+
+	if m.stopped {
+		r.Tail = "stopped"
+		r.Problems = append(r.Problems, "tailing stopped")
+	}
+	if info, err := os.Stat(m.logPath); err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("log: %v", err))
+	} else if written := info.ModTime(); written.Sub(last) > stallAfter {
+		r.Problems = append(r.Problems, fmt.Sprintf("log written %s ago but no line read for %s",
+			now.Sub(written).Round(time.Second), age.Round(time.Second)))
+	}
+	if m.maxAge > 0 && age > m.maxAge {
+		r.Problems = append(r.Problems, fmt.Sprintf("no line read for %s", age.Round(time.Second)))
+	}
+	if lines >= minRateLines && r.ParseFailureRate > maxFailureRate {
+		r.Problems = append(r.Problems, fmt.Sprintf("%.0f%% of recent lines failed to parse", 100*r.ParseFailureRate))
+	}
+	r.Status = "ok"
+	if len(r.Problems) > 0 {
+		r.Status = "unhealthy"
+	}
+	return r
+}
+
+// ServeHTTP serves the health report as JSON, with status 503 Service
+// Unavailable when the instance is unhealthy.
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r := m.Check(time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(r)
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLog(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(path, []byte("line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckHealthy(t *testing.T) {
+	m := NewMonitor(testLog(t), 0)
+	for i := 0; i < 30; i++ {
+		m.Line(i%10 != 0)
+	}
+	r := m.Check(time.Now())
+	if r.Status != "ok" || r.Tail != "running" || r.LastLine == nil {
+		t.Errorf("report = %+v, want ok", r)
+	}
+	if r.Lines != 30 || r.ParseFailures != 3 || r.ParseFailureRate != 0.1 {
+		t.Errorf("lines = %d, failures = %d, rate = %v", r.Lines, r.ParseFailures, r.ParseFailureRate)
+	}
+	// Old minutes leave the rate
+	if r := m.Check(time.Now().Add(10 * time.Minute)); r.ParseFailureRate != 0 {
+		t.Errorf("rate 10 minutes later = %v, want 0", r.ParseFailureRate)
+	}
+}
+
+func TestCheckProblems(t *testing.T) {
+	path := testLog(t)
+	m := NewMonitor(path, time.Minute)
+	for i := 0; i < minRateLines; i++ {
+		m.Line(false)
+	}
+	m.Stopped()
+	// Written long after the last line read
+	later := time.Now().Add(10 * time.Minute)
+	os.Chtimes(path, later, later)
+
+	r := m.Check(later)
+	problems := strings.Join(r.Problems, "; ")
+	for _, want := range []string{"tailing stopped", "log written 0s ago but no line read for 10m0s", "no line read for 10m0s"} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("problems %q lack %q", problems, want)
+		}
+	}
+	if r.Status != "unhealthy" || r.Tail != "stopped" {
+		t.Errorf("report = %+v", r)
+	}
+	if r := m.Check(time.Now()); !strings.Contains(strings.Join(r.Problems, "; "), "100% of recent lines failed to parse") {
+		t.Errorf("problems = %q, want the parse failures", r.Problems)
+	}
+
+	os.Remove(path)
+	if r := NewMonitor(path, 0).Check(time.Now()); r.Status != "unhealthy" || !strings.HasPrefix(r.Problems[0], "log: ") {
+		t.Errorf("missing log report = %+v", r)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	m := NewMonitor(testLog(t), 0)
+	m.SetFeedDropped(func() uint64 { return 7 })
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	var r Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || r.Status != "ok" || r.FeedDropped != 7 {
+		t.Errorf("GET /healthz = %d %+v", rec.Code, r)
+	}
+
+	m.Stopped()
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /healthz after stopping = %d, want 503", rec.Code)
+	}
+
+	var nilMonitor *Monitor
+	nilMonitor.Line(true)
+	nilMonitor.Stopped()
+	nilMonitor.SetFeedDropped(nil)
+}
@@ -0,0 +1,80 @@
+// Package state persists UI preferences across sessions.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// State holds the preferences kept between sessions.
+type State struct {
+	HiddenPanels []string          `json:"hidden_panels,omitempty"` // Panel IDs hidden from the dashboard views
+	Layouts      map[string]Layout `json:"layouts,omitempty"`       // Panel sizes by dashboard view name
+	Session      Session           `json:"session"`                 // What was shown when the last session ended
+}
+
+// Session holds what the dashboard showed when a session ended, so that the
+// next one starts where it left off.
+type Session struct {
+	View          string `json:"view,omitempty"`           // Dashboard view name
+	WindowMinutes int    `json:"window_minutes,omitempty"` // Time window, 0 for all time
+	Status        string `json:"status,omitempty"`         // Status filter, e.g. "5xx" or "404"
+	Path          string `json:"path,omitempty"`           // Path prefix filter
+	IP            string `json:"ip,omitempty"`             // IP network filter, e.g. "192.0.2.0/24"
+	DisplayMode   string `json:"display_mode,omitempty"`   // Table values: "counts", "percentages" or "bars"
+}
+
+// Layout holds the panel sizes of a dashboard view adjusted by the user.
+// Weights are relative to the other rows, or the other panels of the row.
+type Layout struct {
+	Rows    map[int]int    `json:"rows,omitempty"`    // Row height weights by row index
+	Columns map[string]int `json:"columns,omitempty"` // Column width weights by panel ID
+}
+
+// DefaultPath returns the state file location in the user's config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tailnginx", "state.json"), nil
+}
+
+// Load reads the state file. A missing file yields an empty state.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Save writes the state file, creating its directory if needed. The file is
+// replaced atomically so that a crash never leaves it half written.
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
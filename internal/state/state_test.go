@@ -0,0 +1,50 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadMissing(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(s.HiddenPanels) != 0 {
+		t.Errorf("HiddenPanels = %v, want empty", s.HiddenPanels)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tailnginx", "state.json")
+	want := &State{
+		HiddenPanels: []string{"countries", "methods"},
+		Layouts: map[string]Layout{
+			"Dashboard": {Rows: map[int]int{0: 3}, Columns: map[string]int{"paths": 2}},
+		},
+		Session: Session{View: "Traffic", WindowMinutes: 60, Status: "5xx", Path: "/api", IP: "192.0.2.0/24", DisplayMode: "bars"},
+	}
+	if err := want.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() should fail on invalid JSON")
+	}
+}
@@ -0,0 +1,208 @@
+// Package abuse flags the client IPs that exceed request thresholds, e.g.
+// to ban them with fail2ban.
+package abuse
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Detection settings
+const (
+	checkInterval = 5 * time.Second // Interval between deliveries of new offenders
+	flaggedFor    = time.Hour       // How long an offender stays flagged after its last offense
+)
+
+// Flag reasons
+const (
+	ReasonRequests = "requests" // Too many requests in a window
+	ReasonErrors   = "errors"   // Too many 4xx responses in a window, e.g. scanning
+	ReasonScan     = "scan"     // 404 responses for too many distinct paths in a window, e.g. directory brute forcing
+)
+
+// Thresholds are the limits an IP must stay under in every window.
+type Thresholds struct {
+	Requests int // Requests per window, 0 for no limit
+	Errors   int // 4xx responses per window, 0 for no limit
+	Scan     int // Distinct paths answered 404 per window, 0 for no limit
+	Window   time.Duration
+}
+
+// Offender is an IP that exceeded a threshold.
+type Offender struct {
+	IP       string    `json:"ip"`
+	Reason   string    `json:"reason"` // ReasonRequests, ReasonErrors or ReasonScan
+	Requests int       `json:"requests"`
+	Errors   int       `json:"errors"`
+	Paths    int       `json:"paths,omitempty"` // Distinct paths answered 404
+	Window   string    `json:"window"`
+	Time     time.Time `json:"time"` // When it was flagged
+}
+
+// Output receives the offenders flagged since the previous delivery, e.g.
+// to write them to a file.
+type Output func(offenders []Offender) error
+
+// count is the activity of an IP in the current window.
+type count struct {
+	requests int
+	errors   int
+	notFound map[string]bool // Distinct paths answered 404
+	flagged  bool
+}
+
+// Detector counts the requests, 4xx responses and distinct paths answered
+// 404 of each IP in fixed windows and flags the IPs exceeding the thresholds, once per window. A
+// nil Detector ignores everything it is given, so it can be passed around
+// when no threshold is configured. It is safe for concurrent use.
+type Detector struct {
+	mu      sync.Mutex
+	limits  Thresholds
+	start   time.Time // Start of the current window
+	counts  map[string]*count
+	pending []Offender          // Flagged, not yet delivered to the outputs
+	flagged map[string]Offender // Latest offense of each flagged IP
+	outputs []Output
+	now     func() time.Time
+}
+
+// NewDetector creates a detector with the given thresholds and no outputs.
+func NewDetector(limits Thresholds) (*Detector, error) {
+	if limits.Window <= 0 {
+		return nil, errors.New("abuse: window must be positive")
+	}
+	if limits.Requests <= 0 && limits.Errors <= 0 && limits.Scan <= 0 {
+		return nil, errors.New("abuse: no threshold")
+	}
+	return &Detector{
+		limits:  limits,
+		counts:  make(map[string]*count),
+		flagged: make(map[string]Offender),
+		now:     time.Now,
+	}, nil
+}
+
+// AddOutput adds an output the new offenders are handed to. Outputs must
+// be added before Run.
+func (d *Detector) AddOutput(out Output) {
+	d.mu.Lock()
+	d.outputs = append(d.outputs, out)
+	d.mu.Unlock()
+}
+
+// Observe counts a parsed log entry and flags its IP if it exceeds a
+// threshold.
+func (d *Detector) Observe(v *parser.Visitor) {
+	if d == nil || v.IP == "" {
+		return
+	}
+	now := d.now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.start) >= d.limits.Window {
+		d.start = now
+		d.counts = make(map[string]*count)
+	}
+	c := d.counts[v.IP]
+	if c == nil {
+		c = &count{}
+		d.counts[v.IP] = c
+	}
+	c.requests++
+	if v.Status >= 400 && v.Status < 500 {
+		c.errors++
+	}
+	if v.Status == 404 && d.limits.Scan > 0 && !c.flagged {
+		if c.notFound == nil {
+			c.notFound = make(map[string]bool)
+		}
+		c.notFound[v.Path] = true
+	}
+	if c.flagged {
+		return
+	}
+
+	var reason string
+	switch {
+	case d.limits.Requests > 0 && c.requests > d.limits.Requests:
+		reason = ReasonRequests
+	case d.limits.Scan > 0 && len(c.notFound) > d.limits.Scan:
+		reason = ReasonScan
+	case d.limits.Errors > 0 && c.errors > d.limits.Errors:
+		reason = ReasonErrors
+	default:
+		return
+	}
+	c.flagged = true
+	o := Offender{
+		IP:       v.IP,
+		Reason:   reason,
+		Requests: c.requests,
+		Errors:   c.errors,
+		Paths:    len(c.notFound),
+		Window:   d.limits.Window.String(),
+		Time:     now,
+	}
+	d.pending = append(d.pending, o)
+	d.flagged[v.IP] = o
+}
+
+// Flagged returns the IPs flagged in the last hour, sorted by IP.
+func (d *Detector) Flagged() []Offender {
+	if d == nil {
+		return nil
+	}
+	now := d.now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	offenders := make([]Offender, 0, len(d.flagged))
+	for ip, o := range d.flagged {
+		if now.Sub(o.Time) >= flaggedFor {
+			delete(d.flagged, ip)
+			continue
+		}
+		offenders = append(offenders, o)
+	}
+	sort.Slice(offenders, func(i, j int) bool { return offenders[i].IP < offenders[j].IP })
+	return offenders
+}
+
+// Run hands the new offenders to the outputs every few seconds and passes
+// their errors to report. It never returns.
+func (d *Detector) Run(report func(error)) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := d.Flush(); err != nil {
+			report(err)
+		}
+	}
+}
+
+// Flush hands the offenders flagged since the previous flush to every
+// output. It returns the errors of all outputs.
+func (d *Detector) Flush() error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = nil
+	outputs := d.outputs
+	d.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, out := range outputs {
+		errs = append(errs, out(pending))
+	}
+	return errors.Join(errs...)
+}
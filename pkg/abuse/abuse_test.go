@@ -0,0 +1,128 @@
+package abuse
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func newTestDetector(t *testing.T, limits Thresholds) (*Detector, *time.Time) {
+	t.Helper()
+	d, err := NewDetector(limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func TestDetectorScan(t *testing.T) {
+	d, _ := newTestDetector(t, Thresholds{Scan: 3, Window: time.Minute})
+
+	// Missing the same path again and again is no scan
+	for i := 0; i < 10; i++ {
+		d.Observe(&parser.Visitor{IP: "192.0.2.1", Path: "/favicon.ico", Status: 404})
+	}
+	for _, path := range []string{"/.env", "/.git/config", "/admin", "/admin", "/wp-login.php"} {
+		d.Observe(&parser.Visitor{IP: "203.0.113.7", Path: path, Status: 404})
+	}
+
+	flagged := d.Flagged()
+	if len(flagged) != 1 {
+		t.Fatalf("Flagged() = %+v, want the scanner only", flagged)
+	}
+	if o := flagged[0]; o.IP != "203.0.113.7" || o.Reason != ReasonScan || o.Paths != 4 || o.Requests != 5 {
+		t.Errorf("Flagged() = %+v, want a scan of 4 paths", o)
+	}
+}
+
+func TestDetectorFlags(t *testing.T) {
+	d, now := newTestDetector(t, Thresholds{Requests: 3, Errors: 2, Window: time.Minute})
+	var got []Offender
+	d.AddOutput(func(o []Offender) error {
+		got = append(got, o...)
+		return nil
+	})
+
+	for i := 0; i < 5; i++ {
+		d.Observe(&parser.Visitor{IP: "203.0.113.7", Status: 200})
+	}
+	for i := 0; i < 3; i++ {
+		d.Observe(&parser.Visitor{IP: "198.51.100.2", Status: 404})
+	}
+	d.Observe(&parser.Visitor{IP: "192.0.2.1", Status: 500})
+	if err := d.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("flagged %+v, want each offender once", got)
+	}
+	if got[0].IP != "203.0.113.7" || got[0].Reason != ReasonRequests || got[0].Requests != 4 || got[0].Window != "1m0s" {
+		t.Errorf("first offender = %+v", got[0])
+	}
+	if got[1].IP != "198.51.100.2" || got[1].Reason != ReasonErrors || got[1].Errors != 3 {
+		t.Errorf("second offender = %+v", got[1])
+	}
+
+	// Counts start anew every window
+	*now = now.Add(time.Minute)
+	d.Observe(&parser.Visitor{IP: "203.0.113.7", Status: 200})
+	if err := d.Flush(); err != nil || len(got) != 2 {
+		t.Errorf("Flush() = %v, flagged %d, want no new offender", err, len(got))
+	}
+	for i := 0; i < 3; i++ {
+		d.Observe(&parser.Visitor{IP: "203.0.113.7", Status: 200})
+	}
+	d.Flush()
+	if len(got) != 3 {
+		t.Errorf("flagged %d, want the offender again in a new window", len(got))
+	}
+}
+
+func TestDetectorFlagged(t *testing.T) {
+	d, now := newTestDetector(t, Thresholds{Requests: 1, Window: time.Minute})
+	d.Observe(&parser.Visitor{IP: "203.0.113.7"})
+	d.Observe(&parser.Visitor{IP: "203.0.113.7"})
+	*now = now.Add(30 * time.Minute)
+	d.Observe(&parser.Visitor{IP: "198.51.100.2"})
+	d.Observe(&parser.Visitor{IP: "198.51.100.2"})
+
+	flagged := d.Flagged()
+	if len(flagged) != 2 || flagged[0].IP != "198.51.100.2" || flagged[1].IP != "203.0.113.7" {
+		t.Errorf("Flagged() = %+v, want both offenders sorted by IP", flagged)
+	}
+	*now = now.Add(45 * time.Minute)
+	if flagged := d.Flagged(); len(flagged) != 1 || flagged[0].IP != "198.51.100.2" {
+		t.Errorf("Flagged() = %+v, want offenders older than an hour dropped", flagged)
+	}
+}
+
+func TestDetectorErrors(t *testing.T) {
+	if _, err := NewDetector(Thresholds{Window: time.Minute}); err == nil {
+		t.Error("NewDetector() without thresholds should fail")
+	}
+	if _, err := NewDetector(Thresholds{Requests: 10}); err == nil {
+		t.Error("NewDetector() without window should fail")
+	}
+
+	d, _ := newTestDetector(t, Thresholds{Requests: 1, Window: time.Minute})
+	d.AddOutput(func([]Offender) error { return errors.New("disk full") })
+	if err := d.Flush(); err != nil {
+		t.Errorf("Flush() without offenders = %v", err)
+	}
+	d.Observe(&parser.Visitor{IP: "203.0.113.7"})
+	d.Observe(&parser.Visitor{IP: "203.0.113.7"})
+	if err := d.Flush(); err == nil {
+		t.Error("Flush() should return the output errors")
+	}
+
+	var nilDetector *Detector
+	nilDetector.Observe(&parser.Visitor{IP: "203.0.113.7"})
+	if nilDetector.Flush() != nil || nilDetector.Flagged() != nil {
+		t.Error("a nil Detector should ignore everything")
+	}
+}
@@ -0,0 +1,71 @@
+package abuse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Deny list formats
+const (
+	FormatDeny = "deny" // "deny 203.0.113.7;" lines, to include in a server or location block
+	FormatGeo  = "geo"  // "203.0.113.7 1;" lines, to include in a geo block
+)
+
+// CheckFormat returns an error if format is not a deny list format.
+func CheckFormat(format string) error {
+	if format != FormatDeny && format != FormatGeo {
+		return fmt.Errorf("unknown deny list format %q, expected %s or %s", format, FormatDeny, FormatGeo)
+	}
+	return nil
+}
+
+// WriteDenyList writes an nginx snippet blocking the offenders, in format
+// FormatDeny or FormatGeo. A FormatGeo list is included in a block such as:
+//
+//	geo $tailnginx_banned {
+//	    default 0;
+//	    include /etc/nginx/tailnginx-banned.conf;
+//	}
+func WriteDenyList(w io.Writer, offenders []Offender, format string, now time.Time) error {
+	if err := CheckFormat(format); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Generated by tailnginx on %s: %d abusive IPs\n", now.Format(time.RFC1123), len(offenders))
+	for _, o := range offenders {
+		notFound := ""
+		if o.Paths > 0 {
+			notFound = fmt.Sprintf(", %d paths not found", o.Paths)
+		}
+		fmt.Fprintf(bw, "# %s: %d requests, %d 4xx%s in %s, flagged %s\n",
+			o.Reason, o.Requests, o.Errors, notFound, o.Window, o.Time.Format(time.DateTime))
+		if format == FormatGeo {
+			fmt.Fprintf(bw, "%s 1;\n", o.IP)
+		} else {
+			fmt.Fprintf(bw, "deny %s;\n", o.IP)
+		}
+	}
+	return bw.Flush()
+}
+
+// SaveDenyList writes the deny list of the offenders to path. The file is
+// replaced atomically, so that nginx never reads it half written.
+func SaveDenyList(path string, offenders []Offender, format string, now time.Time) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	err = WriteDenyList(f, offenders, format, now)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,90 @@
+package abuse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func denyTestOffenders() []Offender {
+	flagged := time.Date(2025, 10, 10, 11, 58, 0, 0, time.UTC)
+	return []Offender{
+		{IP: "198.51.100.2", Reason: ReasonErrors, Requests: 150, Errors: 120, Window: "1m0s", Time: flagged},
+		{IP: "2001:db8::1", Reason: ReasonRequests, Requests: 700, Window: "1m0s", Time: flagged},
+	}
+}
+
+func TestWriteDenyList(t *testing.T) {
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{FormatDeny, []string{"deny 198.51.100.2;", "deny 2001:db8::1;"}},
+		{FormatGeo, []string{"198.51.100.2 1;", "2001:db8::1 1;"}},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := WriteDenyList(&b, denyTestOffenders(), tt.format, now); err != nil {
+			t.Fatalf("WriteDenyList(%s) error = %v", tt.format, err)
+		}
+		var rules []string
+		for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+			if !strings.HasPrefix(line, "#") {
+				rules = append(rules, line)
+			}
+		}
+		if strings.Join(rules, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("WriteDenyList(%s) rules = %q, want %q", tt.format, rules, tt.want)
+		}
+		if !strings.Contains(b.String(), "# errors: 150 requests, 120 4xx in 1m0s, flagged 2025-10-10 11:58:00\n") {
+			t.Errorf("WriteDenyList(%s) should comment each IP:\n%s", tt.format, b.String())
+		}
+	}
+
+	if err := WriteDenyList(&strings.Builder{}, nil, "iptables", now); err == nil {
+		t.Error("WriteDenyList() with an unknown format should fail")
+	}
+}
+
+func TestSaveDenyList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banned.conf")
+	now := time.Now()
+	if err := SaveDenyList(path, denyTestOffenders(), FormatDeny, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveDenyList(path, denyTestOffenders()[:1], FormatDeny, now); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "deny 198.51.100.2;") || strings.Contains(string(data), "2001:db8::1") {
+		t.Errorf("the list should be replaced:\n%s", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	if err := SaveDenyList(path, nil, "iptables", now); err == nil {
+		t.Error("SaveDenyList() with an unknown format should fail")
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind after an error: %v", err)
+	}
+}
+
+func TestReloadNginxErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := ReloadNginx(filepath.Join(dir, "missing.pid")); err == nil {
+		t.Error("ReloadNginx() without PID file should fail")
+	}
+	pidFile := filepath.Join(dir, "nginx.pid")
+	os.WriteFile(pidFile, []byte("nginx\n"), 0o644)
+	if err := ReloadNginx(pidFile); err == nil {
+		t.Error("ReloadNginx() with an invalid PID should fail")
+	}
+}
@@ -0,0 +1,59 @@
+package abuse
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fail2banTimeFormat is recognized by the default date detection of
+// fail2ban, in local time.
+const fail2banTimeFormat = "2006-01-02 15:04:05"
+
+// Fail2ban appends offenders to a log file a fail2ban jail watches, one
+// line each:
+//
+//	2025-10-10 12:00:00 tailnginx offender 203.0.113.7 reason=requests requests=601 errors=0 window=1m0s
+//
+// A filter with "failregex = tailnginx offender <HOST> " and maxretry = 1
+// bans every offender. The file is opened for each write, so it can be
+// rotated.
+type Fail2ban struct {
+	path string
+}
+
+// NewFail2ban returns a writer of offenders to the file at path, created
+// if needed.
+func NewFail2ban(path string) (*Fail2ban, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("fail2ban: %w", err)
+	}
+	f.Close()
+	return &Fail2ban{path: path}, nil
+}
+
+// Write appends offenders to the file. It is an Output.
+func (f *Fail2ban) Write(offenders []Offender) error {
+	var b strings.Builder
+	for _, o := range offenders {
+		fmt.Fprintf(&b, "%s tailnginx offender %s reason=%s requests=%d errors=%d window=%s",
+			o.Time.Local().Format(fail2banTimeFormat), o.IP, o.Reason, o.Requests, o.Errors, o.Window)
+		if o.Paths > 0 {
+			fmt.Fprintf(&b, " paths=%d", o.Paths)
+		}
+		b.WriteByte('\n')
+	}
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("fail2ban: %w", err)
+	}
+	_, err = file.WriteString(b.String())
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("fail2ban: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package abuse
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestFail2ban(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offenders.log")
+	f, err := NewFail2ban(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	when := time.Date(2025, 10, 10, 12, 0, 0, 0, time.Local)
+	offenders := []Offender{
+		{IP: "203.0.113.7", Reason: ReasonRequests, Requests: 601, Window: "1m0s", Time: when},
+		{IP: "2001:db8::1", Reason: ReasonErrors, Requests: 120, Errors: 101, Window: "1m0s", Time: when},
+		{IP: "198.51.100.2", Reason: ReasonScan, Requests: 31, Errors: 31, Paths: 21, Window: "1m0s", Time: when},
+	}
+	if err := f.Write(offenders[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Write(offenders[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2025-10-10 12:00:00 tailnginx offender 203.0.113.7 reason=requests requests=601 errors=0 window=1m0s\n" +
+		"2025-10-10 12:00:00 tailnginx offender 2001:db8::1 reason=errors requests=120 errors=101 window=1m0s\n" +
+		"2025-10-10 12:00:00 tailnginx offender 198.51.100.2 reason=scan requests=31 errors=31 window=1m0s paths=21\n"
+	if string(b) != want {
+		t.Errorf("file = %q, want %q", b, want)
+	}
+
+	// The failregex documented for the jail, with <HOST> as fail2ban expands it roughly
+	failregex := regexp.MustCompile(`tailnginx offender (\S+) `)
+	if m := failregex.FindStringSubmatch(want); m == nil || m[1] != "203.0.113.7" {
+		t.Errorf("failregex matched %q", m)
+	}
+
+	if _, err := NewFail2ban(filepath.Join(t.TempDir(), "missing", "offenders.log")); err == nil {
+		t.Error("NewFail2ban() in a missing directory should fail")
+	}
+}
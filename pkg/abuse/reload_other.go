@@ -0,0 +1,10 @@
+//go:build !unix
+
+package abuse
+
+import "errors"
+
+// ReloadNginx is not supported on this platform, which has no SIGHUP.
+func ReloadNginx(pidFile string) error {
+	return errors.New("reloading nginx: not supported on this platform")
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package abuse
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ReloadNginx makes nginx reload its configuration, e.g. after a deny list
+// changed, by sending SIGHUP to the master process whose PID is in pidFile.
+// nginx keeps the running configuration if the new one is invalid.
+func ReloadNginx(pidFile string) error {
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		return fmt.Errorf("reloading nginx: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return fmt.Errorf("reloading nginx: invalid PID in %s", pidFile)
+	}
+	if err := syscall.Kill(pid, syscall.SIGHUP); err != nil {
+		return fmt.Errorf("reloading nginx: process %d: %w", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Package alert keeps track of active alerts raised by tailnginx checks.
+package alert
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Severity indicates how urgent an alert is.
+type Severity int
+
+// Alert severities, from least to most urgent
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+// String returns the short display name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityCritical:
+		return "CRIT"
+	case SeverityWarning:
+		return "WARN"
+	default:
+		return "INFO"
+	}
+}
+
+// Alert is an active alert condition.
+type Alert struct {
+	Since    time.Time // When the condition was first raised
+	ID       string    // Stable identifier of the condition, e.g. "5xx_spike"
+	Message  string    // Human readable description with current values
+	Severity Severity
+	Acked    bool // Acknowledged alerts stay active but are not shown in the banner
+
+	Value     float64       // Measured value that raised the alert, e.g. the 5xx percentage
+	Window    time.Duration // Period the value was measured over, 0 for instant checks
+	Offenders []Offender    // Top contributors to the value, most first
+}
+
+// Offender is an IP, path or other value contributing to an alert.
+type Offender struct {
+	Value    string `json:"value"`
+	Requests int    `json:"requests"`
+}
+
+// Notifier is told about alerts as they fire.
+type Notifier interface {
+	Notify(a Alert)
+}
+
+// Resolver is a Notifier also told about alerts that cleared, e.g. to
+// resolve an incident.
+type Resolver interface {
+	Resolve(a Alert)
+}
+
+// Board holds the currently active alerts and the history of the alerts
+// that fired, escalated or cleared. It is safe for concurrent use.
+type Board struct {
+	alerts    map[string]*Alert
+	history   []Event
+	notifiers []notifier
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// notifier is a registered Notifier and the least severe alerts it is told
+// about.
+type notifier struct {
+	Notifier
+	least Severity
+}
+
+// NewBoard creates an empty alert board.
+func NewBoard() *Board {
+	return &Board{
+		alerts: make(map[string]*Alert),
+		now:    time.Now,
+	}
+}
+
+// Raise activates or updates the alert with the given ID.
+// The original start time is kept while the alert stays active. An
+// acknowledged alert becomes unacknowledged again if its severity escalates.
+// Returns true if the alert was not active before.
+func (b *Board) Raise(id string, severity Severity, message string) bool {
+	return b.Fire(Alert{ID: id, Severity: severity, Message: message})
+}
+
+// Fire is Raise with the value, window and offenders of the alert, which
+// replace the previous ones. Notifiers are told about alerts that were not
+// active before or whose severity escalates, from their minimum severity.
+func (b *Board) Fire(fired Alert) bool {
+	b.mu.Lock()
+	a, active := b.alerts[fired.ID]
+	notify := !active || fired.Severity > a.Severity
+	if active {
+		if fired.Severity > a.Severity {
+			a.Acked = false
+		}
+		fired.Since = a.Since
+		fired.Acked = a.Acked
+	} else {
+		fired.Since = b.now()
+		fired.Acked = false
+	}
+	b.alerts[fired.ID] = &fired
+	if !active {
+		b.record(EventFired, fired)
+	} else if notify {
+		b.record(EventEscalated, fired)
+	}
+	notifiers := b.notifiers
+	b.mu.Unlock()
+
+	if notify {
+		for _, n := range notifiers {
+			if fired.Severity >= n.least {
+				n.Notify(fired)
+			}
+		}
+	}
+	return !active
+}
+
+// AddNotifier registers a notifier of the alerts of at least severity least
+// fired from now on.
+func (b *Board) AddNotifier(n Notifier, least Severity) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.notifiers = append(b.notifiers, notifier{Notifier: n, least: least})
+}
+
+// Clear deactivates the alert with the given ID. Notifiers that are
+// resolvers are told about it.
+// Returns true if the alert was active.
+func (b *Board) Clear(id string) bool {
+	b.mu.Lock()
+	a, ok := b.alerts[id]
+	if !ok {
+		b.mu.Unlock()
+		return false
+	}
+	delete(b.alerts, id)
+	b.record(EventCleared, *a)
+	notifiers := b.notifiers
+	b.mu.Unlock()
+
+	for _, n := range notifiers {
+		if r, ok := n.Notifier.(Resolver); ok {
+			r.Resolve(*a)
+		}
+	}
+	return true
+}
+
+// Active returns all active alerts, most severe first, then oldest first.
+func (b *Board) Active() []Alert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	active := make([]Alert, 0, len(b.alerts))
+	for _, a := range b.alerts {
+		active = append(active, *a)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Severity != active[j].Severity {
+			return active[i].Severity > active[j].Severity
+		}
+		if !active[i].Since.Equal(active[j].Since) {
+			return active[i].Since.Before(active[j].Since)
+		}
+		return active[i].ID < active[j].ID
+	})
+	return active
+}
+
+// Unacked returns active alerts that have not been acknowledged, in the same
+// order as Active.
+func (b *Board) Unacked() []Alert {
+	var unacked []Alert
+	for _, a := range b.Active() {
+		if !a.Acked {
+			unacked = append(unacked, a)
+		}
+	}
+	return unacked
+}
+
+// AckAll acknowledges all active alerts.
+func (b *Board) AckAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, a := range b.alerts {
+		a.Acked = true
+	}
+}
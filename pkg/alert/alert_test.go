@@ -0,0 +1,138 @@
+package alert
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoardRaiseAndClear(t *testing.T) {
+	b := NewBoard()
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	if !b.Raise("5xx_spike", SeverityWarning, "12% 5xx") {
+		t.Error("Raise() of a new alert should return true")
+	}
+	now = now.Add(time.Minute)
+	if b.Raise("5xx_spike", SeverityWarning, "15% 5xx") {
+		t.Error("Raise() of an active alert should return false")
+	}
+
+	active := b.Active()
+	if len(active) != 1 {
+		t.Fatalf("Active() returned %d alerts, want 1", len(active))
+	}
+	if active[0].Message != "15% 5xx" {
+		t.Errorf("Raise() should update the message, got %q", active[0].Message)
+	}
+	if !active[0].Since.Equal(now.Add(-time.Minute)) {
+		t.Errorf("Raise() should keep the original start time, got %v", active[0].Since)
+	}
+
+	if !b.Clear("5xx_spike") {
+		t.Error("Clear() of an active alert should return true")
+	}
+	if b.Clear("5xx_spike") {
+		t.Error("Clear() of an inactive alert should return false")
+	}
+	if len(b.Active()) != 0 {
+		t.Error("Active() should be empty after Clear()")
+	}
+}
+
+func TestBoardOrdering(t *testing.T) {
+	b := NewBoard()
+	base := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	now := base
+	b.now = func() time.Time { return now }
+
+	b.Raise("a", SeverityWarning, "first warning")
+	now = base.Add(time.Second)
+	b.Raise("b", SeverityCritical, "critical")
+	now = base.Add(2 * time.Second)
+	b.Raise("c", SeverityWarning, "second warning")
+
+	active := b.Active()
+	want := []string{"b", "a", "c"}
+	for i, id := range want {
+		if active[i].ID != id {
+			t.Errorf("Active()[%d] = %s, want %s", i, active[i].ID, id)
+		}
+	}
+}
+
+func TestBoardAck(t *testing.T) {
+	b := NewBoard()
+	b.Raise("disk", SeverityWarning, "92% used")
+	b.AckAll()
+
+	if len(b.Unacked()) != 0 {
+		t.Error("Unacked() should be empty after AckAll()")
+	}
+	if len(b.Active()) != 1 {
+		t.Error("acknowledged alerts should stay active")
+	}
+
+	// Same severity keeps the acknowledgement
+	b.Raise("disk", SeverityWarning, "93% used")
+	if len(b.Unacked()) != 0 {
+		t.Error("re-raising at the same severity should stay acknowledged")
+	}
+
+	// Escalation needs a new acknowledgement
+	b.Raise("disk", SeverityCritical, "98% used")
+	if len(b.Unacked()) != 1 {
+		t.Error("escalating severity should clear the acknowledgement")
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	if SeverityCritical.String() != "CRIT" || SeverityWarning.String() != "WARN" || SeverityInfo.String() != "INFO" {
+		t.Error("unexpected severity names")
+	}
+}
+
+func TestDiskUsage(t *testing.T) {
+	used, err := DiskUsage(t.TempDir())
+	if err != nil {
+		t.Skipf("DiskUsage() not supported: %v", err)
+	}
+	if used < 0 || used > 100 {
+		t.Errorf("DiskUsage() = %.1f, want 0-100", used)
+	}
+}
+
+// notifications records the alerts it is told about.
+type notifications []Alert
+
+func (n *notifications) Notify(a Alert) { *n = append(*n, a) }
+
+func TestBoardFireNotifies(t *testing.T) {
+	b := NewBoard()
+	var got, all notifications
+	b.AddNotifier(&got, SeverityWarning)
+	b.AddNotifier(&all, SeverityInfo)
+
+	offenders := []Offender{{Value: "203.0.113.7", Requests: 12}}
+	b.Fire(Alert{ID: "5xx_spike", Severity: SeverityWarning, Message: "12% 5xx",
+		Value: 12, Window: time.Minute, Offenders: offenders})
+	b.Fire(Alert{ID: "5xx_spike", Severity: SeverityWarning, Message: "14% 5xx", Value: 14})
+	b.Fire(Alert{ID: "5xx_spike", Severity: SeverityCritical, Message: "30% 5xx", Value: 30})
+	b.Raise("watch:/admin", SeverityInfo, "watched path /admin")
+
+	if len(got) != 2 {
+		t.Fatalf("notified %d alerts, want the new and the escalated one", len(got))
+	}
+	if len(all) != 3 || all[2].ID != "watch:/admin" {
+		t.Errorf("notified %+v from info up, want the info alert too", all)
+	}
+	if got[0].Value != 12 || got[0].Window != time.Minute || len(got[0].Offenders) != 1 {
+		t.Errorf("first notification = %+v", got[0])
+	}
+	if got[1].Severity != SeverityCritical || !got[1].Since.Equal(got[0].Since) {
+		t.Errorf("escalation = %+v, want the original start time", got[1])
+	}
+	if a := b.Active(); a[0].Value != 30 || a[0].Offenders != nil {
+		t.Errorf("Fire() should replace the details, got %+v", a[0])
+	}
+}
@@ -0,0 +1,135 @@
+package alert
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Login brute force check
+const (
+	bruteForceID  = "brute_force"
+	bruteForceTop = 5 // IPs listed as offenders of a brute force
+)
+
+// loginFailures are the statuses of failed or throttled login attempts.
+var loginFailures = []int{401, 403, 429}
+
+// SetLoginPaths sets the authentication endpoints watched for brute force,
+// e.g. "/wp-login.php": more than failures responses 401, 403 or 429 to
+// them from one IP or subnet within window raise an alert.
+func (c *Checker) SetLoginPaths(paths []string, failures int, window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loginPaths = paths
+	c.loginFailures = failures
+	c.loginWindow = window
+}
+
+// isLoginPath reports whether the path of a request, without its query, is
+// a watched authentication endpoint. Caller must hold the lock.
+func (c *Checker) isLoginPath(path string) bool {
+	path, _, _ = strings.Cut(path, "?")
+	return slices.Contains(c.loginPaths, path)
+}
+
+// ipNetwork returns the /24 network of an IPv4 address or the /64 network
+// of an IPv6 address, so that requests spread over neighboring addresses,
+// e.g. login attempts, are counted together.
+func ipNetwork(ip string) (string, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", false
+	}
+	bits := 64
+	if addr.Unmap().Is4() {
+		addr, bits = addr.Unmap(), 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return "", false
+	}
+	return prefix.String(), true
+}
+
+// checkBruteForce raises an alert while an IP, or several IPs of a subnet,
+// failed to log in more than the threshold within the window. The IPs with
+// the most failures are its offenders. Caller must hold the lock.
+func (c *Checker) checkBruteForce(now time.Time) bool {
+	if len(c.loginPaths) == 0 {
+		return false
+	}
+
+	byIP := make(map[string]int)
+	paths := make(map[string]map[string]int) // Failed paths by IP
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		v := &c.entries[i]
+		if now.Sub(v.Time) > c.loginWindow {
+			break // Entries are kept in log order, older ones follow
+		}
+		if !slices.Contains(loginFailures, v.Status) || !c.isLoginPath(v.Path) {
+			continue
+		}
+		byIP[v.IP]++
+		if paths[v.IP] == nil {
+			paths[v.IP] = make(map[string]int)
+		}
+		path, _, _ := strings.Cut(v.Path, "?")
+		paths[v.IP][path]++
+	}
+
+	// Failures of each subnet and its IPs
+	bySubnet := make(map[string]int)
+	subnetIPs := make(map[string][]string)
+	for ip, n := range byIP {
+		if subnet, ok := ipNetwork(ip); ok {
+			bySubnet[subnet] += n
+			subnetIPs[subnet] = append(subnetIPs[subnet], ip)
+		}
+	}
+
+	offending := make(map[string]int)
+	source, worst := "", 0
+	for ip, n := range byIP {
+		if n > c.loginFailures {
+			offending[ip] = n
+			if n > worst || (n == worst && ip < source) {
+				source, worst = ip, n
+			}
+		}
+	}
+	for subnet, n := range bySubnet {
+		ips := subnetIPs[subnet]
+		if n <= c.loginFailures || len(ips) < 2 {
+			continue // A single IP is judged on its own
+		}
+		for _, ip := range ips {
+			offending[ip] = byIP[ip]
+		}
+		if n > worst || (n == worst && subnet < source) {
+			source, worst = fmt.Sprintf("%s (%d IPs)", subnet, len(ips)), n
+		}
+	}
+	if len(offending) == 0 {
+		return c.board.Clear(bruteForceID)
+	}
+
+	targets := make(map[string]int)
+	for ip := range offending {
+		for path, n := range paths[ip] {
+			targets[path] += n
+		}
+	}
+	c.board.Fire(Alert{
+		ID:       bruteForceID,
+		Severity: SeverityWarning,
+		Message: fmt.Sprintf("Login brute force: %d failed logins on %s from %s in the last %s",
+			worst, topOffenders(targets, 1)[0].Value, source, c.loginWindow),
+		Value:     float64(worst),
+		Window:    c.loginWindow,
+		Offenders: topOffenders(offending, bruteForceTop),
+	})
+	return true
+}
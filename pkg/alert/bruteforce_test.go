@@ -0,0 +1,69 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestCheckBruteForce tests that repeated failed logins from an IP or a
+// subnet raise an alert listing the offending IPs.
+func TestCheckBruteForce(t *testing.T) {
+	c := NewChecker(NewBoard(), 1000)
+	c.SetLoginPaths([]string{"/wp-login.php", "/api/login"}, 5, 5*time.Minute)
+	now := time.Now()
+
+	add := func(ip, path string, status, n int) {
+		for i := 0; i < n; i++ {
+			c.Observe(&parser.Visitor{Time: now.Add(-time.Minute), IP: ip, Path: path, Status: status})
+		}
+	}
+
+	// Failures elsewhere, successes and a few failures are no brute force
+	add("203.0.113.7", "/admin", 401, 20)
+	add("203.0.113.7", "/wp-login.php", 200, 20)
+	add("203.0.113.7", "/wp-login.php", 403, 5)
+	if c.checkBruteForce(now) || len(c.board.Active()) != 0 {
+		t.Fatalf("checkBruteForce() = %+v, want no alert", c.board.Active())
+	}
+
+	// Failures spread over a subnet
+	add("198.51.100.1", "/api/login?user=admin", 401, 3)
+	add("198.51.100.2", "/api/login", 429, 3)
+	c.checkBruteForce(now)
+	active := c.board.Active()
+	if len(active) != 1 || active[0].ID != bruteForceID {
+		t.Fatalf("checkBruteForce() = %+v, want one alert", active)
+	}
+	if a := active[0]; !strings.Contains(a.Message, "6 failed logins on /api/login from 198.51.100.0/24 (2 IPs)") || len(a.Offenders) != 2 {
+		t.Errorf("checkBruteForce() alert = %+v", a)
+	}
+
+	// One IP
+	add("203.0.113.7", "/wp-login.php", 401, 5)
+	c.checkBruteForce(now)
+	if a := c.board.Active()[0]; !strings.Contains(a.Message, "10 failed logins on /wp-login.php from 203.0.113.7") || a.Offenders[0].Value != "203.0.113.7" {
+		t.Errorf("checkBruteForce() alert = %+v", a)
+	}
+
+	// The attempts are outside the window later on
+	if !c.checkBruteForce(now.Add(10*time.Minute)) || len(c.board.Active()) != 0 {
+		t.Error("checkBruteForce() should clear the alert once the window is quiet")
+	}
+}
+
+func TestIPNetwork(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.7":          "203.0.113.0/24",
+		"::ffff:203.0.113.7":   "203.0.113.0/24",
+		"2001:db8:1:2:3:4:5:6": "2001:db8:1:2::/64",
+		"not an ip":            "",
+	}
+	for ip, want := range tests {
+		if got, _ := ipNetwork(ip); got != want {
+			t.Errorf("ipNetwork(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
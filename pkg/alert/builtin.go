@@ -0,0 +1,163 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Built-in 5xx spike and traffic drop checks and their thresholds
+const (
+	errorRateID       = "5xx_spike"
+	errorRateWindow   = time.Minute
+	errorRateBaseline = 15 * time.Minute // Period before the window the error rate is compared to
+	errorRateMinReqs  = 20               // Minimum requests in the window, or baseline, before judging the error rate
+	errorRateWarning  = 10.0             // Percent of 5xx responses
+	errorRateCritical = 25.0
+	errorRateSpike    = 3.0 // Times the baseline error rate a warning needs
+	errorRateTop      = 3   // Paths and upstreams listed as offenders of a 5xx spike
+
+	trafficDropID       = "traffic_drop"
+	trafficDropWindow   = time.Minute
+	trafficDropMinRate  = 0.5  // Requests per second of the baseline before judging a drop
+	trafficDropWarning  = 0.25 // Share of the baseline rate left
+	trafficDropCritical = 0.05
+
+	rateInterval = 10 * time.Second
+	rateBuckets  = 60 // 10 minutes of request rates, the baseline of the traffic checks
+)
+
+// checkErrorRate raises an alert when the share of 5xx responses in the last
+// minute spikes above the thresholds and above its baseline, the share over
+// the minutes before. A share above the critical threshold raises a critical
+// alert whatever the baseline. The paths and upstreams with the most 5xx
+// responses are its offenders. Caller must hold the lock.
+func (c *Checker) checkErrorRate(now time.Time) bool {
+	total, errors := 0, 0
+	baseTotal, baseErrors := 0, 0
+	byPath := make(map[string]int)
+	byUpstream := make(map[string]int)
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		v := &c.entries[i]
+		age := now.Sub(v.Time)
+		if age > errorRateWindow+errorRateBaseline {
+			break // Entries are kept in log order, older ones follow
+		}
+		if age > errorRateWindow {
+			baseTotal++
+			if v.Status >= 500 {
+				baseErrors++
+			}
+			continue
+		}
+		total++
+		if v.Status >= 500 {
+			errors++
+			byPath[v.Path]++
+			if v.Upstream != "" {
+				byUpstream["upstream "+v.Upstream]++
+			}
+		}
+	}
+
+	if total < errorRateMinReqs {
+		return c.board.Clear(errorRateID)
+	}
+
+	rate := float64(errors) / float64(total) * 100
+	baseline := 0.0
+	if baseTotal >= errorRateMinReqs {
+		baseline = float64(baseErrors) / float64(baseTotal) * 100
+	}
+	severity := SeverityWarning
+	switch {
+	case rate >= errorRateCritical:
+		severity = SeverityCritical
+	case rate < errorRateWarning || rate < baseline*errorRateSpike:
+		return c.board.Clear(errorRateID)
+	}
+
+	message := fmt.Sprintf("5xx spike: %.0f%% of %d requests in the last minute", rate, total)
+	if baseTotal >= errorRateMinReqs {
+		message += fmt.Sprintf(", %.1f%% before", baseline)
+	}
+	c.board.Fire(Alert{
+		ID:        errorRateID,
+		Severity:  severity,
+		Message:   message,
+		Value:     rate,
+		Window:    errorRateWindow,
+		Offenders: append(topOffenders(byPath, errorRateTop), topOffenders(byUpstream, errorRateTop)...),
+	})
+	return true
+}
+
+// checkTrafficDrop raises an alert when the request rate of the last minute
+// falls far below its baseline, the rate of the minutes before, which
+// usually means a DNS, certificate or upstream failure rather than quiet
+// traffic. The rates count every request whatever the entries kept, and are
+// only judged once they cover the baseline and the baseline is steady
+// enough. Caller must hold the lock.
+func (c *Checker) checkTrafficDrop(now time.Time) bool {
+	if now.Sub(c.start) < rateInterval*rateBuckets {
+		return false
+	}
+	rate, baseline := c.rateChange(now, trafficDropWindow)
+	if baseline < trafficDropMinRate {
+		return c.board.Clear(trafficDropID)
+	}
+
+	severity := SeverityWarning
+	switch {
+	case rate <= baseline*trafficDropCritical:
+		severity = SeverityCritical
+	case rate > baseline*trafficDropWarning:
+		return c.board.Clear(trafficDropID)
+	}
+
+	c.board.Fire(Alert{
+		ID:       trafficDropID,
+		Severity: severity,
+		Message:  fmt.Sprintf("Traffic drop: %.1f req/s in the last minute, %.1f req/s before, check DNS, certificates and upstreams", rate, baseline),
+		Value:    rate,
+		Window:   trafficDropWindow,
+	})
+	return true
+}
+
+// rateChange returns the requests per second of the last window and of the
+// minutes of rates before it, its baseline.
+func (c *Checker) rateChange(now time.Time, window time.Duration) (rate, baseline float64) {
+	windowBuckets := int(window / rateInterval)
+	// The current bucket is still filling up, so it is left out
+	buckets := c.rates.Buckets(now.Add(-rateInterval), rateBuckets)
+	var recent, before uint64
+	for i, n := range buckets {
+		if i >= len(buckets)-windowBuckets {
+			recent += n
+		} else {
+			before += n
+		}
+	}
+	rate = float64(recent) / window.Seconds()
+	baseline = float64(before) / (rateInterval * time.Duration(rateBuckets-windowBuckets)).Seconds()
+	return rate, baseline
+}
+
+// topOffenders returns the n values with the most requests, most first.
+func topOffenders(counts map[string]int, n int) []Offender {
+	offenders := make([]Offender, 0, len(counts))
+	for value, requests := range counts {
+		offenders = append(offenders, Offender{Value: value, Requests: requests})
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].Requests != offenders[j].Requests {
+			return offenders[i].Requests > offenders[j].Requests
+		}
+		return offenders[i].Value < offenders[j].Value
+	})
+	if len(offenders) > n {
+		offenders = offenders[:n]
+	}
+	return offenders
+}
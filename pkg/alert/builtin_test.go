@@ -0,0 +1,159 @@
+package alert
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestCheckErrorRate tests the built-in 5xx spike check.
+func TestCheckErrorRate(t *testing.T) {
+	c := NewChecker(NewBoard(), 1000)
+	now := time.Now()
+
+	addRequests := func(n, status int) {
+		for i := 0; i < n; i++ {
+			c.Observe(&parser.Visitor{Time: now.Add(-10 * time.Second), Status: status})
+		}
+	}
+
+	// Too few requests to judge
+	addRequests(5, 500)
+	c.checkErrorRate(now)
+	if len(c.board.Active()) != 0 {
+		t.Fatal("checkErrorRate() should not alert below the minimum request count")
+	}
+
+	// 5 errors out of 40 requests = 12.5% -> warning
+	addRequests(35, 200)
+	c.checkErrorRate(now)
+	active := c.board.Active()
+	if len(active) != 1 || active[0].Severity != SeverityWarning {
+		t.Fatalf("checkErrorRate() = %+v, want one warning", active)
+	}
+
+	// 15 errors out of 50 requests = 30% -> critical
+	addRequests(10, 503)
+	c.checkErrorRate(now)
+	if active := c.board.Active(); active[0].Severity != SeverityCritical {
+		t.Errorf("checkErrorRate() severity = %v, want critical", active[0].Severity)
+	}
+	if a := c.board.Active()[0]; a.Value != 30 || a.Window != time.Minute || len(a.Offenders) != 1 || a.Offenders[0].Requests != 15 {
+		t.Errorf("checkErrorRate() details = %v over %v by %+v", a.Value, a.Window, a.Offenders)
+	}
+
+	// The spike is outside the window a few minutes later
+	c.checkErrorRate(now.Add(5 * time.Minute))
+	if len(c.board.Active()) != 0 {
+		t.Error("checkErrorRate() should clear the alert once the window is quiet")
+	}
+}
+
+// TestCheckErrorRateBaseline tests that the 5xx spike check compares the
+// last minute to the minutes before and lists the paths and upstreams with
+// the most 5xx responses.
+func TestCheckErrorRateBaseline(t *testing.T) {
+	c := NewChecker(NewBoard(), 1000)
+	now := time.Now()
+
+	add := func(n int, age time.Duration, v parser.Visitor) {
+		v.Time = now.Add(-age)
+		for i := 0; i < n; i++ {
+			c.Observe(&v)
+		}
+	}
+
+	// A steady 12% of 5xx responses is no spike
+	add(12, 5*time.Minute, parser.Visitor{Path: "/flaky", Status: 500})
+	add(88, 5*time.Minute, parser.Visitor{Path: "/", Status: 200})
+	add(6, 10*time.Second, parser.Visitor{Path: "/flaky", Status: 500})
+	add(44, 10*time.Second, parser.Visitor{Path: "/", Status: 200})
+	c.checkErrorRate(now)
+	if active := c.board.Active(); len(active) != 0 {
+		t.Fatalf("checkErrorRate() = %+v, want no alert at the baseline rate", active)
+	}
+
+	// 16 of 60 = 27% is critical whatever the baseline
+	add(6, 5*time.Second, parser.Visitor{Path: "/api/orders", Status: 502, Upstream: "10.0.0.2:8080"})
+	add(4, 5*time.Second, parser.Visitor{Path: "/api/cart", Status: 504, Upstream: "10.0.0.3:8080"})
+	c.checkErrorRate(now)
+	active := c.board.Active()
+	if len(active) != 1 || active[0].Severity != SeverityCritical {
+		t.Fatalf("checkErrorRate() = %+v, want one critical alert", active)
+	}
+	if !strings.Contains(active[0].Message, "12.0% before") {
+		t.Errorf("checkErrorRate() message = %q, want the baseline", active[0].Message)
+	}
+	want := []Offender{
+		{Value: "/api/orders", Requests: 6}, {Value: "/flaky", Requests: 6}, {Value: "/api/cart", Requests: 4},
+		{Value: "upstream 10.0.0.2:8080", Requests: 6}, {Value: "upstream 10.0.0.3:8080", Requests: 4},
+	}
+	if got := active[0].Offenders; !slices.Equal(got, want) {
+		t.Errorf("checkErrorRate() offenders = %+v, want %+v", got, want)
+	}
+}
+
+// TestCheckTrafficDrop tests the built-in traffic drop check.
+func TestCheckTrafficDrop(t *testing.T) {
+	c := NewChecker(NewBoard(), 1000)
+	now := time.Date(2025, 10, 10, 12, 0, 5, 0, time.UTC)
+	c.start = now.Add(-time.Hour)
+
+	// 2 req/s for the 9 minutes before the last one
+	for age := 590 * time.Second; age >= 70*time.Second; age -= 10 * time.Second {
+		c.rates.RecordN(now.Add(-age), 20)
+	}
+	c.checkTrafficDrop(now)
+	active := c.board.Active()
+	if len(active) != 1 || active[0].ID != trafficDropID || active[0].Severity != SeverityCritical {
+		t.Fatalf("checkTrafficDrop() = %+v, want a critical drop with no recent requests", active)
+	}
+
+	// 0.3 req/s is 15% of the baseline
+	c.rates.RecordN(now.Add(-30*time.Second), 18)
+	c.checkTrafficDrop(now)
+	if active := c.board.Active(); len(active) != 1 || active[0].Severity != SeverityWarning {
+		t.Fatalf("checkTrafficDrop() = %+v, want a warning", active)
+	}
+
+	// Back to 1.5 req/s
+	c.rates.RecordN(now.Add(-20*time.Second), 72)
+	if !c.checkTrafficDrop(now) || len(c.board.Active()) != 0 {
+		t.Error("checkTrafficDrop() should clear the alert once traffic recovers")
+	}
+
+	// Nothing is judged before the baseline is covered
+	c.start = now.Add(-time.Minute)
+	c.rates.Reset()
+	for age := 590 * time.Second; age >= 70*time.Second; age -= 10 * time.Second {
+		c.rates.RecordN(now.Add(-age), 20)
+	}
+	if c.checkTrafficDrop(now) || len(c.board.Active()) != 0 {
+		t.Error("checkTrafficDrop() should wait for a baseline after startup")
+	}
+}
+
+// TestCheckBuiltin tests that checks run the built-in checks without rules.
+func TestCheckBuiltin(t *testing.T) {
+	c := NewChecker(NewBoard(), 1000)
+	now := time.Now()
+	c.Observe(&parser.Visitor{Time: now, IP: "203.0.113.7", Path: "/.env", Status: 404})
+	if !c.Check(now) {
+		t.Error("Check() = false, want true when a built-in check fires")
+	}
+	if active := c.Board().Active(); len(active) != 1 || active[0].ID != sensitiveID {
+		t.Errorf("Active() = %+v, want the sensitive path alert", active)
+	}
+}
+
+// TestTopOffenders tests the ranking of alert offenders.
+func TestTopOffenders(t *testing.T) {
+	got := topOffenders(map[string]int{"10.0.0.2": 3, "10.0.0.1": 3, "10.0.0.3": 9, "10.0.0.4": 1}, 3)
+	want := []Offender{{Value: "10.0.0.3", Requests: 9}, {Value: "10.0.0.1", Requests: 3}, {Value: "10.0.0.2", Requests: 3}}
+	if !slices.Equal(got, want) {
+		t.Errorf("topOffenders() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package alert
+
+import "github.com/papaganelli/tailnginx/pkg/stats"
+
+// Chat message limits
+const (
+	summaryTop = 5   // Entries per list of a summary
+	keyLength  = 100 // Longer paths and other keys are cut
+)
+
+// summaryList is a titled list of the top entries of a snapshot section.
+type summaryList struct {
+	title string
+	items []stats.Item
+}
+
+// summaryTitle returns the title of a summary, with its period.
+func summaryTitle(snap *stats.Snapshot) string {
+	if snap.Window == "" {
+		return "tailnginx summary"
+	}
+	return "tailnginx summary (" + snap.Window + ")"
+}
+
+// summaryLists returns the top paths, IPs and status codes of a snapshot,
+// without the empty lists.
+func summaryLists(snap *stats.Snapshot) []summaryList {
+	var lists []summaryList
+	for _, l := range []struct{ title, section string }{
+		{"Top paths", "paths"},
+		{"Top IPs", "ips"},
+		{"Status codes", "status"},
+	} {
+		items := snap.Items(l.section)
+		if len(items) == 0 {
+			continue
+		}
+		lists = append(lists, summaryList{title: l.title, items: items[:min(len(items), summaryTop)]})
+	}
+	return lists
+}
+
+// cutKey cuts a path or other key to keyLength runes.
+func cutKey(text string) string {
+	if r := []rune(text); len(r) > keyLength {
+		return string(r[:keyLength]) + "…"
+	}
+	return text
+}
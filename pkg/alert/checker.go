@@ -0,0 +1,137 @@
+package alert
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/metrics"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/sensitive"
+)
+
+// Checker evaluates the alert rules and the built-in checks, e.g. the 5xx
+// spike and brute force checks, over the entries of the pipeline, for the
+// dashboard and headless mode alike. It keeps the entries of the longest
+// window of its checks itself, up to a limit, so that the checks do not
+// depend on what the dashboard retains. It is safe for concurrent use.
+type Checker struct {
+	board      *Board
+	maxEntries int
+	rules      atomic.Pointer[Evaluator] // Swapped on reload
+	start      time.Time                 // Rates are judged once they cover their baseline since
+
+	mu            sync.Mutex
+	last          *Evaluator       // Rules of the previous check, to clear removed rules
+	entries       []parser.Visitor // In log order
+	evicted       time.Time        // Time of the newest entry dropped, zero if none was
+	rates         *metrics.RateTracker
+	uniques       *metrics.UniqueTracker // Unique IPs per minute
+	loginPaths    []string               // Authentication endpoints watched for brute force
+	loginFailures int
+	loginWindow   time.Duration
+	sensitive     *sensitive.Matcher
+	sensitiveHits []sensitiveHit // In log order
+}
+
+// NewChecker creates a checker without rules firing the alerts on b, with
+// the built-in sensitive paths and no login paths. It keeps at most
+// maxEntries entries, the oldest being evicted first.
+func NewChecker(b *Board, maxEntries int) *Checker {
+	return &Checker{
+		board:      b,
+		maxEntries: maxEntries,
+		start:      time.Now(),
+		rates:      metrics.NewRateTracker(rateInterval, rateBuckets),
+		uniques:    metrics.NewUniqueTracker(time.Minute, ddosBaselineMins+2, 10),
+		sensitive:  sensitive.Default(),
+	}
+}
+
+// Board returns the board the alerts of the rules are fired on.
+func (c *Checker) Board() *Board {
+	return c.board
+}
+
+// SetRules replaces the rules, e.g. after a reload. The alerts of removed
+// rules are cleared by the next check.
+func (c *Checker) SetRules(rules []Rule) {
+	c.rules.Store(NewEvaluator(rules))
+}
+
+// Observe records an entry, given in log order.
+func (c *Checker) Observe(v *parser.Visitor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, *v)
+	c.rates.Record(v.Time)
+	c.uniques.Add(v.Time, v.IP)
+	c.recordSensitiveHit(v)
+	if n := len(c.entries) - c.maxEntries; n > 0 {
+		c.evicted = c.entries[n-1].Time
+		c.entries = c.entries[n:]
+	}
+}
+
+// Check runs the built-in checks, and evaluates the threshold rules over the
+// entries of the last RuleWindow and the expression rules over those of
+// their windows, at now. The alerts of rules removed since the last check
+// are cleared. Returns true if the alerts may have changed.
+func (c *Checker) Check(now time.Time) bool {
+	rules := c.rules.Load()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := false
+	if rules != c.last {
+		for _, old := range c.last.Rules() {
+			kept := slices.ContainsFunc(rules.Rules(), func(r Rule) bool { return r.ID == old.ID })
+			if !kept && c.board.Clear(old.ID) {
+				changed = true
+			}
+		}
+		c.last = rules
+	}
+	c.expire(now, rules.Windows())
+	for _, check := range []func(time.Time) bool{c.checkErrorRate, c.checkTrafficDrop, c.checkDDoS, c.checkBruteForce, c.checkSensitive} {
+		if check(now) {
+			changed = true
+		}
+	}
+	if len(rules.Rules()) == 0 {
+		return changed
+	}
+
+	values := Measure(c.entries, now)
+	snapshot := NewSnapshot(c.entries, now, c.evicted, rules.Windows())
+	if rules.Evaluate(c.board, now, values) {
+		changed = true
+	}
+	if rules.EvaluateExprs(c.board, now, snapshot) {
+		changed = true
+	}
+	return changed
+}
+
+// expire drops the entries older than the longest window of the rules and
+// the built-in checks. They count as evicted, so that a window made longer
+// by a reload is not measured before it is covered again. Caller must hold
+// the lock.
+func (c *Checker) expire(now time.Time, windows []time.Duration) {
+	longest := max(RuleWindow, errorRateWindow+errorRateBaseline, ddosWindow)
+	if len(c.loginPaths) > 0 {
+		longest = max(longest, c.loginWindow)
+	}
+	for _, w := range windows {
+		longest = max(longest, w)
+	}
+	n := 0
+	for n < len(c.entries) && now.Sub(c.entries[n].Time) > longest {
+		n++
+	}
+	if n > 0 {
+		c.evicted = c.entries[n-1].Time
+		c.entries = c.entries[n:]
+	}
+}
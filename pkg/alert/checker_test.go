@@ -0,0 +1,97 @@
+package alert
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestCheckerRules tests that threshold rules fire over the last minute and
+// that the alerts of rules removed by a reload are cleared.
+func TestCheckerRules(t *testing.T) {
+	c := NewChecker(NewBoard(), 1000)
+	now := time.Now()
+	for i := 0; i < 120; i++ {
+		c.Observe(&parser.Visitor{Time: now.Add(-10 * time.Second), Status: 200})
+	}
+
+	rules, err := ParseRules([]string{"rps > 1", "busy: rps >= 2 -> critical"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetRules(rules)
+	if !c.Check(now) {
+		t.Error("Check() = false, want true when rules fire")
+	}
+	if active := c.Board().Active(); len(active) != 2 || active[0].ID != "busy" || active[1].ID != "rps" {
+		t.Fatalf("Active() = %+v, want busy and rps", active)
+	}
+
+	c.SetRules(rules[1:])
+	c.Check(now)
+	if active := c.Board().Active(); len(active) != 1 || active[0].ID != "busy" {
+		t.Errorf("Active() after reload = %+v, want only busy", active)
+	}
+}
+
+// TestCheckerExprRules tests that expression rules fire over their own
+// window, and that entries older than the longest window are dropped.
+func TestCheckerExprRules(t *testing.T) {
+	c := NewChecker(NewBoard(), 1000)
+	now := time.Now()
+	c.Observe(&parser.Visitor{Time: now.Add(-time.Hour), Status: 200})
+	for i := 0; i < 40; i++ {
+		v := parser.Visitor{Time: now.Add(-4 * time.Minute), Status: 200}
+		if i%4 == 0 {
+			v.Status = 502
+		}
+		c.Observe(&v)
+	}
+
+	rules, err := ParseRules([]string{
+		`errors: rate("5xx") / rate("all") > 20% && window("5m") -> critical`,
+		`recent: count("all") > 0`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetRules(rules)
+	if !c.Check(now) {
+		t.Error("Check() = false, want true when rules fire")
+	}
+	if active := c.Board().Active(); len(active) != 1 || active[0].ID != "errors" || active[0].Severity != SeverityCritical {
+		t.Fatalf("Active() = %+v, want errors only, the requests being older than a minute", active)
+	}
+	if len(c.entries) != 40 {
+		t.Errorf("kept %d entries, want the 40 of the last 5 minutes", len(c.entries))
+	}
+}
+
+// TestCheckerEviction tests that windows reaching past the evicted entries
+// are not measured.
+func TestCheckerEviction(t *testing.T) {
+	c := NewChecker(NewBoard(), 10)
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		c.Observe(&parser.Visitor{Time: now.Add(-time.Duration(20-i) * time.Second), Status: 200})
+	}
+	if len(c.entries) != 10 {
+		t.Fatalf("kept %d entries, want 10", len(c.entries))
+	}
+
+	rules, err := ParseRules([]string{`busy: count("all") >= 10`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetRules(rules)
+	c.Check(now)
+	if active := c.Board().Active(); len(active) != 0 {
+		t.Errorf("Active() = %+v, want none over a partial window", active)
+	}
+	snapshot := NewSnapshot(c.entries, now, c.evicted, []time.Duration{RuleWindow})
+	if holds, value := rules[0].Expr.Eval(snapshot); holds || !math.IsNaN(value) {
+		t.Errorf("Eval() = %v, %v, want false, NaN", holds, value)
+	}
+}
@@ -0,0 +1,87 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DDoS check
+const (
+	ddosID           = "ddos"
+	ddosWindow       = time.Minute
+	ddosMinRate      = 10.0 // Requests per second of the window before judging a flood
+	ddosMinBaseline  = 1.0  // Requests per second the baseline is counted as at least, for quiet sites
+	ddosMinUniques   = 10.0 // Unique IPs per minute the baseline is counted as at least
+	ddosSpike        = 5.0  // Times the baseline rate of a flood
+	ddosIPSpike      = 5.0  // Times the baseline unique IPs of a distributed flood
+	ddosDiversity    = 0.1  // Distinct paths per request at most of a distributed flood
+	ddosSinglePath   = 0.8  // Share of the requests of a single-path flood
+	ddosTopNetworks  = 5    // Source networks listed as offenders of a flood
+	ddosBaselineMins = 9    // Minutes before the window the unique IPs are compared to
+)
+
+// checkDDoS raises a critical alert when the request rate of the last minute
+// jumps to several times that of the 9 minutes before and either the unique
+// IPs jump along while few distinct paths are requested, the shape of a
+// botnet flood, or most requests hit a single path. The source networks
+// with the most requests are its offenders. Caller must hold the lock.
+func (c *Checker) checkDDoS(now time.Time) bool {
+	if now.Sub(c.start) < rateInterval*rateBuckets {
+		return false
+	}
+	rate, baseline := c.rateChange(now, ddosWindow)
+	if rate < ddosMinRate || rate < ddosSpike*max(baseline, ddosMinBaseline) {
+		return c.board.Clear(ddosID)
+	}
+
+	var baselineUniques float64
+	for _, n := range c.uniques.Buckets(now.Add(-2*time.Minute), ddosBaselineMins) {
+		baselineUniques += float64(n)
+	}
+	baselineUniques /= ddosBaselineMins
+
+	requests := 0
+	byPath := make(map[string]int)
+	byNetwork := make(map[string]int)
+	ips := make(map[string]bool)
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		v := &c.entries[i]
+		if now.Sub(v.Time) > ddosWindow {
+			break // Entries are kept in log order, older ones follow
+		}
+		requests++
+		path, _, _ := strings.Cut(v.Path, "?")
+		byPath[path]++
+		ips[v.IP] = true
+		if network, ok := ipNetwork(v.IP); ok {
+			byNetwork[network]++
+		}
+	}
+	if requests == 0 {
+		return c.board.Clear(ddosID)
+	}
+
+	top := topOffenders(byPath, 1)[0]
+	var shape string
+	switch {
+	case float64(top.Requests) >= ddosSinglePath*float64(requests):
+		shape = fmt.Sprintf("single-path flood of %s (%.0f%% of the requests)", top.Value, 100*float64(top.Requests)/float64(requests))
+	case float64(len(ips)) >= ddosIPSpike*max(baselineUniques, ddosMinUniques) &&
+		float64(len(byPath)) <= ddosDiversity*float64(requests):
+		shape = fmt.Sprintf("%d IPs (%.0f a minute before) on %d paths", len(ips), baselineUniques, len(byPath))
+	default:
+		return c.board.Clear(ddosID)
+	}
+
+	c.board.Fire(Alert{
+		ID:       ddosID,
+		Severity: SeverityCritical,
+		Message: fmt.Sprintf("Possible DDoS: %.1f req/s in the last minute, %.1f req/s before, %s from %d networks",
+			rate, baseline, shape, len(byNetwork)),
+		Value:     rate,
+		Window:    ddosWindow,
+		Offenders: topOffenders(byNetwork, ddosTopNetworks),
+	})
+	return true
+}
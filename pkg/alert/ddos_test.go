@@ -0,0 +1,94 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// newDDoSTestChecker returns a checker with a baseline of 2 req/s from 5 IPs
+// over 20 paths in the 9 minutes before the last one.
+func newDDoSTestChecker(now time.Time) *Checker {
+	c := NewChecker(NewBoard(), 100000)
+	c.start = now.Add(-time.Hour)
+	var batch []parser.Visitor
+	for age := 600 * time.Second; age > 120*time.Second; age -= 500 * time.Millisecond {
+		i := len(batch)
+		batch = append(batch, parser.Visitor{
+			Time: now.Add(-age),
+			IP:   fmt.Sprintf("192.0.2.%d", i%5),
+			Path: fmt.Sprintf("/page/%d", i%20),
+		})
+	}
+	observe(c, batch)
+	return c
+}
+
+// flood adds requests at rps over 40 seconds of the last minute, with the
+// IP and path of each request.
+func flood(c *Checker, now time.Time, rps int, source func(i int) (ip, path string)) {
+	var batch []parser.Visitor
+	for i := 0; i < 40*rps; i++ {
+		ip, path := source(i)
+		batch = append(batch, parser.Visitor{
+			Time: now.Add(-55*time.Second + time.Duration(i)*time.Second/time.Duration(rps)),
+			IP:   ip,
+			Path: path,
+		})
+	}
+	observe(c, batch)
+}
+
+// observe records entries with the checker, in order.
+func observe(c *Checker, entries []parser.Visitor) {
+	for i := range entries {
+		c.Observe(&entries[i])
+	}
+}
+
+// TestCheckDDoS tests that request floods from many new IPs on few paths,
+// or on a single path, raise a DDoS alert and that other surges do not.
+func TestCheckDDoS(t *testing.T) {
+	now := time.Date(2025, 10, 10, 12, 0, 5, 0, time.UTC)
+
+	c := newDDoSTestChecker(now)
+	if c.checkDDoS(now) || len(c.board.Active()) != 0 {
+		t.Fatalf("checkDDoS() = %+v, want no alert for the baseline", c.board.Active())
+	}
+
+	// 50 req/s from 400 IPs of 40 networks on 3 paths
+	flood(c, now, 50, func(i int) (string, string) {
+		return fmt.Sprintf("10.%d.0.%d", i%40, i%400/40), fmt.Sprintf("/api/%d", i%3)
+	})
+	c.checkDDoS(now)
+	active := c.board.Active()
+	if len(active) != 1 || active[0].ID != ddosID || active[0].Severity != SeverityCritical {
+		t.Fatalf("checkDDoS() = %+v, want a critical alert", active)
+	}
+	if a := active[0]; !strings.Contains(a.Message, "400 IPs") || !strings.Contains(a.Message, "from 40 networks") || len(a.Offenders) != ddosTopNetworks {
+		t.Errorf("checkDDoS() alert = %+v", a)
+	}
+
+	// A single-path flood needs no new IPs
+	c = newDDoSTestChecker(now)
+	flood(c, now, 30, func(i int) (string, string) {
+		return fmt.Sprintf("203.0.113.%d", i%3), "/login?user=admin"
+	})
+	c.checkDDoS(now)
+	if active := c.board.Active(); len(active) != 1 || !strings.Contains(active[0].Message, "single-path flood of /login (100% of the requests)") ||
+		active[0].Offenders[0].Value != "203.0.113.0/24" {
+		t.Errorf("checkDDoS() = %+v, want a single-path flood", active)
+	}
+
+	// A surge of the usual clients over many paths, e.g. a crawl, is no DDoS
+	c = newDDoSTestChecker(now)
+	flood(c, now, 30, func(i int) (string, string) {
+		return fmt.Sprintf("192.0.2.%d", i%5), fmt.Sprintf("/page/%d", i)
+	})
+	if c.checkDDoS(now) || len(c.board.Active()) != 0 {
+		t.Errorf("checkDDoS() = %+v, want no alert for a crawl", c.board.Active())
+	}
+}
@@ -0,0 +1,106 @@
+package alert
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// desktopInterval is the least time between two desktop notifications of
+// the same alert, e.g. of a watched IP seen in every check.
+const desktopInterval = 30 * time.Second
+
+// Desktop notification methods
+const (
+	DesktopOSC        = "osc"         // OSC 777 escape sequence and bell, through the terminal
+	DesktopNotifySend = "notify-send" // notify-send command of freedesktop systems
+)
+
+// Desktop shows alerts as desktop notifications, so that the dashboard
+// does not need to be watched. Notifications are queued by Notify and shown
+// by Run, so that a slow command does not hold up the checks.
+type Desktop struct {
+	queue[Alert]
+	method   string
+	terminal io.Writer // Destination of OSC sequences
+	command  func(name string, args ...string) error
+	now      func() time.Time
+	mu       sync.Mutex
+	last     map[string]time.Time // Last notification of each alert ID
+}
+
+// NewDesktop returns a desktop notifier using method: DesktopOSC writes an
+// OSC 777 notification, understood by terminals such as foot, WezTerm or
+// rxvt-unicode even over SSH, followed by a bell, to terminal.
+// DesktopNotifySend runs notify-send.
+func NewDesktop(method string, terminal io.Writer) (*Desktop, error) {
+	switch method {
+	case DesktopOSC, DesktopNotifySend:
+	default:
+		return nil, fmt.Errorf("desktop: unknown method %q, expected %s or %s", method, DesktopOSC, DesktopNotifySend)
+	}
+	d := &Desktop{
+		method:   method,
+		terminal: terminal,
+		command:  func(name string, args ...string) error { return exec.Command(name, args...).Run() },
+		now:      time.Now,
+		last:     make(map[string]time.Time),
+	}
+	d.queue = newQueue(d.Send)
+	return d, nil
+}
+
+// Notify queues a notification of an alert, unless the same alert was
+// notified less than desktopInterval ago or the queue is full.
+func (d *Desktop) Notify(a Alert) {
+	now := d.now()
+	d.mu.Lock()
+	if now.Sub(d.last[a.ID]) < desktopInterval {
+		d.mu.Unlock()
+		return
+	}
+	d.last[a.ID] = now
+	d.mu.Unlock()
+	d.enqueue(a)
+}
+
+// Send shows a notification of an alert.
+func (d *Desktop) Send(a Alert) error {
+	title := "tailnginx " + a.Severity.String()
+	switch d.method {
+	case DesktopOSC:
+		// Fields are separated by semicolons, which the title cannot contain
+		seq := fmt.Sprintf("\x1b]777;notify;%s;%s\x1b\\\a",
+			strings.ReplaceAll(printable(title), ";", ","), printable(a.Message))
+		if _, err := io.WriteString(d.terminal, seq); err != nil {
+			return fmt.Errorf("desktop: %w", err)
+		}
+	case DesktopNotifySend:
+		urgency := "normal"
+		switch a.Severity {
+		case SeverityCritical:
+			urgency = "critical"
+		case SeverityInfo:
+			urgency = "low"
+		}
+		if err := d.command("notify-send", "--app-name=tailnginx", "--urgency="+urgency, "--", title, a.Message); err != nil {
+			return fmt.Errorf("desktop: notify-send: %w", err)
+		}
+	}
+	return nil
+}
+
+// printable drops the control characters of text, which could end an
+// escape sequence early.
+func printable(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,67 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDesktopOSC(t *testing.T) {
+	var terminal strings.Builder
+	d, err := NewDesktop(DesktopOSC, &terminal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := Alert{ID: "watch:ip:203.0.113.7", Severity: SeverityInfo, Message: "watched ip 203.0.113.7: GET /a;b 200\x1b]0;x\a"}
+	if err := d.Send(a); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	want := "\x1b]777;notify;tailnginx INFO;watched ip 203.0.113.7: GET /a;b 200]0;x\x1b\\\a"
+	if terminal.String() != want {
+		t.Errorf("terminal = %q, want %q", terminal.String(), want)
+	}
+}
+
+func TestDesktopNotifySend(t *testing.T) {
+	d, err := NewDesktop(DesktopNotifySend, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args []string
+	d.command = func(name string, a ...string) error {
+		args = append([]string{name}, a...)
+		return nil
+	}
+	if err := d.Send(testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	want := []string{"notify-send", "--app-name=tailnginx", "--urgency=critical", "--", "tailnginx CRIT", testAlert().Message}
+	if strings.Join(args, "|") != strings.Join(want, "|") {
+		t.Errorf("ran %q, want %q", args, want)
+	}
+
+	if _, err := NewDesktop("growl", nil); err == nil {
+		t.Error("NewDesktop() with an unknown method should fail")
+	}
+}
+
+func TestDesktopThrottle(t *testing.T) {
+	d, err := NewDesktop(DesktopOSC, &strings.Builder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	a := Alert{ID: "watch:path:/admin"}
+	d.Notify(a)
+	now = now.Add(time.Second)
+	d.Notify(a)
+	d.Notify(Alert{ID: "5xx_spike"})
+	now = now.Add(desktopInterval)
+	d.Notify(a)
+
+	if n := len(d.items); n != 3 {
+		t.Errorf("queued %d notifications, want 3", n)
+	}
+}
@@ -0,0 +1,156 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/stats"
+)
+
+// severityColors are the embed colors of alerts, as RGB integers.
+var severityColors = map[Severity]int{
+	SeverityCritical: 0xE01E5A,
+	SeverityWarning:  0xECB22E,
+	SeverityInfo:     0x36C5F0,
+}
+
+// summaryColor is the embed color of summaries.
+const summaryColor = 0x2EB67D
+
+// discordMessage is the body of a Discord webhook request. Mentions in the
+// text are never resolved, so log contents cannot ping anyone.
+type discordMessage struct {
+	Content         string          `json:"content,omitempty"`
+	Embeds          []discordEmbed  `json:"embeds"`
+	AllowedMentions discordMentions `json:"allowed_mentions"`
+}
+
+type discordMentions struct {
+	Parse []string `json:"parse"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+// Discord posts alerts and summaries to a Discord webhook as embeds.
+// Alerts are queued by Notify and delivered by Run, so that slow requests
+// do not hold up the checks.
+type Discord struct {
+	poster
+	queue[Alert]
+	url string
+}
+
+// NewDiscord returns a Discord notifier posting to the webhook url, e.g.
+// https://discord.com/api/webhooks/ID/TOKEN.
+func NewDiscord(url string) (*Discord, error) {
+	if err := checkURL(url); err != nil {
+		return nil, fmt.Errorf("discord: %w", err)
+	}
+	d := &Discord{poster: newPoster("discord"), url: url}
+	d.queue = newQueue(d.Send)
+	return d, nil
+}
+
+// Notify queues an alert for delivery.
+func (d *Discord) Notify(a Alert) {
+	d.enqueue(a)
+}
+
+// Send posts an alert.
+func (d *Discord) Send(a Alert) error {
+	return d.postMessage(discordAlert(a))
+}
+
+// Summary posts a summary of a snapshot. It is a stats.Output, e.g. for
+// hourly summaries.
+func (d *Discord) Summary(snap *stats.Snapshot) error {
+	return d.postMessage(discordSummary(snap))
+}
+
+func (d *Discord) postMessage(msg discordMessage) error {
+	msg.AllowedMentions.Parse = []string{}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("discord: %w", err)
+	}
+	return d.post(d.url, body)
+}
+
+// discordAlert formats an alert as an embed colored by severity: its
+// severity and rule, message, details and top offenders.
+func discordAlert(a Alert) discordMessage {
+	fields := []discordField{
+		{Name: "Value", Value: strconv.FormatFloat(a.Value, 'f', 1, 64), Inline: true},
+	}
+	if a.Window > 0 {
+		fields = append(fields, discordField{Name: "Window", Value: a.Window.String(), Inline: true})
+	}
+	if len(a.Offenders) > 0 {
+		var lines []string
+		for _, o := range a.Offenders {
+			lines = append(lines, fmt.Sprintf("%s %d requests", discordCode(o.Value), o.Requests))
+		}
+		fields = append(fields, discordField{Name: "Top offenders", Value: strings.Join(lines, "\n")})
+	}
+	return discordMessage{
+		Embeds: []discordEmbed{{
+			Title:       fmt.Sprintf("%s %s", a.Severity, a.ID),
+			Description: a.Message,
+			Color:       severityColors[a.Severity],
+			Fields:      fields,
+			Timestamp:   a.Since.UTC().Format(time.RFC3339),
+		}},
+	}
+}
+
+// discordSummary formats a snapshot as an embed: the traffic totals and
+// the top paths, IPs and status codes.
+func discordSummary(snap *stats.Snapshot) discordMessage {
+	fields := []discordField{
+		{Name: "Requests", Value: strconv.Itoa(snap.Requests), Inline: true},
+		{Name: "Unique visitors", Value: strconv.Itoa(snap.UniqueVisitors), Inline: true},
+		{Name: "Bytes", Value: strconv.FormatInt(snap.Bytes, 10), Inline: true},
+	}
+	for _, l := range summaryLists(snap) {
+		var lines []string
+		for _, item := range l.items {
+			lines = append(lines, fmt.Sprintf("%s %d", discordCode(item.Key), item.Count))
+		}
+		fields = append(fields, discordField{Name: l.title, Value: strings.Join(lines, "\n"), Inline: true})
+	}
+	return discordMessage{
+		Embeds: []discordEmbed{{
+			Title:     summaryTitle(snap),
+			Color:     summaryColor,
+			Fields:    fields,
+			Footer:    &discordFooter{Text: snap.LogPath},
+			Timestamp: snap.Time.UTC().Format(time.RFC3339),
+		}},
+	}
+}
+
+// discordCode formats a path, IP or other key as inline code, cut to
+// keyLength runes. Backticks, which would end the code, are replaced.
+func discordCode(text string) string {
+	return "`" + strings.ReplaceAll(cutKey(text), "`", "'") + "`"
+}
@@ -0,0 +1,78 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/stats"
+)
+
+func TestDiscordSend(t *testing.T) {
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d, err := NewDiscord(srv.URL + "/api/webhooks/1/token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Send(testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	var msg struct {
+		Embeds          []discordEmbed `json:"embeds"`
+		AllowedMentions *struct {
+			Parse []string `json:"parse"`
+		} `json:"allowed_mentions"`
+	}
+	if err := json.Unmarshal(bodies[0], &msg); err != nil {
+		t.Fatalf("body %s: %v", bodies[0], err)
+	}
+	if msg.AllowedMentions == nil || msg.AllowedMentions.Parse == nil || len(msg.AllowedMentions.Parse) != 0 {
+		t.Errorf("body %s should disable mentions", bodies[0])
+	}
+	e := msg.Embeds[0]
+	if e.Title != "CRIT 5xx_spike" || e.Color != severityColors[SeverityCritical] || e.Timestamp != "2025-10-10T12:00:00Z" {
+		t.Errorf("embed = %+v", e)
+	}
+	if len(e.Fields) != 3 || e.Fields[1].Value != "1m0s" || e.Fields[2].Value != "`203.0.113.7` 15 requests" {
+		t.Errorf("fields = %+v", e.Fields)
+	}
+}
+
+func TestDiscordSummary(t *testing.T) {
+	snap := &stats.Snapshot{
+		Time:     time.Date(2025, 10, 10, 13, 0, 0, 0, time.UTC),
+		LogPath:  "/var/log/nginx/access.log",
+		Window:   "1h0m0s",
+		Requests: 120,
+	}
+	snap.AddSection("paths", map[string]int{"/a": 100, "/`x`": 20})
+	snap.AddSection("ips", map[string]int{})
+
+	e := discordSummary(snap).Embeds[0]
+	if e.Title != "tailnginx summary (1h0m0s)" || e.Footer.Text != "/var/log/nginx/access.log" {
+		t.Errorf("embed = %+v", e)
+	}
+	if len(e.Fields) != 4 {
+		t.Fatalf("got %d fields, want the totals and the paths", len(e.Fields))
+	}
+	if f := e.Fields[3]; f.Name != "Top paths" || f.Value != "`/a` 100\n`/'x'` 20" {
+		t.Errorf("paths field = %+v", f)
+	}
+}
+
+func TestNewDiscord(t *testing.T) {
+	if _, err := NewDiscord("discord.com/api/webhooks/1/token"); err == nil {
+		t.Error("NewDiscord() without a scheme should fail")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd)
+
+package alert
+
+import "errors"
+
+// DiskUsage returns the used percentage of the filesystem holding path.
+// It is not supported on this platform.
+func DiskUsage(string) (float64, error) {
+	return 0, errors.New("disk usage not supported on this platform")
+}
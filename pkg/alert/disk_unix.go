@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd
+
+package alert
+
+import "syscall"
+
+// DiskUsage returns the used percentage of the filesystem holding path.
+func DiskUsage(path string) (float64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+
+	total := uint64(st.Blocks) * uint64(st.Bsize) // #nosec G115 -- block counts and sizes are non-negative
+	if total == 0 {
+		return 0, nil
+	}
+	avail := uint64(st.Bavail) * uint64(st.Bsize) // #nosec G115 -- block counts and sizes are non-negative
+	return float64(total-avail) / float64(total) * 100, nil
+}
@@ -0,0 +1,219 @@
+package alert
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/stats"
+)
+
+// Email delivery settings
+const (
+	emailTimeout = 30 * time.Second // Whole SMTP session
+	digestTop    = 10               // Entries per section of digest reports
+)
+
+// SMTP holds the settings of the mail server emails are sent through.
+type SMTP struct {
+	Addr     string // Server address; port 465 uses TLS, others STARTTLS when offered
+	Username string // Empty to send without authentication
+	Password string
+	From     string
+	To       []string
+}
+
+// Email sends alerts and digest reports by email. Alerts are queued by
+// Notify and delivered by Run, so that a slow server does not hold up the
+// checks.
+type Email struct {
+	queue[Alert]
+	smtp SMTP
+	host string
+	now  func() time.Time
+}
+
+// NewEmail returns an email sender through the given server.
+func NewEmail(s SMTP) (*Email, error) {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("email: server %q: %w", s.Addr, err)
+	}
+	if _, err := mail.ParseAddress(s.From); err != nil {
+		return nil, fmt.Errorf("email: sender %q: %w", s.From, err)
+	}
+	if len(s.To) == 0 {
+		return nil, errors.New("email: no recipient")
+	}
+	for _, to := range s.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("email: recipient %q: %w", to, err)
+		}
+	}
+	e := &Email{smtp: s, host: host, now: time.Now}
+	e.queue = newQueue(e.Send)
+	return e, nil
+}
+
+// Notify queues an alert for delivery.
+func (e *Email) Notify(a Alert) {
+	e.enqueue(a)
+}
+
+// Send emails an alert: its message, details and top offenders.
+func (e *Email) Send(a Alert) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s\n\n", a.Message)
+	fmt.Fprintf(&body, "Rule:     %s\n", a.ID)
+	fmt.Fprintf(&body, "Severity: %s\n", a.Severity)
+	fmt.Fprintf(&body, "Value:    %.1f\n", a.Value)
+	if a.Window > 0 {
+		fmt.Fprintf(&body, "Window:   %s\n", a.Window)
+	}
+	fmt.Fprintf(&body, "Since:    %s\n", a.Since.Format(time.RFC1123))
+	if len(a.Offenders) > 0 {
+		fmt.Fprintf(&body, "\nTop offenders:\n")
+		for _, o := range a.Offenders {
+			fmt.Fprintf(&body, "  %s  %d requests\n", o.Value, o.Requests)
+		}
+	}
+
+	var msg bytes.Buffer
+	e.writeHeader(&msg, fmt.Sprintf("[tailnginx] %s %s: %s", a.Severity, a.ID, a.Message))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&msg)
+	qp.Write([]byte(body.String()))
+	qp.Close()
+	return e.deliver(msg.Bytes())
+}
+
+// Digest emails a report of a snapshot: the summary as text, with the HTML
+// report attached. It is a stats.Output, e.g. for daily digests.
+func (e *Email) Digest(snap *stats.Snapshot) error {
+	var text, html bytes.Buffer
+	if err := snap.WriteText(&text, digestTop); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	if err := snap.WriteHTML(&html, digestTop); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+
+	var msg bytes.Buffer
+	e.writeHeader(&msg, fmt.Sprintf("[tailnginx] Report for %s: %d requests", snap.LogPath, snap.Requests))
+	mw := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	part, _ := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	qp := quotedprintable.NewWriter(part)
+	qp.Write(text.Bytes())
+	qp.Close()
+
+	name := "tailnginx-" + snap.Time.Format("20060102-1504") + ".html"
+	part, _ = mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType("text/html", map[string]string{"charset": "utf-8", "name": name})},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+	})
+	writeBase64(part, html.Bytes())
+	mw.Close()
+	return e.deliver(msg.Bytes())
+}
+
+// writeHeader writes the address, subject and date headers of a message.
+func (e *Email) writeHeader(msg *bytes.Buffer, subject string) {
+	fmt.Fprintf(msg, "From: %s\r\n", e.smtp.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(e.smtp.To, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+}
+
+// writeBase64 writes data in base64 lines of 76 characters.
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+// deliver sends a message to the recipients in a single SMTP session.
+func (e *Email) deliver(msg []byte) error {
+	if err := e.session(msg); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
+
+func (e *Email) session(msg []byte) error {
+	tlsConfig := &tls.Config{ServerName: e.host}
+	dialer := &net.Dialer{Timeout: emailTimeout}
+	var conn net.Conn
+	var err error
+	if _, port, _ := net.SplitHostPort(e.smtp.Addr); port == "465" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", e.smtp.Addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", e.smtp.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(emailTimeout))
+	c, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.smtp.Username != "" {
+		// Plain authentication is refused over unencrypted connections,
+		// except to localhost
+		if err := c.Auth(smtp.PlainAuth("", e.smtp.Username, e.smtp.Password, e.host)); err != nil {
+			return err
+		}
+	}
+	from, _ := mail.ParseAddress(e.smtp.From)
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range e.smtp.To {
+		addr, _ := mail.ParseAddress(to)
+		if err := c.Rcpt(addr.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
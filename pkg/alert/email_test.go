@@ -0,0 +1,186 @@
+package alert
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/stats"
+)
+
+// smtpSession is what a fake SMTP server received in a session.
+type smtpSession struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP serves a single SMTP session with PLAIN authentication and
+// sends what it received on the returned channel.
+func fakeSMTP(t *testing.T) (string, <-chan smtpSession) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+		var s smtpSession
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimSpace(line)
+			switch verb := strings.ToUpper(strings.Fields(cmd)[0]); verb {
+			case "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				s.auth = cmd
+				reply("235 ok")
+			case "MAIL":
+				s.from = cmd
+				reply("250 ok")
+			case "RCPT":
+				s.to = append(s.to, cmd)
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				s.data = data.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				sessions <- s
+				return
+			default:
+				reply("502 unknown " + verb)
+			}
+		}
+	}()
+	return ln.Addr().String(), sessions
+}
+
+func testEmail(t *testing.T, addr string) *Email {
+	e, err := NewEmail(SMTP{
+		Addr:     addr,
+		Username: "ops",
+		Password: "secret",
+		From:     "tailnginx <tailnginx@example.com>",
+		To:       []string{"ops@example.com", "Oncall <oncall@example.com>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Date(2025, 10, 10, 12, 5, 0, 0, time.UTC) }
+	return e
+}
+
+func TestEmailSend(t *testing.T) {
+	addr, sessions := fakeSMTP(t)
+	e := testEmail(t, addr)
+	if err := e.Send(testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	s := <-sessions
+	if !strings.HasPrefix(s.auth, "AUTH PLAIN ") {
+		t.Errorf("auth = %q", s.auth)
+	}
+	if s.from != "MAIL FROM:<tailnginx@example.com>" || len(s.to) != 2 || s.to[1] != "RCPT TO:<oncall@example.com>" {
+		t.Errorf("envelope = %q %q", s.from, s.to)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(s.data))
+	if err != nil {
+		t.Fatalf("message %q: %v", s.data, err)
+	}
+	if subject := msg.Header.Get("Subject"); subject != `[tailnginx] CRIT 5xx_spike: 5xx spike: 40% of "50" requests` {
+		t.Errorf("subject = %q", subject)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	for _, want := range []string{"Window:   1m0s", "203.0.113.7  15 requests"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestEmailDigest(t *testing.T) {
+	addr, sessions := fakeSMTP(t)
+	e := testEmail(t, addr)
+	snap := &stats.Snapshot{
+		Time:     time.Date(2025, 10, 11, 0, 0, 0, 0, time.UTC),
+		LogPath:  "/var/log/nginx/access.log",
+		Window:   "24h0m0s",
+		Requests: 3,
+	}
+	snap.AddSection("paths", map[string]int{"/a": 3})
+	if err := e.Digest(snap); err != nil {
+		t.Fatalf("Digest() error = %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader((<-sessions).data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	text, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(text); !strings.Contains(string(b), "paths: /a (3)") {
+		t.Errorf("text part = %q", b)
+	}
+	html, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html.FileName() != "tailnginx-20251011-0000.html" {
+		t.Errorf("attachment name = %q", html.FileName())
+	}
+	// multipart decodes quoted-printable only, the attachment is base64
+	b, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, html))
+	if err != nil || !strings.Contains(string(b), "<h1>tailnginx report</h1>") {
+		t.Errorf("attachment = %q, err %v", b, err)
+	}
+}
+
+func TestNewEmail(t *testing.T) {
+	for _, s := range []SMTP{
+		{Addr: "mail.example.com", From: "a@example.com", To: []string{"b@example.com"}},
+		{Addr: "mail.example.com:587", From: "nobody", To: []string{"b@example.com"}},
+		{Addr: "mail.example.com:587", From: "a@example.com"},
+	} {
+		if _, err := NewEmail(s); err == nil {
+			t.Errorf("NewEmail(%+v) should fail", s)
+		}
+	}
+}
@@ -0,0 +1,587 @@
+package alert
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// maxExprWindow is the longest window expressions can measure over.
+const maxExprWindow = 24 * time.Hour
+
+// statusClassRegex matches the status classes of count() and rate(): all
+// requests, a class such as 5xx or a code such as 404.
+var statusClassRegex = regexp.MustCompile(`^(all|[1-5]xx|[1-5][0-9][0-9])$`)
+
+// percentileRegex matches the percentiles of latency(), e.g. p95.
+var percentileRegex = regexp.MustCompile(`^p([1-9][0-9]?|100)$`)
+
+// Expr is a compiled rule condition over the requests of a window, e.g.
+// rate("5xx") / rate("all") > 0.05 && window("5m"). Numbers, with an
+// optional % dividing them by 100, and these functions are combined with
+// + - * /, compared with > >= < <= == != and the comparisons with && (and),
+// || (or), ! (not) and parentheses:
+//
+//	count(class)    requests of the window of a class: "all", e.g. "5xx", or e.g. "404"
+//	rate(class)     count(class) per second
+//	bandwidth()     bytes sent per second
+//	latency(p)      percentile of $request_time in seconds, e.g. latency("p95")
+//	window(d)       measures the functions over d, e.g. window("5m"), instead of a minute
+//
+// window() is joined to the rest of the condition with &&, outside of
+// parentheses, ! and ||. A division by zero, a latency without request
+// times or a window reaching past the entries kept is not a number, which
+// makes the comparisons using it false.
+type Expr struct {
+	text      string
+	window    time.Duration
+	cond      func(m *measurement) bool
+	value     func(m *measurement) float64 // Left side of the first comparison, nil without
+	valueText string
+}
+
+// String returns the expression as written.
+func (e *Expr) String() string {
+	return e.text
+}
+
+// Window returns the window the expression measures over.
+func (e *Expr) Window() time.Duration {
+	return e.window
+}
+
+// Eval evaluates the expression on a snapshot and returns the value of the
+// left side of its first comparison, NaN without.
+func (e *Expr) Eval(s *Snapshot) (bool, float64) {
+	m := s.measure(e.window)
+	value := math.NaN()
+	if e.value != nil {
+		value = e.value(m)
+	}
+	return e.cond(m), value
+}
+
+// ParseExpr compiles an expression, checking its functions, their
+// arguments and the types of its operands.
+func ParseExpr(text string) (*Expr, error) {
+	tokens, err := lexExpr(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %w", err)
+	}
+	p := &exprCompiler{text: text, tokens: tokens}
+	n, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = p.errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err == nil && n.cond == nil {
+		err = fmt.Errorf("expected a condition, e.g. 'rate(\"5xx\") > 1', not a number")
+	}
+	if err == nil && n.window {
+		err = fmt.Errorf("window() needs a condition, e.g. 'rate(\"5xx\") > 1 && %s'", text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %w", err)
+	}
+	e := &Expr{text: text, window: RuleWindow, cond: n.cond, value: p.value, valueText: p.valueText}
+	if p.window > 0 {
+		e.window = p.window
+	}
+	return e, nil
+}
+
+// exprToken is a number, a name, a quoted string or a symbol such as &&.
+type exprToken struct {
+	text   string
+	kind   byte // 'n'umber, 'i'dentifier, 's'tring or 'o'perator
+	offset int
+}
+
+// exprOperators are the symbols of expressions, longest first.
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "(", ")", "%"}
+
+// lexExpr splits an expression into tokens.
+func lexExpr(text string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(text) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			s, err := strconv.Unquote(text[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %w", i, err)
+			}
+			tokens = append(tokens, exprToken{text: s, kind: 's', offset: i})
+			i = end + 1
+		case c >= '0' && c <= '9' || c == '.':
+			end := i
+			for end < len(text) && (text[end] >= '0' && text[end] <= '9' || text[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, exprToken{text: text[i:end], kind: 'n', offset: i})
+			i = end
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_':
+			end := i
+			for end < len(text) && (text[end] >= 'a' && text[end] <= 'z' || text[end] >= 'A' && text[end] <= 'Z' || text[end] >= '0' && text[end] <= '9' || text[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, exprToken{text: text[i:end], kind: 'i', offset: i})
+			i = end
+		default:
+			op := ""
+			for _, o := range exprOperators {
+				if strings.HasPrefix(text[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			tokens = append(tokens, exprToken{text: op, kind: 'o', offset: i})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+// exprNode is a compiled part of an expression: a number or a condition.
+type exprNode struct {
+	num    func(m *measurement) float64
+	cond   func(m *measurement) bool
+	window bool // A window() call, only joined to conditions with &&
+}
+
+// exprCompiler compiles tokens by recursive descent, && binding tighter
+// than ||, comparisons tighter than &&, and * / tighter than + -.
+type exprCompiler struct {
+	text      string
+	tokens    []exprToken
+	pos       int
+	depth     int // Parentheses and ! around the next token
+	window    time.Duration
+	windowAt  int // Offset of the window() call
+	value     func(m *measurement) float64
+	valueText string
+}
+
+// peek returns the next operator, or "" if the next token is none.
+func (p *exprCompiler) peek() string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != 'o' {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+// errorf returns an error at the next token.
+func (p *exprCompiler) errorf(format string, args ...any) error {
+	at := len(p.text)
+	if p.pos < len(p.tokens) {
+		at = p.tokens[p.pos].offset
+	}
+	return fmt.Errorf("%s at %d", fmt.Sprintf(format, args...), at)
+}
+
+// offset returns where the next token starts, or the end of the text.
+func (p *exprCompiler) offset() int {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].offset
+	}
+	return len(p.text)
+}
+
+func (p *exprCompiler) or() (exprNode, error) {
+	left, err := p.and()
+	joined := false
+	for err == nil && p.peek() == "||" {
+		op := p.tokens[p.pos]
+		p.pos++
+		var right exprNode
+		if right, err = p.and(); err != nil {
+			break
+		}
+		if left.cond == nil || right.cond == nil {
+			return exprNode{}, fmt.Errorf("|| needs conditions on both sides at %d", op.offset)
+		}
+		l, r := left.cond, right.cond
+		left = exprNode{cond: func(m *measurement) bool { return l(m) || r(m) }}
+		joined = true
+	}
+	// Deeper window() calls were rejected already
+	if err == nil && joined && p.depth == 0 && p.window > 0 {
+		return exprNode{}, fmt.Errorf("window() at %d cannot be joined with ||, join it to the whole condition with &&", p.windowAt)
+	}
+	return left, err
+}
+
+func (p *exprCompiler) and() (exprNode, error) {
+	left, err := p.not()
+	for err == nil && p.peek() == "&&" {
+		op := p.tokens[p.pos]
+		p.pos++
+		var right exprNode
+		if right, err = p.not(); err != nil {
+			break
+		}
+		if left.cond == nil || right.cond == nil {
+			return exprNode{}, fmt.Errorf("&& needs conditions on both sides at %d", op.offset)
+		}
+		// window() only sets the window of the other conditions
+		switch {
+		case left.window:
+			left = right
+		case right.window:
+		default:
+			l, r := left.cond, right.cond
+			left = exprNode{cond: func(m *measurement) bool { return l(m) && r(m) }}
+		}
+	}
+	return left, err
+}
+
+func (p *exprCompiler) not() (exprNode, error) {
+	if p.peek() != "!" {
+		return p.comparison()
+	}
+	op := p.tokens[p.pos]
+	p.pos++
+	p.depth++
+	n, err := p.not()
+	p.depth--
+	if err != nil {
+		return exprNode{}, err
+	}
+	if n.cond == nil {
+		return exprNode{}, fmt.Errorf("! needs a condition at %d", op.offset)
+	}
+	return exprNode{cond: func(m *measurement) bool { return !n.cond(m) }}, nil
+}
+
+func (p *exprCompiler) comparison() (exprNode, error) {
+	start := p.offset()
+	left, err := p.sum()
+	if err != nil {
+		return exprNode{}, err
+	}
+	end := p.offset()
+	op := p.peek()
+	switch op {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return left, nil
+	}
+	at := p.tokens[p.pos].offset
+	p.pos++
+	right, err := p.sum()
+	if err != nil {
+		return exprNode{}, err
+	}
+	if left.num == nil || right.num == nil {
+		return exprNode{}, fmt.Errorf("%s needs numbers on both sides at %d", op, at)
+	}
+	if p.value == nil {
+		p.value, p.valueText = left.num, strings.TrimSpace(p.text[start:end])
+	}
+	l, r := left.num, right.num
+	var cond func(a, b float64) bool
+	switch op {
+	case ">":
+		cond = func(a, b float64) bool { return a > b }
+	case ">=":
+		cond = func(a, b float64) bool { return a >= b }
+	case "<":
+		cond = func(a, b float64) bool { return a < b }
+	case "<=":
+		cond = func(a, b float64) bool { return a <= b }
+	case "==":
+		cond = func(a, b float64) bool { return a == b }
+	default:
+		cond = func(a, b float64) bool { return a != b }
+	}
+	return exprNode{cond: func(m *measurement) bool { return cond(l(m), r(m)) }}, nil
+}
+
+func (p *exprCompiler) sum() (exprNode, error) {
+	left, err := p.term()
+	for err == nil && (p.peek() == "+" || p.peek() == "-") {
+		op := p.tokens[p.pos]
+		p.pos++
+		var right exprNode
+		if right, err = p.term(); err != nil {
+			break
+		}
+		if left.num == nil || right.num == nil {
+			return exprNode{}, fmt.Errorf("%s needs numbers on both sides at %d", op.text, op.offset)
+		}
+		l, r := left.num, right.num
+		if op.text == "+" {
+			left = exprNode{num: func(m *measurement) float64 { return l(m) + r(m) }}
+		} else {
+			left = exprNode{num: func(m *measurement) float64 { return l(m) - r(m) }}
+		}
+	}
+	return left, err
+}
+
+func (p *exprCompiler) term() (exprNode, error) {
+	left, err := p.unary()
+	for err == nil && (p.peek() == "*" || p.peek() == "/") {
+		op := p.tokens[p.pos]
+		p.pos++
+		var right exprNode
+		if right, err = p.unary(); err != nil {
+			break
+		}
+		if left.num == nil || right.num == nil {
+			return exprNode{}, fmt.Errorf("%s needs numbers on both sides at %d", op.text, op.offset)
+		}
+		l, r := left.num, right.num
+		if op.text == "*" {
+			left = exprNode{num: func(m *measurement) float64 { return l(m) * r(m) }}
+		} else {
+			left = exprNode{num: func(m *measurement) float64 { return divide(l(m), r(m)) }}
+		}
+	}
+	return left, err
+}
+
+// divide divides a by b, or returns NaN if b is 0.
+func divide(a, b float64) float64 {
+	if b == 0 {
+		return math.NaN()
+	}
+	return a / b
+}
+
+func (p *exprCompiler) unary() (exprNode, error) {
+	if p.peek() != "-" {
+		return p.primary()
+	}
+	op := p.tokens[p.pos]
+	p.pos++
+	n, err := p.unary()
+	if err != nil {
+		return exprNode{}, err
+	}
+	if n.num == nil {
+		return exprNode{}, fmt.Errorf("- needs a number at %d", op.offset)
+	}
+	return exprNode{num: func(m *measurement) float64 { return -n.num(m) }}, nil
+}
+
+func (p *exprCompiler) primary() (exprNode, error) {
+	if p.pos >= len(p.tokens) {
+		return exprNode{}, p.errorf("incomplete expression")
+	}
+	t := p.tokens[p.pos]
+	switch {
+	case t.kind == 'o' && t.text == "(":
+		p.pos++
+		p.depth++
+		n, err := p.or()
+		p.depth--
+		if err != nil {
+			return exprNode{}, err
+		}
+		if p.peek() != ")" {
+			return exprNode{}, p.errorf("missing )")
+		}
+		p.pos++
+		return n, nil
+	case t.kind == 'n':
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return exprNode{}, fmt.Errorf("invalid number %q at %d", t.text, t.offset)
+		}
+		p.pos++
+		if p.peek() == "%" {
+			n /= 100
+			p.pos++
+		}
+		return exprNode{num: func(*measurement) float64 { return n }}, nil
+	case t.kind == 'i':
+		return p.call()
+	}
+	return exprNode{}, p.errorf("unexpected %q", t.text)
+}
+
+// call compiles a function call.
+func (p *exprCompiler) call() (exprNode, error) {
+	name := p.tokens[p.pos]
+	p.pos++
+	if p.peek() != "(" {
+		return exprNode{}, fmt.Errorf("unknown name %q at %d, expected a function: count, rate, bandwidth, latency or window", name.text, name.offset)
+	}
+	p.pos++
+	var arg *exprToken
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == 's' {
+		arg = &p.tokens[p.pos]
+		p.pos++
+	}
+	if p.peek() != ")" {
+		return exprNode{}, p.errorf("%s() takes a quoted argument or none, missing )", name.text)
+	}
+	p.pos++
+
+	needArg := func(example string) error {
+		if arg == nil {
+			return fmt.Errorf("%s() needs an argument at %d, e.g. %s", name.text, name.offset, example)
+		}
+		return nil
+	}
+	// Partly covered windows are not measured
+	measured := func(f func(m *measurement) float64) exprNode {
+		return exprNode{num: func(m *measurement) float64 {
+			if m.partial {
+				return math.NaN()
+			}
+			return f(m)
+		}}
+	}
+	switch name.text {
+	case "count", "rate":
+		if err := needArg(`"5xx"`); err != nil {
+			return exprNode{}, err
+		}
+		class := arg.text
+		if !statusClassRegex.MatchString(class) {
+			return exprNode{}, fmt.Errorf("%s(%q) at %d: expected \"all\", a class such as \"5xx\" or a code such as \"404\"", name.text, class, name.offset)
+		}
+		if name.text == "count" {
+			return measured(func(m *measurement) float64 { return float64(m.count(class)) }), nil
+		}
+		return measured(func(m *measurement) float64 { return float64(m.count(class)) / m.seconds }), nil
+	case "bandwidth":
+		if arg != nil {
+			return exprNode{}, fmt.Errorf("bandwidth() takes no argument at %d", name.offset)
+		}
+		return measured(func(m *measurement) float64 { return float64(m.bytes) / m.seconds }), nil
+	case "latency":
+		if err := needArg(`"p95"`); err != nil {
+			return exprNode{}, err
+		}
+		match := percentileRegex.FindStringSubmatch(arg.text)
+		if match == nil {
+			return exprNode{}, fmt.Errorf("latency(%q) at %d: expected a percentile such as \"p95\"", arg.text, name.offset)
+		}
+		pct, _ := strconv.Atoi(match[1])
+		return measured(func(m *measurement) float64 {
+			if len(m.latencies) == 0 {
+				return math.NaN()
+			}
+			return percentile(m.latencies, pct)
+		}), nil
+	case "window":
+		if err := needArg(`"5m"`); err != nil {
+			return exprNode{}, err
+		}
+		d, err := time.ParseDuration(arg.text)
+		if err != nil || d <= 0 || d > maxExprWindow {
+			return exprNode{}, fmt.Errorf("window(%q) at %d: expected a duration up to %s, e.g. \"5m\"", arg.text, name.offset, maxExprWindow)
+		}
+		if p.window > 0 && p.window != d {
+			return exprNode{}, fmt.Errorf("window(%q) at %d: the expression already measures over %s", arg.text, name.offset, p.window)
+		}
+		if p.depth > 0 {
+			return exprNode{}, fmt.Errorf("window() at %d cannot be under ! or in parentheses, join it to the whole condition with &&", name.offset)
+		}
+		p.window, p.windowAt = d, name.offset
+		return exprNode{cond: func(*measurement) bool { return true }, window: true}, nil
+	}
+	return exprNode{}, fmt.Errorf("unknown function %s() at %d, expected count, rate, bandwidth, latency or window", name.text, name.offset)
+}
+
+// measurement is what the requests of a window add up to.
+type measurement struct {
+	partial   bool // Entries of the window were evicted, so the functions are not numbers
+	seconds   float64
+	requests  int
+	statuses  map[int]int
+	bytes     int
+	latencies []float64 // Sorted
+}
+
+// count returns the requests of a status class: "all", e.g. "5xx" or e.g.
+// "404".
+func (m *measurement) count(class string) int {
+	switch {
+	case class == "all":
+		return m.requests
+	case strings.HasSuffix(class, "xx"):
+		n := 0
+		for status, count := range m.statuses {
+			if status/100 == int(class[0]-'0') {
+				n += count
+			}
+		}
+		return n
+	}
+	status, _ := strconv.Atoi(class)
+	return m.statuses[status]
+}
+
+// Snapshot is what the requests of the windows of expressions add up to at
+// a time, measured once for all the expressions of each window.
+type Snapshot struct {
+	windows map[time.Duration]*measurement
+}
+
+// NewSnapshot measures the entries, given in log order, over the last of
+// each window. Evicted is the time of the newest entry dropped from
+// entries, e.g. to bound memory, zero if none was: the windows it falls in
+// are only partly covered, so they are not measured.
+func NewSnapshot(entries []parser.Visitor, now, evicted time.Time, windows []time.Duration) *Snapshot {
+	s := &Snapshot{windows: make(map[time.Duration]*measurement)}
+	for _, w := range windows {
+		if s.windows[w] != nil {
+			continue
+		}
+		m := &measurement{seconds: w.Seconds(), statuses: make(map[int]int)}
+		if !evicted.IsZero() && !evicted.Before(now.Add(-w)) {
+			m.partial = true
+			s.windows[w] = m
+			continue
+		}
+		for i := len(entries) - 1; i >= 0; i-- {
+			v := &entries[i]
+			if now.Sub(v.Time) > w {
+				break // Older entries follow
+			}
+			m.requests++
+			m.statuses[v.Status]++
+			m.bytes += v.Bytes
+			if v.RequestTime > 0 {
+				m.latencies = append(m.latencies, v.RequestTime.Seconds())
+			}
+		}
+		sort.Float64s(m.latencies)
+		s.windows[w] = m
+	}
+	return s
+}
+
+// measure returns the measurement of a window, empty if it was not
+// measured.
+func (s *Snapshot) measure(w time.Duration) *measurement {
+	if s != nil && s.windows[w] != nil {
+		return s.windows[w]
+	}
+	return &measurement{seconds: w.Seconds(), statuses: map[int]int{}}
+}
@@ -0,0 +1,184 @@
+package alert
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// exprEntries returns 100 requests over the last 5 minutes, a fifth of them
+// within the last minute, with 10 5xx and 5 404s among them.
+func exprEntries(now time.Time) []parser.Visitor {
+	var entries []parser.Visitor
+	for i := 99; i >= 0; i-- {
+		v := parser.Visitor{Time: now.Add(-time.Duration(i) * 3 * time.Second), Status: 200, Bytes: 1000, RequestTime: time.Duration(i+1) * time.Millisecond}
+		switch {
+		case i%10 == 0:
+			v.Status = 503
+		case i%20 == 5:
+			v.Status = 404
+		}
+		entries = append(entries, v)
+	}
+	return entries
+}
+
+func TestExpr(t *testing.T) {
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	entries := exprEntries(now)
+
+	tests := []struct {
+		text  string
+		holds bool
+		value float64
+	}{
+		{`count("all") == 100 && window("5m")`, true, 100},
+		{`count("all") >= 20`, true, 21},
+		{`rate("5xx") / rate("all") > 0.05 && window("5m")`, true, 0.1},
+		{`rate("5xx") / rate("all") > 10%`, true, 3.0 / 21},
+		{`count("404") == 5 && window("5m")`, true, 5},
+		{`count("4xx") + count("5xx") >= 15 && window("5m")`, true, 15},
+		{`bandwidth() * 60 == 21000`, true, 21000},
+		{`latency("p50") <= 0.011`, true, 0.011},
+		{`rate("all") > 1 || rate("5xx") > 0.01`, true, 0.35},
+		{`!(count("5xx") > 0)`, false, 3},
+		{`-count("all") < -20`, true, -21},
+		{`(count("all") - 1) / 4 == 5`, true, 5},
+		{`count("5xx") / count("301") > 0`, false, math.NaN()},
+		{`window("1m") && count("all") > 1000`, false, 21},
+		{`window("5m") && (count("5xx") > 1 || count("4xx") > 100)`, true, 10},
+	}
+	for _, tt := range tests {
+		e, err := ParseExpr(tt.text)
+		if err != nil {
+			t.Errorf("ParseExpr(%q) error: %v", tt.text, err)
+			continue
+		}
+		s := NewSnapshot(entries, now, time.Time{}, []time.Duration{e.Window()})
+		holds, value := e.Eval(s)
+		if holds != tt.holds || !(math.Abs(value-tt.value) < 1e-9 || math.IsNaN(value) && math.IsNaN(tt.value)) {
+			t.Errorf("%s = %v, %v, want %v, %v", tt.text, holds, value, tt.holds, tt.value)
+		}
+	}
+
+	// Windows that were not measured are empty
+	e, err := ParseExpr(`count("all") > 0 && window("10m")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Window() != 10*time.Minute {
+		t.Errorf("Window() = %s, want 10m", e.Window())
+	}
+	if holds, _ := e.Eval(NewSnapshot(entries, now, time.Time{}, nil)); holds {
+		t.Error("Eval() of an unmeasured window should not hold")
+	}
+
+	// Windows reaching past the evicted entries are not numbers
+	e, err = ParseExpr(`count("all") >= 0 && window("5m")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evicted := now.Add(-2 * time.Minute)
+	if holds, value := e.Eval(NewSnapshot(entries, now, evicted, []time.Duration{e.Window()})); holds || !math.IsNaN(value) {
+		t.Errorf("Eval() of a partly evicted window = %v, %v, want false, NaN", holds, value)
+	}
+	e, _ = ParseExpr(`count("all") >= 0`)
+	if holds, _ := e.Eval(NewSnapshot(entries, now, evicted, []time.Duration{e.Window()})); !holds {
+		t.Error("Eval() of a window after the evicted entries should hold")
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	tests := map[string]string{
+		``:                                     "incomplete expression",
+		`rate("5xx")`:                          "expected a condition",
+		`rate("5xx") > `:                       "incomplete expression at 14",
+		`rate("6xx") > 1`:                      `expected "all"`,
+		`rate() > 1`:                           "needs an argument",
+		`rate("5xx" > 1`:                       "missing )",
+		`rates("5xx") > 1`:                     "unknown function rates()",
+		`errors > 1`:                           `unknown name "errors"`,
+		`latency("median") > 1`:                "expected a percentile",
+		`bandwidth("5xx") > 1`:                 "takes no argument",
+		`window("5m") && window("1h")`:         "already measures over 5m0s",
+		`window("forever")`:                    "expected a duration",
+		`window("48h")`:                        "expected a duration up to 24h0m0s",
+		`rate("5xx") > 1 && 2`:                 "&& needs conditions",
+		`rate("5xx") > 1 + (count("all") > 1)`: "+ needs numbers",
+		`(rate("5xx") > 1) > 2`:                "> needs numbers",
+		`!rate("5xx")`:                         "! needs a condition",
+		`rate("5xx") > 1 ; rm`:                 `unexpected ';' at 16`,
+		`rate("5xx) > 1`:                       "unterminated string",
+		`rate("5xx") > 1.2.3`:                  `invalid number "1.2.3"`,
+		`rate("5xx") > 1 rate("4xx")`:          "unexpected",
+		`window("5m")`:                         "window() needs a condition",
+		`!window("5m") && rate("5xx") > 1`:     "cannot be under !",
+		`(window("5m") && rate("5xx") > 1)`:    "in parentheses",
+		`count("5xx") > 1 || window("5m")`:     "cannot be joined with ||",
+		`window("5m") && rate("5xx") > 1 || rate("4xx") > 1`: "window() at 0 cannot be joined with ||",
+	}
+	for text, want := range tests {
+		_, err := ParseExpr(text)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseExpr(%q) error = %v, want %q", text, err, want)
+		}
+	}
+}
+
+func TestExprRules(t *testing.T) {
+	r, err := ParseRule(`errors: rate("5xx") / rate("all") > 5% && window("5m") for 2m -> critical`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != "errors" || r.Expr == nil || r.Expr.Window() != 5*time.Minute || r.For != 2*time.Minute || r.Severity != SeverityCritical {
+		t.Errorf("ParseRule() = %+v", r)
+	}
+	unnamed, err := ParseRule(`rate("5xx") / rate("all") > 0.05 && window("5m")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := ParseRule(`rate("5xx") / rate("all") > 0.05 && window("5m") for 1m`); !strings.HasPrefix(unnamed.ID, "expr_") || again.ID != unnamed.ID {
+		t.Errorf("ParseRule() IDs of unnamed expressions = %q, %q, want the same expr_ ID", unnamed.ID, again.ID)
+	}
+	if _, err := ParseRule(`errors: rate("5xx") > 1 for ever`); err == nil {
+		t.Error("ParseRule() should fail on an invalid duration")
+	}
+
+	rules, err := ParseRules([]string{`errors: rate("5xx") / rate("all") > 5% && window("5m") for 2m`, "rps > 1000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEvaluator(rules)
+	if windows := e.Windows(); len(windows) != 1 || windows[0] != 5*time.Minute {
+		t.Errorf("Windows() = %v, want 5m", windows)
+	}
+
+	b := NewBoard()
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	e.EvaluateExprs(b, now, NewSnapshot(exprEntries(now), now, time.Time{}, e.Windows()))
+	if len(b.Active()) != 0 {
+		t.Fatal("EvaluateExprs() should not fire before the rule duration")
+	}
+	later := now.Add(2 * time.Minute)
+	if !e.EvaluateExprs(b, later, NewSnapshot(exprEntries(later), later, time.Time{}, e.Windows())) {
+		t.Error("EvaluateExprs() = false, want true when a rule fires")
+	}
+	active := b.Active()
+	if len(active) != 1 || active[0].ID != "errors" || active[0].Window != 5*time.Minute || active[0].Value != 0.1 {
+		t.Fatalf("Active() = %+v, want the errors alert", active)
+	}
+	if want := `errors: rate("5xx") / rate("all") > 5% && window("5m") for 2m (rate("5xx") / rate("all") = 0.1)`; active[0].Message != want {
+		t.Errorf("message = %q, want %q", active[0].Message, want)
+	}
+
+	// Metric rules are left to Evaluate, expression rules to EvaluateExprs
+	if e.Evaluate(b, later, map[string]float64{"rps": 0}) || len(b.Active()) != 1 {
+		t.Error("Evaluate() should leave expression rules alone")
+	}
+	if !e.EvaluateExprs(b, later, NewSnapshot(nil, later, time.Time{}, e.Windows())) || len(b.Active()) != 0 {
+		t.Error("EvaluateExprs() should clear the alert without requests")
+	}
+}
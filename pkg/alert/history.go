@@ -0,0 +1,125 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// historySize is the number of alert events a board remembers.
+const historySize = 500
+
+// Alert events
+const (
+	EventFired     = "fired"
+	EventEscalated = "escalated"
+	EventCleared   = "cleared"
+)
+
+// Event is an alert firing, escalating or clearing.
+type Event struct {
+	Time  time.Time
+	Kind  string // EventFired, EventEscalated or EventCleared
+	Alert Alert
+}
+
+// record adds an event to the history, forgetting the oldest one when it
+// is full. Caller must hold the lock.
+func (b *Board) record(kind string, a Alert) {
+	if len(b.history) == historySize {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:historySize-1]
+	}
+	b.history = append(b.history, Event{Time: b.now(), Kind: kind, Alert: a})
+}
+
+// History returns the last alert events, oldest first.
+func (b *Board) History() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Event(nil), b.history...)
+}
+
+// auditLine is a line of the audit log: the webhook payload of the alert
+// with the event and its time.
+type auditLine struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	Payload
+}
+
+// AuditLog appends the alerts that fire, escalate or clear to a file, one
+// JSON line each:
+//
+//	{"time":"2025-10-10T12:00:00Z","event":"fired","rule":"5xx_spike","severity":"WARN",...}
+//
+// so that the timeline of an incident can be reconstructed. Events are
+// queued by Notify and Resolve and written by Run. The file is opened for
+// each write, so it can be rotated.
+type AuditLog struct {
+	queue[auditLine]
+	path   string
+	mu     sync.Mutex
+	active map[string]bool // Alerts fired, to tell escalations
+	now    func() time.Time
+}
+
+// NewAuditLog returns an audit log appending to the file at path, created
+// if needed.
+func NewAuditLog(path string) (*AuditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("alert log: %w", err)
+	}
+	f.Close()
+	l := &AuditLog{
+		path:   path,
+		active: make(map[string]bool),
+		now:    time.Now,
+	}
+	l.queue = newQueue(l.write)
+	return l, nil
+}
+
+// Notify queues an alert that fired or escalated. It is dropped if the
+// queue is full.
+func (l *AuditLog) Notify(a Alert) {
+	event := EventFired
+	l.mu.Lock()
+	if l.active[a.ID] {
+		event = EventEscalated
+	}
+	l.active[a.ID] = true
+	l.mu.Unlock()
+	l.enqueue(auditLine{Time: l.now(), Event: event, Payload: NewPayload(a)})
+}
+
+// Resolve queues an alert that cleared.
+func (l *AuditLog) Resolve(a Alert) {
+	l.mu.Lock()
+	delete(l.active, a.ID)
+	l.mu.Unlock()
+	l.enqueue(auditLine{Time: l.now(), Event: EventCleared, Payload: NewPayload(a)})
+}
+
+// write appends an event to the file.
+func (l *AuditLog) write(line auditLine) error {
+	b, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("alert log: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("alert log: %w", err)
+	}
+	_, err = f.Write(append(b, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("alert log: %w", err)
+	}
+	return nil
+}
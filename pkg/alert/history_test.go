@@ -0,0 +1,89 @@
+package alert
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBoardHistory(t *testing.T) {
+	b := NewBoard()
+	a := testAlert()
+	a.Severity = SeverityWarning
+	b.Fire(a)
+	b.Fire(a) // No change
+	a.Severity = SeverityCritical
+	b.Fire(a)
+	a.Severity = SeverityWarning
+	b.Fire(a) // De-escalations are no events
+	b.Clear(a.ID)
+	b.Clear(a.ID)
+
+	history := b.History()
+	want := []string{EventFired, EventEscalated, EventCleared}
+	if len(history) != len(want) {
+		t.Fatalf("History() = %+v, want %v", history, want)
+	}
+	for i, e := range history {
+		if e.Kind != want[i] || e.Alert.ID != a.ID || e.Time.IsZero() {
+			t.Errorf("History()[%d] = %+v, want %s", i, e, want[i])
+		}
+	}
+	if history[1].Alert.Severity != SeverityCritical {
+		t.Errorf("escalation = %+v, want the critical alert", history[1])
+	}
+
+	for i := 0; i < historySize; i++ {
+		b.Raise("watch", SeverityInfo, "hit")
+		b.Clear("watch")
+	}
+	if history := b.History(); len(history) != historySize || history[0].Alert.ID != "watch" {
+		t.Errorf("History() has %d events, want the last %d", len(history), historySize)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.log")
+	l, err := NewAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBoard()
+	b.AddNotifier(l, SeverityInfo)
+	a := testAlert()
+	a.Severity = SeverityWarning
+	b.Fire(a)
+	a.Severity = SeverityCritical
+	b.Fire(a)
+	b.Clear(a.ID)
+	for len(l.items) > 0 {
+		if err := l.write(<-l.items); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []string{EventFired, EventEscalated, EventCleared}
+	if len(lines) != len(want) {
+		t.Fatalf("audit log = %q, want %d lines", data, len(want))
+	}
+	for i, line := range lines {
+		var got map[string]any
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		if got["event"] != want[i] || got["rule"] != a.ID || got["time"] == nil {
+			t.Errorf("line %d = %s, want %s", i, line, want[i])
+		}
+	}
+
+	if _, err := NewAuditLog(filepath.Join(t.TempDir(), "missing", "alerts.log")); err == nil {
+		t.Error("NewAuditLog() should fail in a missing directory")
+	}
+}
@@ -0,0 +1,198 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Incident management endpoints
+const (
+	pagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieURL  = "https://api.opsgenie.com/v2/alerts"
+)
+
+// incident is a page to open for a critical alert, or to close once the
+// alert cleared.
+type incident struct {
+	alert   Alert
+	resolve bool
+}
+
+// pager opens an incident when an alert becomes critical and resolves it
+// when the alert clears. Incidents are queued by Notify and Resolve and
+// delivered by Run, so that slow requests do not hold up the checks.
+type pager struct {
+	poster
+	queue[incident]
+	source string // Host the incidents come from
+	mu     sync.Mutex
+	open   map[string]bool // IDs of the alerts with an open incident
+}
+
+// newPager returns a pager delivering its incidents with send.
+func newPager(name, source string, send func(incident) error) pager {
+	return pager{
+		poster: newPoster(name),
+		queue:  newQueue(send),
+		source: source,
+		open:   make(map[string]bool),
+	}
+}
+
+// Notify opens an incident for a critical alert. Warnings are ignored.
+func (p *pager) Notify(a Alert) {
+	if a.Severity < SeverityCritical {
+		return
+	}
+	p.mu.Lock()
+	opened := !p.open[a.ID]
+	p.open[a.ID] = true
+	p.mu.Unlock()
+	if opened {
+		p.enqueue(incident{alert: a})
+	}
+}
+
+// Resolve closes the incident of an alert that cleared.
+func (p *pager) Resolve(a Alert) {
+	p.mu.Lock()
+	opened := p.open[a.ID]
+	delete(p.open, a.ID)
+	p.mu.Unlock()
+	if opened {
+		p.enqueue(incident{alert: a, resolve: true})
+	}
+}
+
+// dedupKey identifies the incidents of an alert raised on this host.
+func (p *pager) dedupKey(a Alert) string {
+	return "tailnginx/" + p.source + "/" + a.ID
+}
+
+// details returns the value, window and offenders of an alert.
+func details(a Alert) map[string]string {
+	d := map[string]string{
+		"rule":  a.ID,
+		"value": strconv.FormatFloat(a.Value, 'f', 1, 64),
+		"since": a.Since.UTC().Format(time.RFC3339),
+	}
+	if a.Window > 0 {
+		d["window"] = a.Window.String()
+	}
+	for i, o := range a.Offenders {
+		d[fmt.Sprintf("offender_%d", i+1)] = fmt.Sprintf("%s (%d requests)", o.Value, o.Requests)
+	}
+	return d
+}
+
+// PagerDuty triggers PagerDuty incidents through the Events API v2 for
+// critical alerts and resolves them when the alerts clear.
+type PagerDuty struct {
+	pager
+	routingKey string
+	url        string
+}
+
+// NewPagerDuty returns a PagerDuty notifier sending events with the
+// routing key of an Events API v2 integration. Source names the host in
+// the events.
+func NewPagerDuty(routingKey, source string) *PagerDuty {
+	pd := &PagerDuty{routingKey: routingKey, url: pagerDutyURL}
+	pd.pager = newPager("pagerduty", source, pd.send)
+	return pd
+}
+
+// pagerDutyEvent is an Events API v2 event.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"` // "trigger" or "resolve"
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Component     string            `json:"component"`
+	CustomDetails map[string]string `json:"custom_details"`
+}
+
+func (pd *PagerDuty) send(inc incident) error {
+	event := pagerDutyEvent{
+		RoutingKey:  pd.routingKey,
+		EventAction: "trigger",
+		DedupKey:    pd.dedupKey(inc.alert),
+	}
+	if inc.resolve {
+		event.EventAction = "resolve"
+	} else {
+		event.Payload = &pagerDutyPayload{
+			Summary:       inc.alert.Message,
+			Source:        pd.source,
+			Severity:      "critical",
+			Timestamp:     inc.alert.Since.UTC().Format(time.RFC3339),
+			Component:     "nginx",
+			CustomDetails: details(inc.alert),
+		}
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("pagerduty: %w", err)
+	}
+	return pd.post(pd.url, body)
+}
+
+// Opsgenie creates Opsgenie alerts for critical alerts and closes them when
+// the alerts clear.
+type Opsgenie struct {
+	pager
+	url string
+}
+
+// NewOpsgenie returns an Opsgenie notifier creating alerts with an API
+// integration key. Source names the host in the alerts.
+func NewOpsgenie(apiKey, source string) *Opsgenie {
+	o := &Opsgenie{url: opsgenieURL}
+	o.pager = newPager("opsgenie", source, o.send)
+	o.header = http.Header{"Authorization": {"GenieKey " + apiKey}}
+	return o
+}
+
+// opsgenieMessageLength is the longest alert message Opsgenie accepts.
+const opsgenieMessageLength = 130
+
+func (o *Opsgenie) send(inc incident) error {
+	alias := o.dedupKey(inc.alert)
+	var target string
+	var body any
+	if inc.resolve {
+		target = o.url + "/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		body = map[string]string{"source": o.source, "note": "Cleared: " + inc.alert.Message}
+	} else {
+		message := inc.alert.Message
+		if r := []rune(message); len(r) > opsgenieMessageLength {
+			message = string(r[:opsgenieMessageLength-1]) + "…"
+		}
+		target = o.url
+		body = map[string]any{
+			"message":     message,
+			"alias":       alias,
+			"description": inc.alert.Message,
+			"priority":    "P1",
+			"source":      o.source,
+			"details":     details(inc.alert),
+		}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("opsgenie: %w", err)
+	}
+	return o.post(target, b)
+}
@@ -0,0 +1,110 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// pagerRequest is a request received by a fake incident API.
+type pagerRequest struct {
+	path, query, auth string
+	body              map[string]any
+}
+
+func pagerServer(t *testing.T) (*httptest.Server, *[]pagerRequest) {
+	var got []pagerRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		req := pagerRequest{path: r.URL.EscapedPath(), query: r.URL.RawQuery, auth: r.Header.Get("Authorization")}
+		if err := json.Unmarshal(b, &req.body); err != nil {
+			t.Errorf("body %s: %v", b, err)
+		}
+		got = append(got, req)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+// drain delivers the queued incidents of a pager.
+func drain(t *testing.T, p *pager, send func(incident) error) {
+	for {
+		select {
+		case inc := <-p.items:
+			if err := send(inc); err != nil {
+				t.Fatalf("send() error = %v", err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func TestPagerDuty(t *testing.T) {
+	srv, got := pagerServer(t)
+	pd := NewPagerDuty("R0UT1NG", "web1")
+	pd.url = srv.URL
+
+	b := NewBoard()
+	b.AddNotifier(pd, SeverityWarning)
+	a := testAlert()
+	a.Severity = SeverityWarning
+	b.Fire(a)
+	a.Severity = SeverityCritical
+	b.Fire(a)
+	b.Fire(a)
+	b.Clear("disk_full")
+	b.Clear(a.ID)
+	b.Clear(a.ID)
+	drain(t, &pd.pager, pd.send)
+
+	if len(*got) != 2 {
+		t.Fatalf("got %d events, want a trigger on escalation and a resolve", len(*got))
+	}
+	trigger, resolve := (*got)[0].body, (*got)[1].body
+	if trigger["event_action"] != "trigger" || trigger["routing_key"] != "R0UT1NG" || trigger["dedup_key"] != "tailnginx/web1/5xx_spike" {
+		t.Errorf("trigger = %v", trigger)
+	}
+	payload, _ := trigger["payload"].(map[string]any)
+	details, _ := payload["custom_details"].(map[string]any)
+	if payload["severity"] != "critical" || payload["source"] != "web1" || details["offender_1"] != "203.0.113.7 (15 requests)" {
+		t.Errorf("payload = %v", payload)
+	}
+	if resolve["event_action"] != "resolve" || resolve["dedup_key"] != trigger["dedup_key"] || resolve["payload"] != nil {
+		t.Errorf("resolve = %v", resolve)
+	}
+}
+
+func TestOpsgenie(t *testing.T) {
+	srv, got := pagerServer(t)
+	o := NewOpsgenie("k3y", "web1")
+	o.url = srv.URL + "/v2/alerts"
+
+	a := testAlert()
+	o.Notify(a)
+	o.Resolve(a)
+	drain(t, &o.pager, o.send)
+
+	if len(*got) != 2 {
+		t.Fatalf("got %d requests, want a create and a close", len(*got))
+	}
+	create, close := (*got)[0], (*got)[1]
+	if create.path != "/v2/alerts" || create.auth != "GenieKey k3y" || create.body["alias"] != "tailnginx/web1/5xx_spike" || create.body["priority"] != "P1" {
+		t.Errorf("create = %+v", create)
+	}
+	if close.path != "/v2/alerts/tailnginx%2Fweb1%2F5xx_spike/close" || close.query != "identifierType=alias" {
+		t.Errorf("close = %+v", close)
+	}
+}
+
+func TestDetails(t *testing.T) {
+	a := Alert{ID: "disk_full", Value: 97.25, Since: time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)}
+	d := details(a)
+	if len(d) != 3 || d["value"] != "97.2" || d["since"] != "2025-10-10T12:00:00Z" {
+		t.Errorf("details() = %v", d)
+	}
+}
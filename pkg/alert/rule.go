@@ -0,0 +1,426 @@
+package alert
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// RuleWindow is the period the metrics of alert rules are measured over.
+const RuleWindow = time.Minute
+
+// ruleMinRequests is the minimum of requests in the window for rates and
+// latency percentiles to be measured, so a few requests do not fire alerts.
+const ruleMinRequests = 10
+
+// Units of the thresholds of rule metrics
+const (
+	unitNumber = iota
+	unitPercent
+	unitDuration // Seconds
+	unitBytes
+)
+
+// ruleMetrics are the metrics rules can check, with the unit of their
+// thresholds.
+var ruleMetrics = map[string]int{
+	"rps":         unitNumber,   // Requests per second
+	"4xx_rate":    unitPercent,  // Share of 4xx responses
+	"5xx_rate":    unitPercent,  // Share of 5xx responses
+	"p50_latency": unitDuration, // Percentiles of $request_time
+	"p90_latency": unitDuration,
+	"p95_latency": unitDuration,
+	"p99_latency": unitDuration,
+	"bandwidth":   unitBytes, // Bytes sent per second
+}
+
+// ruleNameRegex matches the names of rules.
+var ruleNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ruleRegex matches a rule condition, e.g. "5xx_rate > 5% for 2m".
+var ruleRegex = regexp.MustCompile(`^([a-z0-9_]+)\s*(>=|<=|>|<)\s*(\S+)(?:\s+for\s+(\S+))?$`)
+
+// exprForRegex matches the duration of an expression rule, e.g. " for 2m".
+var exprForRegex = regexp.MustCompile(`^(.*?)\s+for\s+(\S+)$`)
+
+// Rule fires an alert when a metric crosses a threshold, or an expression
+// holds, for a while.
+type Rule struct {
+	ID        string // Alert ID, the metric or expr_ and a hash of the expression unless the rule is named
+	Metric    string
+	Op        string // >, >=, < or <=
+	Threshold float64
+	Expr      *Expr         // Condition of expression rules instead of the metric, nil for others
+	For       time.Duration // How long the condition must hold before firing
+	Severity  Severity
+	threshold string // As written
+	duration  string
+}
+
+// ParseRule parses an alert rule such as "5xx_rate > 5% for 2m -> critical"
+// or "slow: p95_latency > 800ms". Rules are named after their metric unless
+// a name is given before a colon; the name is the ID of their alerts, e.g.
+// for Slack routes. The severity is warning unless given after "->".
+// Conditions calling functions are expressions, see Expr, e.g.
+// `errors: rate("5xx") / rate("all") > 5% for 2m`; unnamed ones are named
+// expr_ followed by a hash of the expression, which stays the same across
+// reloads.
+func ParseRule(text string) (Rule, error) {
+	r := Rule{Severity: SeverityWarning}
+	cond, severity, ok := strings.Cut(text, "->")
+	if ok {
+		switch strings.ToLower(strings.TrimSpace(severity)) {
+		case "info":
+			r.Severity = SeverityInfo
+		case "warning":
+			r.Severity = SeverityWarning
+		case "critical":
+			r.Severity = SeverityCritical
+		default:
+			return Rule{}, fmt.Errorf("alert rule %q: unknown severity %q, expected info, warning or critical", text, strings.TrimSpace(severity))
+		}
+	}
+	cond = strings.TrimSpace(cond)
+	if name, rest, ok := strings.Cut(cond, ":"); ok {
+		name = strings.TrimSpace(name)
+		if !ruleNameRegex.MatchString(name) {
+			return Rule{}, fmt.Errorf("alert rule %q: invalid name %q", text, name)
+		}
+		r.ID, cond = name, strings.TrimSpace(rest)
+	}
+	if strings.Contains(cond, "(") {
+		return parseExprRule(text, cond, r)
+	}
+
+	m := ruleRegex.FindStringSubmatch(cond)
+	if m == nil {
+		return Rule{}, fmt.Errorf("alert rule %q: expected 'metric > threshold [for duration]', e.g. '5xx_rate > 5%% for 2m'", text)
+	}
+	r.Metric, r.Op, r.threshold = m[1], m[2], m[3]
+	unit, ok := ruleMetrics[r.Metric]
+	if !ok {
+		return Rule{}, fmt.Errorf("alert rule %q: unknown metric %q, expected one of %s", text, r.Metric, strings.Join(metricNames(), ", "))
+	}
+	var err error
+	if r.Threshold, err = parseThreshold(r.threshold, unit); err != nil {
+		return Rule{}, fmt.Errorf("alert rule %q: %w", text, err)
+	}
+	if m[4] != "" {
+		if r.For, err = time.ParseDuration(m[4]); err != nil || r.For < 0 {
+			return Rule{}, fmt.Errorf("alert rule %q: invalid duration %q", text, m[4])
+		}
+		r.duration = m[4]
+	}
+	if r.ID == "" {
+		r.ID = r.Metric
+	}
+	return r, nil
+}
+
+// parseExprRule parses the condition of an expression rule, with its
+// optional duration, into r.
+func parseExprRule(text, cond string, r Rule) (Rule, error) {
+	if m := exprForRegex.FindStringSubmatch(cond); m != nil {
+		d, err := time.ParseDuration(m[2])
+		if err != nil || d < 0 {
+			return Rule{}, fmt.Errorf("alert rule %q: invalid duration %q", text, m[2])
+		}
+		cond, r.For, r.duration = m[1], d, m[2]
+	}
+	expr, err := ParseExpr(cond)
+	if err != nil {
+		return Rule{}, fmt.Errorf("alert rule '%s': %w", text, err) // Not %q, expressions quote strings
+	}
+	r.Expr = expr
+	if r.ID == "" {
+		h := fnv.New32a()
+		h.Write([]byte(expr.String()))
+		r.ID = fmt.Sprintf("expr_%08x", h.Sum32())
+	}
+	return r, nil
+}
+
+// ParseRules parses alert rules, whose IDs must be unique.
+func ParseRules(texts []string) ([]Rule, error) {
+	var rules []Rule
+	for _, text := range texts {
+		r, err := ParseRule(text)
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(rules, func(other Rule) bool { return other.ID == r.ID }) {
+			return nil, fmt.Errorf("alert rule %q: name %s is used by another rule, name them, e.g. '%s_2: %s'", text, r.ID, r.ID, strings.TrimSpace(text))
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// metricNames returns the names of the rule metrics, sorted.
+func metricNames() []string {
+	names := make([]string, 0, len(ruleMetrics))
+	for name := range ruleMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseThreshold parses a threshold in the unit of its metric: a percentage
+// with or without %, a duration such as 800ms, or a size such as 10MB.
+func parseThreshold(text string, unit int) (float64, error) {
+	switch unit {
+	case unitPercent:
+		text = strings.TrimSuffix(text, "%")
+	case unitDuration:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q, e.g. 800ms", text)
+		}
+		return d.Seconds(), nil
+	case unitBytes:
+		return parseSize(text)
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid threshold %q", text)
+	}
+	return n, nil
+}
+
+// sizeUnits are the suffixes of sizes, longest first.
+var sizeUnits = []struct {
+	suffix string
+	bytes  float64
+}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+
+// parseSize parses a size in bytes such as 10MB, in powers of 1024.
+func parseSize(text string) (float64, error) {
+	upper := strings.ToUpper(text)
+	multiplier := 1.0
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(upper, u.suffix) {
+			upper, multiplier = strings.TrimSuffix(upper, u.suffix), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(upper, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, e.g. 10MB", text)
+	}
+	return n * multiplier, nil
+}
+
+// holds reports whether value crosses the threshold of the rule.
+func (r Rule) holds(value float64) bool {
+	switch r.Op {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	default:
+		return value <= r.Threshold
+	}
+}
+
+// message describes the alert of the rule for a measured value.
+func (r Rule) message(value float64) string {
+	if r.Expr != nil {
+		text := r.ID + ": " + r.Expr.String()
+		if r.For > 0 {
+			text += " for " + r.duration
+		}
+		if !math.IsNaN(value) {
+			text += fmt.Sprintf(" (%s = %s)", r.Expr.valueText, strconv.FormatFloat(value, 'g', 4, 64))
+		}
+		return text
+	}
+	measured := formatMetric(value, ruleMetrics[r.Metric])
+	text := fmt.Sprintf("%s %s %s %s", r.Metric, measured, r.Op, r.threshold)
+	if r.For > 0 {
+		text += " for " + r.duration
+	}
+	if r.ID != r.Metric {
+		text = r.ID + ": " + text
+	}
+	return text
+}
+
+// formatMetric formats a metric value in its unit.
+func formatMetric(value float64, unit int) string {
+	switch unit {
+	case unitPercent:
+		return fmt.Sprintf("%.1f%%", value)
+	case unitDuration:
+		return time.Duration(value * float64(time.Second)).Round(time.Millisecond).String()
+	case unitBytes:
+		for _, u := range sizeUnits {
+			if value >= u.bytes {
+				return fmt.Sprintf("%.1f%s/s", value/u.bytes, u.suffix)
+			}
+		}
+		return "0B/s"
+	}
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+}
+
+// Measure computes the rule metrics over the entries of the last
+// RuleWindow, given in log order. Rates and latency percentiles are left
+// out with too few requests, latencies also without $request_time.
+func Measure(entries []parser.Visitor, now time.Time) map[string]float64 {
+	var requests, errors4xx, errors5xx, bytes int
+	var latencies []float64
+	for i := len(entries) - 1; i >= 0; i-- {
+		v := &entries[i]
+		if now.Sub(v.Time) > RuleWindow {
+			break // Older entries follow
+		}
+		requests++
+		bytes += v.Bytes
+		switch v.Status / 100 {
+		case 4:
+			errors4xx++
+		case 5:
+			errors5xx++
+		}
+		if v.RequestTime > 0 {
+			latencies = append(latencies, v.RequestTime.Seconds())
+		}
+	}
+
+	seconds := RuleWindow.Seconds()
+	values := map[string]float64{
+		"rps":       float64(requests) / seconds,
+		"bandwidth": float64(bytes) / seconds,
+	}
+	if requests >= ruleMinRequests {
+		values["4xx_rate"] = float64(errors4xx) / float64(requests) * 100
+		values["5xx_rate"] = float64(errors5xx) / float64(requests) * 100
+	}
+	if len(latencies) >= ruleMinRequests {
+		sort.Float64s(latencies)
+		for _, p := range []int{50, 90, 95, 99} {
+			values[fmt.Sprintf("p%d_latency", p)] = percentile(latencies, p)
+		}
+	}
+	return values
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []float64, p int) float64 {
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// Evaluator evaluates alert rules over time, firing their alerts once their
+// condition held for the duration of the rule and clearing them when it no
+// longer holds. It is not safe for concurrent use.
+type Evaluator struct {
+	rules []Rule
+	since map[string]time.Time // When the condition of each rule started to hold
+}
+
+// NewEvaluator returns an evaluator of rules.
+func NewEvaluator(rules []Rule) *Evaluator {
+	return &Evaluator{rules: rules, since: make(map[string]time.Time)}
+}
+
+// Rules returns the rules of the evaluator.
+func (e *Evaluator) Rules() []Rule {
+	if e == nil {
+		return nil
+	}
+	return e.rules
+}
+
+// Windows returns the windows the expression rules measure over, e.g. for
+// NewSnapshot.
+func (e *Evaluator) Windows() []time.Duration {
+	var windows []time.Duration
+	for _, r := range e.Rules() {
+		if r.Expr != nil && !slices.Contains(windows, r.Expr.Window()) {
+			windows = append(windows, r.Expr.Window())
+		}
+	}
+	return windows
+}
+
+// Evaluate checks the metric rules against the metric values measured at
+// now and fires or clears their alerts on b. A rule whose metric is missing
+// is treated as not holding. Returns true if the alerts may have changed.
+func (e *Evaluator) Evaluate(b *Board, now time.Time, values map[string]float64) bool {
+	if e == nil {
+		return false
+	}
+	changed := false
+	for _, r := range e.rules {
+		if r.Expr != nil {
+			continue
+		}
+		value, ok := values[r.Metric]
+		if e.update(b, r, now, ok && r.holds(value), value, RuleWindow) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// EvaluateExprs checks the expression rules against a snapshot taken at now
+// over their windows and fires or clears their alerts on b. Returns true if
+// the alerts may have changed.
+func (e *Evaluator) EvaluateExprs(b *Board, now time.Time, s *Snapshot) bool {
+	if e == nil {
+		return false
+	}
+	changed := false
+	for _, r := range e.rules {
+		if r.Expr == nil {
+			continue
+		}
+		holds, value := r.Expr.Eval(s)
+		if e.update(b, r, now, holds, value, r.Expr.Window()) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// update fires the alert of a rule whose condition held for its duration,
+// or clears it when the condition does not hold. Returns true if the alerts
+// may have changed.
+func (e *Evaluator) update(b *Board, r Rule, now time.Time, holds bool, value float64, window time.Duration) bool {
+	if !holds {
+		delete(e.since, r.ID)
+		return b.Clear(r.ID)
+	}
+	since, pending := e.since[r.ID]
+	if !pending {
+		since = now
+		e.since[r.ID] = since
+	}
+	if now.Sub(since) < r.For {
+		return false
+	}
+	message := r.message(value)
+	if math.IsNaN(value) {
+		value = 0
+	}
+	b.Fire(Alert{
+		ID:       r.ID,
+		Severity: r.Severity,
+		Message:  message,
+		Value:    value,
+		Window:   window,
+	})
+	return true
+}
@@ -0,0 +1,159 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		text string
+		want Rule
+	}{
+		{"5xx_rate > 5% for 2m", Rule{ID: "5xx_rate", Metric: "5xx_rate", Op: ">", Threshold: 5, For: 2 * time.Minute, Severity: SeverityWarning}},
+		{"p95_latency > 800ms -> critical", Rule{ID: "p95_latency", Metric: "p95_latency", Op: ">", Threshold: 0.8, Severity: SeverityCritical}},
+		{"rps>500", Rule{ID: "rps", Metric: "rps", Op: ">", Threshold: 500, Severity: SeverityWarning}},
+		{"quiet: rps <= 0.5 for 10m -> info", Rule{ID: "quiet", Metric: "rps", Op: "<=", Threshold: 0.5, For: 10 * time.Minute, Severity: SeverityInfo}},
+		{"bandwidth >= 10MB", Rule{ID: "bandwidth", Metric: "bandwidth", Op: ">=", Threshold: 10 << 20, Severity: SeverityWarning}},
+		{"4xx_rate > 20", Rule{ID: "4xx_rate", Metric: "4xx_rate", Op: ">", Threshold: 20, Severity: SeverityWarning}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.text)
+		if err != nil {
+			t.Errorf("ParseRule(%q) error: %v", tt.text, err)
+			continue
+		}
+		got.threshold, got.duration = "", ""
+		if got != tt.want {
+			t.Errorf("ParseRule(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+
+	for _, text := range []string{
+		"",
+		"5xx_rate",
+		"errors > 5",
+		"5xx_rate = 5",
+		"p95_latency > 800",
+		"bandwidth > lots",
+		"rps > -1",
+		"rps > 5 for ever",
+		"rps > 5 -> urgent",
+		"bad name: rps > 5",
+	} {
+		if _, err := ParseRule(text); err == nil {
+			t.Errorf("ParseRule(%q) should fail", text)
+		}
+	}
+}
+
+func TestParseRulesDuplicate(t *testing.T) {
+	if _, err := ParseRules([]string{"p99_latency > 1s", "p99_latency > 3s -> critical"}); err == nil || !strings.Contains(err.Error(), "name them") {
+		t.Errorf("ParseRules() of rules with the same ID = %v, want an error", err)
+	}
+	if _, err := ParseRules([]string{"p99_latency > 1s", "slow: p99_latency > 3s -> critical"}); err != nil {
+		t.Errorf("ParseRules() of named rules: %v", err)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	entries := []parser.Visitor{
+		{Time: now.Add(-2 * time.Minute), Status: 500, Bytes: 1 << 20}, // Outside the window
+	}
+	for i := 1; i <= 20; i++ {
+		v := parser.Visitor{Time: now.Add(-30 * time.Second), Status: 200, Bytes: 600, RequestTime: time.Duration(i) * 10 * time.Millisecond}
+		switch {
+		case i <= 2:
+			v.Status = 503
+		case i <= 5:
+			v.Status = 404
+		}
+		entries = append(entries, v)
+	}
+
+	values := Measure(entries, now)
+	want := map[string]float64{
+		"rps":         20.0 / 60,
+		"bandwidth":   20 * 600 / 60.0,
+		"4xx_rate":    15,
+		"5xx_rate":    10,
+		"p50_latency": 0.1,
+		"p90_latency": 0.18,
+		"p95_latency": 0.19,
+		"p99_latency": 0.2,
+	}
+	for name, w := range want {
+		if got, ok := values[name]; !ok || got != w {
+			t.Errorf("Measure()[%s] = %v, want %v", name, got, w)
+		}
+	}
+
+	// Rates and latencies need enough requests
+	values = Measure(entries[:5], now)
+	if _, ok := values["5xx_rate"]; ok {
+		t.Error("Measure() should leave out rates with too few requests")
+	}
+	if _, ok := values["p95_latency"]; ok {
+		t.Error("Measure() should leave out latencies with too few requests")
+	}
+}
+
+func TestEvaluator(t *testing.T) {
+	rules, err := ParseRules([]string{"5xx_rate > 5% for 2m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEvaluator(rules)
+	b := NewBoard()
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+
+	e.Evaluate(b, now, map[string]float64{"5xx_rate": 7.25})
+	if len(b.Active()) != 0 {
+		t.Fatal("Evaluate() should not fire before the rule duration")
+	}
+	e.Evaluate(b, now.Add(2*time.Minute), map[string]float64{"5xx_rate": 8})
+	active := b.Active()
+	if len(active) != 1 {
+		t.Fatalf("Evaluate() after the rule duration = %+v, want one alert", active)
+	}
+	if a := active[0]; a.ID != "5xx_rate" || a.Severity != SeverityWarning || a.Message != "5xx_rate 8.0% > 5% for 2m" || a.Window != RuleWindow {
+		t.Errorf("Evaluate() alert = %+v", a)
+	}
+
+	// A missing metric clears the alert and restarts the duration
+	if !e.Evaluate(b, now.Add(3*time.Minute), map[string]float64{}) || len(b.Active()) != 0 {
+		t.Error("Evaluate() should clear the alert when the metric is missing")
+	}
+	e.Evaluate(b, now.Add(4*time.Minute), map[string]float64{"5xx_rate": 9})
+	if len(b.Active()) != 0 {
+		t.Error("Evaluate() should wait the rule duration again")
+	}
+
+	var nilEvaluator *Evaluator
+	if nilEvaluator.Evaluate(b, now, nil) || nilEvaluator.Rules() != nil {
+		t.Error("nil Evaluator should evaluate nothing")
+	}
+}
+
+func TestFormatMetric(t *testing.T) {
+	tests := []struct {
+		value float64
+		unit  int
+		want  string
+	}{
+		{512.34, unitNumber, "512.3"},
+		{7.26, unitPercent, "7.3%"},
+		{0.8123, unitDuration, "812ms"},
+		{3 << 20, unitBytes, "3.0MB/s"},
+		{0, unitBytes, "0B/s"},
+	}
+	for _, tt := range tests {
+		if got := formatMetric(tt.value, tt.unit); got != tt.want {
+			t.Errorf("formatMetric(%v, %d) = %q, want %q", tt.value, tt.unit, got, tt.want)
+		}
+	}
+}
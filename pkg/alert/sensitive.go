@@ -0,0 +1,81 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/sensitive"
+)
+
+// Sensitive path check
+const (
+	sensitiveID     = "sensitive_path"
+	sensitiveWindow = 5 * time.Minute
+	sensitiveTop    = 5 // IPs listed as offenders of sensitive path requests
+)
+
+// sensitiveHit is a request to a sensitive path.
+type sensitiveHit struct {
+	time time.Time
+	ip   string
+	path string
+}
+
+// SetSensitivePaths sets the patterns of the sensitive paths, e.g. ".env"
+// or "*.sql", whose requests raise an alert whatever their status.
+func (c *Checker) SetSensitivePaths(m *sensitive.Matcher) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sensitive = m
+}
+
+// recordSensitiveHit keeps an entry if it requested a sensitive path, apart
+// from the entries kept, so that the requests of the window are counted
+// whatever the traffic. Caller must hold the lock.
+func (c *Checker) recordSensitiveHit(v *parser.Visitor) {
+	if c.sensitive.Match(v.Path) != "" {
+		path, _, _ := strings.Cut(v.Path, "?")
+		c.sensitiveHits = append(c.sensitiveHits, sensitiveHit{v.Time, v.IP, path})
+	}
+}
+
+// checkSensitive raises an alert while sensitive paths were requested
+// within the window, whatever the responses. The IPs requesting them the
+// most are its offenders. Caller must hold the lock.
+func (c *Checker) checkSensitive(now time.Time) bool {
+	expired := 0
+	for expired < len(c.sensitiveHits) && now.Sub(c.sensitiveHits[expired].time) > sensitiveWindow {
+		expired++
+	}
+	if expired > 0 {
+		c.sensitiveHits = append([]sensitiveHit(nil), c.sensitiveHits[expired:]...)
+	}
+	byPath := make(map[string]int)
+	byIP := make(map[string]int)
+	for _, hit := range c.sensitiveHits {
+		byPath[hit.path]++
+		byIP[hit.ip]++
+	}
+	requests := len(c.sensitiveHits)
+
+	if requests == 0 {
+		return c.board.Clear(sensitiveID)
+	}
+
+	paths := topOffenders(byPath, 1)[0].Value
+	if len(byPath) > 1 {
+		paths += fmt.Sprintf(" and %d other paths", len(byPath)-1)
+	}
+	c.board.Fire(Alert{
+		ID:       sensitiveID,
+		Severity: SeverityWarning,
+		Message: fmt.Sprintf("Sensitive path requested: %s, %d requests from %d IPs in the last %.0fm",
+			paths, requests, len(byIP), sensitiveWindow.Minutes()),
+		Value:     float64(requests),
+		Window:    sensitiveWindow,
+		Offenders: topOffenders(byIP, sensitiveTop),
+	})
+	return true
+}
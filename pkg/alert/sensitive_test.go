@@ -0,0 +1,55 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/sensitive"
+)
+
+// TestCheckSensitive tests that requests to sensitive paths raise an alert
+// whatever their status, until the window is quiet.
+func TestCheckSensitive(t *testing.T) {
+	c := NewChecker(NewBoard(), 1000)
+	now := time.Now()
+
+	observe(c, []parser.Visitor{
+		{Time: now.Add(-time.Hour), IP: "192.0.2.1", Path: "/.env", Status: 404},
+		{Time: now.Add(-time.Minute), IP: "198.51.100.2", Path: "/index.html", Status: 200},
+	})
+	if c.checkSensitive(now) || len(c.board.Active()) != 0 {
+		t.Fatalf("checkSensitive() = %+v, want no alert for old or ordinary requests", c.board.Active())
+	}
+
+	observe(c, []parser.Visitor{
+		{Time: now.Add(-time.Minute), IP: "203.0.113.7", Path: "/.git/config", Status: 403},
+		{Time: now.Add(-time.Minute), IP: "203.0.113.7", Path: "/.git/config?x=1", Status: 403},
+		{Time: now.Add(-time.Minute), IP: "198.51.100.2", Path: "/backup.tar.gz", Status: 200},
+	})
+	c.checkSensitive(now)
+	active := c.board.Active()
+	if len(active) != 1 || active[0].ID != sensitiveID {
+		t.Fatalf("checkSensitive() = %+v, want one alert", active)
+	}
+	if a := active[0]; !strings.Contains(a.Message, "/.git/config and 1 other paths, 3 requests from 2 IPs") || a.Offenders[0].Value != "203.0.113.7" {
+		t.Errorf("checkSensitive() alert = %+v", a)
+	}
+
+	// The requests are outside the window later on
+	if !c.checkSensitive(now.Add(10*time.Minute)) || len(c.board.Active()) != 0 {
+		t.Error("checkSensitive() should clear the alert once the window is quiet")
+	}
+
+	// Built-in patterns can be removed
+	m, err := sensitive.New([]string{"!.git"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetSensitivePaths(m)
+	c.Observe(&parser.Visitor{Time: now, IP: "203.0.113.7", Path: "/.git/HEAD", Status: 404})
+	if c.checkSensitive(now) {
+		t.Errorf("checkSensitive() = %+v, want .git no longer sensitive", c.board.Active())
+	}
+}
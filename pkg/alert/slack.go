@@ -0,0 +1,212 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/stats"
+)
+
+// severityEmoji prefixes the Slack alert headers.
+var severityEmoji = map[Severity]string{
+	SeverityCritical: ":red_circle:",
+	SeverityWarning:  ":large_yellow_circle:",
+	SeverityInfo:     ":large_blue_circle:",
+}
+
+// slackMessage is the body of a Slack incoming webhook request, made of
+// Block Kit blocks. Text is shown in notifications.
+type slackMessage struct {
+	Channel string       `json:"channel,omitempty"`
+	Text    string       `json:"text"`
+	Blocks  []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"` // "plain_text" or "mrkdwn"
+	Text string `json:"text"`
+}
+
+// slackRoute is where the alerts of a rule are posted: another webhook, or
+// a channel of the default one.
+type slackRoute struct {
+	url     string
+	channel string
+}
+
+// Slack posts alerts and summaries to Slack incoming webhooks as formatted
+// messages. Alerts are queued by Notify and delivered by Run, so that slow
+// requests do not hold up the checks.
+type Slack struct {
+	poster
+	queue[Alert]
+	url    string                // Default webhook, empty to only post routed alerts
+	routes map[string]slackRoute // By alert ID
+}
+
+// NewSlack returns a Slack notifier posting to the incoming webhook url.
+// Routes send the alerts of a rule elsewhere, e.g. "5xx_spike=#oncall"
+// to a channel of the default webhook (for webhooks allowed to override
+// their channel) or "disk_full=https://hooks.slack.com/services/..." to
+// another webhook.
+func NewSlack(url string, routes []string) (*Slack, error) {
+	s := &Slack{
+		poster: newPoster("slack"),
+		url:    url,
+		routes: make(map[string]slackRoute),
+	}
+	s.queue = newQueue(s.Send)
+	if url != "" {
+		if err := checkURL(url); err != nil {
+			return nil, fmt.Errorf("slack: %w", err)
+		}
+	}
+	for _, r := range routes {
+		rule, target, ok := strings.Cut(r, "=")
+		rule, target = strings.TrimSpace(rule), strings.TrimSpace(target)
+		if !ok || rule == "" || target == "" {
+			return nil, fmt.Errorf("slack: invalid route %q, expected rule=#channel or rule=URL", r)
+		}
+		if strings.Contains(target, "://") {
+			if err := checkURL(target); err != nil {
+				return nil, fmt.Errorf("slack: route %s: %w", rule, err)
+			}
+			s.routes[rule] = slackRoute{url: target}
+			continue
+		}
+		if url == "" {
+			return nil, fmt.Errorf("slack: route %s to channel %s needs a default webhook", rule, target)
+		}
+		s.routes[rule] = slackRoute{url: url, channel: "#" + strings.TrimPrefix(target, "#")}
+	}
+	if url == "" && len(s.routes) == 0 {
+		return nil, errors.New("slack: no webhook URL")
+	}
+	return s, nil
+}
+
+// Notify queues an alert for delivery.
+func (s *Slack) Notify(a Alert) {
+	s.enqueue(a)
+}
+
+// Send posts an alert to the webhook of its rule, or to the default one.
+// Alerts of other rules are dropped without a default webhook.
+func (s *Slack) Send(a Alert) error {
+	route, ok := s.routes[a.ID]
+	if !ok {
+		route = slackRoute{url: s.url}
+	}
+	if route.url == "" {
+		return nil
+	}
+	msg := slackAlert(a)
+	msg.Channel = route.channel
+	return s.postMessage(route.url, msg)
+}
+
+// Summary posts a summary of a snapshot to the default webhook. It is a
+// stats.Output, e.g. for hourly summaries.
+func (s *Slack) Summary(snap *stats.Snapshot) error {
+	if s.url == "" {
+		return nil
+	}
+	return s.postMessage(s.url, slackSummary(snap))
+}
+
+func (s *Slack) postMessage(url string, msg slackMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return s.post(url, body)
+}
+
+// slackAlert formats an alert: a header with its severity and rule, its
+// message and details, and its top offenders.
+func slackAlert(a Alert) slackMessage {
+	fields := []slackText{
+		mrkdwn("*Value*\n" + strconv.FormatFloat(a.Value, 'f', 1, 64)),
+		mrkdwn("*Since*\n" + a.Since.UTC().Format(time.RFC3339)),
+	}
+	if a.Window > 0 {
+		fields = append(fields, mrkdwn("*Window*\n"+a.Window.String()))
+	}
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text",
+			Text: fmt.Sprintf("%s %s %s", severityEmoji[a.Severity], a.Severity, a.ID)}},
+		{Type: "section", Text: ptr(mrkdwn(slackEscape(a.Message))), Fields: fields},
+	}
+	if len(a.Offenders) > 0 {
+		lines := []string{"*Top offenders*"}
+		for _, o := range a.Offenders {
+			lines = append(lines, fmt.Sprintf("• %s %d requests", slackCode(o.Value), o.Requests))
+		}
+		blocks = append(blocks, slackBlock{Type: "section", Text: ptr(mrkdwn(strings.Join(lines, "\n")))})
+	}
+	return slackMessage{
+		Text:   fmt.Sprintf("[%s] %s", a.Severity, a.Message),
+		Blocks: blocks,
+	}
+}
+
+// slackSummary formats a snapshot: a header with its period, the traffic
+// totals and the top paths, IPs and status codes.
+func slackSummary(snap *stats.Snapshot) slackMessage {
+	title := summaryTitle(snap)
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: ":bar_chart: " + title}},
+		{Type: "section", Fields: []slackText{
+			mrkdwn(fmt.Sprintf("*Requests*\n%d", snap.Requests)),
+			mrkdwn(fmt.Sprintf("*Unique visitors*\n%d", snap.UniqueVisitors)),
+			mrkdwn(fmt.Sprintf("*Bytes*\n%d", snap.Bytes)),
+		}},
+	}
+	var lists []slackText
+	for _, l := range summaryLists(snap) {
+		lines := []string{"*" + l.title + "*"}
+		for _, item := range l.items {
+			lines = append(lines, fmt.Sprintf("%s %d", slackCode(item.Key), item.Count))
+		}
+		lists = append(lists, mrkdwn(strings.Join(lines, "\n")))
+	}
+	if len(lists) > 0 {
+		blocks = append(blocks, slackBlock{Type: "section", Fields: lists})
+	}
+	blocks = append(blocks, slackBlock{Type: "context", Elements: []slackText{
+		mrkdwn(slackCode(snap.LogPath) + " " + snap.Time.UTC().Format(time.RFC3339)),
+	}})
+	return slackMessage{
+		Text:   fmt.Sprintf("%s: %d requests", title, snap.Requests),
+		Blocks: blocks,
+	}
+}
+
+func mrkdwn(text string) slackText { return slackText{Type: "mrkdwn", Text: text} }
+
+func ptr[T any](v T) *T { return &v }
+
+// slackEscaper escapes the characters Slack reserves for links and mentions.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackEscape escapes text for a mrkdwn field.
+func slackEscape(text string) string {
+	return slackEscaper.Replace(text)
+}
+
+// slackCode formats a path, IP or other key as inline code, cut to
+// keyLength runes. Backticks, which would end the code, are replaced.
+func slackCode(text string) string {
+	return "`" + slackEscape(strings.ReplaceAll(cutKey(text), "`", "'")) + "`"
+}
@@ -0,0 +1,126 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/stats"
+)
+
+// slackServer records the messages posted to each path.
+func slackServer(t *testing.T) (*httptest.Server, map[string][]slackMessage) {
+	posted := make(map[string][]slackMessage)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var msg slackMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Errorf("body %s: %v", body, err)
+		}
+		posted[r.URL.Path] = append(posted[r.URL.Path], msg)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, posted
+}
+
+func TestSlackRoutes(t *testing.T) {
+	srv, posted := slackServer(t)
+	s, err := NewSlack(srv.URL+"/default", []string{"5xx_spike = #oncall", "disk_full=" + srv.URL + "/infra"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"5xx_spike", "disk_full", "other"} {
+		a := testAlert()
+		a.ID = id
+		if err := s.Send(a); err != nil {
+			t.Fatalf("Send(%s) error = %v", id, err)
+		}
+	}
+
+	def := posted["/default"]
+	if len(def) != 2 || def[0].Channel != "#oncall" || def[1].Channel != "" {
+		t.Errorf("default webhook got %+v, want the 5xx spike in #oncall and the other alert", def)
+	}
+	if infra := posted["/infra"]; len(infra) != 1 || !strings.Contains(infra[0].Blocks[0].Text.Text, "disk_full") {
+		t.Errorf("routed webhook got %+v", infra)
+	}
+
+	// Without a default webhook only routed alerts are posted
+	s, err = NewSlack("", []string{"disk_full=" + srv.URL + "/infra"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(testAlert()); err != nil || len(posted["/infra"]) != 1 {
+		t.Errorf("Send() of an unrouted alert = %v, posted %d", err, len(posted["/infra"]))
+	}
+}
+
+func TestSlackAlert(t *testing.T) {
+	msg := slackAlert(testAlert())
+	if msg.Text != `[CRIT] 5xx spike: 40% of "50" requests` {
+		t.Errorf("text = %q", msg.Text)
+	}
+	if len(msg.Blocks) != 3 {
+		t.Fatalf("got %d blocks, want header, details and offenders", len(msg.Blocks))
+	}
+	if h := msg.Blocks[0].Text.Text; h != ":red_circle: CRIT 5xx_spike" {
+		t.Errorf("header = %q", h)
+	}
+	if f := msg.Blocks[1].Fields; len(f) != 3 || f[0].Text != "*Value*\n40.0" || f[2].Text != "*Window*\n1m0s" {
+		t.Errorf("fields = %+v", f)
+	}
+	if o := msg.Blocks[2].Text.Text; !strings.Contains(o, "• `203.0.113.7` 15 requests") {
+		t.Errorf("offenders = %q", o)
+	}
+}
+
+func TestSlackSummary(t *testing.T) {
+	srv, posted := slackServer(t)
+	s, err := NewSlack(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := &stats.Snapshot{
+		Time:     time.Date(2025, 10, 10, 13, 0, 0, 0, time.UTC),
+		LogPath:  "/var/log/nginx/access.log",
+		Window:   "1h0m0s",
+		Requests: 120,
+	}
+	snap.AddSection("paths", map[string]int{"/a<b>": 100, "/`x`": 20})
+	if err := s.Summary(snap); err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+
+	msgs := posted["/"]
+	if len(msgs) != 1 {
+		t.Fatalf("posted %d messages, want 1", len(msgs))
+	}
+	msg := msgs[0]
+	if msg.Text != "tailnginx summary (1h0m0s): 120 requests" {
+		t.Errorf("text = %q", msg.Text)
+	}
+	if paths := msg.Blocks[2].Fields[0].Text; paths != "*Top paths*\n`/a&lt;b&gt;` 100\n`/'x'` 20" {
+		t.Errorf("paths = %q", paths)
+	}
+}
+
+func TestNewSlack(t *testing.T) {
+	for _, c := range []struct {
+		url    string
+		routes []string
+	}{
+		{"", nil},
+		{"hooks.slack.com/services/x", nil},
+		{"https://hooks.slack.com/services/x", []string{"5xx_spike"}},
+		{"", []string{"5xx_spike=#oncall"}},
+	} {
+		if _, err := NewSlack(c.url, c.routes); err == nil {
+			t.Errorf("NewSlack(%q, %q) should fail", c.url, c.routes)
+		}
+	}
+}
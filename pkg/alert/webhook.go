@@ -0,0 +1,230 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Webhook delivery settings
+const (
+	webhookTimeout  = 10 * time.Second
+	webhookAttempts = 3               // Tries per URL before giving up
+	webhookBackoff  = 2 * time.Second // Delay before the first retry, doubled after each
+	webhookQueue    = 64              // Alerts or events waiting for delivery before new ones are dropped
+)
+
+// Payload is the JSON document posted to webhooks, and the data of
+// webhook templates.
+type Payload struct {
+	Rule      string     `json:"rule"`     // Alert ID, e.g. "5xx_spike"
+	Severity  string     `json:"severity"` // INFO, WARN or CRIT
+	Message   string     `json:"message"`
+	Value     float64    `json:"value"`
+	Window    string     `json:"window,omitempty"` // e.g. "1m0s", empty for instant checks
+	Offenders []Offender `json:"offenders"`
+	Since     time.Time  `json:"since"`
+}
+
+// NewPayload returns the webhook payload of an alert.
+func NewPayload(a Alert) Payload {
+	p := Payload{
+		Rule:      a.ID,
+		Severity:  a.Severity.String(),
+		Message:   a.Message,
+		Value:     a.Value,
+		Offenders: a.Offenders,
+		Since:     a.Since,
+	}
+	if a.Window > 0 {
+		p.Window = a.Window.String()
+	}
+	if p.Offenders == nil {
+		p.Offenders = []Offender{}
+	}
+	return p
+}
+
+// templateFuncs are available in webhook templates besides the builtins.
+var templateFuncs = template.FuncMap{
+	// json encodes a value, e.g. {{json .Message}} for a quoted string
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Webhook posts fired alerts to HTTP endpoints. Alerts are queued by Notify
+// and delivered by Run, so that slow endpoints do not hold up the checks.
+type Webhook struct {
+	poster
+	queue[Alert]
+	urls []string
+	tmpl *template.Template // Body template, nil for the JSON payload
+}
+
+// queue delivers items with send in the background, so that slow
+// deliveries do not hold up the checks.
+type queue[T any] struct {
+	items chan T
+	send  func(T) error
+}
+
+func newQueue[T any](send func(T) error) queue[T] {
+	return queue[T]{items: make(chan T, webhookQueue), send: send}
+}
+
+// enqueue queues an item for delivery. It is dropped if the queue is full.
+func (q queue[T]) enqueue(v T) {
+	select {
+	case q.items <- v:
+	default:
+	}
+}
+
+// Run delivers the queued items and passes the errors to report. It never
+// returns.
+func (q queue[T]) Run(report func(error)) {
+	for v := range q.items {
+		if err := q.send(v); err != nil {
+			report(err)
+		}
+	}
+}
+
+// poster posts JSON documents with retries.
+type poster struct {
+	name    string      // Prefix of errors, e.g. "webhook"
+	header  http.Header // Extra request headers, e.g. for authentication
+	client  *http.Client
+	backoff time.Duration
+}
+
+func newPoster(name string) poster {
+	return poster{name: name, client: &http.Client{Timeout: webhookTimeout}, backoff: webhookBackoff}
+}
+
+// NewWebhook returns a webhook posting to urls. The body is the JSON
+// payload of the alert or, with a non-empty template, the template
+// executed with the payload, e.g. {"text": {{json .Message}}}.
+func NewWebhook(urls []string, tmpl string) (*Webhook, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("webhook: no URL")
+	}
+	for _, url := range urls {
+		if err := checkURL(url); err != nil {
+			return nil, fmt.Errorf("webhook: %w", err)
+		}
+	}
+	w := &Webhook{
+		poster: newPoster("webhook"),
+		urls:   urls,
+	}
+	w.queue = newQueue(w.Send)
+	if tmpl != "" {
+		t, err := template.New("webhook").Funcs(templateFuncs).Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("webhook: %w", err)
+		}
+		w.tmpl = t
+	}
+	return w, nil
+}
+
+// Notify queues an alert for delivery.
+func (w *Webhook) Notify(a Alert) {
+	w.enqueue(a)
+}
+
+// Send posts an alert to every URL, retrying failed requests and server
+// errors with an increasing delay.
+func (w *Webhook) Send(a Alert) error {
+	var body bytes.Buffer
+	payload := NewPayload(a)
+	if w.tmpl != nil {
+		if err := w.tmpl.Execute(&body, payload); err != nil {
+			return fmt.Errorf("webhook: %w", err)
+		}
+	} else if err := json.NewEncoder(&body).Encode(payload); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	var errs []error
+	for _, url := range w.urls {
+		errs = append(errs, w.post(url, body.Bytes()))
+	}
+	return errors.Join(errs...)
+}
+
+// post sends a body to url, up to webhookAttempts times.
+func (p *poster) post(url string, body []byte) error {
+	delay := p.backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = p.postOnce(url, body)
+		if err == nil || !retry || attempt == webhookAttempts {
+			break
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", p.name, redactURL(url), err)
+	}
+	return nil
+}
+
+// postOnce sends a body to url and reports whether a failure is worth
+// retrying: network errors, 429 and 5xx responses are.
+func (p *poster) postOnce(url string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range p.header {
+		req.Header[k] = v
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		var ue *neturl.Error
+		if errors.As(err, &ue) {
+			err = ue.Err // Without the URL
+		}
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return false, nil
+}
+
+// checkURL returns an error unless url is an absolute HTTP or HTTPS URL.
+func checkURL(url string) error {
+	u, err := neturl.Parse(url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %s, expected http:// or https://", redactURL(url))
+	}
+	return nil
+}
+
+// redactURL drops the path and query of a URL for error messages, since
+// webhook URLs often embed a secret token.
+func redactURL(url string) string {
+	if scheme, rest, ok := strings.Cut(url, "://"); ok {
+		host, _, _ := strings.Cut(rest, "/")
+		return scheme + "://" + host
+	}
+	return "URL"
+}
@@ -0,0 +1,143 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testAlert() Alert {
+	return Alert{
+		ID:        "5xx_spike",
+		Severity:  SeverityCritical,
+		Message:   `5xx spike: 40% of "50" requests`,
+		Since:     time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC),
+		Value:     40,
+		Window:    time.Minute,
+		Offenders: []Offender{{Value: "203.0.113.7", Requests: 15}},
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook([]string{srv.URL}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Send(testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	if p.Rule != "5xx_spike" || p.Severity != "CRIT" || p.Value != 40 || p.Window != "1m0s" ||
+		len(p.Offenders) != 1 || p.Offenders[0].Value != "203.0.113.7" || p.Offenders[0].Requests != 15 {
+		t.Errorf("payload = %+v", p)
+	}
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook([]string{srv.URL}, `{"text": {{json (printf "%s %s" .Severity .Message)}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Send(testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	want := `{"text": "CRIT 5xx spike: 40% of \"50\" requests"}`
+	if string(body) != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+
+	if _, err := NewWebhook([]string{srv.URL}, "{{.Rule"); err == nil {
+		t.Error("NewWebhook() with an invalid template should fail")
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	var calls atomic.Int32
+	status := http.StatusBadGateway
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < webhookAttempts {
+			w.WriteHeader(status)
+		}
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook([]string{srv.URL + "/hooks/secret"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.backoff = time.Millisecond
+	if err := w.Send(testAlert()); err != nil {
+		t.Errorf("Send() should succeed on the last attempt, got %v", err)
+	}
+	if n := calls.Load(); n != webhookAttempts {
+		t.Errorf("%d attempts, want %d", n, webhookAttempts)
+	}
+
+	// Client errors are not retried, and the error hides the URL path
+	calls.Store(0)
+	status = http.StatusNotFound
+	err = w.Send(testAlert())
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Send() error = %v, want a 404 without the path", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d attempts after a 404, want 1", n)
+	}
+}
+
+func TestNewWebhookURLs(t *testing.T) {
+	for _, urls := range [][]string{nil, {"hooks.example.com/x"}, {"ftp://example.com"}} {
+		if _, err := NewWebhook(urls, ""); err == nil {
+			t.Errorf("NewWebhook(%q) should fail", urls)
+		}
+	}
+}
+
+// TestQueue tests that queued items are delivered in order and dropped
+// while the queue is full.
+func TestQueue(t *testing.T) {
+	var sent []int
+	q := newQueue(func(v int) error {
+		sent = append(sent, v)
+		if v == 0 {
+			return io.ErrUnexpectedEOF
+		}
+		return nil
+	})
+	for i := range webhookQueue + 1 {
+		q.enqueue(i)
+	}
+	close(q.items)
+
+	var errs []error
+	q.Run(func(err error) { errs = append(errs, err) })
+	if len(sent) != webhookQueue || sent[0] != 0 || sent[webhookQueue-1] != webhookQueue-1 {
+		t.Errorf("sent %d items, want the first %d", len(sent), webhookQueue)
+	}
+	if len(errs) != 1 {
+		t.Errorf("reported %v, want the error of the first item", errs)
+	}
+}
@@ -0,0 +1,197 @@
+// Package anomaly scores how suspicious the activity of each client IP
+// looks, combining signals that are weak on their own: error ratio, path
+// diversity, request rate, user agent, sensitive paths and attack
+// signatures.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/attack"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/sensitive"
+	"github.com/papaganelli/tailnginx/pkg/useragent"
+)
+
+// Points of each signal at its strongest, adding up to 100.
+const (
+	weightErrors    = 20
+	weightPaths     = 15
+	weightRate      = 20
+	weightAgent     = 15
+	weightSensitive = 15
+	weightAttacks   = 15
+)
+
+// Signal thresholds
+const (
+	fullRequests = 10          // Requests from which the error ratio counts fully
+	fullPaths    = 50          // Distinct paths from which the path diversity counts fully
+	fullRate     = 5.0         // Requests per second that score the rate fully
+	minSpan      = time.Minute // Shortest span rates are averaged over, so that bursts of page assets are no floods
+	minReason    = 3           // Points from which a signal is given as a reason
+	fullHits     = 2           // Sensitive path or attack requests that score fully
+)
+
+// Score is the suspicion score of an IP.
+type Score struct {
+	IP       string
+	Score    int // From 0 to 100
+	Requests int
+	Reasons  []string // Signals that scored, strongest first, e.g. "84% errors"
+}
+
+// profile is the activity of an IP.
+type profile struct {
+	requests   int
+	errors     int
+	paths      map[string]bool
+	first      time.Time
+	last       time.Time
+	agent      string  // Last user agent, to score each one once
+	agentScore float64 // Most suspicious user agent, from 0 to 1
+	agentName  string
+	sensitive  int
+	attacks    int
+}
+
+// pathKind tells whether a path is sensitive or an attack.
+type pathKind struct {
+	sensitive bool
+	attack    bool
+}
+
+// Scorer builds the profiles of IPs from their requests and scores them.
+type Scorer struct {
+	sensitive *sensitive.Matcher
+	profiles  map[string]*profile
+	kinds     map[string]pathKind // Paths matched, as most are requested again
+}
+
+// NewScorer returns a scorer telling sensitive paths with m.
+func NewScorer(m *sensitive.Matcher) *Scorer {
+	return &Scorer{sensitive: m, profiles: make(map[string]*profile), kinds: make(map[string]pathKind)}
+}
+
+// Add adds a request to the profile of its IP, with its parsed user agent.
+func (s *Scorer) Add(v *parser.Visitor, ua useragent.Info) {
+	p := s.profiles[v.IP]
+	if p == nil {
+		p = &profile{paths: make(map[string]bool), first: v.Time, last: v.Time, agent: "\x00"}
+		s.profiles[v.IP] = p
+	}
+	p.requests++
+	if v.Status >= 400 {
+		p.errors++
+	}
+	path, _, _ := strings.Cut(v.Path, "?")
+	p.paths[path] = true
+	if v.Time.Before(p.first) {
+		p.first = v.Time
+	}
+	if v.Time.After(p.last) {
+		p.last = v.Time
+	}
+	if v.Agent != p.agent {
+		p.agent = v.Agent
+		if score, name := agentScore(v.Agent, ua); score > p.agentScore {
+			p.agentScore, p.agentName = score, name
+		}
+	}
+	kind, ok := s.kinds[v.Path]
+	if !ok {
+		kind = pathKind{sensitive: s.sensitive.Match(v.Path) != "", attack: attack.Match(v.Path) != ""}
+		s.kinds[v.Path] = kind
+	}
+	if kind.sensitive {
+		p.sensitive++
+	}
+	if kind.attack {
+		p.attacks++
+	}
+}
+
+// agentScore returns how suspicious a user agent is, from 0 to 1, and how
+// to name it: offensive tools most, then missing user agents, then scripts
+// and headless browsers. Browsers and crawlers score 0.
+func agentScore(agent string, ua useragent.Info) (float64, string) {
+	switch {
+	case attack.Tool(agent) != "":
+		return 1, attack.Tool(agent)
+	case agent == "" || agent == "-":
+		return 0.8, "no user agent"
+	case ua.IsBot():
+		return 0, ""
+	case ua.Browser == "Headless Chrome":
+		return 0.5, ua.Browser
+	case ua.Device == useragent.DeviceOther:
+		if ua.Browser == useragent.Unknown {
+			return 0.5, "unknown client"
+		}
+		return 0.5, ua.Browser
+	}
+	return 0, ""
+}
+
+// Top returns the IPs scoring at least least, the most suspicious first.
+func (s *Scorer) Top(least int) []Score {
+	var scores []Score
+	for ip, p := range s.profiles {
+		if score := p.score(ip); score.Score >= least {
+			scores = append(scores, score)
+		}
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		if scores[i].Requests != scores[j].Requests {
+			return scores[i].Requests > scores[j].Requests
+		}
+		return scores[i].IP < scores[j].IP
+	})
+	return scores
+}
+
+// signal is the points of a signal with how to give it as a reason.
+type signal struct {
+	points float64
+	reason string
+}
+
+// score combines the signals of a profile.
+func (p *profile) score(ip string) Score {
+	requests := float64(p.requests)
+	span := max(p.last.Sub(p.first), minSpan)
+	rate := requests / span.Seconds()
+	signals := []signal{
+		{weightErrors * float64(p.errors) / requests * min(1, requests/fullRequests),
+			fmt.Sprintf("%.0f%% errors", 100*float64(p.errors)/requests)},
+		{weightPaths * float64(len(p.paths)) / requests * min(1, float64(len(p.paths))/fullPaths),
+			fmt.Sprintf("%d paths", len(p.paths))},
+		{weightRate * min(1, rate/fullRate),
+			fmt.Sprintf("%.1f r/s", rate)},
+		{weightAgent * p.agentScore, p.agentName},
+		{weightSensitive * min(1, float64(p.sensitive)/fullHits),
+			fmt.Sprintf("%d sensitive", p.sensitive)},
+		{weightAttacks * min(1, float64(p.attacks)/fullHits),
+			fmt.Sprintf("%d attacks", p.attacks)},
+	}
+
+	total := 0.0
+	for _, s := range signals {
+		total += s.points
+	}
+	sort.SliceStable(signals, func(i, j int) bool { return signals[i].points > signals[j].points })
+	score := Score{IP: ip, Score: int(math.Round(total)), Requests: p.requests}
+	for _, s := range signals {
+		if s.points >= minReason {
+			score.Reasons = append(score.Reasons, s.reason)
+		}
+	}
+	return score
+}
@@ -0,0 +1,86 @@
+package anomaly
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/sensitive"
+	"github.com/papaganelli/tailnginx/pkg/useragent"
+)
+
+const chrome = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0 Safari/537.36"
+
+func add(s *Scorer, v parser.Visitor) {
+	s.Add(&v, useragent.Parse(v.Agent))
+}
+
+func TestScorer(t *testing.T) {
+	s := NewScorer(sensitive.Default())
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+
+	// A visitor browsing a few pages
+	for i := 0; i < 20; i++ {
+		add(s, parser.Visitor{Time: now.Add(time.Duration(i) * time.Second), IP: "192.0.2.1", Path: fmt.Sprintf("/page/%d", i%5), Agent: chrome, Status: 200})
+	}
+	// A health check polling every second
+	for i := 0; i < 600; i++ {
+		add(s, parser.Visitor{Time: now.Add(time.Duration(i) * time.Second), IP: "192.0.2.2", Path: "/healthz", Agent: "curl/8.5.0", Status: 200})
+	}
+	// A scanner trying 60 paths in 30 seconds, .env and an injection among them
+	for i := 0; i < 60; i++ {
+		add(s, parser.Visitor{Time: now.Add(time.Duration(i) * time.Second / 2), IP: "203.0.113.7", Path: fmt.Sprintf("/admin%d.php", i), Agent: "Mozilla/5.00 (Nikto/2.1.6)", Status: 404})
+	}
+	add(s, parser.Visitor{Time: now, IP: "203.0.113.7", Path: "/.env", Agent: "Mozilla/5.00 (Nikto/2.1.6)", Status: 403})
+	add(s, parser.Visitor{Time: now, IP: "203.0.113.7", Path: "/?id=1' OR '1'='1", Agent: "Mozilla/5.00 (Nikto/2.1.6)", Status: 403})
+
+	all := s.Top(0)
+	if len(all) != 3 {
+		t.Fatalf("Top(0) = %+v, want 3 IPs", all)
+	}
+	scanner, health, browser := all[0], all[1], all[2]
+	if scanner.IP != "203.0.113.7" || health.IP != "192.0.2.2" || browser.IP != "192.0.2.1" {
+		t.Fatalf("Top(0) = %+v, want the scanner, the health check and the browser", all)
+	}
+	if scanner.Score < 60 || scanner.Requests != 62 {
+		t.Errorf("scanner = %+v, want a high score", scanner)
+	}
+	for _, reason := range []string{"100% errors", "62 paths", "Nikto", "1 sensitive", "1 attacks"} {
+		if !slices.Contains(scanner.Reasons, reason) {
+			t.Errorf("scanner reasons = %q, want %q", scanner.Reasons, reason)
+		}
+	}
+	if scanner.Reasons[0] != "100% errors" {
+		t.Errorf("scanner reasons = %q, want the strongest first", scanner.Reasons)
+	}
+	if health.Score < 5 || health.Score > 20 || !slices.Equal(health.Reasons, []string{"curl", "1.0 r/s"}) {
+		t.Errorf("health check = %+v", health)
+	}
+	if browser.Score > 5 || len(browser.Reasons) != 0 {
+		t.Errorf("browser = %+v, want a low score", browser)
+	}
+
+	if top := s.Top(20); len(top) != 1 || top[0].IP != "203.0.113.7" {
+		t.Errorf("Top(20) = %+v, want the scanner only", top)
+	}
+}
+
+func TestAgentScore(t *testing.T) {
+	tests := map[string]string{
+		"sqlmap/1.7.2#stable (https://sqlmap.org)": "sqlmap",
+		"-":                    "no user agent",
+		"":                     "no user agent",
+		"python-requests/2.31": "Python",
+		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0 Safari/537.36": "Headless Chrome",
+		"SomethingOdd/1.0": "unknown client",
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)": "",
+		chrome: "",
+	}
+	for agent, want := range tests {
+		if _, got := agentScore(agent, useragent.Parse(agent)); got != want {
+			t.Errorf("agentScore(%q) = %q, want %q", agent, got, want)
+		}
+	}
+}
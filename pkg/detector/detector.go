@@ -211,3 +211,23 @@ func GetBestLogFile(logs []LogFile) LogFile {
 
 	return largest
 }
+
+// DetectErrorLog returns the nginx error log that belongs to an access log,
+// or "" if none is found. It looks next to the access log for the same name
+// with "access" replaced by "error" (e.g. site.access.log -> site.error.log),
+// then for error.log.
+func DetectErrorLog(accessLogPath string) string {
+	dir, base := filepath.Split(accessLogPath)
+	candidates := []string{}
+	if strings.Contains(base, "access") {
+		candidates = append(candidates, filepath.Join(dir, strings.Replace(base, "access", "error", 1)))
+	}
+	candidates = append(candidates, filepath.Join(dir, "error.log"))
+
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && path != filepath.Clean(accessLogPath) {
+			return path
+		}
+	}
+	return ""
+}
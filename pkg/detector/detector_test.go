@@ -171,3 +171,31 @@ func TestDetectLogFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestDetectErrorLog(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	access := write("access.log")
+	if got := DetectErrorLog(access); got != "" {
+		t.Errorf("DetectErrorLog() = %q, want none", got)
+	}
+
+	errorLog := write("error.log")
+	if got := DetectErrorLog(access); got != errorLog {
+		t.Errorf("DetectErrorLog() = %q, want %q", got, errorLog)
+	}
+
+	// A site-specific error log is preferred over the default one
+	siteAccess := write("example.com.access.log")
+	siteError := write("example.com.error.log")
+	if got := DetectErrorLog(siteAccess); got != siteError {
+		t.Errorf("DetectErrorLog() = %q, want %q", got, siteError)
+	}
+}
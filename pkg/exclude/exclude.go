@@ -0,0 +1,152 @@
+// Package exclude drops requests matching patterns, e.g. health checks and
+// uptime monitors, before they are counted.
+package exclude
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Rules are the patterns of excluded requests. A nil *Rules excludes
+// nothing.
+type Rules struct {
+	paths  []*regexp.Regexp
+	ips    []*regexp.Regexp
+	agents []*regexp.Regexp
+	nets   []*net.IPNet
+	since  time.Time // Earliest time of the included requests, zero for none
+	until  time.Time // Latest time of the included requests, zero for none
+}
+
+// New compiles the regular expressions of excluded paths, client IPs and user
+// agents. A request is excluded when any of them matches, anywhere in the
+// value unless anchored, e.g. '^/healthz$'. Returns nil when there are no
+// patterns.
+func New(paths, ips, agents []string) (*Rules, error) {
+	if len(paths)+len(ips)+len(agents) == 0 {
+		return nil, nil
+	}
+	r := &Rules{}
+	var err error
+	if r.paths, err = compile("path", paths); err != nil {
+		return nil, err
+	}
+	if r.ips, err = compile("IP", ips); err != nil {
+		return nil, err
+	}
+	if r.agents, err = compile("agent", agents); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func compile(kind string, patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid excluded %s pattern %q: %w", kind, pattern, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// Between also excludes the requests logged before since or after until,
+// and those without a time. A zero bound is open. Returns the rules, new
+// ones when r is nil.
+func (r *Rules) Between(since, until time.Time) *Rules {
+	if r == nil {
+		r = &Rules{}
+	}
+	r.since, r.until = since, until
+	return r
+}
+
+// Networks also excludes the requests of clients in networks, e.g. office
+// ranges or the host's own addresses. Returns the rules, new ones when r is
+// nil.
+func (r *Rules) Networks(nets []*net.IPNet) *Rules {
+	if r == nil {
+		r = &Rules{}
+	}
+	r.nets = append(r.nets, nets...)
+	return r
+}
+
+// ParseNetworks parses networks in CIDR notation, or single IPs.
+func ParseNetworks(texts []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, text := range texts {
+		if _, n, err := net.ParseCIDR(text); err == nil {
+			nets = append(nets, n)
+			continue
+		}
+		ip := net.ParseIP(text)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid network %q: expected an IP or a network such as 203.0.113.0/24", text)
+		}
+		nets = append(nets, hostNetwork(ip))
+	}
+	return nets, nil
+}
+
+// LocalNetworks returns the addresses of the host's network interfaces,
+// loopback included, as single-address networks. Addresses behind NAT, as
+// seen by the server, are not among them.
+func LocalNetworks() ([]*net.IPNet, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	var nets []*net.IPNet
+	for _, addr := range addrs {
+		if n, ok := addr.(*net.IPNet); ok {
+			nets = append(nets, hostNetwork(n.IP))
+		}
+	}
+	return nets, nil
+}
+
+// hostNetwork returns the network of a single IP.
+func hostNetwork(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// Match reports whether a request is excluded.
+func (r *Rules) Match(v *parser.Visitor) bool {
+	if r == nil {
+		return false
+	}
+	if !r.since.IsZero() || !r.until.IsZero() {
+		if v.Time.IsZero() || v.Time.Before(r.since) || (!r.until.IsZero() && v.Time.After(r.until)) {
+			return true
+		}
+	}
+	if len(r.nets) > 0 {
+		if ip := net.ParseIP(v.IP); ip != nil {
+			for _, n := range r.nets {
+				if n.Contains(ip) {
+					return true
+				}
+			}
+		}
+	}
+	return matchAny(r.paths, v.Path) || matchAny(r.ips, v.IP) || matchAny(r.agents, v.Agent)
+}
+
+func matchAny(res []*regexp.Regexp, value string) bool {
+	for _, re := range res {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package exclude
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestMatch(t *testing.T) {
+	r, err := New([]string{`^/healthz$`, `\.(css|js|png)$`}, []string{`^10\.`}, []string{`(?i)uptimerobot`})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		v    parser.Visitor
+		want bool
+	}{
+		{parser.Visitor{IP: "203.0.113.7", Path: "/healthz"}, true},
+		{parser.Visitor{IP: "203.0.113.7", Path: "/healthz/deep"}, false},
+		{parser.Visitor{IP: "203.0.113.7", Path: "/static/app.js"}, true},
+		{parser.Visitor{IP: "10.0.0.5", Path: "/"}, true},
+		{parser.Visitor{IP: "110.0.0.5", Path: "/"}, false},
+		{parser.Visitor{IP: "203.0.113.7", Path: "/", Agent: "Mozilla/5.0+(compatible; UptimeRobot/2.0)"}, true},
+		{parser.Visitor{IP: "203.0.113.7", Path: "/checkout", Agent: "Mozilla/5.0"}, false},
+	}
+	for _, tt := range tests {
+		if got := r.Match(&tt.v); got != tt.want {
+			t.Errorf("Match(%+v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	r, err := New(nil, nil, nil)
+	if r != nil || err != nil {
+		t.Errorf("New() = %v, %v, want nil, nil", r, err)
+	}
+	if r.Match(&parser.Visitor{Path: "/"}) {
+		t.Error("nil Rules: Match() = true, want false")
+	}
+	if _, err := New(nil, []string{"("}, nil); err == nil {
+		t.Error("New() with an invalid pattern: want error")
+	}
+}
+
+func TestBetween(t *testing.T) {
+	since := time.Date(2025, 10, 8, 12, 0, 0, 0, time.UTC)
+	until := since.Add(time.Hour)
+	var none *Rules
+	r := none.Between(since, until)
+
+	tests := []struct {
+		time time.Time
+		want bool
+	}{
+		{since.Add(-time.Second), true},
+		{since, false},
+		{until, false},
+		{until.Add(time.Second), true},
+		{time.Time{}, true},
+	}
+	for _, tt := range tests {
+		if got := r.Match(&parser.Visitor{Time: tt.time, Path: "/"}); got != tt.want {
+			t.Errorf("Match(%v) = %v, want %v", tt.time, got, tt.want)
+		}
+	}
+
+	// Open bounds
+	r = none.Between(since, time.Time{})
+	if r.Match(&parser.Visitor{Time: until.Add(24 * time.Hour)}) {
+		t.Error("Match() after since without until = true, want false")
+	}
+}
+
+func TestNetworks(t *testing.T) {
+	nets, err := ParseNetworks([]string{"203.0.113.0/24", "2001:db8::1"})
+	if err != nil {
+		t.Fatalf("ParseNetworks() error = %v", err)
+	}
+	var none *Rules
+	r := none.Networks(nets)
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"203.0.113.42", true},
+		{"203.0.114.1", false},
+		{"2001:db8::1", true},
+		{"2001:db8::2", false},
+		{"not an ip", false},
+	}
+	for _, tt := range tests {
+		if got := r.Match(&parser.Visitor{IP: tt.ip}); got != tt.want {
+			t.Errorf("Match(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+
+	if _, err := ParseNetworks([]string{"office"}); err == nil {
+		t.Error("ParseNetworks() with an invalid network: want error")
+	}
+}
+
+func TestLocalNetworks(t *testing.T) {
+	nets, err := LocalNetworks()
+	if err != nil {
+		t.Skipf("no network interfaces: %v", err)
+	}
+	r := (*Rules)(nil).Networks(nets)
+	for _, n := range nets {
+		if ones, bits := n.Mask.Size(); ones != bits {
+			t.Errorf("network %s is not a single address", n)
+		}
+		if !r.Match(&parser.Visitor{IP: n.IP.String()}) {
+			t.Errorf("Match(%s) = false, want true", n.IP)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Package feed streams parsed log entries to live subscribers, e.g. custom
+// dashboards connected over WebSocket, a Kafka topic or Grafana Loki.
+package feed
+
+import (
+	"sync"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+const (
+	// subscriberBuffer is the number of entries queued for a subscriber.
+	// Entries published while the queue is full are dropped for that
+	// subscriber.
+	subscriberBuffer = 256
+	// shipperBuffer is the number of entries queued for Kafka or Loki, a few
+	// batches so that entries are not dropped while one is being sent.
+	shipperBuffer = 4 * kafkaBatch
+)
+
+// Entry is a parsed log entry as sent to subscribers.
+type Entry struct {
+	Time        time.Time `json:"time"`
+	IP          string    `json:"ip"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	Protocol    string    `json:"protocol,omitempty"`
+	Status      int       `json:"status"`
+	Bytes       int       `json:"bytes"`
+	Referer     string    `json:"referer,omitempty"`
+	Agent       string    `json:"agent,omitempty"`
+	Country     string    `json:"country,omitempty"`
+	RequestTime float64   `json:"request_time,omitempty"` // Seconds
+	Host        string    `json:"host,omitempty"`
+	TLSProtocol string    `json:"tls_protocol,omitempty"`
+	TLSCipher   string    `json:"tls_cipher,omitempty"`
+}
+
+// NewEntry converts a parsed visitor to an entry.
+func NewEntry(v *parser.Visitor) Entry {
+	return Entry{
+		Time:        v.Time,
+		IP:          v.IP,
+		Method:      v.Method,
+		Path:        v.Path,
+		Protocol:    v.Protocol,
+		Status:      v.Status,
+		Bytes:       v.Bytes,
+		Referer:     v.Referer,
+		Agent:       v.Agent,
+		Country:     v.Country,
+		RequestTime: v.RequestTime.Seconds(),
+		Host:        v.Host,
+		TLSProtocol: v.TLSProtocol,
+		TLSCipher:   v.TLSCipher,
+	}
+}
+
+// subscriber receives the entries matching all of its filters.
+type subscriber struct {
+	filters highlight.Rules
+	entries chan Entry
+	dropped uint64 // Entries missed because the queue was full
+}
+
+// Hub fans parsed entries out to subscribers. A nil Hub ignores published
+// entries, so it can be passed around when the feed is disabled. It is safe
+// for concurrent use.
+type Hub struct {
+	mu      sync.Mutex
+	subs    map[*subscriber]bool
+	dropped uint64 // Entries missed by all subscribers, past ones included
+	closed  bool
+}
+
+// NewHub creates a hub without subscribers.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*subscriber]bool)}
+}
+
+// Publish sends an entry to the subscribers whose filters it matches. It
+// never blocks: slow subscribers miss entries instead.
+func (h *Hub) Publish(v *parser.Visitor) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subs) == 0 {
+		return
+	}
+
+	entry := NewEntry(v)
+	for s := range h.subs {
+		if !MatchAll(s.filters, v) {
+			continue
+		}
+		select {
+		case s.entries <- entry:
+		default:
+			s.dropped++
+			h.dropped++
+		}
+	}
+}
+
+// Subscribe returns a channel of the entries published from now on that
+// match all filters, and a function that ends the subscription and closes
+// the channel.
+func (h *Hub) Subscribe(filters highlight.Rules) (<-chan Entry, func()) {
+	s, unsubscribe := h.subscribe(filters, subscriberBuffer)
+	return s.entries, unsubscribe
+}
+
+// subscribeAll subscribes a log shipper to all the entries with a queue of
+// size entries. dropped returns the number of entries missed since its last
+// call, so that the shipper can report them.
+func (h *Hub) subscribeAll(size int) (entries <-chan Entry, dropped func() uint64, unsubscribe func()) {
+	s, unsubscribe := h.subscribe(nil, size)
+	dropped = func() uint64 {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		n := s.dropped
+		s.dropped = 0
+		return n
+	}
+	return s.entries, dropped, unsubscribe
+}
+
+func (h *Hub) subscribe(filters highlight.Rules, size int) (*subscriber, func()) {
+	s := &subscriber{filters: filters, entries: make(chan Entry, size)}
+	h.mu.Lock()
+	if h.closed {
+		close(s.entries)
+	} else {
+		h.subs[s] = true
+	}
+	h.mu.Unlock()
+
+	return s, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.subs[s] {
+			delete(h.subs, s)
+			close(s.entries)
+		}
+	}
+}
+
+// Close ends all the subscriptions, closing their channels once the
+// entries queued are received, e.g. so that Kafka and Loki send their last
+// batch on shutdown. Entries published afterwards are ignored.
+func (h *Hub) Close() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		delete(h.subs, s)
+		close(s.entries)
+	}
+	h.closed = true
+}
+
+// Backlog returns the number of entries queued for all subscribers and the
+// room of their queues, e.g. to spot slow subscribers.
+func (h *Hub) Backlog() (queued, capacity int) {
+	if h == nil {
+		return 0, 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		queued += len(s.entries)
+		capacity += cap(s.entries)
+	}
+	return queued, capacity
+}
+
+// Dropped returns the number of entries missed by subscribers whose queue
+// was full since the hub was created.
+func (h *Hub) Dropped() uint64 {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.dropped
+}
+
+// MatchAll reports whether an entry matches every filter.
+func MatchAll(filters highlight.Rules, v *parser.Visitor) bool {
+	for _, f := range filters {
+		if !f.Match(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestHub(t *testing.T) {
+	h := NewHub()
+	h.Publish(&parser.Visitor{Path: "/before"})
+
+	errorsOnly, err := highlight.ParseCondition("status>=500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, stopAll := h.Subscribe(nil)
+	errs, stopErrs := h.Subscribe(highlight.Rules{errorsOnly})
+	defer stopErrs()
+
+	h.Publish(&parser.Visitor{Path: "/ok", Status: 200, RequestTime: 250 * time.Millisecond})
+	h.Publish(&parser.Visitor{Path: "/fail", Status: 502})
+
+	if e := <-all; e.Path != "/ok" || e.RequestTime != 0.25 {
+		t.Errorf("first entry = %+v, want /ok taking 0.25s", e)
+	}
+	if e := <-all; e.Path != "/fail" {
+		t.Errorf("second entry = %+v, want /fail", e)
+	}
+	if e := <-errs; e.Path != "/fail" || len(errs) != 0 {
+		t.Errorf("filtered entry = %+v with %d more, want only /fail", e, len(errs))
+	}
+
+	stopAll()
+	stopAll()
+	if _, ok := <-all; ok {
+		t.Error("channel should be closed after unsubscribing")
+	}
+	h.Publish(&parser.Visitor{Status: 500})
+}
+
+func TestHubDropsForSlowSubscribers(t *testing.T) {
+	h := NewHub()
+	entries, stop := h.Subscribe(nil)
+	defer stop()
+	for i := 0; i < subscriberBuffer+10; i++ {
+		h.Publish(&parser.Visitor{Status: 200})
+	}
+	if len(entries) != subscriberBuffer {
+		t.Errorf("queued %d entries, want %d", len(entries), subscriberBuffer)
+	}
+	if queued, capacity := h.Backlog(); queued != subscriberBuffer || capacity != subscriberBuffer {
+		t.Errorf("Backlog() = %d, %d, want a full queue of %d", queued, capacity, subscriberBuffer)
+	}
+	if dropped := h.Dropped(); dropped != 10 {
+		t.Errorf("Dropped() = %d, want 10", dropped)
+	}
+
+	var nilHub *Hub
+	nilHub.Publish(&parser.Visitor{})
+	if queued, capacity := nilHub.Backlog(); queued != 0 || capacity != 0 {
+		t.Errorf("nil Backlog() = %d, %d", queued, capacity)
+	}
+	if dropped := nilHub.Dropped(); dropped != 0 {
+		t.Errorf("nil Dropped() = %d", dropped)
+	}
+}
+
+func TestSubscribeAll(t *testing.T) {
+	h := NewHub()
+	entries, dropped, stop := h.subscribeAll(2)
+	defer stop()
+	for i := 0; i < 5; i++ {
+		h.Publish(&parser.Visitor{Status: 200})
+	}
+	if len(entries) != 2 {
+		t.Errorf("queued %d entries, want 2", len(entries))
+	}
+	if n := dropped(); n != 3 {
+		t.Errorf("dropped() = %d, want 3", n)
+	}
+	if n := dropped(); n != 0 {
+		t.Errorf("dropped() again = %d, want 0", n)
+	}
+	if n := h.Dropped(); n != 3 {
+		t.Errorf("Dropped() = %d, want 3", n)
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	h := NewHub()
+	entries, stop := h.Subscribe(nil)
+	h.Publish(&parser.Visitor{Status: 200})
+	h.Close()
+	h.Publish(&parser.Visitor{Status: 200})
+	if _, ok := <-entries; !ok {
+		t.Fatal("entry queued before Close was lost")
+	}
+	if _, ok := <-entries; ok {
+		t.Error("channel still open after Close")
+	}
+	stop()
+
+	late, stopLate := h.Subscribe(nil)
+	defer stopLate()
+	if _, ok := <-late; ok {
+		t.Error("subscription after Close is open")
+	}
+	var nilHub *Hub
+	nilHub.Close()
+}
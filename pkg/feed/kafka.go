@@ -0,0 +1,206 @@
+package feed
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// kafkaBatch is the largest number of entries sent in one request.
+	kafkaBatch = 500
+	// kafkaLinger is the longest time entries wait before being sent.
+	kafkaLinger = time.Second
+	// kafkaTimeout bounds connecting to a broker and each request.
+	kafkaTimeout = 10 * time.Second
+	// kafkaMaxResponse is the largest response accepted from a broker.
+	kafkaMaxResponse = 16 << 20
+	// kafkaClientID identifies the producer in broker logs.
+	kafkaClientID = "tailnginx"
+)
+
+// Kafka publishes entries as JSON to a Kafka topic, one record per entry,
+// batching them every second. Batches are spread over the partitions of the
+// topic in turn and sent to their leaders. It only talks plaintext, without
+// authentication. It is not safe for concurrent use: Run owns it.
+type Kafka struct {
+	bootstrap  []string
+	topic      string
+	brokers    map[int32]string // Address by node ID
+	partitions []partitionMeta  // Empty until the metadata is loaded
+	conns      map[string]net.Conn
+	next       int   // Index of the partition of the next batch
+	corrID     int32 // Correlation ID of the last request
+}
+
+// NewKafka creates a producer for topic, bootstrapped from a comma-separated
+// list of brokers, e.g. "kafka1:9092,kafka2:9092". Brokers are contacted
+// when the first entries are sent.
+func NewKafka(brokers, topic string) (*Kafka, error) {
+	var bootstrap []string
+	for _, addr := range strings.Split(brokers, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			bootstrap = append(bootstrap, addr)
+		}
+	}
+	if len(bootstrap) == 0 {
+		return nil, errors.New("no Kafka broker given")
+	}
+	if topic == "" {
+		return nil, errors.New("no Kafka topic given")
+	}
+	return &Kafka{bootstrap: bootstrap, topic: topic, conns: make(map[string]net.Conn)}, nil
+}
+
+// Run publishes the entries of the hub and passes the errors of failed
+// batches to report. Entries of a failed batch are lost, as are entries
+// published while the broker is slower than the log, which are counted in
+// the next report. It returns once the hub is closed, after sending the
+// entries left.
+func (k *Kafka) Run(h *Hub, report func(error)) {
+	entries, dropped, unsubscribe := h.subscribeAll(shipperBuffer)
+	defer unsubscribe()
+	defer k.Close()
+
+	ticker := time.NewTicker(kafkaLinger)
+	defer ticker.Stop()
+	var batch [][]byte
+	send := func() {
+		if n := dropped(); n > 0 {
+			report(fmt.Errorf("kafka: %d entries dropped while the broker was slower than the log", n))
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := k.Send(batch); err != nil {
+			report(err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				send()
+				return
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			batch = append(batch, data)
+			if len(batch) >= kafkaBatch {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		}
+	}
+}
+
+// Send publishes values to the next partition of the topic. On failure, the
+// connections are closed and the metadata is loaded again on the next call.
+func (k *Kafka) Send(values [][]byte) error {
+	if err := k.send(values); err != nil {
+		k.Close()
+		k.partitions = nil
+		return err
+	}
+	return nil
+}
+
+func (k *Kafka) send(values [][]byte) error {
+	if len(k.partitions) == 0 {
+		if err := k.loadMetadata(); err != nil {
+			return err
+		}
+	}
+	p := k.partitions[k.next%len(k.partitions)]
+	k.next++
+	addr, ok := k.brokers[p.leader]
+	if !ok {
+		return fmt.Errorf("kafka: no address for the leader of partition %d", p.id)
+	}
+	req := produceRequest(k.topic, p.id, recordBatch(values, time.Now()), kafkaTimeout)
+	resp, err := k.roundTrip(addr, apiProduce, produceVersion, req)
+	if err != nil {
+		return err
+	}
+	return parseProduce(resp)
+}
+
+// loadMetadata finds the partitions of the topic and their leaders from the
+// first bootstrap broker that answers.
+func (k *Kafka) loadMetadata() error {
+	var errs []error
+	for _, addr := range k.bootstrap {
+		resp, err := k.roundTrip(addr, apiMetadata, metadataVersion, metadataRequest(k.topic))
+		if err == nil {
+			k.brokers, k.partitions, err = parseMetadata(resp, k.topic)
+			if err == nil {
+				return nil
+			}
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// roundTrip sends a request to a broker and returns the response body.
+func (k *Kafka) roundTrip(addr string, apiKey, version int16, body []byte) ([]byte, error) {
+	conn, ok := k.conns[addr]
+	if !ok {
+		var err error
+		conn, err = net.DialTimeout("tcp", addr, kafkaTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: %w", err)
+		}
+		k.conns[addr] = conn
+	}
+	conn.SetDeadline(time.Now().Add(2 * kafkaTimeout))
+
+	k.corrID++
+	var header encoder
+	header.int16(apiKey)
+	header.int16(version)
+	header.int32(k.corrID)
+	header.string(kafkaClientID)
+
+	w := bufio.NewWriter(conn)
+	binary.Write(w, binary.BigEndian, int32(len(header)+len(body)))
+	w.Write(header)
+	w.Write(body)
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("kafka: %w", err)
+	}
+
+	var size int32
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		return nil, fmt.Errorf("kafka: %w", err)
+	}
+	if size < 4 || size > kafkaMaxResponse {
+		return nil, fmt.Errorf("kafka: invalid response size %d", size)
+	}
+	resp := make([]byte, size)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, fmt.Errorf("kafka: %w", err)
+	}
+	if corrID := int32(binary.BigEndian.Uint32(resp)); corrID != k.corrID {
+		return nil, fmt.Errorf("kafka: response %d to request %d", corrID, k.corrID)
+	}
+	return resp[4:], nil
+}
+
+// Close closes the connections to the brokers.
+func (k *Kafka) Close() {
+	for addr, conn := range k.conns {
+		conn.Close()
+		delete(k.conns, addr)
+	}
+}
@@ -0,0 +1,279 @@
+package feed
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// varint reads a zigzag encoded variable length integer.
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = errors.New("bad varint")
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+// fakeBroker is a single Kafka broker leading both partitions of a topic.
+// It records the values produced to each partition.
+type fakeBroker struct {
+	t        *testing.T
+	ln       net.Listener
+	topic    string
+	mu       sync.Mutex
+	produced map[int32][]string
+	failNext int16 // Error code of the next produce response
+}
+
+func newFakeBroker(t *testing.T, topic string) *fakeBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{t: t, ln: ln, topic: topic, produced: make(map[int32][]string)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size int32
+		if binary.Read(conn, binary.BigEndian, &size) != nil {
+			return
+		}
+		req := make([]byte, size)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		d := &decoder{b: req}
+		apiKey, version, corrID := d.int16(), d.int16(), d.int32()
+		d.string() // Client ID
+
+		var resp encoder
+		resp.int32(corrID)
+		switch {
+		case apiKey == apiMetadata && version == metadataVersion:
+			b.metadata(&resp)
+		case apiKey == apiProduce && version == produceVersion:
+			b.produce(d, &resp)
+		default:
+			b.t.Errorf("unexpected request %d v%d", apiKey, version)
+			return
+		}
+		binary.Write(conn, binary.BigEndian, int32(len(resp)))
+		conn.Write(resp)
+	}
+}
+
+func (b *fakeBroker) metadata(resp *encoder) {
+	host, portText, _ := net.SplitHostPort(b.ln.Addr().String())
+	port, _ := strconv.Atoi(portText)
+	resp.int32(0) // Throttle time
+	resp.int32(1)
+	resp.int32(7) // Node ID
+	resp.string(host)
+	resp.int32(int32(port))
+	resp.int16(-1) // Rack
+	resp.int16(-1) // Cluster ID
+	resp.int32(7)  // Controller ID
+	resp.int32(1)
+	resp.int16(0)
+	resp.string(b.topic)
+	resp.int8(0)
+	resp.int32(2)
+	for p := range int32(2) {
+		resp.int16(0)
+		resp.int32(p)
+		resp.int32(7) // Leader
+		resp.int32(1)
+		resp.int32(7)
+		resp.int32(1)
+		resp.int32(7)
+	}
+}
+
+func (b *fakeBroker) produce(d *decoder, resp *encoder) {
+	d.int16() // Transactional ID
+	if acks := d.int16(); acks != 1 {
+		b.t.Errorf("acks = %d, want 1", acks)
+	}
+	d.int32() // Timeout
+	d.int32() // Topics
+	topic := d.string()
+	d.int32() // Partitions
+	partition := d.int32()
+	batch := d.next(int(d.int32()))
+	if d.err != nil || topic != b.topic {
+		b.t.Errorf("produce to %q: %v", topic, d.err)
+	}
+
+	// Record batch
+	bd := &decoder{b: batch}
+	bd.int64()
+	if n := bd.int32(); int(n) != len(batch)-12 {
+		b.t.Errorf("batch length = %d, want %d", n, len(batch)-12)
+	}
+	bd.int32()
+	if magic := bd.int8(); magic != recordBatchVersion {
+		b.t.Errorf("magic = %d", magic)
+	}
+	crc := uint32(bd.int32())
+	if want := crc32.Checksum(bd.b, crc32.MakeTable(crc32.Castagnoli)); crc != want {
+		b.t.Errorf("crc = %x, want %x", crc, want)
+	}
+	bd.int16()
+	lastDelta := bd.int32()
+	bd.int64()
+	bd.int64()
+	bd.int64()
+	bd.int16()
+	bd.int32()
+	count := bd.int32()
+	if lastDelta != count-1 {
+		b.t.Errorf("last offset delta = %d with %d records", lastDelta, count)
+	}
+	var values []string
+	for range count {
+		rd := &decoder{b: bd.next(int(bd.varint()))}
+		rd.int8()
+		rd.varint()
+		rd.varint()
+		if key := rd.varint(); key != -1 {
+			b.t.Errorf("key length = %d, want null", key)
+		}
+		values = append(values, string(rd.next(int(rd.varint()))))
+		if rd.varint() != 0 || rd.err != nil || len(rd.b) != 0 {
+			b.t.Errorf("malformed record: %v", rd.err)
+		}
+	}
+	if bd.err != nil || len(bd.b) != 0 {
+		b.t.Errorf("malformed batch: %v", bd.err)
+	}
+
+	b.mu.Lock()
+	code := b.failNext
+	b.failNext = 0
+	if code == 0 {
+		b.produced[partition] = append(b.produced[partition], values...)
+	}
+	b.mu.Unlock()
+
+	resp.int32(1)
+	resp.string(topic)
+	resp.int32(1)
+	resp.int32(partition)
+	resp.int16(code)
+	resp.int64(0)
+	resp.int64(-1)
+	resp.int32(0) // Throttle time
+}
+
+func TestKafkaSend(t *testing.T) {
+	b := newFakeBroker(t, "nginx")
+	k, err := NewKafka(" , "+b.ln.Addr().String(), "nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close()
+
+	if err := k.Send([][]byte{[]byte(`{"a":1}`), []byte(`{"b":2}`)}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := k.Send([][]byte{[]byte(`{"c":3}`)}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	// A broker error is returned and the next batch goes through again
+	b.mu.Lock()
+	b.failNext = 6
+	b.mu.Unlock()
+	if err := k.Send([][]byte{[]byte(`lost`)}); !errors.Is(err, kafkaError(6)) {
+		t.Errorf("Send() error = %v, want not leader", err)
+	}
+	if err := k.Send([][]byte{[]byte(`{"d":4}`)}); err != nil {
+		t.Fatalf("Send() after an error = %v", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// Batches alternate between the partitions; metadata is reloaded after
+	// the error, so the partitions are used in turn from the last index on
+	if got := b.produced[0]; len(got) != 2 || got[0] != `{"a":1}` || got[1] != `{"b":2}` {
+		t.Errorf("partition 0 = %q", got)
+	}
+	if got := b.produced[1]; len(got) != 2 || got[0] != `{"c":3}` || got[1] != `{"d":4}` {
+		t.Errorf("partition 1 = %q", got)
+	}
+}
+
+func TestKafkaRun(t *testing.T) {
+	b := newFakeBroker(t, "nginx")
+	k, err := NewKafka(b.ln.Addr().String(), "nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := NewHub()
+	go k.Run(hub, func(err error) { t.Errorf("report(%v)", err) })
+
+	// Wait for Run to subscribe
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		hub.mu.Lock()
+		n := len(hub.subs)
+		hub.mu.Unlock()
+		if n == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	hub.Publish(&parser.Visitor{IP: "203.0.113.7", Method: "GET", Path: "/x", Status: 200})
+
+	for time.Now().Before(deadline.Add(2 * kafkaLinger)) {
+		b.mu.Lock()
+		got := append(b.produced[0], b.produced[1]...)
+		b.mu.Unlock()
+		if len(got) > 0 {
+			var e Entry
+			if err := json.Unmarshal([]byte(got[0]), &e); err != nil || e.IP != "203.0.113.7" || e.Path != "/x" {
+				t.Errorf("produced %s, err %v", got[0], err)
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("no entry produced")
+}
+
+func TestNewKafka(t *testing.T) {
+	if _, err := NewKafka(" , ", "nginx"); err == nil {
+		t.Error("NewKafka() without brokers should fail")
+	}
+	if _, err := NewKafka("localhost:9092", ""); err == nil {
+		t.Error("NewKafka() without a topic should fail")
+	}
+}
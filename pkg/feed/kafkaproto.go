@@ -0,0 +1,247 @@
+package feed
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+)
+
+// Kafka API keys and the versions used, supported by brokers from 1.0 on.
+const (
+	apiProduce         = 0
+	apiMetadata        = 3
+	produceVersion     = 3
+	metadataVersion    = 4
+	recordBatchVersion = 2
+)
+
+// castagnoli is the CRC table of record batch checksums.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaError is an error code returned by a broker.
+type kafkaError int16
+
+func (e kafkaError) Error() string {
+	switch e {
+	case 3:
+		return "kafka: unknown topic or partition"
+	case 5:
+		return "kafka: leader not available"
+	case 6:
+		return "kafka: not leader for partition"
+	case 7:
+		return "kafka: request timed out"
+	case 10:
+		return "kafka: message too large"
+	case 29:
+		return "kafka: topic authorization failed"
+	}
+	return fmt.Sprintf("kafka: error code %d", int16(e))
+}
+
+// encoder appends values in the Kafka protocol encoding.
+type encoder []byte
+
+func (e *encoder) int8(v int8)   { *e = append(*e, byte(v)) }
+func (e *encoder) int16(v int16) { *e = binary.BigEndian.AppendUint16(*e, uint16(v)) }
+func (e *encoder) int32(v int32) { *e = binary.BigEndian.AppendUint32(*e, uint32(v)) }
+func (e *encoder) int64(v int64) { *e = binary.BigEndian.AppendUint64(*e, uint64(v)) }
+
+// varint appends a zigzag encoded variable length integer.
+func (e *encoder) varint(v int64) { *e = binary.AppendVarint(*e, v) }
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	*e = append(*e, s...)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	*e = append(*e, b...)
+}
+
+// decoder reads values in the Kafka protocol encoding. The first error is
+// kept and makes the following reads return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = errors.New("kafka: truncated response")
+		return make([]byte, max(n, 0))
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) int8() int8   { return int8(d.next(1)[0]) }
+func (d *decoder) int16() int16 { return int16(binary.BigEndian.Uint16(d.next(2))) }
+func (d *decoder) int32() int32 { return int32(binary.BigEndian.Uint32(d.next(4))) }
+func (d *decoder) int64() int64 { return int64(binary.BigEndian.Uint64(d.next(8))) }
+
+// string reads a string; a null string reads as empty.
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+// array reads an array length; a null array reads as empty.
+func (d *decoder) array() int {
+	n := d.int32()
+	if n < 0 {
+		return 0
+	}
+	if int(n) > len(d.b) {
+		d.err = errors.New("kafka: truncated response")
+		return 0
+	}
+	return int(n)
+}
+
+// partitionMeta is the leader of a partition.
+type partitionMeta struct {
+	id     int32
+	leader int32
+}
+
+// metadataRequest asks for the brokers and the partitions of a topic.
+func metadataRequest(topic string) []byte {
+	var e encoder
+	e.int32(1)
+	e.string(topic)
+	e.int8(1) // Allow auto topic creation
+	return e
+}
+
+// parseMetadata returns the broker addresses by node ID and the partitions
+// of the topic from a metadata response.
+func parseMetadata(b []byte, topic string) (map[int32]string, []partitionMeta, error) {
+	d := &decoder{b: b}
+	d.int32() // Throttle time
+	brokers := make(map[int32]string)
+	for range d.array() {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // Rack
+		brokers[id] = fmt.Sprintf("%s:%d", host, port)
+	}
+	d.string() // Cluster ID
+	d.int32()  // Controller ID
+
+	var partitions []partitionMeta
+	var topicErr error
+	for range d.array() {
+		code := d.int16()
+		name := d.string()
+		d.int8() // Internal
+		for range d.array() {
+			pcode := d.int16()
+			p := partitionMeta{id: d.int32(), leader: d.int32()}
+			for range 2 { // Replicas and in-sync replicas
+				for range d.array() {
+					d.int32()
+				}
+			}
+			if name == topic && pcode == 0 {
+				partitions = append(partitions, p)
+			}
+		}
+		if name == topic && code != 0 {
+			topicErr = kafkaError(code)
+		}
+	}
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+	if topicErr != nil {
+		return nil, nil, topicErr
+	}
+	if len(partitions) == 0 {
+		return nil, nil, kafkaError(5)
+	}
+	return brokers, partitions, nil
+}
+
+// produceRequest sends a record batch to a partition, waiting for the
+// leader to write it.
+func produceRequest(topic string, partition int32, batch []byte, timeout time.Duration) []byte {
+	var e encoder
+	e.int16(-1) // No transactional ID
+	e.int16(1)  // Acks from the leader
+	e.int32(int32(timeout.Milliseconds()))
+	e.int32(1)
+	e.string(topic)
+	e.int32(1)
+	e.int32(partition)
+	e.bytes(batch)
+	return e
+}
+
+// parseProduce returns the first error of a produce response.
+func parseProduce(b []byte) error {
+	d := &decoder{b: b}
+	for range d.array() {
+		d.string() // Topic
+		for range d.array() {
+			d.int32() // Partition
+			code := d.int16()
+			d.int64() // Base offset
+			d.int64() // Log append time
+			if d.err == nil && code != 0 {
+				return kafkaError(code)
+			}
+		}
+	}
+	return d.err
+}
+
+// recordBatch encodes values as an uncompressed record batch without keys,
+// all created at now.
+func recordBatch(values [][]byte, now time.Time) []byte {
+	var records encoder
+	for i, v := range values {
+		var r encoder
+		r.int8(0)   // Attributes
+		r.varint(0) // Timestamp delta
+		r.varint(int64(i))
+		r.varint(-1) // No key
+		r.varint(int64(len(v)))
+		r = append(r, v...)
+		r.varint(0) // Headers
+		records.varint(int64(len(r)))
+		records = append(records, r...)
+	}
+
+	// The checksum covers everything from the attributes on
+	ts := now.UnixMilli()
+	var body encoder
+	body.int16(0) // Attributes
+	body.int32(int32(len(values) - 1))
+	body.int64(ts)
+	body.int64(ts)
+	body.int64(-1) // No producer ID
+	body.int16(-1) // No producer epoch
+	body.int32(-1) // No base sequence
+	body.int32(int32(len(values)))
+	body = append(body, records...)
+
+	var b encoder
+	b.int64(0) // Base offset, assigned by the broker
+	b.int32(int32(4 + 1 + 4 + len(body)))
+	b.int32(-1) // Partition leader epoch
+	b.int8(recordBatchVersion)
+	b = binary.BigEndian.AppendUint32(b, crc32.Checksum(body, castagnoli))
+	return append(b, body...)
+}
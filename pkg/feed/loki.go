@@ -0,0 +1,185 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// lokiBatch is the largest number of entries sent in one request.
+	lokiBatch = 1000
+	// lokiLinger is the longest time entries wait before being sent.
+	lokiLinger = time.Second
+	// lokiTimeout bounds a push request.
+	lokiTimeout = 10 * time.Second
+)
+
+// lokiLabelName matches the label names Loki accepts.
+var lokiLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Loki pushes entries to Grafana Loki as JSON log lines, batching them
+// every second. Each line is in a stream labeled with the status class
+// ("2xx"), the virtual host and the country of the entry, when known, on top
+// of the static labels. It is not safe for concurrent use: Run owns it.
+type Loki struct {
+	url    string
+	labels map[string]string // Static labels of every stream
+	client *http.Client
+}
+
+// NewLoki creates a pusher to the push API at url, e.g.
+// http://localhost:3100/loki/api/v1/push. Credentials in the URL, e.g. for
+// Grafana Cloud, are sent with basic authentication. Labels are static
+// labels given as "name=value", e.g. "env=prod"; job defaults to
+// "tailnginx".
+func NewLoki(pushURL string, labels []string) (*Loki, error) {
+	u, err := url.Parse(pushURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("loki: push URL must be an http or https URL")
+	}
+	static := map[string]string{"job": "tailnginx"}
+	for _, label := range labels {
+		name, value, ok := strings.Cut(label, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !lokiLabelName.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("loki: invalid label %q, expected name=value", label)
+		}
+		static[name] = strings.TrimSpace(value)
+	}
+	return &Loki{url: pushURL, labels: static, client: &http.Client{Timeout: lokiTimeout}}, nil
+}
+
+// Run pushes the entries of the hub and passes the errors of failed batches
+// to report. Entries of a failed batch are lost, as are entries published
+// while Loki is slower than the log, which are counted in the next report.
+// It returns once the hub is closed, after sending the entries left.
+func (l *Loki) Run(h *Hub, report func(error)) {
+	entries, dropped, unsubscribe := h.subscribeAll(shipperBuffer)
+	defer unsubscribe()
+
+	ticker := time.NewTicker(lokiLinger)
+	defer ticker.Stop()
+	var batch []Entry
+	send := func() {
+		if n := dropped(); n > 0 {
+			report(fmt.Errorf("loki: %d entries dropped while Loki was slower than the log", n))
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.Send(batch); err != nil {
+			report(err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				send()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= lokiBatch {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		}
+	}
+}
+
+// lokiPush is the body of a push request.
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"` // Timestamp in nanoseconds and line
+}
+
+// Send pushes entries in a single request.
+func (l *Loki) Send(entries []Entry) error {
+	body, err := json.Marshal(l.push(entries, time.Now()))
+	if err != nil {
+		return fmt.Errorf("loki: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("loki: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("loki: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("loki: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// push groups entries into streams by labels, in the order of their first
+// entry. Entries without a time are stamped with now.
+func (l *Loki) push(entries []Entry, now time.Time) lokiPush {
+	var p lokiPush
+	streams := make(map[string]int) // Index in p.Streams by label set
+	for _, e := range entries {
+		labels := l.streamLabels(e)
+		key := lokiKey(labels)
+		i, ok := streams[key]
+		if !ok {
+			i = len(p.Streams)
+			streams[key] = i
+			p.Streams = append(p.Streams, lokiStream{Stream: labels})
+		}
+		line, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		ts := e.Time
+		if ts.IsZero() {
+			ts = now
+		}
+		p.Streams[i].Values = append(p.Streams[i].Values, [2]string{strconv.FormatInt(ts.UnixNano(), 10), string(line)})
+	}
+	return p
+}
+
+// streamLabels returns the labels of the stream of an entry.
+func (l *Loki) streamLabels(e Entry) map[string]string {
+	labels := maps.Clone(l.labels)
+	if e.Status >= 100 && e.Status < 600 {
+		labels["status"] = strconv.Itoa(e.Status/100) + "xx"
+	}
+	if e.Host != "" {
+		labels["vhost"] = e.Host
+	}
+	if e.Country != "" {
+		labels["country"] = e.Country
+	}
+	return labels
+}
+
+// lokiKey identifies a label set.
+func lokiKey(labels map[string]string) string {
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		fmt.Fprintf(&b, "%s=%q,", name, labels[name])
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLokiSend(t *testing.T) {
+	var got lokiPush
+	var user, password string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		user, password, _ = r.BasicAuth()
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	l, err := NewLoki(strings.Replace(srv.URL, "http://", "http://1234:secret@", 1)+"/loki/api/v1/push", []string{"env=prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	when := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: when, IP: "203.0.113.7", Path: "/", Status: 200, Host: "example.com", Country: "FR"},
+		{Time: when.Add(time.Second), IP: "198.51.100.2", Path: "/x", Status: 404},
+		{Time: when.Add(2 * time.Second), IP: "203.0.113.8", Path: "/a", Status: 204, Host: "example.com", Country: "FR"},
+	}
+	if err := l.Send(entries); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if user != "1234" || password != "secret" {
+		t.Errorf("basic auth = %q %q, want the URL credentials", user, password)
+	}
+	if len(got.Streams) != 2 {
+		t.Fatalf("pushed %d streams, want 2: %+v", len(got.Streams), got.Streams)
+	}
+	first := got.Streams[0]
+	want := map[string]string{"job": "tailnginx", "env": "prod", "status": "2xx", "vhost": "example.com", "country": "FR"}
+	if lokiKey(first.Stream) != lokiKey(want) {
+		t.Errorf("stream labels = %v, want %v", first.Stream, want)
+	}
+	if len(first.Values) != 2 || first.Values[0][0] != "1760097600000000000" {
+		t.Fatalf("values = %v, want 2 entries from 1760097600000000000", first.Values)
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(first.Values[1][1]), &e); err != nil || e.Path != "/a" {
+		t.Errorf("line = %s, err %v", first.Values[1][1], err)
+	}
+	if labels := got.Streams[1].Stream; labels["status"] != "4xx" || labels["vhost"] != "" || labels["country"] != "" {
+		t.Errorf("second stream labels = %v, want 4xx without vhost and country", labels)
+	}
+}
+
+func TestLokiErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		http.Error(w, "entry too far behind", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	l, err := NewLoki(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = l.Send([]Entry{{Status: 200}})
+	if err == nil || !strings.Contains(err.Error(), "400 Bad Request: entry too far behind") {
+		t.Errorf("Send() error = %v, want the response", err)
+	}
+
+	for _, tt := range []struct {
+		url    string
+		labels []string
+	}{
+		{"localhost:3100", nil},
+		{"ftp://loki/push", nil},
+		{"http://loki:3100/loki/api/v1/push", []string{"env"}},
+		{"http://loki:3100/loki/api/v1/push", []string{"bad-name=x"}},
+		{"http://loki:3100/loki/api/v1/push", []string{"__name__=x"}},
+	} {
+		if _, err := NewLoki(tt.url, tt.labels); err == nil {
+			t.Errorf("NewLoki(%q, %q) should fail", tt.url, tt.labels)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package feed
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+)
+
+// websocketGUID is appended to the client key to compute the handshake
+// accept value (RFC 6455, section 1.3).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+// maxControlPayload is the largest payload accepted from clients, which are
+// only expected to send control frames.
+const maxControlPayload = 4096
+
+// ServeHTTP upgrades the request to a WebSocket and streams the published
+// entries to it as JSON text messages, one entry per message. Entries can
+// be filtered with repeated filter parameters in the highlight rule
+// condition syntax, e.g. ?filter=status>=500&filter=path prefix /api; an
+// entry must match all of them.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var filters highlight.Rules
+	for _, text := range r.URL.Query()["filter"] {
+		f, err := highlight.ParseCondition(text)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid filter: %v", err), http.StatusBadRequest)
+			return
+		}
+		filters = append(filters, f)
+	}
+
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "expected a WebSocket upgrade request", http.StatusUpgradeRequired)
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		http.Error(w, "unsupported WebSocket handshake", http.StatusBadRequest)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	entries, unsubscribe := h.Subscribe(filters)
+	defer unsubscribe()
+
+	// Frames from the client are read to answer pings and notice closing
+	var writeMu sync.Mutex
+	write := func(opcode byte, payload []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if err := writeFrame(rw.Writer, opcode, payload); err != nil {
+			return err
+		}
+		return rw.Flush()
+	}
+	go func() {
+		defer unsubscribe()
+		for {
+			opcode, payload, err := readFrame(rw.Reader)
+			if err != nil {
+				return
+			}
+			switch opcode {
+			case opClose:
+				write(opClose, nil)
+				return
+			case opPing:
+				if write(opPong, payload) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		if write(opText, data) != nil {
+			return
+		}
+	}
+}
+
+// headerHasToken reports whether a comma-separated header contains a token,
+// ignoring case.
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeFrame writes an unmasked, unfragmented frame as sent by servers.
+func writeFrame(w io.Writer, opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// readFrame reads a frame sent by a client and returns its opcode and
+// unmasked payload.
+func readFrame(r *bufio.Reader) (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+
+	n := uint64(header[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxControlPayload {
+		return 0, nil, errors.New("websocket frame too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
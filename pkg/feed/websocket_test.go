@@ -0,0 +1,88 @@
+package feed
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestWebSocket(t *testing.T) {
+	h := NewHub()
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	query := url.Values{"filter": {"path prefix /api"}}.Encode()
+	req := "GET /?" + query + " HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake response = %s %v", resp.Status, resp.Header)
+	}
+
+	// Wait for the handler to subscribe before publishing
+	for {
+		h.mu.Lock()
+		n := len(h.subs)
+		h.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.Publish(&parser.Visitor{Path: "/", Status: 200})
+	h.Publish(&parser.Visitor{Path: "/api/users", Status: 201, IP: "10.0.0.1"})
+
+	opcode, payload, err := readFrame(r)
+	if err != nil || opcode != opText {
+		t.Fatalf("readFrame() = %d, %v", opcode, err)
+	}
+	var e Entry
+	if err := json.Unmarshal(payload, &e); err != nil {
+		t.Fatalf("message %s is not an entry: %v", payload, err)
+	}
+	if e.Path != "/api/users" || e.IP != "10.0.0.1" || e.Status != 201 {
+		t.Errorf("entry = %+v, want the /api/users request", e)
+	}
+
+	// Masked ping from the client is answered with a pong
+	conn.Write([]byte{0x80 | opPing, 0x80 | 2, 1, 2, 3, 4, 'h' ^ 1, 'i' ^ 2})
+	if opcode, payload, err := readFrame(r); err != nil || opcode != opPong || string(payload) != "hi" {
+		t.Errorf("ping answer = %d %q, %v, want pong", opcode, payload, err)
+	}
+}
+
+func TestWebSocketRejectsBadRequests(t *testing.T) {
+	h := NewHub()
+	for target, want := range map[string]int{
+		"/":                  http.StatusUpgradeRequired,
+		"/?filter=size%3E10": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", target, rec.Code, want)
+		}
+	}
+}
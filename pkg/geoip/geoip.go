@@ -4,13 +4,16 @@ package geoip
 import (
 	"net"
 	"sync"
+	"sync/atomic"
 
 	"github.com/phuslu/iploc"
 )
 
 // Locator provides IP geolocation lookups using an embedded database with caching
 type Locator struct {
-	cache sync.Map // map[string]*Location for concurrent access
+	cache  sync.Map // map[string]*Location for concurrent access
+	hits   atomic.Uint64
+	misses atomic.Uint64
 }
 
 // Location represents a geographic location
@@ -44,9 +47,11 @@ func (l *Locator) Lookup(ipStr string) (*Location, error) {
 	// Check cache first
 	if cached, ok := l.cache.Load(ipStr); ok {
 		if loc, ok := cached.(*Location); ok {
+			l.hits.Add(1)
 			return loc, nil
 		}
 	}
+	l.misses.Add(1)
 
 	// Parse IP address
 	ip := net.ParseIP(ipStr)
@@ -85,6 +90,15 @@ func (l *Locator) Lookup(ipStr string) (*Location, error) {
 	return loc, nil
 }
 
+// CacheStats returns the number of lookups answered from the cache and the
+// number that needed a database lookup.
+func (l *Locator) CacheStats() (hits, misses uint64) {
+	if l == nil {
+		return 0, 0
+	}
+	return l.hits.Load(), l.misses.Load()
+}
+
 // Close closes the locator and releases any resources.
 // This is a no-op for the embedded database but included for interface compatibility.
 func (l *Locator) Close() error {
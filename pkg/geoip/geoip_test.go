@@ -171,6 +171,26 @@ func TestClose(t *testing.T) {
 	}
 }
 
+func TestCacheStats(t *testing.T) {
+	locator, err := NewLocator()
+	if err != nil {
+		t.Fatalf("NewLocator() error = %v", err)
+	}
+	defer locator.Close()
+
+	for _, ip := range []string{"8.8.8.8", "8.8.8.8", "1.1.1.1", "8.8.8.8"} {
+		_, _ = locator.Lookup(ip)
+	}
+	if hits, misses := locator.CacheStats(); hits != 2 || misses != 2 {
+		t.Errorf("CacheStats() = %d hits, %d misses, want 2 and 2", hits, misses)
+	}
+
+	var nilLocator *Locator
+	if hits, misses := nilLocator.CacheStats(); hits != 0 || misses != 0 {
+		t.Errorf("CacheStats() on nil locator = %d, %d, want 0, 0", hits, misses)
+	}
+}
+
 func BenchmarkLookup(b *testing.B) {
 	locator, err := NewLocator()
 	if err != nil {
@@ -191,3 +211,13 @@ func BenchmarkLookup(b *testing.B) {
 		_, _ = locator.Lookup(ip)
 	}
 }
+
+func TestCentroid(t *testing.T) {
+	lat, lon, ok := Centroid("FR")
+	if !ok || lat < 42 || lat > 51 || lon < -5 || lon > 8 {
+		t.Errorf("Centroid(FR) = %v, %v, %v; want a point in France", lat, lon, ok)
+	}
+	if _, _, ok := Centroid("??"); ok {
+		t.Error("Centroid(??) should not be found")
+	}
+}
@@ -0,0 +1,213 @@
+package grpcapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/feed"
+	"github.com/papaganelli/tailnginx/pkg/stats"
+)
+
+// Protocol buffers wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// message appends fields in the protocol buffers encoding. Fields with the
+// default value are left out, as in proto3.
+type message []byte
+
+func (m *message) tag(field, wire int) {
+	*m = binary.AppendUvarint(*m, uint64(field)<<3|uint64(wire))
+}
+
+func (m *message) int64(field int, v int64) {
+	if v != 0 {
+		m.tag(field, wireVarint)
+		*m = binary.AppendUvarint(*m, uint64(v))
+	}
+}
+
+func (m *message) double(field int, v float64) {
+	if v != 0 {
+		m.tag(field, wireFixed64)
+		*m = binary.LittleEndian.AppendUint64(*m, math.Float64bits(v))
+	}
+}
+
+func (m *message) string(field int, s string) {
+	if s != "" {
+		m.tag(field, wireBytes)
+		*m = binary.AppendUvarint(*m, uint64(len(s)))
+		*m = append(*m, s...)
+	}
+}
+
+// message appends an embedded message, even an empty one.
+func (m *message) message(field int, sub message) {
+	m.tag(field, wireBytes)
+	*m = binary.AppendUvarint(*m, uint64(len(sub)))
+	*m = append(*m, sub...)
+}
+
+// timestamp appends a google.protobuf.Timestamp, unless t is zero.
+func (m *message) timestamp(field int, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	var ts message
+	ts.int64(1, t.Unix())
+	ts.int64(2, int64(t.Nanosecond()))
+	m.message(field, ts)
+}
+
+// errTruncated reports a message ending in the middle of a field.
+var errTruncated = errors.New("truncated message")
+
+// decode calls fn with the fields of a message: the value of varint and
+// fixed size fields, or the data of length-delimited ones.
+func decode(b []byte, fn func(field, wire int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncated
+		}
+		b = b[n:]
+		field, wire := int(key>>3), int(key&7)
+		var v uint64
+		var data []byte
+		switch wire {
+		case wireVarint:
+			v, n = binary.Uvarint(b)
+			if n <= 0 {
+				return errTruncated
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errTruncated
+			}
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return errTruncated
+			}
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return errTruncated
+			}
+			data = b[n : n+int(size)]
+			b = b[n+int(size):]
+		default:
+			return errors.New("unsupported wire type")
+		}
+		if err := fn(field, wire, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotRequest is a decoded SnapshotRequest.
+type snapshotRequest struct {
+	limit    int
+	sections []string
+}
+
+func decodeSnapshotRequest(b []byte) (snapshotRequest, error) {
+	var req snapshotRequest
+	err := decode(b, func(field, wire int, v uint64, data []byte) error {
+		switch {
+		case field == 1 && wire == wireVarint:
+			req.limit = int(int32(v))
+		case field == 2 && wire == wireBytes:
+			req.sections = append(req.sections, string(data))
+		}
+		return nil
+	})
+	return req, err
+}
+
+// decodeStreamRequest returns the filters of a StreamRequest.
+func decodeStreamRequest(b []byte) ([]string, error) {
+	var filters []string
+	err := decode(b, func(field, wire int, _ uint64, data []byte) error {
+		if field == 1 && wire == wireBytes {
+			filters = append(filters, string(data))
+		}
+		return nil
+	})
+	return filters, err
+}
+
+// encodeSnapshot encodes a Snapshot with the items of the requested
+// sections only.
+func encodeSnapshot(s *stats.Snapshot, req snapshotRequest) message {
+	var m message
+	m.timestamp(1, s.Time)
+	m.string(2, s.LogPath)
+	m.string(3, s.Window)
+	m.string(4, s.Filter)
+	m.int64(5, int64(s.Requests))
+	m.int64(6, int64(s.TotalRequests))
+	m.int64(7, int64(s.UniqueVisitors))
+	m.int64(8, s.Bytes)
+	for _, section := range s.Sections {
+		if len(req.sections) > 0 && !slices.Contains(req.sections, section.Name) {
+			continue
+		}
+		items := section.Items
+		if req.limit > 0 {
+			items = items[:min(req.limit, len(items))]
+		}
+		var sm message
+		sm.string(1, section.Name)
+		for _, item := range items {
+			var im message
+			im.string(1, item.Key)
+			im.int64(2, int64(item.Count))
+			sm.message(2, im)
+		}
+		m.message(9, sm)
+	}
+	if s.Rate != nil {
+		var rm message
+		rm.string(1, s.Rate.Interval)
+		for _, p := range s.Rate.Points {
+			var pm message
+			pm.timestamp(1, p.Time)
+			pm.int64(2, int64(p.Requests))
+			rm.message(2, pm)
+		}
+		m.message(10, rm)
+	}
+	return m
+}
+
+// encodeEntry encodes an Entry.
+func encodeEntry(e feed.Entry) message {
+	var m message
+	m.timestamp(1, e.Time)
+	m.string(2, e.IP)
+	m.string(3, e.Method)
+	m.string(4, e.Path)
+	m.string(5, e.Protocol)
+	m.int64(6, int64(e.Status))
+	m.int64(7, int64(e.Bytes))
+	m.string(8, e.Referer)
+	m.string(9, e.Agent)
+	m.string(10, e.Country)
+	m.double(11, e.RequestTime)
+	m.string(12, e.Host)
+	m.string(13, e.TLSProtocol)
+	m.string(14, e.TLSCipher)
+	return m
+}
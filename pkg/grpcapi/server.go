@@ -0,0 +1,206 @@
+// Package grpcapi serves the aggregates and the live entry stream over gRPC,
+// for tools that prefer typed clients to the JSON HTTP API. The service is
+// defined in tailnginx.proto. Only the gRPC wire protocol over HTTP/2 is
+// implemented, without compression or reflection.
+package grpcapi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/papaganelli/tailnginx/pkg/feed"
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+	"github.com/papaganelli/tailnginx/pkg/stats"
+)
+
+// maxRequestSize bounds the size of request messages, which are small.
+const maxRequestSize = 1 << 20
+
+// Methods of the Stats service
+const (
+	methodGetSnapshot   = "/tailnginx.v1.Stats/GetSnapshot"
+	methodStreamEntries = "/tailnginx.v1.Stats/StreamEntries"
+)
+
+// gRPC status codes
+const (
+	codeOK              = 0
+	codeInvalidArgument = 3
+	codeUnimplemented   = 12
+	codeInternal        = 13
+	codeUnavailable     = 14
+)
+
+// status is an error ending a call with a gRPC status code.
+type status struct {
+	code    int
+	message string
+}
+
+func (s *status) Error() string {
+	return fmt.Sprintf("grpc: code %d: %s", s.code, s.message)
+}
+
+func errorf(code int, format string, args ...any) *status {
+	return &status{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Server implements the Stats service of tailnginx.proto.
+type Server struct {
+	snapshot func() *stats.Snapshot
+	hub      *feed.Hub
+}
+
+// NewServer returns a server of the snapshots returned by source and of the
+// entries published to hub.
+func NewServer(source func() *stats.Snapshot, hub *feed.Hub) *Server {
+	return &Server{snapshot: source, hub: hub}
+}
+
+// Serve accepts gRPC connections on a listener, over HTTP/2 without TLS as
+// gRPC clients connect by default. It only returns on errors.
+func (s *Server) Serve(listener net.Listener) error {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	srv := &http.Server{Handler: s, Protocols: &protocols}
+	return srv.Serve(listener)
+}
+
+// ServeHTTP handles a gRPC call.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 || r.Method != http.MethodPost {
+		http.Error(w, "expected a gRPC call over HTTP/2", http.StatusMethodNotAllowed)
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "application/grpc" && !strings.HasPrefix(ct, "application/grpc+proto") {
+		http.Error(w, "unsupported content type "+ct, http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+
+	var err error
+	switch r.URL.Path {
+	case methodGetSnapshot:
+		err = s.getSnapshot(w, r)
+	case methodStreamEntries:
+		err = s.streamEntries(w, r)
+	default:
+		err = errorf(codeUnimplemented, "unknown method %s", r.URL.Path)
+	}
+	code, message := codeOK, ""
+	if err != nil {
+		st, ok := err.(*status)
+		if !ok {
+			st = errorf(codeInternal, "%v", err)
+		}
+		code, message = st.code, st.message
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		w.Header().Set("Grpc-Message", percentEncode(message))
+	}
+}
+
+func (s *Server) getSnapshot(w http.ResponseWriter, r *http.Request) error {
+	body, err := readMessage(r.Body)
+	if err != nil {
+		return err
+	}
+	req, err := decodeSnapshotRequest(body)
+	if err != nil {
+		return errorf(codeInvalidArgument, "SnapshotRequest: %v", err)
+	}
+	return writeMessage(w, encodeSnapshot(s.snapshot(), req))
+}
+
+func (s *Server) streamEntries(w http.ResponseWriter, r *http.Request) error {
+	body, err := readMessage(r.Body)
+	if err != nil {
+		return err
+	}
+	texts, err := decodeStreamRequest(body)
+	if err != nil {
+		return errorf(codeInvalidArgument, "StreamRequest: %v", err)
+	}
+	var filters highlight.Rules
+	for _, text := range texts {
+		f, err := highlight.ParseCondition(text)
+		if err != nil {
+			return errorf(codeInvalidArgument, "invalid filter: %v", err)
+		}
+		filters = append(filters, f)
+	}
+	if s.hub == nil {
+		return errorf(codeUnavailable, "live stream disabled")
+	}
+
+	entries, unsubscribe := s.hub.Subscribe(filters)
+	defer unsubscribe()
+	// Headers are sent at once, so that clients see the stream start
+	http.NewResponseController(w).Flush()
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return nil
+			}
+			if err := writeMessage(w, encodeEntry(entry)); err != nil {
+				return err
+			}
+		case <-r.Context().Done():
+			return nil
+		}
+	}
+}
+
+// readMessage reads the single request message of a call, after its
+// length-prefixed header.
+func readMessage(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, errorf(codeInvalidArgument, "reading request: %v", err)
+	}
+	if header[0] != 0 {
+		return nil, errorf(codeUnimplemented, "compressed messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxRequestSize {
+		return nil, errorf(codeInvalidArgument, "request of %d bytes too large", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, errorf(codeInvalidArgument, "reading request: %v", err)
+	}
+	return body, nil
+}
+
+// writeMessage writes an uncompressed length-prefixed message and flushes
+// it to the client.
+func writeMessage(w http.ResponseWriter, m message) error {
+	header := [5]byte{0}
+	binary.BigEndian.PutUint32(header[1:], uint32(len(m)))
+	if _, err := w.Write(append(header[:], m...)); err != nil {
+		return err
+	}
+	return http.NewResponseController(w).Flush()
+}
+
+// percentEncode encodes a status message for the grpc-message trailer.
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= 0x20 && c <= 0x7e && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
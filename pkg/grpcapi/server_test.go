@@ -0,0 +1,171 @@
+package grpcapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/feed"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/stats"
+)
+
+// startServer serves a snapshot and a hub over HTTP/2 without TLS and
+// returns the base URL and a client speaking it.
+func startServer(t *testing.T, hub *feed.Hub) (string, *http.Client) {
+	t.Helper()
+	s := &stats.Snapshot{Time: time.Unix(1700000000, 5), Window: "1h", Requests: 3}
+	s.AddSection("paths", map[string]int{"/a": 2, "/b": 1})
+	s.AddSection("ips", map[string]int{"10.0.0.1": 3})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go NewServer(func() *stats.Snapshot { return s }, hub).Serve(listener)
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+	return "http://" + listener.Addr().String(), client
+}
+
+// call starts a call with a request message.
+func call(t *testing.T, client *http.Client, url string, req message) *http.Response {
+	t.Helper()
+	body := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(req)))
+	r, _ := http.NewRequest("POST", url, bytes.NewReader(append(body, req...)))
+	r.Header.Set("Content-Type", "application/grpc")
+	r.Header.Set("TE", "trailers")
+	resp, err := client.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// next reads a response message.
+func next(t *testing.T, r io.Reader) []byte {
+	t.Helper()
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+	m := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(r, m); err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+	return m
+}
+
+// fields decodes the strings and varints of a message by field number.
+func fields(t *testing.T, m []byte) map[int][]any {
+	t.Helper()
+	got := make(map[int][]any)
+	err := decode(m, func(field, wire int, v uint64, data []byte) error {
+		if wire == wireBytes {
+			got[field] = append(got[field], string(data))
+		} else {
+			got[field] = append(got[field], v)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestGetSnapshot(t *testing.T) {
+	url, client := startServer(t, nil)
+	var req message
+	req.int64(1, 1)
+	req.string(2, "paths")
+	resp := call(t, client, url+methodGetSnapshot, req)
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 || resp.Header.Get("Content-Type") != "application/grpc" {
+		t.Fatalf("response %s with content type %q", resp.Proto, resp.Header.Get("Content-Type"))
+	}
+
+	snapshot := fields(t, next(t, resp.Body))
+	if snapshot[3][0] != "1h" || snapshot[5][0] != uint64(3) {
+		t.Errorf("snapshot fields = %v", snapshot)
+	}
+	ts := fields(t, []byte(snapshot[1][0].(string)))
+	if ts[1][0] != uint64(1700000000) || ts[2][0] != uint64(5) {
+		t.Errorf("timestamp = %v", ts)
+	}
+	if len(snapshot[9]) != 1 {
+		t.Fatalf("got %d sections, want only paths", len(snapshot[9]))
+	}
+	section := fields(t, []byte(snapshot[9][0].(string)))
+	if section[1][0] != "paths" || len(section[2]) != 1 {
+		t.Errorf("section = %v, want the top path only", section)
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	if code := resp.Trailer.Get("Grpc-Status"); code != "0" {
+		t.Errorf("grpc-status = %q, want 0", code)
+	}
+}
+
+func TestStreamEntries(t *testing.T) {
+	hub := feed.NewHub()
+	url, client := startServer(t, hub)
+	var req message
+	req.string(1, "status>=500")
+	resp := call(t, client, url+methodStreamEntries, req)
+	defer resp.Body.Close()
+
+	// The subscription starts once the headers are sent
+	hub.Publish(&parser.Visitor{IP: "10.0.0.1", Path: "/ok", Status: 200})
+	hub.Publish(&parser.Visitor{IP: "10.0.0.2", Path: "/fail", Status: 502, RequestTime: 1500 * time.Millisecond})
+	got := fields(t, next(t, resp.Body))
+	if got[2][0] != "10.0.0.2" || got[4][0] != "/fail" || got[6][0] != uint64(502) {
+		t.Errorf("entry = %v, want the 502 only", got)
+	}
+	requestTime := math.Float64frombits(got[11][0].(uint64))
+	if requestTime != 1.5 {
+		t.Errorf("request_time = %v, want 1.5", requestTime)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	url, client := startServer(t, feed.NewHub())
+	for _, tc := range []struct {
+		method string
+		req    message
+		want   string
+	}{
+		{"/tailnginx.v1.Stats/Nope", nil, "12"},
+		{methodStreamEntries, func() message { var m message; m.string(1, "nope>>"); return m }(), "3"},
+		{methodGetSnapshot, message{0xff}, "3"},
+	} {
+		resp := call(t, client, url+tc.method, tc.req)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if code := resp.Trailer.Get("Grpc-Status"); code != tc.want {
+			t.Errorf("%s: grpc-status = %q (%s), want %s", tc.method, code, resp.Trailer.Get("Grpc-Message"), tc.want)
+		}
+	}
+
+	resp, err := client.Get(url + methodGetSnapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestPercentEncode(t *testing.T) {
+	if got := percentEncode("bad 100% é"); got != "bad 100%25 %C3%A9" {
+		t.Errorf("percentEncode() = %q", got)
+	}
+}
@@ -0,0 +1,319 @@
+// Package highlight provides user-defined rules that make matching traffic stand out in the UI.
+package highlight
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Fields that rules can match on
+var fields = map[string]bool{
+	"status":   true,
+	"path":     true,
+	"ip":       true,
+	"method":   true,
+	"agent":    true,
+	"referer":  true,
+	"country":  true,
+	"protocol": true,
+	"bytes":    true,
+}
+
+// numericFields are compared as numbers rather than strings
+var numericFields = map[string]bool{
+	"status": true,
+	"bytes":  true,
+}
+
+// conditionRegex splits "<field> <op> <value>"
+var conditionRegex = regexp.MustCompile(`^\s*([a-z]+)\s*(>=|<=|!=|==|=|>|<|~|\s(?:contains|matches|prefix)\s)\s*(.*?)\s*$`)
+
+// textOps are operators that compare values as text
+var textOps = map[string]bool{
+	"contains": true,
+	"prefix":   true,
+	"matches":  true,
+}
+
+// attributes maps style words to tview attribute flags
+var attributes = map[string]string{
+	"bold":          "b",
+	"underline":     "u",
+	"dim":           "d",
+	"blink":         "l",
+	"reverse":       "r",
+	"italic":        "i",
+	"strikethrough": "s",
+}
+
+// Style describes how a matching entry is displayed.
+type Style struct {
+	Fg    string // Foreground color name or #hex, empty for default
+	Bg    string // Background color name or #hex, empty for default
+	Attrs string // tview attribute flags (e.g. "b" for bold)
+}
+
+// Tag returns the tview style tag for the style, e.g. "[yellow:red:b]".
+func (s Style) Tag() string {
+	fg, bg, attrs := s.Fg, s.Bg, s.Attrs
+	if fg == "" {
+		fg = "-"
+	}
+	if bg == "" {
+		bg = "-"
+	}
+	if attrs == "" {
+		attrs = "-"
+	}
+	return fmt.Sprintf("[%s:%s:%s]", fg, bg, attrs)
+}
+
+// Rule highlights entries whose field matches a condition.
+type Rule struct {
+	Field string
+	Op    string
+	Value string
+	Style Style
+	Text  string // Original rule text
+	num   int
+	re    *regexp.Regexp
+}
+
+// Rules is an ordered list of rules; the first matching rule wins.
+type Rules []Rule
+
+// Parse parses a rule such as "status>=500 -> red background" or
+// "path contains /api/payments -> bold yellow".
+// Supported operators are ==, =, !=, >, >=, <, <=, contains, prefix and
+// matches (or ~) for regular expressions.
+// Style words are colors (names or #hex), "background"/"bg" after a color or
+// "on <color>" for the background, and bold, underline, dim, blink, reverse,
+// italic or strikethrough.
+func Parse(text string) (Rule, error) {
+	condition, style, found := strings.Cut(text, "->")
+	if !found || strings.TrimSpace(style) == "" {
+		return Rule{}, fmt.Errorf("invalid rule %q: expected '<field> <op> <value> -> <style>'", text)
+	}
+
+	r, err := ParseCondition(condition)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: %w", text, err)
+	}
+	r.Text = text
+
+	r.Style, err = parseStyle(strings.TrimSpace(style))
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: %w", text, err)
+	}
+	return r, nil
+}
+
+// ParseCondition parses a rule condition without a style, such as
+// "status>=500" or "path prefix /api", e.g. to filter entries with Match.
+func ParseCondition(text string) (Rule, error) {
+	m := conditionRegex.FindStringSubmatch(text)
+	if m == nil {
+		return Rule{}, fmt.Errorf("invalid condition %q: expected '<field> <op> <value>'", strings.TrimSpace(text))
+	}
+
+	r := Rule{
+		Field: m[1],
+		Op:    strings.TrimSpace(m[2]),
+		Value: strings.Trim(m[3], `"'`),
+		Text:  strings.TrimSpace(text),
+	}
+	if !fields[r.Field] {
+		return Rule{}, fmt.Errorf("unknown field %q", r.Field)
+	}
+	if r.Op == "=" {
+		r.Op = "=="
+	}
+	if r.Op == "~" {
+		r.Op = "matches"
+	}
+
+	switch r.Op {
+	case ">", ">=", "<", "<=":
+		if !numericFields[r.Field] {
+			return Rule{}, fmt.Errorf("operator %s needs a numeric field", r.Op)
+		}
+	case "matches":
+		re, err := regexp.Compile(r.Value)
+		if err != nil {
+			return Rule{}, err
+		}
+		r.re = re
+	}
+	if numericFields[r.Field] && !textOps[r.Op] {
+		n, err := strconv.Atoi(r.Value)
+		if err != nil {
+			return Rule{}, fmt.Errorf("%s must be compared to a number", r.Field)
+		}
+		r.num = n
+	}
+	return r, nil
+}
+
+// ParseAll parses a list of rules, stopping at the first error.
+func ParseAll(texts []string) (Rules, error) {
+	rules := make(Rules, 0, len(texts))
+	for _, text := range texts {
+		r, err := Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// parseStyle parses style words like "bold yellow" or "white on red".
+func parseStyle(text string) (Style, error) {
+	var s Style
+	words := strings.Fields(strings.ToLower(text))
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if attr, ok := attributes[w]; ok {
+			s.Attrs += attr
+			continue
+		}
+		if w == "on" && i+1 < len(words) {
+			if !validColor(words[i+1]) {
+				return Style{}, fmt.Errorf("unknown color %q", words[i+1])
+			}
+			s.Bg = words[i+1]
+			i++
+			continue
+		}
+		if !validColor(w) {
+			return Style{}, fmt.Errorf("unknown style %q", w)
+		}
+		if i+1 < len(words) && (words[i+1] == "background" || words[i+1] == "bg") {
+			s.Bg = w
+			i++
+			continue
+		}
+		s.Fg = w
+	}
+	if s == (Style{}) {
+		return Style{}, fmt.Errorf("empty style")
+	}
+	return s, nil
+}
+
+// validColor reports whether name is a color tcell understands.
+func validColor(name string) bool {
+	if _, ok := tcell.ColorNames[name]; ok {
+		return true
+	}
+	return strings.HasPrefix(name, "#") && tcell.GetColor(name) != tcell.ColorDefault
+}
+
+// Match reports whether the entry matches the rule.
+func (r Rule) Match(v *parser.Visitor) bool {
+	switch r.Field {
+	case "status":
+		return r.matchNumber(v.Status)
+	case "bytes":
+		return r.matchNumber(v.Bytes)
+	}
+	return r.MatchValue(r.Field, fieldValue(v, r.Field))
+}
+
+// MatchValue reports whether the rule applies to the given field value.
+// It is used for aggregated tables where only one field is known.
+func (r Rule) MatchValue(field, value string) bool {
+	if field != r.Field {
+		return false
+	}
+	if numericFields[r.Field] {
+		n, err := strconv.Atoi(value)
+		return err == nil && r.matchNumber(n)
+	}
+
+	switch r.Op {
+	case "==":
+		return value == r.Value
+	case "!=":
+		return value != r.Value
+	}
+	return r.matchText(value)
+}
+
+// matchNumber compares a numeric field against the rule value.
+func (r Rule) matchNumber(n int) bool {
+	switch r.Op {
+	case "==":
+		return n == r.num
+	case "!=":
+		return n != r.num
+	case ">":
+		return n > r.num
+	case ">=":
+		return n >= r.num
+	case "<":
+		return n < r.num
+	case "<=":
+		return n <= r.num
+	}
+	return r.matchText(strconv.Itoa(n))
+}
+
+// matchText applies the string operators.
+func (r Rule) matchText(value string) bool {
+	switch r.Op {
+	case "contains":
+		return strings.Contains(value, r.Value)
+	case "prefix":
+		return strings.HasPrefix(value, r.Value)
+	case "matches":
+		return r.re.MatchString(value)
+	}
+	return false
+}
+
+// fieldValue returns the string value of a visitor field.
+func fieldValue(v *parser.Visitor, field string) string {
+	switch field {
+	case "path":
+		return v.Path
+	case "ip":
+		return v.IP
+	case "method":
+		return v.Method
+	case "agent":
+		return v.Agent
+	case "referer":
+		return v.Referer
+	case "country":
+		return v.Country
+	case "protocol":
+		return v.Protocol
+	}
+	return ""
+}
+
+// Style returns the style of the first rule matching the entry.
+func (rs Rules) Style(v *parser.Visitor) (Style, bool) {
+	for _, r := range rs {
+		if r.Match(v) {
+			return r.Style, true
+		}
+	}
+	return Style{}, false
+}
+
+// ValueStyle returns the style of the first rule matching a field value.
+func (rs Rules) ValueStyle(field, value string) (Style, bool) {
+	for _, r := range rs {
+		if r.MatchValue(field, value) {
+			return r.Style, true
+		}
+	}
+	return Style{}, false
+}
@@ -0,0 +1,132 @@
+package highlight
+
+import (
+	"testing"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text  string
+		field string
+		op    string
+		value string
+		tag   string
+	}{
+		{"status>=500 -> red background", "status", ">=", "500", "[-:red:-]"},
+		{"path contains /api/payments -> bold yellow", "path", "contains", "/api/payments", "[yellow:-:b]"},
+		{"ip = 1.2.3.4 -> white on red", "ip", "==", "1.2.3.4", "[white:red:-]"},
+		{`agent ~ "(?i)bot" -> dim`, "agent", "matches", "(?i)bot", "[-:-:d]"},
+		{"method != GET -> #ff8800 underline", "method", "!=", "GET", "[#ff8800:-:u]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			r, err := Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if r.Field != tt.field || r.Op != tt.op || r.Value != tt.value {
+				t.Errorf("Parse() = %s %s %q, want %s %s %q", r.Field, r.Op, r.Value, tt.field, tt.op, tt.value)
+			}
+			if got := r.Style.Tag(); got != tt.tag {
+				t.Errorf("Style.Tag() = %q, want %q", got, tt.tag)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	invalid := []string{
+		"status>=500",                   // no style
+		"size>=500 -> red",              // unknown field
+		"path > /api -> red",            // numeric op on text field
+		"status >= abc -> red",          // non-numeric value
+		"path matches ([ -> red",        // bad regex
+		"status>=500 -> sparkly",        // unknown style word
+		"status>=500 -> red background", // valid, control
+	}
+
+	for i, text := range invalid {
+		_, err := Parse(text)
+		if i == len(invalid)-1 {
+			if err != nil {
+				t.Errorf("Parse(%q) unexpected error: %v", text, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("Parse(%q) expected error", text)
+		}
+	}
+}
+
+func TestRulesStyle(t *testing.T) {
+	rules, err := ParseAll([]string{
+		"status>=500 -> red background",
+		"path prefix /api/payments -> bold yellow",
+		"status matches ^4 -> yellow",
+	})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		v     parser.Visitor
+		match bool
+		bg    string
+	}{
+		{"server error", parser.Visitor{Status: 503, Path: "/"}, true, "red"},
+		{"payments", parser.Visitor{Status: 200, Path: "/api/payments/42"}, true, ""},
+		{"client error", parser.Visitor{Status: 404, Path: "/x"}, true, ""},
+		{"first rule wins", parser.Visitor{Status: 500, Path: "/api/payments"}, true, "red"},
+		{"no match", parser.Visitor{Status: 200, Path: "/"}, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style, ok := rules.Style(&tt.v)
+			if ok != tt.match {
+				t.Fatalf("Style() matched = %v, want %v", ok, tt.match)
+			}
+			if style.Bg != tt.bg {
+				t.Errorf("Style().Bg = %q, want %q", style.Bg, tt.bg)
+			}
+		})
+	}
+}
+
+func TestValueStyle(t *testing.T) {
+	rules, _ := ParseAll([]string{"status>=500 -> red", "path contains admin -> bold"})
+
+	if _, ok := rules.ValueStyle("status", "502"); !ok {
+		t.Error("ValueStyle(status, 502) should match")
+	}
+	if _, ok := rules.ValueStyle("status", "200"); ok {
+		t.Error("ValueStyle(status, 200) should not match")
+	}
+	if _, ok := rules.ValueStyle("path", "/wp-admin"); !ok {
+		t.Error("ValueStyle(path, /wp-admin) should match")
+	}
+	if _, ok := rules.ValueStyle("ip", "/wp-admin"); ok {
+		t.Error("ValueStyle() should only match the rule's field")
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	r, err := ParseCondition("path prefix /api")
+	if err != nil {
+		t.Fatalf("ParseCondition() error = %v", err)
+	}
+	if !r.Match(&parser.Visitor{Path: "/api/users"}) || r.Match(&parser.Visitor{Path: "/"}) {
+		t.Error("path prefix /api should only match paths under /api")
+	}
+
+	for _, text := range []string{"status>=500 -> red", "size>1", "status>=abc", ""} {
+		if _, err := ParseCondition(text); err == nil {
+			t.Errorf("ParseCondition(%q) expected error", text)
+		}
+	}
+}
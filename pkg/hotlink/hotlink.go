@@ -0,0 +1,107 @@
+// Package hotlink tells hotlinked requests: images and videos embedded in
+// foreign sites, which serve them from the bandwidth of this one.
+package hotlink
+
+import (
+	"fmt"
+	"net/netip"
+	"path"
+	"strings"
+
+	"github.com/papaganelli/tailnginx/pkg/referrer"
+)
+
+// mediaExtensions are the extensions of the images and videos that are
+// worth hotlinking.
+var mediaExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true,
+	".avif": true, ".svg": true, ".bmp": true, ".ico": true, ".tif": true, ".tiff": true,
+	".mp4": true, ".webm": true, ".mov": true, ".m4v": true, ".ogv": true, ".mkv": true,
+	".avi": true, ".flv": true, ".m3u8": true, ".ts": true,
+}
+
+// IsMedia reports whether a request path is an image or a video, by its
+// extension, whatever its query string.
+func IsMedia(p string) bool {
+	p, _, _ = strings.Cut(p, "?")
+	return mediaExtensions[strings.ToLower(path.Ext(p))]
+}
+
+// Asset returns the path of a media request without its query string, so
+// that e.g. cache busters count as one asset.
+func Asset(p string) string {
+	p, _, _ = strings.Cut(p, "?")
+	return p
+}
+
+// Detector tells the referers of foreign sites from those of the site
+// itself: its domains and the host requested. A nil Detector only knows the
+// host requested.
+type Detector struct {
+	sites []string
+}
+
+// NewDetector returns a detector of a site with the given domains, e.g.
+// "example.com", which also covers its subdomains and parent domain.
+func NewDetector(domains []string) (*Detector, error) {
+	d := &Detector{}
+	for _, domain := range domains {
+		host := referrer.Domain(domain)
+		if host == "" || strings.ContainsAny(host, " /") {
+			return nil, fmt.Errorf("invalid site domain %q: expected a domain, e.g. 'example.com'", domain)
+		}
+		d.sites = append(d.sites, site(host))
+	}
+	return d, nil
+}
+
+// Knows reports whether the site of a request is known, from the domains of
+// the detector or the host requested ($host), so that hotlinks can be told.
+func (d *Detector) Knows(host string) bool {
+	return host != "" || (d != nil && len(d.sites) > 0)
+}
+
+// Site returns the domain of the foreign site a media request was embedded
+// in, from its referer and the host requested, or "" if it is not a
+// hotlink: no referer, a referer from the site itself or a search engine,
+// or an unknown site.
+func (d *Detector) Site(referer, host string) string {
+	if !d.Knows(host) {
+		return ""
+	}
+	source, domain := referrer.Classify(referer)
+	if source == referrer.SourceDirect || source == referrer.SourceSearch || referrer.Domain(referer) == "" {
+		return ""
+	}
+	from := site(domain)
+	if host != "" && from == site(referrer.Domain(host)) {
+		return ""
+	}
+	if d != nil {
+		for _, s := range d.sites {
+			if from == s {
+				return ""
+			}
+		}
+	}
+	return domain
+}
+
+// site returns the registrable part of a domain, which its subdomains
+// share: its last two labels, or three under country domains like
+// "example.co.uk". IPs are sites of their own.
+func site(domain string) string {
+	domain = strings.TrimSuffix(domain, ".")
+	if _, err := netip.ParseAddr(strings.Trim(domain, "[]")); err == nil {
+		return domain
+	}
+	labels := strings.Split(domain, ".")
+	n := 2
+	if len(labels) >= 3 && len(labels[len(labels)-1]) == 2 && len(labels[len(labels)-2]) <= 3 {
+		n = 3
+	}
+	if len(labels) <= n {
+		return domain
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
@@ -0,0 +1,66 @@
+package hotlink
+
+import "testing"
+
+func TestIsMedia(t *testing.T) {
+	tests := map[string]bool{
+		"/images/logo.png":           true,
+		"/photos/IMG_0042.JPG?w=800": true,
+		"/video/intro.mp4":           true,
+		"/hls/stream.m3u8":           true,
+		"/favicon.ico":               true,
+		"/":                          false,
+		"/index.html":                false,
+		"/app.js?v=png":              false,
+		"/png":                       false,
+	}
+	for p, want := range tests {
+		if got := IsMedia(p); got != want {
+			t.Errorf("IsMedia(%q) = %v, want %v", p, got, want)
+		}
+	}
+	if got := Asset("/images/logo.png?v=3"); got != "/images/logo.png" {
+		t.Errorf("Asset() = %q", got)
+	}
+}
+
+func TestSite(t *testing.T) {
+	d, err := NewDetector([]string{"example.com", "https://www.example.co.uk/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		referer, host, want string
+	}{
+		{"https://forum.leech.net/thread/42", "", "forum.leech.net"},
+		{"https://www.leech.net/", "cdn.example.org", "leech.net"},
+		{"https://blog.example.com/post", "", ""},
+		{"https://example.com/", "", ""},
+		{"https://shop.example.co.uk/", "", ""},
+		{"https://other.co.uk/", "", "other.co.uk"},
+		{"https://www.example.org/gallery", "cdn.example.org", ""},
+		{"http://203.0.113.7:8080/page", "", "203.0.113.7"},
+		{"https://www.google.com/", "", ""},
+		{"https://www.reddit.com/r/pics", "", "reddit.com"},
+		{"-", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := d.Site(tt.referer, tt.host); got != tt.want {
+			t.Errorf("Site(%q, %q) = %q, want %q", tt.referer, tt.host, got, tt.want)
+		}
+	}
+
+	// Without domains, only the host requested tells the site
+	var nilDetector *Detector
+	if nilDetector.Knows("") || nilDetector.Site("https://leech.net/", "") != "" {
+		t.Error("nil Detector should not tell hotlinks without a host")
+	}
+	if got := nilDetector.Site("https://leech.net/", "example.com"); got != "leech.net" {
+		t.Errorf("nil Detector Site() = %q, want leech.net", got)
+	}
+
+	if _, err := NewDetector([]string{"not a domain"}); err == nil {
+		t.Error("NewDetector() should fail on an invalid domain")
+	}
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// latencySamples is the number of most recent request times the latency
+// quantiles are computed from.
+const latencySamples = 1024
+
+// latencyQuantiles are the quantiles of the request time summary.
+var latencyQuantiles = []float64{0.5, 0.9, 0.99}
+
+// Exporter counts parsed log entries and serves them in the Prometheus text
+// exposition format. A nil Exporter ignores everything it is given, so it
+// can be passed around when metrics are disabled. It is safe for concurrent
+// use.
+type Exporter struct {
+	mu          sync.Mutex
+	rate        *RateTracker
+	statuses    map[int]uint64
+	requests    uint64
+	bytes       uint64
+	failures    uint64
+	latencies   []time.Duration // Ring buffer of the latest request times
+	latencyNext int
+	latencySum  time.Duration
+	latencyN    uint64
+}
+
+// NewExporter creates an exporter without any entries counted.
+func NewExporter() *Exporter {
+	return &Exporter{
+		rate:     NewRateTracker(10*time.Second, 60),
+		statuses: make(map[int]uint64),
+	}
+}
+
+// Observe counts a parsed log entry.
+func (e *Exporter) Observe(v *parser.Visitor) {
+	if e == nil {
+		return
+	}
+	e.rate.Record(v.Time)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests++
+	e.bytes += uint64(max(v.Bytes, 0))
+	e.statuses[v.Status]++
+	if v.RequestTime > 0 {
+		if len(e.latencies) < latencySamples {
+			e.latencies = append(e.latencies, v.RequestTime)
+		} else {
+			e.latencies[e.latencyNext] = v.RequestTime
+			e.latencyNext = (e.latencyNext + 1) % latencySamples
+		}
+		e.latencySum += v.RequestTime
+		e.latencyN++
+	}
+}
+
+// ParseFailure counts a log line that could not be parsed.
+func (e *Exporter) ParseFailure() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.failures++
+	e.mu.Unlock()
+}
+
+// ServeHTTP writes the metrics, e.g. for the /metrics path.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.Write(w, time.Now())
+}
+
+// Write writes the metrics in the Prometheus text format. The request rate
+// is the average over the minute before now.
+func (e *Exporter) Write(w io.Writer, now time.Time) error {
+	var lastMinute uint64
+	for _, n := range e.rate.Buckets(now, 6) {
+		lastMinute += n
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	metric("tailnginx_requests_total", "counter", "Parsed requests.")
+	fmt.Fprintf(bw, "tailnginx_requests_total %d\n", e.requests)
+
+	metric("tailnginx_responses_total", "counter", "Parsed requests by response status code.")
+	codes := make([]int, 0, len(e.statuses))
+	for code := range e.statuses {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	for _, code := range codes {
+		fmt.Fprintf(bw, "tailnginx_responses_total{status=\"%d\"} %d\n", code, e.statuses[code])
+	}
+
+	metric("tailnginx_request_rate", "gauge", "Requests per second over the last minute.")
+	fmt.Fprintf(bw, "tailnginx_request_rate %s\n", formatFloat(float64(lastMinute)/60))
+
+	metric("tailnginx_response_bytes_total", "counter", "Response body bytes sent.")
+	fmt.Fprintf(bw, "tailnginx_response_bytes_total %d\n", e.bytes)
+
+	metric("tailnginx_request_duration_seconds", "summary", "Request times logged as $request_time, quantiles of the latest requests.")
+	if len(e.latencies) > 0 {
+		sorted := slices.Clone(e.latencies)
+		slices.Sort(sorted)
+		for _, q := range latencyQuantiles {
+			d := sorted[min(int(q*float64(len(sorted))), len(sorted)-1)]
+			fmt.Fprintf(bw, "tailnginx_request_duration_seconds{quantile=\"%s\"} %s\n", formatFloat(q), formatFloat(d.Seconds()))
+		}
+	}
+	fmt.Fprintf(bw, "tailnginx_request_duration_seconds_sum %s\n", formatFloat(e.latencySum.Seconds()))
+	fmt.Fprintf(bw, "tailnginx_request_duration_seconds_count %d\n", e.latencyN)
+
+	metric("tailnginx_parse_failures_total", "counter", "Log lines that could not be parsed.")
+	fmt.Fprintf(bw, "tailnginx_parse_failures_total %d\n", e.failures)
+
+	return bw.Flush()
+}
+
+// formatFloat formats a sample value as short as possible, e.g. "0.25".
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
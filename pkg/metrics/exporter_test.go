@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestExporter(t *testing.T) {
+	e := NewExporter()
+	now := time.Now()
+	for i := 1; i <= 10; i++ {
+		e.Observe(&parser.Visitor{Time: now, Status: 200, Bytes: 100, RequestTime: time.Duration(i) * 100 * time.Millisecond})
+	}
+	e.Observe(&parser.Visitor{Time: now, Status: 404, Bytes: 20})
+	e.ParseFailure()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	out := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE tailnginx_requests_total counter",
+		"tailnginx_requests_total 11",
+		`tailnginx_responses_total{status="200"} 10`,
+		`tailnginx_responses_total{status="404"} 1`,
+		"tailnginx_response_bytes_total 1020",
+		`tailnginx_request_duration_seconds{quantile="0.5"} 0.6`,
+		`tailnginx_request_duration_seconds{quantile="0.99"} 1`,
+		"tailnginx_request_duration_seconds_sum 5.5",
+		"tailnginx_request_duration_seconds_count 10",
+		"tailnginx_parse_failures_total 1",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("metrics missing %q:\n%s", line, out)
+		}
+	}
+	if !strings.Contains(out, "tailnginx_request_rate 0.18") {
+		t.Errorf("rate of 11 requests in the last minute missing:\n%s", out)
+	}
+}
+
+func TestExporterLatencyWindow(t *testing.T) {
+	e := NewExporter()
+	for i := 0; i < latencySamples+10; i++ {
+		e.Observe(&parser.Visitor{RequestTime: time.Second})
+	}
+	if len(e.latencies) != latencySamples || e.latencyN != latencySamples+10 {
+		t.Errorf("kept %d of %d request times, want the latest %d", len(e.latencies), e.latencyN, latencySamples)
+	}
+
+	var nilExporter *Exporter
+	nilExporter.Observe(&parser.Visitor{})
+	nilExporter.ParseFailure()
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// graphiteTimeout bounds connecting and writing to a Graphite server.
+const graphiteTimeout = 10 * time.Second
+
+// WriteGraphite writes the totals in the Graphite plaintext protocol,
+// timestamped with the end of the interval: the request count, rate, bytes
+// and parse failures, the requests per status class and the latency in
+// seconds, e.g. "tailnginx.status.5xx 3 1748872810". Metric paths start
+// with prefix and a dot.
+func WriteGraphite(w io.Writer, t *Totals, prefix string) error {
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	ts := t.End.Unix()
+	bw := bufio.NewWriter(w)
+	metric := func(name, value string) {
+		fmt.Fprintf(bw, "%s%s %s %d\n", prefix, name, value, ts)
+	}
+
+	metric("requests", fmt.Sprint(t.Requests))
+	metric("rate", formatFloat(t.Rate()))
+	metric("bytes", fmt.Sprint(t.Bytes))
+	metric("parse_failures", fmt.Sprint(t.Failures))
+
+	classes := make([]string, 0, len(t.Statuses))
+	for class := range t.Statuses {
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+	for _, class := range classes {
+		metric("status."+class, fmt.Sprint(t.Statuses[class]))
+	}
+
+	if l := t.Latency; l.Count > 0 {
+		metric("latency.mean", formatFloat(l.Mean.Seconds()))
+		metric("latency.p50", formatFloat(l.P50.Seconds()))
+		metric("latency.p90", formatFloat(l.P90.Seconds()))
+		metric("latency.p99", formatFloat(l.P99.Seconds()))
+	}
+	return bw.Flush()
+}
+
+// GraphiteTCP returns an output sending the totals to a Graphite (carbon)
+// server over TCP, e.g. "graphite.example.com:2003". A connection is made
+// for every interval, so a restarted server is picked up again.
+func GraphiteTCP(addr, prefix string) Output {
+	return func(t *Totals) error {
+		conn, err := net.DialTimeout("tcp", addr, graphiteTimeout)
+		if err != nil {
+			return fmt.Errorf("graphite: %w", err)
+		}
+		defer conn.Close()
+		conn.SetWriteDeadline(time.Now().Add(graphiteTimeout))
+		if err := WriteGraphite(conn, t, prefix); err != nil {
+			return fmt.Errorf("graphite: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+const testGraphite = `nginx.web1.requests 5 1748872810
+nginx.web1.rate 0.5 1748872810
+nginx.web1.bytes 2048 1748872810
+nginx.web1.parse_failures 0 1748872810
+nginx.web1.status.2xx 4 1748872810
+nginx.web1.status.4xx 1 1748872810
+nginx.web1.latency.mean 0.15 1748872810
+nginx.web1.latency.p50 0.2 1748872810
+nginx.web1.latency.p90 0.2 1748872810
+nginx.web1.latency.p99 0.2 1748872810
+`
+
+func TestWriteGraphite(t *testing.T) {
+	var b strings.Builder
+	if err := WriteGraphite(&b, testTotals(), "nginx.web1"); err != nil {
+		t.Fatalf("WriteGraphite() error = %v", err)
+	}
+	if b.String() != testGraphite {
+		t.Errorf("WriteGraphite() =\n%s\nwant\n%s", b.String(), testGraphite)
+	}
+}
+
+func TestGraphiteTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		data, _ := io.ReadAll(conn)
+		conn.Close()
+		received <- string(data)
+	}()
+
+	if err := GraphiteTCP(ln.Addr().String(), "nginx.web1.")(testTotals()); err != nil {
+		t.Fatalf("output error = %v", err)
+	}
+	if got := <-received; got != testGraphite {
+		t.Errorf("received =\n%s\nwant\n%s", got, testGraphite)
+	}
+
+	// Nothing listens after the listener is closed
+	ln.Close()
+	if err := GraphiteTCP(ln.Addr().String(), "")(testTotals()); err == nil {
+		t.Error("output to a closed port should fail")
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// HyperLogLog estimates the number of distinct strings with fixed memory.
+// With precision p it uses 2^p one-byte registers and has a standard error
+// of about 1.04/sqrt(2^p) (1.6% for p=12).
+type HyperLogLog struct {
+	registers []uint8
+	p         uint8
+}
+
+// NewHyperLogLog creates a HyperLogLog sketch with the given precision (4-16).
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < 4 {
+		precision = 4
+	}
+	if precision > 16 {
+		precision = 16
+	}
+	return &HyperLogLog{
+		registers: make([]uint8, 1<<precision),
+		p:         precision,
+	}
+}
+
+// Add adds a value to the sketch.
+func (h *HyperLogLog) Add(value string) {
+	x := hash64(value)
+	idx := x >> (64 - h.p)
+	rank := uint8(bits.LeadingZeros64(x<<h.p|1<<(h.p-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// Merge folds another sketch of the same precision into h.
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	if other == nil || other.p != h.p {
+		return
+	}
+	for i, r := range other.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+}
+
+// Count returns the estimated number of distinct values added.
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha(m) * m * m / sum
+	// Small range correction: linear counting is more accurate
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// alpha returns the bias correction constant for m registers.
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/m)
+}
+
+// hash64 hashes a string with FNV-1a followed by a 64-bit finalizer, since
+// FNV alone distributes similar inputs (like IPs) poorly in the high bits.
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// influxTimeout bounds a write to an InfluxDB HTTP endpoint.
+const influxTimeout = 10 * time.Second
+
+// influxEscaper escapes tag keys and values in the line protocol.
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// WriteInflux writes the totals in the InfluxDB line protocol, timestamped
+// with the end of the interval in nanoseconds: a "tailnginx" point with the
+// request, rate, byte, parse failure and latency fields, and a
+// "tailnginx_status" point per status class. Every point has the given
+// tags, e.g. the log file.
+func WriteInflux(w io.Writer, t *Totals, tags map[string]string) error {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var tagSet strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&tagSet, ",%s=%s", influxEscaper.Replace(k), influxEscaper.Replace(tags[k]))
+	}
+	ts := t.End.UnixNano()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "tailnginx%s requests=%di,rate=%s,bytes=%di,parse_failures=%di",
+		tagSet.String(), t.Requests, formatFloat(t.Rate()), t.Bytes, t.Failures)
+	if l := t.Latency; l.Count > 0 {
+		fmt.Fprintf(bw, ",latency_count=%di,latency_mean=%s,latency_p50=%s,latency_p90=%s,latency_p99=%s",
+			l.Count, formatFloat(l.Mean.Seconds()), formatFloat(l.P50.Seconds()),
+			formatFloat(l.P90.Seconds()), formatFloat(l.P99.Seconds()))
+	}
+	fmt.Fprintf(bw, " %d\n", ts)
+
+	classes := make([]string, 0, len(t.Statuses))
+	for class := range t.Statuses {
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+	for _, class := range classes {
+		fmt.Fprintf(bw, "tailnginx_status%s,class=%s requests=%di %d\n", tagSet.String(), class, t.Statuses[class], ts)
+	}
+	return bw.Flush()
+}
+
+// InfluxFile returns an output appending the totals to a file in the
+// InfluxDB line protocol, e.g. for Telegraf's tail input.
+func InfluxFile(path string, tags map[string]string) Output {
+	return func(t *Totals) error {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("influx: %w", err)
+		}
+		if err := WriteInflux(f, t, tags); err != nil {
+			f.Close()
+			return fmt.Errorf("influx: %w", err)
+		}
+		return f.Close()
+	}
+}
+
+// InfluxHTTP returns an output posting the totals to an InfluxDB write
+// endpoint, e.g. http://localhost:8086/api/v2/write?org=ops&bucket=nginx
+// for InfluxDB 2 or http://localhost:8086/write?db=nginx for InfluxDB 1.
+// A non-empty token is sent in the Authorization header.
+func InfluxHTTP(url, token string, tags map[string]string) Output {
+	client := &http.Client{Timeout: influxTimeout}
+	return func(t *Totals) error {
+		var body bytes.Buffer
+		WriteInflux(&body, t, tags)
+		req, err := http.NewRequest(http.MethodPost, url, &body)
+		if err != nil {
+			return fmt.Errorf("influx: %w", err)
+		}
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		if token != "" {
+			req.Header.Set("Authorization", "Token "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("influx: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return fmt.Errorf("influx: %s: %s", resp.Status, bytes.TrimSpace(msg))
+		}
+		return nil
+	}
+}
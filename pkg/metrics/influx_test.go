@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTotals() *Totals {
+	end := time.Date(2025, 6, 2, 14, 0, 10, 0, time.UTC)
+	return &Totals{
+		Start:    end.Add(-10 * time.Second),
+		End:      end,
+		Requests: 5,
+		Statuses: map[string]uint64{"4xx": 1, "2xx": 4},
+		Bytes:    2048,
+		Latency:  Latency{Count: 2, Mean: 150 * time.Millisecond, P50: 200 * time.Millisecond, P90: 200 * time.Millisecond, P99: 200 * time.Millisecond},
+	}
+}
+
+const testInflux = `tailnginx,log=/var/log/my\ access.log requests=5i,rate=0.5,bytes=2048i,parse_failures=0i,latency_count=2i,latency_mean=0.15,latency_p50=0.2,latency_p90=0.2,latency_p99=0.2 1748872810000000000
+tailnginx_status,log=/var/log/my\ access.log,class=2xx requests=4i 1748872810000000000
+tailnginx_status,log=/var/log/my\ access.log,class=4xx requests=1i 1748872810000000000
+`
+
+func TestWriteInflux(t *testing.T) {
+	var b strings.Builder
+	tags := map[string]string{"log": "/var/log/my access.log"}
+	if err := WriteInflux(&b, testTotals(), tags); err != nil {
+		t.Fatalf("WriteInflux() error = %v", err)
+	}
+	if b.String() != testInflux {
+		t.Errorf("WriteInflux() =\n%s\nwant\n%s", b.String(), testInflux)
+	}
+
+	// Without request times there are no latency fields
+	b.Reset()
+	WriteInflux(&b, &Totals{End: testTotals().End}, nil)
+	if want := "tailnginx requests=0i,rate=0,bytes=0i,parse_failures=0i 1748872810000000000\n"; b.String() != want {
+		t.Errorf("WriteInflux() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestInfluxFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.lp")
+	out := InfluxFile(path, nil)
+	for range 2 {
+		if err := out(testTotals()); err != nil {
+			t.Fatalf("output error = %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\ntailnginx "); n != 1 {
+		t.Errorf("file should have 2 appended intervals:\n%s", data)
+	}
+}
+
+func TestInfluxHTTP(t *testing.T) {
+	var body, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, auth = string(data), r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	tags := map[string]string{"log": "/var/log/my access.log"}
+	if err := InfluxHTTP(srv.URL+"/api/v2/write", "secret", tags)(testTotals()); err != nil {
+		t.Fatalf("output error = %v", err)
+	}
+	if body != testInflux || auth != "Token secret" {
+		t.Errorf("posted %q with Authorization %q", body, auth)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bucket not found", http.StatusNotFound)
+	}))
+	defer failing.Close()
+	err := InfluxHTTP(failing.URL, "", nil)(testTotals())
+	if err == nil || !strings.Contains(err.Error(), "bucket not found") {
+		t.Errorf("output error = %v, want the server message", err)
+	}
+}
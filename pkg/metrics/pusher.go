@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Totals are the requests counted over one push interval.
+type Totals struct {
+	Start    time.Time
+	End      time.Time
+	Requests uint64
+	Statuses map[string]uint64 // Requests by status class, e.g. "2xx"
+	Bytes    uint64
+	Failures uint64 // Log lines that could not be parsed
+	Latency  Latency
+}
+
+// Rate returns the average number of requests per second.
+func (t *Totals) Rate() float64 {
+	seconds := t.End.Sub(t.Start).Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(t.Requests) / seconds
+}
+
+// Latency summarizes the request times logged as $request_time. Requests
+// without a request time are not counted.
+type Latency struct {
+	Count int
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+// Output receives the totals of each interval, e.g. to write them to a time
+// series database.
+type Output func(t *Totals) error
+
+// Pusher counts parsed log entries per interval and hands the totals of
+// each interval to its outputs. A nil Pusher ignores everything it is given,
+// so it can be passed around when no output is configured. It is safe for
+// concurrent use.
+type Pusher struct {
+	mu      sync.Mutex
+	outputs []Output
+	totals  Totals
+	timings []time.Duration // Sampled request times of the interval
+	timed   int             // Request times observed, including dropped ones
+	sum     time.Duration
+}
+
+// NewPusher creates a pusher without outputs whose first interval starts
+// now.
+func NewPusher() *Pusher {
+	p := &Pusher{}
+	p.reset(time.Now())
+	return p
+}
+
+// AddOutput adds an output the totals are pushed to.
+func (p *Pusher) AddOutput(out Output) {
+	p.mu.Lock()
+	p.outputs = append(p.outputs, out)
+	p.mu.Unlock()
+}
+
+// Observe counts a parsed log entry.
+func (p *Pusher) Observe(v *parser.Visitor) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.totals.Requests++
+	p.totals.Statuses[statusClass(v.Status)]++
+	p.totals.Bytes += uint64(max(v.Bytes, 0))
+	if v.RequestTime > 0 {
+		p.timed++
+		p.sum += v.RequestTime
+		if len(p.timings) < latencySamples {
+			p.timings = append(p.timings, v.RequestTime)
+		} else if i := rand.IntN(p.timed); i < latencySamples {
+			p.timings[i] = v.RequestTime
+		}
+	}
+}
+
+// ParseFailure counts a log line that could not be parsed.
+func (p *Pusher) ParseFailure() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.totals.Failures++
+	p.mu.Unlock()
+}
+
+// Run pushes the totals every interval and passes the errors of the
+// outputs to report. It never returns.
+func (p *Pusher) Run(interval time.Duration, report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if err := p.Flush(now); err != nil {
+			report(err)
+		}
+	}
+}
+
+// Flush ends the current interval at now, pushes its totals to every output
+// and starts the next interval. It returns the errors of all outputs.
+func (p *Pusher) Flush(now time.Time) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	t := p.totals
+	t.End = now
+	if n := len(p.timings); n > 0 {
+		slices.Sort(p.timings)
+		t.Latency = Latency{
+			Count: p.timed,
+			Mean:  p.sum / time.Duration(p.timed),
+			P50:   p.timings[min(n/2, n-1)],
+			P90:   p.timings[min(n*9/10, n-1)],
+			P99:   p.timings[min(n*99/100, n-1)],
+		}
+	}
+	outputs := p.outputs
+	p.reset(now)
+	p.mu.Unlock()
+
+	var errs []error
+	for _, out := range outputs {
+		errs = append(errs, out(&t))
+	}
+	return errors.Join(errs...)
+}
+
+// reset starts a new interval at now. Caller must hold the lock.
+func (p *Pusher) reset(now time.Time) {
+	p.totals = Totals{Start: now, Statuses: make(map[string]uint64)}
+	p.timings = p.timings[:0]
+	p.timed = 0
+	p.sum = 0
+}
+
+// statusClass returns the class of a status code, e.g. "4xx" for 404.
+func statusClass(status int) string {
+	return fmt.Sprintf("%dxx", status/100)
+}
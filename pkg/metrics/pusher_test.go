@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestPusherFlush(t *testing.T) {
+	p := NewPusher()
+	start := time.Date(2025, 6, 2, 14, 0, 0, 0, time.UTC)
+	p.Flush(start)
+
+	var got []Totals
+	p.AddOutput(func(t *Totals) error {
+		got = append(got, *t)
+		return nil
+	})
+	for i := 1; i <= 10; i++ {
+		p.Observe(&parser.Visitor{Status: 200, Bytes: 10, RequestTime: time.Duration(i) * 10 * time.Millisecond})
+	}
+	p.Observe(&parser.Visitor{Status: 503})
+	p.ParseFailure()
+
+	if err := p.Flush(start.Add(10 * time.Second)); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("pushed %d totals, want 1", len(got))
+	}
+	tot := got[0]
+	if tot.Requests != 11 || tot.Bytes != 100 || tot.Failures != 1 {
+		t.Errorf("totals = %+v", tot)
+	}
+	if tot.Statuses["2xx"] != 10 || tot.Statuses["5xx"] != 1 {
+		t.Errorf("statuses = %v", tot.Statuses)
+	}
+	if rate := tot.Rate(); rate != 1.1 {
+		t.Errorf("Rate() = %v, want 1.1", rate)
+	}
+	want := Latency{Count: 10, Mean: 55 * time.Millisecond, P50: 60 * time.Millisecond, P90: 100 * time.Millisecond, P99: 100 * time.Millisecond}
+	if tot.Latency != want {
+		t.Errorf("latency = %+v, want %+v", tot.Latency, want)
+	}
+
+	// The next interval starts empty where the previous one ended
+	p.Flush(start.Add(20 * time.Second))
+	if next := got[1]; next.Requests != 0 || next.Latency.Count != 0 || !next.Start.Equal(tot.End) {
+		t.Errorf("next totals = %+v", next)
+	}
+}
+
+func TestPusherOutputErrors(t *testing.T) {
+	p := NewPusher()
+	failed := errors.New("unreachable")
+	calls := 0
+	p.AddOutput(func(*Totals) error { calls++; return failed })
+	p.AddOutput(func(*Totals) error { calls++; return nil })
+	if err := p.Flush(time.Now()); !errors.Is(err, failed) {
+		t.Errorf("Flush() error = %v, want %v", err, failed)
+	}
+	if calls != 2 {
+		t.Errorf("called %d outputs, want 2 despite the error", calls)
+	}
+
+	var nilPusher *Pusher
+	nilPusher.Observe(&parser.Visitor{})
+	nilPusher.ParseFailure()
+	if err := nilPusher.Flush(time.Now()); err != nil {
+		t.Errorf("Flush() on nil = %v", err)
+	}
+}
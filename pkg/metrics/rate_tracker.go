@@ -1,4 +1,5 @@
-// Package metrics provides request rate tracking and analysis.
+// Package metrics provides request rate tracking and analysis, and exports
+// request metrics to Prometheus and StatsD.
 package metrics
 
 import (
@@ -144,6 +145,25 @@ func (rt *RateTracker) GetStats() Stats {
 	}
 }
 
+// Buckets returns the requests of the last n buckets ending at now, oldest
+// first. Buckets without data or no longer kept count as zero.
+func (rt *RateTracker) Buckets(now time.Time, n int) []uint64 {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	counts := make([]uint64, n)
+	end := now.Truncate(rt.bucketSize)
+	for i := 0; i < rt.windowSize; i++ {
+		if rt.timestamps[i].IsZero() || rt.timestamps[i].After(end) {
+			continue
+		}
+		if age := int(end.Sub(rt.timestamps[i]) / rt.bucketSize); age < n {
+			counts[n-1-age] += uint64(rt.buckets[i])
+		}
+	}
+	return counts
+}
+
 // Reset clears all tracking data.
 func (rt *RateTracker) Reset() {
 	rt.mu.Lock()
@@ -75,6 +75,23 @@ func TestMultipleBuckets(t *testing.T) {
 	}
 }
 
+func TestBuckets(t *testing.T) {
+	rt := NewRateTracker(10*time.Second, 60)
+	base := time.Date(2025, 6, 2, 14, 0, 0, 0, time.UTC)
+
+	rt.RecordN(base, 5)
+	rt.RecordN(base.Add(12*time.Second), 3)
+	rt.RecordN(base.Add(35*time.Second), 7)
+
+	got := rt.Buckets(base.Add(38*time.Second), 5)
+	want := []uint64{0, 5, 3, 0, 7}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Buckets() = %v, want %v", got, want)
+		}
+	}
+}
+
 func TestTrendCalculation(t *testing.T) {
 	rt := NewRateTracker(10*time.Second, 10)
 	baseTime := time.Now().Add(-100 * time.Second) // Start in past
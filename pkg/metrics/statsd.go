@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// maxStatsDPacket is the largest UDP payload sent, which fits in a single
+// Ethernet frame.
+const maxStatsDPacket = 1432
+
+// maxStatsDTimings is the number of request times sent per flush. Above it,
+// the times are sampled and sent with a sample rate.
+const maxStatsDTimings = 1000
+
+// StatsD pushes request counters and timers to a StatsD or DogStatsD server
+// over UDP, e.g. the Datadog agent or Telegraf. Entries are counted until
+// the next Flush. A nil StatsD ignores everything it is given, so it can be
+// passed around when the emitter is disabled. It is safe for concurrent use.
+type StatsD struct {
+	conn     net.Conn
+	prefix   string // Metric name prefix, e.g. "tailnginx."
+	tags     bool   // DogStatsD tags instead of name suffixes
+	mu       sync.Mutex
+	statuses map[string]uint64 // Requests by status class, e.g. "2xx"
+	bytes    uint64
+	failures uint64
+	timings  []float64 // Request times in milliseconds
+	timed    int       // Request times observed, including dropped ones
+}
+
+// DialStatsD creates an emitter sending to addr, e.g. "127.0.0.1:8125".
+// Metric names start with prefix and a dot, e.g. "tailnginx.requests". With
+// tags, the status class is sent as a DogStatsD tag (requests with
+// #status_class:2xx) instead of in the name (requests.2xx).
+func DialStatsD(addr, prefix string, tags bool) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return &StatsD{
+		conn:     conn,
+		prefix:   prefix,
+		tags:     tags,
+		statuses: make(map[string]uint64),
+	}, nil
+}
+
+// Observe counts a parsed log entry.
+func (s *StatsD) Observe(v *parser.Visitor) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[statusClass(v.Status)]++
+	s.bytes += uint64(max(v.Bytes, 0))
+	if v.RequestTime > 0 {
+		s.timed++
+		ms := float64(v.RequestTime) / float64(time.Millisecond)
+		if len(s.timings) < maxStatsDTimings {
+			s.timings = append(s.timings, ms)
+		} else if i := rand.IntN(s.timed); i < maxStatsDTimings {
+			// Reservoir sampling keeps every time equally likely to be sent
+			s.timings[i] = ms
+		}
+	}
+}
+
+// ParseFailure counts a log line that could not be parsed.
+func (s *StatsD) ParseFailure() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.failures++
+	s.mu.Unlock()
+}
+
+// Run flushes the counts every interval. It never returns.
+func (s *StatsD) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.Flush()
+	}
+}
+
+// Flush sends the counts since the last flush and resets them. Counters
+// that did not change are not sent.
+func (s *StatsD) Flush() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	lines := s.lines()
+	clear(s.statuses)
+	s.bytes, s.failures = 0, 0
+	s.timings, s.timed = s.timings[:0], 0
+	s.mu.Unlock()
+
+	for _, packet := range packets(lines) {
+		if _, err := s.conn.Write([]byte(packet)); err != nil {
+			return fmt.Errorf("statsd: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close sends the remaining counts and closes the connection to the server.
+func (s *StatsD) Close() error {
+	if s == nil {
+		return nil
+	}
+	err := s.Flush()
+	if cerr := s.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// lines formats the counts as StatsD lines. Caller must hold the lock.
+func (s *StatsD) lines() []string {
+	var lines []string
+	classes := make([]string, 0, len(s.statuses))
+	for class := range s.statuses {
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+	for _, class := range classes {
+		if s.tags {
+			lines = append(lines, fmt.Sprintf("%srequests:%d|c|#status_class:%s", s.prefix, s.statuses[class], class))
+		} else {
+			lines = append(lines, fmt.Sprintf("%srequests.%s:%d|c", s.prefix, class, s.statuses[class]))
+		}
+	}
+	if s.bytes > 0 {
+		lines = append(lines, fmt.Sprintf("%sresponse_bytes:%d|c", s.prefix, s.bytes))
+	}
+	if s.failures > 0 {
+		lines = append(lines, fmt.Sprintf("%sparse_failures:%d|c", s.prefix, s.failures))
+	}
+
+	rate := ""
+	if s.timed > len(s.timings) {
+		rate = "|@" + formatFloat(float64(len(s.timings))/float64(s.timed))
+	}
+	for _, ms := range s.timings {
+		lines = append(lines, fmt.Sprintf("%srequest_time:%s|ms%s", s.prefix, formatFloat(ms), rate))
+	}
+	return lines
+}
+
+// packets joins lines with newlines into payloads of at most
+// maxStatsDPacket bytes.
+func packets(lines []string) []string {
+	var packets []string
+	var b strings.Builder
+	for _, line := range lines {
+		if b.Len() > 0 && b.Len()+1+len(line) > maxStatsDPacket {
+			packets = append(packets, b.String())
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line)
+	}
+	if b.Len() > 0 {
+		packets = append(packets, b.String())
+	}
+	return packets
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// listenStatsD returns a UDP server address and a function reading the next
+// packet sent to it.
+func listenStatsD(t *testing.T) (string, func() string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String(), func() string {
+		buf := make([]byte, 64*1024)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("reading packet: %v", err)
+		}
+		return string(buf[:n])
+	}
+}
+
+func TestStatsDFlush(t *testing.T) {
+	addr, read := listenStatsD(t)
+	s, err := DialStatsD(addr, "nginx", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.Observe(&parser.Visitor{Status: 200, Bytes: 100, RequestTime: 120 * time.Millisecond})
+	s.Observe(&parser.Visitor{Status: 204, Bytes: 20})
+	s.Observe(&parser.Visitor{Status: 502})
+	s.ParseFailure()
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := "nginx.requests.2xx:2|c\nnginx.requests.5xx:1|c\nnginx.response_bytes:120|c\nnginx.parse_failures:1|c\nnginx.request_time:120|ms"
+	if got := read(); got != want {
+		t.Errorf("packet =\n%s\nwant\n%s", got, want)
+	}
+
+	// The counts are reset after a flush
+	s.Observe(&parser.Visitor{Status: 404})
+	s.Flush()
+	if got := read(); got != "nginx.requests.4xx:1|c" {
+		t.Errorf("second packet = %q", got)
+	}
+}
+
+func TestStatsDTags(t *testing.T) {
+	addr, read := listenStatsD(t)
+	s, err := DialStatsD(addr, "tailnginx.", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.Observe(&parser.Visitor{Status: 301})
+	s.Flush()
+	if got := read(); got != "tailnginx.requests:1|c|#status_class:3xx" {
+		t.Errorf("packet = %q", got)
+	}
+}
+
+func TestStatsDSampling(t *testing.T) {
+	s := &StatsD{prefix: "t.", statuses: make(map[string]uint64)}
+	for range 2 * maxStatsDTimings {
+		s.Observe(&parser.Visitor{Status: 200, RequestTime: time.Millisecond})
+	}
+	var timers int
+	for _, line := range s.lines() {
+		if strings.HasPrefix(line, "t.request_time:") {
+			timers++
+			if !strings.HasSuffix(line, "|ms|@0.5") {
+				t.Fatalf("timer %q should have a 0.5 sample rate", line)
+			}
+		}
+	}
+	if timers != maxStatsDTimings {
+		t.Errorf("sent %d timers, want %d", timers, maxStatsDTimings)
+	}
+}
+
+func TestPackets(t *testing.T) {
+	line := strings.Repeat("x", 600)
+	got := packets([]string{line, line, line})
+	if len(got) != 2 || got[0] != line+"\n"+line || got[1] != line {
+		t.Errorf("packets() = %d packets, want 2 of at most %d bytes", len(got), maxStatsDPacket)
+	}
+	if got := packets(nil); len(got) != 0 {
+		t.Errorf("packets(nil) = %q, want none", got)
+	}
+}
+
+func TestNilStatsD(t *testing.T) {
+	var s *StatsD
+	s.Observe(&parser.Visitor{Status: 200})
+	s.ParseFailure()
+	if err := s.Flush(); err != nil {
+		t.Errorf("Flush() on nil = %v", err)
+	}
+}
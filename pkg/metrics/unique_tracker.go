@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// UniqueTracker estimates distinct values (e.g. visitor IPs) over time using
+// one HyperLogLog sketch per time bucket, so any window can be counted by
+// merging its buckets.
+type UniqueTracker struct {
+	sketches   map[time.Time]*HyperLogLog // Sketch per bucket start time
+	bucketSize time.Duration              // Duration of each time bucket
+	windowSize int                        // Number of buckets to keep
+	precision  uint8                      // HyperLogLog precision of each sketch
+	latest     time.Time                  // Start of the most recent bucket
+	mu         sync.RWMutex               // Protects all fields above
+}
+
+// NewUniqueTracker creates a new UniqueTracker.
+// bucketSize: duration of each time bucket (e.g., 1 hour)
+// windowSize: number of buckets to keep (e.g., 744 = 31 days with 1h buckets)
+// precision: HyperLogLog precision of each bucket (e.g., 12 = 4KB per bucket)
+func NewUniqueTracker(bucketSize time.Duration, windowSize int, precision uint8) *UniqueTracker {
+	return &UniqueTracker{
+		sketches:   make(map[time.Time]*HyperLogLog),
+		bucketSize: bucketSize,
+		windowSize: windowSize,
+		precision:  precision,
+	}
+}
+
+// Add records a value seen at the given time.
+func (ut *UniqueTracker) Add(t time.Time, value string) {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	bucketTime := t.Truncate(ut.bucketSize)
+	oldest := ut.latest.Add(-time.Duration(ut.windowSize-1) * ut.bucketSize)
+	if !ut.latest.IsZero() && bucketTime.Before(oldest) {
+		return // Too old to be kept
+	}
+
+	sketch, ok := ut.sketches[bucketTime]
+	if !ok {
+		sketch = NewHyperLogLog(ut.precision)
+		ut.sketches[bucketTime] = sketch
+	}
+	sketch.Add(value)
+
+	if bucketTime.After(ut.latest) {
+		ut.latest = bucketTime
+		ut.prune()
+	}
+}
+
+// prune drops buckets that fell out of the window. Caller must hold the lock.
+func (ut *UniqueTracker) prune() {
+	oldest := ut.latest.Add(-time.Duration(ut.windowSize-1) * ut.bucketSize)
+	for start := range ut.sketches {
+		if start.Before(oldest) {
+			delete(ut.sketches, start)
+		}
+	}
+}
+
+// Count returns the estimated number of distinct values seen since the given
+// time. The bucket containing since is included in full. A zero since counts
+// all kept buckets.
+func (ut *UniqueTracker) Count(since time.Time) uint64 {
+	ut.mu.RLock()
+	defer ut.mu.RUnlock()
+
+	from := since.Truncate(ut.bucketSize)
+	merged := NewHyperLogLog(ut.precision)
+	for start, sketch := range ut.sketches {
+		if since.IsZero() || !start.Before(from) {
+			merged.Merge(sketch)
+		}
+	}
+	return merged.Count()
+}
+
+// Buckets returns the estimated distinct values of the last n buckets ending
+// at now, oldest first. Buckets without data count as zero.
+func (ut *UniqueTracker) Buckets(now time.Time, n int) []uint64 {
+	ut.mu.RLock()
+	defer ut.mu.RUnlock()
+
+	counts := make([]uint64, n)
+	end := now.Truncate(ut.bucketSize)
+	for i := 0; i < n; i++ {
+		start := end.Add(-time.Duration(n-1-i) * ut.bucketSize)
+		if sketch, ok := ut.sketches[start]; ok {
+			counts[i] = sketch.Count()
+		}
+	}
+	return counts
+}
+
+// Reset clears all tracking data.
+func (ut *UniqueTracker) Reset() {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	ut.sketches = make(map[time.Time]*HyperLogLog)
+	ut.latest = time.Time{}
+}
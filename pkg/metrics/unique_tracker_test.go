@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHyperLogLogAccuracy(t *testing.T) {
+	for _, n := range []int{10, 1000, 50000} {
+		h := NewHyperLogLog(12)
+		for i := 0; i < n; i++ {
+			ip := fmt.Sprintf("10.%d.%d.%d", i>>16&255, i>>8&255, i&255)
+			h.Add(ip)
+			h.Add(ip) // Duplicates must not count
+		}
+
+		got := float64(h.Count())
+		if errPct := math.Abs(got-float64(n)) / float64(n) * 100; errPct > 5 {
+			t.Errorf("Count() = %.0f for %d distinct values (%.1f%% error)", got, n, errPct)
+		}
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a, b := NewHyperLogLog(12), NewHyperLogLog(12)
+	for i := 0; i < 1000; i++ {
+		a.Add(fmt.Sprintf("a%d", i))
+		b.Add(fmt.Sprintf("b%d", i))
+	}
+	a.Merge(b)
+
+	got := float64(a.Count())
+	if math.Abs(got-2000)/2000 > 0.05 {
+		t.Errorf("Merged Count() = %.0f, want ~2000", got)
+	}
+}
+
+func TestUniqueTrackerCount(t *testing.T) {
+	ut := NewUniqueTracker(time.Hour, 24, 12)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// 100 IPs two hours ago, 50 of the same IPs plus 50 new ones now
+	for i := 0; i < 100; i++ {
+		ut.Add(base.Add(-2*time.Hour), fmt.Sprintf("ip%d", i))
+	}
+	for i := 50; i < 150; i++ {
+		ut.Add(base, fmt.Sprintf("ip%d", i))
+	}
+
+	if got := ut.Count(time.Time{}); got < 145 || got > 155 {
+		t.Errorf("Count(all) = %d, want ~150", got)
+	}
+	if got := ut.Count(base.Add(-30 * time.Minute)); got < 97 || got > 103 {
+		t.Errorf("Count(last 30m) = %d, want ~100", got)
+	}
+}
+
+func TestUniqueTrackerWindow(t *testing.T) {
+	ut := NewUniqueTracker(time.Minute, 5, 10)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	ut.Add(base, "old")
+	ut.Add(base.Add(10*time.Minute), "new")
+
+	if len(ut.sketches) != 1 {
+		t.Errorf("Expected old bucket to be pruned, have %d buckets", len(ut.sketches))
+	}
+
+	// Entries older than the window are ignored
+	ut.Add(base, "too-old")
+	if got := ut.Count(time.Time{}); got != 1 {
+		t.Errorf("Count() = %d, want 1", got)
+	}
+}
+
+func TestUniqueTrackerBuckets(t *testing.T) {
+	ut := NewUniqueTracker(time.Minute, 10, 10)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	ut.Add(base.Add(-2*time.Minute), "a")
+	ut.Add(base, "a")
+	ut.Add(base, "b")
+
+	got := ut.Buckets(base, 3)
+	want := []uint64{1, 0, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Buckets()[%d] = %d, want %d", i, got[i], want[i])
+		}
+	}
+}
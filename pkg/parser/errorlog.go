@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrorEntry represents a parsed nginx error log entry.
+type ErrorEntry struct {
+	Time     time.Time
+	Level    string // debug, info, notice, warn, error, crit, alert or emerg
+	Message  string // Message without the trailing key/value context
+	Client   string
+	Server   string
+	Request  string
+	Upstream string
+}
+
+// errorRegex matches "2025/10/08 12:00:00 [error] 1234#1234: *5 <message>"
+var errorRegex = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[([a-z]+)\] \d+#\d+: (?:\*\d+ )?(.*)$`)
+
+// errorContextRegex matches the ", key: value" context nginx appends to messages
+var errorContextRegex = regexp.MustCompile(`, (client|server|request|upstream|host|referrer): ("[^"]*"|[^,]*)`)
+
+// ParseError parses a line of the nginx error log.
+// Returns nil if the line is not an error log entry (e.g. a continuation line).
+func ParseError(line string) *ErrorEntry {
+	m := errorRegex.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+
+	entry := &ErrorEntry{Level: m[2], Message: m[3]}
+	// nginx writes error log times in the server's local time zone
+	if t, err := time.ParseInLocation("2006/01/02 15:04:05", m[1], time.Local); err == nil {
+		entry.Time = t
+	}
+
+	if loc := errorContextRegex.FindStringIndex(entry.Message); loc != nil {
+		context := entry.Message[loc[0]:]
+		entry.Message = entry.Message[:loc[0]]
+		for _, kv := range errorContextRegex.FindAllStringSubmatch(context, -1) {
+			value := strings.Trim(kv[2], `"`)
+			switch kv[1] {
+			case "client":
+				entry.Client = value
+			case "server":
+				entry.Server = value
+			case "request":
+				entry.Request = value
+			case "upstream":
+				entry.Upstream = value
+			}
+		}
+	}
+	return entry
+}
+
+// IsUpstream reports whether the entry is about a failing upstream
+// (connection refused, timeouts, invalid responses...).
+func (e *ErrorEntry) IsUpstream() bool {
+	return e.Upstream != "" || strings.Contains(e.Message, "upstream")
+}
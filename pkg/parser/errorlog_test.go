@@ -0,0 +1,47 @@
+package parser
+
+import "testing"
+
+func TestParseError(t *testing.T) {
+	line := `2025/10/08 12:00:00 [error] 1234#1234: *5 connect() failed (111: Connection refused) while connecting to upstream, client: 10.0.0.1, server: example.com, request: "GET /api HTTP/1.1", upstream: "http://127.0.0.1:8080/api", host: "example.com"`
+	e := ParseError(line)
+	if e == nil {
+		t.Fatal("expected parse, got nil")
+	}
+	if e.Level != "error" {
+		t.Errorf("Level = %q, want error", e.Level)
+	}
+	if e.Message != "connect() failed (111: Connection refused) while connecting to upstream" {
+		t.Errorf("Message = %q", e.Message)
+	}
+	if e.Client != "10.0.0.1" || e.Server != "example.com" || e.Request != "GET /api HTTP/1.1" || e.Upstream != "http://127.0.0.1:8080/api" {
+		t.Errorf("context = %+v", e)
+	}
+	if e.Time.IsZero() {
+		t.Error("Time not parsed")
+	}
+	if !e.IsUpstream() {
+		t.Error("expected an upstream error")
+	}
+}
+
+func TestParseErrorWithoutContext(t *testing.T) {
+	e := ParseError(`2025/10/08 12:00:00 [notice] 1#1: signal process started`)
+	if e == nil {
+		t.Fatal("expected parse, got nil")
+	}
+	if e.Level != "notice" || e.Message != "signal process started" {
+		t.Errorf("ParseError() = %+v", e)
+	}
+	if e.IsUpstream() {
+		t.Error("expected a non-upstream entry")
+	}
+}
+
+func TestParseErrorInvalid(t *testing.T) {
+	for _, line := range []string{"", "random text", `127.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 1 "-" "-"`} {
+		if e := ParseError(line); e != nil {
+			t.Errorf("ParseError(%q) = %+v, want nil", line, e)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CombinedFormat is nginx's predefined "combined" log_format.
+const CombinedFormat = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`
+
+// CommonFormat is the Common Log Format: the combined format without the
+// referer and user agent.
+const CommonFormat = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent`
+
+// VCombinedFormat is the combined format preceded by the virtual host and
+// port, like Apache's vhost_combined.
+const VCombinedFormat = `$host:$server_port ` + CombinedFormat
+
+// Presets are the names of the predefined formats accepted by NewFormats
+// instead of a log_format definition. "json" is an nginx log_format with
+// escape=json whose keys are variable names, e.g.
+// '{"remote_addr":"$remote_addr","status":"$status",...}', and "caddy" is
+// Caddy's JSON access log.
+var Presets = []string{"combined", "common", "vcombined", "json", "caddy"}
+
+// customPrefix marks a log_format definition, e.g. to log one whose text is
+// a preset name.
+const customPrefix = "custom:"
+
+// variableRegex matches nginx variables such as $status or ${status}.
+var variableRegex = regexp.MustCompile(`\$(?:\{([a-z0-9_]+)\}|([a-z0-9_]+))`)
+
+// variablePatterns are the patterns for variables with a known shape.
+// Other variables match up to the next space (or quote when quoted).
+var variablePatterns = map[string]string{
+	"time_local":      `[^\]]+`,
+	"time_iso8601":    `\S+`,
+	"msec":            `[\d.]+`,
+	"status":          `\d{3}`,
+	"body_bytes_sent": `\d+|-`,
+	"bytes_sent":      `\d+|-`,
+	"request_time":    `[\d.]+|-`,
+}
+
+// Format parses access log lines written with a custom nginx log_format.
+type Format struct {
+	re     *regexp.Regexp
+	fields []string                   // Variable name for each capture group
+	decode func(line string) *Visitor // Parser of JSON formats, instead of re
+	next   *Format                    // Format tried when a line doesn't match, for mixed logs
+}
+
+// NewFormat compiles an nginx log_format definition, e.g.
+// `$remote_addr [$time_local] "$request" $status $ssl_protocol $ssl_cipher`.
+// The format must contain $status and either $request or $request_method
+// and $request_uri (or $uri).
+func NewFormat(logFormat string) (*Format, error) {
+	var pattern strings.Builder
+	var fields []string
+	seen := make(map[string]bool)
+
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range variableRegex.FindAllStringSubmatchIndex(logFormat, -1) {
+		literal := logFormat[last:loc[0]]
+		pattern.WriteString(regexp.QuoteMeta(literal))
+		last = loc[1]
+
+		name := submatch(logFormat, loc, 1) + submatch(logFormat, loc, 2)
+		valuePattern, ok := variablePatterns[name]
+		if !ok {
+			valuePattern = `\S*`
+			if strings.HasSuffix(literal, `"`) {
+				valuePattern = `[^"]*`
+			}
+			if name == "request" {
+				valuePattern = `[^"]*`
+			}
+		}
+
+		// A variable logged twice is only captured once
+		if seen[name] {
+			fmt.Fprintf(&pattern, "(?:%s)", valuePattern)
+			continue
+		}
+		seen[name] = true
+		fmt.Fprintf(&pattern, "(%s)", valuePattern)
+		fields = append(fields, name)
+	}
+	pattern.WriteString(regexp.QuoteMeta(logFormat[last:]))
+
+	if !seen["status"] {
+		return nil, fmt.Errorf("log format %q: missing $status", logFormat)
+	}
+	if !seen["request"] && !(seen["request_method"] && (seen["request_uri"] || seen["uri"])) {
+		return nil, fmt.Errorf("log format %q: missing $request (or $request_method and $request_uri)", logFormat)
+	}
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("log format %q: %w", logFormat, err)
+	}
+	return &Format{re: re, fields: fields}, nil
+}
+
+// NewFormats compiles the formats of logs read together, e.g. several -log
+// files. Each is a preset name (see Presets), a log_format definition, or a
+// definition prefixed with "custom:". Lines are parsed with the first format
+// they match. It returns nil when all the logs are in the combined format.
+func NewFormats(logFormats []string) (*Format, error) {
+	var first, last *Format
+	custom := false
+	for _, logFormat := range logFormats {
+		f, err := newPreset(logFormat)
+		if err != nil {
+			return nil, err
+		}
+		if logFormat != "combined" && logFormat != "" {
+			custom = true
+		}
+		if first == nil {
+			first = f
+		} else {
+			last.next = f
+		}
+		last = f
+	}
+	if !custom {
+		return nil, nil
+	}
+	return first, nil
+}
+
+// newPreset returns the format of a preset name or log_format definition.
+func newPreset(logFormat string) (*Format, error) {
+	switch logFormat {
+	case "combined", "":
+		return NewFormat(CombinedFormat)
+	case "common":
+		return NewFormat(CommonFormat)
+	case "vcombined":
+		return NewFormat(VCombinedFormat)
+	case "json":
+		return &Format{decode: decodeJSON}, nil
+	case "caddy":
+		return &Format{decode: decodeCaddy}, nil
+	}
+	return NewFormat(strings.TrimPrefix(logFormat, customPrefix))
+}
+
+// submatch returns the text of capture group n, or "" if it did not participate.
+func submatch(s string, loc []int, n int) string {
+	if loc[2*n] < 0 {
+		return ""
+	}
+	return s[loc[2*n]:loc[2*n+1]]
+}
+
+// Parse parses a log line into a Visitor.
+// Returns nil if the line doesn't match the format.
+func (f *Format) Parse(line string) *Visitor {
+	for ; f != nil; f = f.next {
+		if v := f.parse(line); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// parse parses a log line with this format only.
+func (f *Format) parse(line string) *Visitor {
+	if f.decode != nil {
+		return f.decode(line)
+	}
+	m := f.re.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+
+	result := &Visitor{}
+	for i, name := range f.fields {
+		setVariable(result, name, m[i+1])
+	}
+	return result
+}
+
+// setVariable sets the field of result logged by an nginx variable.
+func setVariable(result *Visitor, name, val string) {
+	switch name {
+	case "remote_addr":
+		result.IP = val
+	case "time_local":
+		if t, err := time.Parse("02/Jan/2006:15:04:05 -0700", val); err == nil {
+			result.Time = t
+		}
+	case "time_iso8601":
+		if t, err := time.Parse(time.RFC3339, val); err == nil {
+			result.Time = t
+		}
+	case "msec":
+		if secs, err := strconv.ParseFloat(val, 64); err == nil {
+			result.Time = time.UnixMilli(int64(secs * 1000))
+		}
+	case "request":
+		// e.g. "GET /index.html HTTP/1.1"
+		parts := strings.SplitN(val, " ", 3)
+		if len(parts) == 3 {
+			result.Method, result.Path, result.Protocol = parts[0], parts[1], parts[2]
+		}
+	case "request_method":
+		result.Method = val
+	case "request_uri", "uri":
+		if result.Path == "" || name == "request_uri" {
+			result.Path = val
+		}
+	case "server_protocol":
+		result.Protocol = val
+	case "status":
+		if v, err := strconv.Atoi(val); err == nil {
+			result.Status = v
+		}
+	case "body_bytes_sent", "bytes_sent":
+		if result.Bytes == 0 || name == "body_bytes_sent" {
+			result.Bytes, _ = strconv.Atoi(val)
+		}
+	case "http_referer":
+		result.Referer = val
+	case "http_user_agent":
+		result.Agent = val
+	case "host", "server_name":
+		if result.Host == "" || name == "host" {
+			result.Host = optional(val)
+		}
+	case "ssl_protocol":
+		result.TLSProtocol = optional(val)
+	case "ssl_cipher":
+		result.TLSCipher = optional(val)
+	case "upstream_addr":
+		result.Upstream = optional(val)
+	case "request_time":
+		// Seconds with millisecond resolution, e.g. "0.125"
+		if secs, err := strconv.ParseFloat(val, 64); err == nil {
+			result.RequestTime = time.Duration(secs * float64(time.Second))
+		}
+	}
+}
+
+// optional returns val, or "" when nginx logged "-" for an unset variable.
+func optional(val string) string {
+	if val == "-" {
+		return ""
+	}
+	return val
+}
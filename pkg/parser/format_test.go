@@ -0,0 +1,213 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatCombined(t *testing.T) {
+	f, err := NewFormat(CombinedFormat)
+	if err != nil {
+		t.Fatalf("NewFormat(combined) failed: %v", err)
+	}
+
+	line := `127.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET /index.html HTTP/1.1" 200 612 "-" "curl/7.68.0"`
+	got := f.Parse(line)
+	want := Parse(line)
+	if got == nil || *got != *want {
+		t.Errorf("Format.Parse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFormatTLS(t *testing.T) {
+	f, err := NewFormat(CombinedFormat + ` $ssl_protocol $ssl_cipher`)
+	if err != nil {
+		t.Fatalf("NewFormat() failed: %v", err)
+	}
+
+	v := f.Parse(`10.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/2.0" 200 10 "-" "Mozilla/5.0" TLSv1.3 TLS_AES_128_GCM_SHA256`)
+	if v == nil {
+		t.Fatal("expected parse, got nil")
+	}
+	if v.TLSProtocol != "TLSv1.3" || v.TLSCipher != "TLS_AES_128_GCM_SHA256" {
+		t.Errorf("TLS = %q %q, want TLSv1.3 TLS_AES_128_GCM_SHA256", v.TLSProtocol, v.TLSCipher)
+	}
+	if v.Protocol != "HTTP/2.0" || v.Path != "/" {
+		t.Errorf("request = %q %q, want HTTP/2.0 /", v.Protocol, v.Path)
+	}
+
+	// Plain HTTP requests log "-" for TLS variables
+	v = f.Parse(`10.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 10 "-" "Mozilla/5.0" - -`)
+	if v == nil || v.TLSProtocol != "" || v.TLSCipher != "" {
+		t.Errorf("Parse() = %+v, want empty TLS fields", v)
+	}
+}
+
+func TestFormatHost(t *testing.T) {
+	f, err := NewFormat(`$host $server_name ` + CombinedFormat)
+	if err != nil {
+		t.Fatalf("NewFormat() failed: %v", err)
+	}
+	v := f.Parse(`www.example.com example.com 10.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 10 "-" "Mozilla/5.0"`)
+	if v == nil || v.Host != "www.example.com" {
+		t.Errorf("Parse() = %+v, want host www.example.com", v)
+	}
+
+	f, err = NewFormat(`$server_name ` + CombinedFormat)
+	if err != nil {
+		t.Fatalf("NewFormat() failed: %v", err)
+	}
+	v = f.Parse(`example.com 10.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 10 "-" "Mozilla/5.0"`)
+	if v == nil || v.Host != "example.com" {
+		t.Errorf("Parse() = %+v, want host example.com", v)
+	}
+}
+
+func TestFormatRequestTime(t *testing.T) {
+	f, err := NewFormat(CombinedFormat + ` $request_time`)
+	if err != nil {
+		t.Fatalf("NewFormat() failed: %v", err)
+	}
+
+	v := f.Parse(`10.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 10 "-" "Mozilla/5.0" 0.125`)
+	if v == nil {
+		t.Fatal("expected parse, got nil")
+	}
+	if v.RequestTime != 125*time.Millisecond {
+		t.Errorf("RequestTime = %v, want 125ms", v.RequestTime)
+	}
+}
+
+func TestFormatUpstream(t *testing.T) {
+	f, err := NewFormat(CombinedFormat + ` $upstream_addr`)
+	if err != nil {
+		t.Fatalf("NewFormat() failed: %v", err)
+	}
+
+	v := f.Parse(`10.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET /api HTTP/1.1" 502 10 "-" "Mozilla/5.0" 10.0.0.2:8080`)
+	if v == nil || v.Upstream != "10.0.0.2:8080" {
+		t.Errorf("Parse() = %+v, want upstream 10.0.0.2:8080", v)
+	}
+	// Requests served by nginx itself are logged with "-"
+	v = f.Parse(`10.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 10 "-" "Mozilla/5.0" -`)
+	if v == nil || v.Upstream != "" {
+		t.Errorf("Parse() = %+v, want no upstream", v)
+	}
+}
+
+func TestFormatSplitRequest(t *testing.T) {
+	f, err := NewFormat(`${time_iso8601} $remote_addr $request_method $request_uri $server_protocol $status $bytes_sent`)
+	if err != nil {
+		t.Fatalf("NewFormat() failed: %v", err)
+	}
+
+	v := f.Parse(`2025-10-08T12:00:00+00:00 ::1 POST /api?x=1 HTTP/1.1 201 -`)
+	if v == nil {
+		t.Fatal("expected parse, got nil")
+	}
+	want := Visitor{
+		Time:     time.Date(2025, 10, 8, 12, 0, 0, 0, time.UTC),
+		IP:       "::1",
+		Method:   "POST",
+		Path:     "/api?x=1",
+		Protocol: "HTTP/1.1",
+		Status:   201,
+	}
+	if !v.Time.Equal(want.Time) {
+		t.Errorf("Time = %v, want %v", v.Time, want.Time)
+	}
+	v.Time = want.Time
+	if *v != want {
+		t.Errorf("Parse() = %+v, want %+v", *v, want)
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	for _, format := range []string{
+		`$remote_addr "$request"`,
+		`$remote_addr $status`,
+	} {
+		if _, err := NewFormat(format); err == nil {
+			t.Errorf("NewFormat(%q) succeeded, want error", format)
+		}
+	}
+}
+
+func TestFormatNoMatch(t *testing.T) {
+	f, err := NewFormat(CombinedFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := f.Parse("not a log line"); v != nil {
+		t.Errorf("Parse() = %+v, want nil", v)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	f, err := NewFormats([]string{`$remote_addr [$time_iso8601] "$request" $status $request_time`, "combined"})
+	if err != nil {
+		t.Fatalf("NewFormats() failed: %v", err)
+	}
+
+	v := f.Parse(`10.0.0.1 [2025-10-08T12:00:00+00:00] "GET /api HTTP/1.1" 200 0.125`)
+	if v == nil || v.Path != "/api" || v.RequestTime != 125*time.Millisecond {
+		t.Errorf("Parse(custom) = %+v, want /api in 125ms", v)
+	}
+	line := `127.0.0.1 - - [08/Oct/2025:12:00:00 +0000] "GET /index.html HTTP/1.1" 200 612 "-" "curl/7.68.0"`
+	if v := f.Parse(line); v == nil || *v != *Parse(line) {
+		t.Errorf("Parse(combined) = %+v, want %+v", v, Parse(line))
+	}
+	if v := f.Parse("not a log line"); v != nil {
+		t.Errorf("Parse() = %+v, want nil", v)
+	}
+
+	if f, err := NewFormats([]string{"combined", ""}); f != nil || err != nil {
+		t.Errorf("NewFormats(combined) = %v, %v, want nil, nil", f, err)
+	}
+	if _, err := NewFormats([]string{"combined", "$remote_addr"}); err == nil {
+		t.Error("NewFormats() with an invalid format: want error")
+	}
+}
+
+func TestFormatPresets(t *testing.T) {
+	tests := []struct {
+		preset string
+		line   string
+		want   Visitor
+	}{
+		{"common", `192.0.2.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 5`,
+			Visitor{IP: "192.0.2.1", Method: "GET", Path: "/", Protocol: "HTTP/1.1", Status: 200, Bytes: 5}},
+		{"vcombined", `example.com:443 192.0.2.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 5 "-" "curl/8.0"`,
+			Visitor{IP: "192.0.2.1", Method: "GET", Path: "/", Protocol: "HTTP/1.1", Status: 200, Bytes: 5, Referer: "-", Agent: "curl/8.0", Host: "example.com"}},
+		{"json", `{"remote_addr":"192.0.2.1","request_method":"GET","request_uri":"/","status":"200"}`,
+			Visitor{IP: "192.0.2.1", Method: "GET", Path: "/", Status: 200}},
+		{"caddy", `{"request":{"remote_ip":"192.0.2.1","method":"GET","uri":"/","headers":{}},"status":200}`,
+			Visitor{IP: "192.0.2.1", Method: "GET", Path: "/", Status: 200, Referer: "-"}},
+		{"custom:$remote_addr $status \"$request\"", `192.0.2.1 200 "GET / HTTP/1.1"`,
+			Visitor{IP: "192.0.2.1", Method: "GET", Path: "/", Protocol: "HTTP/1.1", Status: 200}},
+	}
+	for _, tt := range tests {
+		f, err := NewFormats([]string{tt.preset})
+		if err != nil {
+			t.Fatalf("NewFormats(%q) error = %v", tt.preset, err)
+		}
+		v := f.Parse(tt.line)
+		if v == nil {
+			t.Errorf("%s: Parse() = nil", tt.preset)
+			continue
+		}
+		v.Time = time.Time{}
+		if *v != tt.want {
+			t.Errorf("%s: Parse() = %+v, want %+v", tt.preset, *v, tt.want)
+		}
+	}
+
+	// Presets can be mixed with definitions, for logs read together
+	f, err := NewFormats([]string{"caddy", "combined"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := f.Parse(`192.0.2.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 5 "-" "curl/8"`); v == nil {
+		t.Error("combined line not parsed after the caddy format")
+	}
+}
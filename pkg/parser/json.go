@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// decodeJSON parses a line logged by an nginx log_format with escape=json
+// whose keys are the names of the logged variables, e.g.
+// {"remote_addr":"192.0.2.1","time_iso8601":"...","request":"GET / HTTP/1.1","status":200}.
+// Numbers may be logged unquoted. It returns nil for lines that are not JSON
+// objects or lack the status or request.
+func decodeJSON(line string) *Visitor {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return nil
+	}
+
+	v := &Visitor{}
+	for name, value := range fields {
+		switch value := value.(type) {
+		case string:
+			setVariable(v, name, value)
+		case float64:
+			setVariable(v, name, strconv.FormatFloat(value, 'f', -1, 64))
+		}
+	}
+	if v.Status == 0 || v.Method == "" || v.Path == "" {
+		return nil
+	}
+	return v
+}
+
+// caddyEntry is the part of a Caddy access log entry that is parsed.
+type caddyEntry struct {
+	TS      json.RawMessage `json:"ts"` // Unix seconds, or a time with another time_format
+	Request struct {
+		RemoteIP string              `json:"remote_ip"`
+		ClientIP string              `json:"client_ip"` // Set when trusted proxies are configured
+		Proto    string              `json:"proto"`
+		Method   string              `json:"method"`
+		Host     string              `json:"host"`
+		URI      string              `json:"uri"`
+		Headers  map[string][]string `json:"headers"`
+		TLS      *struct {
+			Version     uint16 `json:"version"`
+			CipherSuite uint16 `json:"cipher_suite"`
+		} `json:"tls"`
+	} `json:"request"`
+	Duration float64 `json:"duration"` // Seconds
+	Size     int     `json:"size"`
+	Status   int     `json:"status"`
+}
+
+// decodeCaddy parses a line of a Caddy JSON access log. It returns nil for
+// lines that are not access log entries.
+func decodeCaddy(line string) *Visitor {
+	var e caddyEntry
+	if err := json.Unmarshal([]byte(line), &e); err != nil || e.Status == 0 || e.Request.Method == "" {
+		return nil
+	}
+
+	v := &Visitor{
+		Time:        caddyTime(e.TS),
+		IP:          e.Request.ClientIP,
+		Method:      e.Request.Method,
+		Path:        e.Request.URI,
+		Protocol:    e.Request.Proto,
+		Status:      e.Status,
+		Bytes:       e.Size,
+		Host:        e.Request.Host,
+		RequestTime: time.Duration(e.Duration * float64(time.Second)),
+		Referer:     "-",
+	}
+	if v.IP == "" {
+		v.IP = e.Request.RemoteIP
+	}
+	if referer := e.Request.Headers["Referer"]; len(referer) > 0 {
+		v.Referer = referer[0]
+	}
+	if agent := e.Request.Headers["User-Agent"]; len(agent) > 0 {
+		v.Agent = agent[0]
+	}
+	if t := e.Request.TLS; t != nil && t.Version != 0 {
+		// As nginx logs them, e.g. TLSv1.3
+		v.TLSProtocol = strings.Replace(tls.VersionName(t.Version), "TLS ", "TLSv", 1)
+		v.TLSCipher = tls.CipherSuiteName(t.CipherSuite)
+	}
+	return v
+}
+
+// caddyTimeLayouts are the layouts of the ts of Caddy entries logged with
+// time_format rfc3339 or iso8601.
+var caddyTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"}
+
+// caddyTime parses the ts of a Caddy entry: Unix seconds by default, or a
+// time with time_format rfc3339 or iso8601.
+func caddyTime(ts json.RawMessage) time.Time {
+	var secs float64
+	if err := json.Unmarshal(ts, &secs); err == nil {
+		return time.UnixMilli(int64(secs * 1000))
+	}
+	var text string
+	if err := json.Unmarshal(ts, &text); err != nil {
+		return time.Time{}
+	}
+	for _, layout := range caddyTimeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	v := decodeJSON(`{"remote_addr":"192.0.2.1","time_iso8601":"2025-10-08T12:00:00+00:00","request":"GET /shop?q=1 HTTP/2.0","status":404,"body_bytes_sent":"153","http_referer":"","http_user_agent":"curl/8.0","request_time":0.012,"host":"example.com"}`)
+	if v == nil {
+		t.Fatal("decodeJSON() = nil")
+	}
+	want := Visitor{
+		Time:        time.Date(2025, 10, 8, 12, 0, 0, 0, time.UTC),
+		IP:          "192.0.2.1",
+		Method:      "GET",
+		Path:        "/shop?q=1",
+		Protocol:    "HTTP/2.0",
+		Status:      404,
+		Bytes:       153,
+		Agent:       "curl/8.0",
+		Host:        "example.com",
+		RequestTime: 12 * time.Millisecond,
+	}
+	if !v.Time.Equal(want.Time) {
+		t.Errorf("Time = %v, want %v", v.Time, want.Time)
+	}
+	v.Time = want.Time
+	if *v != want {
+		t.Errorf("decodeJSON() = %+v, want %+v", *v, want)
+	}
+
+	for _, line := range []string{
+		`192.0.2.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 5 "-" "curl/8"`,
+		`{"remote_addr":"192.0.2.1","status":200}`,
+		`["not", "an", "object"]`,
+	} {
+		if v := decodeJSON(line); v != nil {
+			t.Errorf("decodeJSON(%q) = %+v, want nil", line, v)
+		}
+	}
+}
+
+func TestDecodeCaddy(t *testing.T) {
+	line := `{"level":"info","ts":1759924800.25,"logger":"http.log.access.log0","msg":"handled request","request":{"remote_ip":"10.0.0.2","remote_port":"41342","client_ip":"203.0.113.9","proto":"HTTP/2.0","method":"GET","host":"example.com","uri":"/","headers":{"User-Agent":["curl/8.0"],"Referer":["https://example.org/"]},"tls":{"resumed":false,"version":772,"cipher_suite":4865,"proto":"h2","server_name":"example.com"}},"bytes_read":0,"user_id":"","duration":0.5,"size":10900,"status":200,"resp_headers":{"Server":["Caddy"]}}`
+	v := decodeCaddy(line)
+	if v == nil {
+		t.Fatal("decodeCaddy() = nil")
+	}
+	want := Visitor{
+		Time:        time.UnixMilli(1759924800250),
+		IP:          "203.0.113.9",
+		Method:      "GET",
+		Path:        "/",
+		Protocol:    "HTTP/2.0",
+		Referer:     "https://example.org/",
+		Agent:       "curl/8.0",
+		Status:      200,
+		Bytes:       10900,
+		Host:        "example.com",
+		TLSProtocol: "TLSv1.3",
+		TLSCipher:   "TLS_AES_128_GCM_SHA256",
+		RequestTime: 500 * time.Millisecond,
+	}
+	if *v != want {
+		t.Errorf("decodeCaddy() = %+v, want %+v", *v, want)
+	}
+
+	// Without trusted proxies, no referer and with time_format iso8601
+	v = decodeCaddy(`{"ts":"2025-10-08T12:00:00.000+0200","request":{"remote_ip":"10.0.0.2","proto":"HTTP/1.1","method":"POST","uri":"/api","headers":{}},"size":2,"status":201}`)
+	if v == nil || v.IP != "10.0.0.2" || v.Referer != "-" || !v.Time.Equal(time.Date(2025, 10, 8, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("decodeCaddy() = %+v, want remote_ip, no referer and the iso8601 time", v)
+	}
+
+	if v := decodeCaddy(`{"level":"info","ts":1759924800.25,"logger":"tls","msg":"certificate obtained"}`); v != nil {
+		t.Errorf("decodeCaddy() = %+v for a non-access entry, want nil", v)
+	}
+}
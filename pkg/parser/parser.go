@@ -19,6 +19,13 @@ type Visitor struct {
 	Country  string // Added by geoip lookup, not from log
 	Status   int
 	Bytes    int
+
+	// Only set when logged with a custom log format (see NewFormat)
+	Host        string        // $host or $server_name, e.g. "example.com"
+	TLSProtocol string        // $ssl_protocol, e.g. "TLSv1.3"
+	TLSCipher   string        // $ssl_cipher
+	RequestTime time.Duration // $request_time, 0 when not logged
+	Upstream    string        // $upstream_addr, e.g. "10.0.0.2:8080", "" when not proxied
 }
 
 // combinedRegex matches the nginx combined log format
@@ -0,0 +1,221 @@
+// Package ratelimit finds the client IPs whose request rates exceed a
+// threshold and suggests nginx limit_req settings to throttle them.
+package ratelimit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Report settings
+const (
+	keptFor       = time.Hour   // How long IPs are remembered after their last request
+	pruneInterval = time.Minute // Interval between removals of IPs no longer remembered
+	zoneName      = "tailnginx"
+	statesPerMB   = 16000 // $binary_remote_addr states in a megabyte of zone, per the nginx docs
+)
+
+// Candidate is an IP whose request rate exceeded the threshold.
+type Candidate struct {
+	IP       string    `json:"ip"`
+	Rate     float64   `json:"rate"`     // Peak requests per second over a window
+	Requests int       `json:"requests"` // Requests of the peak window
+	Burst    int       `json:"burst"`    // Most requests in one second
+	Time     time.Time `json:"time"`     // When the rate last exceeded the threshold
+}
+
+// Suggestion are limit_req settings throttling the candidates and not the
+// other IPs.
+type Suggestion struct {
+	Rate   string // limit_req_zone rate, e.g. "10r/s" or "30r/m"
+	Burst  int    // limit_req burst, the largest one-second burst of the other IPs
+	ZoneMB int    // Size of the zone for the IPs seen, with headroom
+	IPs    int    // IPs seen in the last hour
+}
+
+// ipRate is the activity of an IP.
+type ipRate struct {
+	window    time.Time // Start of the current window
+	requests  int       // Requests in the current window
+	second    time.Time
+	burst     int // Requests in the current second
+	peak      int // Most requests in a window
+	peakBurst int // Most requests in a second
+	seen      time.Time
+	exceeded  time.Time // Last time the rate exceeded the threshold, zero if never
+}
+
+// Tracker computes the request rate of every IP over fixed windows of log
+// time. A nil Tracker ignores everything it is given. It is safe for
+// concurrent use.
+type Tracker struct {
+	mu        sync.Mutex
+	rps       float64
+	window    time.Duration
+	ips       map[string]*ipRate
+	lastPrune time.Time
+}
+
+// NewTracker creates a tracker of the IPs making more than rps requests per
+// second on average over a window.
+func NewTracker(rps float64, window time.Duration) (*Tracker, error) {
+	if rps <= 0 {
+		return nil, errors.New("ratelimit: rate must be positive")
+	}
+	if window < time.Second {
+		return nil, errors.New("ratelimit: window must be at least 1s")
+	}
+	return &Tracker{rps: rps, window: window, ips: make(map[string]*ipRate)}, nil
+}
+
+// Observe counts a parsed log entry at its log time.
+func (t *Tracker) Observe(v *parser.Visitor) {
+	if t == nil || v.IP == "" || v.Time.IsZero() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := t.ips[v.IP]
+	if r == nil {
+		r = &ipRate{}
+		t.ips[v.IP] = r
+	}
+	if window := v.Time.Truncate(t.window); window.After(r.window) {
+		r.window, r.requests = window, 0
+	}
+	if second := v.Time.Truncate(time.Second); second.After(r.second) {
+		r.second, r.burst = second, 0
+	}
+	r.requests++
+	r.burst++
+	r.peak = max(r.peak, r.requests)
+	r.peakBurst = max(r.peakBurst, r.burst)
+	if v.Time.After(r.seen) {
+		r.seen = v.Time
+	}
+	if float64(r.requests) > t.rps*t.window.Seconds() {
+		r.exceeded = r.seen
+	}
+
+	if v.Time.Sub(t.lastPrune) >= pruneInterval {
+		t.lastPrune = v.Time
+		for ip, r := range t.ips {
+			if v.Time.Sub(r.seen) >= keptFor {
+				delete(t.ips, ip)
+			}
+		}
+	}
+}
+
+// Candidates returns the IPs that exceeded the threshold, highest rate
+// first, and the limit_req settings suggested for them.
+func (t *Tracker) Candidates() ([]Candidate, Suggestion) {
+	if t == nil {
+		return nil, Suggestion{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var candidates []Candidate
+	burst := 1
+	for ip, r := range t.ips {
+		if r.exceeded.IsZero() {
+			burst = max(burst, r.peakBurst)
+			continue
+		}
+		if r.seen.Sub(r.exceeded) >= keptFor {
+			continue // Well behaved for an hour
+		}
+		candidates = append(candidates, Candidate{
+			IP:       ip,
+			Rate:     float64(r.peak) / t.window.Seconds(),
+			Requests: r.peak,
+			Burst:    r.peakBurst,
+			Time:     r.exceeded,
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Rate != candidates[j].Rate {
+			return candidates[i].Rate > candidates[j].Rate
+		}
+		return candidates[i].IP < candidates[j].IP
+	})
+	return candidates, Suggestion{
+		Rate:   formatRate(t.rps),
+		Burst:  burst,
+		ZoneMB: max(1, int(math.Ceil(float64(2*len(t.ips))/statesPerMB))),
+		IPs:    len(t.ips),
+	}
+}
+
+// formatRate formats a rate in requests per second as nginx expects it:
+// whole requests per second, or per minute below one per second.
+func formatRate(rps float64) string {
+	if rps < 1 {
+		return fmt.Sprintf("%dr/m", int(math.Ceil(rps*60)))
+	}
+	return fmt.Sprintf("%dr/s", int(math.Ceil(rps)))
+}
+
+// WriteReport writes the candidates as comments of an nginx snippet
+// defining the suggested limit_req zone, to include in the http block. The
+// limit_req directives to add to the server or location blocks to protect
+// are commented out.
+func (t *Tracker) WriteReport(w io.Writer, now time.Time) error {
+	candidates, s := t.Candidates()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Generated by tailnginx on %s: %d IPs above %s over %s in the last hour\n",
+		now.Format(time.RFC1123), len(candidates), formatRate(t.rps), t.window)
+	for _, c := range candidates {
+		fmt.Fprintf(bw, "# %s: %.1f r/s over %s (%d requests), up to %d requests in one second, last %s\n",
+			c.IP, c.Rate, t.window, c.Requests, c.Burst, c.Time.Format(time.DateTime))
+	}
+	fmt.Fprintf(bw, "\n# Zone for the %d IPs seen in the last hour\n", s.IPs)
+	fmt.Fprintf(bw, "limit_req_zone $binary_remote_addr zone=%s:%dm rate=%s;\n", zoneName, s.ZoneMB, s.Rate)
+	fmt.Fprintf(bw, "\n# In the server or location blocks to protect; the burst covers the largest\n")
+	fmt.Fprintf(bw, "# one-second burst of the IPs under the threshold:\n")
+	fmt.Fprintf(bw, "# limit_req zone=%s burst=%d nodelay;\n", zoneName, s.Burst)
+	fmt.Fprintf(bw, "# limit_req_status 429;\n")
+	return bw.Flush()
+}
+
+// SaveReport writes the report to path. The file is replaced atomically,
+// so that nginx never reads it half written.
+func (t *Tracker) SaveReport(path string, now time.Time) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	err = t.WriteReport(f, now)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Run saves the report to path every interval and passes the errors to
+// report. It never returns.
+func (t *Tracker) Run(path string, interval time.Duration, report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if err := t.SaveReport(path, now); err != nil {
+			report(fmt.Errorf("ratelimit: %w", err))
+		}
+	}
+}
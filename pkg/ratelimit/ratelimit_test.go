@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestTrackerCandidates(t *testing.T) {
+	tr, err := NewTracker(2, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+
+	// 30 requests in 10s, 15 of them in one second
+	for i := 0; i < 30; i++ {
+		at := start.Add(time.Duration(i%16) * time.Second / 2)
+		if i < 15 {
+			at = start.Add(3 * time.Second)
+		}
+		tr.Observe(&parser.Visitor{IP: "203.0.113.7", Time: at})
+	}
+	// 2 req/s is not above the threshold, with bursts of 4
+	for i := 0; i < 20; i++ {
+		tr.Observe(&parser.Visitor{IP: "198.51.100.2", Time: start.Add(time.Duration(i/4) * 2 * time.Second)})
+	}
+
+	candidates, s := tr.Candidates()
+	if len(candidates) != 1 {
+		t.Fatalf("Candidates() = %+v, want one", candidates)
+	}
+	if c := candidates[0]; c.IP != "203.0.113.7" || c.Requests != 30 || c.Rate != 3 || c.Burst < 15 {
+		t.Errorf("Candidates() = %+v", c)
+	}
+	if s.Rate != "2r/s" || s.Burst != 4 || s.ZoneMB != 1 || s.IPs != 2 {
+		t.Errorf("Suggestion = %+v", s)
+	}
+
+	// Counts start anew every window
+	tr.Observe(&parser.Visitor{IP: "198.51.100.2", Time: start.Add(time.Minute)})
+	if candidates, _ := tr.Candidates(); len(candidates) != 1 {
+		t.Errorf("Candidates() = %+v, want the same candidate", candidates)
+	}
+
+	// Idle IPs are forgotten after an hour
+	tr.Observe(&parser.Visitor{IP: "192.0.2.1", Time: start.Add(2 * time.Hour)})
+	if candidates, s := tr.Candidates(); len(candidates) != 0 || s.IPs != 1 {
+		t.Errorf("Candidates() = %+v, %+v, want the idle IPs forgotten", candidates, s)
+	}
+}
+
+func TestNewTracker(t *testing.T) {
+	if _, err := NewTracker(0, time.Minute); err == nil {
+		t.Error("NewTracker() with no rate should fail")
+	}
+	if _, err := NewTracker(10, time.Millisecond); err == nil {
+		t.Error("NewTracker() with a window under a second should fail")
+	}
+	var nilTracker *Tracker
+	nilTracker.Observe(&parser.Visitor{IP: "192.0.2.1", Time: time.Now()})
+	if candidates, _ := nilTracker.Candidates(); candidates != nil {
+		t.Error("nil Tracker should have no candidates")
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	tests := map[float64]string{10: "10r/s", 2.5: "3r/s", 0.5: "30r/m", 0.01: "1r/m"}
+	for rps, want := range tests {
+		if got := formatRate(rps); got != want {
+			t.Errorf("formatRate(%v) = %q, want %q", rps, got, want)
+		}
+	}
+}
+
+func TestSaveReport(t *testing.T) {
+	tr, err := NewTracker(1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 90; i++ {
+		tr.Observe(&parser.Visitor{IP: "203.0.113.7", Time: start.Add(time.Duration(i) * 500 * time.Millisecond)})
+	}
+
+	path := filepath.Join(t.TempDir(), "ratelimit.conf")
+	if err := tr.SaveReport(path, start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"1 IPs above 1r/s over 1m0s",
+		"# 203.0.113.7: 1.5 r/s over 1m0s (90 requests), up to 2 requests in one second, last 2025-10-10 12:00:44\n",
+		"\nlimit_req_zone $binary_remote_addr zone=tailnginx:1m rate=1r/s;\n",
+		"# limit_req zone=tailnginx burst=1 nodelay;\n",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("report = %q, want it to contain %q", b, want)
+		}
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("SaveReport() should not leave the temporary file behind")
+	}
+}
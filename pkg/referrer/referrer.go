@@ -0,0 +1,126 @@
+// Package referrer classifies HTTP referers into traffic sources.
+package referrer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Source is the kind of site a visitor came from.
+type Source string
+
+// Traffic sources
+const (
+	SourceDirect Source = "Direct" // No referer
+	SourceSearch Source = "Search"
+	SourceSocial Source = "Social"
+	SourceOther  Source = "Other"
+)
+
+// searchEngines are matched against the referer domain and its parents.
+var searchEngines = map[string]bool{
+	"google.com":       true,
+	"bing.com":         true,
+	"duckduckgo.com":   true,
+	"yahoo.com":        true,
+	"yandex.ru":        true,
+	"yandex.com":       true,
+	"baidu.com":        true,
+	"ecosia.org":       true,
+	"qwant.com":        true,
+	"startpage.com":    true,
+	"search.brave.com": true,
+	"naver.com":        true,
+	"kagi.com":         true,
+	"perplexity.ai":    true,
+}
+
+// socialNetworks are matched against the referer domain and its parents.
+var socialNetworks = map[string]bool{
+	"facebook.com":         true,
+	"fb.com":               true,
+	"instagram.com":        true,
+	"twitter.com":          true,
+	"x.com":                true,
+	"t.co":                 true,
+	"linkedin.com":         true,
+	"lnkd.in":              true,
+	"reddit.com":           true,
+	"youtube.com":          true,
+	"tiktok.com":           true,
+	"pinterest.com":        true,
+	"mastodon.social":      true,
+	"bsky.app":             true,
+	"news.ycombinator.com": true,
+	"t.me":                 true,
+	"vk.com":               true,
+}
+
+// Classify returns the traffic source and the domain of a referer.
+// The domain is lowercased without "www." and empty for direct traffic.
+func Classify(referer string) (Source, string) {
+	if referer == "" || referer == "-" {
+		return SourceDirect, ""
+	}
+
+	domain := Domain(referer)
+	if domain == "" {
+		return SourceOther, referer
+	}
+
+	// Match the domain and its parents, e.g. "m.facebook.com" -> "facebook.com".
+	// Country variants such as "google.co.uk" match by their second-level name.
+	for d := domain; d != ""; {
+		if searchEngines[d] || searchEngines[genericTLD(d)] {
+			return SourceSearch, domain
+		}
+		if socialNetworks[d] || socialNetworks[genericTLD(d)] {
+			return SourceSocial, domain
+		}
+		_, parent, found := strings.Cut(d, ".")
+		if !found {
+			break
+		}
+		d = parent
+	}
+	return SourceOther, domain
+}
+
+// Domain returns the lowercased host of a referer URL without "www.",
+// or "" if it cannot be parsed.
+func Domain(referer string) string {
+	if !strings.Contains(referer, "://") {
+		referer = "http://" + referer
+	}
+	u, err := url.Parse(referer)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// countryVariants are the names of the lists that also have country
+// domains, e.g. "google.de" or "yahoo.co.jp".
+var countryVariants = map[string]bool{
+	"google": true,
+	"bing":   true,
+	"yahoo":  true,
+	"yandex": true,
+}
+
+// genericTLD rewrites country variants like "google.co.uk" or "google.de" to
+// "google.com" so they match the lists. Other names are left alone, so that
+// e.g. "x.io" is not taken for "x.com".
+func genericTLD(domain string) string {
+	labels := strings.Split(domain, ".")
+	if !countryVariants[labels[0]] {
+		return domain
+	}
+	switch {
+	case len(labels) == 3 && (labels[1] == "co" || labels[1] == "com") && len(labels[2]) == 2:
+		return labels[0] + ".com"
+	case len(labels) == 2 && len(labels[1]) == 2:
+		return labels[0] + ".com"
+	}
+	return domain
+}
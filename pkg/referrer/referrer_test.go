@@ -0,0 +1,49 @@
+package referrer
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		referer    string
+		wantSource Source
+		wantDomain string
+	}{
+		{"-", SourceDirect, ""},
+		{"", SourceDirect, ""},
+		{"https://www.google.com/search?q=nginx", SourceSearch, "google.com"},
+		{"https://www.google.co.uk/", SourceSearch, "google.co.uk"},
+		{"https://www.google.de/", SourceSearch, "google.de"},
+		{"https://duckduckgo.com/", SourceSearch, "duckduckgo.com"},
+		{"https://search.yahoo.co.jp/search?p=nginx", SourceSearch, "search.yahoo.co.jp"},
+		{"https://m.facebook.com/story.php", SourceSocial, "m.facebook.com"},
+		{"https://t.co/abc123", SourceSocial, "t.co"},
+		{"https://news.ycombinator.com/item?id=1", SourceSocial, "news.ycombinator.com"},
+		{"https://old.reddit.com/r/golang", SourceSocial, "old.reddit.com"},
+		{"https://blog.example.com/post", SourceOther, "blog.example.com"},
+		{"https://x.io/a", SourceOther, "x.io"},
+		{"https://reddit.co.uk/", SourceOther, "reddit.co.uk"},
+		{"HTTPS://WWW.Example.ORG", SourceOther, "example.org"},
+		{"android-app://com.google.android.gm/", SourceOther, "com.google.android.gm"},
+	}
+
+	for _, tt := range tests {
+		source, domain := Classify(tt.referer)
+		if source != tt.wantSource || domain != tt.wantDomain {
+			t.Errorf("Classify(%q) = %s, %q; want %s, %q", tt.referer, source, domain, tt.wantSource, tt.wantDomain)
+		}
+	}
+}
+
+func TestDomain(t *testing.T) {
+	tests := map[string]string{
+		"https://www.example.com/path": "example.com",
+		"example.com/path":             "example.com",
+		"http://Example.com:8080/":     "example.com",
+		"http://[::1]:8080/":           "::1",
+	}
+	for in, want := range tests {
+		if got := Domain(in); got != want {
+			t.Errorf("Domain(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
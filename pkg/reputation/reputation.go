@@ -0,0 +1,238 @@
+// Package reputation scores client IPs with threat intelligence: offline
+// blocklists such as FireHOL's and the AbuseIPDB API.
+package reputation
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lookup settings
+const (
+	abuseIPDBURL   = "https://api.abuseipdb.com/api/v2/check"
+	maxAgeDays     = 90               // Age of the AbuseIPDB reports counted
+	cacheFor       = 24 * time.Hour   // How long scores are kept, the free plan allows 1000 checks a day
+	retryAfter     = 15 * time.Minute // How long failed lookups wait before being tried again
+	requestTimeout = 10 * time.Second
+	queueSize      = 64 // Lookups waiting, further ones are dropped until the next render
+)
+
+// Reputation is what threat intelligence knows about an IP.
+type Reputation struct {
+	Score   int      `json:"score"`             // AbuseIPDB abuse confidence from 0 to 100, -1 if unknown
+	Reports int      `json:"reports,omitempty"` // AbuseIPDB reports of the last 90 days
+	Lists   []string `json:"lists,omitempty"`   // Blocklists listing the IP
+}
+
+// Blocklist is a list of IPs and networks, e.g. FireHOL's firehol_level1.
+type Blocklist struct {
+	Name     string
+	prefixes []netip.Prefix
+}
+
+// LoadBlocklist reads a blocklist of one IP or CIDR network per line, with
+// "#" or ";" comments, as in the FireHOL .netset and .ipset files. It is
+// named after its file, without the extension.
+func LoadBlocklist(path string) (*Blocklist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("blocklist: %w", err)
+	}
+	defer f.Close()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	b := &Blocklist{Name: name}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line, _, _ = strings.Cut(line, ";")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		prefix, err := parsePrefix(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("blocklist %s:%d: %w", path, n, err)
+		}
+		b.prefixes = append(b.prefixes, prefix)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("blocklist %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// parsePrefix parses an IP, as a network of that IP alone, or a CIDR
+// network.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Contains reports whether the list holds an IP.
+func (b *Blocklist) Contains(addr netip.Addr) bool {
+	for _, prefix := range b.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of IPs and networks of the list.
+func (b *Blocklist) Len() int {
+	return len(b.prefixes)
+}
+
+// cached is a score and when it stops being used.
+type cached struct {
+	score   int
+	reports int
+	expires time.Time
+}
+
+// Checker looks IPs up in the blocklists and, with an API key, in
+// AbuseIPDB. API lookups are queued by Lookup and made by Run, so that slow
+// requests never hold up the caller, and cached for a day. A nil Checker
+// knows nothing. It is safe for concurrent use.
+type Checker struct {
+	key    string
+	url    string
+	client *http.Client
+	lists  []*Blocklist
+	mu     sync.Mutex
+	cache  map[string]cached
+	queued map[string]bool
+	queue  chan string
+	now    func() time.Time
+}
+
+// NewChecker returns a checker of the blocklists and, if key is not empty,
+// of AbuseIPDB with that API key.
+func NewChecker(key string, lists []*Blocklist) *Checker {
+	return &Checker{
+		key:    key,
+		url:    abuseIPDBURL,
+		client: &http.Client{Timeout: requestTimeout},
+		lists:  lists,
+		cache:  make(map[string]cached),
+		queued: make(map[string]bool),
+		queue:  make(chan string, queueSize),
+		now:    time.Now,
+	}
+}
+
+// Lookup returns the reputation of an IP. Its score is -1 until AbuseIPDB
+// answered, the lookup being queued meanwhile, and for private addresses.
+func (c *Checker) Lookup(ip string) Reputation {
+	r := Reputation{Score: -1}
+	addr, err := netip.ParseAddr(ip)
+	if c == nil || err != nil {
+		return r
+	}
+	addr = addr.Unmap()
+	for _, list := range c.lists {
+		if list.Contains(addr) {
+			r.Lists = append(r.Lists, list.Name)
+		}
+	}
+	if c.key == "" || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return r
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.cache[ip]; ok && c.now().Before(entry.expires) {
+		r.Score, r.Reports = entry.score, entry.reports
+		return r
+	}
+	if !c.queued[ip] {
+		select {
+		case c.queue <- ip:
+			c.queued[ip] = true
+		default:
+		}
+	}
+	return r
+}
+
+// Run makes the queued AbuseIPDB lookups and passes their errors to
+// report. It never returns.
+func (c *Checker) Run(report func(error)) {
+	for ip := range c.queue {
+		score, reports, err := c.check(ip)
+		expires := c.now().Add(cacheFor)
+		if err != nil {
+			score, reports, expires = -1, 0, c.now().Add(retryAfter)
+			report(err)
+		}
+		c.mu.Lock()
+		c.cache[ip] = cached{score: score, reports: reports, expires: expires}
+		delete(c.queued, ip)
+		for ip, entry := range c.cache {
+			if !c.now().Before(entry.expires) {
+				delete(c.cache, ip)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// abuseIPDBResponse is the answer of the AbuseIPDB check endpoint.
+type abuseIPDBResponse struct {
+	Data struct {
+		Score   int `json:"abuseConfidenceScore"`
+		Reports int `json:"totalReports"`
+	} `json:"data"`
+	Errors []struct {
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// check asks AbuseIPDB for the abuse confidence score and the reports of an
+// IP.
+func (c *Checker) check(ip string) (score, reports int, err error) {
+	query := url.Values{"ipAddress": {ip}, "maxAgeInDays": {fmt.Sprint(maxAgeDays)}}
+	req, err := http.NewRequest(http.MethodGet, c.url+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("abuseipdb: %w", err)
+	}
+	req.Header.Set("Key", c.key)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("abuseipdb: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body abuseIPDBResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, 0, fmt.Errorf("abuseipdb: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		err := errors.New(resp.Status)
+		if len(body.Errors) > 0 {
+			err = fmt.Errorf("%s: %s", resp.Status, body.Errors[0].Detail)
+		}
+		return 0, 0, fmt.Errorf("abuseipdb: %w", err)
+	}
+	return body.Data.Score, body.Data.Reports, nil
+}
@@ -0,0 +1,124 @@
+package reputation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeList(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	path := writeList(t, "firehol_level1.netset", "#\n# firehol_level1\n#\n203.0.113.0/24\n198.51.100.7 ; single IP\n2001:db8::/32\n\n")
+	b, err := LoadBlocklist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name != "firehol_level1" || b.Len() != 3 {
+		t.Errorf("LoadBlocklist() = %s with %d entries", b.Name, b.Len())
+	}
+	for ip, want := range map[string]bool{
+		"203.0.113.42":  true,
+		"198.51.100.7":  true,
+		"198.51.100.8":  false,
+		"2001:db8::1":   true,
+		"2001:db9::1":   false,
+		"192.0.2.1":     false,
+		"203.0.114.255": false,
+	} {
+		if got := b.Contains(netip.MustParseAddr(ip)); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	if _, err := LoadBlocklist(writeList(t, "bad.ipset", "203.0.113.0/24\nnot-an-ip\n")); err == nil {
+		t.Error("LoadBlocklist() should fail on an invalid line")
+	}
+	if _, err := LoadBlocklist(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadBlocklist() should fail on a missing file")
+	}
+}
+
+func TestChecker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Key") != "K3Y" || r.URL.Query().Get("maxAgeInDays") != "90" {
+			t.Errorf("request %s with key %q", r.URL, r.Header.Get("Key"))
+		}
+		switch r.URL.Query().Get("ipAddress") {
+		case "203.0.113.7":
+			w.Write([]byte(`{"data": {"ipAddress": "203.0.113.7", "abuseConfidenceScore": 87, "totalReports": 42}}`))
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"errors": [{"detail": "Daily rate limit of 1000 requests exceeded for this endpoint."}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	list, err := LoadBlocklist(writeList(t, "spamhaus_drop.netset", "203.0.113.0/24\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewChecker("K3Y", []*Blocklist{list})
+	c.url = srv.URL
+	var errs []error
+	go c.Run(func(err error) { errs = append(errs, err) })
+
+	// Listed at once, scored once AbuseIPDB answered
+	r := c.Lookup("203.0.113.7")
+	if r.Score != -1 || len(r.Lists) != 1 || r.Lists[0] != "spamhaus_drop" {
+		t.Errorf("Lookup() = %+v, want listed and pending", r)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Score == -1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		r = c.Lookup("203.0.113.7")
+	}
+	if r.Score != 87 || r.Reports != 42 {
+		t.Errorf("Lookup() = %+v, want the AbuseIPDB score", r)
+	}
+	c.Lookup("203.0.113.7")
+	if n := calls.Load(); n != 1 {
+		t.Errorf("AbuseIPDB called %d times, want the score cached", n)
+	}
+
+	// Private addresses are never sent
+	if r := c.Lookup("10.0.0.1"); r.Score != -1 || len(r.Lists) != 0 {
+		t.Errorf("Lookup() = %+v for a private address", r)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("AbuseIPDB called %d times, want private addresses skipped", n)
+	}
+
+	var nilChecker *Checker
+	if r := nilChecker.Lookup("203.0.113.7"); r.Score != -1 {
+		t.Errorf("nil Checker Lookup() = %+v", r)
+	}
+}
+
+func TestCheckError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors": [{"detail": "Authentication failed."}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewChecker("wrong", nil)
+	c.url = srv.URL
+	if _, _, err := c.check("203.0.113.7"); err == nil || err.Error() != "abuseipdb: 401 Unauthorized: Authentication failed." {
+		t.Errorf("check() error = %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+// Package sensitive matches request paths against targets that should never
+// be requested from the internet, e.g. leaked secrets, repositories, admin
+// consoles and backup archives.
+package sensitive
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Defaults are the built-in patterns, matched against each segment of a
+// path.
+var Defaults = []string{
+	".env", ".env.*", ".git", ".svn", ".hg", ".htpasswd", ".aws", ".ssh", "id_rsa*",
+	"wp-admin", "wp-config.php*", "phpmyadmin*", "adminer*.php", "server-status",
+	"*backup*", "*.bak", "*.old", "*.swp", "*.sql", "*.sql.gz", "*.sql.zip",
+}
+
+// Matcher matches paths against glob patterns, e.g. ".env" or "*.sql". A
+// path matches if one of its segments does, case-insensitively, so ".git"
+// matches "/.git/config" and "/app/.git/HEAD". A nil Matcher matches no
+// path.
+type Matcher struct {
+	patterns []string
+}
+
+// Default returns a matcher of the built-in patterns.
+func Default() *Matcher {
+	return &Matcher{patterns: Defaults}
+}
+
+// New returns a matcher of the built-in patterns and the given ones. A
+// pattern starting with "!" removes the built-in patterns it matches
+// instead, e.g. "!wp-admin" on a WordPress site or "!*" to keep only the
+// given patterns.
+func New(patterns []string) (*Matcher, error) {
+	m := &Matcher{patterns: append([]string(nil), Defaults...)}
+	for _, p := range patterns {
+		removal, ok := strings.CutPrefix(p, "!")
+		p = strings.ToLower(strings.Trim(removal, "/"))
+		if p == "" || strings.Contains(p, "/") {
+			return nil, fmt.Errorf("invalid sensitive path %q: expected a path segment, e.g. '.env' or '*.sql'", removal)
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid sensitive path %q: %w", removal, err)
+		}
+		if !ok {
+			m.patterns = append(m.patterns, p)
+			continue
+		}
+		kept := m.patterns[:0]
+		for _, builtin := range m.patterns {
+			if matched, _ := path.Match(p, builtin); !matched {
+				kept = append(kept, builtin)
+			}
+		}
+		if len(kept) == len(m.patterns) {
+			return nil, fmt.Errorf("invalid sensitive path \"!%s\": no built-in pattern to remove among %s", removal, strings.Join(Defaults, " "))
+		}
+		m.patterns = kept
+	}
+	return m, nil
+}
+
+// Patterns returns the patterns matched.
+func (m *Matcher) Patterns() []string {
+	if m == nil {
+		return nil
+	}
+	return m.patterns
+}
+
+// Match returns the pattern the path of a request, without its query,
+// matches, or "" if none.
+func (m *Matcher) Match(p string) string {
+	if m == nil || len(m.patterns) == 0 {
+		return ""
+	}
+	p, _, _ = strings.Cut(p, "?")
+	if decoded, err := url.PathUnescape(p); err == nil {
+		p = decoded
+	}
+	for _, segment := range strings.Split(strings.ToLower(p), "/") {
+		if segment == "" {
+			continue
+		}
+		for _, pattern := range m.patterns {
+			if matched, _ := path.Match(pattern, segment); matched {
+				return pattern
+			}
+		}
+	}
+	return ""
+}
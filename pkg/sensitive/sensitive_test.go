@@ -0,0 +1,65 @@
+package sensitive
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	m := Default()
+	tests := map[string]string{
+		"/.env":                     ".env",
+		"/app/.ENV.production":      ".env.*",
+		"/.git/config":              ".git",
+		"/%2egit/HEAD":              ".git",
+		"/wp-admin/install.php":     "wp-admin",
+		"/phpMyAdmin/index.php":     "phpmyadmin*",
+		"/backup.zip":               "*backup*",
+		"/db/dump.sql?download=1":   "*.sql",
+		"/":                         "",
+		"/index.html":               "",
+		"/blog/environment-tips":    "",
+		"/static/app.js?v=.env":     "",
+		"/downloads/release-1.0.gz": "",
+	}
+	for path, want := range tests {
+		if got := m.Match(path); got != want {
+			t.Errorf("Match(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	var nilMatcher *Matcher
+	if nilMatcher.Match("/.env") != "" {
+		t.Error("nil Matcher should match no path")
+	}
+}
+
+func TestNew(t *testing.T) {
+	m, err := New([]string{"/*.pem", "!wp-*", "config.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Match("/certs/server.PEM"); got != "*.pem" {
+		t.Errorf("Match() = %q, want the added pattern", got)
+	}
+	if got := m.Match("/wp-admin/"); got != "" {
+		t.Errorf("Match() = %q, want wp-admin removed", got)
+	}
+	if slices.Contains(m.Patterns(), "wp-config.php*") || !slices.Contains(Defaults, "wp-admin") {
+		t.Errorf("Patterns() = %v, want the wp-* built-ins removed without changing the defaults", m.Patterns())
+	}
+
+	m, err = New([]string{"!*", "secret.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Patterns(); len(got) != 1 || m.Match("/.env") != "" {
+		t.Errorf("Patterns() = %v, want only the given pattern", got)
+	}
+
+	for _, bad := range []string{"", "/", "admin/config", "[", "!nothing-like-this"} {
+		if _, err := New([]string{bad}); err == nil {
+			t.Errorf("New(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/metrics"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/referrer"
+	"github.com/papaganelli/tailnginx/pkg/useragent"
+)
+
+// sectionOrder is the order of the sections in aggregated snapshots, the
+// same as in snapshots exported from the dashboard.
+var sectionOrder = []string{
+	"status", "paths", "ips", "methods", "clients", "bots", "os", "devices",
+	"countries", "sources", "referers", "protocols", "tls_protocols",
+	"tls_ciphers", "not_found", "path_bytes", "ip_bytes",
+}
+
+// Request rate series of aggregated snapshots: 10 minutes in 10s buckets
+const (
+	rateInterval = 10 * time.Second
+	rateBuckets  = 60
+)
+
+// Aggregator counts log entries into snapshot sections without a user
+// interface, e.g. for periodic summaries. It is not safe for concurrent use.
+type Aggregator struct {
+	ua       *useragent.Parser
+	rate     *metrics.RateTracker
+	latency  *metrics.RateTracker // Sum of request times in microseconds
+	timed    *metrics.RateTracker // Requests logged with a request time
+	counts   map[string]map[string]int
+	requests int
+	bytes    int64
+}
+
+// NewAggregator creates an empty aggregator.
+func NewAggregator() *Aggregator {
+	a := &Aggregator{
+		ua:      useragent.NewParser(),
+		rate:    metrics.NewRateTracker(rateInterval, rateBuckets),
+		latency: metrics.NewRateTracker(rateInterval, rateBuckets),
+		timed:   metrics.NewRateTracker(rateInterval, rateBuckets),
+	}
+	a.reset()
+	return a
+}
+
+// reset clears the counts. The rate and latency series keep going.
+func (a *Aggregator) reset() {
+	a.counts = make(map[string]map[string]int, len(sectionOrder))
+	for _, name := range sectionOrder {
+		a.counts[name] = make(map[string]int)
+	}
+	a.requests = 0
+	a.bytes = 0
+}
+
+// Add counts a log entry.
+func (a *Aggregator) Add(v *parser.Visitor) {
+	a.requests++
+	a.bytes += int64(v.Bytes)
+	a.rate.Record(v.Time)
+	if v.RequestTime > 0 {
+		a.latency.RecordN(v.Time, int(v.RequestTime.Microseconds()))
+		a.timed.Record(v.Time)
+	}
+
+	a.counts["status"][strconv.Itoa(v.Status)]++
+	a.counts["paths"][v.Path]++
+	a.counts["ips"][v.IP]++
+	a.counts["methods"][v.Method]++
+	a.counts["path_bytes"][v.Path] += v.Bytes
+	a.counts["ip_bytes"][v.IP] += v.Bytes
+	if v.Protocol != "" {
+		a.counts["protocols"][v.Protocol]++
+	}
+	if v.Status == 404 {
+		a.counts["not_found"][v.Path]++
+	}
+	if v.Country != "" && v.Country != "Unknown" {
+		a.counts["countries"][v.Country]++
+	}
+	if v.TLSProtocol != "" {
+		a.counts["tls_protocols"][v.TLSProtocol]++
+	}
+	if v.TLSCipher != "" {
+		a.counts["tls_ciphers"][v.TLSCipher]++
+	}
+
+	// Crawlers are counted separately from human clients
+	ua := a.ua.Parse(v.Agent)
+	a.counts["os"][ua.OS]++
+	a.counts["devices"][ua.Device]++
+	if ua.IsBot() {
+		a.counts["bots"][ua.Bot]++
+	} else {
+		a.counts["clients"][v.Agent]++
+	}
+
+	source, domain := referrer.Classify(v.Referer)
+	a.counts["sources"][string(source)]++
+	if domain != "" {
+		a.counts["referers"][domain]++
+	}
+}
+
+// Flush returns a snapshot of the entries counted since the previous flush,
+// labelled with the log path and the time window they cover, and starts
+// counting anew. The rate series covers the last 10 minutes, as does the
+// latency series when request times are logged.
+func (a *Aggregator) Flush(now time.Time, logPath, window string) *Snapshot {
+	s := &Snapshot{
+		Time:           now,
+		LogPath:        logPath,
+		Window:         window,
+		Requests:       a.requests,
+		TotalRequests:  a.requests,
+		UniqueVisitors: len(a.counts["ips"]),
+		Bytes:          a.bytes,
+		Rate:           NewSeries(now, rateInterval, a.rate.Buckets(now, rateBuckets)),
+	}
+	if timed := a.timed.Buckets(now, rateBuckets); slices.ContainsFunc(timed, func(n uint64) bool { return n > 0 }) {
+		s.Latency = NewLatency(now, rateInterval, a.latency.Buckets(now, rateBuckets), timed)
+	}
+	for _, name := range sectionOrder {
+		s.AddSection(name, a.counts[name])
+	}
+	a.reset()
+	return s
+}
@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestAggregator(t *testing.T) {
+	a := NewAggregator()
+	a.Add(&parser.Visitor{IP: "10.0.0.1", Method: "GET", Path: "/", Status: 200, Bytes: 100,
+		Protocol: "HTTP/1.1", Country: "France", Agent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64)"})
+	a.Add(&parser.Visitor{IP: "10.0.0.2", Method: "GET", Path: "/missing", Status: 404, Bytes: 20,
+		Agent: "Googlebot/2.1 (+http://www.google.com/bot.html)", Country: "Unknown"})
+
+	now := time.Date(2025, 6, 2, 14, 3, 22, 0, time.UTC)
+	s := a.Flush(now, "/var/log/nginx/access.log", "1m0s")
+	if s.Requests != 2 || s.UniqueVisitors != 2 || s.Bytes != 120 || s.Window != "1m0s" {
+		t.Errorf("Flush() summary = %+v", s)
+	}
+	if len(s.Sections) != len(sectionOrder) {
+		t.Fatalf("Flush() has %d sections, want %d", len(s.Sections), len(sectionOrder))
+	}
+	counts := make(map[string][]Item)
+	for _, section := range s.Sections {
+		counts[section.Name] = section.Items
+	}
+	for name, want := range map[string]string{
+		"not_found": "/missing", "countries": "France", "bots": "Googlebot", "protocols": "HTTP/1.1",
+	} {
+		if items := counts[name]; len(items) != 1 || items[0].Key != want {
+			t.Errorf("section %s = %v, want only %s", name, items, want)
+		}
+	}
+
+	if s := a.Flush(now, "", "1m0s"); s.Requests != 0 || len(s.Sections[0].Items) != 0 {
+		t.Errorf("Flush() after a flush = %+v, want empty counts", s)
+	}
+}
+
+func TestAggregatorLatency(t *testing.T) {
+	now := time.Date(2025, 6, 2, 14, 3, 22, 0, time.UTC)
+	a := NewAggregator()
+	a.Add(&parser.Visitor{Time: now, Status: 200})
+	if s := a.Flush(now, "", "1m0s"); s.Latency != nil {
+		t.Errorf("latency without request times = %+v, want none", s.Latency)
+	}
+
+	a.Add(&parser.Visitor{Time: now.Add(-20 * time.Second), Status: 200, RequestTime: 40 * time.Millisecond})
+	a.Add(&parser.Visitor{Time: now, Status: 200, RequestTime: 100 * time.Millisecond})
+	a.Add(&parser.Visitor{Time: now, Status: 200, RequestTime: 300 * time.Millisecond})
+	a.Add(&parser.Visitor{Time: now, Status: 200})
+	s := a.Flush(now, "", "1m0s")
+	if s.Latency == nil || len(s.Latency.Points) != rateBuckets || s.Latency.Interval != "10s" {
+		t.Fatalf("latency = %+v, want %d buckets of 10s", s.Latency, rateBuckets)
+	}
+	points := s.Latency.Points
+	if last := points[len(points)-1]; last.Average != 200 || !last.Time.Equal(now.Truncate(10*time.Second)) {
+		t.Errorf("last bucket = %+v, want 200ms at %s", last, now.Truncate(10*time.Second))
+	}
+	if points[len(points)-2].Average != 0 || points[len(points)-3].Average != 40 {
+		t.Errorf("earlier buckets = %+v, want 0 then 40ms", points[len(points)-3:])
+	}
+}
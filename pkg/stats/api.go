@@ -0,0 +1,89 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultTopLimit is the number of items returned by /api/top without a
+// limit parameter.
+const defaultTopLimit = 10
+
+// Overview is the summary of a snapshot, without its sections.
+type Overview struct {
+	Time           time.Time `json:"time"`
+	LogPath        string    `json:"log_path"`
+	Window         string    `json:"window"`
+	Filter         string    `json:"filter,omitempty"`
+	Requests       int       `json:"requests"`
+	TotalRequests  int       `json:"total_requests"`
+	UniqueVisitors int       `json:"unique_visitors"`
+	Bytes          int64     `json:"bytes"`
+}
+
+// NewHandler serves the snapshots returned by source as JSON:
+//
+//	GET /api/overview                 summary values
+//	GET /api/top/{section}?limit=10   most frequent items of a section, e.g. paths
+//	GET /api/series/rate              requests per time bucket
+//	GET /api/geo/countries            requests by country as GeoJSON points
+func NewHandler(source func() *Snapshot) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/overview", func(w http.ResponseWriter, _ *http.Request) {
+		s := source()
+		writeJSON(w, Overview{
+			Time:           s.Time,
+			LogPath:        s.LogPath,
+			Window:         s.Window,
+			Filter:         s.Filter,
+			Requests:       s.Requests,
+			TotalRequests:  s.TotalRequests,
+			UniqueVisitors: s.UniqueVisitors,
+			Bytes:          s.Bytes,
+		})
+	})
+	mux.HandleFunc("GET /api/top/{section}", func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultTopLimit
+		if text := r.URL.Query().Get("limit"); text != "" {
+			n, err := strconv.Atoi(text)
+			if err != nil || n < 1 {
+				http.Error(w, fmt.Sprintf("invalid limit %q", text), http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		name := r.PathValue("section")
+		for _, section := range source().Sections {
+			if section.Name == name {
+				section.Items = section.Items[:min(limit, len(section.Items))]
+				writeJSON(w, section)
+				return
+			}
+		}
+		http.Error(w, fmt.Sprintf("unknown section %q", name), http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /api/series/rate", func(w http.ResponseWriter, _ *http.Request) {
+		rate := source().Rate
+		if rate == nil {
+			rate = &Series{Points: []Point{}}
+		}
+		writeJSON(w, rate)
+	})
+	mux.HandleFunc("GET /api/geo/countries", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/geo+json")
+		source().WriteGeoJSON(w)
+	})
+	return mux
+}
+
+// writeJSON writes v as an indented JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
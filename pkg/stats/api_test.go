@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	s := testSnapshot()
+	now := s.Time
+	s.Rate = NewSeries(now, 10*time.Second, []uint64{4, 0, 7})
+	h := NewHandler(func() *Snapshot { return s })
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec
+	}
+
+	rec := get("/api/overview")
+	var overview Overview
+	if err := json.Unmarshal(rec.Body.Bytes(), &overview); err != nil {
+		t.Fatalf("overview %s: %v", rec.Body, err)
+	}
+	if overview.Requests != 6 || overview.Window != "1h" || strings.Contains(rec.Body.String(), "sections") {
+		t.Errorf("overview = %s", rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var section Section
+	if err := json.Unmarshal(get("/api/top/paths?limit=2").Body.Bytes(), &section); err != nil {
+		t.Fatal(err)
+	}
+	if section.Name != "paths" || len(section.Items) != 2 || section.Items[0].Key != "/a" {
+		t.Errorf("top paths = %+v, want /a and /b", section)
+	}
+
+	var rate Series
+	if err := json.Unmarshal(get("/api/series/rate").Body.Bytes(), &rate); err != nil {
+		t.Fatal(err)
+	}
+	if rate.Interval != "10s" || len(rate.Points) != 3 || rate.Points[2].Requests != 7 ||
+		!rate.Points[0].Time.Equal(now.Truncate(10*time.Second).Add(-20*time.Second)) {
+		t.Errorf("rate series = %+v", rate)
+	}
+
+	rec = get("/api/geo/countries")
+	if ct := rec.Header().Get("Content-Type"); ct != "application/geo+json" || !strings.Contains(rec.Body.String(), "FeatureCollection") {
+		t.Errorf("geo countries = %s (%s)", rec.Body, ct)
+	}
+
+	for target, want := range map[string]int{
+		"/api/top/nope":          http.StatusNotFound,
+		"/api/top/paths?limit=0": http.StatusBadRequest,
+	} {
+		if code := get(target).Code; code != want {
+			t.Errorf("GET %s = %d, want %d", target, code, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+
+	"github.com/papaganelli/tailnginx/pkg/geoip"
+)
+
+// geoJSON types of RFC 7946
+type (
+	featureCollection struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}
+
+	feature struct {
+		Type       string          `json:"type"`
+		Geometry   point           `json:"geometry"`
+		Properties countryProperty `json:"properties"`
+	}
+
+	point struct {
+		Type        string     `json:"type"`
+		Coordinates [2]float64 `json:"coordinates"` // Longitude, latitude
+	}
+
+	countryProperty struct {
+		Country  string  `json:"country"` // ISO 3166-1 alpha-2 code
+		Requests int     `json:"requests"`
+		Share    float64 `json:"share"` // Percentage of the requests of the snapshot
+	}
+)
+
+// WriteGeoJSON writes the countries of the snapshot as a GeoJSON feature
+// collection of points at their approximate centers, with the country code,
+// request count and share of the requests as properties, most frequent
+// first. Countries without a known center, e.g. "Unknown", are left out.
+func (s *Snapshot) WriteGeoJSON(w io.Writer) error {
+	collection := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	for _, item := range s.Items("countries") {
+		lat, lon, ok := geoip.Centroid(item.Key)
+		if !ok {
+			continue
+		}
+		collection.Features = append(collection.Features, feature{
+			Type:     "Feature",
+			Geometry: point{Type: "Point", Coordinates: [2]float64{lon, lat}},
+			Properties: countryProperty{
+				Country:  item.Key,
+				Requests: item.Count,
+				Share:    math.Round(s.share(item.Count)*100) / 100,
+			},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(collection)
+}
+
+// ExportGeoJSON writes the countries of the snapshot to a timestamped
+// GeoJSON file in dir, e.g. tailnginx-20250602-140322-countries.geojson,
+// and returns its path.
+func (s *Snapshot) ExportGeoJSON(dir string) (string, error) {
+	path := filepath.Join(dir, "tailnginx-"+s.Time.Format("20060102-150405")+"-countries.geojson")
+	if err := writeFile(path, s.WriteGeoJSON); err != nil {
+		return "", fmt.Errorf("export %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteGeoJSON(t *testing.T) {
+	s := testSnapshot()
+	s.AddSection("countries", map[string]int{"FR": 3, "US": 2, "Unknown": 1})
+	var b strings.Builder
+	if err := s.WriteGeoJSON(&b); err != nil {
+		t.Fatalf("WriteGeoJSON() error = %v", err)
+	}
+
+	var got featureCollection
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, b.String())
+	}
+	if got.Type != "FeatureCollection" || len(got.Features) != 2 {
+		t.Fatalf("got %+v, want a collection of FR and US", got)
+	}
+	fr := got.Features[0]
+	if fr.Type != "Feature" || fr.Geometry.Type != "Point" || fr.Geometry.Coordinates != [2]float64{2.2, 46.2} {
+		t.Errorf("first feature = %+v, want a point at the center of France, longitude first", fr)
+	}
+	if fr.Properties != (countryProperty{Country: "FR", Requests: 3, Share: 50}) {
+		t.Errorf("properties = %+v", fr.Properties)
+	}
+	if got.Features[1].Properties.Share != 33.33 {
+		t.Errorf("US share = %v, want 33.33", got.Features[1].Properties.Share)
+	}
+}
+
+func TestWriteGeoJSONEmpty(t *testing.T) {
+	var b strings.Builder
+	testSnapshot().WriteGeoJSON(&b)
+	if !strings.Contains(b.String(), `"features": []`) {
+		t.Errorf("snapshot without countries should have an empty features array:\n%s", b.String())
+	}
+}
+
+func TestExportGeoJSON(t *testing.T) {
+	dir := t.TempDir()
+	path, err := testSnapshot().ExportGeoJSON(dir)
+	if err != nil {
+		t.Fatalf("ExportGeoJSON() error = %v", err)
+	}
+	if want := filepath.Join(dir, "tailnginx-20250602-140322-countries.geojson"); path != want {
+		t.Errorf("ExportGeoJSON() = %q, want %q", path, want)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+// htmlReport lays out WriteHTML reports as a standalone page, readable in
+// a browser or as an email attachment.
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>tailnginx report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+td.n, th.n { text-align: right; }
+code { font-size: 0.95em; }
+.meta { color: #666; }
+</style>
+</head>
+<body>
+<h1>tailnginx report</h1>
+<p class="meta"><code>{{.LogPath}}</code>, {{.Time}}{{with .Window}}, window {{.}}{{end}}{{with .Filter}}, status filter {{.}}{{end}}</p>
+<h2>Traffic</h2>
+<table>
+<tr><th class="n">Requests</th><th class="n">Unique visitors</th><th class="n">Bytes</th></tr>
+<tr><td class="n">{{.Requests}}</td><td class="n">{{.UniqueVisitors}}</td><td class="n">{{.Bytes}}</td></tr>
+</table>
+{{with .RateChart}}<h2>Request rate</h2>
+{{.}}
+{{end}}{{with .LatencyChart}}<h2>Request time</h2>
+{{.}}
+{{end}}{{with .StatusChart}}<h2>Status codes</h2>
+{{.}}
+{{end}}{{range .Lists}}<h2>{{.Title}}</h2>
+{{if .Rows}}<table>
+<tr><th>{{.Column}}</th><th class="n">Requests</th><th class="n">Share</th></tr>
+{{range .Rows}}<tr><td><code>{{.Key}}</code></td><td class="n">{{.Count}}</td><td class="n">{{printf "%.1f" .Share}}%</td></tr>
+{{end}}</table>
+{{else}}<p>None.</p>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// htmlList is a report table with the rows to show.
+type htmlList struct {
+	Title  string
+	Column string
+	Rows   []htmlRow
+}
+
+type htmlRow struct {
+	Key   string
+	Count int
+	Share float64
+}
+
+// WriteHTML writes the report of WriteMarkdown as a standalone HTML page,
+// e.g. to attach to an email, with inline SVG charts of the request rate,
+// the request time and the status codes, so it needs no scripts.
+func (s *Snapshot) WriteHTML(w io.Writer, top int) error {
+	data := struct {
+		LogPath, Time, Window, Filter string
+		Requests, UniqueVisitors      int
+		Bytes                         int64
+		RateChart, LatencyChart       template.HTML // Inline SVG, empty without data
+		StatusChart                   template.HTML
+		Lists                         []htmlList
+	}{
+		LogPath:        s.LogPath,
+		Time:           s.Time.Format(time.RFC1123),
+		Window:         s.Window,
+		Filter:         s.Filter,
+		Requests:       s.Requests,
+		UniqueVisitors: s.UniqueVisitors,
+		Bytes:          s.Bytes,
+		RateChart:      rateChart(s.Rate),
+		LatencyChart:   latencyChart(s.Latency),
+		StatusChart:    statusChart(s, top),
+	}
+	for _, list := range s.reportLists() {
+		l := htmlList{Title: list.title, Column: list.column}
+		for i, item := range list.items {
+			if i == top {
+				break
+			}
+			l.Rows = append(l.Rows, htmlRow{Key: item.Key, Count: item.Count, Share: s.share(item.Count)})
+		}
+		data.Lists = append(data.Lists, l)
+	}
+	return htmlReport.Execute(w, data)
+}
@@ -0,0 +1,33 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	s := testSnapshot()
+	s.AddSection("status", map[string]int{"200": 3, "404": 2, "502": 1})
+	s.AddSection("countries", map[string]int{"<script>": 1})
+
+	var b strings.Builder
+	if err := s.WriteHTML(&b, 2); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"<code>/var/log/nginx/access.log</code>, Mon, 02 Jun 2025 14:03:22 UTC, window 1h</p>",
+		`<tr><td class="n">6</td><td class="n">0</td><td class="n">4096</td></tr>`,
+		`<tr><td><code>404</code></td><td class="n">2</td><td class="n">33.3%</td></tr>`,
+		"<h2>Top not found paths</h2>\n<p>None.</p>",
+		"<code>&lt;script&gt;</code>",
+		"<h2>Status codes</h2>\n<svg ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Request rate") || strings.Contains(out, "<script") {
+		t.Errorf("report without a rate series should have no rate chart nor script:\n%s", out)
+	}
+}
@@ -0,0 +1,102 @@
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteMarkdown writes a report of the key stats as Markdown, e.g. to paste
+// into an incident postmortem or a chat: the traffic totals, then the top
+// entries of the endpoints, error status codes, not found paths and
+// countries tables.
+func (s *Snapshot) WriteMarkdown(w io.Writer, top int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# tailnginx report\n\n")
+	fmt.Fprintf(bw, "%s, %s", markdownCode(s.LogPath), s.Time.Format(time.RFC1123))
+	if s.Window != "" {
+		fmt.Fprintf(bw, ", window %s", s.Window)
+	}
+	if s.Filter != "" {
+		fmt.Fprintf(bw, ", status filter %s", s.Filter)
+	}
+	fmt.Fprintf(bw, "\n\n## Traffic\n\n")
+	fmt.Fprintf(bw, "| Requests | Unique visitors | Bytes |\n|---:|---:|---:|\n")
+	fmt.Fprintf(bw, "| %d | %d | %d |\n", s.Requests, s.UniqueVisitors, s.Bytes)
+
+	for _, list := range s.reportLists() {
+		fmt.Fprintf(bw, "\n## %s\n\n", list.title)
+		if len(list.items) == 0 {
+			fmt.Fprintf(bw, "None.\n")
+			continue
+		}
+		fmt.Fprintf(bw, "| %s | Requests | Share |\n|---|---:|---:|\n", list.column)
+		for i, item := range list.items {
+			if i == top {
+				break
+			}
+			fmt.Fprintf(bw, "| %s | %d | %.1f%% |\n", markdownCode(item.Key), item.Count, s.share(item.Count))
+		}
+	}
+	return bw.Flush()
+}
+
+// reportList is a table of a report, e.g. the top endpoints.
+type reportList struct {
+	title  string
+	column string // Header of the key column
+	items  []Item
+}
+
+// reportLists returns the tables of reports: the endpoints, error status
+// codes, not found paths and countries.
+func (s *Snapshot) reportLists() []reportList {
+	var errors []Item
+	for _, item := range s.Items("status") {
+		if code, err := strconv.Atoi(item.Key); err == nil && code >= 400 {
+			errors = append(errors, item)
+		}
+	}
+	return []reportList{
+		{"Top endpoints", "Path", s.Items("paths")},
+		{"Errors", "Status", errors},
+		{"Top not found paths", "Path", s.Items("not_found")},
+		{"Top countries", "Country", s.Items("countries")},
+	}
+}
+
+// share returns the percentage of the requests a count stands for.
+func (s *Snapshot) share(count int) float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(s.Requests)
+}
+
+// Items returns the items of the named section, or nil if there is none.
+func (s *Snapshot) Items(name string) []Item {
+	for _, section := range s.Sections {
+		if section.Name == name {
+			return section.Items
+		}
+	}
+	return nil
+}
+
+// markdownCode formats text as an inline code span that is safe in a table
+// cell: pipes are escaped and the span is delimited with more backticks than
+// the text contains.
+func markdownCode(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if len(fence) > 1 {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
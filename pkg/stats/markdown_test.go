@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	s := testSnapshot()
+	s.AddSection("status", map[string]int{"200": 3, "404": 2, "502": 1})
+	s.AddSection("countries", map[string]int{"FR": 6})
+
+	var b strings.Builder
+	if err := s.WriteMarkdown(&b, 2); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"`/var/log/nginx/access.log`, Mon, 02 Jun 2025 14:03:22 UTC, window 1h\n",
+		"| 6 | 0 | 4096 |\n",
+		"## Top endpoints\n\n| Path | Requests | Share |\n|---|---:|---:|\n| `/a` | 2 | 33.3% |\n| `/b` | 2 | 33.3% |\n\n",
+		"## Errors\n\n| Status | Requests | Share |\n|---|---:|---:|\n| `404` | 2 | 33.3% |\n| `502` | 1 | 16.7% |\n",
+		"## Top not found paths\n\nNone.\n",
+		"| `FR` | 6 | 100.0% |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "/login") {
+		t.Errorf("report should be limited to the top 2 entries:\n%s", out)
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	tests := map[string]string{
+		"/api":   "`/api`",
+		"/a|b":   "`/a\\|b`",
+		"/x`y":   "`` /x`y ``",
+		"/x``y`": "``` /x``y` ```",
+	}
+	for text, want := range tests {
+		if got := markdownCode(text); got != want {
+			t.Errorf("markdownCode(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package stats
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// mqttTimeout bounds connecting and publishing to an MQTT broker.
+	mqttTimeout = 10 * time.Second
+	// mqttTop is the number of entries per section in published snapshots.
+	mqttTop = 10
+	// mqttMaxLength is the largest remaining length of an MQTT packet.
+	mqttMaxLength = 268435455
+)
+
+// MQTT packet types and CONNECT flags of MQTT 3.1.1
+const (
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttDisconnect = 0xe0
+
+	mqttRetain       = 0x01
+	mqttCleanSession = 0x02
+	mqttPasswordFlag = 0x40
+	mqttUsernameFlag = 0x80
+)
+
+// MQTT publishes snapshots as retained JSON messages to a topic of an MQTT
+// broker, e.g. for Home Assistant or Node-RED. It only speaks MQTT 3.1.1
+// with QoS 0.
+type MQTT struct {
+	addr     string // Broker address; port 8883 uses TLS
+	topic    string
+	username string // Empty to connect without authentication
+	password string
+	clientID string
+}
+
+// NewMQTT returns a publisher to topic on the broker at addr, e.g.
+// "localhost:1883".
+func NewMQTT(addr, topic, username, password string) (*MQTT, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("mqtt: broker %q: %w", addr, err)
+	}
+	if topic == "" || strings.ContainsAny(topic, "+#\x00") {
+		return nil, fmt.Errorf("mqtt: invalid topic %q", topic)
+	}
+	return &MQTT{
+		addr:     addr,
+		topic:    topic,
+		username: username,
+		password: password,
+		clientID: fmt.Sprintf("tailnginx-%d", os.Getpid()),
+	}, nil
+}
+
+// Publish publishes a snapshot, with the top entries of each section only.
+// It is an Output. A connection is made for every snapshot, so a restarted
+// broker is picked up again.
+func (m *MQTT) Publish(s *Snapshot) error {
+	top := *s
+	top.Sections = make([]Section, len(s.Sections))
+	for i, section := range s.Sections {
+		top.Sections[i] = Section{Name: section.Name, Items: section.Items[:min(len(section.Items), mqttTop)]}
+	}
+	payload, err := json.Marshal(&top)
+	if err != nil {
+		return fmt.Errorf("mqtt: %w", err)
+	}
+	if err := m.publish(payload); err != nil {
+		return fmt.Errorf("mqtt: %w", err)
+	}
+	return nil
+}
+
+func (m *MQTT) publish(payload []byte) error {
+	dialer := &net.Dialer{Timeout: mqttTimeout}
+	var conn net.Conn
+	var err error
+	if host, port, _ := net.SplitHostPort(m.addr); port == "8883" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", m.addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", m.addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(mqttTimeout))
+
+	w := bufio.NewWriter(conn)
+	writeMQTTPacket(w, mqttConnect, m.connectBody())
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := readConnack(conn); err != nil {
+		return err
+	}
+
+	var publish []byte
+	publish = appendMQTTString(publish, m.topic)
+	publish = append(publish, payload...)
+	if err := writeMQTTPacket(w, mqttPublish|mqttRetain, publish); err != nil {
+		return err
+	}
+	writeMQTTPacket(w, mqttDisconnect, nil)
+	return w.Flush()
+}
+
+// connectBody returns the variable header and payload of a CONNECT packet
+// starting a clean session.
+func (m *MQTT) connectBody() []byte {
+	b := appendMQTTString(nil, "MQTT")
+	flags := byte(mqttCleanSession)
+	if m.username != "" {
+		flags |= mqttUsernameFlag
+		if m.password != "" {
+			flags |= mqttPasswordFlag
+		}
+	}
+	b = append(b, 4, flags, 0, 0) // Protocol level 3.1.1, no keep alive
+	b = appendMQTTString(b, m.clientID)
+	if flags&mqttUsernameFlag != 0 {
+		b = appendMQTTString(b, m.username)
+	}
+	if flags&mqttPasswordFlag != 0 {
+		b = appendMQTTString(b, m.password)
+	}
+	return b
+}
+
+// connackErrors are the reasons of refused connections by return code.
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// readConnack reads the CONNACK packet answering a CONNECT.
+func readConnack(r io.Reader) error {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return fmt.Errorf("reading CONNACK: %w", err)
+	}
+	if b[0] != mqttConnack || b[1] != 2 {
+		return errors.New("unexpected answer to CONNECT")
+	}
+	if code := b[3]; code != 0 {
+		if reason, ok := connackErrors[code]; ok {
+			return fmt.Errorf("connection refused: %s", reason)
+		}
+		return fmt.Errorf("connection refused: code %d", code)
+	}
+	return nil
+}
+
+// writeMQTTPacket writes a packet with its fixed header.
+func writeMQTTPacket(w io.Writer, header byte, body []byte) error {
+	if len(body) > mqttMaxLength {
+		return fmt.Errorf("packet of %d bytes too large", len(body))
+	}
+	packet := []byte{header}
+	// Remaining length, 7 bits per byte, least significant first
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if n == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(packet, body...))
+	return err
+}
+
+// appendMQTTString appends a string prefixed with its length.
+func appendMQTTString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
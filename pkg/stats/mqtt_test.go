@@ -0,0 +1,140 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mqttPacket is a packet received by the fake broker.
+type mqttPacket struct {
+	header byte
+	body   []byte
+}
+
+func readMQTTPacket(r *bufio.Reader) (mqttPacket, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return mqttPacket{}, err
+	}
+	length, shift := 0, 0
+	for {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return mqttPacket{}, err
+		}
+		length |= int(digit&0x7f) << shift
+		shift += 7
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return mqttPacket{header, body}, err
+}
+
+// fakeBroker accepts one connection, answers its CONNECT with code and
+// returns the packets received.
+func fakeBroker(t *testing.T, code byte) (string, <-chan []mqttPacket) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received := make(chan []mqttPacket, 1)
+	go func() {
+		var packets []mqttPacket
+		defer func() { received <- packets }()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			p, err := readMQTTPacket(r)
+			if err != nil {
+				return
+			}
+			packets = append(packets, p)
+			if p.header == mqttConnect {
+				conn.Write([]byte{mqttConnack, 2, 0, code})
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestMQTTPublish(t *testing.T) {
+	addr, received := fakeBroker(t, 0)
+	m, err := NewMQTT(addr, "home/nginx", "ha", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Snapshot{Time: time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC), LogPath: "/var/log/nginx/access.log", Window: "1m0s", Requests: 42}
+	paths := make(map[string]int)
+	for i := 0; i < 15; i++ {
+		paths[fmt.Sprintf("/page%d", i)] = i + 1
+	}
+	s.AddSection("paths", paths)
+	if err := m.Publish(s); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	packets := <-received
+	if len(packets) != 3 || packets[0].header != mqttConnect || packets[1].header != mqttPublish|mqttRetain || packets[2].header != mqttDisconnect {
+		t.Fatalf("received %d packets: %+v", len(packets), packets)
+	}
+	connect := string(packets[0].body)
+	if !strings.HasPrefix(connect, "\x00\x04MQTT\x04\xc2\x00\x00") || !strings.HasSuffix(connect, "\x00\x02ha\x00\x06secret") {
+		t.Errorf("CONNECT = %q", connect)
+	}
+
+	publish := packets[1].body
+	if topic := string(publish[2 : 2+int(publish[1])]); topic != "home/nginx" {
+		t.Errorf("topic = %q, want home/nginx", topic)
+	}
+	var got Snapshot
+	if err := json.Unmarshal(publish[2+len("home/nginx"):], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Requests != 42 || len(got.Sections) != 1 || len(got.Sections[0].Items) != mqttTop || got.Sections[0].Items[0].Key != "/page14" {
+		t.Errorf("payload = %+v, want the top %d paths", got, mqttTop)
+	}
+	if len(s.Sections[0].Items) != 15 {
+		t.Error("Publish() should not change the snapshot")
+	}
+}
+
+func TestMQTTRefused(t *testing.T) {
+	addr, _ := fakeBroker(t, 5)
+	m, err := NewMQTT(addr, "tailnginx/stats", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Publish(&Snapshot{})
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("Publish() error = %v, want not authorized", err)
+	}
+
+	if _, err := NewMQTT("localhost", "tailnginx/stats", "", ""); err == nil {
+		t.Error("NewMQTT() without port should fail")
+	}
+	if _, err := NewMQTT("localhost:1883", "tailnginx/#", "", ""); err == nil {
+		t.Error("NewMQTT() with a wildcard topic should fail")
+	}
+}
+
+func TestWriteMQTTPacketLength(t *testing.T) {
+	var b strings.Builder
+	writeMQTTPacket(&b, mqttPublish, make([]byte, 321))
+	if got := b.String()[:3]; got != "\x30\xc1\x02" {
+		t.Errorf("fixed header = %q, want 321 as two length bytes", got)
+	}
+}
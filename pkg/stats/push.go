@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// pushTop is the number of entries per section in pushed snapshots.
+	pushTop = 1000
+	// pushTimeout bounds a push request.
+	pushTimeout = 30 * time.Second
+	// pushPath is the endpoint of the HTTP API receiving pushed snapshots.
+	pushPath = "/api/push"
+	// pushSourceHeader names the instance a snapshot is pushed from.
+	pushSourceHeader = "X-Tailnginx-Source"
+)
+
+// Push posts snapshots to the HTTP API of a central tailnginx instance,
+// which merges the snapshots of all instances pushing to it (see Rollup).
+type Push struct {
+	url    string // Endpoint, the base URL with pushPath
+	token  string // Shared secret, sent as a bearer token
+	source string // Name of this instance, e.g. its host name
+	client *http.Client
+}
+
+// NewPush returns a pusher to the instance serving its API at baseURL,
+// e.g. "https://central:9146", authenticated with a shared token.
+func NewPush(baseURL, token, source string) (*Push, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("push: URL must be an http or https URL")
+	}
+	if token == "" {
+		return nil, errors.New("push: missing token")
+	}
+	if source == "" {
+		return nil, errors.New("push: missing source name")
+	}
+	return &Push{
+		url:    strings.TrimSuffix(baseURL, "/") + pushPath,
+		token:  token,
+		source: source,
+		client: &http.Client{Timeout: pushTimeout},
+	}, nil
+}
+
+// Send posts a snapshot, with the top entries of each section only and
+// without its rate and latency series, gzipped. It is an Output: snapshots of
+// consecutive periods add up on the central instance.
+func (p *Push) Send(s *Snapshot) error {
+	compact := *s
+	compact.Rate = nil
+	compact.Latency = nil
+	compact.Sections = make([]Section, len(s.Sections))
+	for i, section := range s.Sections {
+		compact.Sections[i] = Section{Name: section.Name, Items: section.Items[:min(len(section.Items), pushTop)]}
+	}
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	if err := json.NewEncoder(zw).Encode(&compact); err != nil {
+		return fmt.Errorf("push: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("push: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url, &body)
+	if err != nil {
+		return fmt.Errorf("push: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set(pushSourceHeader, p.source)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushToRollup(t *testing.T) {
+	rollup := NewRollup(time.Hour)
+	srv := httptest.NewServer(rollup.Handler("secret"))
+	defer srv.Close()
+
+	p, err := NewPush(srv.URL+"/", "secret", "web1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := testSnapshot()
+	s.Rate = NewSeries(s.Time, 10*time.Second, []uint64{1, 2})
+	if err := p.Send(s); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := p.Send(testSnapshot()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	merged := rollup.Snapshot(time.Now())
+	if merged.Requests != 12 || merged.Bytes != 8192 {
+		t.Errorf("merged requests = %d, bytes = %d, want 12 and 8192", merged.Requests, merged.Bytes)
+	}
+	if items := merged.Items("paths"); len(items) != 3 || items[0] != (Item{"/a", 4}) {
+		t.Errorf("merged paths = %v", items)
+	}
+	sources := rollup.Sources(time.Now())
+	if len(sources) != 1 || sources[0].Name != "web1" || sources[0].Pushes != 2 || sources[0].Requests != 12 {
+		t.Errorf("sources = %+v", sources)
+	}
+
+	bad, _ := NewPush(srv.URL, "wrong", "web2")
+	if err := bad.Send(testSnapshot()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Send() with a wrong token error = %v, want 401", err)
+	}
+}
+
+func TestNewPushErrors(t *testing.T) {
+	for _, args := range [][3]string{
+		{"central:9146", "secret", "web1"},
+		{"https://central:9146", "", "web1"},
+		{"https://central:9146", "secret", ""},
+	} {
+		if _, err := NewPush(args[0], args[1], args[2]); err == nil {
+			t.Errorf("NewPush(%q) should fail", args)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// reportTop is the number of entries per section in HTML report files.
+const reportTop = 20
+
+// reportFormats are the files written for each report, by extension.
+var reportFormats = []struct {
+	ext   string
+	write func(s *Snapshot, w io.Writer) error
+}{
+	{".json", func(s *Snapshot, w io.Writer) error { return s.WriteJSON(w) }},
+	{".html", func(s *Snapshot, w io.Writer) error { return s.WriteHTML(w, reportTop) }},
+}
+
+// ReportDir writes reports to timestamped JSON and HTML files in a
+// directory, e.g. tailnginx-report-20250602-140000.html, and removes the
+// oldest ones beyond a number to keep.
+type ReportDir struct {
+	dir  string
+	keep int // Reports kept per format, 0 to keep all
+}
+
+// NewReportDir returns a writer of reports to dir, created if needed,
+// keeping the latest keep reports.
+func NewReportDir(dir string, keep int) (*ReportDir, error) {
+	if keep < 0 {
+		return nil, errors.New("reports: negative number of reports to keep")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("reports: %w", err)
+	}
+	return &ReportDir{dir: dir, keep: keep}, nil
+}
+
+// Write writes the report of a snapshot, then removes the oldest reports.
+// It is an Output, e.g. for hourly reports.
+func (r *ReportDir) Write(s *Snapshot) error {
+	base := filepath.Join(r.dir, "tailnginx-report-"+s.Time.Format("20060102-150405"))
+	for _, f := range reportFormats {
+		path := base + f.ext
+		write := func(w io.Writer) error { return f.write(s, w) }
+		if err := writeFile(path, write); err != nil {
+			return fmt.Errorf("reports: %s: %w", path, err)
+		}
+	}
+	return r.rotate()
+}
+
+// rotate removes the oldest reports of each format beyond the number to
+// keep. Timestamped names sort in chronological order.
+func (r *ReportDir) rotate() error {
+	if r.keep == 0 {
+		return nil
+	}
+	var errs []error
+	for _, f := range reportFormats {
+		paths, err := filepath.Glob(filepath.Join(r.dir, "tailnginx-report-*"+f.ext))
+		if err != nil {
+			return fmt.Errorf("reports: %w", err)
+		}
+		slices.Sort(paths)
+		for len(paths) > r.keep {
+			if err := os.Remove(paths[0]); err != nil {
+				errs = append(errs, fmt.Errorf("reports: %w", err))
+			}
+			paths = paths[1:]
+		}
+	}
+	return errors.Join(errs...)
+}
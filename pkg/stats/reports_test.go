@@ -0,0 +1,68 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	r, err := NewReportDir(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		s := &Snapshot{Time: start.Add(time.Duration(i) * time.Hour), LogPath: "/var/log/nginx/access.log", Window: "1h0m0s", Requests: i}
+		s.AddSection("paths", map[string]int{"/": i + 1})
+		if err := r.Write(s); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{
+		"tailnginx-report-20251010-130000.html",
+		"tailnginx-report-20251010-130000.json",
+		"tailnginx-report-20251010-140000.html",
+		"tailnginx-report-20251010-140000.json",
+	}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("reports = %v, want the latest 2 of each format %v", names, want)
+	}
+	html, _ := os.ReadFile(filepath.Join(dir, want[2]))
+	if !strings.Contains(string(html), "<td class=\"n\">2</td>") {
+		t.Errorf("HTML report lacks the request count:\n%s", html)
+	}
+
+	if _, err := NewReportDir(dir, -1); err == nil {
+		t.Error("NewReportDir() with a negative count should fail")
+	}
+}
+
+func TestReportDirKeepAll(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewReportDir(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := r.Write(&Snapshot{Time: start.AddDate(0, 0, i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(matches) != 3 {
+		t.Errorf("kept %d reports, want all 3", len(matches))
+	}
+}
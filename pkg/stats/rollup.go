@@ -0,0 +1,177 @@
+package stats
+
+import (
+	"cmp"
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxPushSize bounds the uncompressed size of a pushed snapshot.
+const maxPushSize = 64 << 20
+
+// Rollup merges the snapshots pushed by other instances (see Push) over a
+// sliding window, for a view of the traffic of several servers. It is safe
+// for concurrent use.
+type Rollup struct {
+	mu      sync.Mutex
+	window  time.Duration
+	pushes  []push
+	sources map[string]*Source
+}
+
+// push is a snapshot received from a source.
+type push struct {
+	source   string
+	received time.Time
+	snapshot *Snapshot
+}
+
+// Source describes an instance pushing snapshots.
+type Source struct {
+	Name     string    `json:"name"`
+	LastPush time.Time `json:"last_push"`
+	Pushes   int       `json:"pushes"`   // Snapshots received since startup
+	Requests int       `json:"requests"` // Requests of its snapshots in the window
+}
+
+// NewRollup creates a rollup of the snapshots received in the last window.
+func NewRollup(window time.Duration) *Rollup {
+	return &Rollup{window: window, sources: make(map[string]*Source)}
+}
+
+// Add merges a snapshot pushed by a source.
+func (r *Rollup) Add(source string, s *Snapshot, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pushes = append(r.pushes, push{source: source, received: now, snapshot: s})
+	src, ok := r.sources[source]
+	if !ok {
+		src = &Source{Name: source}
+		r.sources[source] = src
+	}
+	src.LastPush = now
+	src.Pushes++
+	r.expire(now)
+}
+
+// expire forgets the snapshots received before the window. Caller must
+// hold the lock.
+func (r *Rollup) expire(now time.Time) {
+	start := now.Add(-r.window)
+	i := 0
+	for i < len(r.pushes) && r.pushes[i].received.Before(start) {
+		i++
+	}
+	r.pushes = r.pushes[i:]
+}
+
+// Snapshot returns the sum of the snapshots received in the window. Its
+// unique visitors are the distinct IPs among the top IPs of each snapshot.
+func (r *Rollup) Snapshot(now time.Time) *Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire(now)
+
+	merged := &Snapshot{
+		Time:    now,
+		LogPath: fmt.Sprintf("rollup of %d sources", len(r.sources)),
+		Window:  r.window.String(),
+	}
+	counts := make(map[string]map[string]int)
+	var names []string
+	for _, p := range r.pushes {
+		s := p.snapshot
+		merged.Requests += s.Requests
+		merged.TotalRequests += s.TotalRequests
+		merged.Bytes += s.Bytes
+		for _, section := range s.Sections {
+			c, ok := counts[section.Name]
+			if !ok {
+				c = make(map[string]int, len(section.Items))
+				counts[section.Name] = c
+				names = append(names, section.Name)
+			}
+			for _, item := range section.Items {
+				c[item.Key] += item.Count
+			}
+		}
+	}
+	merged.UniqueVisitors = len(counts["ips"])
+	for _, name := range names {
+		merged.AddSection(name, counts[name])
+	}
+	return merged
+}
+
+// Sources returns the instances that pushed snapshots since startup, by
+// name, with the requests of their snapshots in the window.
+func (r *Rollup) Sources(now time.Time) []Source {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire(now)
+
+	requests := make(map[string]int)
+	for _, p := range r.pushes {
+		requests[p.source] += p.snapshot.Requests
+	}
+	sources := make([]Source, 0, len(r.sources))
+	for _, src := range r.sources {
+		s := *src
+		s.Requests = requests[s.Name]
+		sources = append(sources, s)
+	}
+	slices.SortFunc(sources, func(a, b Source) int { return cmp.Compare(a.Name, b.Name) })
+	return sources
+}
+
+// Handler serves the rollup and receives pushed snapshots:
+//
+//	POST /api/push              snapshot pushed with the token as a bearer token
+//	GET  /api/rollup            merged snapshot of the window
+//	GET  /api/rollup/sources    instances pushing snapshots
+func (r *Rollup) Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+pushPath, func(w http.ResponseWriter, req *http.Request) {
+		given := []byte(req.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(given, []byte("Bearer "+token)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		source := req.Header.Get(pushSourceHeader)
+		if source == "" {
+			http.Error(w, "missing "+pushSourceHeader+" header", http.StatusBadRequest)
+			return
+		}
+		body := io.Reader(req.Body)
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(req.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer zr.Close()
+			body = zr
+		}
+		var s Snapshot
+		if err := json.NewDecoder(io.LimitReader(body, maxPushSize)).Decode(&s); err != nil {
+			http.Error(w, fmt.Sprintf("invalid snapshot: %v", err), http.StatusBadRequest)
+			return
+		}
+		r.Add(source, &s, time.Now())
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /api/rollup", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, r.Snapshot(time.Now()))
+	})
+	mux.HandleFunc("GET /api/rollup/sources", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, r.Sources(time.Now()))
+	})
+	return mux
+}
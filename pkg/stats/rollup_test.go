@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRollupWindow(t *testing.T) {
+	now := time.Date(2025, 6, 2, 14, 0, 0, 0, time.UTC)
+	r := NewRollup(time.Hour)
+
+	old := &Snapshot{Requests: 5}
+	old.AddSection("ips", map[string]int{"10.0.0.1": 5})
+	r.Add("web1", old, now.Add(-2*time.Hour))
+	web1 := &Snapshot{Requests: 3}
+	web1.AddSection("ips", map[string]int{"10.0.0.1": 1, "10.0.0.2": 2})
+	r.Add("web1", web1, now.Add(-time.Minute))
+	web2 := &Snapshot{Requests: 4}
+	web2.AddSection("ips", map[string]int{"10.0.0.2": 4})
+	r.Add("web2", web2, now)
+
+	s := r.Snapshot(now)
+	if s.Requests != 7 || s.UniqueVisitors != 2 || s.Window != "1h0m0s" {
+		t.Errorf("rollup = %+v, want 7 requests from 2 visitors in 1h", s)
+	}
+	if items := s.Items("ips"); len(items) != 2 || items[0] != (Item{"10.0.0.2", 6}) {
+		t.Errorf("ips = %v", items)
+	}
+	sources := r.Sources(now)
+	if len(sources) != 2 || sources[0].Name != "web1" || sources[0].Requests != 3 || sources[0].Pushes != 2 {
+		t.Errorf("sources = %+v", sources)
+	}
+}
+
+func TestRollupHandlerRejects(t *testing.T) {
+	h := NewRollup(time.Hour).Handler("secret")
+	for _, tc := range []struct {
+		auth, source, body string
+		want               int
+	}{
+		{"", "web1", "{}", 401},
+		{"Bearer nope", "web1", "{}", 401},
+		{"Bearer secret", "", "{}", 400},
+		{"Bearer secret", "web1", "not json", 400},
+		{"Bearer secret", "web1", `{"requests": 1}`, 204},
+	} {
+		req := httptest.NewRequest("POST", "/api/push", strings.NewReader(tc.body))
+		req.Header.Set("Authorization", tc.auth)
+		req.Header.Set(pushSourceHeader, tc.source)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("push %+v = %d, want %d", tc, rec.Code, tc.want)
+		}
+	}
+}
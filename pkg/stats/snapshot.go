@@ -0,0 +1,257 @@
+// Package stats holds point-in-time copies of the aggregated log statistics
+// and writes them out for sharing.
+package stats
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Item is one counted value, e.g. a path and its request count.
+type Item struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// Section is a named list of counted values, most frequent first.
+type Section struct {
+	Name  string `json:"name"`
+	Items []Item `json:"items"`
+}
+
+// Snapshot is a copy of the aggregates displayed at a point in time.
+type Snapshot struct {
+	Time           time.Time `json:"time"`
+	LogPath        string    `json:"log_path"`
+	Window         string    `json:"window"`           // Time window, e.g. "1h" or "all"
+	Filter         string    `json:"filter,omitempty"` // Active filters, e.g. "4xx" or "404, path /api*"
+	Requests       int       `json:"requests"`         // Requests matching the window and filter
+	TotalRequests  int       `json:"total_requests"`   // Requests in memory
+	UniqueVisitors int       `json:"unique_visitors"`
+	Bytes          int64     `json:"bytes"`
+	Sections       []Section `json:"sections"`
+	Rate           *Series   `json:"rate,omitempty"`    // Recent request rate
+	Latency        *Latency  `json:"latency,omitempty"` // Recent average request time
+}
+
+// Series is the number of requests in consecutive time buckets.
+type Series struct {
+	Interval string  `json:"interval"` // Bucket length, e.g. "10s"
+	Points   []Point `json:"points"`   // Oldest first
+}
+
+// Point is the number of requests in the bucket starting at Time.
+type Point struct {
+	Time     time.Time `json:"time"`
+	Requests int       `json:"requests"`
+}
+
+// NewSeries creates a series from per-bucket request counts, oldest first,
+// the last one being the bucket of now.
+func NewSeries(now time.Time, interval time.Duration, counts []uint64) *Series {
+	r := &Series{Interval: interval.String(), Points: make([]Point, len(counts))}
+	end := now.Truncate(interval)
+	for i, n := range counts {
+		r.Points[i] = Point{
+			Time:     end.Add(-time.Duration(len(counts)-1-i) * interval),
+			Requests: int(n),
+		}
+	}
+	return r
+}
+
+// Latency is the average request time of the requests logged with
+// $request_time in consecutive time buckets.
+type Latency struct {
+	Interval string         `json:"interval"` // Bucket length, e.g. "10s"
+	Points   []LatencyPoint `json:"points"`   // Oldest first
+}
+
+// LatencyPoint is the average request time in the bucket starting at Time,
+// 0 without timed requests.
+type LatencyPoint struct {
+	Time    time.Time `json:"time"`
+	Average float64   `json:"average_ms"`
+}
+
+// NewLatency creates a latency series from per-bucket sums of request times
+// in microseconds and numbers of timed requests, oldest first, the last one
+// being the bucket of now.
+func NewLatency(now time.Time, interval time.Duration, sums, counts []uint64) *Latency {
+	l := &Latency{Interval: interval.String(), Points: make([]LatencyPoint, len(sums))}
+	end := now.Truncate(interval)
+	for i, sum := range sums {
+		l.Points[i].Time = end.Add(-time.Duration(len(sums)-1-i) * interval)
+		if counts[i] > 0 {
+			l.Points[i].Average = float64(sum) / float64(counts[i]) / 1000
+		}
+	}
+	return l
+}
+
+// AddSection adds the counts of a map as a section, most frequent first.
+func (s *Snapshot) AddSection(name string, counts map[string]int) {
+	items := make([]Item, 0, len(counts))
+	for k, v := range counts {
+		items = append(items, Item{Key: k, Count: v})
+	}
+	slices.SortFunc(items, func(a, b Item) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	s.Sections = append(s.Sections, Section{Name: name, Items: items})
+}
+
+// WriteJSON writes the snapshot as indented JSON.
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// WriteCSV writes the snapshot as CSV rows of section, key and count. The
+// summary values come first in a "summary" section.
+func (s *Snapshot) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{
+		{"section", "key", "count"},
+		{"summary", "requests", strconv.Itoa(s.Requests)},
+		{"summary", "total_requests", strconv.Itoa(s.TotalRequests)},
+		{"summary", "unique_visitors", strconv.Itoa(s.UniqueVisitors)},
+		{"summary", "bytes", strconv.FormatInt(s.Bytes, 10)},
+	}
+	for _, section := range s.Sections {
+		for _, item := range section.Items {
+			rows = append(rows, []string{section.Name, item.Key, strconv.Itoa(item.Count)})
+		}
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteText writes a human-readable summary of the snapshot: the totals on
+// one line, then the top entries of each non-empty section.
+func (s *Snapshot) WriteText(w io.Writer, top int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s  window %s  requests %d  visitors %d  bytes %d\n",
+		s.Time.Format(time.RFC3339), s.Window, s.Requests, s.UniqueVisitors, s.Bytes)
+	for _, section := range s.Sections {
+		if len(section.Items) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "  %s:", section.Name)
+		for i, item := range section.Items {
+			if i == top {
+				break
+			}
+			if i > 0 {
+				bw.WriteString(",")
+			}
+			fmt.Fprintf(bw, " %s (%d)", item.Key, item.Count)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// Export writes the snapshot to timestamped JSON and CSV files in dir, e.g.
+// tailnginx-20250602-140322.json, and returns their paths.
+func (s *Snapshot) Export(dir string) ([]string, error) {
+	base := filepath.Join(dir, "tailnginx-"+s.Time.Format("20060102-150405"))
+	writers := []struct {
+		ext   string
+		write func(io.Writer) error
+	}{
+		{".json", s.WriteJSON},
+		{".csv", s.WriteCSV},
+	}
+
+	var paths []string
+	for _, w := range writers {
+		path := base + w.ext
+		if err := writeFile(path, w.write); err != nil {
+			return paths, fmt.Errorf("export %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// tables are the sections exported as separate CSV files by ExportTables,
+// with the header of their key column.
+var tables = []struct {
+	section string
+	column  string
+}{
+	{"paths", "path"},
+	{"ips", "ip"},
+	{"countries", "country"},
+	{"status", "status"},
+	{"referers", "referer"},
+}
+
+// ExportTables writes the top paths, visitors, countries, status codes and
+// referers to timestamped CSV files in dir, one table per file, e.g.
+// tailnginx-20250602-140322-paths.csv, and returns their paths.
+func (s *Snapshot) ExportTables(dir string) ([]string, error) {
+	base := filepath.Join(dir, "tailnginx-"+s.Time.Format("20060102-150405"))
+	var paths []string
+	for _, table := range tables {
+		path := base + "-" + table.section + ".csv"
+		items := s.Items(table.section)
+		write := func(w io.Writer) error {
+			return writeTable(w, table.column, items)
+		}
+		if err := writeFile(path, write); err != nil {
+			return paths, fmt.Errorf("export %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// writeTable writes items as CSV rows of key and request count, most
+// frequent first, under a header naming the key column.
+func writeTable(w io.Writer, column string, items []Item) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{column, "requests"})
+	for _, item := range items {
+		cw.Write([]string{item.Key, strconv.Itoa(item.Count)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Save writes the snapshot to a JSON file, replacing it if it exists.
+func (s *Snapshot) Save(path string) error {
+	if err := writeFile(path, s.WriteJSON); err != nil {
+		return fmt.Errorf("save %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeFile creates path and fills it with write.
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,153 @@
+package stats
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSnapshot() *Snapshot {
+	s := &Snapshot{
+		Time:          time.Date(2025, 6, 2, 14, 3, 22, 0, time.UTC),
+		LogPath:       "/var/log/nginx/access.log",
+		Window:        "1h",
+		Requests:      6,
+		TotalRequests: 10,
+		Bytes:         4096,
+	}
+	s.AddSection("paths", map[string]int{"/b": 2, "/a": 2, "/login": 2})
+	return s
+}
+
+func TestAddSectionOrder(t *testing.T) {
+	s := testSnapshot()
+	items := s.Sections[0].Items
+	want := []string{"/a", "/b", "/login"}
+	for i, key := range want {
+		if items[i].Key != key {
+			t.Errorf("item %d = %q, want %q (count desc, then key)", i, items[i].Key, key)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b strings.Builder
+	if err := testSnapshot().WriteCSV(&b); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	out := b.String()
+	for _, line := range []string{"section,key,count", "summary,requests,6", "paths,/login,2"} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("CSV missing %q:\n%s", line, out)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := testSnapshot().Export(dir)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "tailnginx-20250602-140322.json"),
+		filepath.Join(dir, "tailnginx-20250602-140322.csv"),
+	}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("Export() = %v, want %v", paths, want)
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Snapshot
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("exported JSON is invalid: %v", err)
+	}
+	if got.Window != "1h" || len(got.Sections) != 1 || got.Sections[0].Name != "paths" {
+		t.Errorf("exported snapshot = %+v", got)
+	}
+
+	if _, err := testSnapshot().Export(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Export() to a missing directory should fail")
+	}
+}
+
+func TestExportTables(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := testSnapshot().ExportTables(dir)
+	if err != nil {
+		t.Fatalf("ExportTables() error = %v", err)
+	}
+	if len(paths) != 5 {
+		t.Fatalf("ExportTables() = %v, want 5 files", paths)
+	}
+	if want := filepath.Join(dir, "tailnginx-20250602-140322-paths.csv"); paths[0] != want {
+		t.Errorf("first file = %q, want %q", paths[0], want)
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "path,requests\n/a,2\n/b,2\n/login,2\n"; string(data) != want {
+		t.Errorf("paths table = %q, want %q", data, want)
+	}
+
+	// Sections missing from the snapshot are written with the header only
+	data, err = os.ReadFile(paths[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "referer,requests\n"; string(data) != want {
+		t.Errorf("referers table = %q, want %q", data, want)
+	}
+
+	if _, err := testSnapshot().ExportTables(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ExportTables() to a missing directory should fail")
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := testSnapshot().Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Snapshot
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("saved JSON is invalid: %v", err)
+	}
+	if got.Requests != 6 || len(got.Sections) != 1 {
+		t.Errorf("saved snapshot = %+v", got)
+	}
+
+	if err := testSnapshot().Save(filepath.Join(path, "nested.json")); err == nil {
+		t.Error("Save() below a file should fail")
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	s := testSnapshot()
+	s.AddSection("ips", nil)
+	var b strings.Builder
+	if err := s.WriteText(&b, 2); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	want := "2025-06-02T14:03:22Z  window 1h  requests 6  visitors 0  bytes 4096\n" +
+		"  paths: /a (2), /b (2)\n"
+	if got := b.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package stats
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Output receives the snapshot of each summary period, e.g. to post it to
+// a chat service.
+type Output func(s *Snapshot) error
+
+// Summarizer aggregates parsed log entries per period and hands the
+// snapshot of each period to an output. Every output has its own period. A
+// nil Summarizer ignores everything it is given, so it can be passed around
+// when no output is configured. It is safe for concurrent use.
+type Summarizer struct {
+	mu      sync.Mutex
+	logPath string
+	periods []*period
+}
+
+// period is the aggregation of the entries for an output since its last
+// snapshot.
+type period struct {
+	every  time.Duration
+	agg    *Aggregator
+	output Output
+}
+
+// NewSummarizer creates a summarizer of the entries of a log without
+// outputs.
+func NewSummarizer(logPath string) *Summarizer {
+	return &Summarizer{logPath: logPath}
+}
+
+// AddOutput adds an output a snapshot is handed to every period. Outputs
+// must be added before Run.
+func (s *Summarizer) AddOutput(every time.Duration, out Output) {
+	s.mu.Lock()
+	s.periods = append(s.periods, &period{every: every, agg: NewAggregator(), output: out})
+	s.mu.Unlock()
+}
+
+// Observe counts a parsed log entry.
+func (s *Summarizer) Observe(v *parser.Visitor) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	for _, p := range s.periods {
+		p.agg.Add(v)
+	}
+	s.mu.Unlock()
+}
+
+// Run hands a snapshot to each output every period and passes their errors
+// to report. It never returns.
+func (s *Summarizer) Run(report func(error)) {
+	s.mu.Lock()
+	periods := s.periods
+	s.mu.Unlock()
+	for _, p := range periods {
+		go func() {
+			ticker := time.NewTicker(p.every)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				if err := s.flush(p, now); err != nil {
+					report(err)
+				}
+			}
+		}()
+	}
+	select {}
+}
+
+// Flush hands the snapshot of the entries counted since the previous flush
+// to every output and starts counting anew. It returns the errors of all
+// outputs.
+func (s *Summarizer) Flush(now time.Time) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	periods := s.periods
+	s.mu.Unlock()
+
+	var errs []error
+	for _, p := range periods {
+		errs = append(errs, s.flush(p, now))
+	}
+	return errors.Join(errs...)
+}
+
+// flush hands the snapshot of a period to its output.
+func (s *Summarizer) flush(p *period, now time.Time) error {
+	s.mu.Lock()
+	snap := p.agg.Flush(now, s.logPath, p.every.String())
+	s.mu.Unlock()
+	return p.output(snap)
+}
@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestSummarizer(t *testing.T) {
+	var nilSummarizer *Summarizer
+	nilSummarizer.Observe(&parser.Visitor{})
+	if err := nilSummarizer.Flush(time.Now()); err != nil {
+		t.Errorf("nil Flush() = %v", err)
+	}
+
+	s := NewSummarizer("/var/log/nginx/access.log")
+	var got, daily []*Snapshot
+	s.AddOutput(time.Hour, func(snap *Snapshot) error {
+		got = append(got, snap)
+		return nil
+	})
+	s.AddOutput(24*time.Hour, func(snap *Snapshot) error {
+		daily = append(daily, snap)
+		return errors.New("unreachable")
+	})
+
+	now := time.Date(2025, 10, 10, 13, 0, 0, 0, time.UTC)
+	s.Observe(&parser.Visitor{Time: now, IP: "203.0.113.7", Path: "/a", Status: 200, Bytes: 10})
+	s.Observe(&parser.Visitor{Time: now, IP: "203.0.113.8", Path: "/a", Status: 500, Bytes: 5})
+	if err := s.Flush(now); err == nil || err.Error() != "unreachable" {
+		t.Errorf("Flush() error = %v, want the output error", err)
+	}
+	if err := s.Flush(now.Add(time.Hour)); err == nil {
+		t.Error("Flush() should return the output error every time")
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("outputs got %d snapshots, want 2", len(got))
+	}
+	first := got[0]
+	if first.Requests != 2 || first.UniqueVisitors != 2 || first.Bytes != 15 || first.Window != "1h0m0s" {
+		t.Errorf("first snapshot = %+v", first)
+	}
+	if paths := first.Items("paths"); len(paths) != 1 || paths[0] != (Item{Key: "/a", Count: 2}) {
+		t.Errorf("paths = %+v", paths)
+	}
+	if got[1].Requests != 0 {
+		t.Errorf("second snapshot has %d requests, want 0", got[1].Requests)
+	}
+	// Each output counts the entries on its own
+	if len(daily) != 2 || daily[0].Requests != 2 || daily[0].Window != "24h0m0s" {
+		t.Errorf("daily snapshots = %+v", daily)
+	}
+}
@@ -0,0 +1,160 @@
+package stats
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dimensions of the SVG charts of HTML reports, in pixels
+const (
+	chartWidth  = 720
+	chartHeight = 200
+	chartLeft   = 56 // Room for the value labels
+	chartRight  = 10
+	chartTop    = 10
+	chartBottom = 30 // Room for the time labels
+	barHeight   = 22 // Row of the status code chart
+	barLeft     = 48 // Room for the status codes
+	barRight    = 130
+)
+
+// statusColors are the colors of the status classes, by first digit.
+var statusColors = map[string]string{
+	"1": "#888888",
+	"2": "#2e7d32",
+	"3": "#1565c0",
+	"4": "#ef6c00",
+	"5": "#c62828",
+}
+
+// rateChart draws the request rate series as a line chart of requests per
+// second, or returns "" when there is nothing to draw.
+func rateChart(r *Series) template.HTML {
+	if r == nil || len(r.Points) < 2 {
+		return ""
+	}
+	interval, err := time.ParseDuration(r.Interval)
+	if err != nil || interval <= 0 {
+		return ""
+	}
+	times := make([]time.Time, len(r.Points))
+	values := make([]float64, len(r.Points))
+	for i, p := range r.Points {
+		times[i] = p.Time
+		values[i] = float64(p.Requests) / interval.Seconds()
+	}
+	return lineChart("Requests per second", times, values, "/s", "#1565c0")
+}
+
+// latencyChart draws the latency series as a line chart of milliseconds,
+// or returns "" when there is nothing to draw.
+func latencyChart(l *Latency) template.HTML {
+	if l == nil || len(l.Points) < 2 {
+		return ""
+	}
+	times := make([]time.Time, len(l.Points))
+	values := make([]float64, len(l.Points))
+	for i, p := range l.Points {
+		times[i] = p.Time
+		values[i] = p.Average
+	}
+	return lineChart("Average request time in milliseconds", times, values, " ms", "#6a1b9a")
+}
+
+// lineChart draws values at regular times as a filled line chart with the
+// zero, middle and top values and the first and last times as labels.
+func lineChart(title string, times []time.Time, values []float64, unit, color string) template.HTML {
+	top := niceCeil(slices.Max(values))
+	plotWidth := float64(chartWidth - chartLeft - chartRight)
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+	base := float64(chartTop) + plotHeight
+	x := func(i int) float64 { return chartLeft + plotWidth*float64(i)/float64(len(values)-1) }
+	y := func(v float64) float64 { return base - plotHeight*v/top }
+
+	var b strings.Builder
+	openSVG(&b, chartWidth, chartHeight, title)
+	for _, v := range []float64{0, top / 2, top} {
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`+"\n", chartLeft, y(v), chartWidth-chartRight, y(v))
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" dominant-baseline="middle">%s%s</text>`+"\n",
+			chartLeft-6, y(v), formatValue(v), template.HTMLEscapeString(unit))
+	}
+	var line strings.Builder
+	for i, v := range values {
+		fmt.Fprintf(&line, "%.1f,%.1f ", x(i), y(v))
+	}
+	points := strings.TrimSpace(line.String())
+	fmt.Fprintf(&b, `<polygon points="%.1f,%.1f %s %.1f,%.1f" fill="%s" fill-opacity="0.15"/>`+"\n",
+		x(0), base, points, x(len(values)-1), base, color)
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", points, color)
+	fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", chartLeft, chartHeight-8, times[0].Format("15:04:05"))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n",
+		chartWidth-chartRight, chartHeight-8, times[len(times)-1].Format("15:04:05"))
+	b.WriteString("</svg>")
+	return template.HTML(b.String())
+}
+
+// statusChart draws the top status codes as horizontal bars colored by
+// class, with their count and share, or returns "" without requests.
+func statusChart(s *Snapshot, top int) template.HTML {
+	items := s.Items("status")
+	if top > 0 && len(items) > top {
+		items = items[:top]
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	height := len(items)*barHeight + 4
+	plotWidth := float64(chartWidth - barLeft - barRight)
+	longest := float64(items[0].Count)
+
+	var b strings.Builder
+	openSVG(&b, chartWidth, height, "Requests by status code")
+	for i, item := range items {
+		y := i*barHeight + 2
+		color, ok := statusColors[item.Key[:min(len(item.Key), 1)]]
+		if !ok {
+			color = statusColors["1"]
+		}
+		width := max(plotWidth*float64(item.Count)/longest, 1)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" dominant-baseline="middle">%s</text>`+"\n",
+			barLeft-6, y+barHeight/2, template.HTMLEscapeString(item.Key))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"/>`+"\n", barLeft, y+3, width, barHeight-6, color)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" dominant-baseline="middle">%d (%.1f%%)</text>`+"\n",
+			float64(barLeft)+width+6, y+barHeight/2, item.Count, s.share(item.Count))
+	}
+	b.WriteString("</svg>")
+	return template.HTML(b.String())
+}
+
+// openSVG starts an inline SVG image described by title.
+func openSVG(b *strings.Builder, width, height int, title string) {
+	title = template.HTMLEscapeString(title)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="%s" font-family="sans-serif" font-size="12" fill="#222">`+"\n",
+		width, height, width, height, title)
+	fmt.Fprintf(b, "<title>%s</title>\n", title)
+}
+
+// niceCeil rounds a positive value up to 1, 2 or 5 times a power of ten,
+// for round axis labels. It returns 1 for values that are not positive.
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	scale := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*scale {
+			return m * scale
+		}
+	}
+	return 10 * scale
+}
+
+// formatValue formats an axis value without useless decimals.
+func formatValue(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNiceCeil(t *testing.T) {
+	for v, want := range map[float64]float64{0: 1, -3: 1, 0.7: 1, 1: 1, 1.2: 2, 3: 5, 7: 10, 12: 20, 4200: 5000} {
+		if got := niceCeil(v); got != want {
+			t.Errorf("niceCeil(%v) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestRateChart(t *testing.T) {
+	now := time.Date(2025, 6, 2, 14, 3, 22, 0, time.UTC)
+	chart := string(rateChart(NewSeries(now, 10*time.Second, []uint64{10, 0, 35})))
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="720" height="200"`,
+		"<title>Requests per second</title>",
+		">5/s</text>",   // Top of the axis, 3.5/s rounded up
+		">2.5/s</text>", // Middle
+		`<polyline points="56.0,138.0 383.0,170.0 710.0,58.0"`,
+		">14:03:00</text>",
+	} {
+		if !strings.Contains(chart, want) {
+			t.Errorf("chart lacks %q:\n%s", want, chart)
+		}
+	}
+	if rateChart(nil) != "" || rateChart(NewSeries(now, time.Second, []uint64{1})) != "" {
+		t.Error("rate chart drawn without a series of at least 2 points")
+	}
+}
+
+func TestStatusChart(t *testing.T) {
+	s := testSnapshot()
+	s.AddSection("status", map[string]int{"200": 4, "502": 2, "<x>": 1})
+	chart := string(statusChart(s, 10))
+	for _, want := range []string{
+		`width="542.0" height="16" fill="#2e7d32"`,
+		`width="271.0" height="16" fill="#c62828"`,
+		">2 (33.3%)</text>",
+		"&lt;x&gt;",
+	} {
+		if !strings.Contains(chart, want) {
+			t.Errorf("chart lacks %q:\n%s", want, chart)
+		}
+	}
+	if statusChart(testSnapshot(), 10) != "" {
+		t.Error("status chart drawn without status codes")
+	}
+}
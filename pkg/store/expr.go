@@ -0,0 +1,314 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Expr is a condition on stored requests, e.g.
+// status>=500 && path=~"^/api". Comparisons of a field with a value are
+// combined with && (and), || (or), ! (not) and parentheses; && binds
+// tighter than ||. Operators are ==, !=, <, <=, >, >= and =~, !~ for
+// regular expressions. Values are numbers, double-quoted strings or bare
+// words.
+type Expr interface {
+	Match(v *parser.Visitor) bool
+}
+
+// fields are the fields usable in expressions and groups, with whether they
+// are numeric.
+var fields = map[string]bool{
+	"ip": false, "method": false, "path": false, "protocol": false,
+	"status": true, "bytes": true, "referer": false, "agent": false,
+	"country": false, "request_time": true, "tls_protocol": false,
+	"tls_cipher": false,
+}
+
+// field returns the value of a field of an entry, as a string and, for
+// numeric fields, as a number. Request times are in seconds.
+func field(v *parser.Visitor, name string) (string, float64) {
+	switch name {
+	case "ip":
+		return v.IP, 0
+	case "method":
+		return v.Method, 0
+	case "path":
+		return v.Path, 0
+	case "protocol":
+		return v.Protocol, 0
+	case "status":
+		return strconv.Itoa(v.Status), float64(v.Status)
+	case "bytes":
+		return strconv.Itoa(v.Bytes), float64(v.Bytes)
+	case "referer":
+		return v.Referer, 0
+	case "agent":
+		return v.Agent, 0
+	case "country":
+		return v.Country, 0
+	case "request_time":
+		s := v.RequestTime.Seconds()
+		return strconv.FormatFloat(s, 'f', -1, 64), s
+	case "tls_protocol":
+		return v.TLSProtocol, 0
+	case "tls_cipher":
+		return v.TLSCipher, 0
+	}
+	return "", 0
+}
+
+type andExpr struct{ left, right Expr }
+
+func (e andExpr) Match(v *parser.Visitor) bool { return e.left.Match(v) && e.right.Match(v) }
+
+type orExpr struct{ left, right Expr }
+
+func (e orExpr) Match(v *parser.Visitor) bool { return e.left.Match(v) || e.right.Match(v) }
+
+type notExpr struct{ expr Expr }
+
+func (e notExpr) Match(v *parser.Visitor) bool { return !e.expr.Match(v) }
+
+// comparison compares a field with a value.
+type comparison struct {
+	field  string
+	op     string
+	text   string
+	number float64        // Value of numeric fields
+	re     *regexp.Regexp // Pattern of =~ and !~
+}
+
+func (c comparison) Match(v *parser.Visitor) bool {
+	text, number := field(v, c.field)
+	switch c.op {
+	case "=~":
+		return c.re.MatchString(text)
+	case "!~":
+		return !c.re.MatchString(text)
+	}
+
+	var cmp int
+	if fields[c.field] {
+		switch {
+		case number < c.number:
+			cmp = -1
+		case number > c.number:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(text, c.text)
+	}
+	switch c.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	}
+	return cmp >= 0
+}
+
+// operators are the comparison operators, longest first.
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">"}
+
+// ParseExpr parses an expression, e.g. status>=500 && path=~"/api".
+func ParseExpr(text string) (Expr, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", text, err)
+	}
+	p := &exprParser{tokens: tokens}
+	e, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", text, err)
+	}
+	return e, nil
+}
+
+// token is a word, a quoted string or a symbol such as && or >=.
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits an expression into tokens.
+func tokenize(text string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(text) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			s, err := strconv.Unquote(text[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %w", i, err)
+			}
+			tokens = append(tokens, token{text: s, quoted: true})
+			i = end + 1
+		case strings.HasPrefix(text[i:], "&&") || strings.HasPrefix(text[i:], "||"):
+			tokens = append(tokens, token{text: text[i : i+2]})
+			i += 2
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: text[i : i+1]})
+			i++
+		case strings.ContainsRune("=!<>", rune(c)):
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(text[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" && c != '!' {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			if op == "" {
+				op = "!" // Negation
+			}
+			tokens = append(tokens, token{text: op})
+			i += len(op)
+		default:
+			end := i
+			for end < len(text) && !isSpecial(rune(text[end])) {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			tokens = append(tokens, token{text: text[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// isSpecial reports whether c ends a bare word.
+func isSpecial(c rune) bool {
+	return unicode.IsSpace(c) || strings.ContainsRune(`"&|()=!<>`, c)
+}
+
+// exprParser is a recursive descent parser of expression tokens.
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+// peek returns the next unquoted symbol or word, or "" at the end.
+func (p *exprParser) peek() string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *exprParser) or() (Expr, error) {
+	left, err := p.and()
+	for err == nil && p.peek() == "||" {
+		p.pos++
+		var right Expr
+		right, err = p.and()
+		left = orExpr{left, right}
+	}
+	return left, err
+}
+
+func (p *exprParser) and() (Expr, error) {
+	left, err := p.unary()
+	for err == nil && p.peek() == "&&" {
+		p.pos++
+		var right Expr
+		right, err = p.unary()
+		left = andExpr{left, right}
+	}
+	return left, err
+}
+
+func (p *exprParser) unary() (Expr, error) {
+	switch p.peek() {
+	case "!":
+		p.pos++
+		e, err := p.unary()
+		return notExpr{e}, err
+	case "(":
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return e, nil
+	}
+	return p.comparison()
+}
+
+func (p *exprParser) comparison() (Expr, error) {
+	if p.pos+3 > len(p.tokens) {
+		return nil, fmt.Errorf("incomplete comparison")
+	}
+	name, op, value := p.tokens[p.pos], p.tokens[p.pos+1], p.tokens[p.pos+2]
+	numeric, ok := fields[name.text]
+	if name.quoted || !ok {
+		return nil, fmt.Errorf("unknown field %q", name.text)
+	}
+	if op.quoted || !isOperator(op.text) {
+		return nil, fmt.Errorf("expected an operator after %s, got %q", name.text, op.text)
+	}
+	if !value.quoted && isSpecial(rune(value.text[0])) {
+		return nil, fmt.Errorf("expected a value after %s%s, got %q", name.text, op.text, value.text)
+	}
+	p.pos += 3
+
+	c := comparison{field: name.text, op: op.text, text: value.text}
+	switch {
+	case op.text == "=~" || op.text == "!~":
+		re, err := regexp.Compile(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", value.text, err)
+		}
+		c.re = re
+	case numeric:
+		n, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s needs a number, got %q", name.text, value.text)
+		}
+		c.number = n
+	}
+	return c, nil
+}
+
+// isOperator reports whether text is a comparison operator.
+func isOperator(text string) bool {
+	for _, o := range operators {
+		if text == o {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestParseExpr(t *testing.T) {
+	v := &parser.Visitor{
+		IP:          "203.0.113.7",
+		Method:      "POST",
+		Path:        "/api/login",
+		Status:      502,
+		Bytes:       0,
+		RequestTime: 1500 * time.Millisecond,
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`status>=500 && path=~"/api"`, true},
+		{`status >= 500 && path =~ "^/admin"`, false},
+		{`status==404 || method==POST`, true},
+		{`status==404 || method==GET && status==502`, false},
+		{`(status==404 || method==GET) && status==502`, false},
+		{`!(status<500)`, true},
+		{`path!~"\\.php$" && ip==203.0.113.7`, true},
+		{`request_time>1.2`, true},
+		{`request_time<=1.5 && bytes<1`, true},
+		{`method != "POST"`, false},
+		{`path > "/a"`, true},
+	}
+	for _, tt := range tests {
+		e, err := ParseExpr(tt.expr)
+		if err != nil {
+			t.Errorf("ParseExpr(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := e.Match(v); got != tt.want {
+			t.Errorf("ParseExpr(%q).Match() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"status",
+		"status>=",
+		"code>=500",
+		"status>=abc",
+		`path=~"("`,
+		`path=="/a`,
+		"status==500 &&",
+		"status==500 & path==/",
+		"(status==500",
+		"status==500)",
+		"status=500",
+		"status==&&",
+	} {
+		if _, err := ParseExpr(expr); err == nil {
+			t.Errorf("ParseExpr(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+// Package store persists parsed log entries in an SQLite database, so that
+// historical questions can be answered with SQL after the fact.
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TimeFormat is the format of the time column, which sorts chronologically
+// and is understood by the SQLite date and time functions, e.g.
+// time >= datetime('now', '-1 day').
+const TimeFormat = "2006-01-02 15:04:05"
+
+// schema creates the requests table, one row per parsed entry. Times are in
+// UTC and request times in seconds.
+const schema = `
+CREATE TABLE IF NOT EXISTS requests (
+	time         TEXT NOT NULL,
+	ip           TEXT NOT NULL,
+	method       TEXT NOT NULL,
+	path         TEXT NOT NULL,
+	protocol     TEXT NOT NULL,
+	status       INTEGER NOT NULL,
+	bytes        INTEGER NOT NULL,
+	referer      TEXT NOT NULL,
+	agent        TEXT NOT NULL,
+	country      TEXT NOT NULL,
+	request_time REAL NOT NULL,
+	tls_protocol TEXT NOT NULL,
+	tls_cipher   TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS requests_time ON requests (time);
+`
+
+const insert = `INSERT INTO requests (time, ip, method, path, protocol, status, bytes,
+	referer, agent, country, request_time, tls_protocol, tls_cipher)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// Store buffers parsed entries and writes them to the database in batches.
+// A nil Store ignores everything it is given, so it can be passed around
+// when persistence is disabled. It is safe for concurrent use.
+type Store struct {
+	db      *sql.DB
+	mu      sync.Mutex
+	pending []row
+	latest  string      // Time of the latest row stored by a previous run, older entries were stored
+	stored  map[row]int // Rows of that second, which may hold entries not stored yet
+}
+
+// row is an entry as stored, one value per column.
+type row struct {
+	time        string
+	ip          string
+	method      string
+	path        string
+	protocol    string
+	status      int
+	bytes       int
+	referer     string
+	agent       string
+	country     string
+	requestTime float64
+	tlsProtocol string
+	tlsCipher   string
+}
+
+// newRow returns the row of an entry.
+func newRow(v *parser.Visitor) row {
+	return row{v.Time.UTC().Format(TimeFormat), v.IP, v.Method, v.Path, v.Protocol,
+		v.Status, v.Bytes, v.Referer, v.Agent, v.Country, v.RequestTime.Seconds(),
+		v.TLSProtocol, v.TLSCipher}
+}
+
+// Open opens or creates the database at path. Entries older than the latest
+// stored one are skipped, and so are those of its second that match a
+// stored row, so that the lines read again on startup are not stored twice
+// while the lines logged later in that second are.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("open store %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open store %s: %w", path, err)
+	}
+
+	s := &Store{db: db}
+	if err := s.loadLatest(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// loadLatest reads the rows of the latest second stored.
+func (s *Store) loadLatest() error {
+	var latest sql.NullString
+	if err := s.db.QueryRow("SELECT MAX(time) FROM requests").Scan(&latest); err != nil || !latest.Valid {
+		return err
+	}
+	rows, err := s.db.Query(`SELECT time, ip, method, path, protocol, status, bytes, referer, agent,
+		country, request_time, tls_protocol, tls_cipher FROM requests WHERE time = ?`, latest.String)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	s.latest = latest.String
+	s.stored = make(map[row]int)
+	for rows.Next() {
+		var r row
+		err := rows.Scan(&r.time, &r.ip, &r.method, &r.path, &r.protocol, &r.status, &r.bytes,
+			&r.referer, &r.agent, &r.country, &r.requestTime, &r.tlsProtocol, &r.tlsCipher)
+		if err != nil {
+			return err
+		}
+		s.stored[r]++
+	}
+	return rows.Err()
+}
+
+// Add queues a parsed entry until the next Flush.
+func (s *Store) Add(v *parser.Visitor) {
+	if s == nil {
+		return
+	}
+	r := newRow(v)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.time < s.latest:
+		return
+	case r.time == s.latest && s.stored[r] > 0:
+		s.stored[r]-- // Each stored row matches one entry read again
+		return
+	}
+	s.pending = append(s.pending, r)
+}
+
+// Run writes the queued entries every interval and passes the errors to
+// report. It never returns.
+func (s *Store) Run(interval time.Duration, report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.Flush(); err != nil {
+			report(err)
+		}
+	}
+}
+
+// Flush writes the queued entries in a single transaction. Entries of a
+// failed transaction are dropped.
+func (s *Store) Flush() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	stmt, err := tx.Prepare(insert)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("store: %w", err)
+	}
+	defer stmt.Close()
+	for _, r := range pending {
+		_, err := stmt.Exec(r.time, r.ip, r.method, r.path, r.protocol, r.status, r.bytes,
+			r.referer, r.agent, r.country, r.requestTime, r.tlsProtocol, r.tlsCipher)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("store: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	return nil
+}
+
+// Close writes the queued entries and closes the database.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	return errors.Join(s.Flush(), s.db.Close())
+}
+
+// Query runs an SQL query and writes its rows to w as an aligned table
+// under a header of the column names.
+func (s *Store) Query(w io.Writer, query string, args ...any) error {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	cells := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			cells[i] = formatValue(v)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// cellEscaper keeps a value on a single table cell.
+var cellEscaper = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// formatValue formats a column value for a table cell.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return cellEscaper.Replace(string(v))
+	case string:
+		return cellEscaper.Replace(v)
+	case time.Time:
+		return v.UTC().Format(TimeFormat)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func testVisitor(sec int, path string, status int) *parser.Visitor {
+	return &parser.Visitor{
+		Time:        time.Date(2025, 6, 2, 14, 0, sec, 0, time.FixedZone("CEST", 2*3600)),
+		IP:          "203.0.113.7",
+		Method:      "GET",
+		Path:        path,
+		Status:      status,
+		Bytes:       512,
+		Agent:       "curl/8.0\tbeta",
+		RequestTime: 250 * time.Millisecond,
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	s.Add(testVisitor(1, "/a", 200))
+	s.Add(testVisitor(2, "/b", 502))
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Lines read again after a restart are skipped, newer ones are stored
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() again error = %v", err)
+	}
+	defer s.Close()
+	s.Add(testVisitor(2, "/b", 502))
+	s.Add(testVisitor(3, "/c", 404))
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var b strings.Builder
+	err = s.Query(&b, "SELECT time, path, status, request_time, agent, NULL AS x FROM requests WHERE status >= ? ORDER BY time", 400)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := "time                 path  status  request_time  agent          x\n" +
+		"2025-06-02 12:00:02  /b    502     0.25          curl/8.0 beta  NULL\n" +
+		"2025-06-02 12:00:03  /c    404     0.25          curl/8.0 beta  NULL\n"
+	if b.String() != want {
+		t.Errorf("Query() =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	if err := s.Query(&b, "SELECT nope FROM requests"); err == nil {
+		t.Error("Query() with an unknown column should fail")
+	}
+}
+
+// TestStoreSameSecond tests that entries logged in the second of the latest
+// stored entry after it are stored after a restart, and those stored before
+// are not stored twice.
+func TestStoreSameSecond(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	s.Add(testVisitor(4, "/a", 200))
+	s.Add(testVisitor(5, "/b", 200))
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() again error = %v", err)
+	}
+	defer s.Close()
+	// Read again, then logged in the same second after the restart,
+	// including the same request a second time
+	s.Add(testVisitor(4, "/a", 200))
+	s.Add(testVisitor(5, "/b", 200))
+	s.Add(testVisitor(5, "/c", 200))
+	s.Add(testVisitor(5, "/b", 200))
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var b strings.Builder
+	if err := s.Query(&b, "SELECT time, path, COUNT(*) AS n FROM requests GROUP BY time, path ORDER BY time, path"); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := "time                 path  n\n" +
+		"2025-06-02 12:00:04  /a    1\n" +
+		"2025-06-02 12:00:05  /b    2\n" +
+		"2025-06-02 12:00:05  /c    1\n"
+	if b.String() != want {
+		t.Errorf("Query() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	s.Add(testVisitor(1, "/", 200))
+	if err := s.Flush(); err != nil {
+		t.Errorf("Flush() on nil = %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() on nil = %v", err)
+	}
+}
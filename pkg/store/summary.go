@@ -0,0 +1,156 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Search selects stored requests for Summarize.
+type Search struct {
+	Where   Expr          // Condition on the requests, nil for all
+	Since   time.Duration // Only the requests of the last duration, 0 for all
+	GroupBy string        // Field to group by, "hour" or "day", empty to list requests
+	Limit   int           // Groups or requests printed, 0 for all
+}
+
+// timeGroups are the time buckets requests can be grouped by besides the
+// expression fields, with the layout of their UTC keys.
+var timeGroups = map[string]string{
+	"hour": "2006-01-02 15:00",
+	"day":  "2006-01-02",
+}
+
+// Summarize writes the requests matching a search to w as an aligned table.
+// With GroupBy, each row is a group with its request count, bytes, 4xx and
+// 5xx counts and average request time, most requests first. Otherwise each
+// row is a request, latest first.
+func (s *Store) Summarize(w io.Writer, search Search, now time.Time) error {
+	if g := search.GroupBy; g != "" {
+		if _, ok := fields[g]; !ok && timeGroups[g] == "" {
+			return fmt.Errorf("unknown group field %q", g)
+		}
+	}
+
+	query := "SELECT time, ip, method, path, protocol, status, bytes, referer, agent, country, request_time, tls_protocol, tls_cipher FROM requests"
+	var args []any
+	if search.Since > 0 {
+		query += " WHERE time >= ?"
+		args = append(args, now.Add(-search.Since).UTC().Format(TimeFormat))
+	}
+	query += " ORDER BY time DESC"
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if search.GroupBy == "" {
+		fmt.Fprintln(tw, "time\tip\tmethod\tpath\tstatus\tbytes\trequest_time")
+	}
+	groups := make(map[string]*group)
+	listed := 0
+	for rows.Next() {
+		var v parser.Visitor
+		var t string
+		var seconds float64
+		err := rows.Scan(&t, &v.IP, &v.Method, &v.Path, &v.Protocol, &v.Status, &v.Bytes,
+			&v.Referer, &v.Agent, &v.Country, &seconds, &v.TLSProtocol, &v.TLSCipher)
+		if err != nil {
+			return err
+		}
+		v.Time, _ = time.Parse(TimeFormat, t)
+		v.RequestTime = time.Duration(seconds * float64(time.Second))
+		if search.Where != nil && !search.Where.Match(&v) {
+			continue
+		}
+
+		if search.GroupBy == "" {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n", t, v.IP, v.Method,
+				formatValue(v.Path), v.Status, v.Bytes, strconv.FormatFloat(seconds, 'f', -1, 64))
+			if listed++; listed == search.Limit {
+				break
+			}
+			continue
+		}
+
+		key, _ := field(&v, search.GroupBy)
+		if layout := timeGroups[search.GroupBy]; layout != "" {
+			key = v.Time.Format(layout)
+		}
+		g := groups[key]
+		if g == nil {
+			g = &group{key: key}
+			groups[key] = g
+		}
+		g.add(&v)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if search.GroupBy != "" {
+		sorted := make([]*group, 0, len(groups))
+		for _, g := range groups {
+			sorted = append(sorted, g)
+		}
+		slices.SortFunc(sorted, func(a, b *group) int {
+			if c := cmp.Compare(b.requests, a.requests); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.key, b.key)
+		})
+		if search.Limit > 0 && len(sorted) > search.Limit {
+			sorted = sorted[:search.Limit]
+		}
+		fmt.Fprintf(tw, "%s\trequests\tbytes\t4xx\t5xx\tavg_request_time\n", search.GroupBy)
+		for _, g := range sorted {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\n", formatValue(g.key), g.requests, g.bytes,
+				g.clientErrors, g.serverErrors, g.avgTime())
+		}
+	}
+	return tw.Flush()
+}
+
+// group counts the requests sharing a field value.
+type group struct {
+	key          string
+	requests     int
+	bytes        int64
+	clientErrors int
+	serverErrors int
+	timed        int
+	totalTime    time.Duration
+}
+
+func (g *group) add(v *parser.Visitor) {
+	g.requests++
+	g.bytes += int64(v.Bytes)
+	switch v.Status / 100 {
+	case 4:
+		g.clientErrors++
+	case 5:
+		g.serverErrors++
+	}
+	if v.RequestTime > 0 {
+		g.timed++
+		g.totalTime += v.RequestTime
+	}
+}
+
+// avgTime returns the average request time in seconds, or "-" without
+// request times.
+func (g *group) avgTime() string {
+	if g.timed == 0 {
+		return "-"
+	}
+	avg := g.totalTime / time.Duration(g.timed)
+	return strconv.FormatFloat(avg.Seconds(), 'f', 3, 64)
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestSummarize(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "requests.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	now := time.Date(2025, 6, 2, 14, 30, 0, 0, time.UTC)
+	add := func(ago time.Duration, path string, status int, rt time.Duration) {
+		s.Add(&parser.Visitor{Time: now.Add(-ago), IP: "203.0.113.7", Method: "GET", Path: path, Status: status, Bytes: 100, RequestTime: rt})
+	}
+	add(48*time.Hour, "/api/old", 500, 0)
+	add(3*time.Hour, "/api/a", 502, time.Second)
+	add(2*time.Hour, "/api/a", 504, 3*time.Second)
+	add(time.Hour, "/api/b", 500, 0)
+	add(time.Minute, "/home", 500, 0)
+	add(time.Second, "/api/b", 200, 0)
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	where, err := ParseExpr(`status>=500 && path=~"/api"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	err = s.Summarize(&b, Search{Where: where, Since: 24 * time.Hour, GroupBy: "path"}, now)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	want := "path    requests  bytes  4xx  5xx  avg_request_time\n" +
+		"/api/a  2         200    0    2    2.000\n" +
+		"/api/b  1         100    0    1    -\n"
+	if b.String() != want {
+		t.Errorf("Summarize() by path =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := s.Summarize(&b, Search{Where: where, Limit: 2}, now); err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	want = "time                 ip           method  path    status  bytes  request_time\n" +
+		"2025-06-02 13:30:00  203.0.113.7  GET     /api/b  500     100    0\n" +
+		"2025-06-02 12:30:00  203.0.113.7  GET     /api/a  504     100    3\n"
+	if b.String() != want {
+		t.Errorf("Summarize() list =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := s.Summarize(&b, Search{GroupBy: "day"}, now); err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if !strings.Contains(b.String(), "2025-06-02  5 ") || !strings.Contains(b.String(), "2025-05-31  1 ") {
+		t.Errorf("Summarize() by day =\n%s", b.String())
+	}
+
+	if err := s.Summarize(&b, Search{GroupBy: "nope"}, now); err == nil {
+		t.Error("Summarize() with an unknown group should fail")
+	}
+}
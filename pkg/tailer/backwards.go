@@ -0,0 +1,57 @@
+package tailer
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// backwardsChunk is the number of bytes read at a time by ReadBackwards.
+const backwardsChunk = 64 * 1024
+
+// ReadBackwards calls fn with the lines of a file, last first, until fn
+// returns false or the start of the file is reached. Only the lines read
+// are kept in memory, so the recent end of a large log is read quickly.
+func ReadBackwards(path string, fn func(line string) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	pos := stat.Size()
+	var rest []byte // Start of the file part read, up to its first newline
+	buf := make([]byte, backwardsChunk)
+	for pos > 0 {
+		n := int64(len(buf))
+		if n > pos {
+			n = pos
+		}
+		pos -= n
+		if _, err := file.ReadAt(buf[:n], pos); err != nil && err != io.EOF {
+			return err
+		}
+		data := append(buf[:n:n], rest...)
+		for {
+			i := bytes.LastIndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			if line := data[i+1:]; len(line) > 0 {
+				if !fn(string(bytes.TrimSuffix(line, []byte("\r")))) {
+					return nil
+				}
+			}
+			data = data[:i]
+		}
+		rest = append([]byte(nil), data...)
+	}
+	if len(rest) > 0 {
+		fn(string(bytes.TrimSuffix(rest, []byte("\r"))))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package tailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadBackwards(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	var want []string
+	var content strings.Builder
+	// Lines longer than a chunk in total, some spanning chunk boundaries
+	for i := 0; i < 3000; i++ {
+		line := fmt.Sprintf("line %d %s", i, strings.Repeat("x", i%97))
+		want = append(want, line)
+		content.WriteString(line + "\n")
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	if err := ReadBackwards(path, func(line string) bool {
+		got = append(got, line)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %d lines, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[len(want)-1-i] {
+			t.Fatalf("line %d = %q, want %q", i, got[i], want[len(want)-1-i])
+		}
+	}
+
+	got = nil
+	ReadBackwards(path, func(line string) bool {
+		got = append(got, line)
+		return len(got) < 2
+	})
+	if len(got) != 2 || got[1] != want[len(want)-2] {
+		t.Errorf("stopped after %q, want the last 2 lines", got)
+	}
+}
+
+func TestReadBackwardsUnterminated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	os.WriteFile(path, []byte("first\r\n\nsecond\nthird"), 0o644)
+	var got []string
+	if err := ReadBackwards(path, func(line string) bool {
+		got = append(got, line)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "third,second,first" {
+		t.Errorf("read %q", got)
+	}
+
+	if err := ReadBackwards(filepath.Join(t.TempDir(), "missing.log"), nil); err == nil {
+		t.Error("ReadBackwards() on a missing file should fail")
+	}
+}
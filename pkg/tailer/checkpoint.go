@@ -0,0 +1,133 @@
+package tailer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/nxadm/tail"
+)
+
+// Position is how far a log was read, to resume reading it where a previous
+// run stopped.
+type Position struct {
+	Path   string `json:"path"`
+	Inode  uint64 `json:"inode"`  // Identifies the file, which a rotation replaces
+	Offset int64  `json:"offset"` // Bytes read, up to the end of the last line
+}
+
+// Line is a line of a log and the position of the log after it.
+type Line struct {
+	Text string
+	Position
+}
+
+// FollowFiles tails several files like TailFiles from their end, or from
+// their position in from, if any. A file replaced since, e.g. by a rotation,
+// is read from its start. Each line is sent with the position after it, to
+// be saved with SavePositions once it is handled.
+func FollowFiles(paths []string, from []Position, done <-chan struct{}) (<-chan Line, error) {
+	var sources []<-chan Line
+	for _, path := range paths {
+		out := make(chan Line, 1000)
+		go follow(path, startPosition(path, from), out, done)
+		sources = append(sources, out)
+	}
+	return merge(sources), nil
+}
+
+// startPosition returns where to start tailing path: at its position in
+// from if the file is the same and was not truncated, at its start if it was
+// replaced or truncated, and at its end without a position.
+func startPosition(path string, from []Position) *tail.SeekInfo {
+	end := &tail.SeekInfo{Offset: 0, Whence: io.SeekEnd}
+	info, err := os.Stat(path)
+	if err != nil {
+		return end
+	}
+	for _, p := range from {
+		if p.Path != path {
+			continue
+		}
+		if p.Inode != inode(info) || p.Offset > info.Size() {
+			return &tail.SeekInfo{Offset: 0, Whence: io.SeekStart}
+		}
+		return &tail.SeekInfo{Offset: p.Offset, Whence: io.SeekStart}
+	}
+	return end
+}
+
+// follow tails path from start and sends its lines with their position to
+// out, which is closed when tailing stops.
+func follow(path string, start *tail.SeekInfo, out chan<- Line, done <-chan struct{}) {
+	defer close(out)
+	t, err := tail.TailFile(path, tail.Config{Follow: true, ReOpen: true, Location: start, Logger: tail.DiscardingLogger})
+	if err != nil {
+		return
+	}
+
+	// The file is identified again when the offsets go back, after it was
+	// reopened
+	var ino uint64
+	offset := int64(-1)
+	for {
+		select {
+		case <-done:
+			t.Cleanup()
+			return
+		case line, ok := <-t.Lines:
+			if !ok {
+				return
+			}
+			if line.Err != nil {
+				continue
+			}
+			if line.SeekInfo.Offset < offset || offset < 0 {
+				if info, err := os.Stat(path); err == nil {
+					ino = inode(info)
+				}
+			}
+			offset = line.SeekInfo.Offset
+			out <- Line{Text: line.Text, Position: Position{Path: path, Inode: ino, Offset: offset}}
+		}
+	}
+}
+
+// LoadPositions reads the positions saved by SavePositions. A missing file
+// yields none.
+func LoadPositions(path string) ([]Position, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var positions []Position
+	if err := json.Unmarshal(data, &positions); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %w", path, err)
+	}
+	return positions, nil
+}
+
+// SavePositions writes positions to a file, creating its directory if
+// needed. The file is replaced atomically so that a crash never leaves it
+// half written.
+func SavePositions(path string, positions []Position) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(positions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,113 @@
+package tailer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// receive returns the next line of lines, failing the test after a second.
+func receive(t *testing.T, lines <-chan Line) Line {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for a line")
+		return Line{}
+	}
+}
+
+func appendLine(t *testing.T, path, line string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollowFilesResumes(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "access.log")
+	checkpoint := filepath.Join(dir, "state", "checkpoint.json")
+	appendLine(t, logFile, "old")
+
+	// Without a position, only new lines are read
+	done := make(chan struct{})
+	lines, err := FollowFiles([]string{logFile}, nil, done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	appendLine(t, logFile, "first")
+	line := receive(t, lines)
+	info, err := os.Stat(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Position{Path: logFile, Inode: inode(info), Offset: int64(len("old\nfirst\n"))}
+	if line.Text != "first" || line.Position != want {
+		t.Fatalf("line = %+v, want first at %+v", line, want)
+	}
+	if err := SavePositions(checkpoint, []Position{line.Position}); err != nil {
+		t.Fatal(err)
+	}
+	close(done)
+
+	// Lines written while stopped are read on resume
+	appendLine(t, logFile, "while stopped")
+	from, err := LoadPositions(checkpoint)
+	if err != nil || len(from) != 1 || from[0] != line.Position {
+		t.Fatalf("LoadPositions() = %+v, %v, want %+v", from, err, line.Position)
+	}
+	done = make(chan struct{})
+	defer close(done)
+	lines, err = FollowFiles([]string{logFile}, from, done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := receive(t, lines); line.Text != "while stopped" {
+		t.Errorf("first line on resume = %q, want %q", line.Text, "while stopped")
+	}
+}
+
+func TestStartPosition(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "access.log")
+	appendLine(t, logFile, "a line")
+	info, err := os.Stat(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if start := startPosition(logFile, nil); start.Whence != io.SeekEnd {
+		t.Errorf("without a position, start = %+v, want the end", start)
+	}
+	same := []Position{{Path: logFile, Inode: inode(info), Offset: 3}}
+	if start := startPosition(logFile, same); start.Whence != io.SeekStart || start.Offset != 3 {
+		t.Errorf("same file, start = %+v, want offset 3", start)
+	}
+	truncated := []Position{{Path: logFile, Inode: inode(info), Offset: 100}}
+	if start := startPosition(logFile, truncated); start.Whence != io.SeekStart || start.Offset != 0 {
+		t.Errorf("truncated file, start = %+v, want its start", start)
+	}
+	if filepath.Separator == '/' {
+		rotated := []Position{{Path: logFile, Inode: inode(info) + 1, Offset: 3}}
+		if start := startPosition(logFile, rotated); start.Whence != io.SeekStart || start.Offset != 0 {
+			t.Errorf("rotated file, start = %+v, want its start", start)
+		}
+	}
+}
+
+func TestLoadPositionsMissing(t *testing.T) {
+	positions, err := LoadPositions(filepath.Join(t.TempDir(), "none.json"))
+	if err != nil || positions != nil {
+		t.Errorf("LoadPositions() = %+v, %v, want none", positions, err)
+	}
+}
@@ -0,0 +1,119 @@
+package tailer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRotated is the highest number of the rotated files read by TailHistory.
+const maxRotated = 100
+
+// TailHistory sends the lines of the rotated files of path, oldest first,
+// then the lines of path. When follow is true, new lines of path are sent
+// as they are written, like TailLines. Rotated files are named like
+// logrotate's, e.g. access.log.1 and access.log.2.gz; those last written
+// before since are skipped. The returned channel is closed when all the
+// lines were sent without follow, or when done is closed.
+func TailHistory(path string, since time.Time, follow bool, done <-chan struct{}) (<-chan string, error) {
+	rotated := RotatedFiles(path, since)
+	out := make(chan string, 1000)
+	go func() {
+		for _, file := range rotated {
+			if !readAll(file, out, done) {
+				close(out)
+				return
+			}
+		}
+		if follow {
+			startTailing(path, false, out, done)
+			return
+		}
+		readAll(path, out, done)
+		close(out)
+	}()
+	return out, nil
+}
+
+// TailFilesHistory calls TailHistory for several files, and merges their
+// lines into the returned channel like TailFiles.
+func TailFilesHistory(paths []string, since time.Time, follow bool, done <-chan struct{}) (<-chan string, error) {
+	var sources []<-chan string
+	for _, path := range paths {
+		lines, err := TailHistory(path, since, follow, done)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, lines)
+	}
+	return merge(sources), nil
+}
+
+// RotatedFiles returns the rotated files of path, oldest first, without
+// those last written before since.
+func RotatedFiles(path string, since time.Time) []string {
+	type rotatedFile struct {
+		path string
+		n    int
+	}
+	var files []rotatedFile
+	for n := 1; n <= maxRotated; n++ {
+		found := false
+		for _, name := range []string{path + "." + strconv.Itoa(n), path + "." + strconv.Itoa(n) + ".gz"} {
+			info, err := os.Stat(name)
+			if err != nil {
+				continue
+			}
+			found = true
+			if !info.ModTime().Before(since) {
+				files = append(files, rotatedFile{name, n})
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	// Higher numbers are older
+	sort.SliceStable(files, func(i, j int) bool { return files[i].n > files[j].n })
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
+
+// readAll sends the lines of a file, decompressed when gzipped, and returns
+// false when done was closed. Unreadable files are skipped.
+func readAll(path string, out chan<- string, done <-chan struct{}) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return true
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		select {
+		case out <- scanner.Text():
+		case <-done:
+			return false
+		}
+	}
+	return true
+}
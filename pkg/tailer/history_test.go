@@ -0,0 +1,111 @@
+package tailer
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeRotated creates a log with rotated files, the oldest gzipped.
+func writeRotated(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+
+	f, err := os.Create(path + ".3.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	_, _ = gz.Write([]byte("line 1\n"))
+	gz.Close()
+	f.Close()
+	for name, content := range map[string]string{".2": "line 2\n", ".1": "line 3\nline 4\n", "": "line 5\n"} {
+		if err := os.WriteFile(path+name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	_ = os.Chtimes(path+".3.gz", old, old)
+	return path
+}
+
+func collect(t *testing.T, lines <-chan string) []string {
+	t.Helper()
+	var got []string
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return got
+			}
+			got = append(got, line)
+		case <-timeout:
+			t.Fatalf("Timeout waiting for the channel to close, got %v", got)
+		}
+	}
+}
+
+func TestTailHistory(t *testing.T) {
+	path := writeRotated(t)
+	done := make(chan struct{})
+	defer close(done)
+
+	lines, err := TailHistory(path, time.Time{}, false, done)
+	if err != nil {
+		t.Fatalf("TailHistory() error = %v", err)
+	}
+	want := []string{"line 1", "line 2", "line 3", "line 4", "line 5"}
+	if got := collect(t, lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+
+	// Files last written before since are skipped
+	lines, err = TailHistory(path, time.Now().Add(-24*time.Hour), false, done)
+	if err != nil {
+		t.Fatalf("TailHistory() error = %v", err)
+	}
+	if got := collect(t, lines); !reflect.DeepEqual(got, want[1:]) {
+		t.Errorf("lines = %v, want %v", got, want[1:])
+	}
+}
+
+func TestTailHistoryFollow(t *testing.T) {
+	path := writeRotated(t)
+	done := make(chan struct{})
+	defer close(done)
+
+	lines, err := TailFilesHistory([]string{path}, time.Time{}, true, done)
+	if err != nil {
+		t.Fatalf("TailFilesHistory() error = %v", err)
+	}
+	for _, want := range []string{"line 1", "line 2", "line 3", "line 4", "line 5"} {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Fatalf("line = %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timeout waiting for %q", want)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("line 6\n")
+	f.Close()
+	select {
+	case got := <-lines:
+		if got != "line 6" {
+			t.Errorf("line = %q, want line 6", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("Timeout waiting for a new line")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package tailer
+
+import (
+	"os"
+	"syscall"
+)
+
+// inode returns the inode number of a file, which identifies it across
+// renames.
+func inode(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
@@ -5,6 +5,7 @@ import (
 	"bufio"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/nxadm/tail"
 )
@@ -30,6 +31,50 @@ func TailLines(path string, fromEnd bool, done <-chan struct{}) (<-chan string,
 	return out, nil
 }
 
+// TailFiles tails several files like TailLines, and merges their lines into
+// the returned channel. The channel is closed when all the files stopped
+// tailing.
+func TailFiles(paths []string, fromEnd bool, done <-chan struct{}) (<-chan string, error) {
+	if len(paths) == 1 {
+		return TailLines(paths[0], fromEnd, done)
+	}
+
+	var sources []<-chan string
+	for _, path := range paths {
+		lines, err := TailLines(path, fromEnd, done)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, lines)
+	}
+	return merge(sources), nil
+}
+
+// merge sends the lines of several channels to the returned channel, which
+// is closed when they all are.
+func merge[T any](sources []<-chan T) <-chan T {
+	if len(sources) == 1 {
+		return sources[0]
+	}
+
+	out := make(chan T, 1000)
+	var wg sync.WaitGroup
+	for _, lines := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for line := range lines {
+				out <- line
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
 // readLastNLines reads the last N lines from a file and sends to channel
 func readLastNLines(path string, n int, out chan<- string) error {
 	file, err := os.Open(path)
@@ -216,3 +216,56 @@ func TestReadLastNLinesNonExistent(t *testing.T) {
 		t.Error("readLastNLines() should error on non-existent file")
 	}
 }
+
+func TestTailFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	paths := []string{filepath.Join(tmpDir, "a.log"), filepath.Join(tmpDir, "b.log")}
+	for _, path := range paths {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	done := make(chan struct{})
+	lines, err := TailFiles(paths, true, done)
+	if err != nil {
+		t.Fatalf("TailFiles() error = %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond) // Give tailers time to start
+	for _, path := range paths {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open file for append: %v", err)
+		}
+		_, _ = f.WriteString(filepath.Base(path) + "\n")
+		f.Close()
+	}
+
+	got := make(map[string]bool)
+	for len(got) < 2 {
+		select {
+		case line := <-lines:
+			got[line] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timeout waiting for lines, got %v", got)
+		}
+	}
+	if !got["a.log"] || !got["b.log"] {
+		t.Errorf("lines = %v, want a.log and b.log", got)
+	}
+
+	// The merged channel is closed once every file stopped tailing
+	close(done)
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-lines:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Timeout waiting for the channel to close")
+		}
+	}
+}
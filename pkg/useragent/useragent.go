@@ -0,0 +1,225 @@
+// Package useragent classifies HTTP user agents by browser, operating system,
+// device class and crawler.
+package useragent
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Device classes
+const (
+	DeviceDesktop = "Desktop"
+	DeviceMobile  = "Mobile"
+	DeviceTablet  = "Tablet"
+	DeviceBot     = "Bot"
+	DeviceOther   = "Other" // Scripts, tools and unknown clients
+)
+
+// Unknown is used when a property cannot be determined.
+const Unknown = "Other"
+
+// maxCacheEntries bounds the parser cache; user agents can be attacker-controlled.
+const maxCacheEntries = 10000
+
+// Info describes a parsed user agent.
+type Info struct {
+	Browser string // Browser or client family, e.g. "Chrome", "curl"
+	OS      string // Operating system, e.g. "Windows", "iOS"
+	Device  string // One of the Device* classes
+	Bot     string // Crawler name, empty for non-bots
+}
+
+// IsBot reports whether the user agent is a crawler.
+func (i Info) IsBot() bool {
+	return i.Bot != ""
+}
+
+// token maps a user agent substring to a name.
+type token struct {
+	match string
+	name  string
+}
+
+// knownBots are matched case-sensitively, before the generic bot keywords.
+var knownBots = []token{
+	{"Googlebot", "Googlebot"},
+	{"Google-InspectionTool", "Googlebot"},
+	{"AdsBot-Google", "AdsBot-Google"},
+	{"bingbot", "bingbot"},
+	{"GPTBot", "GPTBot"},
+	{"ChatGPT-User", "ChatGPT-User"},
+	{"OAI-SearchBot", "OAI-SearchBot"},
+	{"ClaudeBot", "ClaudeBot"},
+	{"PerplexityBot", "PerplexityBot"},
+	{"CCBot", "CCBot"},
+	{"AhrefsBot", "AhrefsBot"},
+	{"SemrushBot", "SemrushBot"},
+	{"MJ12bot", "MJ12bot"},
+	{"DotBot", "DotBot"},
+	{"DataForSeoBot", "DataForSeoBot"},
+	{"YandexBot", "YandexBot"},
+	{"Baiduspider", "Baiduspider"},
+	{"DuckDuckBot", "DuckDuckBot"},
+	{"Applebot", "Applebot"},
+	{"Amazonbot", "Amazonbot"},
+	{"Bytespider", "Bytespider"},
+	{"PetalBot", "PetalBot"},
+	{"facebookexternalhit", "Facebook"},
+	{"meta-externalagent", "Meta"},
+	{"Twitterbot", "Twitterbot"},
+	{"LinkedInBot", "LinkedInBot"},
+	{"Slackbot", "Slackbot"},
+	{"Discordbot", "Discordbot"},
+	{"UptimeRobot", "UptimeRobot"},
+	{"Pingdom", "Pingdom"},
+}
+
+// botKeywords identify other crawlers (matched on the lowercased agent).
+var botKeywords = []string{"bot", "crawler", "spider", "crawl", "slurp", "scrapy"}
+
+// browsers are checked in order; more specific tokens come first because
+// most browsers also claim to be Chrome, Safari or Mozilla.
+var browsers = []token{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Opera", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"YaBrowser/", "Yandex"},
+	{"Vivaldi/", "Vivaldi"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"HeadlessChrome/", "Headless Chrome"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Chromium/", "Chromium"},
+	{"Version/", "Safari"}, // Safari sends Version/x Safari/y
+	{"MSIE ", "Internet Explorer"},
+	{"Trident/", "Internet Explorer"},
+	{"curl/", "curl"},
+	{"Wget/", "Wget"},
+	{"python-requests/", "Python"},
+	{"Python-urllib/", "Python"},
+	{"aiohttp/", "Python"},
+	{"Go-http-client/", "Go"},
+	{"okhttp/", "OkHttp"},
+	{"Java/", "Java"},
+	{"axios/", "axios"},
+	{"node-fetch/", "Node.js"},
+	{"PostmanRuntime/", "Postman"},
+}
+
+// operatingSystems are checked in order (Android before Linux, iOS before macOS).
+var operatingSystems = []token{
+	{"Windows", "Windows"},
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"iPod", "iOS"},
+	{"CrOS", "ChromeOS"},
+	{"Macintosh", "macOS"},
+	{"Mac OS X", "macOS"},
+	{"Linux", "Linux"},
+	{"FreeBSD", "FreeBSD"},
+}
+
+// Parse classifies a user agent string.
+func Parse(ua string) Info {
+	info := Info{
+		Browser: Unknown,
+		OS:      Unknown,
+		Device:  DeviceOther,
+		Bot:     botName(ua),
+	}
+
+	for _, b := range browsers {
+		if strings.Contains(ua, b.match) {
+			info.Browser = b.name
+			break
+		}
+	}
+	for _, o := range operatingSystems {
+		if strings.Contains(ua, o.match) {
+			info.OS = o.name
+			break
+		}
+	}
+
+	switch {
+	case info.Bot != "":
+		info.Device = DeviceBot
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet") ||
+		(info.OS == "Android" && !strings.Contains(ua, "Mobile")):
+		info.Device = DeviceTablet
+	case strings.Contains(ua, "Mobi") || info.OS == "iOS" || info.OS == "Android":
+		info.Device = DeviceMobile
+	case info.OS != Unknown && strings.HasPrefix(ua, "Mozilla/"):
+		info.Device = DeviceDesktop
+	}
+	return info
+}
+
+// botName returns the crawler name for a user agent, or "" if it is not a bot.
+func botName(ua string) string {
+	for _, b := range knownBots {
+		if strings.Contains(ua, b.match) {
+			return b.name
+		}
+	}
+
+	lower := strings.ToLower(ua)
+	for _, kw := range botKeywords {
+		if strings.Contains(lower, kw) {
+			return productName(ua)
+		}
+	}
+	return ""
+}
+
+// productName extracts the product token of a user agent for unknown
+// crawlers, e.g. "ExampleBot" from "Mozilla/5.0 (compatible; ExampleBot/1.0; ...)".
+func productName(ua string) string {
+	for _, field := range strings.FieldsFunc(ua, func(r rune) bool {
+		return r == ' ' || r == ';' || r == '(' || r == ')' || r == ','
+	}) {
+		name, _, _ := strings.Cut(field, "/")
+		lower := strings.ToLower(name)
+		for _, kw := range botKeywords {
+			if strings.Contains(lower, kw) {
+				return name
+			}
+		}
+	}
+	name, _, _ := strings.Cut(ua, "/")
+	return name
+}
+
+// Parser parses user agents with a cache for repeated lookups.
+type Parser struct {
+	cache sync.Map // map[string]Info
+	size  atomic.Int64
+}
+
+// NewParser creates a Parser with an empty cache.
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Parse classifies a user agent, using the cache when possible.
+func (p *Parser) Parse(ua string) Info {
+	if cached, ok := p.cache.Load(ua); ok {
+		return cached.(Info)
+	}
+
+	info := Parse(ua)
+	if p.size.Add(1) > maxCacheEntries {
+		// Start over rather than tracking recency
+		p.cache.Clear()
+		p.size.Store(1)
+	}
+	p.cache.Store(ua, info)
+	return info
+}
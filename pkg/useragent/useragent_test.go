@@ -0,0 +1,108 @@
+package useragent
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want Info
+	}{
+		{
+			name: "Chrome on Windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: Info{Browser: "Chrome", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "Edge on Windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+			want: Info{Browser: "Edge", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "Safari on iPhone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			want: Info{Browser: "Safari", OS: "iOS", Device: DeviceMobile},
+		},
+		{
+			name: "Safari on iPad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			want: Info{Browser: "Safari", OS: "iOS", Device: DeviceTablet},
+		},
+		{
+			name: "Chrome on Android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			want: Info{Browser: "Chrome", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			name: "Android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: Info{Browser: "Chrome", OS: "Android", Device: DeviceTablet},
+		},
+		{
+			name: "Firefox on Linux",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: Info{Browser: "Firefox", OS: "Linux", Device: DeviceDesktop},
+		},
+		{
+			name: "Safari on macOS",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			want: Info{Browser: "Safari", OS: "macOS", Device: DeviceDesktop},
+		},
+		{
+			name: "Googlebot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: Info{Browser: Unknown, OS: Unknown, Device: DeviceBot, Bot: "Googlebot"},
+		},
+		{
+			name: "GPTBot",
+			ua:   "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.2; +https://openai.com/gptbot)",
+			want: Info{Browser: Unknown, OS: Unknown, Device: DeviceBot, Bot: "GPTBot"},
+		},
+		{
+			name: "unknown crawler",
+			ua:   "Mozilla/5.0 (compatible; ExampleCrawler/1.0; +https://example.com)",
+			want: Info{Browser: Unknown, OS: Unknown, Device: DeviceBot, Bot: "ExampleCrawler"},
+		},
+		{
+			name: "curl",
+			ua:   "curl/8.4.0",
+			want: Info{Browser: "curl", OS: Unknown, Device: DeviceOther},
+		},
+		{
+			name: "empty",
+			ua:   "-",
+			want: Info{Browser: Unknown, OS: Unknown, Device: DeviceOther},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.ua); got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserCache(t *testing.T) {
+	p := NewParser()
+	ua := "curl/8.4.0"
+
+	first := p.Parse(ua)
+	second := p.Parse(ua)
+	if first != second {
+		t.Errorf("Cached result %+v differs from %+v", second, first)
+	}
+	if p.size.Load() != 1 {
+		t.Errorf("Expected 1 cache entry, got %d", p.size.Load())
+	}
+}
+
+func TestInfoIsBot(t *testing.T) {
+	if (Info{Bot: "Googlebot"}).IsBot() != true {
+		t.Error("Expected IsBot() for a crawler")
+	}
+	if (Info{Browser: "Chrome"}).IsBot() {
+		t.Error("Expected !IsBot() for a browser")
+	}
+}
@@ -0,0 +1,135 @@
+// Package watchlist keeps track of bookmarked IPs and paths.
+package watchlist
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Kind is the type of a watched value.
+type Kind string
+
+// Watchable kinds
+const (
+	KindIP   Kind = "ip"
+	KindPath Kind = "path"
+)
+
+// Entry is a watched IP or path.
+type Entry struct {
+	Kind  Kind
+	Value string
+}
+
+// String returns the entry as accepted by ParseEntry, e.g. "ip:10.0.0.1".
+func (e Entry) String() string {
+	return string(e.Kind) + ":" + e.Value
+}
+
+// ParseEntry parses "ip:<address>" or "path:<path>". Without a prefix,
+// values starting with "/" are paths and valid addresses are IPs.
+func ParseEntry(text string) (Entry, error) {
+	text = strings.TrimSpace(text)
+	kind, value, found := strings.Cut(text, ":")
+	switch {
+	case found && Kind(kind) == KindIP:
+		if net.ParseIP(value) == nil {
+			return Entry{}, fmt.Errorf("invalid watch IP %q", value)
+		}
+		return Entry{KindIP, value}, nil
+	case found && Kind(kind) == KindPath:
+		if !strings.HasPrefix(value, "/") {
+			return Entry{}, fmt.Errorf("invalid watch path %q: must start with /", value)
+		}
+		return Entry{KindPath, value}, nil
+	case strings.HasPrefix(text, "/"):
+		return Entry{KindPath, text}, nil
+	case net.ParseIP(text) != nil:
+		return Entry{KindIP, text}, nil
+	}
+	return Entry{}, fmt.Errorf("invalid watch entry %q: expected an IP or a path", text)
+}
+
+// List is an ordered set of watched entries. It is safe for concurrent use.
+type List struct {
+	entries []Entry
+	mu      sync.RWMutex
+}
+
+// New creates a list from entries in ParseEntry syntax.
+func New(texts []string) (*List, error) {
+	l := &List{}
+	for _, text := range texts {
+		e, err := ParseEntry(text)
+		if err != nil {
+			return nil, err
+		}
+		if !l.Contains(e) {
+			l.entries = append(l.entries, e)
+		}
+	}
+	return l, nil
+}
+
+// Toggle adds the entry if it is not watched and removes it otherwise.
+// Returns true if the entry is watched afterwards.
+func (l *List) Toggle(e Entry) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, w := range l.entries {
+		if w == e {
+			l.entries = append(l.entries[:i:i], l.entries[i+1:]...)
+			return false
+		}
+	}
+	l.entries = append(l.entries, e)
+	return true
+}
+
+// Contains reports whether the entry is watched.
+func (l *List) Contains(e Entry) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, w := range l.entries {
+		if w == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns the watched entries in the order they were added.
+func (l *List) Entries() []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return append([]Entry(nil), l.entries...)
+}
+
+// Len returns the number of watched entries.
+func (l *List) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.entries)
+}
+
+// Match returns the watched entries matching a log entry.
+func (l *List) Match(v *parser.Visitor) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var matches []Entry
+	for _, w := range l.entries {
+		if (w.Kind == KindIP && w.Value == v.IP) || (w.Kind == KindPath && w.Value == v.Path) {
+			matches = append(matches, w)
+		}
+	}
+	return matches
+}
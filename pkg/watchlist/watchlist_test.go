@@ -0,0 +1,70 @@
+package watchlist
+
+import (
+	"testing"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Entry
+		wantErr bool
+	}{
+		{"ip:10.0.0.1", Entry{KindIP, "10.0.0.1"}, false},
+		{"path:/admin", Entry{KindPath, "/admin"}, false},
+		{"/login", Entry{KindPath, "/login"}, false},
+		{"2001:db8::1", Entry{KindIP, "2001:db8::1"}, false},
+		{" 192.168.1.10 ", Entry{KindIP, "192.168.1.10"}, false},
+		{"ip:not-an-ip", Entry{}, true},
+		{"path:admin", Entry{}, true},
+		{"example.com", Entry{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseEntry(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEntry(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseEntry(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestListToggle(t *testing.T) {
+	l, err := New([]string{"/admin", "10.0.0.1", "path:/admin"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if l.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2 (duplicates are ignored)", l.Len())
+	}
+
+	ip := Entry{KindIP, "10.0.0.1"}
+	if l.Toggle(ip) || l.Contains(ip) {
+		t.Error("Toggle() of a watched entry should remove it")
+	}
+	if !l.Toggle(ip) || !l.Contains(ip) {
+		t.Error("Toggle() of an unwatched entry should add it")
+	}
+	if got := l.Entries(); got[len(got)-1] != ip {
+		t.Errorf("Entries() = %v, want the re-added entry last", got)
+	}
+
+	if _, err := New([]string{"nope"}); err == nil {
+		t.Error("New() should reject invalid entries")
+	}
+}
+
+func TestListMatch(t *testing.T) {
+	l, _ := New([]string{"/admin", "10.0.0.1"})
+
+	if got := l.Match(&parser.Visitor{IP: "10.0.0.1", Path: "/admin"}); len(got) != 2 {
+		t.Errorf("Match() = %v, want both entries", got)
+	}
+	if got := l.Match(&parser.Visitor{IP: "10.0.0.2", Path: "/admin/users"}); len(got) != 0 {
+		t.Errorf("Match() = %v, want no match (paths match exactly)", got)
+	}
+}
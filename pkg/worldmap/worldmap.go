@@ -0,0 +1,126 @@
+// Package worldmap draws a coarse world map with braille characters.
+package worldmap
+
+// Map bounds in degrees; the polar regions are cropped
+const (
+	MinLon = -180.0
+	MaxLon = 180.0
+	MaxLat = 84.0
+	MinLat = -58.0
+)
+
+// point is a longitude/latitude pair in degrees.
+type point struct{ lon, lat float64 }
+
+// landmasses are coarse outlines of the continents and large islands.
+var landmasses = [][]point{
+	// North America
+	{{-168, 66}, {-162, 70}, {-140, 70}, {-125, 70}, {-95, 72}, {-80, 73}, {-65, 60}, {-55, 52}, {-66, 45}, {-70, 42}, {-76, 35}, {-81, 31}, {-80, 25}, {-82, 28}, {-84, 30}, {-90, 29}, {-97, 26}, {-97, 21}, {-92, 18}, {-88, 21}, {-87, 15}, {-83, 10}, {-79, 9}, {-80, 7}, {-85, 11}, {-92, 14}, {-105, 20}, {-110, 24}, {-112, 30}, {-117, 32}, {-124, 40}, {-124, 48}, {-135, 58}, {-150, 60}, {-165, 60}},
+	// Greenland
+	{{-73, 78}, {-60, 82}, {-30, 83}, {-20, 75}, {-22, 70}, {-43, 60}, {-50, 64}, {-55, 70}},
+	// Iceland
+	{{-24, 64}, {-14, 64}, {-14, 66}, {-22, 66}},
+	// South America
+	{{-80, 8}, {-75, 11}, {-62, 10}, {-50, 0}, {-35, -5}, {-39, -15}, {-48, -26}, {-58, -38}, {-65, -42}, {-68, -52}, {-72, -54}, {-75, -48}, {-73, -37}, {-71, -18}, {-76, -14}, {-81, -5}, {-80, 1}},
+	// Europe
+	{{-10, 36}, {-9, 43}, {-2, 44}, {-5, 48}, {2, 51}, {8, 54}, {9, 57}, {5, 62}, {15, 69}, {25, 71}, {40, 68}, {60, 69}, {60, 45}, {48, 42}, {40, 41}, {28, 41}, {26, 38}, {22, 37}, {20, 40}, {15, 38}, {16, 41}, {12, 44}, {8, 44}, {3, 43}, {0, 39}, {-6, 36}},
+	// Great Britain and Ireland
+	{{-6, 50}, {2, 51}, {0, 53}, {-2, 56}, {-3, 59}, {-6, 58}, {-5, 54}},
+	{{-10, 52}, {-6, 52}, {-6, 55}, {-10, 54}},
+	// Africa
+	{{-17, 21}, {-10, 30}, {-6, 36}, {10, 37}, {11, 33}, {20, 31}, {32, 31}, {35, 28}, {43, 12}, {51, 12}, {42, -1}, {40, -10}, {35, -24}, {27, -34}, {18, -34}, {12, -17}, {13, -6}, {9, 4}, {-8, 4}, {-17, 14}},
+	// Madagascar
+	{{44, -25}, {47, -25}, {50, -15}, {49, -12}, {44, -16}},
+	// Asia
+	{{26, 41}, {36, 36}, {35, 32}, {43, 13}, {52, 16}, {57, 25}, {62, 25}, {67, 24}, {73, 20}, {77, 8}, {80, 13}, {80, 16}, {88, 22}, {92, 22}, {98, 16}, {100, 8}, {104, 1}, {104, 10}, {109, 12}, {108, 21}, {117, 23}, {122, 30}, {122, 40}, {127, 38}, {129, 35}, {130, 43}, {140, 48}, {143, 53}, {135, 55}, {156, 60}, {163, 60}, {170, 64}, {180, 66}, {180, 71}, {140, 73}, {110, 77}, {100, 78}, {80, 73}, {70, 73}, {60, 69}, {60, 45}, {48, 42}, {40, 41}},
+	// Japan
+	{{130, 31}, {132, 35}, {140, 36}, {142, 40}, {141, 45}, {145, 44}, {140, 41}, {136, 34}},
+	// Sumatra, Borneo, New Guinea
+	{{95, 5}, {98, 4}, {106, -6}, {102, -4}},
+	{{109, 2}, {117, 7}, {119, 1}, {116, -4}, {110, -3}},
+	{{131, -1}, {141, -3}, {150, -10}, {141, -9}, {137, -5}},
+	// Australia
+	{{114, -22}, {122, -18}, {130, -12}, {137, -12}, {142, -11}, {146, -19}, {153, -26}, {151, -34}, {145, -39}, {138, -35}, {131, -31}, {115, -34}},
+	// New Zealand
+	{{172, -34}, {178, -38}, {174, -42}, {168, -46}, {167, -45}},
+}
+
+// brailleDots maps a dot position [y][x] within a cell to its braille bit.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// Land renders the land masses as rows of braille characters for a map of
+// width x height cells. Water is left as spaces.
+func Land(width, height int) []string {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	rows := make([]string, height)
+	for row := 0; row < height; row++ {
+		line := make([]rune, width)
+		for col := 0; col < width; col++ {
+			var bits rune
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					lon, lat := unproject(col*2+dx, row*4+dy, width*2, height*4)
+					if isLand(lon, lat) {
+						bits |= brailleDots[dy][dx]
+					}
+				}
+			}
+			if bits == 0 {
+				line[col] = ' '
+			} else {
+				line[col] = 0x2800 + bits
+			}
+		}
+		rows[row] = string(line)
+	}
+	return rows
+}
+
+// Project returns the cell for a longitude/latitude on a map of width x
+// height cells, and false if it falls outside the map.
+func Project(lon, lat float64, width, height int) (col, row int, ok bool) {
+	if lon < MinLon || lon > MaxLon || lat < MinLat || lat > MaxLat {
+		return 0, 0, false
+	}
+	col = int((lon - MinLon) / (MaxLon - MinLon) * float64(width))
+	row = int((MaxLat - lat) / (MaxLat - MinLat) * float64(height))
+	return min(col, width-1), min(row, height-1), true
+}
+
+// unproject returns the longitude/latitude at the center of dot (x, y) on a
+// grid of w x h dots.
+func unproject(x, y, w, h int) (lon, lat float64) {
+	lon = MinLon + (float64(x)+0.5)/float64(w)*(MaxLon-MinLon)
+	lat = MaxLat - (float64(y)+0.5)/float64(h)*(MaxLat-MinLat)
+	return lon, lat
+}
+
+// isLand reports whether a point lies within any land mass.
+func isLand(lon, lat float64) bool {
+	for _, polygon := range landmasses {
+		if contains(polygon, lon, lat) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether a point lies inside a polygon (ray casting).
+func contains(polygon []point, lon, lat float64) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.lat > lat) != (b.lat > lat) &&
+			lon < (b.lon-a.lon)*(lat-a.lat)/(b.lat-a.lat)+a.lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
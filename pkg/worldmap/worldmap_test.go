@@ -0,0 +1,57 @@
+package worldmap
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+// TestLand tests the dimensions of the rendered map.
+func TestLand(t *testing.T) {
+	rows := Land(80, 20)
+	if len(rows) != 20 {
+		t.Fatalf("Land() returned %d rows, want 20", len(rows))
+	}
+	for i, row := range rows {
+		if n := utf8.RuneCountInString(row); n != 80 {
+			t.Errorf("row %d has %d cells, want 80", i, n)
+		}
+	}
+	if rows := Land(0, 10); len(rows) != 0 {
+		t.Errorf("Land(0, 10) returned %d rows, want 0", len(rows))
+	}
+}
+
+// TestIsLand tests a few well-known points.
+func TestIsLand(t *testing.T) {
+	tests := []struct {
+		name     string
+		lon, lat float64
+		want     bool
+	}{
+		{"Paris", 2.35, 48.86, true},
+		{"Kansas", -98.0, 38.5, true},
+		{"Brasilia", -47.9, -15.8, true},
+		{"Beijing", 116.4, 39.9, true},
+		{"Mid-Atlantic", -35.0, 30.0, false},
+		{"Mid-Pacific", -150.0, 0.0, false},
+		{"Indian Ocean", 80.0, -30.0, false},
+	}
+	for _, tt := range tests {
+		if got := isLand(tt.lon, tt.lat); got != tt.want {
+			t.Errorf("isLand(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestProject tests projecting coordinates to map cells.
+func TestProject(t *testing.T) {
+	if col, row, ok := Project(MinLon, MaxLat, 100, 30); !ok || col != 0 || row != 0 {
+		t.Errorf("Project(top left) = %d, %d, %v; want 0, 0, true", col, row, ok)
+	}
+	if col, row, ok := Project(MaxLon, MinLat, 100, 30); !ok || col != 99 || row != 29 {
+		t.Errorf("Project(bottom right) = %d, %d, %v; want 99, 29, true", col, row, ok)
+	}
+	if _, _, ok := Project(0, -80, 100, 30); ok {
+		t.Error("Project() should reject points south of the map")
+	}
+}
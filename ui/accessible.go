@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// accessibleHelp is the footer help of the accessible rendering, in words
+// rather than glyphs.
+const accessibleHelp = "q quit, space pause, t time window, 2 to 5 status filter, escape clear filter, v next view, arrows scroll"
+
+// styleTag matches tview color and style tags such as "[red::b]" or "[-::-]".
+var styleTag = regexp.MustCompile(`\[[a-zA-Z#0-9-]*(:[a-zA-Z#0-9-]*)?(:[a-zA-Z-]*)?\]`)
+
+// SetAccessible switches to a linear, text-first rendering for terminal
+// screen readers: the panels of the current view are listed one after the
+// other as a title line followed by their values, without borders, bars or
+// charts.
+func (ta *TviewApp) SetAccessible(accessible bool) {
+	if !accessible {
+		ta.accessView = nil
+		ta.footer.SetText(ta.help)
+		return
+	}
+	ta.accessView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(true).
+		SetWordWrap(true)
+	ta.footer.SetText(accessibleHelp)
+}
+
+// accessibleRoot returns the accessible rendering with the footer below it.
+func (ta *TviewApp) accessibleRoot() tview.Primitive {
+	return tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(ta.accessView, 0, 1, true).
+		AddItem(ta.footer, 1, 0, false)
+}
+
+// renderAccessible renders the panels of the current view as lines of text.
+func (ta *TviewApp) renderAccessible() {
+	if ta.accessView == nil {
+		return
+	}
+
+	var b strings.Builder
+	view := ta.views[ta.viewIndex]
+	fmt.Fprintf(&b, "tailnginx, %s. View %s, %d of %d.\n", ta.logFilePath, view.name, ta.viewIndex+1, len(ta.views))
+	writePanelText(&b, "Overview", ta.overview.GetText(false))
+	for _, id := range ta.currentPanels() {
+		switch p := ta.panels[id].(type) {
+		case *tview.Table:
+			writePanelLines(&b, ta.panelTitle(id), tableLines(p))
+		case *tview.TextView:
+			writePanelText(&b, ta.panelTitle(id), p.GetText(false))
+		}
+	}
+	ta.accessView.SetText(b.String())
+}
+
+// writePanelText writes a panel title and its text lines, leaving out lines
+// that only draw bars or charts.
+func writePanelText(b *strings.Builder, title, text string) {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !graphicOnly(line) {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	writePanelLines(b, title, lines)
+}
+
+// writePanelLines writes a panel title followed by its lines.
+func writePanelLines(b *strings.Builder, title string, lines []string) {
+	fmt.Fprintf(b, "\n%s:\n", title)
+	if len(lines) == 0 {
+		b.WriteString("  No data.\n")
+	}
+	for _, line := range lines {
+		fmt.Fprintf(b, "  %s\n", line)
+	}
+}
+
+// tableLines returns a line per table row. Values are named after their
+// column header (see setHeader), e.g. "Path /login, Count 12"; bar columns
+// are left out.
+func tableLines(table *tview.Table) []string {
+	start, headers := 0, []string(nil)
+	if header := table.GetCell(0, 0); header.NotSelectable && strings.HasPrefix(header.Text, "[::b]") {
+		start = 1
+		for col := 0; col < table.GetColumnCount(); col++ {
+			headers = append(headers, plainText(table.GetCell(0, col).Text))
+		}
+	}
+
+	var lines []string
+	for row := start; row < table.GetRowCount(); row++ {
+		var values []string
+		for col := 0; col < table.GetColumnCount(); col++ {
+			text := table.GetCell(row, col).Text
+			if graphicOnly(text) {
+				continue
+			}
+			if col < len(headers) && headers[col] != "" {
+				text = headers[col] + " " + strings.TrimSpace(text)
+			}
+			values = append(values, strings.TrimSpace(text))
+		}
+		if len(values) > 0 {
+			lines = append(lines, strings.Join(values, ", "))
+		}
+	}
+	return lines
+}
+
+// plainText returns a text without its color and style tags.
+func plainText(text string) string {
+	return strings.TrimSpace(styleTag.ReplaceAllString(text, ""))
+}
+
+// graphicOnly reports whether a text is empty or only made of bar, block
+// and braille glyphs, which screen readers cannot convey.
+func graphicOnly(text string) bool {
+	for _, r := range plainText(text) {
+		switch {
+		case r == ' ':
+		case r >= 0x2580 && r <= 0x259f: // Block elements
+		case r >= 0x2800 && r <= 0x28ff: // Braille patterns
+		default:
+			return false
+		}
+	}
+	return true
+}
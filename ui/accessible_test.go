@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// TestTableLines tests that table rows are read out with their column
+// headers and without bars.
+func TestTableLines(t *testing.T) {
+	table := tview.NewTable()
+	setHeader(table, "Status", "", "%")
+	table.SetCell(1, 0, tview.NewTableCell("[green]✓ 200[-::-]"))
+	table.SetCell(1, 1, tview.NewTableCell("[green]████[-::-][::d]░░[-::-]"))
+	table.SetCell(1, 2, tview.NewTableCell("66.7%"))
+
+	lines := tableLines(table)
+	if len(lines) != 1 || lines[0] != "Status [green]✓ 200[-::-], % 66.7%" {
+		t.Errorf("tableLines() = %q", lines)
+	}
+
+	plain := tview.NewTable()
+	plain.SetCell(0, 0, tview.NewTableCell("Devices"))
+	plain.SetCell(1, 0, tview.NewTableCell("Mobile")).SetCell(1, 1, tview.NewTableCell("12"))
+	if lines := tableLines(plain); len(lines) != 2 || lines[1] != "Mobile, 12" {
+		t.Errorf("tableLines() without header = %q", lines)
+	}
+}
+
+// TestRenderAccessible tests the linear rendering of the current view.
+func TestRenderAccessible(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	app.SetAccessible(true)
+
+	app.pathsData = map[string]int{"/login": 12}
+	app.renderAll()
+	text := app.accessView.GetText(true)
+	for _, want := range []string{"View Dashboard, 1 of 4.", "\nOverview:\n", "\nTop Paths:\n  Path /login, Count 12\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("accessible rendering does not contain %q:\n%s", want, text)
+		}
+	}
+	for _, glyph := range []string{"╔", "│", "█", "📊"} {
+		if strings.Contains(text, glyph) {
+			t.Errorf("accessible rendering contains %q", glyph)
+		}
+	}
+	if got := app.helpText(); got != accessibleHelp {
+		t.Errorf("helpText() = %q, want the accessible help", got)
+	}
+}
+
+func TestGraphicOnly(t *testing.T) {
+	for text, want := range map[string]bool{
+		"":                     true,
+		"  [cyan]▁▃█ ▂[-::-]":  true,
+		"⣿⡇":                   true,
+		"[::b]Peak:[-::-] 14h": false,
+	} {
+		if got := graphicOnly(text); got != want {
+			t.Errorf("graphicOnly(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/papaganelli/tailnginx/pkg/alert"
+)
+
+// The header flashes alarmFlashes times, alarmInterval on and off, when an
+// alert becomes critical.
+const (
+	alarmFlashes  = 5
+	alarmInterval = 300 * time.Millisecond
+)
+
+// alarmColor is the header background while it flashes.
+var alarmColor = tcell.NewRGBColor(185, 28, 28) // Red 700
+
+// alarm rings the bell and flashes the header of the dashboard.
+type alarm struct {
+	ta *TviewApp
+}
+
+// Notify sounds the alarm from the UI goroutine.
+func (a alarm) Notify(alert.Alert) {
+	a.ta.app.QueueUpdateDraw(a.ta.soundAlarm)
+}
+
+// SetAlarm makes critical alerts ring the terminal bell and flash the
+// header, so that they are noticed from another window. Must be called
+// before Run.
+func (ta *TviewApp) SetAlarm(enabled bool) {
+	if enabled {
+		ta.alerts.AddNotifier(alarm{ta}, alert.SeverityCritical)
+	}
+}
+
+// soundAlarm rings the bell and starts flashing the header, again from the
+// start if it was flashing. Must be called from the UI goroutine.
+func (ta *TviewApp) soundAlarm() {
+	if ta.screen != nil {
+		ta.screen.Beep()
+	}
+	ta.alarmSeq++
+	ta.flashHeader(ta.alarmSeq, 2*alarmFlashes)
+}
+
+// flashHeader switches the header background between the alarm color and
+// its own, steps more times, unless a later alarm took over.
+func (ta *TviewApp) flashHeader(seq, steps int) {
+	if seq != ta.alarmSeq {
+		return
+	}
+	color := ta.headerColor
+	if steps%2 == 0 && steps > 0 {
+		color = alarmColor
+	}
+	ta.header.SetBackgroundColor(color)
+	ta.healthView.SetBackgroundColor(color)
+	if steps == 0 {
+		return
+	}
+	time.AfterFunc(alarmInterval, func() {
+		ta.app.QueueUpdateDraw(func() { ta.flashHeader(seq, steps-1) })
+	})
+}
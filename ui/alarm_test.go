@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// beepScreen counts the bells rung on a simulated screen.
+type beepScreen struct {
+	tcell.SimulationScreen
+	beeps int
+}
+
+func (s *beepScreen) Beep() error {
+	s.beeps++
+	return nil
+}
+
+// TestSoundAlarm tests that the alarm rings the bell of the screen and
+// flashes the header.
+func TestSoundAlarm(t *testing.T) {
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	app.soundAlarm() // No screen before the first draw
+
+	screen := &beepScreen{SimulationScreen: tcell.NewSimulationScreen("")}
+	app.screen = screen
+	app.soundAlarm()
+	if screen.beeps != 1 {
+		t.Errorf("rang the bell %d times, want 1", screen.beeps)
+	}
+	if app.header.GetBackgroundColor() != alarmColor {
+		t.Error("header should be in the alarm color")
+	}
+}
+
+// TestFlashHeader tests that the header flashes in the alarm color and gets
+// its own back, a later alarm taking over.
+func TestFlashHeader(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	headerColor := app.header.GetBackgroundColor()
+
+	app.alarmSeq = 1
+	app.flashHeader(1, 2)
+	if app.header.GetBackgroundColor() != alarmColor || app.healthView.GetBackgroundColor() != alarmColor {
+		t.Error("header should be in the alarm color")
+	}
+
+	// A step of an earlier alarm changes nothing
+	app.alarmSeq = 2
+	app.flashHeader(1, 0)
+	if app.header.GetBackgroundColor() != alarmColor {
+		t.Error("header changed by an earlier alarm")
+	}
+
+	app.flashHeader(2, 0)
+	if app.header.GetBackgroundColor() != headerColor || app.healthView.GetBackgroundColor() != headerColor {
+		t.Error("header should get its color back")
+	}
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/papaganelli/tailnginx/pkg/alert"
+	"github.com/rivo/tview"
+)
+
+// alertEventLabels are the labels of the alert events.
+var alertEventLabels = map[string]string{
+	alert.EventFired:     "[red]fired[-::-]",
+	alert.EventEscalated: "[red::b]escalated[-::-]",
+	alert.EventCleared:   "[green]cleared[-::-]",
+}
+
+// renderAlertHistory renders the alerts that fired, escalated or cleared,
+// most recent first, e.g. to follow the timeline of an incident.
+func (ta *TviewApp) renderAlertHistory() {
+	table := ta.historyTable
+	table.Clear()
+
+	history := ta.alerts.History()
+	if len(history) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell("[::d]No alerts yet[-::-]"))
+		return
+	}
+
+	setHeader(table, "Time", "Event", "Level", "Alert")
+	table.GetCell(0, 1).SetAlign(tview.AlignLeft)
+	table.GetCell(0, 3).SetAlign(tview.AlignLeft)
+	for i := 0; i < min(len(history), ta.topLimit(table, 1)); i++ {
+		e := history[len(history)-1-i]
+		row := i + 1
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("[::d]%s[-::-]", ta.formatTime(e.Time))).
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 1, tview.NewTableCell(alertEventLabels[e.Kind]).SetAlign(tview.AlignLeft))
+		table.SetCell(row, 2,
+			tview.NewTableCell(severityTags[e.Alert.Severity]+e.Alert.Severity.String()+"[-:-:-]").
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 3,
+			tview.NewTableCell(tview.Escape(e.Alert.Message)).
+				SetAlign(tview.AlignLeft).
+				SetExpansion(1))
+	}
+}
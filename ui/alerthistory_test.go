@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/alert"
+)
+
+// TestRenderAlertHistory tests that the alerts that fired, escalated and
+// cleared are listed most recent first.
+func TestRenderAlertHistory(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	app.renderAlertHistory()
+	if cell := app.historyTable.GetCell(0, 0); !strings.Contains(cell.Text, "No alerts yet") {
+		t.Errorf("empty history = %q", cell.Text)
+	}
+
+	app.alerts.Raise("5xx_spike", alert.SeverityWarning, "5xx rate at 12%")
+	app.alerts.Raise("5xx_spike", alert.SeverityCritical, "5xx rate at 31%")
+	app.alerts.Clear("5xx_spike")
+	app.renderAlertHistory()
+
+	want := []struct{ event, level, message string }{
+		{"cleared", "CRIT", "31%"},
+		{"escalated", "CRIT", "31%"},
+		{"fired", "WARN", "12%"},
+	}
+	if rows := app.historyTable.GetRowCount(); rows != len(want)+1 {
+		t.Fatalf("history has %d rows, want %d", rows, len(want)+1)
+	}
+	for i, w := range want {
+		row := i + 1
+		event := app.historyTable.GetCell(row, 1).Text
+		level := app.historyTable.GetCell(row, 2).Text
+		message := app.historyTable.GetCell(row, 3).Text
+		if !strings.Contains(event, w.event) || !strings.Contains(level, w.level) || !strings.Contains(message, w.message) {
+			t.Errorf("row %d = %q %q %q, want %s %s %s", row, event, level, message, w.event, w.level, w.message)
+		}
+	}
+}
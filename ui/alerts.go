@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/alert"
+	"github.com/rivo/tview"
+)
+
+// Alert checks of the dashboard and their thresholds
+const (
+	alertDiskID       = "disk_full"
+	alertDiskInterval = 30 * time.Second
+	alertDiskWarning  = 90.0 // Percent of the log partition used
+	alertDiskCritical = 97.0
+	maxBannerAlerts   = 3 // Maximum alerts listed in the banner before summarizing
+)
+
+// severityTags maps alert severities to banner label styles
+var severityTags = map[alert.Severity]string{
+	alert.SeverityCritical: "[white:red:b]",
+	alert.SeverityWarning:  "[black:yellow:b]",
+	alert.SeverityInfo:     "[white:blue:b]",
+}
+
+// SetAlertChecker sets the checker of the alert rules and built-in checks,
+// shared with the rest of the pipeline. The checks of the dashboard fire
+// their alerts on its board too, so that its notifiers are told about them.
+// Must be called before Run.
+func (ta *TviewApp) SetAlertChecker(c *alert.Checker) {
+	ta.checker = c
+	ta.alerts = c.Board()
+}
+
+// Terminal returns a writer to the terminal of the dashboard, e.g. for
+// escape sequences. Writes go to the tty of the screen from the UI
+// goroutine, so that they do not interleave with drawing; they are dropped
+// before the first draw or when the screen is not a terminal.
+func (ta *TviewApp) Terminal() io.Writer {
+	return terminalWriter{ta}
+}
+
+type terminalWriter struct {
+	ta *TviewApp
+}
+
+func (w terminalWriter) Write(p []byte) (int, error) {
+	b := append([]byte(nil), p...)
+	w.ta.app.QueueUpdate(func() {
+		if w.ta.screen == nil {
+			return
+		}
+		if tty, ok := w.ta.screen.Tty(); ok {
+			tty.Write(b)
+		}
+	})
+	return len(p), nil
+}
+
+// layoutMain arranges header, alert banner, content and footer in the main
+// grid. The banner row is only present when it has lines to show.
+func (ta *TviewApp) layoutMain(bannerLines int) {
+	ta.grid.Clear()
+	if bannerLines == 0 {
+		ta.grid.SetRows(1, 0, 1)
+		ta.grid.AddItem(ta.headerRow, 0, 0, 1, 1, 0, 0, false)
+		ta.grid.AddItem(ta.pages, 1, 0, 1, 1, 0, 0, false)
+		ta.grid.AddItem(ta.footer, 2, 0, 1, 1, 0, 0, false)
+		return
+	}
+
+	ta.grid.SetRows(1, bannerLines, 0, 1)
+	ta.grid.AddItem(ta.headerRow, 0, 0, 1, 1, 0, 0, false)
+	ta.grid.AddItem(ta.banner, 1, 0, 1, 1, 0, 0, false)
+	ta.grid.AddItem(ta.pages, 2, 0, 1, 1, 0, 0, false)
+	ta.grid.AddItem(ta.footer, 3, 0, 1, 1, 0, 0, false)
+}
+
+// checkAlerts evaluates the alert checks of the dashboard, then the rules
+// and built-in checks of the checker, and updates the alert board. Returns
+// true if the set of unacknowledged alerts may have changed.
+func (ta *TviewApp) checkAlerts(now time.Time) bool {
+	changed := ta.checkWatchlist()
+	if ta.checkRetention(now) {
+		changed = true
+	}
+	if ta.checker.Check(now) {
+		changed = true
+	}
+
+	if now.Sub(ta.lastDiskCheck) >= alertDiskInterval {
+		ta.lastDiskCheck = now
+		if ta.checkDisk() {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// checkDisk raises an alert when the partition holding the log file is nearly full.
+func (ta *TviewApp) checkDisk() bool {
+	used, err := alert.DiskUsage(filepath.Dir(ta.logFilePath))
+	if err != nil || used < alertDiskWarning {
+		return ta.alerts.Clear(alertDiskID)
+	}
+
+	severity := alert.SeverityWarning
+	if used >= alertDiskCritical {
+		severity = alert.SeverityCritical
+	}
+	ta.alerts.Fire(alert.Alert{
+		ID:       alertDiskID,
+		Severity: severity,
+		Message:  fmt.Sprintf("log partition %.0f%% full (%s)", used, filepath.Dir(ta.logFilePath)),
+		Value:    used,
+	})
+	return true
+}
+
+// renderBanner shows unacknowledged alerts under the header, resizing the
+// banner row as needed. Must be called from the UI goroutine.
+func (ta *TviewApp) renderBanner() {
+	alerts := ta.alerts.Unacked()
+	text := ta.formatBanner(alerts)
+
+	lines := 0
+	if text != "" {
+		lines = strings.Count(text, "\n") + 1
+	}
+	if lines != ta.bannerLines {
+		ta.bannerLines = lines
+		ta.layoutMain(lines)
+	}
+	ta.banner.SetText(text)
+}
+
+// formatBanner formats alerts as banner lines, one per alert.
+func (ta *TviewApp) formatBanner(alerts []alert.Alert) string {
+	if len(alerts) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for i, a := range alerts {
+		if i == maxBannerAlerts {
+			lines[len(lines)-1] += fmt.Sprintf("  [::d](+%d more)[-::-]", len(alerts)-maxBannerAlerts)
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s %s [-:-:-] %s [::d]since %s[-::-]",
+			severityTags[a.Severity],
+			a.Severity,
+			tview.Escape(a.Message),
+			ta.formatTime(a.Since)))
+	}
+	lines[0] += "  [::d](a: acknowledge)[-::-]"
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/alert"
+)
+
+// TestRenderBanner tests banner contents and acknowledgement.
+func TestRenderBanner(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	app.renderBanner()
+	if app.bannerLines != 0 {
+		t.Errorf("bannerLines = %d without alerts, want 0", app.bannerLines)
+	}
+
+	app.alerts.Raise("disk_full", alert.SeverityWarning, "log partition 92% full")
+	app.alerts.Raise("5xx_spike", alert.SeverityCritical, "5xx spike: 30%")
+	app.renderBanner()
+	if app.bannerLines != 2 {
+		t.Fatalf("bannerLines = %d, want 2", app.bannerLines)
+	}
+	text := app.banner.GetText(true)
+	if strings.Index(text, "CRIT") > strings.Index(text, "WARN") {
+		t.Errorf("critical alerts should be listed first:\n%s", text)
+	}
+
+	app.alerts.AckAll()
+	app.renderBanner()
+	if app.bannerLines != 0 {
+		t.Errorf("bannerLines = %d after acknowledging, want 0", app.bannerLines)
+	}
+}
+
+// TestFormatBannerOverflow tests that extra alerts are summarized.
+func TestFormatBannerOverflow(t *testing.T) {
+	var alerts []alert.Alert
+	for i := 0; i < maxBannerAlerts+2; i++ {
+		alerts = append(alerts, alert.Alert{ID: string(rune('a' + i)), Message: "x", Severity: alert.SeverityWarning})
+	}
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	text := app.formatBanner(alerts)
+	if got := strings.Count(text, "\n") + 1; got != maxBannerAlerts {
+		t.Errorf("formatBanner() has %d lines, want %d", got, maxBannerAlerts)
+	}
+	if !strings.Contains(text, "(+2 more)") {
+		t.Errorf("formatBanner() should summarize extra alerts:\n%s", text)
+	}
+}
@@ -3,21 +3,43 @@ package ui
 
 import (
 	"fmt"
+	"net"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/papaganelli/tailnginx/internal/health"
+	"github.com/papaganelli/tailnginx/internal/state"
+	"github.com/papaganelli/tailnginx/pkg/abuse"
+	"github.com/papaganelli/tailnginx/pkg/alert"
+	"github.com/papaganelli/tailnginx/pkg/anomaly"
+	"github.com/papaganelli/tailnginx/pkg/attack"
+	"github.com/papaganelli/tailnginx/pkg/exclude"
+	"github.com/papaganelli/tailnginx/pkg/feed"
 	"github.com/papaganelli/tailnginx/pkg/geoip"
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+	"github.com/papaganelli/tailnginx/pkg/hotlink"
 	"github.com/papaganelli/tailnginx/pkg/metrics"
 	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/ratelimit"
+	"github.com/papaganelli/tailnginx/pkg/referrer"
+	"github.com/papaganelli/tailnginx/pkg/reputation"
+	"github.com/papaganelli/tailnginx/pkg/sensitive"
+	"github.com/papaganelli/tailnginx/pkg/stats"
+	"github.com/papaganelli/tailnginx/pkg/store"
+	"github.com/papaganelli/tailnginx/pkg/useragent"
+	"github.com/papaganelli/tailnginx/pkg/watchlist"
 	"github.com/rivo/tview"
 )
 
 // TviewApp represents the tview-based application.
 type TviewApp struct {
 	startTime       time.Time
+	lastDiskCheck   time.Time
+	droppedUntil    time.Time
 	statusCodes     map[int]int
 	pathsData       map[string]int
 	overview        *tview.TextView
@@ -28,28 +50,163 @@ type TviewApp struct {
 	methodsTable    *tview.Table
 	countriesTable  *tview.Table
 	referersTable   *tview.Table
+	bandwidthTable  *tview.Table
+	platformsTable  *tview.Table
+	botsTable       *tview.Table
+	protocolsTable  *tview.Table
+	tlsTable        *tview.Table
+	notFoundTable   *tview.Table
+	securityTable   *tview.Table
+	historyTable    *tview.Table
+	hotlinksTable   *tview.Table
+	suspiciousTable *tview.Table
 	logStream       *tview.TextView
+	uniquesView     *tview.TextView
+	watchTable      *tview.Table
+	panelMenu       *tview.Table
+	countryTable    *tview.Table
+	hoursView       *tview.TextView
+	errorLogView    *tview.TextView
+	miniView        *tview.TextView
+	accessView      *tview.TextView
+	mini            tview.Primitive
+	rawView         *tview.TextView
+	geoMap          *geoMap
+	rawSearch       *tview.InputField
+	statusPrompt    *tview.InputField
+	header          *tview.TextView
+	healthView      *tview.TextView
+	headerRow       *tview.Flex
+	banner          *tview.TextView
+	footer          *tview.TextView
 	lines           <-chan string
+	errorLines      <-chan string
 	grid            *tview.Grid
+	pages           *tview.Pages
+	panels          map[string]tview.Primitive
+	focused         *tview.Table
 	geoLocator      *geoip.Locator
+	noGeo           bool // geo lookups disabled: no Countries panel or map
+	uaParser        *useragent.Parser
+	format          *parser.Format
+	exclude         atomic.Pointer[exclude.Rules] // swapped on reload, read by the ingest goroutine
+	sensitive       *sensitive.Matcher            // sensitive paths scored by the Suspicious panel
 	rateTracker     *metrics.RateTracker
+	exporter        *metrics.Exporter
+	statsd          *metrics.StatsD
+	pusher          *metrics.Pusher
+	store           *store.Store
+	feed            *feed.Hub
+	summarizer      *stats.Summarizer
+	offenders       *abuse.Detector
+	attacks         *attack.Tracker
+	reputation      *reputation.Checker
+	suspicious      []anomaly.Score
+	hotlinks        *hotlink.Detector
+	hotlinkData     map[hotlinkKey]hotlinkStat
+	hotlinkKnown    bool // whether the site of the shown entries is known, to tell hotlinks
+	healthMonitor   *health.Monitor
+	denyFile        string // nginx include the deny list is written to, empty for the export directory
+	denyFormat      string
+	denyPIDFile     string // nginx PID file, to reload nginx after writing the deny list
+	rateLimits      *ratelimit.Tracker
+	rateLimitFile   string // nginx include the rate limit report is written to, empty for the export directory
+	bytesTracker    *metrics.RateTracker
+	uniqueTracker   *metrics.UniqueTracker
+	uniqueTrend     *metrics.UniqueTracker
+	highlights      highlight.Rules
+	alerts          *alert.Board
+	checker         *alert.Checker // alert rules, firing on alerts
+	watchlist       *watchlist.List
+	state           *state.State
+	hidden          map[string]bool
+	watchPending    map[watchlist.Entry]watchHit
+	watchCounts     map[watchlist.Entry]int
+	watchSeen       map[watchlist.Entry]time.Time
 	referersData    map[string]int
+	sourcesData     map[string]int
 	countriesData   map[string]int
 	userAgents      map[string]int
 	ips             map[string]int
 	app             *tview.Application
 	methodsData     map[string]int
+	pathBytes       map[string]int
+	ipBytes         map[string]int
+	osData          map[string]int
+	devicesData     map[string]int
+	botsData        map[string]int
+	protocolsData   map[string]int
+	tlsProtocols    map[string]int
+	tlsCiphers      map[string]int
+	notFoundPaths   map[string]int
+	notFoundIPs     map[string]map[string]int
+	previous        map[string]map[string]int
 	logFilePath     string
+	statePath       string
+	country         string
+	exportDir       string
+	dumpFile        string
+	errorLogPath    string
 	allVisitors     []parser.Visitor
 	logLines        []string
+	streamColumns   []string
+	rawLines        []string
 	visitors        []parser.Visitor
+	previousEntries []parser.Visitor
+	classCodes      map[int]int
+	errorEntries    []parser.ErrorEntry
+	views           []dashboardView
+	rawQuery        string
+	page            string
 	refreshRate     time.Duration
 	timeWindow      time.Duration
 	statusFilter    int
+	statusCode      int
+	pathFilter      string                     // Path prefix of the shown entries, empty for all
+	timeFormat      string                     // Layout of displayed times
+	help            string                     // Dashboard key help, with remapped keys
+	keyMap          map[string]*tcell.EventKey // Default keys of the actions bound to other keys
+	timeZone        *time.Location             // Zone of displayed times, nil for the zone of the log
+	ipFilter        *net.IPNet                 // Network of the shown entries, nil for all
 	timeWindowIndex int
+	rawSeq          int
+	rawDropped      int
+	droppedEntries  int
+	maxEntries      int   // entries kept in memory for the panels
+	maxBytes        int64 // approximate memory of the entries kept, 0 for no limit
+	retainedBytes   int64 // approximate memory of the entries kept
+	rawMatch        int
+	flashSeq        int
+	alarmSeq        int
+	pausedPending   int
+	shownRequests   int
+	shownTotal      int
+	bannerLines     int
+	uniqueVisitors  int
+	viewIndex       int
+	displayMode     displayMode
+	topN            int
+	screenWidth     int
+	screenHeight    int
+	streamWidth     int
+	layoutCols      int
+	layoutRows      int
+	windowBytes     int64
+	parseFailures   atomic.Uint64
+	hourCounts      [24]uint64
+	previousTotal   int
+	weekdayCounts   [7]uint64
+	borderColor     tcell.Color
+	headerColor     tcell.Color
+	screen          tcell.Screen // Set on the first draw, for the bell and escape sequences
 	mu              sync.RWMutex
 	paused          bool
 	dataChanged     bool
+	rawChanged      bool
+	rawFollow       bool
+	uniqueExact     bool
+	compare         bool
+	plain           bool
 }
 
 // Time window presets (in minutes)
@@ -57,8 +214,21 @@ var timeWindowPresets = []int{5, 30, 60, 180, 720, 1440, 10080, 43200, 0} // 5m,
 
 // UI display limits
 const (
-	maxTopItemsDisplay = 10 // Maximum items to display in top N tables
-	maxLogLinesDisplay = 15 // Maximum log lines to keep in stream
+	maxLogLinesDisplay  = 15    // Maximum log lines to keep in stream
+	maxVisitorsInMemory = 10000 // Default maximum of entries kept for the panels
+)
+
+// Request and bandwidth rates are tracked over 10 minutes in 10s buckets
+const (
+	rateInterval = 10 * time.Second
+	rateBuckets  = 60
+)
+
+// Unique visitor estimation: hourly sketches cover the longest time window,
+// per-minute sketches feed the trend panel.
+const (
+	uniqueTrackerHours = 31*24 + 1
+	uniqueTrendMinutes = 60
 )
 
 // NewTviewApp creates a new tview-based application.
@@ -78,25 +248,137 @@ func NewTviewApp(lines <-chan string, logFilePath string, refreshRate time.Durat
 		methodsData:     make(map[string]int),
 		countriesData:   make(map[string]int),
 		referersData:    make(map[string]int),
+		sourcesData:     make(map[string]int),
+		hotlinkData:     make(map[hotlinkKey]hotlinkStat),
+		pathBytes:       make(map[string]int),
+		ipBytes:         make(map[string]int),
+		osData:          make(map[string]int),
+		devicesData:     make(map[string]int),
+		botsData:        make(map[string]int),
+		protocolsData:   make(map[string]int),
+		tlsProtocols:    make(map[string]int),
+		tlsCiphers:      make(map[string]int),
+		notFoundPaths:   make(map[string]int),
+		notFoundIPs:     make(map[string]map[string]int),
+		views:           defaultViews,
+		streamColumns:   defaultStreamColumns,
 		logLines:        make([]string, 0),
+		rawLines:        make([]string, 0),
+		rawFollow:       true,
+		rawMatch:        -1,
+		page:            pageDashboard,
 		startTime:       time.Now(),
 		refreshRate:     refreshRate,
 		timeWindow:      0,                          // Default: all time
 		timeWindowIndex: len(timeWindowPresets) - 1, // Last preset (all time)
+		maxEntries:      maxVisitorsInMemory,
+		timeFormat:      defaultTimeFormat,
+		help:            dashboardHelp(nil),
 		geoLocator:      geoLocator,
-		rateTracker:     metrics.NewRateTracker(10*time.Second, 60), // 10-minute window with 10s buckets
+		uaParser:        useragent.NewParser(),
+		alerts:          alert.NewBoard(),
+		attacks:         attack.NewTracker(),
+		sensitive:       sensitive.Default(),
+		watchlist:       &watchlist.List{},
+		state:           &state.State{},
+		hidden:          make(map[string]bool),
+		watchPending:    make(map[watchlist.Entry]watchHit),
+		rateTracker:     metrics.NewRateTracker(rateInterval, rateBuckets),
+		bytesTracker:    metrics.NewRateTracker(rateInterval, rateBuckets),
+		uniqueTracker:   metrics.NewUniqueTracker(time.Hour, uniqueTrackerHours, 12),
+		uniqueTrend:     metrics.NewUniqueTracker(time.Minute, uniqueTrendMinutes, 10),
 	}
 
+	ta.checker = alert.NewChecker(ta.alerts, maxVisitorsInMemory)
+
 	ta.initUI()
 	return ta
 }
 
+// SetLogFormat sets a custom log format used instead of the combined format.
+// Must be called before Run.
+func (ta *TviewApp) SetLogFormat(format *parser.Format) {
+	ta.format = format
+}
+
+// SetExclude sets the patterns of requests left out of every panel, alert
+// and exporter. Must be called before Run.
+func (ta *TviewApp) SetExclude(rules *exclude.Rules) {
+	ta.exclude.Store(rules)
+}
+
+// parse parses a log line with the configured format.
+func (ta *TviewApp) parse(line string) *parser.Visitor {
+	if ta.format != nil {
+		return ta.format.Parse(line)
+	}
+	return parser.Parse(line)
+}
+
+// SetMetrics sets the exporter that every parsed entry and parse failure is
+// counted in, e.g. for a Prometheus endpoint. Must be called before Run.
+func (ta *TviewApp) SetMetrics(exporter *metrics.Exporter) {
+	ta.exporter = exporter
+}
+
+// SetStatsD sets the emitter that every parsed entry and parse failure is
+// counted in. Must be called before Run.
+func (ta *TviewApp) SetStatsD(statsd *metrics.StatsD) {
+	ta.statsd = statsd
+}
+
+// SetPusher sets the pusher that every parsed entry and parse failure is
+// counted in, e.g. for InfluxDB or Graphite. Must be called before Run.
+func (ta *TviewApp) SetPusher(pusher *metrics.Pusher) {
+	ta.pusher = pusher
+}
+
+// SetStore sets the store that every parsed entry is saved to. Must be
+// called before Run.
+func (ta *TviewApp) SetStore(s *store.Store) {
+	ta.store = s
+}
+
+// SetFeed sets the hub that every parsed entry is published to, e.g. for
+// WebSocket subscribers. Must be called before Run.
+func (ta *TviewApp) SetFeed(hub *feed.Hub) {
+	ta.feed = hub
+}
+
+// SetSummarizer sets the summarizer that every parsed entry is counted in,
+// e.g. for periodic chat summaries. Must be called before Run.
+func (ta *TviewApp) SetSummarizer(s *stats.Summarizer) {
+	ta.summarizer = s
+}
+
+// SetOffenders sets the detector that every parsed entry is counted in, to
+// flag abusive IPs. Must be called before Run.
+func (ta *TviewApp) SetOffenders(d *abuse.Detector) {
+	ta.offenders = d
+}
+
+// SetHealth sets the monitor every line read is recorded in, for the
+// /healthz endpoints. Must be called before Run.
+func (ta *TviewApp) SetHealth(m *health.Monitor) {
+	ta.healthMonitor = m
+}
+
+// SetHighlightRules sets the rules used to highlight matching entries in the
+// live stream and tables.
+func (ta *TviewApp) SetHighlightRules(rules highlight.Rules) {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+	ta.highlights = rules
+	ta.dataChanged = true
+}
+
 // initUI initializes the tview UI components.
 func (ta *TviewApp) initUI() {
 	// Define elegant color scheme
 	borderColor := tcell.NewRGBColor(75, 85, 99)  // Gray 600
 	titleColor := tcell.NewRGBColor(139, 92, 246) // Purple
 	headerBg := tcell.NewRGBColor(31, 41, 55)     // Gray 800
+	ta.borderColor = borderColor
 
 	// Create panels with borders and titles
 	ta.overview = ta.createTextView("📊 Overview", borderColor, titleColor)
@@ -108,74 +390,248 @@ func (ta *TviewApp) initUI() {
 	ta.countriesTable = ta.createTable("🌍 Countries", borderColor, titleColor)
 	ta.referersTable = ta.createTable("🔗 Sources", borderColor, titleColor)
 	ta.logStream = ta.createTextView("📝 Live Stream", borderColor, titleColor)
+	ta.bandwidthTable = ta.createTable("📦 Bandwidth", borderColor, titleColor)
+	ta.uniquesView = ta.createTextView("👥 Unique Visitors", borderColor, titleColor)
+	ta.watchTable = ta.createTable("👁 Watchlist", borderColor, titleColor)
+	ta.hoursView = ta.createTextView("🕒 Requests by Hour", borderColor, titleColor)
+	ta.hoursView.SetWrap(false)
+	ta.platformsTable = ta.createTable("💻 OS & Devices", borderColor, titleColor)
+	ta.botsTable = ta.createTable("🤖 Bots & Crawlers", borderColor, titleColor)
+	ta.protocolsTable = ta.createTable("🔀 HTTP Versions", borderColor, titleColor)
+	ta.tlsTable = ta.createTable("🔐 TLS", borderColor, titleColor)
+	ta.hotlinksTable = ta.createTable("🔗 Hotlinks", borderColor, titleColor)
+	ta.errorLogView = ta.createTextView("🧯 Error Log", borderColor, titleColor)
+	ta.notFoundTable = ta.createTable("🚫 404 Hot Paths", borderColor, titleColor)
+	ta.securityTable = ta.createTable("🛡 Security", borderColor, titleColor)
+	ta.historyTable = ta.createTable("🔔 Alert History", borderColor, titleColor)
+	ta.suspiciousTable = ta.createTable("🕵 Suspicious IPs", borderColor, titleColor)
+
+	ta.panels = map[string]tview.Primitive{
+		panelStatus:    ta.statusTable,
+		panelPaths:     ta.pathsTable,
+		panelMethods:   ta.methodsTable,
+		panelVisitors:  ta.visitorsTable,
+		panelClients:   ta.clientsTable,
+		panelCountries: ta.countriesTable,
+		panelReferers:  ta.referersTable,
+		panelStream:    ta.logStream,
+		panelBandwidth: ta.bandwidthTable,
+		panelUniques:   ta.uniquesView,
+		panelHours:     ta.hoursView,
+		panelWatch:     ta.watchTable,
+		panelPlatforms: ta.platformsTable,
+		panelBots:      ta.botsTable,
+		panelProtocols: ta.protocolsTable,
+		panelTLS:       ta.tlsTable,
+		panelHotlinks:  ta.hotlinksTable,
+		panelErrorLog:  ta.errorLogView,
+		panelNotFound:  ta.notFoundTable,
+		panelSecurity:  ta.securityTable,
+		panelAlerts:    ta.historyTable,
+		panelSuspects:  ta.suspiciousTable,
+	}
 
-	// Create header with log file path
-	headerText := fmt.Sprintf("[white::b] TAILNGINX [-::-] [::d]%s[-::-]", ta.logFilePath)
-	header := tview.NewTextView().
+	// Create header with log file path and view tabs
+	ta.header = tview.NewTextView().
 		SetDynamicColors(true).
-		SetTextAlign(tview.AlignCenter).
-		SetText(headerText)
-	header.SetBackgroundColor(headerBg)
+		SetTextAlign(tview.AlignCenter)
+	ta.header.SetBackgroundColor(headerBg)
+	ta.headerColor = headerBg
+	ta.renderHeader()
+
+	// Pipeline health segment on the right of the header
+	ta.healthView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignRight)
+	ta.healthView.SetBackgroundColor(headerBg)
+	ta.headerRow = tview.NewFlex().
+		AddItem(ta.header, 0, 1, false).
+		AddItem(ta.healthView, 0, 0, false)
+	ta.renderHealth()
+
+	// Create alert banner, shown under the header while alerts are active
+	ta.banner = tview.NewTextView().
+		SetDynamicColors(true)
+	ta.banner.SetBackgroundColor(headerBg)
 
 	// Create footer with help text
-	footer := tview.NewTextView().
+	ta.footer = tview.NewTextView().
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignCenter).
-		SetText("[yellow]q[-::-]:quit  [yellow]space[-::-]:pause  [yellow]±[-::-]:speed  [yellow]t[-::-]:window  [yellow]2-5[-::-]:filter  [yellow]esc[-::-]:clear")
-	footer.SetBackgroundColor(headerBg)
+		SetText(ta.help)
+	ta.footer.SetBackgroundColor(headerBg)
 
 	// Create main grid layout
 	ta.grid = tview.NewGrid().
-		SetRows(1, 0, 1). // header, content, footer
-		SetColumns(0).    // full width
+		SetColumns(0). // full width
 		SetBorders(false)
 
-	// Create content grid - responsive 3-column layout
-	content := tview.NewGrid().
-		SetRows(4, 0, 0).    // overview (smaller), middle row, bottom row
-		SetColumns(0, 0, 0). // 3 equal columns
-		SetBorders(true)
-
-	// Row 1: Overview spans all columns
-	content.AddItem(ta.overview, 0, 0, 1, 3, 0, 0, false)
-
-	// Row 2: Status, Paths, Methods (3 columns)
-	content.AddItem(ta.statusTable, 1, 0, 1, 1, 0, 0, false)
-	content.AddItem(ta.pathsTable, 1, 1, 1, 1, 0, 0, false)
-	content.AddItem(ta.methodsTable, 1, 2, 1, 1, 0, 0, false)
-
-	// Row 3: Bottom section with 2 rows
-	bottomGrid := tview.NewGrid().
-		SetRows(0, 0).       // 2 equal rows
-		SetColumns(0, 0, 0). // 3 equal columns
-		SetBorders(true)
-
-	// Bottom row 1: Visitors, Clients, Countries
-	bottomGrid.AddItem(ta.visitorsTable, 0, 0, 1, 1, 0, 0, false)
-	bottomGrid.AddItem(ta.clientsTable, 0, 1, 1, 1, 0, 0, false)
-	bottomGrid.AddItem(ta.countriesTable, 0, 2, 1, 1, 0, 0, false)
-
-	// Bottom row 2: Referers (1 col) + Log Stream (2 cols)
-	bottomGrid.AddItem(ta.referersTable, 1, 0, 1, 1, 0, 0, false)
-	bottomGrid.AddItem(ta.logStream, 1, 1, 1, 2, 0, 0, false)
-
-	content.AddItem(bottomGrid, 2, 0, 1, 3, 0, 0, false)
+	// Pages switch the content area between the dashboard views and the raw log viewer
+	ta.pages = tview.NewPages()
+	for i, view := range ta.views {
+		ta.pages.AddPage(viewPageName(i), ta.buildView(view), true, i == ta.viewIndex)
+	}
+	ta.pages.AddPage(pageRaw, ta.initRawView(borderColor, titleColor, headerBg), true, false)
+	ta.pages.AddPage(pageGeo, ta.initGeoView(borderColor, titleColor), true, false)
+	ta.pages.AddPage(pagePanels, ta.initPanelMenu(borderColor, titleColor), true, false)
+	ta.pages.AddPage(pageCountry, ta.initCountryView(borderColor, titleColor), true, false)
+	ta.pages.AddPage(pageStatus, ta.initStatusPrompt(borderColor, titleColor, headerBg), true, false)
 
-	// Add all to main grid
-	ta.grid.AddItem(header, 0, 0, 1, 1, 0, 0, false)
-	ta.grid.AddItem(content, 1, 0, 1, 1, 0, 0, false)
-	ta.grid.AddItem(footer, 2, 0, 1, 1, 0, 0, false)
+	// Add all to main grid: header, (alert banner), content, footer
+	ta.layoutMain(0)
 
 	// Set up key bindings
 	ta.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Let the input fields receive typed text untouched
+		if focus := ta.app.GetFocus(); focus == ta.rawSearch || focus == ta.statusPrompt {
+			return event
+		}
+		// Keys bound to actions act as the default keys of the actions
+		if ta.page == pageDashboard {
+			event = ta.translateKey(event)
+		}
+		// A single panel has no views or pages to switch, only scrolling
+		if ta.mini != nil {
+			if event.Rune() == 'q' {
+				ta.app.Stop()
+				return nil
+			}
+			return event
+		}
+		// The accessible rendering lists the current view, so only keys that
+		// change what it lists apply; the others scroll it
+		if ta.accessView != nil {
+			switch {
+			case event.Rune() == 'v' || event.Rune() == 'V':
+				if event.Rune() == 'v' {
+					ta.switchView(ta.viewIndex + 1)
+				} else {
+					ta.switchView(ta.viewIndex - 1)
+				}
+				ta.app.SetFocus(ta.accessView)
+				return nil
+			case event.Key() == tcell.KeyEscape:
+			case event.Key() != tcell.KeyRune || !strings.ContainsRune("q tT2345", event.Rune()):
+				return event
+			}
+		}
+		if ta.page == pageCountry {
+			switch {
+			case event.Key() == tcell.KeyEscape:
+				ta.showCountry("")
+			case event.Rune() == 'q':
+				ta.app.Stop()
+			case event.Rune() == 'y':
+				ta.copySelection()
+			case event.Rune() == 'w':
+				ta.toggleWatch()
+			default:
+				return event
+			}
+			return nil
+		}
+		if ta.page == pagePanels {
+			if event.Rune() == 'p' || event.Rune() == 'P' || event.Key() == tcell.KeyEscape {
+				ta.showPanelMenu(false)
+				return nil
+			}
+			if event.Rune() == 'q' {
+				ta.app.Stop()
+				return nil
+			}
+			return event
+		}
+		if ta.page == pageRaw {
+			if ta.handleRawKey(event) {
+				return nil
+			}
+		} else if ta.page == pageGeo && (event.Rune() == 'g' || event.Rune() == 'G' || event.Key() == tcell.KeyEscape) {
+			ta.showGeoView(false)
+			return nil
+		} else if event.Rune() == 'r' || event.Rune() == 'R' {
+			ta.showRawView(true)
+			return nil
+		} else if event.Rune() == 'g' || event.Rune() == 'G' {
+			ta.showGeoView(true)
+			return nil
+		} else if ta.page == pageDashboard && (event.Rune() == 'p' || event.Rune() == 'P') {
+			ta.showPanelMenu(true)
+			return nil
+		} else if ta.page == pageDashboard && (event.Rune() == 's' || event.Rune() == 'S') {
+			ta.showStatusPrompt(true)
+			return nil
+		} else if event.Key() == tcell.KeyTab || event.Key() == tcell.KeyBacktab {
+			if event.Key() == tcell.KeyTab {
+				ta.cycleFocus(1)
+			} else {
+				ta.cycleFocus(-1)
+			}
+			return nil
+		}
+		if ta.page == pageDashboard && event.Modifiers()&tcell.ModShift != 0 {
+			switch event.Key() {
+			case tcell.KeyUp:
+				ta.resizeFocused(-1, 0)
+				return nil
+			case tcell.KeyDown:
+				ta.resizeFocused(1, 0)
+				return nil
+			case tcell.KeyLeft:
+				ta.resizeFocused(0, -1)
+				return nil
+			case tcell.KeyRight:
+				ta.resizeFocused(0, 1)
+				return nil
+			}
+		}
+		if event.Rune() == 'y' {
+			ta.copySelection()
+			return nil
+		}
+		if event.Rune() == 'w' {
+			ta.toggleWatch()
+			return nil
+		}
+		if event.Rune() == 'm' {
+			ta.cycleDisplayMode()
+			return nil
+		}
+		if event.Rune() == 'e' {
+			ta.exportSnapshot()
+			return nil
+		}
+		if event.Rune() == 'E' {
+			ta.exportTables()
+			return nil
+		}
+		if event.Rune() == 'b' {
+			ta.writeDenyList()
+			return nil
+		}
+		if event.Rune() == 'L' {
+			ta.writeRateLimits()
+			return nil
+		}
+		if ta.page == pageDashboard && (event.Rune() == 'v' || event.Rune() == 'V') {
+			if event.Rune() == 'v' {
+				ta.switchView(ta.viewIndex + 1)
+			} else {
+				ta.switchView(ta.viewIndex - 1)
+			}
+			return nil
+		}
+		if event.Rune() == 'a' {
+			ta.alerts.AckAll()
+			ta.renderBanner()
+			return nil
+		}
+
 		switch event.Rune() {
 		case 'q':
 			ta.app.Stop()
 			return nil
 		case ' ':
-			ta.mu.Lock()
-			ta.paused = !ta.paused
-			ta.mu.Unlock()
+			ta.togglePause()
 		case '+', '=':
 			ta.mu.Lock()
 			if ta.refreshRate > 100*time.Millisecond {
@@ -188,30 +644,14 @@ func (ta *TviewApp) initUI() {
 				ta.refreshRate += 100 * time.Millisecond
 			}
 			ta.mu.Unlock()
-		case '2':
-			ta.mu.Lock()
-			ta.statusFilter = 2
-			ta.applyFilters()
-			ta.dataChanged = true
-			ta.mu.Unlock()
-		case '3':
+		case '2', '3', '4', '5':
 			ta.mu.Lock()
-			ta.statusFilter = 3
-			ta.applyFilters()
-			ta.dataChanged = true
-			ta.mu.Unlock()
-		case '4':
-			ta.mu.Lock()
-			ta.statusFilter = 4
-			ta.applyFilters()
-			ta.dataChanged = true
-			ta.mu.Unlock()
-		case '5':
-			ta.mu.Lock()
-			ta.statusFilter = 5
+			ta.selectStatus(int(event.Rune() - '0'))
 			ta.applyFilters()
 			ta.dataChanged = true
 			ta.mu.Unlock()
+		case 'c', 'C':
+			ta.toggleCompare()
 		case 't', 'T':
 			// Toggle time window to next preset
 			ta.mu.Lock()
@@ -226,9 +666,12 @@ func (ta *TviewApp) initUI() {
 			ta.dataChanged = true
 			ta.mu.Unlock()
 		}
-		if event.Key() == tcell.KeyEscape {
+		if event.Key() == tcell.KeyEscape && ta.page == pageDashboard {
 			ta.mu.Lock()
 			ta.statusFilter = 0
+			ta.statusCode = 0
+			ta.pathFilter = ""
+			ta.ipFilter = nil
 			ta.applyFilters()
 			ta.dataChanged = true
 			ta.mu.Unlock()
@@ -271,17 +714,67 @@ func (ta *TviewApp) createTable(title string, borderColor, titleColor tcell.Colo
 func (ta *TviewApp) Run() error {
 	// Start log reader goroutine BEFORE running app
 	go ta.readLines()
+	if ta.errorLines != nil {
+		go ta.readErrorLines()
+	}
 
 	// Start update ticker
 	go ta.updateLoop()
 
+	// Tables fit their rows to the panel height and the live stream its
+	// columns to the panel width, so re-render after a resize. Narrow
+	// terminals get the views reflowed into fewer columns.
+	ta.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		ta.screen = screen
+		width, height := screen.Size()
+		if cols, rows := reflowSize(width, height); cols != ta.layoutCols || rows != ta.layoutRows {
+			ta.layoutCols, ta.layoutRows = cols, rows
+			ta.rebuildViews()
+		}
+		_, _, streamWidth, _ := ta.logStream.GetInnerRect()
+		if width != ta.screenWidth || height != ta.screenHeight || streamWidth != ta.streamWidth {
+			ta.screenWidth, ta.screenHeight = width, height
+			ta.mu.Lock()
+			ta.streamWidth = streamWidth
+			ta.dataChanged = true
+			ta.mu.Unlock()
+		}
+		return false
+	})
+
 	// Set root and run
-	return ta.app.SetRoot(ta.grid, true).Run()
+	var root tview.Primitive = ta.grid
+	switch {
+	case ta.mini != nil:
+		root = ta.mini
+	case ta.accessView != nil:
+		root = ta.accessibleRoot()
+	}
+	if err := ta.app.SetRoot(root, true).Run(); err != nil {
+		return err
+	}
+
+	// Keep the findings of the session once the terminal is closed
+	if ta.dumpFile != "" {
+		if err := ta.Snapshot().Save(ta.dumpFile); err != nil {
+			return fmt.Errorf("dump snapshot: %w", err)
+		}
+	}
+
+	// The next session starts where this one ends
+	if ta.statePath != "" {
+		ta.state.Session = ta.session()
+		if err := ta.state.Save(ta.statePath); err != nil {
+			return fmt.Errorf("save UI state: %w", err)
+		}
+	}
+	return nil
 }
 
 // readLines reads log lines from the channel.
 func (ta *TviewApp) readLines() {
 	batch := make([]parser.Visitor, 0, 100)
+	raw := make([]string, 0, 100)
 	batchTicker := time.NewTicker(100 * time.Millisecond)
 	defer batchTicker.Stop()
 
@@ -289,20 +782,44 @@ func (ta *TviewApp) readLines() {
 		select {
 		case line, ok := <-ta.lines:
 			if !ok {
+				ta.healthMonitor.Stopped()
 				// Channel closed, flush remaining batch
 				if len(batch) > 0 {
 					ta.processBatch(batch)
 				}
+				if len(raw) > 0 {
+					ta.appendRaw(raw)
+				}
 				return
 			}
 
-			if v := parser.Parse(line); v != nil {
+			// Keep every line for the raw viewer, including unparsable ones
+			raw = append(raw, line)
+			if len(raw) >= 100 {
+				ta.appendRaw(raw)
+				raw = make([]string, 0, 100)
+			}
+
+			if v := ta.parse(line); v != nil && ta.exclude.Load().Match(v) {
+				ta.healthMonitor.Line(true)
+			} else if v != nil {
 				// Add country information if available
 				if ta.geoLocator != nil {
 					if loc, err := ta.geoLocator.Lookup(v.IP); err == nil && loc != nil {
 						v.Country = loc.Country
 					}
 				}
+				ta.healthMonitor.Line(true)
+				ta.exporter.Observe(v)
+				ta.statsd.Observe(v)
+				ta.pusher.Observe(v)
+				ta.store.Add(v)
+				ta.feed.Publish(v)
+				ta.checker.Observe(v)
+				ta.summarizer.Observe(v)
+				ta.offenders.Observe(v)
+				ta.rateLimits.Observe(v)
+				ta.attacks.Observe(v)
 				batch = append(batch, *v)
 
 				// Process batch when it reaches 100 entries
@@ -310,6 +827,12 @@ func (ta *TviewApp) readLines() {
 					ta.processBatch(batch)
 					batch = make([]parser.Visitor, 0, 100)
 				}
+			} else if strings.TrimSpace(line) != "" {
+				ta.parseFailures.Add(1)
+				ta.healthMonitor.Line(false)
+				ta.exporter.ParseFailure()
+				ta.statsd.ParseFailure()
+				ta.pusher.ParseFailure()
 			}
 
 		case <-batchTicker.C:
@@ -318,6 +841,10 @@ func (ta *TviewApp) readLines() {
 				ta.processBatch(batch)
 				batch = make([]parser.Visitor, 0, 100)
 			}
+			if len(raw) > 0 {
+				ta.appendRaw(raw)
+				raw = make([]string, 0, 100)
+			}
 		}
 	}
 }
@@ -327,23 +854,37 @@ func (ta *TviewApp) processBatch(batch []parser.Visitor) {
 	ta.mu.Lock()
 	defer ta.mu.Unlock()
 
-	// Record requests in rate tracker
+	// Record requests and bytes in rate trackers
 	for _, v := range batch {
+		ta.retainedBytes += entrySize(&v)
 		ta.rateTracker.Record(v.Time)
+		ta.bytesTracker.RecordN(v.Time, v.Bytes)
+		ta.uniqueTracker.Add(v.Time, v.IP)
+		ta.uniqueTrend.Add(v.Time, v.IP)
 	}
 
-	ta.allVisitors = append(ta.allVisitors, batch...)
-	// Keep only last 10000 visitors in memory
-	if len(ta.allVisitors) > 10000 {
-		ta.allVisitors = ta.allVisitors[len(ta.allVisitors)-10000:]
+	ta.recordWatchHits(batch)
+
+	// Count what accumulates while the display is frozen
+	if ta.paused {
+		ta.pausedPending += len(batch)
 	}
+
+	ta.allVisitors = append(ta.allVisitors, batch...)
+	// Keep only the most recent visitors in memory
+	ta.evict()
 	ta.applyFilters()
 	ta.dataChanged = true
 }
 
-// applyFilters filters visitors based on current filters (status and time window).
+// applyFilters filters visitors based on current filters (status, path, IP
+// and time window).
+// The exact codes of the selected class are counted in classCodes so the
+// Status panel can list them while a single code is selected.
 func (ta *TviewApp) applyFilters() {
 	ta.visitors = nil
+	ta.previousEntries = nil
+	ta.classCodes = make(map[int]int)
 	now := time.Now()
 
 	for _, v := range ta.allVisitors {
@@ -351,15 +892,28 @@ func (ta *TviewApp) applyFilters() {
 		if ta.statusFilter > 0 && v.Status/100 != ta.statusFilter {
 			continue
 		}
+		if !ta.inScope(&v) {
+			continue
+		}
 
 		// Apply time window filter
 		if ta.timeWindow > 0 {
 			age := now.Sub(v.Time)
 			if age > ta.timeWindow {
+				// Compare mode keeps the preceding window for deltas
+				if ta.comparing() && ta.inPreviousWindow(now, v.Time) &&
+					(ta.statusCode == 0 || v.Status == ta.statusCode) {
+					ta.previousEntries = append(ta.previousEntries, v)
+				}
 				continue // Entry is too old
 			}
 		}
 
+		ta.classCodes[v.Status]++
+		if ta.statusCode > 0 && v.Status != ta.statusCode {
+			continue
+		}
+
 		ta.visitors = append(ta.visitors, v)
 	}
 }
@@ -369,13 +923,36 @@ func (ta *TviewApp) updateLoop() {
 	ticker := time.NewTicker(ta.refreshRate)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for now := range ticker.C {
+		if ta.checkAlerts(now) {
+			ta.app.QueueUpdateDraw(ta.renderBanner)
+		}
+
 		ta.mu.Lock()
 		paused := ta.paused
 		changed := ta.dataChanged
 		ta.dataChanged = false // Reset flag
+		rawChanged := ta.rawChanged && ta.page == pageRaw
+		if rawChanged {
+			ta.rawChanged = false
+		}
 		ta.mu.Unlock()
 
+		if !paused && rawChanged {
+			ta.app.QueueUpdateDraw(ta.renderRaw)
+		}
+
+		// While paused only the overview is refreshed to show the pending backlog
+		if paused && changed {
+			ta.app.QueueUpdateDraw(func() {
+				ta.mu.RLock()
+				defer ta.mu.RUnlock()
+				ta.renderOverview()
+				ta.renderHealth()
+				ta.renderAccessible()
+			})
+		}
+
 		// Only update if not paused AND data actually changed
 		if !paused && changed {
 			ta.mu.Lock()
@@ -391,8 +968,35 @@ func (ta *TviewApp) updateLoop() {
 	}
 }
 
+// togglePause pauses or resumes the display. On resume the entries buffered
+// while paused are rendered immediately instead of waiting for the next tick.
+// Must be called from the UI goroutine.
+func (ta *TviewApp) togglePause() {
+	ta.mu.Lock()
+	ta.paused = !ta.paused
+	if ta.paused {
+		ta.pausedPending = 0
+		ta.mu.Unlock()
+		ta.mu.RLock()
+		ta.renderOverview()
+		ta.mu.RUnlock()
+		return
+	}
+	ta.pausedPending = 0
+	ta.dataChanged = false
+	ta.updateData()
+	ta.mu.Unlock()
+
+	ta.mu.RLock()
+	defer ta.mu.RUnlock()
+	ta.renderAll()
+}
+
 // updateData updates internal data structures from visitors.
 func (ta *TviewApp) updateData() {
+	ta.shownRequests = len(ta.visitors)
+	ta.shownTotal = len(ta.allVisitors)
+
 	// Reset maps
 	ta.statusCodes = make(map[int]int)
 	ta.pathsData = make(map[string]int)
@@ -401,43 +1005,91 @@ func (ta *TviewApp) updateData() {
 	ta.methodsData = make(map[string]int)
 	ta.countriesData = make(map[string]int)
 	ta.referersData = make(map[string]int)
-	ta.logLines = make([]string, 0)
+	ta.sourcesData = make(map[string]int)
+	ta.hotlinkData = make(map[hotlinkKey]hotlinkStat)
+	ta.hotlinkKnown = false
+	ta.pathBytes = make(map[string]int)
+	ta.ipBytes = make(map[string]int)
+	ta.osData = make(map[string]int)
+	ta.devicesData = make(map[string]int)
+	ta.botsData = make(map[string]int)
+	ta.protocolsData = make(map[string]int)
+	ta.tlsProtocols = make(map[string]int)
+	ta.tlsCiphers = make(map[string]int)
+	ta.notFoundPaths = make(map[string]int)
+	ta.notFoundIPs = make(map[string]map[string]int)
+	ta.windowBytes = 0
+	ta.hourCounts = [24]uint64{}
+	ta.weekdayCounts = [7]uint64{}
+	scorer := anomaly.NewScorer(ta.sensitive)
 
 	for _, v := range ta.visitors {
 		ta.statusCodes[v.Status]++
 		ta.pathsData[v.Path]++
 		ta.ips[v.IP]++
-
-		// Truncate long user agents
-		agent := v.Agent
-		if len(agent) > 50 {
-			agent = agent[:47] + "..."
+		ta.pathBytes[v.Path] += v.Bytes
+		ta.ipBytes[v.IP] += v.Bytes
+		ta.windowBytes += int64(v.Bytes)
+		ta.hourCounts[v.Time.Hour()]++
+		ta.weekdayCounts[v.Time.Weekday()]++
+
+		// Crawlers are listed separately from human clients
+		ua := ta.uaParser.Parse(v.Agent)
+		scorer.Add(&v, ua)
+		ta.osData[ua.OS]++
+		ta.devicesData[ua.Device]++
+		if ua.IsBot() {
+			ta.botsData[ua.Bot]++
+		} else {
+			ta.userAgents[clientName(v.Agent)]++
 		}
-		ta.userAgents[agent]++
 
 		ta.methodsData[v.Method]++
+		ta.protocolsData[httpVersion(v.Protocol)]++
+		if v.Status == 404 {
+			ta.notFoundPaths[v.Path]++
+			if ta.notFoundIPs[v.Path] == nil {
+				ta.notFoundIPs[v.Path] = make(map[string]int)
+			}
+			ta.notFoundIPs[v.Path][v.IP]++
+		}
+		if v.TLSProtocol != "" {
+			ta.tlsProtocols[v.TLSProtocol]++
+		}
+		if v.TLSCipher != "" {
+			ta.tlsCiphers[v.TLSCipher]++
+		}
 
 		if v.Country != "" && v.Country != "Unknown" {
 			ta.countriesData[v.Country]++
 		}
 
-		if v.Referer != "" && v.Referer != "-" {
-			ta.referersData[v.Referer]++
+		source, domain := referrer.Classify(v.Referer)
+		ta.sourcesData[string(source)]++
+		if domain != "" {
+			ta.referersData[domain]++
+		}
+		ta.countHotlink(&v)
+	}
+	ta.suspicious = scorer.Top(minSuspicion)
+	ta.updatePrevious()
+	ta.updateWatchlist()
+
+	// With a single code selected, the Status panel keeps showing its whole class
+	if ta.statusCode > 0 {
+		for code, count := range ta.classCodes {
+			ta.statusCodes[code] = count
 		}
-
-		// Add to log stream (last 15 lines)
-		logLine := fmt.Sprintf("[::d]%s[-::-] [yellow]%s[-::-] %s [cyan]%d[-::-]",
-			v.Time.Format("15:04:05"),
-			v.Method,
-			v.Path,
-			v.Status)
-		ta.logLines = append(ta.logLines, logLine)
 	}
 
-	// Keep only last N log lines
-	if len(ta.logLines) > maxLogLinesDisplay {
-		ta.logLines = ta.logLines[len(ta.logLines)-maxLogLinesDisplay:]
+	// Live stream of the last N entries
+	recent := ta.visitors
+	if len(recent) > maxLogLinesDisplay {
+		recent = recent[len(recent)-maxLogLinesDisplay:]
 	}
+	ta.logLines = ta.streamLines(recent, ta.streamWidth)
+
+	ta.updateUniqueVisitors(time.Now())
 }
 
 // renderAll renders all UI components.
@@ -451,37 +1103,79 @@ func (ta *TviewApp) renderAll() {
 	ta.renderCountries()
 	ta.renderReferers()
 	ta.renderLogStream()
+	ta.renderBandwidth()
+	ta.renderUniques()
+	ta.renderHours()
+	ta.renderWatchlist()
+	ta.renderPlatforms()
+	ta.renderBots()
+	ta.renderProtocols()
+	ta.renderTLS()
+	ta.renderHotlinks()
+	ta.renderErrorLog()
+	ta.renderNotFound()
+	ta.renderSecurity()
+	ta.renderAlertHistory()
+	ta.renderSuspicious()
+	ta.renderGeo()
+	ta.renderHealth()
+	ta.renderMini()
+	ta.renderAccessible()
+	if ta.page == pageCountry {
+		ta.renderCountry()
+		focusRows(ta.countryTable)
+	}
+	if ta.focused != nil {
+		focusRows(ta.focused)
+	}
+
+	// Tables start out tracking their end (they are empty on the first draw),
+	// which hides the top rows once content overflows. Keep unfocused tables
+	// at the top; the focused one scrolls with its selection.
+	for _, panel := range ta.panels {
+		if table, ok := panel.(*tview.Table); ok && table != ta.focused {
+			table.ScrollToBeginning()
+		}
+	}
 }
 
 // renderOverview renders the overview panel.
 func (ta *TviewApp) renderOverview() {
 	uptime := time.Since(ta.startTime).Round(time.Second)
-	totalRequests := len(ta.visitors)
-	totalAll := len(ta.allVisitors)
+	totalRequests := ta.shownRequests
+	totalAll := ta.shownTotal
 
 	status := "[green::b]Running[-::-]"
 	if ta.paused {
-		status = "[yellow::b]Paused[-::-]"
+		status = fmt.Sprintf("[yellow::b]⏸ paused — %s new entries[-::-]", formatCount(ta.pausedPending))
 	}
 
 	filterText := "All"
-	if ta.statusFilter > 0 {
-		filterText = fmt.Sprintf("[cyan]%dxx[-::-]", ta.statusFilter)
+	switch {
+	case ta.statusCode > 0:
+		filterText = fmt.Sprintf("[cyan]%d[-::-] [::d](%d: next code)[-::-]", ta.statusCode, ta.statusFilter)
+	case ta.statusFilter > 0:
+		filterText = fmt.Sprintf("[cyan]%dxx[-::-] [::d](%d: exact codes)[-::-]", ta.statusFilter, ta.statusFilter)
+	}
+	if ta.pathFilter != "" || ta.ipFilter != nil {
+		scope := ""
+		if ta.pathFilter != "" {
+			scope += fmt.Sprintf(" [cyan]path %s*[-::-]", tview.Escape(ta.pathFilter))
+		}
+		if ta.ipFilter != nil {
+			scope += fmt.Sprintf(" [cyan]ip %s[-::-]", ta.ipFilter)
+		}
+		if filterText == "All" {
+			filterText = scope[1:]
+		} else {
+			filterText += scope
+		}
 	}
 
 	// Format time window
 	windowText := "[green]All time[-::-]"
 	if ta.timeWindow > 0 {
-		minutes := int(ta.timeWindow.Minutes())
-		if minutes >= 1440 { // >= 1 day
-			days := minutes / 1440
-			windowText = fmt.Sprintf("[green]%dd[-::-]", days)
-		} else if minutes >= 60 { // >= 1 hour
-			hours := minutes / 60
-			windowText = fmt.Sprintf("[green]%dh[-::-]", hours)
-		} else {
-			windowText = fmt.Sprintf("[green]%dm[-::-]", minutes)
-		}
+		windowText = fmt.Sprintf("[green]%s[-::-]", formatWindow(ta.timeWindow))
 	}
 
 	// Get rate statistics
@@ -503,10 +1197,26 @@ func (ta *TviewApp) renderOverview() {
 		rateText = fmt.Sprintf("  •  [::b]Rate:[-::-] [white]%.1f req/s[-::-] [%s]%s[-::-]", stats.Current, trendColor, trendIndicator)
 	}
 
+	requestsText := fmt.Sprintf("[white]%d[-::-]", totalRequests)
+	if ta.compare {
+		if ta.comparing() {
+			requestsText += " " + deltaText(totalRequests, ta.previousTotal)
+			windowText += " [::d]vs previous[-::-]"
+		} else {
+			windowText += " [::d](compare: pick a window with t)[-::-]"
+		}
+	}
+
+	visitorsText := formatCount(ta.uniqueVisitors)
+	if !ta.uniqueExact {
+		visitorsText = "~" + visitorsText
+	}
+
 	text := fmt.Sprintf(
-		"  [::b]Requests:[-::-] [white]%d[-::-] / [::d]%d[-::-]  •  [::b]Window:[-::-] %s  •  [::b]Uptime:[-::-] [white]%s[-::-]  •  [::b]Status:[-::-] %s  •  [::b]Filter:[-::-] %s%s",
-		totalRequests,
+		"  [::b]Requests:[-::-] %s / [::d]%d[-::-]  •  [::b]Visitors:[-::-] [white]%s[-::-]  •  [::b]Window:[-::-] %s  •  [::b]Uptime:[-::-] [white]%s[-::-]  •  [::b]Status:[-::-] %s  •  [::b]Filter:[-::-] %s%s",
+		requestsText,
 		totalAll,
+		visitorsText,
 		windowText,
 		uptime,
 		status,
@@ -537,9 +1247,11 @@ func (ta *TviewApp) renderStatus() {
 		return sorted[i].value > sorted[j].value
 	})
 
-	row := 0
+	setHeader(ta.statusTable, "Status", "", "%")
+	limit := ta.topLimit(ta.statusTable, 1)
+	row := 1
 	for _, item := range sorted {
-		if row >= maxTopItemsDisplay {
+		if row > limit {
 			break
 		}
 
@@ -570,9 +1282,18 @@ func (ta *TviewApp) renderStatus() {
 			strings.Repeat("█", filledWidth),
 			strings.Repeat("░", barWidth-filledWidth))
 
+		label := fmt.Sprintf("[%s]%s %d[-::-]", color, symbol, item.key)
+		if item.key == ta.statusCode {
+			label = fmt.Sprintf("[%s::r]%s %d[-::-]", color, symbol, item.key) // Selected exact code
+		}
+		if style, ok := ta.highlights.ValueStyle("status", fmt.Sprint(item.key)); ok {
+			label = fmt.Sprintf("%s%s %d[-:-:-]", style.Tag(), symbol, item.key)
+		}
+
 		ta.statusTable.SetCell(row, 0,
-			tview.NewTableCell(fmt.Sprintf("[%s]%s %d[-::-]", color, symbol, item.key)).
-				SetAlign(tview.AlignLeft))
+			tview.NewTableCell(label).
+				SetAlign(tview.AlignLeft).
+				SetReference(fmt.Sprint(item.key)))
 		ta.statusTable.SetCell(row, 1,
 			tview.NewTableCell(bar).
 				SetAlign(tview.AlignLeft))
@@ -587,25 +1308,25 @@ func (ta *TviewApp) renderStatus() {
 // renderPaths renders the top paths table.
 func (ta *TviewApp) renderPaths() {
 	ta.pathsTable.Clear()
-	ta.renderTopN(ta.pathsTable, ta.pathsData)
+	ta.renderTopN(ta.pathsTable, ta.pathsData, "path")
 }
 
 // renderVisitors renders the top visitors table.
 func (ta *TviewApp) renderVisitors() {
 	ta.visitorsTable.Clear()
-	ta.renderTopN(ta.visitorsTable, ta.ips)
+	ta.renderTopN(ta.visitorsTable, ta.ips, "ip")
 }
 
 // renderClients renders the top clients table.
 func (ta *TviewApp) renderClients() {
 	ta.clientsTable.Clear()
-	ta.renderTopN(ta.clientsTable, ta.userAgents)
+	ta.renderTopN(ta.clientsTable, ta.userAgents, "agent")
 }
 
 // renderMethods renders the HTTP methods table.
 func (ta *TviewApp) renderMethods() {
 	ta.methodsTable.Clear()
-	ta.renderTopN(ta.methodsTable, ta.methodsData)
+	ta.renderTopN(ta.methodsTable, ta.methodsData, "method")
 }
 
 // renderCountries renders the top countries table.
@@ -626,35 +1347,49 @@ func (ta *TviewApp) renderCountries() {
 		return sorted[i].value > sorted[j].value
 	})
 
-	row := 0
+	setHeader(ta.countriesTable, append([]string{"Country"}, ta.valueHeader()...)...)
+	limit := ta.topLimit(ta.countriesTable, 1)
+	row := 1
 	for _, item := range sorted {
-		if row >= 10 {
+		if row > limit {
 			break
 		}
 
 		// Get country name - display as "US - United States"
 		countryName := getCountryName(item.key)
 		displayText := fmt.Sprintf("[yellow::b]%s[-::-] %s", item.key, countryName)
+		if style, ok := ta.highlights.ValueStyle("country", item.key); ok {
+			displayText = fmt.Sprintf("%s%s %s[-:-:-]", style.Tag(), item.key, countryName)
+		}
 
 		ta.countriesTable.SetCell(row, 0,
 			tview.NewTableCell(displayText).
-				SetAlign(tview.AlignLeft))
+				SetAlign(tview.AlignLeft).
+				SetReference(item.key))
 		ta.countriesTable.SetCell(row, 1,
-			tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", item.value)).
+			tview.NewTableCell(ta.formatValue(item.value, sorted[0].value)).
 				SetAlign(tview.AlignRight))
+		if ta.previous != nil {
+			ta.countriesTable.SetCell(row, 2,
+				tview.NewTableCell(deltaText(item.value, ta.previous["country"][item.key])).
+					SetAlign(tview.AlignRight))
+		}
 
 		row++
 	}
 }
 
-// renderReferers renders the top referers table.
-func (ta *TviewApp) renderReferers() {
-	ta.referersTable.Clear()
-	ta.renderTopN(ta.referersTable, ta.referersData)
+// fieldHeaders are the key column headers of top N tables by entry field.
+var fieldHeaders = map[string]string{
+	"path":   "Path",
+	"ip":     "IP",
+	"agent":  "Client",
+	"method": "Method",
 }
 
-// renderTopN is a helper to render top N items from a map.
-func (ta *TviewApp) renderTopN(table *tview.Table, data map[string]int) {
+// renderTopN is a helper to render top N items from a map under a header row.
+// field names the entry field the keys come from, for highlight rules.
+func (ta *TviewApp) renderTopN(table *tview.Table, data map[string]int, field string) {
 	type kv struct {
 		key   string
 		value int
@@ -669,9 +1404,11 @@ func (ta *TviewApp) renderTopN(table *tview.Table, data map[string]int) {
 		return sorted[i].value > sorted[j].value
 	})
 
-	row := 0
+	setHeader(table, append([]string{fieldHeaders[field]}, ta.valueHeader()...)...)
+	limit := ta.topLimit(table, 1)
+	row := 1
 	for _, item := range sorted {
-		if row >= maxTopItemsDisplay {
+		if row > limit {
 			break
 		}
 
@@ -681,18 +1418,49 @@ func (ta *TviewApp) renderTopN(table *tview.Table, data map[string]int) {
 			key = key[:37] + "..."
 		}
 
+		tag := "[white]"
+		if style, ok := ta.highlights.ValueStyle(field, item.key); ok {
+			tag = style.Tag()
+		}
+
 		table.SetCell(row, 0,
-			tview.NewTableCell(fmt.Sprintf("[white]%s[-::-]", key)).
+			tview.NewTableCell(fmt.Sprintf("%s%s[-:-:-]", tag, key)).
 				SetAlign(tview.AlignLeft).
-				SetMaxWidth(40))
+				SetMaxWidth(40).
+				SetReference(item.key))
 		table.SetCell(row, 1,
-			tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", item.value)).
+			tview.NewTableCell(ta.formatValue(item.value, sorted[0].value)).
 				SetAlign(tview.AlignRight))
+		if ta.previous != nil {
+			table.SetCell(row, 2,
+				tview.NewTableCell(deltaText(item.value, ta.previous[field][item.key])).
+					SetAlign(tview.AlignRight))
+		}
 
 		row++
 	}
 }
 
+// formatWindow formats a time window in its largest whole unit, e.g. "3h".
+func formatWindow(window time.Duration) string {
+	minutes := int(window.Minutes())
+	if minutes >= 1440 { // >= 1 day
+		return fmt.Sprintf("%dd", minutes/1440)
+	} else if minutes >= 60 { // >= 1 hour
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+// clientName returns the user agent as listed in the clients table, with
+// long user agents truncated.
+func clientName(agent string) string {
+	if len(agent) > 50 {
+		return agent[:47] + "..."
+	}
+	return agent
+}
+
 // renderLogStream renders the live log stream.
 func (ta *TviewApp) renderLogStream() {
 	var b strings.Builder
@@ -704,6 +1472,22 @@ func (ta *TviewApp) renderLogStream() {
 	ta.logStream.ScrollToEnd()
 }
 
+// formatCount formats n with spaces as thousands separators (e.g. "1 482").
+func formatCount(n int) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	s := fmt.Sprint(n)
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
 // countryCodeToName maps 2-letter country codes to full names
 var countryCodeToName = map[string]string{
 	"US": "United States", "GB": "United Kingdom", "DE": "Germany",
@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/papaganelli/tailnginx/pkg/highlight"
 	"github.com/papaganelli/tailnginx/pkg/parser"
 )
 
@@ -242,3 +244,97 @@ func BenchmarkProcessBatch(b *testing.B) {
 		app.processBatch(batch)
 	}
 }
+
+// TestFormatCount tests thousands grouping.
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		in   int
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1482, "1 482"},
+		{1234567, "1 234 567"},
+		{-12345, "-12 345"},
+	}
+	for _, tt := range tests {
+		if got := formatCount(tt.in); got != tt.want {
+			t.Errorf("formatCount(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestPauseBuffering tests that entries are counted while paused and
+// rendered on resume.
+func TestPauseBuffering(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	app.togglePause()
+	if !app.paused {
+		t.Fatal("togglePause() should pause")
+	}
+
+	now := time.Now()
+	app.processBatch([]parser.Visitor{{Time: now, Status: 200}, {Time: now, Status: 404}})
+	app.processBatch([]parser.Visitor{{Time: now, Status: 500}})
+	if app.pausedPending != 3 {
+		t.Errorf("pausedPending = %d, want 3", app.pausedPending)
+	}
+	if app.shownTotal != 0 {
+		t.Errorf("shownTotal = %d while paused, want 0", app.shownTotal)
+	}
+
+	app.togglePause()
+	if app.paused {
+		t.Fatal("togglePause() should resume")
+	}
+	if app.pausedPending != 0 {
+		t.Errorf("pausedPending = %d after resume, want 0", app.pausedPending)
+	}
+	if app.shownTotal != 3 || app.statusCodes[404] != 1 {
+		t.Errorf("resume should process the backlog immediately, shownTotal = %d", app.shownTotal)
+	}
+}
+
+// TestHighlightRules tests that highlight rules style stream lines and table rows.
+func TestHighlightRules(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	rules, err := highlight.ParseAll([]string{"status>=500 -> red background", "path contains /api/payments -> bold yellow"})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	app.SetHighlightRules(rules)
+
+	now := time.Now()
+	app.visitors = []parser.Visitor{
+		{Time: now, Status: 200, Path: "/", Method: "GET"},
+		{Time: now, Status: 502, Path: "/checkout", Method: "POST"},
+		{Time: now, Status: 200, Path: "/api/payments/1", Method: "GET"},
+	}
+	app.updateData()
+
+	if strings.Contains(app.logLines[0], "[-:red:-]") || strings.Contains(app.logLines[0], "[yellow:-:b]") {
+		t.Errorf("unmatched line should not be highlighted: %q", app.logLines[0])
+	}
+	if !strings.HasPrefix(app.logLines[1], "[-:red:-]") {
+		t.Errorf("5xx line should be highlighted: %q", app.logLines[1])
+	}
+	if !strings.HasPrefix(app.logLines[2], "[yellow:-:b]") {
+		t.Errorf("payments line should be highlighted: %q", app.logLines[2])
+	}
+
+	app.renderPaths()
+	found := false
+	for row := 0; row < app.pathsTable.GetRowCount(); row++ {
+		cell := app.pathsTable.GetCell(row, 0)
+		if cell.GetReference() == "/api/payments/1" {
+			found = strings.HasPrefix(cell.Text, "[yellow:-:b]")
+		}
+	}
+	if !found {
+		t.Error("payments path row should be highlighted in the paths table")
+	}
+}
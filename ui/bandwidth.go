@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rivo/tview"
+)
+
+// maxBandwidthItems is the number of paths and IPs listed in the bandwidth panel.
+const maxBandwidthItems = 5
+
+// entry is a key with its count, used for sorted top lists.
+type entry struct {
+	key   string
+	value int
+}
+
+// topEntries returns up to n entries of data sorted by descending value, then key.
+// n <= 0 returns all entries.
+func topEntries(data map[string]int, n int) []entry {
+	entries := make([]entry, 0, len(data))
+	for k, v := range data {
+		entries = append(entries, entry{k, v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].value != entries[j].value {
+			return entries[i].value > entries[j].value
+		}
+		return entries[i].key < entries[j].key
+	})
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// formatBytes formats a byte count with binary units (e.g. "1.5 MB").
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// renderBandwidth renders current throughput, window total and top
+// paths/IPs by transferred bytes.
+func (ta *TviewApp) renderBandwidth() {
+	table := ta.bandwidthTable
+	table.Clear()
+
+	current := ta.bytesTracker.GetStats().Current
+	row := 0
+	setRow := func(label, value, ref string) {
+		cell := tview.NewTableCell(label).SetAlign(tview.AlignLeft).SetMaxWidth(40)
+		if ref != "" {
+			cell.SetReference(ref)
+		}
+		table.SetCell(row, 0, cell)
+		table.SetCell(row, 1, tview.NewTableCell(value).SetAlign(tview.AlignRight))
+		row++
+	}
+
+	setRow("[::b]Current[-::-]", fmt.Sprintf("[cyan::b]%s/s[-::-]", formatBytes(int64(current))), "")
+	setRow("[::b]Window total[-::-]", fmt.Sprintf("[cyan::b]%s[-::-]", formatBytes(ta.windowBytes)), "")
+
+	sections := []struct {
+		title string
+		data  map[string]int
+	}{
+		{"Top paths by bytes", ta.pathBytes},
+		{"Top IPs by bytes", ta.ipBytes},
+	}
+	for _, section := range sections {
+		setRow("", "", "")
+		setRow(fmt.Sprintf("[::d]%s[-::-]", section.title), "", "")
+		for _, e := range topEntries(section.data, maxBandwidthItems) {
+			key := e.key
+			if len(key) > 40 {
+				key = key[:37] + "..."
+			}
+			setRow(fmt.Sprintf("[white]%s[-::-]", tview.Escape(key)), fmt.Sprintf("[cyan]%s[-::-]", formatBytes(int64(e.value))), e.key)
+		}
+	}
+}
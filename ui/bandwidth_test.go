@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestFormatBytes tests byte formatting with binary units.
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.in); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestTopEntries tests sorting and truncation of top lists.
+func TestTopEntries(t *testing.T) {
+	data := map[string]int{"/a": 10, "/b": 30, "/c": 20, "/d": 20}
+	got := topEntries(data, 3)
+	want := []entry{{"/b", 30}, {"/c", 20}, {"/d", 20}}
+	if len(got) != len(want) {
+		t.Fatalf("topEntries() returned %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("topEntries()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if all := topEntries(data, 0); len(all) != len(data) {
+		t.Errorf("topEntries(n=0) returned %d entries, want %d", len(all), len(data))
+	}
+}
+
+// TestBandwidthAggregation tests that bytes are summed per path and IP.
+func TestBandwidthAggregation(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.processBatch([]parser.Visitor{
+		{IP: "1.1.1.1", Path: "/big", Bytes: 5000, Status: 200, Time: now},
+		{IP: "1.1.1.1", Path: "/small", Bytes: 100, Status: 200, Time: now},
+		{IP: "2.2.2.2", Path: "/big", Bytes: 5000, Status: 200, Time: now},
+	})
+	app.updateData()
+
+	if app.windowBytes != 10100 {
+		t.Errorf("windowBytes = %d, want 10100", app.windowBytes)
+	}
+	if app.pathBytes["/big"] != 10000 {
+		t.Errorf("pathBytes[/big] = %d, want 10000", app.pathBytes["/big"])
+	}
+	if app.ipBytes["1.1.1.1"] != 5100 {
+		t.Errorf("ipBytes[1.1.1.1] = %d, want 5100", app.ipBytes["1.1.1.1"])
+	}
+}
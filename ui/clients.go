@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/rivo/tview"
+)
+
+// maxPlatformItems is the number of operating systems listed in the platforms panel.
+const maxPlatformItems = 6
+
+// renderPlatforms renders the operating system and device class breakdowns.
+func (ta *TviewApp) renderPlatforms() {
+	table := ta.platformsTable
+	table.Clear()
+
+	total := 0
+	for _, count := range ta.devicesData {
+		total += count
+	}
+
+	row := 0
+	section := func(title string, entries []entry) {
+		if row > 0 {
+			row++ // Blank line between sections
+		}
+		table.SetCell(row, 0, tview.NewTableCell(fmt.Sprintf("[::d]%s[-::-]", title)))
+		row++
+		for _, e := range entries {
+			table.SetCell(row, 0,
+				tview.NewTableCell(fmt.Sprintf("[white]%s[-::-]", tview.Escape(e.key))).
+					SetAlign(tview.AlignLeft).
+					SetReference(e.key))
+			table.SetCell(row, 1,
+				tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", e.value)).
+					SetAlign(tview.AlignRight))
+			table.SetCell(row, 2,
+				tview.NewTableCell(fmt.Sprintf("[::d]%4.1f%%[-::-]", percent(e.value, total))).
+					SetAlign(tview.AlignRight))
+			row++
+		}
+	}
+
+	section("Operating systems", topEntries(ta.osData, maxPlatformItems))
+	section("Devices", topEntries(ta.devicesData, 0))
+}
+
+// percent returns part as a percentage of total, or 0 when total is 0.
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// renderBots renders the top crawlers with their share of all requests.
+func (ta *TviewApp) renderBots() {
+	table := ta.botsTable
+	table.Clear()
+
+	bots := 0
+	for _, count := range ta.botsData {
+		bots += count
+	}
+
+	table.SetCell(0, 0, tview.NewTableCell("[::b]All bots[-::-]"))
+	table.SetCell(0, 1,
+		tview.NewTableCell(fmt.Sprintf("[cyan::b]%d[-::-]", bots)).
+			SetAlign(tview.AlignRight))
+	table.SetCell(0, 2,
+		tview.NewTableCell(fmt.Sprintf("[::b]%4.1f%%[-::-]", percent(bots, ta.shownRequests))).
+			SetAlign(tview.AlignRight))
+
+	for i, e := range topEntries(ta.botsData, ta.topLimit(table, 2)) {
+		row := i + 2 // Leave a blank line under the total
+		tag := "[white]"
+		if style, ok := ta.highlights.ValueStyle("agent", e.key); ok {
+			tag = style.Tag()
+		}
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("%s%s[-:-:-]", tag, tview.Escape(e.key))).
+				SetAlign(tview.AlignLeft).
+				SetMaxWidth(30).
+				SetReference(e.key))
+		table.SetCell(row, 1,
+			tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", e.value)).
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 2,
+			tview.NewTableCell(fmt.Sprintf("[::d]%4.1f%%[-::-]", percent(e.value, ta.shownRequests))).
+				SetAlign(tview.AlignRight))
+	}
+}
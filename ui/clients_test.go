@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestPlatformAggregation tests OS and device counts from user agents.
+func TestPlatformAggregation(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.processBatch([]parser.Visitor{
+		{IP: "1.1.1.1", Status: 200, Time: now, Agent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0.0.0 Safari/537.36"},
+		{IP: "2.2.2.2", Status: 200, Time: now, Agent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) Version/17.1 Mobile/15E148 Safari/604.1"},
+		{IP: "3.3.3.3", Status: 200, Time: now, Agent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"},
+	})
+	app.updateData()
+
+	if app.osData["Windows"] != 1 || app.osData["iOS"] != 1 {
+		t.Errorf("osData = %v, want one Windows and one iOS", app.osData)
+	}
+	if app.devicesData["Desktop"] != 1 || app.devicesData["Mobile"] != 1 || app.devicesData["Bot"] != 1 {
+		t.Errorf("devicesData = %v, want one Desktop, Mobile and Bot", app.devicesData)
+	}
+}
+
+// TestPercent tests percentage calculation.
+func TestPercent(t *testing.T) {
+	if got := percent(1, 4); got != 25 {
+		t.Errorf("percent(1, 4) = %v, want 25", got)
+	}
+	if got := percent(1, 0); got != 0 {
+		t.Errorf("percent(1, 0) = %v, want 0", got)
+	}
+}
+
+// TestBotsSeparatedFromClients tests that crawlers are counted in the bots
+// panel and left out of the clients panel.
+func TestBotsSeparatedFromClients(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	googlebot := "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+	app.processBatch([]parser.Visitor{
+		{IP: "1.1.1.1", Status: 200, Time: now, Agent: "curl/8.4.0"},
+		{IP: "3.3.3.3", Status: 200, Time: now, Agent: googlebot},
+		{IP: "3.3.3.4", Status: 200, Time: now, Agent: googlebot},
+		{IP: "4.4.4.4", Status: 200, Time: now, Agent: "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.2; +https://openai.com/gptbot)"},
+	})
+	app.updateData()
+
+	if app.botsData["Googlebot"] != 2 || app.botsData["GPTBot"] != 1 {
+		t.Errorf("botsData = %v, want Googlebot=2 GPTBot=1", app.botsData)
+	}
+	if len(app.userAgents) != 1 || app.userAgents["curl/8.4.0"] != 1 {
+		t.Errorf("userAgents = %v, want only curl", app.userAgents)
+	}
+}
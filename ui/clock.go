@@ -0,0 +1,35 @@
+package ui
+
+import "time"
+
+// defaultTimeFormat is the layout of the times in the live stream, error log
+// and alert banner.
+const defaultTimeFormat = "15:04:05"
+
+// widestTime is a time with the longest names and two-digit values, to size
+// the live stream time column.
+var widestTime = time.Date(2026, time.September, 30, 23, 59, 59, 999999999, time.UTC)
+
+// SetTimeDisplay sets the time zone times are shown in, nil for the zone of
+// the log, and their layout, e.g. "Jan 02 15:04:05" to show dates when the
+// time window spans days. Must be called before Run.
+func (ta *TviewApp) SetTimeDisplay(loc *time.Location, layout string) {
+	ta.timeZone = loc
+	if layout == "" {
+		layout = defaultTimeFormat
+	}
+	ta.timeFormat = layout
+}
+
+// formatTime formats a time for display.
+func (ta *TviewApp) formatTime(t time.Time) string {
+	if ta.timeZone != nil {
+		t = t.In(ta.timeZone)
+	}
+	return t.Format(ta.timeFormat)
+}
+
+// timeWidth returns the width of formatted times.
+func (ta *TviewApp) timeWidth() int {
+	return len(widestTime.Format(ta.timeFormat))
+}
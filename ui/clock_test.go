@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestTimeDisplay tests the time zone and layout of displayed times.
+func TestTimeDisplay(t *testing.T) {
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	logged := time.Date(2025, time.October, 8, 23, 30, 0, 0, time.FixedZone("", 0))
+
+	if got := app.formatTime(logged); got != "23:30:00" {
+		t.Errorf("formatTime() = %q, want the logged time", got)
+	}
+
+	tokyo := time.FixedZone("JST", 9*3600)
+	app.SetTimeDisplay(tokyo, "Jan 02 15:04")
+	if got := app.formatTime(logged); got != "Oct 09 08:30" {
+		t.Errorf("formatTime() = %q, want Oct 09 08:30", got)
+	}
+
+	lines := app.streamLines([]parser.Visitor{{Time: logged, IP: "192.0.2.1", Method: "GET", Path: "/", Status: 200}}, 120)
+	if len(lines) != 1 || !strings.Contains(lines[0], "Oct 09 08:30") {
+		t.Errorf("streamLines() = %q, want the converted time", lines)
+	}
+	if got := app.timeWidth(); got != len("Sep 30 23:59") {
+		t.Errorf("timeWidth() = %d", got)
+	}
+}
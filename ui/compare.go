@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// toggleCompare turns compare mode on or off. In compare mode the top tables
+// show how each entry changed against the equally sized preceding window.
+// Must be called from the UI goroutine.
+func (ta *TviewApp) toggleCompare() {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	ta.compare = !ta.compare
+	ta.applyFilters()
+	ta.dataChanged = true
+}
+
+// comparing reports whether delta columns are shown. Compare mode needs a
+// bounded time window to have a preceding window.
+func (ta *TviewApp) comparing() bool {
+	return ta.compare && ta.timeWindow > 0
+}
+
+// inPreviousWindow reports whether an entry falls in the window preceding
+// the active time window.
+func (ta *TviewApp) inPreviousWindow(now, t time.Time) bool {
+	age := now.Sub(t)
+	return age > ta.timeWindow && age <= 2*ta.timeWindow
+}
+
+// updatePrevious counts the entries of the preceding window by the fields
+// of the top tables, keyed like the current window's maps. Caller must hold
+// the lock.
+func (ta *TviewApp) updatePrevious() {
+	ta.previousTotal = len(ta.previousEntries)
+	if !ta.comparing() {
+		ta.previous = nil
+		return
+	}
+
+	ta.previous = map[string]map[string]int{
+		"path":    {},
+		"ip":      {},
+		"agent":   {},
+		"method":  {},
+		"country": {},
+	}
+	for _, v := range ta.previousEntries {
+		ta.previous["path"][v.Path]++
+		ta.previous["ip"][v.IP]++
+		ta.previous["method"][v.Method]++
+		if v.Country != "" && v.Country != "Unknown" {
+			ta.previous["country"][v.Country]++
+		}
+		if !ta.uaParser.Parse(v.Agent).IsBot() {
+			ta.previous["agent"][clientName(v.Agent)]++
+		}
+	}
+}
+
+// deltaText formats the change from previous to current, e.g. "+180%", or
+// "new" for entries absent from the preceding window.
+func deltaText(current, previous int) string {
+	if previous == 0 {
+		return "[yellow::b]new[-::-]"
+	}
+	change := (current - previous) * 100 / previous
+	switch {
+	case change > 0:
+		return fmt.Sprintf("[yellow]+%d%%[-::-]", change)
+	case change < 0:
+		return fmt.Sprintf("[blue]%d%%[-::-]", change)
+	default:
+		return "[::d]±0%[-::-]"
+	}
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestDeltaText tests the formatting of changes against the previous window.
+func TestDeltaText(t *testing.T) {
+	tests := []struct {
+		current, previous int
+		want              string
+	}{
+		{320, 0, "[yellow::b]new[-::-]"},
+		{320, 114, "[yellow]+180%[-::-]"},
+		{50, 100, "[blue]-50%[-::-]"},
+		{7, 7, "[::d]±0%[-::-]"},
+	}
+	for _, tt := range tests {
+		if got := deltaText(tt.current, tt.previous); got != tt.want {
+			t.Errorf("deltaText(%d, %d) = %q, want %q", tt.current, tt.previous, got, tt.want)
+		}
+	}
+}
+
+// TestCompareMode tests that the preceding window is counted for deltas.
+func TestCompareMode(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.allVisitors = []parser.Visitor{
+		{Time: now.Add(-90 * time.Minute), Status: 200, Path: "/old"},     // before both windows
+		{Time: now.Add(-45 * time.Minute), Status: 200, Path: "/login"},   // previous window
+		{Time: now.Add(-40 * time.Minute), Status: 500, Path: "/login"},   // previous window
+		{Time: now.Add(-10 * time.Minute), Status: 200, Path: "/login"},   // current window
+		{Time: now.Add(-5 * time.Minute), Status: 200, Path: "/login"},    // current window
+		{Time: now.Add(-1 * time.Minute), Status: 200, Path: "/checkout"}, // current window
+	}
+	app.timeWindow = 30 * time.Minute
+
+	app.toggleCompare()
+	app.updateData()
+	if app.previousTotal != 2 {
+		t.Errorf("previousTotal = %d, want 2", app.previousTotal)
+	}
+	if got := app.previous["path"]["/login"]; got != 2 {
+		t.Errorf("previous /login = %d, want 2", got)
+	}
+
+	app.renderPaths()
+	deltas := map[string]string{}
+	for row := 1; row < app.pathsTable.GetRowCount(); row++ {
+		deltas[app.pathsTable.GetCell(row, 0).GetReference().(string)] = app.pathsTable.GetCell(row, 2).Text
+	}
+	if deltas["/login"] != deltaText(2, 2) || deltas["/checkout"] != deltaText(1, 0) {
+		t.Errorf("path deltas = %v", deltas)
+	}
+
+	// The status filter applies to the previous window too
+	app.statusFilter = 2
+	app.applyFilters()
+	app.updateData()
+	if app.previousTotal != 1 {
+		t.Errorf("previousTotal with 2xx filter = %d, want 1", app.previousTotal)
+	}
+
+	// Without a time window there is nothing to compare against
+	app.timeWindow = 0
+	app.applyFilters()
+	app.updateData()
+	if app.previous != nil {
+		t.Error("compare mode without a time window should not compute deltas")
+	}
+
+	app.toggleCompare()
+	app.timeWindow = 30 * time.Minute
+	app.applyFilters()
+	app.updateData()
+	app.renderPaths()
+	if app.pathsTable.GetColumnCount() != 2 {
+		t.Errorf("paths table has %d columns with compare off, want 2", app.pathsTable.GetColumnCount())
+	}
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Country drill-down size and the number of top paths listed per IP
+const (
+	countryViewWidth  = 100
+	countryViewHeight = 24
+	maxCountryPaths   = 3
+)
+
+// initCountryView creates the country drill-down, shown over the dashboard
+// when a country is selected with Enter.
+func (ta *TviewApp) initCountryView(borderColor, titleColor tcell.Color) tview.Primitive {
+	ta.countryTable = tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	ta.countryTable.SetBorder(true).
+		SetBorderColor(borderColor).
+		SetTitleColor(titleColor)
+
+	ta.countriesTable.SetSelectedFunc(func(row, _ int) {
+		if code, ok := ta.countriesTable.GetCell(row, 0).GetReference().(string); ok {
+			ta.showCountry(code)
+		}
+	})
+
+	return modal(ta.countryTable, countryViewWidth, countryViewHeight)
+}
+
+// showCountry opens the drill-down for a country code, or closes it when
+// code is empty.
+func (ta *TviewApp) showCountry(code string) {
+	ta.mu.Lock()
+	ta.country = code
+	if code != "" {
+		ta.page = pageCountry
+	} else {
+		ta.page = pageDashboard
+	}
+	ta.mu.Unlock()
+
+	if code != "" {
+		ta.mu.RLock()
+		ta.renderCountry()
+		ta.mu.RUnlock()
+		ta.countryTable.Select(1, 0).ScrollToBeginning()
+		focusRows(ta.countryTable)
+		ta.pages.ShowPage(pageCountry)
+		ta.pages.SendToFront(pageCountry)
+		ta.footer.SetText(countryHelp)
+		ta.app.SetFocus(ta.countryTable)
+		return
+	}
+	ta.pages.HidePage(pageCountry)
+	ta.footer.SetText(ta.help)
+	if ta.focused != nil {
+		ta.app.SetFocus(ta.focused)
+	} else {
+		ta.app.SetFocus(ta.grid)
+	}
+}
+
+// renderCountry lists the IPs of the selected country in the window with
+// their top paths. Caller must hold the lock.
+func (ta *TviewApp) renderCountry() {
+	table := ta.countryTable
+	table.Clear()
+
+	ips := make(map[string]int)
+	paths := make(map[string]map[string]int)
+	for _, v := range ta.visitors {
+		if v.Country != ta.country {
+			continue
+		}
+		ips[v.IP]++
+		if paths[v.IP] == nil {
+			paths[v.IP] = make(map[string]int)
+		}
+		paths[v.IP][v.Path]++
+	}
+
+	requests := 0
+	for _, n := range ips {
+		requests += n
+	}
+	table.SetTitle(ta.title(fmt.Sprintf("🌍 %s (%s) — %s requests from %s IPs",
+		getCountryName(ta.country), ta.country, formatCount(requests), formatCount(len(ips)))))
+
+	for col, header := range []string{"IP", "Requests", "Top paths"} {
+		table.SetCell(0, col,
+			tview.NewTableCell(fmt.Sprintf("[::b]%s[-::-]", header)).
+				SetSelectable(false))
+	}
+	if len(ips) == 0 {
+		table.SetCell(1, 0, tview.NewTableCell("[::d]No requests from this country in the window[-::-]"))
+		return
+	}
+
+	for i, e := range topEntries(ips, 0) {
+		row := i + 1
+		tag := "[white]"
+		if style, ok := ta.highlights.ValueStyle("ip", e.key); ok {
+			tag = style.Tag()
+		}
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("%s%s[-:-:-]", tag, e.key)).
+				SetReference(e.key))
+		table.SetCell(row, 1,
+			tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]  ", e.value)).
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 2,
+			tview.NewTableCell(topList(paths[e.key], maxCountryPaths)).
+				SetExpansion(1))
+	}
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestCountryDrillDown tests the IPs and top paths listed for a country.
+func TestCountryDrillDown(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.visitors = []parser.Visitor{
+		{Time: now, Country: "FR", IP: "10.0.0.1", Path: "/login"},
+		{Time: now, Country: "FR", IP: "10.0.0.1", Path: "/login"},
+		{Time: now, Country: "FR", IP: "10.0.0.1", Path: "/"},
+		{Time: now, Country: "FR", IP: "10.0.0.2", Path: "/"},
+		{Time: now, Country: "US", IP: "10.0.0.3", Path: "/"},
+	}
+	app.showCountry("FR")
+
+	table := app.countryTable
+	if !strings.Contains(table.GetTitle(), "France (FR) — 4 requests from 2 IPs") {
+		t.Errorf("title = %q", table.GetTitle())
+	}
+	if table.GetRowCount() != 3 {
+		t.Fatalf("got %d rows, want a header and 2 IPs", table.GetRowCount())
+	}
+	if ip := table.GetCell(1, 0).GetReference(); ip != "10.0.0.1" {
+		t.Errorf("first IP = %v, want the most active one", ip)
+	}
+	if paths := table.GetCell(1, 2).Text; !strings.HasPrefix(paths, "/login [::d]×2") {
+		t.Errorf("top paths = %q, want /login first", paths)
+	}
+	if got := app.selectedValue(); got != "10.0.0.1" {
+		t.Errorf("selectedValue() = %q, want the selected IP", got)
+	}
+
+	app.showCountry("")
+	if app.page != pageDashboard {
+		t.Errorf("page = %q after closing, want the dashboard", app.page)
+	}
+}
+
+// TestTopList tests the compact list of top keys.
+func TestTopList(t *testing.T) {
+	got := topList(map[string]int{"a": 3, "b": 2, "c": 1}, 2)
+	want := "a [::d]×3[-::-], b [::d]×2[-::-], [::d]+1 more[-::-]"
+	if got != want {
+		t.Errorf("topList() = %q, want %q", got, want)
+	}
+}
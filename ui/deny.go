@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/abuse"
+	"github.com/rivo/tview"
+)
+
+// SetDenyList sets where the b key writes the deny list of the flagged
+// IPs: the nginx include file at path, in the given abuse format, or a
+// timestamped file in the export directory if path is empty. With a PID
+// file, nginx is then told to reload its configuration.
+func (ta *TviewApp) SetDenyList(path, format, pidFile string) {
+	ta.denyFile = path
+	ta.denyFormat = format
+	ta.denyPIDFile = pidFile
+}
+
+// writeDenyList writes the deny list of the IPs flagged by the abuse
+// detector, reloads nginx if configured and reports the outcome in the
+// footer. Must be called from the UI goroutine.
+func (ta *TviewApp) writeDenyList() {
+	if ta.offenders == nil {
+		ta.flash("[red]Deny list:[-::-] abuse detection is off")
+		return
+	}
+	offenders := ta.offenders.Flagged()
+	if len(offenders) == 0 {
+		ta.flash("No abusive IPs flagged in the last hour")
+		return
+	}
+
+	now := time.Now()
+	path := ta.denyFile
+	if path == "" {
+		path = filepath.Join(ta.exportDir, "tailnginx-deny-"+now.Format("20060102-150405")+".conf")
+	}
+	format := ta.denyFormat
+	if format == "" {
+		format = abuse.FormatDeny
+	}
+	if err := abuse.SaveDenyList(path, offenders, format, now); err != nil {
+		ta.flash(fmt.Sprintf("[red]Deny list failed:[-::-] %v", err))
+		return
+	}
+	message := fmt.Sprintf("[green]Denied %d IPs in[-::-] %s", len(offenders), tview.Escape(path))
+	if ta.denyPIDFile != "" {
+		if err := abuse.ReloadNginx(ta.denyPIDFile); err != nil {
+			ta.flash(fmt.Sprintf("[red]Deny list written but %v", err))
+			return
+		}
+		message += ", nginx reloaded"
+	}
+	ta.flash(message)
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/abuse"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+func TestWriteDenyList(t *testing.T) {
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	dir := t.TempDir()
+	app.SetExportDir(dir)
+
+	app.writeDenyList()
+	if !strings.Contains(app.footer.GetText(false), "off") {
+		t.Errorf("footer = %q, want abuse detection reported off", app.footer.GetText(false))
+	}
+
+	d, err := abuse.NewDetector(abuse.Thresholds{Requests: 2, Window: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetOffenders(d)
+	app.writeDenyList()
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*")); len(matches) != 0 {
+		t.Errorf("wrote %v without flagged IPs", matches)
+	}
+
+	for i := 0; i < 3; i++ {
+		d.Observe(&parser.Visitor{IP: "203.0.113.7"})
+	}
+	app.writeDenyList()
+	matches, _ := filepath.Glob(filepath.Join(dir, "tailnginx-deny-*.conf"))
+	if len(matches) != 1 {
+		t.Fatalf("wrote %d deny lists to the export directory, want 1", len(matches))
+	}
+	data, _ := os.ReadFile(matches[0])
+	if !strings.Contains(string(data), "deny 203.0.113.7;") {
+		t.Errorf("deny list = %q", data)
+	}
+
+	include := filepath.Join(dir, "banned.conf")
+	app.SetDenyList(include, abuse.FormatGeo, filepath.Join(dir, "missing.pid"))
+	app.writeDenyList()
+	data, _ = os.ReadFile(include)
+	if !strings.Contains(string(data), "203.0.113.7 1;") {
+		t.Errorf("geo list = %q", data)
+	}
+	if footer := app.footer.GetText(false); !strings.Contains(footer, "reloading nginx") {
+		t.Errorf("footer = %q, want the reload error", footer)
+	}
+}
@@ -0,0 +1,47 @@
+package ui
+
+import "fmt"
+
+// displayMode selects how the values of top N tables are shown.
+type displayMode int
+
+// Display modes, cycled with the m key
+const (
+	displayCounts displayMode = iota
+	displayPercent
+	displayBars
+)
+
+// displayModeNames are the names of the display modes, in cycle order.
+var displayModeNames = []string{"counts", "percentages", "bars"}
+
+// valueBarWidth is the width of the bars in the bars display mode.
+const valueBarWidth = 10
+
+// cycleDisplayMode switches top N tables to the next display mode.
+// Must be called from the UI goroutine.
+func (ta *TviewApp) cycleDisplayMode() {
+	ta.mu.Lock()
+	ta.displayMode = (ta.displayMode + 1) % displayMode(len(displayModeNames))
+	mode := ta.displayMode
+	ta.dataChanged = true
+	ta.mu.Unlock()
+
+	ta.flash(fmt.Sprintf("Tables show [yellow]%s[-::-]", displayModeNames[mode]))
+}
+
+// formatValue formats a table value in the current display mode. Percentages
+// are of the requests in the window; bars are scaled to the top value so that
+// differences stay visible.
+func (ta *TviewApp) formatValue(value, top int) string {
+	switch ta.displayMode {
+	case displayPercent:
+		return fmt.Sprintf("[cyan]%4.1f%%[-::-]", percent(value, ta.shownRequests))
+	case displayBars:
+		return fmt.Sprintf("%s [cyan]%4.1f%%[-::-]",
+			percentBar(percent(value, top), valueBarWidth, "cyan"),
+			percent(value, ta.shownRequests))
+	default:
+		return fmt.Sprintf("[cyan]%d[-::-]", value)
+	}
+}
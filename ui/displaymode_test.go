@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDisplayModes tests that top N tables cycle between counts,
+// percentages and bars.
+func TestDisplayModes(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	app.shownRequests = 400
+	app.methodsData = map[string]int{"GET": 300, "POST": 100}
+
+	tests := []struct {
+		mode     displayMode
+		get      string
+		post     string
+		postFull int // filled bar cells
+	}{
+		{displayCounts, "[cyan]300[-::-]", "[cyan]100[-::-]", -1},
+		{displayPercent, "[cyan]75.0%[-::-]", "[cyan]25.0%[-::-]", -1},
+		{displayBars, "[cyan]██████████[-::-][::d][-::-] [cyan]75.0%[-::-]", "", 3},
+	}
+	for _, tt := range tests {
+		app.displayMode = tt.mode
+		app.renderMethods()
+
+		if got := app.methodsTable.GetCell(1, 1).Text; got != tt.get {
+			t.Errorf("%s: GET = %q, want %q", displayModeNames[tt.mode], got, tt.get)
+		}
+		post := app.methodsTable.GetCell(2, 1).Text
+		if tt.post != "" && post != tt.post {
+			t.Errorf("%s: POST = %q, want %q", displayModeNames[tt.mode], post, tt.post)
+		}
+		if tt.postFull >= 0 && strings.Count(post, "█") != tt.postFull {
+			t.Errorf("%s: POST bar = %q, want %d filled cells (scaled to the top value)", displayModeNames[tt.mode], post, tt.postFull)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/rivo/tview"
+)
+
+// Error log panel limits
+const (
+	maxErrorEntries   = 500 // Error log entries kept in memory
+	errorTrendMinutes = 30  // Minutes compared between 5xx responses and upstream errors
+)
+
+// errorLevelColors maps nginx error levels to display styles.
+var errorLevelColors = map[string]string{
+	"emerg":  "[white:red:b]",
+	"alert":  "[white:red:b]",
+	"crit":   "[red::b]",
+	"error":  "[red]",
+	"warn":   "[yellow]",
+	"notice": "[cyan]",
+	"info":   "[cyan]",
+	"debug":  "[::d]",
+}
+
+// errorLogHelp is shown in the error log panel when no error log is configured.
+const errorLogHelp = "[::d]No error log. Pass one with -error-log (error.log next to the access log is used when found).[-::-]"
+
+// SetErrorLog sets the nginx error log lines shown in the error log panel.
+// Must be called before Run.
+func (ta *TviewApp) SetErrorLog(path string, lines <-chan string) {
+	ta.errorLogPath = path
+	ta.errorLines = lines
+	ta.errorLogView.SetTitle(ta.title("🧯 Error Log — " + path))
+}
+
+// readErrorLines reads error log lines from the channel.
+func (ta *TviewApp) readErrorLines() {
+	for line := range ta.errorLines {
+		entry := parser.ParseError(line)
+		if entry == nil {
+			continue // Continuation lines and other noise
+		}
+
+		ta.mu.Lock()
+		ta.errorEntries = append(ta.errorEntries, *entry)
+		if excess := len(ta.errorEntries) - maxErrorEntries; excess > 0 {
+			ta.errorEntries = append([]parser.ErrorEntry(nil), ta.errorEntries[excess:]...)
+		}
+		ta.dataChanged = true
+		ta.mu.Unlock()
+	}
+}
+
+// renderErrorLog renders 5xx responses next to upstream errors per minute,
+// followed by the most recent error log entries.
+func (ta *TviewApp) renderErrorLog() {
+	if ta.errorLines == nil {
+		ta.errorLogView.SetText(errorLogHelp)
+		return
+	}
+
+	now := time.Now()
+	serverErrors := make([]uint64, errorTrendMinutes)
+	upstreamErrors := make([]uint64, errorTrendMinutes)
+	var serverTotal, upstreamTotal uint64
+	for _, v := range ta.allVisitors {
+		if i := minuteIndex(now, v.Time, errorTrendMinutes); i >= 0 && v.Status >= 500 {
+			serverErrors[i]++
+			serverTotal++
+		}
+	}
+	for _, e := range ta.errorEntries {
+		if i := minuteIndex(now, e.Time, errorTrendMinutes); i >= 0 && e.IsUpstream() {
+			upstreamErrors[i]++
+			upstreamTotal++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[::b]Last %d min[-::-]\n", errorTrendMinutes)
+	fmt.Fprintf(&b, "[red]5xx     [-::-] [red]%s[-::-] %d\n", sparkline(serverErrors), serverTotal)
+	fmt.Fprintf(&b, "[yellow]upstream[-::-] [yellow]%s[-::-] %d\n\n", sparkline(upstreamErrors), upstreamTotal)
+
+	// Show as many recent entries as fit below the trend
+	_, _, _, height := ta.errorLogView.GetInnerRect()
+	count := max(height-4, 5)
+	start := max(len(ta.errorEntries)-count, 0)
+	for _, e := range ta.errorEntries[start:] {
+		color, ok := errorLevelColors[e.Level]
+		if !ok {
+			color = "[white]"
+		}
+		message := e.Message
+		if e.Request != "" {
+			message += " — " + e.Request
+		}
+		fmt.Fprintf(&b, "[::d]%s[-::-] %s%-6s[-:-:-] %s\n",
+			ta.formatTime(e.Time), color, e.Level, tview.Escape(message))
+	}
+	if len(ta.errorEntries) == 0 {
+		b.WriteString("[::d]No entries yet[-::-]\n")
+	}
+
+	ta.errorLogView.SetText(strings.TrimSuffix(b.String(), "\n"))
+	ta.errorLogView.ScrollToEnd() // Long entries wrap; keep the newest visible
+}
+
+// minuteIndex returns the per-minute bucket of t in a series of n minutes
+// ending at now (oldest first), or -1 if t is outside the series.
+func minuteIndex(now, t time.Time, n int) int {
+	age := int(now.Truncate(time.Minute).Sub(t.Truncate(time.Minute)) / time.Minute)
+	if age < 0 || age >= n {
+		return -1
+	}
+	return n - 1 - age
+}
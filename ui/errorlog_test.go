@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestMinuteIndex tests per-minute bucketing of timestamps.
+func TestMinuteIndex(t *testing.T) {
+	now := time.Date(2025, 10, 8, 12, 30, 45, 0, time.UTC)
+	tests := []struct {
+		t    time.Time
+		want int
+	}{
+		{now, 9},
+		{now.Add(-30 * time.Second), 9},
+		{now.Add(-time.Minute), 8},
+		{now.Add(-9 * time.Minute), 0},
+		{now.Add(-10 * time.Minute), -1},
+		{now.Add(time.Minute), -1},
+	}
+	for _, tt := range tests {
+		if got := minuteIndex(now, tt.t, 10); got != tt.want {
+			t.Errorf("minuteIndex(%v) = %d, want %d", tt.t, got, tt.want)
+		}
+	}
+}
+
+// TestErrorLog tests reading error log entries and rendering them with the
+// 5xx correlation.
+func TestErrorLog(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	errorLines := make(chan string, 3)
+	app.SetErrorLog("/test-error.log", errorLines)
+
+	now := time.Now().Format("2006/01/02 15:04:05")
+	errorLines <- now + ` [error] 10#10: *1 connect() failed (111: Connection refused) while connecting to upstream, client: 1.1.1.1, server: x, request: "GET /api HTTP/1.1", upstream: "http://127.0.0.1:8080/api", host: "x"`
+	errorLines <- "continuation line"
+	errorLines <- now + ` [warn] 10#10: *2 an upstream response is buffered to a temporary file`
+	close(errorLines)
+	app.readErrorLines()
+
+	if len(app.errorEntries) != 2 {
+		t.Fatalf("errorEntries = %d, want 2", len(app.errorEntries))
+	}
+
+	app.processBatch([]parser.Visitor{{IP: "1.1.1.1", Status: 502, Time: time.Now()}})
+	app.updateData()
+	app.renderErrorLog()
+
+	text := app.errorLogView.GetText(true)
+	for _, want := range []string{"5xx", "upstream", "Connection refused", "GET /api HTTP/1.1"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("error log panel missing %q:\n%s", want, text)
+		}
+	}
+}
+
+// TestErrorLogNotConfigured tests the hint shown without an error log.
+func TestErrorLogNotConfigured(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	app.renderErrorLog()
+
+	if text := app.errorLogView.GetText(true); !strings.Contains(text, "-error-log") {
+		t.Errorf("expected configuration hint, got %q", text)
+	}
+}
@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/exclude"
+)
+
+// TestExclude tests that excluded requests are neither aggregated nor
+// counted as parse failures.
+func TestExclude(t *testing.T) {
+	lines := make(chan string, 3)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	rules, err := exclude.New([]string{`^/healthz$`}, nil, []string{"UptimeRobot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetExclude(rules)
+
+	lines <- `127.0.0.1 - - [10/Oct/2025:13:55:36 +0000] "GET /healthz HTTP/1.1" 200 2 "-" "kube-probe/1.30"`
+	lines <- `127.0.0.1 - - [10/Oct/2025:13:55:36 +0000] "GET / HTTP/1.1" 200 612 "-" "UptimeRobot/2.0"`
+	lines <- `127.0.0.1 - - [10/Oct/2025:13:55:36 +0000] "GET /shop HTTP/1.1" 200 612 "-" "curl/8.0"`
+	close(lines)
+	app.readLines()
+
+	if len(app.allVisitors) != 1 || app.allVisitors[0].Path != "/shop" {
+		t.Errorf("allVisitors = %+v, want only /shop", app.allVisitors)
+	}
+	if n := app.parseFailures.Load(); n != 0 {
+		t.Errorf("parseFailures = %d, want 0", n)
+	}
+	if len(app.rawLines) != 3 {
+		t.Errorf("rawLines = %d, want 3 (the raw viewer shows every line)", len(app.rawLines))
+	}
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/stats"
+	"github.com/rivo/tview"
+)
+
+// SetExportDir sets the directory snapshots are exported to. The default is
+// the working directory.
+func (ta *TviewApp) SetExportDir(dir string) {
+	ta.exportDir = dir
+}
+
+// SetDumpFile sets the JSON file the aggregates are written to when the
+// application quits or DumpSnapshot is called. Empty disables dumping.
+func (ta *TviewApp) SetDumpFile(path string) {
+	ta.dumpFile = path
+}
+
+// DumpSnapshot writes the aggregates to the dump file, e.g. on SIGUSR1, and
+// reports the outcome in the footer. It is safe to call from any goroutine.
+func (ta *TviewApp) DumpSnapshot() {
+	if ta.dumpFile == "" {
+		return
+	}
+	message := fmt.Sprintf("[green]Snapshot saved to[-::-] %s", tview.Escape(ta.dumpFile))
+	if err := ta.Snapshot().Save(ta.dumpFile); err != nil {
+		message = fmt.Sprintf("[red]Snapshot failed:[-::-] %v", err)
+	}
+	ta.app.QueueUpdateDraw(func() {
+		ta.flash(message)
+	})
+}
+
+// snapshot copies the displayed aggregates, which respect the active time
+// window and status filter. Caller must hold the lock.
+func (ta *TviewApp) snapshot(now time.Time) *stats.Snapshot {
+	s := &stats.Snapshot{
+		Time:           now,
+		LogPath:        ta.logFilePath,
+		Window:         "all",
+		Requests:       ta.shownRequests,
+		TotalRequests:  ta.shownTotal,
+		UniqueVisitors: ta.uniqueVisitors,
+		Bytes:          ta.windowBytes,
+	}
+	if ta.timeWindow > 0 {
+		s.Window = formatWindow(ta.timeWindow)
+	}
+	s.Filter = ta.filterDescription()
+
+	statuses := make(map[string]int, len(ta.statusCodes))
+	for code, count := range ta.statusCodes {
+		statuses[strconv.Itoa(code)] = count
+	}
+
+	s.AddSection("status", statuses)
+	s.AddSection("paths", ta.pathsData)
+	s.AddSection("ips", ta.ips)
+	s.AddSection("methods", ta.methodsData)
+	s.AddSection("clients", ta.userAgents)
+	s.AddSection("bots", ta.botsData)
+	s.AddSection("os", ta.osData)
+	s.AddSection("devices", ta.devicesData)
+	s.AddSection("countries", ta.countriesData)
+	s.AddSection("sources", ta.sourcesData)
+	s.AddSection("referers", ta.referersData)
+	s.AddSection("protocols", ta.protocolsData)
+	s.AddSection("tls_protocols", ta.tlsProtocols)
+	s.AddSection("tls_ciphers", ta.tlsCiphers)
+	s.AddSection("not_found", ta.notFoundPaths)
+	s.AddSection("path_bytes", ta.pathBytes)
+	s.AddSection("ip_bytes", ta.ipBytes)
+	return s
+}
+
+// Snapshot returns a copy of the displayed aggregates with the request rate
+// series, e.g. for the HTTP API. It is safe to call from any goroutine.
+func (ta *TviewApp) Snapshot() *stats.Snapshot {
+	now := time.Now()
+	ta.mu.RLock()
+	defer ta.mu.RUnlock()
+	s := ta.snapshot(now)
+	s.Rate = stats.NewSeries(now, rateInterval, ta.rateTracker.Buckets(now, rateBuckets))
+	return s
+}
+
+// exportSnapshot writes the displayed aggregates to timestamped JSON and CSV
+// files and confirms in the footer. Must be called from the UI goroutine.
+func (ta *TviewApp) exportSnapshot() {
+	ta.mu.RLock()
+	s := ta.snapshot(time.Now())
+	ta.mu.RUnlock()
+
+	paths, err := s.Export(ta.exportDir)
+	if err != nil {
+		ta.flash(fmt.Sprintf("[red]Export failed:[-::-] %v", err))
+		return
+	}
+	ta.flash(fmt.Sprintf("[green]Exported[-::-] %s", tview.Escape(strings.Join(paths, ", "))))
+}
+
+// exportTables writes the displayed top paths, visitors, countries, status
+// codes and referers to timestamped CSV files, one per table, and the
+// countries to a GeoJSON file for mapping tools, and confirms in the footer. Must be called from the UI goroutine.
+func (ta *TviewApp) exportTables() {
+	ta.mu.RLock()
+	s := ta.snapshot(time.Now())
+	ta.mu.RUnlock()
+
+	paths, err := s.ExportTables(ta.exportDir)
+	if err == nil {
+		_, err = s.ExportGeoJSON(ta.exportDir)
+	}
+	if err != nil {
+		ta.flash(fmt.Sprintf("[red]Export failed:[-::-] %v", err))
+		return
+	}
+	ta.flash(fmt.Sprintf("[green]Exported %d CSV tables and a GeoJSON map to[-::-] %s", len(paths), tview.Escape(filepath.Dir(paths[0]))))
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestExportSnapshot tests that the export respects the active filters.
+func TestExportSnapshot(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	dir := t.TempDir()
+	app.SetExportDir(dir)
+
+	now := time.Now()
+	app.allVisitors = []parser.Visitor{
+		{Time: now, Status: 404, IP: "10.0.0.1", Path: "/missing", Method: "GET"},
+		{Time: now, Status: 200, IP: "10.0.0.2", Path: "/", Method: "GET"},
+		{Time: now.Add(-2 * time.Hour), Status: 404, IP: "10.0.0.3", Path: "/old", Method: "GET"},
+	}
+	app.statusFilter = 4
+	app.timeWindow = time.Hour
+	app.applyFilters()
+	app.updateData()
+
+	s := app.snapshot(now)
+	if s.Window != "1h" || s.Filter != "4xx" || s.Requests != 1 {
+		t.Errorf("snapshot window = %q, filter = %q, requests = %d; want 1h, 4xx, 1", s.Window, s.Filter, s.Requests)
+	}
+
+	app.rateTracker.RecordN(now, 3)
+	if rate := app.Snapshot().Rate; rate == nil || len(rate.Points) != rateBuckets || rate.Points[rateBuckets-1].Requests != 3 {
+		t.Errorf("Snapshot() rate = %+v, want %d buckets ending with 3 requests", rate, rateBuckets)
+	}
+
+	app.exportSnapshot()
+	matches, _ := filepath.Glob(filepath.Join(dir, "tailnginx-*.csv"))
+	if len(matches) != 1 {
+		t.Fatalf("exported %d CSV files, want 1", len(matches))
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "paths,/missing,1") || strings.Contains(string(data), "/old") {
+		t.Errorf("CSV should only contain the filtered window:\n%s", data)
+	}
+
+	app.exportTables()
+	matches, _ = filepath.Glob(filepath.Join(dir, "tailnginx-*-paths.csv"))
+	if len(matches) != 1 {
+		t.Fatalf("exported %d paths tables, want 1", len(matches))
+	}
+	data, err = os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "path,requests\n/missing,1\n") || strings.Contains(string(data), "/old") {
+		t.Errorf("paths table should only contain the filtered window:\n%s", data)
+	}
+	if matches, _ = filepath.Glob(filepath.Join(dir, "tailnginx-*-countries.geojson")); len(matches) != 1 {
+		t.Errorf("exported %d GeoJSON files, want 1", len(matches))
+	}
+}
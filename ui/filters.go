@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// Filters are the filters the dashboard opens with, e.g. to start an
+// investigation from the command line.
+type Filters struct {
+	Status string // Status class or code, e.g. "5xx" or "404"
+	Path   string // Path prefix, e.g. "/api"
+	IP     string // Client IP or network, e.g. "192.0.2.0/24"
+}
+
+// SetFilters sets the filters the dashboard opens with. The status filter
+// works like the 2-5 keys and the s prompt; Esc clears all filters. Must
+// be called before Run.
+func (ta *TviewApp) SetFilters(f Filters) error {
+	class, code, err := parseStatusFilter(f.Status)
+	if err != nil {
+		return err
+	}
+	var network *net.IPNet
+	if f.IP != "" {
+		if network, err = parseNetwork(f.IP); err != nil {
+			return err
+		}
+	}
+	if f.Path != "" && !strings.HasPrefix(f.Path, "/") {
+		return fmt.Errorf("invalid path filter %q: must start with /", f.Path)
+	}
+
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+	ta.statusFilter, ta.statusCode = class, code
+	ta.pathFilter = f.Path
+	ta.ipFilter = network
+	return nil
+}
+
+// parseStatusFilter parses a status class such as "5xx" or an exact code
+// such as "404". An empty text is no filter.
+func parseStatusFilter(text string) (class, code int, err error) {
+	if text == "" {
+		return 0, 0, nil
+	}
+	if c, ok := strings.CutSuffix(strings.ToLower(text), "xx"); ok {
+		if len(c) == 1 && c[0] >= '1' && c[0] <= '5' {
+			return int(c[0] - '0'), 0, nil
+		}
+		return 0, 0, fmt.Errorf("invalid status filter %q: expected a class from 1xx to 5xx", text)
+	}
+	code, err = strconv.Atoi(text)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, fmt.Errorf("invalid status filter %q: expected a class such as 5xx or a code between 100 and 599", text)
+	}
+	return code / 100, code, nil
+}
+
+// parseNetwork parses an IP network in CIDR notation, or a single IP.
+func parseNetwork(text string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(text); err == nil {
+		return network, nil
+	}
+	ip := net.ParseIP(text)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP filter %q: expected an IP or a network such as 192.0.2.0/24", text)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// inScope reports whether an entry matches the path and IP filters. Caller
+// must hold the lock.
+func (ta *TviewApp) inScope(v *parser.Visitor) bool {
+	if ta.pathFilter != "" && !strings.HasPrefix(v.Path, ta.pathFilter) {
+		return false
+	}
+	if ta.ipFilter != nil {
+		ip := net.ParseIP(v.IP)
+		if ip == nil || !ta.ipFilter.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// statusText returns the status filter as accepted by SetFilters, e.g. "5xx"
+// or "404", or "" when there is none. Caller must hold the lock.
+func (ta *TviewApp) statusText() string {
+	switch {
+	case ta.statusCode > 0:
+		return strconv.Itoa(ta.statusCode)
+	case ta.statusFilter > 0:
+		return fmt.Sprintf("%dxx", ta.statusFilter)
+	}
+	return ""
+}
+
+// filterDescription describes the active filters, e.g. "5xx, path /api*",
+// or returns "" when there are none. Caller must hold the lock.
+func (ta *TviewApp) filterDescription() string {
+	var parts []string
+	if status := ta.statusText(); status != "" {
+		parts = append(parts, status)
+	}
+	if ta.pathFilter != "" {
+		parts = append(parts, "path "+ta.pathFilter+"*")
+	}
+	if ta.ipFilter != nil {
+		parts = append(parts, "ip "+ta.ipFilter.String())
+	}
+	return strings.Join(parts, ", ")
+}
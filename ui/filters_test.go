@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestSetFilters tests the filters the dashboard opens with.
+func TestSetFilters(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.allVisitors = []parser.Visitor{
+		{Time: now, IP: "192.0.2.10", Path: "/api/orders", Status: 502},
+		{Time: now, IP: "192.0.2.11", Path: "/api/users", Status: 200},
+		{Time: now, IP: "198.51.100.1", Path: "/api/orders", Status: 500},
+		{Time: now, IP: "192.0.2.12", Path: "/shop", Status: 503},
+		{Time: now, IP: "192.0.2.13", Path: "/api", Status: 504},
+	}
+
+	if err := app.SetFilters(Filters{Status: "5xx", Path: "/api", IP: "192.0.2.0/24"}); err != nil {
+		t.Fatalf("SetFilters() error = %v", err)
+	}
+	app.applyFilters()
+	if len(app.visitors) != 2 || app.visitors[0].IP != "192.0.2.10" || app.visitors[1].IP != "192.0.2.13" {
+		t.Errorf("visitors = %+v, want 192.0.2.10 and 192.0.2.13", app.visitors)
+	}
+	if got, want := app.filterDescription(), "5xx, path /api*, ip 192.0.2.0/24"; got != want {
+		t.Errorf("filterDescription() = %q, want %q", got, want)
+	}
+
+	if err := app.SetFilters(Filters{Status: "500", IP: "198.51.100.1"}); err != nil {
+		t.Fatalf("SetFilters() error = %v", err)
+	}
+	app.applyFilters()
+	if len(app.visitors) != 1 || app.visitors[0].Status != 500 {
+		t.Errorf("visitors = %+v, want the 500 of 198.51.100.1", app.visitors)
+	}
+
+	for _, f := range []Filters{
+		{Status: "6xx"},
+		{Status: "99"},
+		{Status: "abc"},
+		{Path: "api"},
+		{IP: "192.0.2"},
+	} {
+		if err := app.SetFilters(f); err == nil {
+			t.Errorf("SetFilters(%+v): want error", f)
+		}
+	}
+}
+
+// TestParseStatusFilter tests status classes and exact codes.
+func TestParseStatusFilter(t *testing.T) {
+	tests := []struct {
+		text        string
+		class, code int
+	}{
+		{"", 0, 0},
+		{"5xx", 5, 0},
+		{"4XX", 4, 0},
+		{"404", 4, 404},
+	}
+	for _, tt := range tests {
+		class, code, err := parseStatusFilter(tt.text)
+		if err != nil || class != tt.class || code != tt.code {
+			t.Errorf("parseStatusFilter(%q) = %d, %d, %v, want %d, %d", tt.text, class, code, err, tt.class, tt.code)
+		}
+	}
+}
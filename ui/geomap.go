@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/papaganelli/tailnginx/pkg/geoip"
+	"github.com/papaganelli/tailnginx/pkg/worldmap"
+	"github.com/rivo/tview"
+)
+
+// geoLegendWidth is the width of the top countries legend next to the map.
+const geoLegendWidth = 28
+
+// geoLevels are the marker glyphs and colors, from least to most requests.
+var geoLevels = []struct {
+	glyph rune
+	color tcell.Color
+}{
+	{'∙', tcell.ColorYellow},
+	{'•', tcell.ColorOrange},
+	{'●', tcell.ColorRed},
+}
+
+// geoMap is a full-screen world map with a marker per country, shaded by
+// request volume.
+type geoMap struct {
+	*tview.Box
+
+	// countries are the request counts by country code, most requests first
+	countries []entry
+
+	// land caches the rendered land masses for the current map size
+	land         []string
+	landW, landH int
+	landColor    tcell.Color
+	legendColor  tcell.Color
+}
+
+// newGeoMap creates an empty world map panel.
+func newGeoMap() *geoMap {
+	return &geoMap{
+		Box:         tview.NewBox(),
+		landColor:   tcell.ColorDarkSlateGray,
+		legendColor: tcell.ColorSilver,
+	}
+}
+
+// geoLevel returns the shading level (index into geoLevels) of count relative
+// to max on a logarithmic scale, one level per half decade below max, so that
+// small countries remain visible next to a dominant one.
+func geoLevel(count, max int) int {
+	top := len(geoLevels) - 1
+	if count <= 0 || count >= max {
+		return top
+	}
+	level := top - int(2*math.Log10(float64(max)/float64(count)))
+	if level < 0 {
+		level = 0
+	}
+	return level
+}
+
+// Draw draws the map, the country markers and the legend.
+func (m *geoMap) Draw(screen tcell.Screen) {
+	m.Box.DrawForSubclass(screen, m)
+	x, y, width, height := m.GetInnerRect()
+
+	mapWidth := width
+	if width > 2*geoLegendWidth {
+		mapWidth = width - geoLegendWidth
+	}
+	if mapWidth <= 0 || height <= 0 {
+		return
+	}
+
+	if m.landW != mapWidth || m.landH != height {
+		m.land = worldmap.Land(mapWidth, height)
+		m.landW, m.landH = mapWidth, height
+	}
+	landStyle := tcell.StyleDefault.Foreground(m.landColor)
+	for row, line := range m.land {
+		col := 0
+		for _, r := range line {
+			if r != ' ' {
+				screen.SetContent(x+col, y+row, r, nil, landStyle)
+			}
+			col++
+		}
+	}
+
+	max := 0
+	if len(m.countries) > 0 {
+		max = m.countries[0].value
+	}
+
+	// Draw the smallest countries first so that busy ones stay on top
+	for i := len(m.countries) - 1; i >= 0; i-- {
+		c := m.countries[i]
+		lat, lon, ok := geoip.Centroid(c.key)
+		if !ok {
+			continue
+		}
+		col, row, ok := worldmap.Project(lon, lat, mapWidth, height)
+		if !ok {
+			continue
+		}
+		level := geoLevels[geoLevel(c.value, max)]
+		screen.SetContent(x+col, y+row, level.glyph, nil,
+			tcell.StyleDefault.Foreground(level.color).Bold(true))
+	}
+
+	if mapWidth == width {
+		return
+	}
+
+	// Legend with the busiest countries
+	legendX := x + mapWidth + 1
+	tview.Print(screen, "[::b]Top countries[::-]", legendX, y, geoLegendWidth-1, tview.AlignLeft, m.legendColor)
+	for i, c := range m.countries {
+		if i+2 >= height {
+			break
+		}
+		level := geoLevels[geoLevel(c.value, max)]
+		line := fmt.Sprintf("[%s]%c[-] [yellow::b]%s[-::-] %s", level.color.Name(), level.glyph, c.key, getCountryName(c.key))
+		tview.Print(screen, line, legendX, y+i+2, geoLegendWidth-9, tview.AlignLeft, m.legendColor)
+		tview.Print(screen, fmt.Sprintf("[cyan]%s", formatCount(c.value)), legendX, y+i+2, geoLegendWidth-2, tview.AlignRight, m.legendColor)
+	}
+}
+
+// initGeoView creates the world map page.
+func (ta *TviewApp) initGeoView(borderColor, titleColor tcell.Color) tview.Primitive {
+	ta.geoMap = newGeoMap()
+	ta.geoMap.SetBorder(true).
+		SetTitle("🌍 Requests by Country").
+		SetBorderColor(borderColor).
+		SetTitleColor(titleColor)
+	return ta.geoMap
+}
+
+// SetGeoIP enables or disables geo lookups. Without them the Countries panel
+// is hidden and the world map is not available. Must be called before Run.
+func (ta *TviewApp) SetGeoIP(enabled bool) {
+	ta.noGeo = !enabled
+	ta.rebuildViews()
+}
+
+// showGeoView switches the content area between the dashboard and the world map.
+func (ta *TviewApp) showGeoView(show bool) {
+	if show && ta.noGeo {
+		ta.flash("[yellow]GeoIP lookups are disabled[-::-] [::d](-no-geoip)[-::-]")
+		return
+	}
+	ta.mu.Lock()
+	if show {
+		ta.page = pageGeo
+	} else {
+		ta.page = pageDashboard
+	}
+	ta.mu.Unlock()
+
+	if show {
+		ta.mu.RLock()
+		ta.renderGeo()
+		ta.mu.RUnlock()
+		ta.pages.SwitchToPage(pageGeo)
+		ta.footer.SetText(geoHelp)
+		ta.app.SetFocus(ta.geoMap)
+		return
+	}
+	ta.pages.SwitchToPage(viewPageName(ta.viewIndex))
+	ta.footer.SetText(ta.help)
+	if ta.focused != nil {
+		ta.app.SetFocus(ta.focused)
+	} else {
+		ta.app.SetFocus(ta.grid)
+	}
+}
+
+// renderGeo updates the world map with the current requests by country.
+func (ta *TviewApp) renderGeo() {
+	ta.geoMap.countries = topEntries(ta.countriesData, 0)
+}
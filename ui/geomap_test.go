@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TestGeoLevel tests the logarithmic shading of country markers.
+func TestGeoLevel(t *testing.T) {
+	tests := []struct {
+		count, max int
+		want       int
+	}{
+		{1000, 1000, 2},
+		{500, 1000, 2},
+		{200, 1000, 1},
+		{50, 1000, 0},
+		{1, 1000, 0},
+		{0, 0, 2},
+	}
+	for _, tt := range tests {
+		if got := geoLevel(tt.count, tt.max); got != tt.want {
+			t.Errorf("geoLevel(%d, %d) = %d, want %d", tt.count, tt.max, got, tt.want)
+		}
+	}
+}
+
+// TestGeoMapDraw tests that countries are drawn on the map and in the legend.
+func TestGeoMapDraw(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	app.countriesData = map[string]int{"FR": 100, "US": 40, "XX": 5}
+	app.renderGeo()
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	screen.SetSize(120, 30)
+	app.geoMap.SetRect(0, 0, 120, 30)
+	app.geoMap.Draw(screen)
+	screen.Show()
+
+	cells, width, height := screen.GetContents()
+	var text strings.Builder
+	markers := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := cells[y*width+x]
+			if len(c.Runes) == 0 {
+				continue
+			}
+			text.WriteRune(c.Runes[0])
+			for _, level := range geoLevels {
+				if c.Runes[0] == level.glyph && x < width-geoLegendWidth {
+					markers++
+				}
+			}
+		}
+		text.WriteByte('\n')
+	}
+
+	// XX has no known location, so only FR and US get a marker
+	if markers != 2 {
+		t.Errorf("drew %d markers, want 2", markers)
+	}
+	if !strings.Contains(text.String(), "France") || !strings.Contains(text.String(), "XX") {
+		t.Errorf("legend should list all countries:\n%s", text.String())
+	}
+}
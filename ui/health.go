@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// pipelineHealth is a snapshot of the ingestion pipeline counters shown in
+// the header, so the numbers on screen can be trusted (or debugged).
+type pipelineHealth struct {
+	unparsed  uint64 // non-empty lines the log format did not match
+	dropped   int    // entries evicted from memory, no longer counted in panels
+	heapBytes uint64
+	geoHits   uint64
+	geoMisses uint64
+	geoOff    bool // no GeoIP locator
+}
+
+// health collects the pipeline counters. Caller must hold the lock.
+func (ta *TviewApp) health() pipelineHealth {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	h := pipelineHealth{
+		unparsed:  ta.parseFailures.Load(),
+		dropped:   ta.droppedEntries,
+		heapBytes: mem.HeapAlloc,
+		geoOff:    ta.geoLocator == nil,
+	}
+	h.geoHits, h.geoMisses = ta.geoLocator.CacheStats()
+	return h
+}
+
+// formatHealth formats the pipeline counters as the header status segment.
+// Counters that may skew the numbers on screen are highlighted when non-zero.
+func formatHealth(h pipelineHealth) string {
+	counter := func(label string, n int) string {
+		if n > 0 {
+			return fmt.Sprintf("[::d]%s[-::-] [yellow]%s[-::-]", label, formatCount(n))
+		}
+		return fmt.Sprintf("[::d]%s[-::-] 0", label)
+	}
+
+	geo := "off"
+	if lookups := h.geoHits + h.geoMisses; !h.geoOff && lookups > 0 {
+		geo = fmt.Sprintf("%.0f%%", float64(h.geoHits)*100/float64(lookups))
+	} else if !h.geoOff {
+		geo = "–"
+	}
+
+	return strings.Join([]string{
+		counter("unparsed", int(h.unparsed)),
+		counter("dropped", h.dropped),
+		"[::d]heap[-::-] " + formatBytes(int64(h.heapBytes)),
+		"[::d]geo cache[-::-] " + geo,
+	}, "  ")
+}
+
+// renderHealth updates the pipeline health segment and fits its width to the
+// text, leaving the rest of the header row to the tabs.
+func (ta *TviewApp) renderHealth() {
+	text := formatHealth(ta.health())
+	ta.healthView.SetText(text)
+	ta.headerRow.ResizeItem(ta.healthView, tview.TaggedStringWidth(text)+1, 0)
+}
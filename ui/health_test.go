@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestFormatHealth tests the pipeline health segment.
+func TestFormatHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		health pipelineHealth
+		want   []string
+	}{
+		{
+			name:   "clean",
+			health: pipelineHealth{heapBytes: 3 << 20, geoHits: 3, geoMisses: 1},
+			want:   []string{"unparsed[-::-] 0", "dropped[-::-] 0", "heap[-::-] 3.0 MB", "geo cache[-::-] 75%"},
+		},
+		{
+			name:   "problems highlighted",
+			health: pipelineHealth{unparsed: 12, dropped: 1500},
+			want:   []string{"unparsed[-::-] [yellow]12", "dropped[-::-] [yellow]1 500", "geo cache[-::-] –"},
+		},
+		{
+			name:   "no locator",
+			health: pipelineHealth{geoOff: true},
+			want:   []string{"geo cache[-::-] off"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatHealth(tt.health)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("formatHealth() = %q, missing %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestHealthCounters tests that unparsable lines and evicted entries are counted.
+func TestHealthCounters(t *testing.T) {
+	lines := make(chan string, 3)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	lines <- "not an access log line"
+	lines <- ""
+	lines <- `127.0.0.1 - - [10/Oct/2025:13:55:36 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.0"`
+	close(lines)
+	app.readLines()
+
+	now := time.Now()
+	batch := make([]parser.Visitor, maxVisitorsInMemory+5)
+	for i := range batch {
+		batch[i] = parser.Visitor{Time: now, Status: 200}
+	}
+	app.processBatch(batch)
+
+	h := app.health()
+	if h.unparsed != 1 {
+		t.Errorf("unparsed = %d, want 1 (empty lines are not failures)", h.unparsed)
+	}
+	if h.dropped != 6 {
+		t.Errorf("dropped = %d, want 6", h.dropped)
+	}
+	if !h.geoOff {
+		t.Error("geoOff = false without a locator")
+	}
+}
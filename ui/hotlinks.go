@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/papaganelli/tailnginx/pkg/hotlink"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/rivo/tview"
+)
+
+// hotlinkKey is a media asset embedded in a foreign site.
+type hotlinkKey struct {
+	site  string
+	asset string
+}
+
+// hotlinkStat counts the hotlinked requests of an asset from a site.
+type hotlinkStat struct {
+	requests int
+	bytes    int64
+}
+
+// SetSiteDomains sets the domains of the site, whose referers are not
+// hotlinks, for logs without the host requested. Must be called before Run.
+func (ta *TviewApp) SetSiteDomains(d *hotlink.Detector) {
+	ta.hotlinks = d
+}
+
+// countHotlink counts an image or video request embedded in a foreign site.
+func (ta *TviewApp) countHotlink(v *parser.Visitor) {
+	if !ta.hotlinks.Knows(v.Host) {
+		return
+	}
+	ta.hotlinkKnown = true
+	if !hotlink.IsMedia(v.Path) {
+		return
+	}
+	site := ta.hotlinks.Site(v.Referer, v.Host)
+	if site == "" {
+		return
+	}
+	key := hotlinkKey{site: site, asset: hotlink.Asset(v.Path)}
+	stat := ta.hotlinkData[key]
+	stat.requests++
+	stat.bytes += int64(v.Bytes)
+	ta.hotlinkData[key] = stat
+}
+
+// renderHotlinks renders the images and videos embedded in foreign sites by
+// site and asset, the most bandwidth first.
+func (ta *TviewApp) renderHotlinks() {
+	table := ta.hotlinksTable
+	table.Clear()
+
+	if len(ta.hotlinkData) == 0 {
+		msg := "[::d]No hotlinked images or videos in the window[-::-]"
+		if !ta.hotlinkKnown {
+			msg = "[::d]Hotlinks need $host in the log format or -site-domain[-::-]"
+		}
+		table.SetCell(0, 0, tview.NewTableCell(msg))
+		return
+	}
+
+	keys := make([]hotlinkKey, 0, len(ta.hotlinkData))
+	for key := range ta.hotlinkData {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := ta.hotlinkData[keys[i]], ta.hotlinkData[keys[j]]
+		if a.bytes != b.bytes {
+			return a.bytes > b.bytes
+		}
+		if a.requests != b.requests {
+			return a.requests > b.requests
+		}
+		if keys[i].site != keys[j].site {
+			return keys[i].site < keys[j].site
+		}
+		return keys[i].asset < keys[j].asset
+	})
+
+	setHeader(table, "Site", "Asset", "Count", "Bandwidth")
+	table.GetCell(0, 0).SetAlign(tview.AlignLeft)
+	table.GetCell(0, 1).SetAlign(tview.AlignLeft)
+	for i, key := range keys[:min(len(keys), ta.topLimit(table, 1))] {
+		row := i + 1
+		stat := ta.hotlinkData[key]
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("[yellow]%s[-::-]", tview.Escape(key.site))).
+				SetAlign(tview.AlignLeft).
+				SetMaxWidth(25).
+				SetReference(key.site))
+		table.SetCell(row, 1,
+			tview.NewTableCell(tview.Escape(key.asset)).
+				SetAlign(tview.AlignLeft).
+				SetMaxWidth(40).
+				SetExpansion(1))
+		table.SetCell(row, 2,
+			tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", stat.requests)).
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 3,
+			tview.NewTableCell(fmt.Sprintf("[cyan::b]%s[-::-]", formatBytes(stat.bytes))).
+				SetAlign(tview.AlignRight))
+	}
+}
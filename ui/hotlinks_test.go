@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/hotlink"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestHotlinks tests that images and videos embedded in foreign sites are
+// counted by site and asset, the most bandwidth first.
+func TestHotlinks(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	now := time.Now()
+
+	// Without $host nor site domains, hotlinks cannot be told
+	app.processBatch([]parser.Visitor{
+		{Time: now, IP: "192.0.2.1", Path: "/img/cat.jpg", Referer: "https://leech.net/", Bytes: 1000, Status: 200},
+	})
+	app.updateData()
+	app.renderHotlinks()
+	if cell := app.hotlinksTable.GetCell(0, 0); len(app.hotlinkData) != 0 || !strings.Contains(cell.Text, "-site-domain") {
+		t.Errorf("hotlinks without a known site = %v, %q", app.hotlinkData, cell.Text)
+	}
+
+	d, err := hotlink.NewDetector([]string{"example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetSiteDomains(d)
+	app.processBatch([]parser.Visitor{
+		{Time: now, IP: "192.0.2.2", Path: "/img/cat.jpg?w=800", Referer: "https://forum.leech.net/t/1", Bytes: 2000, Status: 200},
+		{Time: now, IP: "192.0.2.3", Path: "/video/intro.mp4", Referer: "https://forum.leech.net/t/2", Bytes: 500000, Status: 206},
+		{Time: now, IP: "192.0.2.4", Path: "/img/cat.jpg", Referer: "https://www.example.com/blog", Bytes: 2000, Status: 200},
+		{Time: now, IP: "192.0.2.5", Path: "/page.html", Referer: "https://leech.net/", Bytes: 9000, Status: 200},
+		{Time: now, IP: "192.0.2.6", Path: "/img/dog.png", Referer: "https://www.google.com/", Bytes: 3000, Status: 200},
+	})
+	app.updateData()
+
+	if stat := app.hotlinkData[hotlinkKey{"leech.net", "/img/cat.jpg"}]; stat.requests != 1 || stat.bytes != 1000 {
+		t.Errorf("leech.net /img/cat.jpg = %+v", stat)
+	}
+	if stat := app.hotlinkData[hotlinkKey{"forum.leech.net", "/img/cat.jpg"}]; stat.requests != 1 || stat.bytes != 2000 {
+		t.Errorf("forum.leech.net /img/cat.jpg = %+v", stat)
+	}
+	if len(app.hotlinkData) != 3 {
+		t.Errorf("hotlinkData = %v, want 3 site and asset pairs", app.hotlinkData)
+	}
+
+	app.renderHotlinks()
+	if site, asset := app.hotlinksTable.GetCell(1, 0).Text, app.hotlinksTable.GetCell(1, 1).Text; !strings.Contains(site, "forum.leech.net") || asset != "/video/intro.mp4" {
+		t.Errorf("first row = %q %q, want the video", site, asset)
+	}
+	if bytes := app.hotlinksTable.GetCell(1, 3).Text; !strings.Contains(bytes, "488.3 KB") {
+		t.Errorf("bandwidth = %q", bytes)
+	}
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdayOrder lists the days of the week starting on Monday.
+var weekdayOrder = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday,
+	time.Friday, time.Saturday, time.Sunday,
+}
+
+// maxHourWidth is the maximum width in cells of one hour in the histogram.
+const maxHourWidth = 3
+
+// renderHours renders the hour-of-day histogram and the day-of-week trend of
+// the requests in the window, using the timestamps' own time zone.
+func (ta *TviewApp) renderHours() {
+	if ta.shownRequests == 0 {
+		ta.hoursView.SetText("  [::d]No requests in window[-::-]")
+		return
+	}
+
+	// The chart takes the height left by the axis, peak and weekday lines,
+	// with hours widened to fill the panel
+	_, _, width, height := ta.hoursView.GetInnerRect()
+	rows := max(1, height-3)
+	columnWidth := min(max(1, (width-4)/24), maxHourWidth)
+
+	var b strings.Builder
+	for _, line := range columnChart(ta.hourCounts[:], rows) {
+		fmt.Fprintf(&b, "  [cyan]%s[-::-]\n", widen(line, columnWidth))
+	}
+	b.WriteString("  [::d]")
+	for _, label := range []string{"0h", "6h", "12h", "18h"} {
+		fmt.Fprintf(&b, "%-*s", 6*columnWidth, label)
+	}
+	b.WriteString("[-::-]\n")
+
+	peak, quiet := 0, 0
+	for h, c := range ta.hourCounts {
+		if c > ta.hourCounts[peak] {
+			peak = h
+		}
+		if c < ta.hourCounts[quiet] {
+			quiet = h
+		}
+	}
+	fmt.Fprintf(&b, "  [::b]Peak:[-::-] [white]%02dh[-::-]  [::b]Quiet:[-::-] [white]%02dh[-::-]\n", peak, quiet)
+
+	days := make([]uint64, len(weekdayOrder))
+	for i, d := range weekdayOrder {
+		days[i] = ta.weekdayCounts[d]
+	}
+	spark := []rune(sparkline(days))
+	b.WriteString("  ")
+	for i, d := range weekdayOrder {
+		fmt.Fprintf(&b, "[::d]%c[-::-][cyan]%c[-::-] ", d.String()[0], spark[i])
+	}
+
+	ta.hoursView.SetText(b.String())
+}
+
+// columnChart renders values as vertical bars of the given height in rows,
+// one column per value, scaled to the maximum. Partial rows use eighth blocks.
+func columnChart(values []uint64, height int) []string {
+	var peak uint64
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	lines := make([]string, height)
+	for row := range lines {
+		// Eighths of a row below the top of this row
+		base := uint64(height-1-row) * 8
+		line := make([]rune, len(values))
+		for i, v := range values {
+			var eighths uint64
+			if peak > 0 {
+				eighths = (v*uint64(height)*8 + peak - 1) / peak
+			}
+			switch {
+			case eighths <= base:
+				line[i] = ' '
+			case eighths-base >= 8:
+				line[i] = sparkBlocks[len(sparkBlocks)-1]
+			default:
+				line[i] = sparkBlocks[eighths-base-1]
+			}
+		}
+		lines[row] = string(line)
+	}
+	return lines
+}
+
+// widen repeats every rune of line n times.
+func widen(line string, n int) string {
+	if n <= 1 {
+		return line
+	}
+	var b strings.Builder
+	for _, r := range line {
+		b.WriteString(strings.Repeat(string(r), n))
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestColumnChart tests the vertical bar rendering.
+func TestColumnChart(t *testing.T) {
+	got := columnChart([]uint64{0, 1, 2, 4}, 2)
+	want := []string{"   █", " ▄██"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if got := widen("▄█", 2); got != "▄▄██" {
+		t.Errorf("widen() = %q, want %q", got, "▄▄██")
+	}
+
+	// Zero values leave an empty chart
+	for _, line := range columnChart([]uint64{0, 0}, 3) {
+		if strings.TrimSpace(line) != "" {
+			t.Errorf("empty chart row = %q, want blanks", line)
+		}
+	}
+}
+
+// TestHourCounts tests that requests are bucketed by hour of day and weekday.
+func TestHourCounts(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	zone := time.FixedZone("CEST", 2*3600)
+	monday := time.Date(2025, 6, 2, 9, 30, 0, 0, zone)
+	app.visitors = []parser.Visitor{
+		{Time: monday, Status: 200},
+		{Time: monday.Add(10 * time.Minute), Status: 200},
+		{Time: monday.Add(24*time.Hour + 5*time.Hour), Status: 200},
+	}
+	app.updateData()
+
+	if app.hourCounts[9] != 2 || app.hourCounts[14] != 1 {
+		t.Errorf("hourCounts[9] = %d, [14] = %d; want 2, 1", app.hourCounts[9], app.hourCounts[14])
+	}
+	if app.weekdayCounts[time.Monday] != 2 || app.weekdayCounts[time.Tuesday] != 1 {
+		t.Errorf("weekdayCounts = %v, want 2 on Monday and 1 on Tuesday", app.weekdayCounts)
+	}
+
+	app.renderHours()
+	if text := app.hoursView.GetText(true); !strings.Contains(text, "Peak: 09h") {
+		t.Errorf("hours panel should show the peak hour:\n%s", text)
+	}
+}
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// keyAction is a dashboard action with its default keys. The first key is
+// the one the key handler dispatches on.
+type keyAction struct {
+	name string
+	keys []string
+	hint string // Label of the key in the dashboard help, shared by the actions of one entry
+}
+
+// keyActions are the dashboard actions that can be bound to other keys, in
+// the order of the dashboard help.
+var keyActions = []keyAction{
+	{"quit", []string{"q"}, "quit"},
+	{"pause", []string{"Space"}, "pause"},
+	{"faster", []string{"+", "="}, "speed"},
+	{"slower", []string{"-", "_"}, "speed"},
+	{"window", []string{"t", "T"}, "window"},
+	{"compare", []string{"c", "C"}, "compare"},
+	{"status-2xx", []string{"2"}, "filter"},
+	{"status-3xx", []string{"3"}, "filter"},
+	{"status-4xx", []string{"4"}, "filter"},
+	{"status-5xx", []string{"5"}, "filter"},
+	{"status-code", []string{"s", "S"}, "filter"},
+	{"clear-filters", []string{"Esc"}, "clear"},
+	{"next-view", []string{"v"}, "view"},
+	{"previous-view", []string{"V"}, "view"},
+	{"raw", []string{"r", "R"}, "raw"},
+	{"map", []string{"g", "G"}, "map"},
+	{"panels", []string{"p", "P"}, "panels"},
+	{"copy", []string{"y"}, "copy"},
+	{"watch", []string{"w"}, "watch"},
+	{"display-mode", []string{"m"}, "mode"},
+	{"export", []string{"e"}, "export"},
+	{"export-tables", []string{"E"}, "export"},
+	{"deny", []string{"b"}, "deny"},
+	{"ratelimit", []string{"L"}, "ratelimits"},
+	{"acknowledge", []string{"a"}, "ack"},
+}
+
+// dashboardHelp returns the footer help of the dashboard, listing the key
+// of every action, its bound key if any, then the keys that cannot be bound.
+func dashboardHelp(bound map[string]string) string {
+	var entries []string
+	var keys []string
+	for i, a := range keyActions {
+		key, ok := bound[a.name]
+		if !ok {
+			key = a.keys[0]
+		}
+		keys = append(keys, helpKey(key))
+		if i+1 < len(keyActions) && keyActions[i+1].hint == a.hint {
+			continue
+		}
+		entries = append(entries, "[yellow]"+joinHelpKeys(keys)+"[-::-]:"+a.hint)
+		keys = nil
+	}
+	entries = append(entries, "[yellow]tab[-::-]:select")
+	return strings.Join(entries, " ")
+}
+
+// joinHelpKeys joins the keys of a help entry with slashes, runs of
+// consecutive digits as a range, e.g. "2-5/s".
+func joinHelpKeys(keys []string) string {
+	var parts []string
+	for i := 0; i < len(keys); i++ {
+		j := i
+		for j+1 < len(keys) && isDigitKey(keys[j]) && isDigitKey(keys[j+1]) && keys[j+1][0] == keys[j][0]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			parts = append(parts, keys[i]+"-"+keys[j])
+			i = j
+			continue
+		}
+		parts = append(parts, keys[i])
+	}
+	return strings.Join(parts, "/")
+}
+
+// isDigitKey reports whether a key is a digit.
+func isDigitKey(key string) bool {
+	return len(key) == 1 && key[0] >= '0' && key[0] <= '9'
+}
+
+// SetKeys binds dashboard actions to other keys, e.g. "pause=z" or
+// "window=F2", for terminal multiplexers or keyboard layouts the default
+// keys do not suit. The default keys keep working unless bound to another
+// action. Must be called before Run.
+func (ta *TviewApp) SetKeys(bindings []string) error {
+	if len(bindings) == 0 {
+		return nil
+	}
+	ta.keyMap = make(map[string]*tcell.EventKey)
+	bound := make(map[string]string)
+	for _, binding := range bindings {
+		name, key, ok := strings.Cut(binding, "=")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		i := slices.IndexFunc(keyActions, func(a keyAction) bool { return a.name == name })
+		if !ok || i < 0 {
+			return fmt.Errorf("invalid key binding %q: expected action=key with an action among %s", binding, keyActionNames())
+		}
+		event, err := parseKey(key)
+		if err != nil {
+			return fmt.Errorf("invalid key binding %q: %w", binding, err)
+		}
+		if _, bound := ta.keyMap[keyName(event)]; bound {
+			return fmt.Errorf("invalid key binding %q: %s is bound twice", binding, key)
+		}
+		target, _ := parseKey(keyActions[i].keys[0])
+		ta.keyMap[keyName(event)] = target
+		bound[name] = key
+	}
+	ta.help = dashboardHelp(bound)
+	if ta.footer != nil {
+		ta.footer.SetText(ta.help)
+	}
+	return nil
+}
+
+// translateKey returns the default key of the action bound to a key, or
+// the key itself.
+func (ta *TviewApp) translateKey(event *tcell.EventKey) *tcell.EventKey {
+	if target, ok := ta.keyMap[keyName(event)]; ok {
+		return target
+	}
+	return event
+}
+
+// parseKey parses a key such as "z", "Space", "Esc", "F2" or "Ctrl-T".
+func parseKey(text string) (*tcell.EventKey, error) {
+	if r := []rune(text); len(r) == 1 {
+		return tcell.NewEventKey(tcell.KeyRune, r[0], tcell.ModNone), nil
+	}
+	if strings.EqualFold(text, "Space") {
+		return tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), nil
+	}
+	for key, name := range tcell.KeyNames {
+		if strings.EqualFold(name, text) || strings.EqualFold(strings.ReplaceAll(name, "-", "+"), text) {
+			return tcell.NewEventKey(key, 0, tcell.ModNone), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key %q: expected a character, Space or a key name such as Esc, F2 or Ctrl-T", text)
+}
+
+// keyName identifies a key press, ignoring modifiers other than those of
+// control keys.
+func keyName(event *tcell.EventKey) string {
+	if event.Key() == tcell.KeyRune {
+		return string(event.Rune())
+	}
+	return tcell.KeyNames[event.Key()]
+}
+
+// helpKey returns how a key is shown in the dashboard help.
+func helpKey(key string) string {
+	if strings.EqualFold(key, "Space") {
+		return "␣"
+	}
+	if strings.EqualFold(key, "Esc") {
+		return "esc"
+	}
+	return key
+}
+
+// keyActionNames returns the names of the actions that can be bound.
+func keyActionNames() string {
+	names := make([]string, len(keyActions))
+	for i, a := range keyActions {
+		names[i] = a.name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TestSetKeys tests that bound keys act as the default keys of their actions.
+func TestSetKeys(t *testing.T) {
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	if err := app.SetKeys([]string{"pause=z", "window = F2", "clear-filters=Ctrl-X", "quit=Q"}); err != nil {
+		t.Fatalf("SetKeys() error = %v", err)
+	}
+
+	tests := []struct {
+		event    *tcell.EventKey
+		wantKey  tcell.Key
+		wantRune rune
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone), tcell.KeyRune, ' '},
+		{tcell.NewEventKey(tcell.KeyF2, 0, tcell.ModNone), tcell.KeyRune, 't'},
+		{tcell.NewEventKey(tcell.KeyCtrlX, 0, tcell.ModCtrl), tcell.KeyEscape, 0},
+		{tcell.NewEventKey(tcell.KeyRune, 'Q', tcell.ModNone), tcell.KeyRune, 'q'},
+		{tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), tcell.KeyRune, ' '}, // Default keys keep working
+		{tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), tcell.KeyRune, 'x'},
+	}
+	for _, tt := range tests {
+		got := app.translateKey(tt.event)
+		if got.Key() != tt.wantKey || (tt.wantKey == tcell.KeyRune && got.Rune() != tt.wantRune) {
+			t.Errorf("translateKey(%s) = %s, want %v %q", tt.event.Name(), got.Name(), tt.wantKey, tt.wantRune)
+		}
+	}
+
+	if !strings.Contains(app.help, "[yellow]z[-::-]:pause") || !strings.Contains(app.help, "[yellow]F2[-::-]:window") ||
+		!strings.Contains(app.help, "[yellow]Q[-::-]:quit") || !strings.Contains(app.help, "[yellow]Ctrl-X[-::-]:clear") {
+		t.Errorf("help = %q, want the bound keys", app.help)
+	}
+}
+
+// TestDashboardHelp tests that the footer lists every action, grouping the
+// keys of related ones.
+func TestDashboardHelp(t *testing.T) {
+	want := "[yellow]q[-::-]:quit [yellow]␣[-::-]:pause [yellow]+/-[-::-]:speed [yellow]t[-::-]:window [yellow]c[-::-]:compare " +
+		"[yellow]2-5/s[-::-]:filter [yellow]esc[-::-]:clear [yellow]v/V[-::-]:view [yellow]r[-::-]:raw [yellow]g[-::-]:map " +
+		"[yellow]p[-::-]:panels [yellow]y[-::-]:copy [yellow]w[-::-]:watch [yellow]m[-::-]:mode [yellow]e/E[-::-]:export " +
+		"[yellow]b[-::-]:deny [yellow]L[-::-]:ratelimits [yellow]a[-::-]:ack [yellow]tab[-::-]:select"
+	if got := dashboardHelp(nil); got != want {
+		t.Errorf("dashboardHelp() = %q, want %q", got, want)
+	}
+	if got := dashboardHelp(map[string]string{"status-3xx": "x", "slower": "F3"}); !strings.Contains(got, "[yellow]+/F3[-::-]:speed") ||
+		!strings.Contains(got, "[yellow]2/x/4/5/s[-::-]:filter") {
+		t.Errorf("dashboardHelp() = %q, want the bound keys", got)
+	}
+}
+
+// TestSetKeysErrors tests invalid bindings.
+func TestSetKeysErrors(t *testing.T) {
+	for _, bindings := range [][]string{
+		{"pause"},
+		{"jump=j"},
+		{"pause=Hyper-Z"},
+		{"pause=z", "window=z"},
+	} {
+		app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+		if err := app.SetKeys(bindings); err == nil {
+			t.Errorf("SetKeys(%q): want error", bindings)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// Panel IDs used in view layouts
+const (
+	panelStatus    = "status"
+	panelPaths     = "paths"
+	panelMethods   = "methods"
+	panelVisitors  = "visitors"
+	panelClients   = "clients"
+	panelCountries = "countries"
+	panelReferers  = "referers"
+	panelStream    = "stream"
+	panelBandwidth = "bandwidth"
+	panelUniques   = "uniques"
+	panelPlatforms = "platforms"
+	panelBots      = "bots"
+	panelProtocols = "protocols"
+	panelTLS       = "tls"
+	panelHotlinks  = "hotlinks"
+	panelErrorLog  = "errorlog"
+	panelNotFound  = "notfound"
+	panelSecurity  = "security"
+	panelAlerts    = "alerts"
+	panelSuspects  = "suspicious"
+	panelHours     = "hours"
+	panelWatch     = "watch"
+)
+
+// overviewHeight is the fixed height of the overview panel on top of every view.
+const overviewHeight = 4
+
+// dashboardView is a named arrangement of panels below the overview.
+type dashboardView struct {
+	name string
+	rows []viewRow
+}
+
+// viewRow is one row of panels. A panel listed several times in a row spans
+// that many columns. weight is the row height relative to the other rows.
+type viewRow struct {
+	panels []string
+	weight int
+}
+
+// defaultViews are the dashboard views, cycled with the v key.
+var defaultViews = []dashboardView{
+	{
+		name: "Dashboard",
+		rows: []viewRow{
+			{weight: 2, panels: []string{panelStatus, panelPaths, panelMethods}},
+			{weight: 1, panels: []string{panelVisitors, panelClients, panelCountries}},
+			{weight: 1, panels: []string{panelReferers, panelWatch, panelStream}},
+		},
+	},
+	{
+		name: "Traffic",
+		rows: []viewRow{
+			{weight: 2, panels: []string{panelBandwidth, panelStream}},
+			{weight: 1, panels: []string{panelUniques, panelHours, panelHours}},
+			{weight: 1, panels: []string{panelProtocols, panelTLS, panelHotlinks}},
+		},
+	},
+	{
+		name: "Clients",
+		rows: []viewRow{
+			{weight: 1, panels: []string{panelClients, panelPlatforms, panelBots}},
+		},
+	},
+	{
+		name: "Errors",
+		rows: []viewRow{
+			{weight: 1, panels: []string{panelStatus, panelErrorLog, panelSuspects}},
+			{weight: 1, panels: []string{panelNotFound, panelSecurity, panelAlerts}},
+		},
+	},
+}
+
+// viewPageName returns the page name of the dashboard view with the given index.
+func viewPageName(index int) string {
+	return fmt.Sprintf("%s-%d", pageDashboard, index)
+}
+
+// Below these terminal widths the dashboard views are reflowed into fewer
+// columns so that tables stay readable.
+const (
+	twoColumnWidth = 100
+	oneColumnWidth = 60
+)
+
+// minReflowHeight is the smallest height of a reflowed panel: its border,
+// header row and two entries. Panels beyond the rows of this height that fit
+// in the terminal are left out.
+const minReflowHeight = 5
+
+// reflowSize returns the number of panel columns and rows the views are
+// reflowed into for a terminal size, or 0 columns to arrange them as defined.
+func reflowSize(width, height int) (columns, rows int) {
+	switch {
+	case width <= 0 || width >= twoColumnWidth:
+		return 0, 0
+	case width >= oneColumnWidth:
+		columns = 2
+	default:
+		columns = 1
+	}
+	// Header, footer and the overview with its borders come first; each
+	// row takes one more line for the grid border below it
+	rows = (height - 3 - overviewHeight - 1) / (minReflowHeight + 1)
+	return columns, max(1, rows)
+}
+
+// viewRows returns the visible rows of a view: as defined, with the row
+// heights resized by the user, or on narrow terminals the panels in reading
+// order reflowed into as many rows of equal height as fit.
+func (ta *TviewApp) viewRows(view dashboardView) []viewRow {
+	var rows []viewRow
+	if ta.layoutCols > 0 {
+		panels := ta.viewPanels(view)
+		for len(panels) > 0 && len(rows) < ta.layoutRows {
+			n := min(len(panels), ta.layoutCols)
+			rows = append(rows, viewRow{panels: panels[:n], weight: 1})
+			panels = panels[n:]
+		}
+		return rows
+	}
+
+	// Rows whose panels are all hidden are left out
+	for i, row := range view.rows {
+		if panels := ta.visiblePanels(row); len(panels) > 0 {
+			rows = append(rows, viewRow{panels: panels, weight: ta.rowWeight(view, i)})
+		}
+	}
+	return rows
+}
+
+// buildView creates the content grid for a dashboard view. Row heights and
+// panel widths follow the view definition unless resized by the user.
+func (ta *TviewApp) buildView(view dashboardView) tview.Primitive {
+	rows := ta.viewRows(view)
+	rowSizes := []int{overviewHeight}
+	for _, row := range rows {
+		rowSizes = append(rowSizes, -row.weight)
+	}
+
+	grid := tview.NewGrid().
+		SetRows(rowSizes...).
+		SetColumns(0).
+		SetBorders(true)
+
+	// Overview spans the full width on top of every view
+	grid.AddItem(ta.overview, 0, 0, 1, 1, 0, 0, false)
+
+	for i, row := range rows {
+		panels := row.panels
+		rowGrid := tview.NewGrid().SetRows(0).SetBorders(false)
+
+		// Consecutive repeats of a panel make it proportionally wider
+		var columns []int
+		for j := 0; j < len(panels); {
+			span := 1
+			for j+span < len(panels) && panels[j+span] == panels[j] {
+				span++
+			}
+			rowGrid.AddItem(ta.panels[panels[j]], 0, len(columns), 1, 1, 0, 0, false)
+			weight := span
+			if ta.layoutCols == 0 {
+				weight = ta.columnWeight(view, panels[j], span)
+			}
+			columns = append(columns, -weight)
+			j += span
+		}
+		rowGrid.SetColumns(columns...)
+
+		grid.AddItem(rowGrid, i+1, 0, 1, 1, 0, 0, false)
+	}
+
+	return grid
+}
+
+// switchView shows the dashboard view at index (wrapping around).
+// Must be called from the UI goroutine.
+func (ta *TviewApp) switchView(index int) {
+	if ta.focused != nil {
+		ta.focused.SetSelectable(false, false).SetBorderColor(ta.borderColor)
+		ta.focused = nil
+	}
+
+	ta.mu.Lock()
+	ta.viewIndex = (index + len(ta.views)) % len(ta.views)
+	ta.dataChanged = true
+	ta.mu.Unlock()
+
+	ta.pages.SwitchToPage(viewPageName(ta.viewIndex))
+	ta.app.SetFocus(ta.grid)
+	ta.renderHeader()
+}
+
+// currentPanels returns the IDs of the panels shown in the current view,
+// in reading order and without duplicates.
+func (ta *TviewApp) currentPanels() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, row := range ta.viewRows(ta.views[ta.viewIndex]) {
+		for _, id := range row.panels {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// viewPanels returns the IDs of the visible panels in a view, in reading
+// order and without duplicates.
+func (ta *TviewApp) viewPanels(view dashboardView) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, row := range view.rows {
+		for _, id := range row.panels {
+			if !seen[id] && !ta.hidden[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// renderHeader renders the header with the log path and view tabs.
+func (ta *TviewApp) renderHeader() {
+	var tabs []string
+	for i, view := range ta.views {
+		if i == ta.viewIndex {
+			tabs = append(tabs, fmt.Sprintf("[black:white:b] %s [-:-:-]", view.name))
+		} else {
+			tabs = append(tabs, fmt.Sprintf("[::d] %s [-::-]", view.name))
+		}
+	}
+
+	ta.header.SetText(fmt.Sprintf("[white::b] TAILNGINX [-::-] [::d]%s[-::-]   %s",
+		ta.logFilePath, strings.Join(tabs, "")))
+}
+
+// modal centers a primitive of the given size over the pages below it.
+func modal(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestReflowSize tests the number of columns and rows of narrow layouts.
+func TestReflowSize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		cols, rows    int
+	}{
+		{120, 40, 0, 0},
+		{100, 40, 0, 0},
+		{80, 40, 2, 5},
+		{50, 40, 1, 5},
+		{50, 12, 1, 1},
+	}
+	for _, tt := range tests {
+		cols, rows := reflowSize(tt.width, tt.height)
+		if cols != tt.cols || rows != tt.rows {
+			t.Errorf("reflowSize(%d, %d) = %d, %d, want %d, %d", tt.width, tt.height, cols, rows, tt.cols, tt.rows)
+		}
+	}
+}
+
+// TestReflowViewRows tests that narrow layouts keep the panels in reading
+// order and leave out those that do not fit.
+func TestReflowViewRows(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	view := app.views[0] // Dashboard
+	if rows := app.viewRows(view); len(rows) != len(view.rows) {
+		t.Errorf("wide layout has %d rows, want %d as defined", len(rows), len(view.rows))
+	}
+
+	app.layoutCols, app.layoutRows = 2, 2
+	rows := app.viewRows(view)
+	if len(rows) != 2 {
+		t.Fatalf("reflowed layout has %d rows, want 2", len(rows))
+	}
+	if !slices.Equal(rows[0].panels, []string{panelStatus, panelPaths}) ||
+		!slices.Equal(rows[1].panels, []string{panelMethods, panelVisitors}) {
+		t.Errorf("reflowed rows = %v, %v", rows[0].panels, rows[1].panels)
+	}
+	if got := app.currentPanels(); len(got) != 4 {
+		t.Errorf("currentPanels() = %v, want only the 4 panels shown", got)
+	}
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// Panels only available in mini mode
+const (
+	miniRate     = "rate"
+	miniOverview = "overview"
+)
+
+// statusClasses are the status code classes of the mini rate panel.
+var statusClasses = []struct {
+	class int
+	color string
+}{{2, "green"}, {3, "blue"}, {4, "yellow"}, {5, "red"}}
+
+// SetMini shows a single panel instead of the dashboard, e.g. in a small
+// tmux pane. The rate panel shows the request rate with a sparkline of the
+// last minutes and the share of each status class in two lines; the other
+// panels are the dashboard ones, by ID.
+func (ta *TviewApp) SetMini(id string) error {
+	switch id {
+	case "":
+		ta.mini = nil
+		return nil
+	case miniRate:
+		ta.miniView = tview.NewTextView().SetDynamicColors(true)
+		ta.mini = ta.miniView
+	case miniOverview:
+		ta.mini = ta.overview
+	default:
+		p, ok := ta.panels[id]
+		if !ok {
+			ids := slices.Sorted(maps.Keys(ta.panels))
+			return fmt.Errorf("unknown panel %q (want %s, %s or one of %s)",
+				id, miniRate, miniOverview, strings.Join(ids, ", "))
+		}
+		ta.mini = p
+	}
+	return nil
+}
+
+// renderMini renders the mini rate panel: the request rate and its trend,
+// then a sparkline of the requests per 10 seconds fitted to the width.
+func (ta *TviewApp) renderMini() {
+	if ta.miniView == nil {
+		return
+	}
+
+	total := 0
+	classes := make(map[int]int)
+	for code, count := range ta.statusCodes {
+		classes[code/100] += count
+		total += count
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[::b]%.1f req/s[-::-]", ta.rateTracker.GetStats().Current)
+	for _, c := range statusClasses {
+		fmt.Fprintf(&b, "  [%s]%dxx[-::-] %.0f%%", c.color, c.class, percent(classes[c.class], total))
+	}
+
+	_, _, width, _ := ta.miniView.GetInnerRect()
+	if width <= 0 {
+		width = 60
+	}
+	fmt.Fprintf(&b, "\n[cyan]%s[-::-]", sparkline(ta.rateTracker.Buckets(time.Now(), width)))
+
+	ta.miniView.SetText(b.String())
+}
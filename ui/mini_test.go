@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSetMini tests choosing the single panel shown in mini mode.
+func TestSetMini(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	if err := app.SetMini(panelPaths); err != nil || app.mini != app.pathsTable {
+		t.Errorf("SetMini(paths) = %v, want the paths table", err)
+	}
+	if err := app.SetMini("nope"); err == nil {
+		t.Error("SetMini() with an unknown panel should fail")
+	}
+	if err := app.SetMini(miniRate); err != nil || app.mini != app.miniView {
+		t.Fatalf("SetMini(rate) = %v, want the rate view", err)
+	}
+
+	app.statusCodes = map[int]int{200: 3, 404: 1}
+	app.rateTracker.RecordN(time.Now(), 20)
+	app.miniView.SetRect(0, 0, 40, 2)
+	app.renderMini()
+	text := app.miniView.GetText(true)
+	for _, want := range []string{"2.0 req/s", "2xx 75%", "4xx 25%", "5xx 0%"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("rate panel %q does not contain %q", text, want)
+		}
+	}
+	if lines := strings.Split(text, "\n"); len(lines) != 2 || len([]rune(lines[1])) != 40 {
+		t.Errorf("rate panel sparkline = %q, want 40 columns", lines[len(lines)-1])
+	}
+}
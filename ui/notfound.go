@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// maxNotFoundIPs is the number of requesting IPs listed for each missing path.
+const maxNotFoundIPs = 3
+
+// renderNotFound renders the most requested missing paths with the IPs
+// requesting them.
+func (ta *TviewApp) renderNotFound() {
+	table := ta.notFoundTable
+	table.Clear()
+
+	entries := topEntries(ta.notFoundPaths, ta.topLimit(table, 1))
+	if len(entries) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell("[::d]No 404 responses in the window[-::-]"))
+		return
+	}
+
+	setHeader(table, "Path", "Count", "IPs", "Top IPs")
+	table.GetCell(0, 3).SetAlign(tview.AlignLeft)
+	for i, e := range entries {
+		row := i + 1
+		tag := "[yellow]"
+		if style, ok := ta.highlights.ValueStyle("path", e.key); ok {
+			tag = style.Tag()
+		}
+
+		path := e.key
+		if len(path) > 40 {
+			path = path[:37] + "..."
+		}
+
+		ips := ta.notFoundIPs[e.key]
+
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("%s%s[-:-:-]", tag, tview.Escape(path))).
+				SetAlign(tview.AlignLeft).
+				SetMaxWidth(40).
+				SetReference(e.key))
+		table.SetCell(row, 1,
+			tview.NewTableCell(fmt.Sprintf("  [cyan]%d[-::-]", e.value)).
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 2,
+			tview.NewTableCell(fmt.Sprintf("[::d]%4d IPs[-::-] ", len(ips))).
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 3,
+			tview.NewTableCell(topList(ips, maxNotFoundIPs)).
+				SetAlign(tview.AlignLeft).
+				SetExpansion(1))
+	}
+}
+
+// topList formats the n most frequent keys with their counts, e.g.
+// "10.0.0.1 ×12, 10.0.0.2 ×3, +4 more".
+func topList(counts map[string]int, n int) string {
+	var top []string
+	for _, e := range topEntries(counts, n) {
+		top = append(top, fmt.Sprintf("%s [::d]×%d[-::-]", tview.Escape(e.key), e.value))
+	}
+	if len(counts) > n {
+		top = append(top, fmt.Sprintf("[::d]+%d more[-::-]", len(counts)-n))
+	}
+	return strings.Join(top, ", ")
+}
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestNotFoundPaths tests that 404 paths are counted with their requesting IPs.
+func TestNotFoundPaths(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.processBatch([]parser.Visitor{
+		{IP: "1.1.1.1", Path: "/wp-login.php", Status: 404, Time: now},
+		{IP: "1.1.1.1", Path: "/wp-login.php", Status: 404, Time: now},
+		{IP: "2.2.2.2", Path: "/wp-login.php", Status: 404, Time: now},
+		{IP: "3.3.3.3", Path: "/old-page", Status: 404, Time: now},
+		{IP: "3.3.3.3", Path: "/", Status: 200, Time: now},
+	})
+	app.updateData()
+
+	if app.notFoundPaths["/wp-login.php"] != 3 || app.notFoundPaths["/old-page"] != 1 || len(app.notFoundPaths) != 2 {
+		t.Errorf("notFoundPaths = %v", app.notFoundPaths)
+	}
+	if app.notFoundIPs["/wp-login.php"]["1.1.1.1"] != 2 || len(app.notFoundIPs["/wp-login.php"]) != 2 {
+		t.Errorf("notFoundIPs[/wp-login.php] = %v", app.notFoundIPs["/wp-login.php"])
+	}
+
+	app.renderNotFound()
+	cell := app.notFoundTable.GetCell(1, 3)
+	if !strings.Contains(cell.Text, "1.1.1.1") {
+		t.Errorf("IPs cell = %q, want it to list 1.1.1.1", cell.Text)
+	}
+}
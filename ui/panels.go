@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/papaganelli/tailnginx/internal/state"
+	"github.com/rivo/tview"
+)
+
+// Size of the panel visibility menu
+const (
+	panelMenuWidth  = 40
+	panelMenuHeight = 22
+)
+
+// SetState applies the preferences saved in a previous session and shows
+// what it ended with. Changes to the preferences, and what is shown when Run
+// returns, are saved to path; an empty path disables saving. Must be called
+// before SetFilters.
+func (ta *TviewApp) SetState(st *state.State, path string) {
+	ta.state = st
+	ta.statePath = path
+	ta.hidden = make(map[string]bool)
+	for _, id := range st.HiddenPanels {
+		ta.hidden[id] = true
+	}
+	ta.restoreSession(st.Session)
+	ta.rebuildViews()
+}
+
+// panelIDs returns the IDs of all panels used by the views, in order of
+// first appearance.
+func (ta *TviewApp) panelIDs() []string {
+	var ids []string
+	for _, view := range ta.views {
+		for _, row := range view.rows {
+			for _, id := range row.panels {
+				if !slices.Contains(ids, id) {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// visiblePanels returns the panels of a row that are not hidden.
+func (ta *TviewApp) visiblePanels(row viewRow) []string {
+	var ids []string
+	for _, id := range row.panels {
+		if !ta.hidden[id] && ta.available(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// available reports whether a panel can be shown at all: the Countries
+// panel needs geo lookups.
+func (ta *TviewApp) available(id string) bool {
+	return id != panelCountries || !ta.noGeo
+}
+
+// panelTitle returns the title of a panel without its icon.
+func (ta *TviewApp) panelTitle(id string) string {
+	p, ok := ta.panels[id].(interface{ GetTitle() string })
+	if !ok {
+		return id
+	}
+	if title := stripIcon(p.GetTitle()); title != "" {
+		return title
+	}
+	return id
+}
+
+// stripIcon returns a title without the icon in front of it.
+func stripIcon(title string) string {
+	return strings.TrimLeftFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// initPanelMenu creates the panel visibility menu, shown over the dashboard.
+func (ta *TviewApp) initPanelMenu(borderColor, titleColor tcell.Color) tview.Primitive {
+	ta.panelMenu = tview.NewTable().
+		SetSelectable(true, false)
+	ta.panelMenu.SetBorder(true).
+		SetTitle("🧩 Panels").
+		SetBorderColor(borderColor).
+		SetTitleColor(titleColor)
+
+	ta.panelMenu.SetSelectedFunc(func(row, _ int) {
+		if id, ok := ta.panelMenu.GetCell(row, 0).GetReference().(string); ok {
+			ta.togglePanel(id)
+		}
+	})
+
+	return modal(ta.panelMenu, panelMenuWidth, panelMenuHeight)
+}
+
+// renderPanelMenu lists the panels with their visibility.
+func (ta *TviewApp) renderPanelMenu() {
+	ta.panelMenu.Clear()
+	row := 0
+	for _, id := range ta.panelIDs() {
+		if !ta.available(id) {
+			continue
+		}
+		mark := "[green]✓[-::-]"
+		if ta.hidden[id] {
+			mark = "[::d]·[-::-]"
+		}
+		ta.panelMenu.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf(" %s %s", mark, ta.panelTitle(id))).
+				SetExpansion(1).
+				SetReference(id))
+		row++
+	}
+}
+
+// showPanelMenu opens or closes the panel visibility menu.
+func (ta *TviewApp) showPanelMenu(show bool) {
+	ta.mu.Lock()
+	if show {
+		ta.page = pagePanels
+	} else {
+		ta.page = pageDashboard
+	}
+	ta.mu.Unlock()
+
+	if show {
+		if ta.focused != nil {
+			ta.focused.SetSelectable(false, false).SetBorderColor(ta.borderColor)
+			ta.focused = nil
+		}
+		ta.renderPanelMenu()
+		ta.pages.ShowPage(pagePanels)
+		ta.pages.SendToFront(pagePanels)
+		ta.footer.SetText(panelsHelp)
+		ta.app.SetFocus(ta.panelMenu)
+		return
+	}
+	ta.pages.HidePage(pagePanels)
+	ta.footer.SetText(ta.help)
+	ta.app.SetFocus(ta.grid)
+}
+
+// togglePanel hides or shows a panel in all views and saves the preference.
+func (ta *TviewApp) togglePanel(id string) {
+	ta.hidden[id] = !ta.hidden[id]
+	if !ta.hidden[id] {
+		delete(ta.hidden, id)
+	}
+
+	ta.state.HiddenPanels = ta.state.HiddenPanels[:0]
+	for _, p := range ta.panelIDs() {
+		if ta.hidden[p] {
+			ta.state.HiddenPanels = append(ta.state.HiddenPanels, p)
+		}
+	}
+
+	ta.rebuildViews()
+	ta.mu.Lock()
+	ta.dataChanged = true
+	ta.mu.Unlock()
+	ta.renderPanelMenu()
+	ta.app.SetFocus(ta.panelMenu)
+
+	if ta.statePath != "" {
+		if err := ta.state.Save(ta.statePath); err != nil {
+			ta.flash(fmt.Sprintf("[red]Saving panels failed:[-::-] %v", err))
+		}
+	}
+}
+
+// rebuildViews recreates the view pages after a change of visible panels
+// or layout. The page shown keeps being drawn on top.
+func (ta *TviewApp) rebuildViews() {
+	if ta.pages == nil {
+		return
+	}
+	// Full-screen pages hide the dashboard, overlays are drawn over it
+	dashboardShown := ta.page != pageRaw && ta.page != pageGeo
+	for i, view := range ta.views {
+		ta.pages.AddPage(viewPageName(i), ta.buildView(view), true, dashboardShown && i == ta.viewIndex)
+	}
+	if ta.page != pageDashboard {
+		ta.pages.SendToFront(ta.page)
+	}
+}
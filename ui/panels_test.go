@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/internal/state"
+)
+
+// TestHiddenPanels tests that hidden panels are left out of the views and
+// that the preference is saved.
+func TestHiddenPanels(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	app.SetState(&state.State{HiddenPanels: []string{panelCountries}}, path)
+
+	if slices.Contains(app.currentPanels(), panelCountries) {
+		t.Error("hidden panel should not be part of the current view")
+	}
+	for _, table := range app.focusOrder() {
+		if table == app.countriesTable {
+			t.Error("hidden panel should not be focusable")
+		}
+	}
+
+	app.togglePanel(panelCountries)
+	app.togglePanel(panelMethods)
+	if !slices.Contains(app.currentPanels(), panelCountries) || slices.Contains(app.currentPanels(), panelMethods) {
+		t.Errorf("currentPanels() = %v after toggling countries and methods", app.currentPanels())
+	}
+
+	saved, err := state.Load(path)
+	if err != nil {
+		t.Fatalf("state.Load() error = %v", err)
+	}
+	if !slices.Equal(saved.HiddenPanels, []string{panelMethods}) {
+		t.Errorf("saved HiddenPanels = %v, want [%s]", saved.HiddenPanels, panelMethods)
+	}
+}
+
+// TestVisiblePanels tests that rows keep only their visible panels.
+func TestVisiblePanels(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	app.hidden[panelWatch] = true
+
+	row := viewRow{panels: []string{panelReferers, panelWatch, panelStream, panelStream}}
+	want := []string{panelReferers, panelStream, panelStream}
+	if got := app.visiblePanels(row); !slices.Equal(got, want) {
+		t.Errorf("visiblePanels() = %v, want %v", got, want)
+	}
+
+	if got := app.panelTitle(panelNotFound); got != "404 Hot Paths" {
+		t.Errorf("panelTitle() = %q, want the title without its icon", got)
+	}
+
+	ids := app.panelIDs()
+	if ids[0] != panelStatus || !slices.Contains(ids, panelNotFound) {
+		t.Errorf("panelIDs() = %v, want all panels in order of appearance", ids)
+	}
+}
+
+// TestNoGeoIP tests that the Countries panel is left out of the views and the
+// panel menu when geo lookups are disabled.
+func TestNoGeoIP(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	app.SetGeoIP(false)
+
+	if slices.Contains(app.currentPanels(), panelCountries) {
+		t.Error("Countries panel should not be part of the current view without geo lookups")
+	}
+
+	app.renderPanelMenu()
+	for row := range app.panelMenu.GetRowCount() {
+		if app.panelMenu.GetCell(row, 0).GetReference() == panelCountries {
+			t.Error("Countries panel should not be listed in the panel menu")
+		}
+	}
+
+	app.SetGeoIP(true)
+	if !slices.Contains(app.currentPanels(), panelCountries) {
+		t.Error("Countries panel should be shown again with geo lookups")
+	}
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// titledBox is a primitive with a border title.
+type titledBox interface {
+	GetTitle() string
+	SetTitle(title string) *tview.Box
+}
+
+// asciiGlyphs are the ASCII look-alikes of the glyphs drawn by the panels.
+var asciiGlyphs = map[rune]rune{
+	// Box drawing lines; other box drawing characters become '+'
+	'─': '-', '━': '-', '═': '-', '│': '|', '┃': '|', '║': '|',
+	// Bars and sparklines
+	'█': '#', '▓': '#', '▒': ':', '░': '.',
+	'▁': '_', '▂': '.', '▃': '-', '▄': '=', '▅': '+', '▆': '*', '▇': '%',
+	// Status, trend and map markers
+	'✓': '+', '✗': 'x', '↻': '~', '⚠': '!',
+	'↑': '^', '↓': 'v', '→': '>', '←': '<',
+	'∙': '.', '•': '*', '●': 'O', '·': '.',
+	// Punctuation
+	'…': '.', '—': '-', '–': '-', '×': 'x', '±': '+', 'Δ': 'D', '␣': '_',
+}
+
+// SetPlain switches to plain text rendering for terminals and locales that
+// cannot display emoji, block elements or box drawing characters. Titles lose
+// their icons and the remaining glyphs are replaced with ASCII look-alikes
+// once the screen is drawn.
+func (ta *TviewApp) SetPlain(plain bool) {
+	ta.plain = plain
+	if !plain {
+		ta.app.SetAfterDrawFunc(nil)
+		return
+	}
+
+	titled := []titledBox{ta.overview, ta.geoMap, ta.panelMenu, ta.rawView, ta.countryTable, ta.statusPrompt}
+	for _, p := range ta.panels {
+		if box, ok := p.(titledBox); ok {
+			titled = append(titled, box)
+		}
+	}
+	for _, box := range titled {
+		box.SetTitle(stripIcon(box.GetTitle()))
+	}
+	ta.app.SetAfterDrawFunc(asciiScreen)
+}
+
+// title returns a panel title as displayed: without its icon in plain mode.
+func (ta *TviewApp) title(title string) string {
+	if ta.plain {
+		return stripIcon(title)
+	}
+	return title
+}
+
+// asciiScreen replaces every non-ASCII cell of the screen with its ASCII
+// look-alike. Letters (e.g. accented country names) are kept.
+func asciiScreen(screen tcell.Screen) {
+	width, height := screen.Size()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, combining, style, w := screen.GetContent(x, y)
+			if r <= unicode.MaxASCII && len(combining) == 0 {
+				continue
+			}
+			screen.SetContent(x, y, asciiRune(r, w), nil, style)
+		}
+	}
+}
+
+// asciiRune returns the ASCII look-alike of a rune drawn w cells wide.
+func asciiRune(r rune, w int) rune {
+	if a, ok := asciiGlyphs[r]; ok {
+		return a
+	}
+	switch {
+	case r <= unicode.MaxASCII:
+		return r
+	case r >= 0x2500 && r <= 0x257f: // Box drawing
+		return '+'
+	case r == 0x2800: // Blank braille pattern
+		return ' '
+	case r > 0x2800 && r <= 0x28ff: // Braille world map
+		return '.'
+	case w > 1: // Emoji
+		return ' '
+	case unicode.IsLetter(r) || unicode.IsDigit(r):
+		return r
+	default:
+		return '?'
+	}
+}
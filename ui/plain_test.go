@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TestAsciiRune tests the ASCII look-alikes of the glyphs in use.
+func TestAsciiRune(t *testing.T) {
+	tests := []struct {
+		r    rune
+		w    int
+		want rune
+	}{
+		{'a', 1, 'a'},
+		{'─', 1, '-'},
+		{'╔', 1, '+'},
+		{'█', 1, '#'},
+		{'▃', 1, '-'},
+		{'✗', 1, 'x'},
+		{'⣿', 1, '.'},
+		{'⠀', 1, ' '},
+		{'📊', 2, ' '},
+		{'ô', 1, 'ô'},
+		{'☃', 1, '?'},
+	}
+	for _, tt := range tests {
+		if got := asciiRune(tt.r, tt.w); got != tt.want {
+			t.Errorf("asciiRune(%q) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
+
+// TestSetPlain tests that plain mode strips title icons and draws ASCII only.
+func TestSetPlain(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	app.SetPlain(true)
+
+	if got := app.pathsTable.GetTitle(); got != "Top Paths" {
+		t.Errorf("paths title = %q, want it without its icon", got)
+	}
+	if got := app.title("🧯 Error Log — /var/log/nginx/error.log"); got != "Error Log — /var/log/nginx/error.log" {
+		t.Errorf("title() = %q", got)
+	}
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(40, 10)
+	app.pathsTable.SetRect(0, 0, 40, 10)
+	app.pathsTable.Draw(screen)
+	asciiScreen(screen)
+
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 40; x++ {
+			if r, _, _, _ := screen.GetContent(x, y); r > 0x7f {
+				t.Fatalf("cell %d,%d = %q, want ASCII", x, y, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// protocolColors maps HTTP versions to bar colors.
+var protocolColors = map[string]string{
+	"HTTP/1.0": "red",
+	"HTTP/1.1": "yellow",
+	"HTTP/2":   "cyan",
+	"HTTP/3":   "green",
+}
+
+// httpVersion normalizes a request protocol for display, e.g. "HTTP/2.0" to "HTTP/2".
+func httpVersion(protocol string) string {
+	switch protocol {
+	case "":
+		return "Other"
+	case "HTTP/2.0":
+		return "HTTP/2"
+	case "HTTP/3.0":
+		return "HTTP/3"
+	}
+	return protocol
+}
+
+// renderProtocols renders the share of each HTTP version.
+func (ta *TviewApp) renderProtocols() {
+	table := ta.protocolsTable
+	table.Clear()
+
+	total := 0
+	for _, count := range ta.protocolsData {
+		total += count
+	}
+
+	for row, e := range topEntries(ta.protocolsData, 0) {
+		color, ok := protocolColors[e.key]
+		if !ok {
+			color = "white"
+		}
+		pct := percent(e.value, total)
+
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("[%s]%s[-::-]", color, tview.Escape(e.key))).
+				SetAlign(tview.AlignLeft).
+				SetReference(e.key))
+		table.SetCell(row, 1,
+			tview.NewTableCell(percentBar(pct, 12, color)).
+				SetAlign(tview.AlignLeft))
+		table.SetCell(row, 2,
+			tview.NewTableCell(fmt.Sprintf("[cyan::b]%.0f%%[-::-]", pct)).
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 3,
+			tview.NewTableCell(fmt.Sprintf("[::d]%d[-::-]", e.value)).
+				SetAlign(tview.AlignRight))
+	}
+}
+
+// percentBar renders a filled bar of width characters for a percentage.
+func percentBar(pct float64, width int, color string) string {
+	filled := min(int(pct*float64(width)/100), width)
+	return fmt.Sprintf("[%s]%s[-::-][::d]%s[-::-]",
+		color,
+		strings.Repeat("█", filled),
+		strings.Repeat("░", width-filled))
+}
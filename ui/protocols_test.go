@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestHTTPVersion tests protocol normalization.
+func TestHTTPVersion(t *testing.T) {
+	tests := map[string]string{
+		"HTTP/1.1": "HTTP/1.1",
+		"HTTP/2.0": "HTTP/2",
+		"HTTP/3.0": "HTTP/3",
+		"HTTP/3":   "HTTP/3",
+		"":         "Other",
+	}
+	for in, want := range tests {
+		if got := httpVersion(in); got != want {
+			t.Errorf("httpVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestPercentBar tests bar filling and clamping.
+func TestPercentBar(t *testing.T) {
+	if got, want := percentBar(50, 4, "red"), "[red]██[-::-][::d]░░[-::-]"; got != want {
+		t.Errorf("percentBar(50) = %q, want %q", got, want)
+	}
+	if got, want := percentBar(150, 2, "red"), "[red]██[-::-][::d][-::-]"; got != want {
+		t.Errorf("percentBar(150) = %q, want %q", got, want)
+	}
+}
+
+// TestProtocolAggregation tests HTTP version counts.
+func TestProtocolAggregation(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.processBatch([]parser.Visitor{
+		{IP: "1.1.1.1", Status: 200, Time: now, Protocol: "HTTP/1.1"},
+		{IP: "1.1.1.1", Status: 200, Time: now, Protocol: "HTTP/2.0"},
+		{IP: "1.1.1.1", Status: 200, Time: now, Protocol: "HTTP/2.0"},
+	})
+	app.updateData()
+
+	if app.protocolsData["HTTP/2"] != 2 || app.protocolsData["HTTP/1.1"] != 1 {
+		t.Errorf("protocolsData = %v, want HTTP/2=2 HTTP/1.1=1", app.protocolsData)
+	}
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/ratelimit"
+	"github.com/rivo/tview"
+)
+
+// SetRateLimits sets the tracker that every parsed entry is counted in, to
+// find the IPs above a request rate, and where the L key writes its report:
+// the nginx include file at path, or a timestamped file in the export
+// directory if path is empty. Must be called before Run.
+func (ta *TviewApp) SetRateLimits(t *ratelimit.Tracker, path string) {
+	ta.rateLimits = t
+	ta.rateLimitFile = path
+}
+
+// writeRateLimits writes the report of the rate limit candidates and
+// reports the outcome in the footer. Must be called from the UI goroutine.
+func (ta *TviewApp) writeRateLimits() {
+	if ta.rateLimits == nil {
+		ta.flash("[red]Rate limits:[-::-] rate tracking is off")
+		return
+	}
+	candidates, _ := ta.rateLimits.Candidates()
+
+	now := time.Now()
+	path := ta.rateLimitFile
+	if path == "" {
+		path = filepath.Join(ta.exportDir, "tailnginx-ratelimit-"+now.Format("20060102-150405")+".conf")
+	}
+	if err := ta.rateLimits.SaveReport(path, now); err != nil {
+		ta.flash(fmt.Sprintf("[red]Rate limit report failed:[-::-] %v", err))
+		return
+	}
+	ta.flash(fmt.Sprintf("[green]%d rate limit candidates written to[-::-] %s", len(candidates), tview.Escape(path)))
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/ratelimit"
+)
+
+func TestWriteRateLimits(t *testing.T) {
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	dir := t.TempDir()
+	app.SetExportDir(dir)
+
+	app.writeRateLimits()
+	if !strings.Contains(app.footer.GetText(false), "off") {
+		t.Errorf("footer = %q, want rate tracking reported off", app.footer.GetText(false))
+	}
+
+	tr, err := ratelimit.NewTracker(1, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		tr.Observe(&parser.Visitor{IP: "203.0.113.7", Time: now})
+	}
+	app.SetRateLimits(tr, "")
+	app.writeRateLimits()
+	matches, _ := filepath.Glob(filepath.Join(dir, "tailnginx-ratelimit-*.conf"))
+	if len(matches) != 1 {
+		t.Fatalf("wrote %d reports to the export directory, want 1", len(matches))
+	}
+	data, _ := os.ReadFile(matches[0])
+	if !strings.Contains(string(data), "# 203.0.113.7:") || !strings.Contains(string(data), "limit_req_zone") {
+		t.Errorf("report = %q", data)
+	}
+	if footer := app.footer.GetText(false); !strings.Contains(footer, "1 rate limit candidates") {
+		t.Errorf("footer = %q", footer)
+	}
+
+	include := filepath.Join(dir, "ratelimit.conf")
+	app.SetRateLimits(tr, include)
+	app.writeRateLimits()
+	if _, err := os.Stat(include); err != nil {
+		t.Errorf("report not written to the include file: %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Page names for the content area
+const (
+	pageDashboard = "dashboard"
+	pageRaw       = "raw"
+	pageGeo       = "geo"
+	pagePanels    = "panels"
+	pageCountry   = "country"
+	pageStatus    = "status"
+)
+
+// maxRawLines is the number of raw log lines kept for the raw viewer scrollback.
+const maxRawLines = 5000
+
+// Footer help texts for each page but the dashboard, see dashboardHelp
+const (
+	panelsHelp  = "[yellow]↑↓[-::-]:select  [yellow]enter[-::-]:show/hide  [yellow]p/esc[-::-]:close"
+	countryHelp = "[yellow]↑↓ PgUp/PgDn[-::-]:scroll  [yellow]y[-::-]:copy IP  [yellow]w[-::-]:watch IP  [yellow]esc[-::-]:close"
+	statusHelp  = "[yellow]0-9[-::-]:status code  [yellow]enter[-::-]:filter (empty: all)  [yellow]esc[-::-]:cancel"
+	geoHelp     = "[yellow]g/esc[-::-]:dashboard  [yellow]t[-::-]:window  [yellow]2-5[-::-]:filter  [yellow]space[-::-]:pause  [yellow]q[-::-]:quit"
+	rawHelp     = "[yellow]r[-::-]:dashboard  [yellow]f[-::-]:follow  [yellow]/[-::-]:search  [yellow]n/N[-::-]:next/prev match  [yellow]↑↓ PgUp/PgDn[-::-]:scroll  [yellow]esc[-::-]:clear search  [yellow]y[-::-]:copy line"
+)
+
+// initRawView creates the raw log viewer page with its search field.
+func (ta *TviewApp) initRawView(borderColor, titleColor, fieldBg tcell.Color) tview.Primitive {
+	ta.rawView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetScrollable(true).
+		SetWrap(false)
+
+	ta.rawView.SetBorder(true).
+		SetTitle("📜 Raw Log").
+		SetBorderColor(borderColor).
+		SetTitleColor(titleColor)
+
+	// Any manual scroll towards older lines stops following the end of the log
+	ta.rawView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyPgUp, tcell.KeyHome, tcell.KeyCtrlB:
+			ta.setRawFollow(false)
+		case tcell.KeyEnd:
+			ta.setRawFollow(true)
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'k', 'g':
+				ta.setRawFollow(false)
+			case 'G':
+				ta.setRawFollow(true)
+			}
+		}
+		return event
+	})
+
+	ta.rawSearch = tview.NewInputField().
+		SetLabel(" / ").
+		SetPlaceholder("search raw log (enter to apply, esc to cancel)").
+		SetFieldBackgroundColor(fieldBg)
+
+	ta.rawSearch.SetDoneFunc(func(key tcell.Key) {
+		ta.mu.Lock()
+		if key == tcell.KeyEnter {
+			ta.rawQuery = ta.rawSearch.GetText()
+		} else {
+			ta.rawSearch.SetText(ta.rawQuery)
+		}
+		ta.rawMatch = -1
+		ta.mu.Unlock()
+
+		ta.app.SetFocus(ta.rawView)
+		ta.renderRaw()
+		ta.jumpToMatch(0)
+	})
+
+	return tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(ta.rawView, 0, 1, true).
+		AddItem(ta.rawSearch, 1, 0, false)
+}
+
+// showRawView switches the content area between the dashboard and the raw log viewer.
+func (ta *TviewApp) showRawView(show bool) {
+	ta.mu.Lock()
+	if show {
+		ta.page = pageRaw
+	} else {
+		ta.page = pageDashboard
+	}
+	ta.mu.Unlock()
+
+	if show {
+		ta.pages.SwitchToPage(pageRaw)
+		ta.footer.SetText(rawHelp)
+		ta.app.SetFocus(ta.rawView)
+		ta.renderRaw()
+		return
+	}
+	ta.pages.SwitchToPage(viewPageName(ta.viewIndex))
+	ta.footer.SetText(ta.help)
+	if ta.focused != nil {
+		ta.app.SetFocus(ta.focused)
+	} else {
+		ta.app.SetFocus(ta.grid)
+	}
+}
+
+// handleRawKey handles keys specific to the raw log viewer.
+// Returns true if the key was consumed.
+func (ta *TviewApp) handleRawKey(event *tcell.EventKey) bool {
+	if event.Key() == tcell.KeyEscape {
+		ta.mu.Lock()
+		hadQuery := ta.rawQuery != ""
+		ta.rawQuery = ""
+		ta.rawMatch = -1
+		ta.mu.Unlock()
+		ta.rawSearch.SetText("")
+		if hadQuery {
+			ta.renderRaw()
+		}
+		return true
+	}
+
+	switch event.Rune() {
+	case 'r', 'R':
+		ta.showRawView(false)
+	case 'f', 'F':
+		ta.mu.RLock()
+		follow := ta.rawFollow
+		ta.mu.RUnlock()
+		ta.setRawFollow(!follow)
+		ta.renderRaw()
+	case '/':
+		ta.app.SetFocus(ta.rawSearch)
+	case 'n':
+		ta.jumpToMatch(1)
+	case 'N':
+		ta.jumpToMatch(-1)
+	default:
+		return false
+	}
+	return true
+}
+
+// setRawFollow enables or disables following new lines in the raw viewer.
+func (ta *TviewApp) setRawFollow(follow bool) {
+	ta.mu.Lock()
+	ta.rawFollow = follow
+	ta.mu.Unlock()
+	ta.updateRawTitle()
+}
+
+// appendRaw adds raw log lines to the scrollback buffer, dropping the oldest
+// lines once the buffer exceeds maxRawLines.
+func (ta *TviewApp) appendRaw(lines []string) {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	ta.rawLines = append(ta.rawLines, lines...)
+	ta.rawSeq += len(lines)
+	if excess := len(ta.rawLines) - maxRawLines; excess > 0 {
+		ta.rawLines = append([]string(nil), ta.rawLines[excess:]...)
+		ta.rawDropped += excess
+	}
+	ta.rawChanged = true
+}
+
+// renderRaw renders the raw log buffer, highlighting search matches.
+// Must be called from the UI goroutine.
+func (ta *TviewApp) renderRaw() {
+	ta.mu.Lock()
+	text, _ := highlightMatches(ta.rawLines, ta.rawQuery, ta.rawSeq-len(ta.rawLines))
+	dropped := ta.rawDropped
+	ta.rawDropped = 0
+	follow := ta.rawFollow
+	ta.mu.Unlock()
+
+	row, col := ta.rawView.GetScrollOffset()
+	ta.rawView.SetText(text)
+	if follow {
+		ta.rawView.ScrollToEnd()
+	} else if dropped > 0 {
+		// Keep the same lines on screen while old lines fall off the buffer
+		row -= dropped
+		if row < 0 {
+			row = 0
+		}
+		ta.rawView.ScrollTo(row, col)
+	}
+	ta.updateRawTitle()
+}
+
+// jumpToMatch moves the current search match by delta and scrolls to it.
+// A delta of 0 selects the most recent match.
+func (ta *TviewApp) jumpToMatch(delta int) {
+	ta.mu.Lock()
+	if ta.rawQuery == "" {
+		ta.mu.Unlock()
+		return
+	}
+	first := ta.rawSeq - len(ta.rawLines)
+	matches := matchingLines(ta.rawLines, ta.rawQuery, first)
+	if len(matches) == 0 {
+		ta.rawMatch = -1
+		ta.mu.Unlock()
+		ta.updateRawTitle()
+		return
+	}
+
+	// Locate the current match; it may have scrolled out of the buffer
+	current := -1
+	for i, seq := range matches {
+		if seq == ta.rawMatch {
+			current = i
+			break
+		}
+	}
+	switch {
+	case delta == 0 || current < 0:
+		current = len(matches) - 1
+	default:
+		current = (current + delta + len(matches)) % len(matches)
+	}
+	ta.rawMatch = matches[current]
+	ta.rawFollow = false
+	ta.mu.Unlock()
+
+	ta.rawView.Highlight(matchRegion(matches[current]))
+	ta.rawView.ScrollToHighlight()
+	ta.updateRawTitle()
+}
+
+// updateRawTitle refreshes the raw viewer title with buffer and search state.
+func (ta *TviewApp) updateRawTitle() {
+	ta.mu.RLock()
+	defer ta.mu.RUnlock()
+
+	mode := "[green]following[-::-]"
+	if !ta.rawFollow {
+		mode = "[yellow]scrolling[-::-]"
+	}
+	title := fmt.Sprintf("📜 Raw Log — %d lines · %s", len(ta.rawLines), mode)
+	if ta.rawQuery != "" {
+		count := len(matchingLines(ta.rawLines, ta.rawQuery, 0))
+		title += fmt.Sprintf(" · [cyan]%d matches[-::-] for %q", count, ta.rawQuery)
+	}
+	ta.rawView.SetTitle(ta.title(title))
+}
+
+// matchRegion returns the region ID used for the raw line with the given sequence number.
+func matchRegion(seq int) string {
+	return fmt.Sprintf("m%d", seq)
+}
+
+// matchingLines returns the sequence numbers of lines containing query
+// (case-insensitive). first is the sequence number of lines[0].
+func matchingLines(lines []string, query string, first int) []int {
+	if query == "" {
+		return nil
+	}
+	needle := strings.ToLower(query)
+	var matches []int
+	for i, line := range lines {
+		if strings.Contains(strings.ToLower(line), needle) {
+			matches = append(matches, first+i)
+		}
+	}
+	return matches
+}
+
+// highlightMatches escapes raw lines for display and marks lines containing
+// query as regions with the matching text highlighted. first is the sequence
+// number of lines[0]. Returns the rendered text and the number of matching lines.
+func highlightMatches(lines []string, query string, first int) (string, int) {
+	var b strings.Builder
+	needle := strings.ToLower(query)
+	matches := 0
+
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		// Lowercasing may change byte lengths for some runes; skip highlighting then
+		if needle == "" || len(lower) != len(line) || !strings.Contains(lower, needle) {
+			b.WriteString(tview.Escape(line))
+			b.WriteString("\n")
+			continue
+		}
+
+		matches++
+		fmt.Fprintf(&b, `["%s"]`, matchRegion(first+i))
+		rest, restLower := line, lower
+		for {
+			idx := strings.Index(restLower, needle)
+			if idx < 0 {
+				b.WriteString(tview.Escape(rest))
+				break
+			}
+			b.WriteString(tview.Escape(rest[:idx]))
+			b.WriteString("[black:yellow]")
+			b.WriteString(tview.Escape(rest[idx : idx+len(needle)]))
+			b.WriteString("[-:-]")
+			rest, restLower = rest[idx+len(needle):], restLower[idx+len(needle):]
+		}
+		b.WriteString(`[""]`)
+		b.WriteString("\n")
+	}
+
+	return b.String(), matches
+}
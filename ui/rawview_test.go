@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAppendRawLimit tests that the raw buffer is capped at maxRawLines.
+func TestAppendRawLimit(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	batch := make([]string, maxRawLines+10)
+	for i := range batch {
+		batch[i] = fmt.Sprintf("line %d", i)
+	}
+	app.appendRaw(batch)
+
+	if len(app.rawLines) != maxRawLines {
+		t.Fatalf("appendRaw() kept %d lines, want %d", len(app.rawLines), maxRawLines)
+	}
+	if app.rawLines[0] != "line 10" {
+		t.Errorf("appendRaw() oldest line = %q, want %q", app.rawLines[0], "line 10")
+	}
+	if app.rawDropped != 10 {
+		t.Errorf("appendRaw() dropped = %d, want 10", app.rawDropped)
+	}
+	if app.rawSeq != maxRawLines+10 {
+		t.Errorf("appendRaw() seq = %d, want %d", app.rawSeq, maxRawLines+10)
+	}
+	if !app.rawChanged {
+		t.Error("appendRaw() should set rawChanged to true")
+	}
+}
+
+// TestHighlightMatches tests search highlighting in the raw viewer.
+func TestHighlightMatches(t *testing.T) {
+	lines := []string{
+		`1.2.3.4 - - "GET /api/login HTTP/1.1" 401`,
+		`5.6.7.8 - - "GET /index.html HTTP/1.1" 200`,
+		`9.9.9.9 - - "POST /API/login HTTP/1.1" 200 [x]`,
+	}
+
+	text, matches := highlightMatches(lines, "api/login", 100)
+	if matches != 2 {
+		t.Fatalf("highlightMatches() matches = %d, want 2", matches)
+	}
+	if !strings.Contains(text, `["m100"]`) || !strings.Contains(text, `["m102"]`) {
+		t.Errorf("highlightMatches() missing match regions:\n%s", text)
+	}
+	if strings.Contains(text, `["m101"]`) {
+		t.Errorf("highlightMatches() marked a non-matching line:\n%s", text)
+	}
+	if !strings.Contains(text, "[black:yellow]API/login[-:-]") {
+		t.Errorf("highlightMatches() should keep original case of matches:\n%s", text)
+	}
+	if !strings.Contains(text, "[x[]") {
+		t.Errorf("highlightMatches() should escape raw text:\n%s", text)
+	}
+
+	if _, matches := highlightMatches(lines, "", 0); matches != 0 {
+		t.Errorf("highlightMatches() with empty query matches = %d, want 0", matches)
+	}
+}
+
+// TestMatchingLines tests match sequence numbers.
+func TestMatchingLines(t *testing.T) {
+	lines := []string{"GET /a", "GET /b", "POST /a"}
+	got := matchingLines(lines, "/A", 10)
+	if len(got) != 2 || got[0] != 10 || got[1] != 12 {
+		t.Errorf("matchingLines() = %v, want [10 12]", got)
+	}
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/papaganelli/tailnginx/pkg/referrer"
+	"github.com/rivo/tview"
+)
+
+// maxRefererDomains is the number of domains listed under the traffic sources.
+const maxRefererDomains = 6
+
+// sourceOrder is the display order of traffic sources, each with its color.
+var sourceOrder = []struct {
+	source referrer.Source
+	color  string
+}{
+	{referrer.SourceSearch, "green"},
+	{referrer.SourceSocial, "blue"},
+	{referrer.SourceDirect, "white"},
+	{referrer.SourceOther, "yellow"},
+}
+
+// renderReferers renders traffic sources by kind followed by the top referring domains.
+func (ta *TviewApp) renderReferers() {
+	table := ta.referersTable
+	table.Clear()
+
+	total := 0
+	for _, count := range ta.sourcesData {
+		total += count
+	}
+
+	// Sources are shown two per row to leave room for the domains
+	for i, s := range sourceOrder {
+		pct := percent(ta.sourcesData[string(s.source)], total)
+		table.SetCell(i/2, i%2,
+			tview.NewTableCell(fmt.Sprintf("[%s]%s[-::-] [cyan::b]%.0f%%[-::-]", s.color, s.source, pct)).
+				SetAlign(tview.AlignLeft))
+	}
+
+	row := (len(sourceOrder) + 1) / 2
+	table.SetCell(row, 0, tview.NewTableCell("[::d]Top domains[-::-]"))
+	row++
+	for _, e := range topEntries(ta.referersData, maxRefererDomains) {
+		tag := "[white]"
+		if style, ok := ta.highlights.ValueStyle("referer", e.key); ok {
+			tag = style.Tag()
+		}
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("%s%s[-:-:-]", tag, tview.Escape(e.key))).
+				SetAlign(tview.AlignLeft).
+				SetMaxWidth(30).
+				SetReference(e.key))
+		table.SetCell(row, 1,
+			tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", e.value)).
+				SetAlign(tview.AlignRight))
+		row++
+	}
+}
@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestRefererSources tests that referers are grouped by source and domain.
+func TestRefererSources(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.processBatch([]parser.Visitor{
+		{IP: "1.1.1.1", Status: 200, Time: now, Referer: "https://www.google.com/search?q=a"},
+		{IP: "1.1.1.1", Status: 200, Time: now, Referer: "https://www.google.com/search?q=b"},
+		{IP: "1.1.1.1", Status: 200, Time: now, Referer: "https://t.co/xyz"},
+		{IP: "1.1.1.1", Status: 200, Time: now, Referer: "-"},
+		{IP: "1.1.1.1", Status: 200, Time: now, Referer: "https://blog.example.com/post"},
+	})
+	app.updateData()
+
+	wantSources := map[string]int{"Search": 2, "Social": 1, "Direct": 1, "Other": 1}
+	for source, want := range wantSources {
+		if got := app.sourcesData[source]; got != want {
+			t.Errorf("sourcesData[%s] = %d, want %d", source, got, want)
+		}
+	}
+	if app.referersData["google.com"] != 2 || app.referersData["blog.example.com"] != 1 {
+		t.Errorf("referersData = %v, want google.com=2 blog.example.com=1", app.referersData)
+	}
+	if _, ok := app.referersData[""]; ok {
+		t.Error("Direct traffic should not be listed as a domain")
+	}
+}
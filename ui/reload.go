@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/papaganelli/tailnginx/pkg/alert"
+	"github.com/papaganelli/tailnginx/pkg/exclude"
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+	"github.com/rivo/tview"
+)
+
+// ReloadRules replaces the highlight, exclude and alert rules while running,
+// e.g. after the configuration was reloaded on SIGHUP. Everything counted so
+// far is kept; the exclude rules apply to the lines read from now on, the
+// alerts of removed alert rules are cleared. A notice
+// lists the changed options that only apply after a restart. It is safe to
+// call from any goroutine.
+func (ta *TviewApp) ReloadRules(highlights highlight.Rules, excluded *exclude.Rules, alertRules []alert.Rule, restart []string) {
+	message := ta.reloadRules(highlights, excluded, alertRules, restart)
+	ta.app.QueueUpdateDraw(func() {
+		ta.flash(message)
+	})
+}
+
+// reloadRules replaces the rules and returns the notice of the reload.
+func (ta *TviewApp) reloadRules(highlights highlight.Rules, excluded *exclude.Rules, alertRules []alert.Rule, restart []string) string {
+	ta.exclude.Store(excluded)
+	ta.SetHighlightRules(highlights)
+	ta.checker.SetRules(alertRules)
+
+	message := "[green]Configuration reloaded[-::-]"
+	if len(restart) > 0 {
+		message += fmt.Sprintf(" [yellow]restart to apply %s[-::-]", tview.Escape(strings.Join(restart, ", ")))
+	}
+	return message
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/exclude"
+	"github.com/papaganelli/tailnginx/pkg/highlight"
+)
+
+// TestReloadRules tests that reloaded rules apply to the lines read from
+// then on and keep what was already counted.
+func TestReloadRules(t *testing.T) {
+	lines := make(chan string, 2)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	lines <- `127.0.0.1 - - [10/Oct/2025:13:55:36 +0000] "GET /healthz HTTP/1.1" 200 2 "-" "kube-probe/1.30"`
+	close(lines)
+	app.readLines()
+
+	rules, err := exclude.New([]string{`^/healthz$`}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	highlights, err := highlight.ParseAll([]string{"status>=500 -> red"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notice := app.reloadRules(highlights, rules, nil, []string{"-statsd"}); !strings.Contains(notice, "restart to apply -statsd") {
+		t.Errorf("notice = %q, want the options to restart for", notice)
+	}
+
+	lines = make(chan string, 2)
+	lines <- `127.0.0.1 - - [10/Oct/2025:13:55:37 +0000] "GET /healthz HTTP/1.1" 200 2 "-" "kube-probe/1.30"`
+	lines <- `127.0.0.1 - - [10/Oct/2025:13:55:37 +0000] "GET /shop HTTP/1.1" 500 612 "-" "curl/8.0"`
+	close(lines)
+	app.lines = lines
+	app.readLines()
+
+	if len(app.allVisitors) != 2 || app.allVisitors[0].Path != "/healthz" || app.allVisitors[1].Path != "/shop" {
+		t.Errorf("allVisitors = %+v, want the first /healthz and /shop", app.allVisitors)
+	}
+	if _, ok := app.highlights.Style(&app.allVisitors[1]); !ok {
+		t.Error("reloaded highlight rule should match the 500 response")
+	}
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/papaganelli/tailnginx/internal/state"
+)
+
+// Panel size weights are kept within this range when resizing.
+const (
+	minPanelWeight = 1
+	maxPanelWeight = 12
+)
+
+// rowWeight returns the height weight of row i of a view, as adjusted by the
+// user or from the view definition.
+func (ta *TviewApp) rowWeight(view dashboardView, i int) int {
+	if w, ok := ta.state.Layouts[view.name].Rows[i]; ok {
+		return w
+	}
+	return view.rows[i].weight
+}
+
+// columnWeight returns the width weight of a panel in a view, as adjusted by
+// the user or the number of columns it spans in the view definition.
+func (ta *TviewApp) columnWeight(view dashboardView, id string, span int) int {
+	if w, ok := ta.state.Layouts[view.name].Columns[id]; ok {
+		return w
+	}
+	return span
+}
+
+// focusedPosition returns the ID of the focused panel, the index of its row
+// in the current view and the number of columns it spans there.
+func (ta *TviewApp) focusedPosition() (id string, row, span int) {
+	for i, r := range ta.views[ta.viewIndex].rows {
+		for _, p := range r.panels {
+			if ta.panels[p] == ta.focused {
+				id, row = p, i
+				span++
+			}
+		}
+		if id != "" {
+			return id, row, span
+		}
+	}
+	return "", 0, 0
+}
+
+// resizeFocused grows or shrinks the focused panel: dHeight changes the
+// weight of its row, dWidth its weight within the row. The new sizes are
+// saved with the other preferences.
+// Must be called from the UI goroutine.
+func (ta *TviewApp) resizeFocused(dHeight, dWidth int) {
+	if ta.layoutCols > 0 {
+		ta.flash("[yellow]Panels cannot be resized[-::-] while the terminal is too narrow for the full layout")
+		return
+	}
+	id, row, span := ta.focusedPosition()
+	if id == "" {
+		ta.flash("[yellow]No panel selected[-::-] — use tab to select the panel to resize")
+		return
+	}
+
+	view := ta.views[ta.viewIndex]
+	layout := ta.state.Layouts[view.name]
+	if dHeight != 0 {
+		if layout.Rows == nil {
+			layout.Rows = make(map[int]int)
+		}
+		layout.Rows[row] = clampWeight(ta.rowWeight(view, row) + dHeight)
+	}
+	if dWidth != 0 {
+		if layout.Columns == nil {
+			layout.Columns = make(map[string]int)
+		}
+		layout.Columns[id] = clampWeight(ta.columnWeight(view, id, span) + dWidth)
+	}
+	if ta.state.Layouts == nil {
+		ta.state.Layouts = make(map[string]state.Layout)
+	}
+	ta.state.Layouts[view.name] = layout
+
+	// Tables fit their rows to the new panel heights on the next update
+	ta.rebuildViews()
+	ta.mu.Lock()
+	ta.dataChanged = true
+	ta.mu.Unlock()
+
+	if ta.statePath != "" {
+		if err := ta.state.Save(ta.statePath); err != nil {
+			ta.flash(fmt.Sprintf("[red]Saving layout failed:[-::-] %v", err))
+		}
+	}
+}
+
+// clampWeight keeps a panel weight within the allowed range.
+func clampWeight(w int) int {
+	return min(max(w, minPanelWeight), maxPanelWeight)
+}
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/internal/state"
+)
+
+// TestResizeFocused tests that the focused panel's row and column weights
+// change within bounds and are saved.
+func TestResizeFocused(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	app.SetState(&state.State{}, path)
+
+	// Nothing focused: nothing to resize
+	app.resizeFocused(1, 0)
+	if len(app.state.Layouts) != 0 {
+		t.Errorf("Layouts = %v without a focused panel, want none", app.state.Layouts)
+	}
+
+	view := app.views[app.viewIndex]
+	app.focused = app.pathsTable
+	app.resizeFocused(1, 0)
+	app.resizeFocused(0, 1)
+	if got := app.rowWeight(view, 0); got != 3 {
+		t.Errorf("row weight = %d, want 3", got)
+	}
+	if got := app.columnWeight(view, panelPaths, 1); got != 2 {
+		t.Errorf("column weight = %d, want 2", got)
+	}
+	if got := app.rowWeight(view, 1); got != 1 {
+		t.Errorf("other row weight = %d, want it unchanged", got)
+	}
+
+	for i := 0; i < 20; i++ {
+		app.resizeFocused(-1, -1)
+	}
+	if app.rowWeight(view, 0) != minPanelWeight || app.columnWeight(view, panelPaths, 1) != minPanelWeight {
+		t.Errorf("weights should stop at %d", minPanelWeight)
+	}
+
+	saved, err := state.Load(path)
+	if err != nil {
+		t.Fatalf("state.Load() error = %v", err)
+	}
+	if got := saved.Layouts[view.name].Columns[panelPaths]; got != minPanelWeight {
+		t.Errorf("saved paths column weight = %d, want %d", got, minPanelWeight)
+	}
+}
+
+// TestColumnWeightSpan tests that a panel repeated in a row starts from the
+// number of columns it spans.
+func TestColumnWeightSpan(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	app.views = []dashboardView{{
+		name: "Wide",
+		rows: []viewRow{{weight: 1, panels: []string{panelStatus, panelPaths, panelPaths}}},
+	}}
+	app.focused = app.pathsTable
+
+	if id, row, span := app.focusedPosition(); id != panelPaths || row != 0 || span != 2 {
+		t.Errorf("focusedPosition() = %s, %d, %d, want paths, 0, 2", id, row, span)
+	}
+	app.resizeFocused(0, 1)
+	if got := app.columnWeight(app.views[0], panelPaths, 2); got != 3 {
+		t.Errorf("column weight = %d, want 3", got)
+	}
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/papaganelli/tailnginx/pkg/alert"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// alertRetentionID is the alert raised when evicted entries fall inside the
+// active time window.
+const alertRetentionID = "retention"
+
+// SetRetention sets how many entries are kept in memory for the panels: at
+// most maxEntries (10,000 when 0) and, when maxBytes is positive, about
+// maxBytes of memory. The oldest entries are evicted first. Must be called
+// before Run.
+func (ta *TviewApp) SetRetention(maxEntries int, maxBytes int64) {
+	if maxEntries <= 0 {
+		maxEntries = maxVisitorsInMemory
+	}
+	ta.maxEntries = maxEntries
+	ta.maxBytes = maxBytes
+}
+
+// entrySize estimates the memory held by an entry in memory.
+func entrySize(v *parser.Visitor) int64 {
+	return int64(unsafe.Sizeof(*v)) + int64(len(v.IP)+len(v.Method)+len(v.Path)+len(v.Protocol)+
+		len(v.Referer)+len(v.Agent)+len(v.Country)+len(v.Host)+len(v.TLSProtocol)+len(v.TLSCipher))
+}
+
+// evict drops the oldest entries beyond the retention limits. Caller must
+// hold the lock.
+func (ta *TviewApp) evict() {
+	n := max(len(ta.allVisitors)-ta.maxEntries, 0)
+	var freed int64
+	for i := range n {
+		freed += entrySize(&ta.allVisitors[i])
+	}
+	// The newest entry is always kept
+	for ta.maxBytes > 0 && n < len(ta.allVisitors)-1 && ta.retainedBytes-freed > ta.maxBytes {
+		freed += entrySize(&ta.allVisitors[n])
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	ta.retainedBytes -= freed
+	ta.droppedUntil = ta.allVisitors[n-1].Time
+	ta.droppedEntries += n
+	ta.allVisitors = ta.allVisitors[n:]
+}
+
+// checkRetention raises an alert while entries inside the active time
+// window were evicted, since the panels then cover only part of it.
+func (ta *TviewApp) checkRetention(now time.Time) bool {
+	ta.mu.RLock()
+	droppedUntil, window := ta.droppedUntil, ta.timeWindow
+	ta.mu.RUnlock()
+
+	if window <= 0 || droppedUntil.IsZero() || !droppedUntil.After(now.Add(-window)) {
+		return ta.alerts.Clear(alertRetentionID)
+	}
+	ta.alerts.Fire(alert.Alert{
+		ID:       alertRetentionID,
+		Severity: alert.SeverityInfo,
+		Message:  fmt.Sprintf("memory limit reached: panels only cover the requests since %s, raise -max-entries or -max-memory-mb", ta.formatTime(droppedUntil)),
+		Window:   window,
+	})
+	return true
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestRetention tests that the oldest entries are evicted beyond the entry
+// and memory limits.
+func TestRetention(t *testing.T) {
+	now := time.Now()
+	batch := func(n int) []parser.Visitor {
+		b := make([]parser.Visitor, n)
+		for i := range b {
+			b[i] = parser.Visitor{Time: now.Add(time.Duration(i) * time.Second), IP: "192.0.2.1", Path: "/", Status: 200}
+		}
+		return b
+	}
+
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	app.SetRetention(100, 0)
+	app.processBatch(batch(150))
+	if len(app.allVisitors) != 100 || app.droppedEntries != 50 {
+		t.Errorf("kept %d and dropped %d entries, want 100 and 50", len(app.allVisitors), app.droppedEntries)
+	}
+	if want := now.Add(49 * time.Second); !app.droppedUntil.Equal(want) {
+		t.Errorf("droppedUntil = %v, want the time of the 50th entry %v", app.droppedUntil, want)
+	}
+
+	size := entrySize(&parser.Visitor{IP: "192.0.2.1", Path: "/"})
+	app = NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	app.SetRetention(0, 10*size)
+	app.processBatch(batch(25))
+	if len(app.allVisitors) != 10 || app.droppedEntries != 15 {
+		t.Errorf("kept %d and dropped %d entries, want 10 and 15", len(app.allVisitors), app.droppedEntries)
+	}
+	if app.retainedBytes != 10*size {
+		t.Errorf("retainedBytes = %d, want %d", app.retainedBytes, 10*size)
+	}
+}
+
+// TestRetentionAlert tests that an alert is raised while evicted entries
+// fall inside the active time window.
+func TestRetentionAlert(t *testing.T) {
+	now := time.Now()
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+
+	app.droppedUntil = now.Add(-time.Hour)
+	if app.checkRetention(now); len(app.alerts.Active()) != 0 {
+		t.Error("no alert expected with the all time window")
+	}
+
+	app.timeWindow = 5 * time.Minute
+	if app.checkRetention(now); len(app.alerts.Active()) != 0 {
+		t.Error("no alert expected when evicted entries are older than the window")
+	}
+
+	app.droppedUntil = now.Add(-time.Minute)
+	app.checkRetention(now)
+	if active := app.alerts.Active(); len(active) != 1 || active[0].ID != alertRetentionID {
+		t.Errorf("active alerts = %+v, want the retention alert", active)
+	}
+}
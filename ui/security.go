@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/abuse"
+	"github.com/papaganelli/tailnginx/pkg/attack"
+	"github.com/papaganelli/tailnginx/pkg/reputation"
+	"github.com/rivo/tview"
+)
+
+// reasonLabels are the labels of the reasons IPs are flagged for.
+var reasonLabels = map[string]string{
+	abuse.ReasonRequests: "[yellow]flood[-::-]",
+	abuse.ReasonErrors:   "[yellow]4xx[-::-]",
+	abuse.ReasonScan:     "[red]404 scan[-::-]",
+}
+
+// attackLabels are the labels of the kinds of attack attempted.
+var attackLabels = map[string]string{
+	attack.SQLi:      "[red]SQLi[-::-]",
+	attack.XSS:       "[red]XSS[-::-]",
+	attack.Traversal: "[red]traversal[-::-]",
+	attack.Log4Shell: "[red]log4shell[-::-]",
+}
+
+// SetReputation sets the checker of the threat intelligence about the IPs
+// listed in the Security panel. Must be called before Run.
+func (ta *TviewApp) SetReputation(c *reputation.Checker) {
+	ta.reputation = c
+}
+
+// formatReputation formats the AbuseIPDB score of an IP, colored by how
+// likely it is abusive, and the blocklists listing it.
+func formatReputation(r reputation.Reputation) string {
+	var parts []string
+	switch {
+	case r.Score < 0:
+		if len(r.Lists) == 0 {
+			parts = append(parts, "[::d]-[-::-]")
+		}
+	case r.Score >= 75:
+		parts = append(parts, fmt.Sprintf("[red]%d%%[-::-]", r.Score))
+	case r.Score >= 25:
+		parts = append(parts, fmt.Sprintf("[yellow]%d%%[-::-]", r.Score))
+	default:
+		parts = append(parts, fmt.Sprintf("[green]%d%%[-::-]", r.Score))
+	}
+	for _, list := range r.Lists {
+		parts = append(parts, "[red]"+tview.Escape(list)+"[-::-]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// securityRow is a row of the Security panel: an IP flagged by the abuse
+// detector or attempting attacks, with the kinds of attack and tools used.
+type securityRow struct {
+	ip       string
+	reason   string
+	requests int
+	errors   int
+	paths    int
+	time     time.Time
+}
+
+// renderSecurity renders the IPs flagged by the abuse detector, e.g. those
+// brute forcing directories, and the IPs whose requests matched an attack
+// signature or came from an offensive tool such as sqlmap in the last hour,
+// most recent first. The deny list key blocks
+// the flagged IPs. With threat intelligence, their reputation is looked up.
+func (ta *TviewApp) renderSecurity() {
+	table := ta.securityTable
+	table.Clear()
+
+	var rows []securityRow
+	for _, o := range ta.offenders.Flagged() {
+		rows = append(rows, securityRow{o.IP, reasonLabels[o.Reason], o.Requests, o.Errors, o.Paths, o.Time})
+	}
+	for _, a := range ta.attacks.Attempts() {
+		labels := make([]string, 0, len(a.Kinds)+len(a.Tools))
+		for _, kind := range a.Kinds {
+			labels = append(labels, attackLabels[kind])
+		}
+		for _, tool := range a.Tools {
+			labels = append(labels, "[fuchsia]"+tview.Escape(tool)+"[-::-]")
+		}
+		rows = append(rows, securityRow{a.IP, strings.Join(labels, ","), a.Requests, a.Errors, a.Paths, a.Time})
+	}
+	if len(rows) == 0 {
+		message := "[::d]No IPs flagged or attacks in the last hour[-::-]"
+		if ta.offenders == nil {
+			message = "[::d]Abuse detection is off, no attacks in the last hour[-::-]"
+		}
+		table.SetCell(0, 0, tview.NewTableCell(message))
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].time.After(rows[j].time) })
+
+	headers := []string{"IP", "Reason", "Requests", "4xx", "Paths", "Flagged"}
+	if ta.reputation != nil {
+		headers = slices.Insert(headers, 5, "Reputation")
+	}
+	setHeader(table, headers...)
+	table.GetCell(0, 1).SetAlign(tview.AlignLeft)
+	for i, r := range rows[:min(len(rows), ta.topLimit(table, 1))] {
+		row := i + 1
+		paths := "[::d]-[-::-]"
+		if r.paths > 0 {
+			paths = fmt.Sprintf("[cyan]%d[-::-]", r.paths)
+		}
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("[white]%s[-::-]", tview.Escape(r.ip))).
+				SetAlign(tview.AlignLeft).
+				SetReference(r.ip))
+		table.SetCell(row, 1, tview.NewTableCell(r.reason).SetAlign(tview.AlignLeft))
+		table.SetCell(row, 2, tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", r.requests)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 3, tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", r.errors)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 4, tview.NewTableCell(paths).SetAlign(tview.AlignRight))
+		col := 5
+		if ta.reputation != nil {
+			table.SetCell(row, col, tview.NewTableCell(formatReputation(ta.reputation.Lookup(r.ip))).SetAlign(tview.AlignRight))
+			col++
+		}
+		table.SetCell(row, col,
+			tview.NewTableCell(fmt.Sprintf("[::d]%s[-::-]", ta.formatTime(r.time))).
+				SetAlign(tview.AlignRight).
+				SetExpansion(1))
+	}
+}
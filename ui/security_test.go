@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/abuse"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/reputation"
+)
+
+// TestRenderSecurity tests that the IPs scanning for missing paths, those
+// attempting attacks and those using offensive tools are listed in the
+// Security panel.
+func TestRenderSecurity(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	app.topN = 10
+
+	app.renderSecurity()
+	if cell := app.securityTable.GetCell(0, 0); !strings.Contains(cell.Text, "off") {
+		t.Errorf("cell = %q, want abuse detection off", cell.Text)
+	}
+
+	d, err := abuse.NewDetector(abuse.Thresholds{Scan: 2, Window: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetOffenders(d)
+	app.renderSecurity()
+	if cell := app.securityTable.GetCell(0, 0); !strings.Contains(cell.Text, "No IPs flagged") {
+		t.Errorf("cell = %q, want no IPs flagged", cell.Text)
+	}
+
+	for _, path := range []string{"/.env", "/.git/config", "/backup.zip"} {
+		d.Observe(&parser.Visitor{IP: "203.0.113.7", Path: path, Status: 404})
+	}
+	app.renderSecurity()
+	if ip, _ := app.securityTable.GetCell(1, 0).GetReference().(string); ip != "203.0.113.7" {
+		t.Errorf("row IP = %q, want 203.0.113.7", ip)
+	}
+	if reason := app.securityTable.GetCell(1, 1).Text; !strings.Contains(reason, "404 scan") {
+		t.Errorf("reason = %q, want 404 scan", reason)
+	}
+	if paths := app.securityTable.GetCell(1, 4).Text; !strings.Contains(paths, "3") {
+		t.Errorf("paths = %q, want 3", paths)
+	}
+
+	// Attack attempts are listed too, most recent first
+	time.Sleep(time.Millisecond)
+	app.attacks.Observe(&parser.Visitor{IP: "198.51.100.2", Path: "/?id=1%20UNION%20SELECT%20password", Status: 403})
+	app.attacks.Observe(&parser.Visitor{IP: "198.51.100.2", Path: "/?f=../../etc/passwd", Status: 200})
+	app.renderSecurity()
+	if ip, _ := app.securityTable.GetCell(1, 0).GetReference().(string); ip != "198.51.100.2" {
+		t.Errorf("row IP = %q, want the attacker first", ip)
+	}
+	if reason := app.securityTable.GetCell(1, 1).Text; !strings.Contains(reason, "SQLi") || !strings.Contains(reason, "traversal") {
+		t.Errorf("reason = %q, want SQLi and traversal", reason)
+	}
+	if errors := app.securityTable.GetCell(1, 3).Text; !strings.Contains(errors, "1") {
+		t.Errorf("4xx = %q, want 1", errors)
+	}
+
+	// So are the requests of offensive tools, whatever their paths
+	time.Sleep(time.Millisecond)
+	app.attacks.Observe(&parser.Visitor{IP: "192.0.2.9", Path: "/", Agent: "Mozilla/5.00 (Nikto/2.1.6) (Evasions:None)", Status: 200})
+	app.renderSecurity()
+	if reason := app.securityTable.GetCell(1, 1).Text; !strings.Contains(reason, "Nikto") {
+		t.Errorf("reason = %q, want Nikto", reason)
+	}
+}
+
+func TestSecurityReputation(t *testing.T) {
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	app.topN = 10
+	path := filepath.Join(t.TempDir(), "firehol_level1.netset")
+	if err := os.WriteFile(path, []byte("203.0.113.0/24\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := reputation.LoadBlocklist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetReputation(reputation.NewChecker("", []*reputation.Blocklist{list}))
+
+	app.attacks.Observe(&parser.Visitor{IP: "203.0.113.7", Path: "/?q=<script>", Status: 200})
+	app.attacks.Observe(&parser.Visitor{IP: "198.51.100.2", Path: "/../../etc/passwd", Status: 400})
+	app.renderSecurity()
+	if header := app.securityTable.GetCell(0, 5).Text; !strings.Contains(header, "Reputation") {
+		t.Fatalf("header = %q, want a Reputation column", header)
+	}
+	for row := 1; row <= 2; row++ {
+		ip, _ := app.securityTable.GetCell(row, 0).GetReference().(string)
+		rep := app.securityTable.GetCell(row, 5).Text
+		if listed := strings.Contains(rep, "firehol_level1"); listed != (ip == "203.0.113.7") {
+			t.Errorf("reputation of %s = %q", ip, rep)
+		}
+	}
+}
+
+func TestFormatReputation(t *testing.T) {
+	tests := []struct {
+		r    reputation.Reputation
+		want string
+	}{
+		{reputation.Reputation{Score: -1}, "[::d]-[-::-]"},
+		{reputation.Reputation{Score: 92}, "[red]92%[-::-]"},
+		{reputation.Reputation{Score: 30}, "[yellow]30%[-::-]"},
+		{reputation.Reputation{Score: 0, Lists: []string{"spamhaus_drop"}}, "[green]0%[-::-] [red]spamhaus_drop[-::-]"},
+		{reputation.Reputation{Score: -1, Lists: []string{"spamhaus_drop"}}, "[red]spamhaus_drop[-::-]"},
+	}
+	for _, tt := range tests {
+		if got := formatReputation(tt.r); got != tt.want {
+			t.Errorf("formatReputation(%+v) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
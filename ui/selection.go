@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/papaganelli/tailnginx/pkg/clipboard"
+	"github.com/rivo/tview"
+)
+
+// flashDuration is how long a footer notification stays visible.
+const flashDuration = 3 * time.Second
+
+// focusColor highlights the border of the focused panel.
+var focusColor = tcell.NewRGBColor(250, 204, 21) // Yellow 400
+
+// focusOrder returns the tables of the current view that can be focused with
+// Tab, in order.
+func (ta *TviewApp) focusOrder() []*tview.Table {
+	var tables []*tview.Table
+	for _, id := range ta.currentPanels() {
+		if table, ok := ta.panels[id].(*tview.Table); ok {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// cycleFocus moves table focus forward (delta 1) or backward (delta -1).
+// Cycling past the last table clears the focus.
+func (ta *TviewApp) cycleFocus(delta int) {
+	tables := ta.focusOrder()
+
+	current := -1
+	for i, t := range tables {
+		if t == ta.focused {
+			current = i
+			break
+		}
+	}
+
+	// The position after the last table means "nothing focused"
+	if current < 0 {
+		current = len(tables)
+	}
+	next := (current + delta + len(tables) + 1) % (len(tables) + 1)
+
+	if ta.focused != nil {
+		ta.focused.SetSelectable(false, false).SetBorderColor(ta.borderColor)
+	}
+	if next == len(tables) {
+		ta.focused = nil
+		ta.app.SetFocus(ta.grid)
+	} else {
+		ta.focused = tables[next]
+		ta.focused.SetBorderColor(focusColor)
+		ta.app.SetFocus(ta.focused)
+	}
+
+	// The focused table lists every entry, the others only their top N
+	ta.mu.RLock()
+	defer ta.mu.RUnlock()
+	ta.renderAll()
+}
+
+// selectedValue returns the full (untruncated) value of the selected row in
+// the focused table or country drill-down, or the current line in the raw
+// log viewer.
+func (ta *TviewApp) selectedValue() string {
+	if ta.page == pageRaw {
+		return ta.selectedRawLine()
+	}
+	table := ta.focused
+	if ta.page == pageCountry {
+		table = ta.countryTable
+	}
+	if table == nil {
+		return ""
+	}
+	row, _ := table.GetSelection()
+	cell := table.GetCell(row, 0)
+	if value, ok := cell.GetReference().(string); ok {
+		return value
+	}
+	return ""
+}
+
+// selectedRawLine returns the current search match in the raw viewer, or the
+// most recent line when there is no match.
+func (ta *TviewApp) selectedRawLine() string {
+	ta.mu.RLock()
+	defer ta.mu.RUnlock()
+
+	if len(ta.rawLines) == 0 {
+		return ""
+	}
+	if ta.rawMatch >= 0 {
+		if i := ta.rawMatch - (ta.rawSeq - len(ta.rawLines)); i >= 0 && i < len(ta.rawLines) {
+			return ta.rawLines[i]
+		}
+	}
+	return ta.rawLines[len(ta.rawLines)-1]
+}
+
+// copySelection copies the selected entry to the system clipboard.
+func (ta *TviewApp) copySelection() {
+	value := ta.selectedValue()
+	if value == "" {
+		ta.flash("[yellow]Nothing selected[-::-] — use tab to select a table row")
+		return
+	}
+
+	method, err := clipboard.Copy(value)
+	if err != nil {
+		ta.flash(fmt.Sprintf("[red]Copy failed:[-::-] %v", err))
+		return
+	}
+
+	display := value
+	if len(display) > 60 {
+		display = display[:57] + "..."
+	}
+	ta.flash(fmt.Sprintf("[green]Copied[-::-] %s [::d](%s)[-::-]", tview.Escape(display), method))
+}
+
+// flash shows a temporary notification in the footer.
+// Must be called from the UI goroutine.
+func (ta *TviewApp) flash(message string) {
+	ta.flashSeq++
+	seq := ta.flashSeq
+	ta.footer.SetText(message)
+
+	time.AfterFunc(flashDuration, func() {
+		ta.app.QueueUpdateDraw(func() {
+			if ta.flashSeq == seq {
+				ta.footer.SetText(ta.helpText())
+			}
+		})
+	})
+}
+
+// ShowError shows an error in the footer, e.g. from a background metrics
+// output. It is safe to call from any goroutine.
+func (ta *TviewApp) ShowError(err error) {
+	message := fmt.Sprintf("[red]Error:[-::-] %s", tview.Escape(err.Error()))
+	ta.app.QueueUpdateDraw(func() {
+		ta.flash(message)
+	})
+}
+
+// helpText returns the footer help for the current page.
+func (ta *TviewApp) helpText() string {
+	if ta.accessView != nil {
+		return accessibleHelp
+	}
+	if ta.page == pageRaw {
+		return rawHelp
+	}
+	if ta.page == pageGeo {
+		return geoHelp
+	}
+	if ta.page == pagePanels {
+		return panelsHelp
+	}
+	if ta.page == pageCountry {
+		return countryHelp
+	}
+	if ta.page == pageStatus {
+		return statusHelp
+	}
+	return ta.help
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// TestCycleFocus tests Tab focus cycling across tables.
+func TestCycleFocus(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	app.statusCodes = map[int]int{200: 3}
+	tables := app.focusOrder()
+
+	app.cycleFocus(1)
+	if app.focused != tables[0] || tables[0] != app.statusTable {
+		t.Fatalf("cycleFocus(1) from nothing should focus the first table")
+	}
+	if selectable, _ := app.focused.GetSelectable(); !selectable {
+		t.Error("focused table should have selectable rows")
+	}
+
+	app.cycleFocus(-1)
+	if app.focused != nil {
+		t.Fatalf("cycleFocus(-1) from the first table should clear focus")
+	}
+
+	app.cycleFocus(-1)
+	if app.focused != tables[len(tables)-1] {
+		t.Fatalf("cycleFocus(-1) from nothing should focus the last table")
+	}
+	app.cycleFocus(1)
+	if app.focused != nil {
+		t.Fatalf("cycleFocus(1) from the last table should clear focus")
+	}
+	if selectable, _ := tables[len(tables)-1].GetSelectable(); selectable {
+		t.Error("unfocused table should not be selectable")
+	}
+}
+
+// TestFocusEmptyTable tests that moving the selection of a focused table
+// without rows does not hang, whether it was empty when focused or emptied
+// since.
+func TestFocusEmptyTable(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	app.cycleFocus(1)
+	if selectable, _ := app.focused.GetSelectable(); selectable {
+		t.Error("focused table with only its header should not be selectable")
+	}
+	pressKeys(t, app.focused)
+
+	app.statusCodes = map[int]int{200: 3, 404: 2, 500: 1}
+	app.renderAll()
+	app.focused.Select(3, 0)
+	if selectable, _ := app.focused.GetSelectable(); !selectable {
+		t.Fatal("focused table with rows should be selectable")
+	}
+	app.statusCodes = map[int]int{}
+	app.renderAll()
+	pressKeys(t, app.focused)
+}
+
+// pressKeys sends the keys moving the selection to a table, failing the test
+// if it does not handle them within a second.
+func pressKeys(t *testing.T, table *tview.Table) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, key := range []tcell.Key{tcell.KeyDown, tcell.KeyUp, tcell.KeyPgDn, tcell.KeyPgUp, tcell.KeyHome, tcell.KeyEnd} {
+			table.InputHandler()(tcell.NewEventKey(key, 0, tcell.ModNone), func(tview.Primitive) {})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("moving the selection of a table without rows hangs")
+	}
+}
+
+// TestSelectedValue tests that selection returns the untruncated value.
+func TestSelectedValue(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	if got := app.selectedValue(); got != "" {
+		t.Errorf("selectedValue() without focus = %q, want empty", got)
+	}
+
+	longPath := "/api/v1/some/really/long/path/that/gets/truncated/in/the/table"
+	app.pathsData = map[string]int{longPath: 3, "/": 1}
+	app.renderPaths()
+	app.focused = app.pathsTable
+	app.pathsTable.SetSelectable(true, false).Select(1, 0)
+
+	if got := app.selectedValue(); got != longPath {
+		t.Errorf("selectedValue() = %q, want %q", got, longPath)
+	}
+
+	// Raw viewer selects the current match or the latest line
+	app.page = pageRaw
+	app.appendRaw([]string{"first", "second", "third"})
+	if got := app.selectedValue(); got != "third" {
+		t.Errorf("selectedValue() in raw view = %q, want %q", got, "third")
+	}
+	app.rawMatch = 1
+	if got := app.selectedValue(); got != "second" {
+		t.Errorf("selectedValue() with match = %q, want %q", got, "second")
+	}
+}
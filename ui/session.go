@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"slices"
+	"time"
+
+	"github.com/papaganelli/tailnginx/internal/state"
+)
+
+// restoreSession shows what the last session ended with: the view, time
+// window, filters and display mode. Values that no longer exist, e.g. a
+// renamed view, are ignored.
+func (ta *TviewApp) restoreSession(s state.Session) {
+	for i, view := range ta.views {
+		if view.name == s.View {
+			ta.viewIndex = i
+		}
+	}
+	if i := slices.Index(timeWindowPresets, s.WindowMinutes); i >= 0 {
+		ta.timeWindowIndex = i
+		ta.timeWindow = time.Duration(s.WindowMinutes) * time.Minute
+	}
+	if i := slices.Index(displayModeNames, s.DisplayMode); i >= 0 {
+		ta.displayMode = displayMode(i)
+	}
+	if class, code, err := parseStatusFilter(s.Status); err == nil {
+		ta.statusFilter, ta.statusCode = class, code
+	}
+	if network, err := parseNetwork(s.IP); err == nil {
+		ta.ipFilter = network
+	}
+	ta.pathFilter = s.Path
+}
+
+// session returns what the dashboard shows, to restore it in the next
+// session.
+func (ta *TviewApp) session() state.Session {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	s := state.Session{
+		View:          ta.views[ta.viewIndex].name,
+		WindowMinutes: int(ta.timeWindow / time.Minute),
+		Status:        ta.statusText(),
+		Path:          ta.pathFilter,
+		DisplayMode:   displayModeNames[ta.displayMode],
+	}
+	if ta.ipFilter != nil {
+		s.IP = ta.ipFilter.String()
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/internal/state"
+)
+
+// TestSessionRestore tests that a session starts with what the last one
+// ended with.
+func TestSessionRestore(t *testing.T) {
+	saved := state.Session{
+		View:          "Traffic",
+		WindowMinutes: 60,
+		Status:        "404",
+		Path:          "/api",
+		IP:            "192.0.2.0/24",
+		DisplayMode:   "bars",
+	}
+
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	app.SetState(&state.State{Session: saved}, "")
+
+	if app.views[app.viewIndex].name != "Traffic" {
+		t.Errorf("view = %q, want Traffic", app.views[app.viewIndex].name)
+	}
+	if app.timeWindow != time.Hour || timeWindowPresets[app.timeWindowIndex] != 60 {
+		t.Errorf("timeWindow = %v (preset %d), want 1h", app.timeWindow, app.timeWindowIndex)
+	}
+	if app.statusFilter != 4 || app.statusCode != 404 || app.displayMode != displayBars {
+		t.Errorf("status filter = %d/%d, display mode = %d", app.statusFilter, app.statusCode, app.displayMode)
+	}
+	if got := app.session(); got != saved {
+		t.Errorf("session() = %+v, want %+v", got, saved)
+	}
+
+	// Filters given on the command line replace the saved ones
+	if err := app.SetFilters(Filters{Status: "5xx"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := app.session(); got.Status != "5xx" || got.Path != "" || got.IP != "" {
+		t.Errorf("session() = %+v after SetFilters, want only 5xx", got)
+	}
+}
+
+// TestSessionRestoreUnknown tests that stale values are ignored.
+func TestSessionRestoreUnknown(t *testing.T) {
+	app := NewTviewApp(make(chan string), "/test.log", time.Second, nil)
+	app.SetState(&state.State{Session: state.Session{View: "Gone", WindowMinutes: 7, Status: "9xx", IP: "nope", DisplayMode: "pie"}}, "")
+
+	want := state.Session{View: "Dashboard", DisplayMode: "counts"}
+	if got := app.session(); got != want {
+		t.Errorf("session() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// selectStatus applies the status filter for a class key (2-5). The first
+// press filters the whole class; pressing the same key again steps through
+// the exact codes seen in that class, then back to the whole class.
+// Caller must hold the lock.
+func (ta *TviewApp) selectStatus(class int) {
+	if ta.statusFilter != class {
+		ta.statusFilter = class
+		ta.statusCode = 0
+		return
+	}
+
+	codes := make([]int, 0, len(ta.classCodes))
+	for code := range ta.classCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	// classCodes only holds codes of the current class; take the one after
+	// the selected code, or the first when none is selected yet
+	next := 0
+	for _, code := range codes {
+		if code > ta.statusCode {
+			next = code
+			break
+		}
+	}
+	ta.statusCode = next
+}
+
+// Size of the status code prompt
+const (
+	statusPromptWidth  = 44
+	statusPromptHeight = 3
+)
+
+// initStatusPrompt creates the prompt for an exact status code filter, shown
+// over the dashboard.
+func (ta *TviewApp) initStatusPrompt(borderColor, titleColor, fieldBg tcell.Color) tview.Primitive {
+	ta.statusPrompt = tview.NewInputField().
+		SetLabel(" Code: ").
+		SetPlaceholder("e.g. 429, empty for all").
+		SetFieldBackgroundColor(fieldBg).
+		SetAcceptanceFunc(func(text string, _ rune) bool {
+			return len(text) <= 3 && tview.InputFieldInteger(text, 0)
+		})
+	ta.statusPrompt.SetBorder(true).
+		SetTitle("🔢 Status code").
+		SetBorderColor(borderColor).
+		SetTitleColor(titleColor)
+
+	ta.statusPrompt.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			if err := ta.filterStatusCode(ta.statusPrompt.GetText()); err != nil {
+				ta.flash(fmt.Sprintf("[red]Invalid status code:[-::-] %v", err))
+				return
+			}
+		}
+		ta.showStatusPrompt(false)
+	})
+
+	return modal(ta.statusPrompt, statusPromptWidth, statusPromptHeight)
+}
+
+// showStatusPrompt opens or closes the status code prompt.
+func (ta *TviewApp) showStatusPrompt(show bool) {
+	ta.mu.Lock()
+	if show {
+		ta.page = pageStatus
+	} else {
+		ta.page = pageDashboard
+	}
+	code := ta.statusCode
+	ta.mu.Unlock()
+
+	if show {
+		text := ""
+		if code > 0 {
+			text = strconv.Itoa(code)
+		}
+		ta.statusPrompt.SetText(text)
+		ta.pages.ShowPage(pageStatus)
+		ta.pages.SendToFront(pageStatus)
+		ta.footer.SetText(statusHelp)
+		ta.app.SetFocus(ta.statusPrompt)
+		return
+	}
+	ta.pages.HidePage(pageStatus)
+	ta.footer.SetText(ta.help)
+	if ta.focused != nil {
+		ta.app.SetFocus(ta.focused)
+	} else {
+		ta.app.SetFocus(ta.grid)
+	}
+}
+
+// filterStatusCode filters the panels by an exact status code such as
+// "429", or clears the status filter when text is empty.
+func (ta *TviewApp) filterStatusCode(text string) error {
+	code := 0
+	if text != "" {
+		var err error
+		if code, err = strconv.Atoi(text); err != nil || code < 100 || code > 599 {
+			return fmt.Errorf("%q is not between 100 and 599", text)
+		}
+	}
+
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+	ta.statusFilter = code / 100
+	ta.statusCode = code
+	ta.applyFilters()
+	ta.dataChanged = true
+	return nil
+}
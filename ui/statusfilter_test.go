@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestSelectStatus tests stepping from a status class to its exact codes.
+func TestSelectStatus(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.allVisitors = []parser.Visitor{
+		{Time: now, Status: 200},
+		{Time: now, Status: 404},
+		{Time: now, Status: 404},
+		{Time: now, Status: 401},
+		{Time: now, Status: 429},
+	}
+
+	press := func(class int) {
+		app.selectStatus(class)
+		app.applyFilters()
+	}
+
+	steps := []struct {
+		class     int
+		wantCode  int
+		wantCount int
+	}{
+		{4, 0, 4},   // Whole 4xx class
+		{4, 401, 1}, // Then each exact code in order
+		{4, 404, 2},
+		{4, 429, 1},
+		{4, 0, 4}, // Back to the class
+		{2, 0, 1}, // Another class resets the exact code
+	}
+	for i, step := range steps {
+		press(step.class)
+		if app.statusCode != step.wantCode || len(app.visitors) != step.wantCount {
+			t.Errorf("step %d: statusCode = %d with %d visitors, want %d with %d",
+				i, app.statusCode, len(app.visitors), step.wantCode, step.wantCount)
+		}
+	}
+}
+
+// TestStatusPanelKeepsClass tests that the Status panel lists all codes of
+// the class while a single code is selected.
+func TestStatusPanelKeepsClass(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.allVisitors = []parser.Visitor{
+		{Time: now, Status: 404},
+		{Time: now, Status: 403},
+		{Time: now, Status: 200},
+	}
+	app.statusFilter = 4
+	app.statusCode = 404
+	app.applyFilters()
+	app.updateData()
+
+	if len(app.visitors) != 1 {
+		t.Errorf("visitors = %d, want 1", len(app.visitors))
+	}
+	if app.statusCodes[403] != 1 || app.statusCodes[404] != 1 || app.statusCodes[200] != 0 {
+		t.Errorf("statusCodes = %v, want 403 and 404 only", app.statusCodes)
+	}
+}
+
+// TestFilterStatusCode tests filtering by a status code typed in the prompt.
+func TestFilterStatusCode(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.allVisitors = []parser.Visitor{
+		{Time: now, Status: 200},
+		{Time: now, Status: 429},
+		{Time: now, Status: 404},
+	}
+
+	if err := app.filterStatusCode("429"); err != nil {
+		t.Fatalf("filterStatusCode(429) error = %v", err)
+	}
+	if app.statusFilter != 4 || app.statusCode != 429 || len(app.visitors) != 1 {
+		t.Errorf("filter = %d/%d with %d visitors, want 4/429 with 1",
+			app.statusFilter, app.statusCode, len(app.visitors))
+	}
+
+	for _, text := range []string{"99", "600", "4x"} {
+		if err := app.filterStatusCode(text); err == nil {
+			t.Errorf("filterStatusCode(%q) should fail", text)
+		}
+	}
+	if app.statusCode != 429 {
+		t.Errorf("invalid code changed the filter to %d", app.statusCode)
+	}
+
+	if err := app.filterStatusCode(""); err != nil || app.statusFilter != 0 || len(app.visitors) != 3 {
+		t.Errorf("empty code = %v, filter %d with %d visitors, want all", err, app.statusFilter, len(app.visitors))
+	}
+}
@@ -0,0 +1,272 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/rivo/tview"
+)
+
+// Live stream column names, as accepted by SetStreamColumns
+const (
+	columnTime    = "time"
+	columnIP      = "ip"
+	columnMethod  = "method"
+	columnPath    = "path"
+	columnStatus  = "status"
+	columnBytes   = "bytes"
+	columnLatency = "latency"
+	columnReferer = "referer"
+)
+
+// defaultStreamColumns are the live stream columns shown unless configured.
+var defaultStreamColumns = []string{
+	columnTime, columnIP, columnMethod, columnPath, columnStatus, columnBytes, columnLatency, columnReferer,
+}
+
+// streamDropOrder lists the columns left out first when the panel is too
+// narrow for the configured set.
+var streamDropOrder = []string{columnReferer, columnLatency, columnBytes, columnIP}
+
+// minFlexWidth is the narrowest a flexible column (path, referer) gets
+// before other columns are dropped.
+const minFlexWidth = 12
+
+// streamColumn describes a live stream column. Fixed columns are padded to
+// width; flexible ones (width 0) share the space left on the line.
+type streamColumn struct {
+	width int
+	right bool   // right-aligned
+	color string // color tag for lines without a highlight rule
+	value func(v *parser.Visitor) string
+}
+
+// streamColumns are the available live stream columns by name.
+var streamColumns = map[string]streamColumn{
+	columnTime: {width: 8, color: "::d", value: func(v *parser.Visitor) string {
+		return v.Time.Format("15:04:05")
+	}},
+	columnIP: {width: 15, value: func(v *parser.Visitor) string {
+		return v.IP
+	}},
+	columnMethod: {width: 7, color: "yellow", value: func(v *parser.Visitor) string {
+		return v.Method
+	}},
+	columnPath: {value: func(v *parser.Visitor) string {
+		return v.Path
+	}},
+	columnStatus: {width: 3, color: "cyan", value: func(v *parser.Visitor) string {
+		return strconv.Itoa(v.Status)
+	}},
+	columnBytes: {width: 9, right: true, color: "::d", value: func(v *parser.Visitor) string {
+		return formatBytes(int64(v.Bytes))
+	}},
+	columnLatency: {width: 7, right: true, value: func(v *parser.Visitor) string {
+		return formatLatency(v.RequestTime)
+	}},
+	columnReferer: {color: "::d", value: func(v *parser.Visitor) string {
+		if v.Referer == "" {
+			return "-"
+		}
+		return v.Referer
+	}},
+}
+
+// SetStreamColumns sets the live stream columns, in display order.
+func (ta *TviewApp) SetStreamColumns(columns []string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("no stream columns given")
+	}
+	for _, name := range columns {
+		if _, ok := streamColumns[name]; !ok {
+			return fmt.Errorf("unknown stream column %q (available: %s)", name, strings.Join(defaultStreamColumns, ","))
+		}
+	}
+	ta.streamColumns = columns
+	return nil
+}
+
+// formatLatency formats a request time, or "-" when it was not logged.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	default:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+}
+
+// latencyColor returns the color of a request time: yellow from 300ms and
+// red from one second.
+func latencyColor(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return "red"
+	case d >= 300*time.Millisecond:
+		return "yellow"
+	default:
+		return ""
+	}
+}
+
+// fitText truncates s to width, marking the cut with "...". Width 0 or
+// less leaves s untouched.
+func fitText(s string, width int) string {
+	if width <= 0 || len(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return s[:width]
+	}
+	return s[:width-3] + "..."
+}
+
+// streamLayout returns the columns that fit in width and the width of each,
+// with timeWidth for the time column.
+// Columns are dropped in streamDropOrder until the flexible ones get at
+// least minFlexWidth; the space left goes to the path, with up to a third
+// for the referer. Width 0 (not drawn yet) keeps every column with flexible
+// columns unbounded.
+func streamLayout(columns []string, width, timeWidth int) ([]string, []int) {
+	columnWidth := func(name string) int {
+		if name == columnTime {
+			return timeWidth
+		}
+		return streamColumns[name].width
+	}
+	need := func(columns []string) int {
+		total := len(columns) - 1 // separators
+		for _, name := range columns {
+			if w := columnWidth(name); w > 0 {
+				total += w
+			} else {
+				total += minFlexWidth
+			}
+		}
+		return total
+	}
+
+	for _, drop := range streamDropOrder {
+		if width <= 0 || need(columns) <= width {
+			break
+		}
+		columns = without(columns, drop)
+	}
+
+	free := 0
+	if width > 0 {
+		free = width - need(columns)
+	}
+	both := contains(columns, columnPath) && contains(columns, columnReferer)
+	share := 0
+	if both {
+		share = free / 3
+	}
+	widths := make([]int, len(columns))
+	for i, name := range columns {
+		widths[i] = columnWidth(name)
+		if widths[i] > 0 || width <= 0 {
+			continue
+		}
+		if name == columnReferer && both {
+			widths[i] = minFlexWidth + share
+		} else {
+			widths[i] = minFlexWidth + free - share
+		}
+	}
+	return columns, widths
+}
+
+// streamLines formats entries as live stream lines fitting width. The
+// latency column is left out while none of the entries has a request time
+// (the log format has no $request_time).
+func (ta *TviewApp) streamLines(entries []parser.Visitor, width int) []string {
+	columns := ta.streamColumns
+	if !hasLatency(entries) {
+		columns = without(columns, columnLatency)
+	}
+	columns, widths := streamLayout(columns, width, ta.timeWidth())
+
+	lines := make([]string, 0, len(entries))
+	for i := range entries {
+		v := &entries[i]
+		style, highlighted := ta.highlights.Style(v)
+
+		var b strings.Builder
+		if highlighted {
+			// Highlighted lines use a single style for the whole line
+			b.WriteString(style.Tag())
+		}
+		for j, name := range columns {
+			column := streamColumns[name]
+			value := column.value(v)
+			if name == columnTime {
+				value = ta.formatTime(v.Time)
+			}
+			text := fitText(value, widths[j])
+			// Pad all but a trailing left-aligned column so that columns line up
+			if pad := widths[j] - len(text); pad > 0 {
+				if column.right {
+					text = strings.Repeat(" ", pad) + text
+				} else if j < len(columns)-1 {
+					text += strings.Repeat(" ", pad)
+				}
+			}
+			text = tview.Escape(text)
+
+			color := column.color
+			if name == columnLatency {
+				color = latencyColor(v.RequestTime)
+			}
+			if j > 0 {
+				b.WriteString(" ")
+			}
+			if highlighted || color == "" {
+				b.WriteString(text)
+			} else {
+				fmt.Fprintf(&b, "[%s]%s[-::-]", color, text)
+			}
+		}
+		if highlighted {
+			b.WriteString("[-:-:-]")
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// hasLatency reports whether any of the entries has a request time.
+func hasLatency(entries []parser.Visitor) bool {
+	for i := range entries {
+		if entries[i].RequestTime > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// without returns a copy of list without the given name.
+func without(list []string, name string) []string {
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		if s != name {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// contains reports whether list contains name.
+func contains(list []string, name string) bool {
+	for _, s := range list {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/rivo/tview"
+)
+
+// TestStreamLayout tests that columns are dropped and sized to the width.
+func TestStreamLayout(t *testing.T) {
+	tests := []struct {
+		name        string
+		width       int
+		wantColumns string
+		wantWidths  []int
+	}{
+		{"unknown width", 0, "time,ip,method,path,status,bytes,latency,referer", []int{8, 15, 7, 0, 3, 9, 7, 0}},
+		{"wide", 120, "time,ip,method,path,status,bytes,latency,referer", []int{8, 15, 7, 39, 3, 9, 7, 25}},
+		{"referer dropped", 79, "time,ip,method,path,status,bytes,latency", []int{8, 15, 7, 24, 3, 9, 7}},
+		{"narrow", 37, "time,method,path,status", []int{8, 7, 16, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, widths := streamLayout(defaultStreamColumns, tt.width, len(defaultTimeFormat))
+			if got := strings.Join(columns, ","); got != tt.wantColumns {
+				t.Errorf("columns = %s, want %s", got, tt.wantColumns)
+			}
+			for i := range widths {
+				if i >= len(tt.wantWidths) || widths[i] != tt.wantWidths[i] {
+					t.Fatalf("widths = %v, want %v", widths, tt.wantWidths)
+				}
+			}
+		})
+	}
+}
+
+// TestStreamLines tests the formatting of live stream lines.
+func TestStreamLines(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	at := time.Date(2025, 10, 8, 12, 0, 0, 0, time.UTC)
+	entries := []parser.Visitor{
+		{Time: at, IP: "10.0.0.1", Method: "GET", Path: "/[a]", Status: 200, Bytes: 2048, Referer: "https://example.com/"},
+	}
+
+	got := app.streamLines(entries, 120)
+	if len(got) != 1 {
+		t.Fatalf("got %d lines, want 1", len(got))
+	}
+	plain := tview.TaggedStringWidth(got[0])
+	if plain > 120 {
+		t.Errorf("line is %d wide, want at most 120", plain)
+	}
+	for _, want := range []string{"10.0.0.1", "/[a[]", "2.0 KB", "https://example.com/"} {
+		if !strings.Contains(got[0], want) {
+			t.Errorf("line %q is missing %q", got[0], want)
+		}
+	}
+	if strings.Contains(got[0], "ms") {
+		t.Errorf("line %q has a latency column without $request_time", got[0])
+	}
+
+	entries[0].RequestTime = 1500 * time.Millisecond
+	if got := app.streamLines(entries, 120); !strings.Contains(got[0], "[red]  1.50s[-::-]") {
+		t.Errorf("line %q should show the slow request time in red", got[0])
+	}
+
+	if err := app.SetStreamColumns([]string{"path", "status"}); err != nil {
+		t.Fatalf("SetStreamColumns() error = %v", err)
+	}
+	if got := app.streamLines(entries, 40); got[0] != "/[a[]"+strings.Repeat(" ", 33)+"[cyan]200[-::-]" {
+		t.Errorf("line = %q, want path and status only", got[0])
+	}
+	if err := app.SetStreamColumns([]string{"path", "host"}); err == nil {
+		t.Error("SetStreamColumns() should reject unknown columns")
+	}
+}
+
+// TestFitText tests truncation of column values.
+func TestFitText(t *testing.T) {
+	if got := fitText("/very/long/path", 10); got != "/very/l..." {
+		t.Errorf("fitText() = %q", got)
+	}
+	if got := fitText("/short", 10); got != "/short" {
+		t.Errorf("fitText() = %q", got)
+	}
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/papaganelli/tailnginx/pkg/sensitive"
+	"github.com/rivo/tview"
+)
+
+// minSuspicion is the score from which IPs are listed as suspicious.
+const minSuspicion = 20
+
+// SetSensitivePaths sets the patterns of the sensitive paths, e.g. ".env"
+// or "*.sql", whose requests raise the suspicion scores. Must be called
+// before Run.
+func (ta *TviewApp) SetSensitivePaths(m *sensitive.Matcher) {
+	ta.sensitive = m
+}
+
+// renderSuspicious renders the IPs of the window with the highest suspicion
+// scores, with the signals that scored.
+func (ta *TviewApp) renderSuspicious() {
+	table := ta.suspiciousTable
+	table.Clear()
+
+	if len(ta.suspicious) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell("[::d]No suspicious IPs in the window[-::-]"))
+		return
+	}
+
+	setHeader(table, "IP", "Score", "Requests", "Signals")
+	table.GetCell(0, 0).SetAlign(tview.AlignLeft)
+	table.GetCell(0, 3).SetAlign(tview.AlignLeft)
+	for i, s := range ta.suspicious[:min(len(ta.suspicious), ta.topLimit(table, 1))] {
+		row := i + 1
+		color := "yellow"
+		if s.Score >= 60 {
+			color = "red"
+		}
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("[white]%s[-::-]", s.IP)).
+				SetAlign(tview.AlignLeft).
+				SetReference(s.IP))
+		table.SetCell(row, 1,
+			tview.NewTableCell(fmt.Sprintf("[%s::b]%d[-::-]", color, s.Score)).
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 2,
+			tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", s.Requests)).
+				SetAlign(tview.AlignRight))
+		table.SetCell(row, 3,
+			tview.NewTableCell(tview.Escape(strings.Join(s.Reasons, ", "))).
+				SetAlign(tview.AlignLeft).
+				SetExpansion(1))
+	}
+}
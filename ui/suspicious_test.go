@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestSuspiciousIPs tests that IPs are listed by suspicion score, leaving
+// out ordinary visitors.
+func TestSuspiciousIPs(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+	now := time.Now()
+
+	app.updateData()
+	app.renderSuspicious()
+	if cell := app.suspiciousTable.GetCell(0, 0); !strings.Contains(cell.Text, "No suspicious IPs") {
+		t.Errorf("empty panel = %q", cell.Text)
+	}
+
+	var batch []parser.Visitor
+	for i := 0; i < 10; i++ {
+		batch = append(batch, parser.Visitor{Time: now, IP: "192.0.2.1", Path: "/", Agent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/131.0", Status: 200})
+	}
+	for i := 0; i < 40; i++ {
+		batch = append(batch, parser.Visitor{Time: now, IP: "203.0.113.7", Path: fmt.Sprintf("/backup%d.zip", i), Agent: "sqlmap/1.7.2#stable", Status: 404})
+	}
+	app.processBatch(batch)
+	app.updateData()
+
+	if len(app.suspicious) != 1 || app.suspicious[0].IP != "203.0.113.7" {
+		t.Fatalf("suspicious = %+v, want the scanner only", app.suspicious)
+	}
+	app.renderSuspicious()
+	if ip, _ := app.suspiciousTable.GetCell(1, 0).GetReference().(string); ip != "203.0.113.7" {
+		t.Errorf("first row IP = %q", ip)
+	}
+	if signals := app.suspiciousTable.GetCell(1, 3).Text; !strings.Contains(signals, "sqlmap") || !strings.Contains(signals, "40 sensitive") {
+		t.Errorf("signals = %q", signals)
+	}
+}
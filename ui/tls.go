@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/rivo/tview"
+)
+
+// maxCipherItems is the number of ciphers listed in the TLS panel.
+const maxCipherItems = 6
+
+// tlsColors maps TLS protocol versions to colors; deprecated versions are red.
+var tlsColors = map[string]string{
+	"SSLv3":   "red",
+	"TLSv1":   "red",
+	"TLSv1.1": "red",
+	"TLSv1.2": "yellow",
+	"TLSv1.3": "green",
+}
+
+// tlsHelp is shown in the TLS panel when the log has no TLS variables.
+const tlsHelp = "[::d]No TLS data. Log $ssl_protocol and $ssl_cipher and pass the format with -log-format.[-::-]"
+
+// renderTLS renders the TLS protocol shares and top ciphers.
+func (ta *TviewApp) renderTLS() {
+	table := ta.tlsTable
+	table.Clear()
+
+	total := 0
+	for _, count := range ta.tlsProtocols {
+		total += count
+	}
+	if total == 0 {
+		table.SetCell(0, 0, tview.NewTableCell(tlsHelp).SetExpansion(1))
+		return
+	}
+
+	row := 0
+	for _, e := range topEntries(ta.tlsProtocols, 0) {
+		color, ok := tlsColors[e.key]
+		if !ok {
+			color = "white"
+		}
+		pct := percent(e.value, total)
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("[%s]%s[-::-]", color, tview.Escape(e.key))).
+				SetAlign(tview.AlignLeft).
+				SetReference(e.key))
+		table.SetCell(row, 1,
+			tview.NewTableCell(fmt.Sprintf("%s [cyan::b]%3.0f%%[-::-]", percentBar(pct, 8, color), pct)).
+				SetAlign(tview.AlignRight))
+		row++
+	}
+
+	row++ // Blank line between sections
+	table.SetCell(row, 0, tview.NewTableCell("[::d]Top ciphers[-::-]"))
+	row++
+	for _, e := range topEntries(ta.tlsCiphers, maxCipherItems) {
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("[white]%s[-::-]", tview.Escape(e.key))).
+				SetAlign(tview.AlignLeft).
+				SetMaxWidth(24).
+				SetReference(e.key))
+		table.SetCell(row, 1,
+			tview.NewTableCell(fmt.Sprintf("[::d]%3.0f%%[-::-]", percent(e.value, total))).
+				SetAlign(tview.AlignRight))
+		row++
+	}
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestTLSAggregation tests TLS protocol and cipher counts from a custom format.
+func TestTLSAggregation(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	format, err := parser.NewFormat(parser.CombinedFormat + " $ssl_protocol $ssl_cipher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetLogFormat(format)
+
+	var batch []parser.Visitor
+	for _, line := range []string{
+		`1.1.1.1 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/2.0" 200 10 "-" "curl/8.0" TLSv1.3 TLS_AES_128_GCM_SHA256`,
+		`1.1.1.2 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 10 "-" "curl/8.0" TLSv1.2 ECDHE-RSA-AES128-GCM-SHA256`,
+		`1.1.1.3 - - [08/Oct/2025:12:00:00 +0000] "GET / HTTP/1.1" 200 10 "-" "curl/8.0" - -`,
+	} {
+		v := app.parse(line)
+		if v == nil {
+			t.Fatalf("parse(%q) = nil", line)
+		}
+		batch = append(batch, *v)
+	}
+	app.processBatch(batch)
+	app.updateData()
+
+	if app.tlsProtocols["TLSv1.3"] != 1 || app.tlsProtocols["TLSv1.2"] != 1 || len(app.tlsProtocols) != 2 {
+		t.Errorf("tlsProtocols = %v, want TLSv1.3=1 TLSv1.2=1", app.tlsProtocols)
+	}
+	if app.tlsCiphers["TLS_AES_128_GCM_SHA256"] != 1 {
+		t.Errorf("tlsCiphers = %v, want TLS_AES_128_GCM_SHA256=1", app.tlsCiphers)
+	}
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rivo/tview"
+)
+
+// SetTopN sets the number of rows shown in top N tables. Zero fits the rows
+// to the height of each panel.
+func (ta *TviewApp) SetTopN(n int) {
+	ta.topN = n
+}
+
+// topLimit returns how many entries a top N table shows: the configured
+// number, or as many as fit in the panel below reserved rows. The focused
+// table lists every entry so that the whole distribution can be scrolled.
+func (ta *TviewApp) topLimit(table *tview.Table, reserved int) int {
+	if table == ta.focused {
+		return math.MaxInt
+	}
+	if ta.topN > 0 {
+		return ta.topN
+	}
+	_, _, _, height := table.GetInnerRect()
+	return max(1, height-reserved)
+}
+
+// setHeader sets the header row of a table, which stays in place while the
+// rows below it scroll. The first column is left-aligned, the others right.
+func setHeader(table *tview.Table, labels ...string) {
+	table.SetFixed(1, 0)
+	for col, label := range labels {
+		align := tview.AlignRight
+		if col == 0 {
+			align = tview.AlignLeft
+		}
+		table.SetCell(0, col,
+			tview.NewTableCell(fmt.Sprintf("[::b]%s[-::-]", label)).
+				SetAlign(align).
+				SetSelectable(false))
+	}
+}
+
+// valueHeader returns the header of the value column in the current display
+// mode, followed by the delta column header in compare mode.
+func (ta *TviewApp) valueHeader() []string {
+	labels := []string{"Count"}
+	if ta.displayMode != displayCounts {
+		labels[0] = "%"
+	}
+	if ta.previous != nil {
+		labels = append(labels, "Δ")
+	}
+	return labels
+}
+
+// selectFirstRow moves the selection of a table below its header when it
+// is on a row that cannot be selected, or past the last row. Returns false,
+// leaving the selection, when no row can be selected.
+func selectFirstRow(table *tview.Table) bool {
+	row, _ := table.GetSelection()
+	row = min(row, table.GetRowCount()-1)
+	for row < table.GetRowCount() && table.GetCell(row, 0).NotSelectable {
+		row++
+	}
+	if row < 0 || row >= table.GetRowCount() {
+		return false
+	}
+	table.Select(row, 0)
+	return true
+}
+
+// focusRows makes the rows of a focused table selectable while it has any,
+// with a row below its header selected. tview loops forever moving the
+// selection of a table whose rows cannot be selected, e.g. when it only has
+// its header.
+func focusRows(table *tview.Table) {
+	table.SetSelectable(selectFirstRow(table), false)
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestTopLimit tests that top N tables fit the panel height unless a fixed
+// size is configured.
+func TestTopLimit(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	app.pathsData = make(map[string]int)
+	for i := 0; i < 50; i++ {
+		app.pathsData[fmt.Sprintf("/page/%d", i)] = i + 1
+	}
+
+	// A 30-row panel has 28 rows inside its border, one for the header
+	app.pathsTable.SetRect(0, 0, 60, 30)
+	app.renderPaths()
+	if got := app.pathsTable.GetRowCount(); got != 28 {
+		t.Errorf("auto-sized table has %d rows, want 28", got)
+	}
+	if got := app.pathsTable.GetCell(0, 0).Text; got != "[::b]Path[-::-]" {
+		t.Errorf("header = %q, want Path", got)
+	}
+	if got := app.topLimit(app.pathsTable, 2); got != 26 {
+		t.Errorf("topLimit() with 2 reserved rows = %d, want 26", got)
+	}
+
+	app.pathsTable.SetRect(0, 0, 60, 2)
+	if got := app.topLimit(app.pathsTable, 0); got != 1 {
+		t.Errorf("topLimit() of a collapsed panel = %d, want 1", got)
+	}
+
+	app.SetTopN(5)
+	app.renderPaths()
+	if got := app.pathsTable.GetRowCount(); got != 6 {
+		t.Errorf("table with -top 5 has %d rows, want a header and 5", got)
+	}
+
+	// The focused table lists every entry to scroll through
+	app.focused = app.pathsTable
+	app.renderPaths()
+	if got := app.pathsTable.GetRowCount(); got != 51 {
+		t.Errorf("focused table has %d rows, want a header and all 50", got)
+	}
+	app.pathsTable.Select(0, 0)
+	selectFirstRow(app.pathsTable)
+	if row, _ := app.pathsTable.GetSelection(); row != 1 {
+		t.Errorf("selection is on row %d, want the first row below the header", row)
+	}
+}
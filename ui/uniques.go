@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sparkBlocks are the bar glyphs used by sparklines, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// updateUniqueVisitors counts unique IPs in the active window. The count is
+// exact while the in-memory entries cover the whole window and estimated from
+// the HyperLogLog sketches otherwise. Caller must hold the lock.
+func (ta *TviewApp) updateUniqueVisitors(now time.Time) {
+	windowStart := time.Time{}
+	if ta.timeWindow > 0 {
+		windowStart = now.Add(-ta.timeWindow)
+	}
+
+	ta.uniqueExact = ta.droppedUntil.IsZero() || (!windowStart.IsZero() && windowStart.After(ta.droppedUntil))
+	if ta.uniqueExact {
+		ta.uniqueVisitors = len(ta.ips)
+		return
+	}
+	// Sketches hold every entry, regardless of the status filter
+	ta.uniqueVisitors = int(ta.uniqueTracker.Count(windowStart))
+}
+
+// renderUniques renders the unique visitors panel with a per-minute trend.
+func (ta *TviewApp) renderUniques() {
+	accuracy := "[green]exact[-::-]"
+	if !ta.uniqueExact {
+		accuracy = "[yellow]estimated[-::-]"
+	}
+
+	// Fit the trend to the panel width, up to the tracked minutes
+	_, _, width, _ := ta.uniquesView.GetInnerRect()
+	n := width - 4
+	if n <= 0 || n > uniqueTrendMinutes {
+		n = uniqueTrendMinutes
+	}
+	counts := ta.uniqueTrend.Buckets(time.Now(), n)
+
+	peak := 0
+	for _, c := range counts {
+		peak = max(peak, int(c))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "  [::b]In window:[-::-] [white::b]%s[-::-] unique IPs (%s)\n", formatCount(ta.uniqueVisitors), accuracy)
+	fmt.Fprintf(&b, "  [::b]Per minute:[-::-] [white]%d[-::-] now  •  [white]%d[-::-] peak (last %d min)\n",
+		counts[len(counts)-1], peak, n)
+	fmt.Fprintf(&b, "  [cyan]%s[-::-]\n", sparkline(counts))
+	fmt.Fprintf(&b, "  [::d]-%dm%s now[-::-]", n, strings.Repeat(" ", max(0, n-len(fmt.Sprint(n))-6)))
+
+	ta.uniquesView.SetText(b.String())
+}
+
+// sparkline renders values as a line of block glyphs scaled to the maximum.
+// Zero values are shown as spaces.
+func sparkline(values []uint64) string {
+	var peak uint64
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		if v == 0 || peak == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		idx := int(v * uint64(len(sparkBlocks)-1) / peak)
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+)
+
+// TestSparkline tests sparkline scaling.
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		in   []uint64
+		want string
+	}{
+		{[]uint64{0, 0}, "  "},
+		{[]uint64{1, 8, 0, 4}, "▁█ ▄"},
+		{[]uint64{5, 5}, "██"},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.in); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestUniqueVisitorsExact tests that unique IPs are counted exactly while all
+// entries are in memory.
+func TestUniqueVisitorsExact(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	app.processBatch([]parser.Visitor{
+		{IP: "1.1.1.1", Path: "/", Status: 200, Time: now},
+		{IP: "1.1.1.1", Path: "/a", Status: 200, Time: now},
+		{IP: "2.2.2.2", Path: "/", Status: 200, Time: now},
+	})
+	app.updateData()
+
+	if !app.uniqueExact || app.uniqueVisitors != 2 {
+		t.Errorf("uniqueVisitors = %d (exact %v), want 2 (exact)", app.uniqueVisitors, app.uniqueExact)
+	}
+}
+
+// TestUniqueVisitorsEstimated tests that the count falls back to the sketches
+// once entries were dropped from memory.
+func TestUniqueVisitorsEstimated(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	now := time.Now()
+	batch := make([]parser.Visitor, 0, maxVisitorsInMemory+2000)
+	for i := 0; i < maxVisitorsInMemory+2000; i++ {
+		batch = append(batch, parser.Visitor{
+			IP:     fmt.Sprintf("10.0.%d.%d", i/256, i%256),
+			Status: 200,
+			Time:   now.Add(time.Duration(i-maxVisitorsInMemory-2000) * time.Millisecond),
+		})
+	}
+	app.processBatch(batch)
+	app.updateData()
+
+	if app.uniqueExact {
+		t.Fatal("Expected an estimated count after entries were dropped")
+	}
+	want := float64(maxVisitorsInMemory + 2000)
+	if got := float64(app.uniqueVisitors); got < want*0.95 || got > want*1.05 {
+		t.Errorf("uniqueVisitors = %.0f, want ~%.0f", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/alert"
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/watchlist"
+	"github.com/rivo/tview"
+)
+
+// watchAlertPrefix prefixes the alert IDs of watchlist notifications.
+const watchAlertPrefix = "watch:"
+
+// watchHelp is shown in the watchlist panel while nothing is watched.
+const watchHelp = "[::d]Nothing watched. Select an IP or path row (tab) and press w, or start with -watch.[-::-]"
+
+// watchHit summarizes the new requests of a watched entry since the last check.
+type watchHit struct {
+	count int
+	last  parser.Visitor
+}
+
+// SetWatchlist sets the watched IPs and paths.
+func (ta *TviewApp) SetWatchlist(list *watchlist.List) {
+	ta.watchlist = list
+}
+
+// recordWatchHits remembers new requests of watched entries for the next
+// notification. Caller must hold the lock.
+func (ta *TviewApp) recordWatchHits(batch []parser.Visitor) {
+	if ta.watchlist.Len() == 0 {
+		return
+	}
+	for i := range batch {
+		for _, e := range ta.watchlist.Match(&batch[i]) {
+			hit := ta.watchPending[e]
+			hit.count++
+			hit.last = batch[i]
+			ta.watchPending[e] = hit
+		}
+	}
+}
+
+// checkWatchlist raises a notification in the alert banner for every watched
+// entry seen in new traffic. A new hit shows an acknowledged notification
+// again. Returns true if the banner may have changed.
+func (ta *TviewApp) checkWatchlist() bool {
+	ta.mu.Lock()
+	pending := ta.watchPending
+	ta.watchPending = make(map[watchlist.Entry]watchHit)
+	ta.mu.Unlock()
+
+	for e, hit := range pending {
+		if !ta.watchlist.Contains(e) {
+			continue
+		}
+		id := watchAlertPrefix + e.String()
+		ta.alerts.Clear(id)
+		ta.alerts.Raise(id, alert.SeverityInfo, fmt.Sprintf("watched %s %s: %s %s %d (%d new)",
+			e.Kind, e.Value, hit.last.Method, hit.last.Path, hit.last.Status, hit.count))
+	}
+	return len(pending) > 0
+}
+
+// toggleWatch watches or unwatches the IP or path of the selected row.
+// Must be called from the UI goroutine.
+func (ta *TviewApp) toggleWatch() {
+	value := ta.selectedValue()
+	if value == "" || (ta.page != pageDashboard && ta.page != pageCountry) {
+		ta.flash("[yellow]Nothing selected[-::-] — use tab to select an IP or path row")
+		return
+	}
+	e, err := watchlist.ParseEntry(value)
+	if err != nil {
+		ta.flash(fmt.Sprintf("[yellow]Cannot watch[-::-] %s [::d](only IPs and paths)[-::-]", tview.Escape(value)))
+		return
+	}
+
+	if ta.watchlist.Toggle(e) {
+		ta.flash(fmt.Sprintf("[green]Watching[-::-] %s %s", e.Kind, tview.Escape(e.Value)))
+	} else {
+		if ta.alerts.Clear(watchAlertPrefix + e.String()) {
+			ta.renderBanner()
+		}
+		ta.flash(fmt.Sprintf("[yellow]Stopped watching[-::-] %s %s", e.Kind, tview.Escape(e.Value)))
+	}
+
+	ta.mu.Lock()
+	ta.dataChanged = true
+	ta.mu.Unlock()
+}
+
+// updateWatchlist counts the requests of watched entries in the window.
+// Caller must hold the lock.
+func (ta *TviewApp) updateWatchlist() {
+	ta.watchCounts = make(map[watchlist.Entry]int)
+	ta.watchSeen = make(map[watchlist.Entry]time.Time)
+	if ta.watchlist.Len() == 0 {
+		return
+	}
+	for i := range ta.visitors {
+		for _, e := range ta.watchlist.Match(&ta.visitors[i]) {
+			ta.watchCounts[e]++
+			if t := ta.visitors[i].Time; t.After(ta.watchSeen[e]) {
+				ta.watchSeen[e] = t
+			}
+		}
+	}
+}
+
+// renderWatchlist renders the watched entries with their requests in the
+// window and when they were last seen.
+func (ta *TviewApp) renderWatchlist() {
+	ta.watchTable.Clear()
+
+	entries := ta.watchlist.Entries()
+	if len(entries) == 0 {
+		ta.watchTable.SetCell(0, 0, tview.NewTableCell(watchHelp).SetExpansion(1))
+		return
+	}
+
+	for row, e := range entries {
+		key := e.Value
+		if len(key) > 40 {
+			key = key[:37] + "..."
+		}
+		ta.watchTable.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("[::d]%s[-::-] [white]%s[-::-]", e.Kind, tview.Escape(key))).
+				SetAlign(tview.AlignLeft).
+				SetMaxWidth(44).
+				SetReference(e.Value))
+		ta.watchTable.SetCell(row, 1,
+			tview.NewTableCell(fmt.Sprintf("[cyan]%d[-::-]", ta.watchCounts[e])).
+				SetAlign(tview.AlignRight))
+
+		seen := "[::d]not seen[-::-]"
+		if t, ok := ta.watchSeen[e]; ok {
+			seen = fmt.Sprintf("[::d]%s[-::-]", t.Format("15:04:05"))
+		}
+		ta.watchTable.SetCell(row, 2, tview.NewTableCell(seen).SetAlign(tview.AlignRight))
+	}
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/papaganelli/tailnginx/pkg/parser"
+	"github.com/papaganelli/tailnginx/pkg/watchlist"
+)
+
+// TestWatchlistNotifications tests that watched entries in new traffic raise
+// a notification in the alert banner.
+func TestWatchlistNotifications(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	list, err := watchlist.New([]string{"10.0.0.1", "/admin"})
+	if err != nil {
+		t.Fatalf("watchlist.New() error = %v", err)
+	}
+	app.SetWatchlist(list)
+
+	now := time.Now()
+	app.processBatch([]parser.Visitor{
+		{Time: now, IP: "10.0.0.1", Method: "GET", Path: "/", Status: 200},
+		{Time: now, IP: "10.0.0.1", Method: "POST", Path: "/login", Status: 401},
+		{Time: now, IP: "10.0.0.2", Method: "GET", Path: "/", Status: 200},
+	})
+
+	if !app.checkWatchlist() {
+		t.Fatal("checkWatchlist() should report new hits")
+	}
+	alerts := app.alerts.Unacked()
+	if len(alerts) != 1 {
+		t.Fatalf("got %d notifications, want 1: %v", len(alerts), alerts)
+	}
+	if !strings.Contains(alerts[0].Message, "POST /login 401 (2 new)") {
+		t.Errorf("notification = %q, want the last request and the hit count", alerts[0].Message)
+	}
+
+	// Acknowledged notifications come back on new hits only
+	app.alerts.AckAll()
+	if app.checkWatchlist() || len(app.alerts.Unacked()) != 0 {
+		t.Error("no new hits should not raise notifications")
+	}
+	app.processBatch([]parser.Visitor{{Time: now, IP: "10.0.0.1", Method: "GET", Path: "/", Status: 200}})
+	app.checkWatchlist()
+	if len(app.alerts.Unacked()) != 1 {
+		t.Error("a new hit should show the notification again")
+	}
+}
+
+// TestWatchlistPanel tests the watchlist counts in the active window.
+func TestWatchlistPanel(t *testing.T) {
+	lines := make(chan string)
+	app := NewTviewApp(lines, "/test.log", time.Second, nil)
+
+	app.renderWatchlist()
+	if !strings.Contains(app.watchTable.GetCell(0, 0).Text, "Nothing watched") {
+		t.Error("empty watchlist should show how to add entries")
+	}
+
+	list, _ := watchlist.New([]string{"/admin", "10.0.0.9"})
+	app.SetWatchlist(list)
+
+	seen := time.Date(2025, 6, 2, 14, 3, 22, 0, time.UTC)
+	app.visitors = []parser.Visitor{
+		{Time: seen.Add(-time.Minute), Path: "/admin", IP: "10.0.0.1"},
+		{Time: seen, Path: "/admin", IP: "10.0.0.2"},
+		{Time: seen, Path: "/", IP: "10.0.0.2"},
+	}
+	app.updateData()
+	app.renderWatchlist()
+
+	if got := app.watchTable.GetCell(0, 1).Text; !strings.Contains(got, "2") {
+		t.Errorf("/admin count = %q, want 2", got)
+	}
+	if got := app.watchTable.GetCell(0, 2).Text; !strings.Contains(got, "14:03:22") {
+		t.Errorf("/admin last seen = %q, want 14:03:22", got)
+	}
+	if got := app.watchTable.GetCell(1, 2).Text; !strings.Contains(got, "not seen") {
+		t.Errorf("unseen IP = %q, want not seen", got)
+	}
+}